@@ -0,0 +1,78 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"fmt"
+
+	"github.com/rajchain/go-rajchain/accounts/abi"
+)
+
+// BindV2 generates a v2 Go wrapper around a contract ABI. Contrary to Bind, the
+// wrapper is stateless, only packing and unpacking the inputs and outputs of
+// methods, events and errors. The interaction with the chain is done through
+// the generic Call, Transact, FilterEvents, WatchEvents and Batch helpers of
+// this package.
+//
+// Library linking is not done by v2 bindings: the link placeholders of the
+// bytecode are to be replaced with the library addresses before deployment.
+func BindV2(types []string, abis []string, bytecodes []string, pkg string, lang Lang, libs map[string]string, aliases map[string]string) (string, error) {
+	data, err := bindData(types, abis, bytecodes, nil, pkg, lang, libs, aliases, true)
+	if err != nil {
+		return "", err
+	}
+	for _, contract := range data.Contracts {
+		// Constructor inputs are packed through a method too, name them
+		inputs := make([]abi.Argument, len(contract.Constructor.Inputs))
+		copy(inputs, contract.Constructor.Inputs)
+		for j, input := range inputs {
+			if input.Name == "" || isKeyWord(input.Name) {
+				inputs[j].Name = fmt.Sprintf("arg%d", j)
+			}
+		}
+		contract.Constructor.Inputs = inputs
+
+		// Calls and transacts are bound the same way, ensure they don't collide.
+		// Errors and events are both bound to types named after the contract.
+		identifiers := make(map[string]string)
+		for _, methods := range []map[string]*tmplMethod{contract.Calls, contract.Transacts} {
+			for _, method := range methods {
+				if orig, ok := identifiers[method.Normalized.Name]; ok {
+					return "", fmt.Errorf("duplicated identifier \"%s\"(normalized \"%s\") of \"%s\", use --alias for renaming", method.Original.Name, method.Normalized.Name, orig)
+				}
+				identifiers[method.Normalized.Name] = method.Original.Name
+
+				// Multiple returns are always bound to a struct, name the anonymous ones
+				if len(method.Normalized.Outputs) > 1 && !method.Structured {
+					for j := range method.Normalized.Outputs {
+						method.Normalized.Outputs[j].Name = fmt.Sprintf("Arg%d", j)
+					}
+				}
+			}
+		}
+		types := make(map[string]string)
+		for _, event := range contract.Events {
+			types[event.Normalized.Name] = event.Original.Name
+		}
+		for _, e := range contract.Errors {
+			if orig, ok := types[e.Normalized.Name]; ok {
+				return "", fmt.Errorf("duplicated identifier \"%s\"(normalized \"%s\") of event \"%s\", use --alias for renaming", e.Original.Name, e.Normalized.Name, orig)
+			}
+		}
+	}
+	return render(data, lang, tmplSourceV2[lang])
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/rajchain/go-rajchain/common"
+)
+
+// Tests that the v2 bindings of all the binding test contracts compile.
+func TestGolangBindingsV2(t *testing.T) {
+	t.Parallel()
+	// Skip the test if no Go command can be found
+	gocmd := runtime.GOROOT() + "/bin/go"
+	if !common.FileExist(gocmd) {
+		t.Skip("go sdk not found for testing")
+	}
+	// Create a temporary workspace for the test suite
+	ws := t.TempDir()
+
+	pkg := filepath.Join(ws, "bindtest")
+	if err := os.MkdirAll(pkg, 0700); err != nil {
+		t.Fatalf("failed to create package: %v", err)
+	}
+	// Generate the bindings for all the contracts
+	for i, tt := range bindTests {
+		types := tt.types
+		if types == nil {
+			types = []string{tt.name}
+		}
+		bind, err := BindV2(types, tt.abi, tt.bytecode, "bindtest", LangGo, tt.libs, tt.aliases)
+		if err != nil {
+			t.Fatalf("test %d: failed to generate binding: %v", i, err)
+		}
+		if err = os.WriteFile(filepath.Join(pkg, strings.ToLower(tt.name)+".go"), []byte(bind), 0600); err != nil {
+			t.Fatalf("test %d: failed to write binding: %v", i, err)
+		}
+	}
+	// Convert the package to go modules and use the current source for go-rajchain
+	moder := exec.Command(gocmd, "mod", "init", "bindtest")
+	moder.Dir = pkg
+	if out, err := moder.CombinedOutput(); err != nil {
+		t.Fatalf("failed to convert binding test to modules: %v\n%s", err, out)
+	}
+	pwd, _ := os.Getwd()
+	replacer := exec.Command(gocmd, "mod", "edit", "-x", "-require", "github.com/rajchain/go-rajchain@v0.0.0", "-replace", "github.com/rajchain/go-rajchain="+filepath.Join(pwd, "..", "..", "..")) // Repo root
+	replacer.Dir = pkg
+	if out, err := replacer.CombinedOutput(); err != nil {
+		t.Fatalf("failed to replace binding test dependency to current source tree: %v\n%s", err, out)
+	}
+	tidier := exec.Command(gocmd, "mod", "tidy")
+	tidier.Dir = pkg
+	if out, err := tidier.CombinedOutput(); err != nil {
+		t.Fatalf("failed to tidy Go module file: %v\n%s", err, out)
+	}
+	// Vet the entire package and report any failures
+	cmd := exec.Command(gocmd, "vet")
+	cmd.Dir = pkg
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build binding: %v\n%s", err, out)
+	}
+}
+
+// Tests that identifiers colliding only in v2 bindings are rejected.
+func TestBindV2Conflicts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		abi  string
+		fail bool
+	}{
+		// A constant and a mutating method of the same name
+		{`[{"name":"get","type":"function","stateMutability":"view","inputs":[],"outputs":[]},{"name":"Get","type":"function","inputs":[],"outputs":[]}]`, true},
+		// An event and an error of the same name
+		{`[{"name":"Failed","type":"event","inputs":[]},{"name":"Failed","type":"error","inputs":[]}]`, true},
+		// An event and an error of different names
+		{`[{"name":"Failed","type":"event","inputs":[]},{"name":"Failure","type":"error","inputs":[]}]`, false},
+	}
+	for i, tt := range tests {
+		_, err := BindV2([]string{"Test"}, []string{tt.abi}, []string{""}, "bindtest", LangGo, nil, nil)
+		if tt.fail && err == nil {
+			t.Errorf("test %d: no error", i)
+		}
+		if !tt.fail && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+// Tests that custom errors are only bound by the v2 bindings, leaving the output
+// of the v1 bindings untouched.
+func TestBindErrorsV2Only(t *testing.T) {
+	abis := []string{`[
+		{"type":"function","name":"get","inputs":[],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"},
+		{"type":"error","name":"Failed","inputs":[{"name":"info","type":"tuple","internalType":"struct Oops","components":[{"name":"code","type":"uint256","internalType":"uint256"}]}]}
+	]`}
+	v1, err := Bind([]string{"Test"}, abis, []string{""}, nil, "bindtest", LangGo, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to generate v1 binding: %v", err)
+	}
+	if strings.Contains(v1, "type Oops struct") {
+		t.Fatalf("custom error bound by v1 binding:\n%s", v1)
+	}
+	v2, err := BindV2([]string{"Test"}, abis, []string{""}, "bindtest", LangGo, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to generate v2 binding: %v", err)
+	}
+	if !strings.Contains(v2, "type Oops struct") {
+		t.Fatalf("custom error not bound by v2 binding:\n%s", v2)
+	}
+}
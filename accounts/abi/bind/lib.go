@@ -0,0 +1,225 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/rajchain/go-rajchain"
+	"github.com/rajchain/go-rajchain/accounts/abi"
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/event"
+	"github.com/rajchain/go-rajchain/rpc"
+)
+
+// This file contains the runtime library of the v2 bindings generated by BindV2.
+// Contrary to the v1 bindings, the v2 ones are stateless: they only pack inputs
+// and unpack outputs, events and errors, leaving the interaction with the chain
+// to the generic functions below, operating on a BoundContract instance.
+
+// ContractEvent is implemented by the event types of the v2 bindings.
+type ContractEvent interface {
+	// ContractEventName returns the name of the event in the contract ABI.
+	ContractEventName() string
+}
+
+// Call executes an eth_call against the contract with the packed calldata, and
+// unpacks the output with the unpack method of the binding.
+func Call[T any](c *BoundContract, opts *CallOpts, calldata []byte, unpack func([]byte) (T, error)) (T, error) {
+	var res T
+	output, err := c.CallRaw(opts, calldata)
+	if err != nil {
+		return res, err
+	}
+	return unpack(output)
+}
+
+// Transact sends a transaction to the contract with the packed calldata.
+func Transact(c *BoundContract, opts *TransactOpts, calldata []byte) (*types.Transaction, error) {
+	return c.RawTransact(opts, calldata)
+}
+
+// DeployContractRaw deploys a contract onto the rajchain blockchain with the
+// given bytecode and packed constructor input, returning the address of the
+// contract to be created.
+func DeployContractRaw(opts *TransactOpts, bytecode []byte, constructorInput []byte, backend ContractBackend) (common.Address, *types.Transaction, error) {
+	c := NewBoundContract(common.Address{}, abi.ABI{}, backend, backend, backend)
+
+	tx, err := c.transact(opts, nil, slices.Concat(bytecode, constructorInput))
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	return crypto.CreateAddress(opts.From, tx.Nonce()), tx, nil
+}
+
+// EventIterator is returned from FilterEvents and is used to iterate over the
+// unpacked events matched by the filter.
+type EventIterator[T any] struct {
+	current *T
+	unpack  func(*types.Log) (*T, error)
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  rajchain.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Value returns the event the iterator is positioned at.
+func (it *EventIterator[T]) Value() *T {
+	return it.current
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *EventIterator[T]) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			return it.unpackLog(log)
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		return it.unpackLog(log)
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// unpackLog unpacks a retrieved log into the current event of the iterator.
+func (it *EventIterator[T]) unpackLog(log types.Log) bool {
+	event, err := it.unpack(&log)
+	if err != nil {
+		it.fail = err
+		return false
+	}
+	it.current = event
+	return true
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *EventIterator[T]) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *EventIterator[T]) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// FilterEvents filters the past events of type T emitted by the contract,
+// matching the given indexed topics. The returned iterator unpacks the logs
+// with the unpack method of the binding.
+func FilterEvents[T ContractEvent](c *BoundContract, opts *FilterOpts, unpack func(*types.Log) (*T, error), topics ...[]interface{}) (*EventIterator[T], error) {
+	var proto T
+	logs, sub, err := c.FilterLogs(opts, proto.ContractEventName(), topics...)
+	if err != nil {
+		return nil, err
+	}
+	return &EventIterator[T]{unpack: unpack, logs: logs, sub: sub}, nil
+}
+
+// WatchEvents subscribes to the future events of type T emitted by the contract,
+// matching the given indexed topics, and delivers them unpacked to the sink.
+func WatchEvents[T ContractEvent](c *BoundContract, opts *WatchOpts, unpack func(*types.Log) (*T, error), sink chan<- *T, topics ...[]interface{}) (event.Subscription, error) {
+	var proto T
+	logs, sub, err := c.WatchLogs(opts, proto.ContractEventName(), topics...)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				ev, err := unpack(&log)
+				if err != nil {
+					return err
+				}
+				select {
+				case sink <- ev:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// RevertError is the error of a reverted call within a batch, carrying the
+// revert data for decoding with the UnpackError method of the bindings.
+type RevertError struct {
+	Data []byte
+}
+
+// Error implements error, decoding the revert reason if it's a plain string.
+func (e *RevertError) Error() string {
+	if reason, err := abi.UnpackRevert(e.Data); err == nil {
+		return "execution reverted: " + reason
+	}
+	return "execution reverted"
+}
+
+// ErrorData implements rpc.DataError, returning the hex encoded revert data the
+// same way nodes do.
+func (e *RevertError) ErrorData() interface{} {
+	return hexutil.Encode(e.Data)
+}
+
+// RevertData extracts the revert data from the error of a call, transaction or
+// gas estimation, if any. It is to be decoded with the UnpackError method of
+// the bindings.
+func RevertData(err error) ([]byte, bool) {
+	var derr rpc.DataError
+	if !errors.As(err, &derr) {
+		return nil, false
+	}
+	hex, ok := derr.ErrorData().(string)
+	if !ok {
+		return nil, false
+	}
+	data, err := hexutil.Decode(hex)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
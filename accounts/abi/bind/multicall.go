@@ -0,0 +1,143 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rajchain/go-rajchain/accounts/abi"
+	"github.com/rajchain/go-rajchain/common"
+)
+
+// Multicall3Address is the address of the Multicall3 contract, deployed through
+// a keyless transaction at the same address on most networks.
+var Multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+// multicallABI is the subset of the Multicall3 ABI needed to batch calls.
+const multicallABI = `[{"inputs":[{"components":[{"internalType":"address","name":"target","type":"address"},{"internalType":"bool","name":"allowFailure","type":"bool"},{"internalType":"bytes","name":"callData","type":"bytes"}],"internalType":"struct Multicall3.Call3[]","name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"internalType":"bool","name":"success","type":"bool"},{"internalType":"bytes","name":"returnData","type":"bytes"}],"internalType":"struct Multicall3.Result[]","name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}]`
+
+// multicallMetaData holds the parsed multicall ABI.
+var multicallMetaData = &MetaData{ABI: multicallABI}
+
+// multicallCall is the Multicall3.Call3 input struct.
+type multicallCall struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// multicallResult is the Multicall3.Result output struct.
+type multicallResult struct {
+	Success    bool
+	ReturnData []byte
+}
+
+var (
+	// ErrBatchPending is returned when the result of a batched call is accessed
+	// before the batch is executed.
+	ErrBatchPending = errors.New("batch not executed")
+
+	// errBatchExecuted is returned when a batch is executed twice.
+	errBatchExecuted = errors.New("batch already executed")
+)
+
+// Batch accumulates calls to any number of contracts, to be executed in a single
+// eth_call through a Multicall3 contract. Calls are executed independently, the
+// failure of one not affecting the others.
+type Batch struct {
+	calls    []multicallCall
+	deliver  []func(output []byte, err error)
+	executed bool
+}
+
+// BatchResult is the result of a call added to a batch, available once the
+// batch is executed.
+type BatchResult[T any] struct {
+	value T
+	err   error
+}
+
+// Result returns the unpacked output of the call, or the error it failed with.
+// Calls reverted by the target contract fail with a RevertError.
+func (r *BatchResult[T]) Result() (T, error) {
+	return r.value, r.err
+}
+
+// AddCall adds a call to the contract with the packed calldata to the batch.
+// Once the batch is executed, the output is unpacked with the unpack method of
+// the binding into the returned result.
+func AddCall[T any](b *Batch, c *BoundContract, calldata []byte, unpack func([]byte) (T, error)) *BatchResult[T] {
+	res := &BatchResult[T]{err: ErrBatchPending}
+
+	b.calls = append(b.calls, multicallCall{Target: c.address, AllowFailure: true, CallData: calldata})
+	b.deliver = append(b.deliver, func(output []byte, err error) {
+		if err != nil {
+			res.err = err
+			return
+		}
+		res.value, res.err = unpack(output)
+	})
+	return res
+}
+
+// Len returns the number of calls in the batch.
+func (b *Batch) Len() int {
+	return len(b.calls)
+}
+
+// Execute executes all calls of the batch in a single eth_call to the multicall
+// contract at the given address, filling the results of the individual calls.
+// An error is returned only if the batch as a whole failed.
+func (b *Batch) Execute(caller ContractCaller, opts *CallOpts, multicall common.Address) error {
+	if b.executed {
+		return errBatchExecuted
+	}
+	if len(b.calls) == 0 {
+		b.executed = true
+		return nil
+	}
+	parsed, err := multicallMetaData.GetAbi()
+	if err != nil {
+		return err
+	}
+	input, err := parsed.Pack("aggregate3", b.calls)
+	if err != nil {
+		return err
+	}
+	output, err := NewBoundContract(multicall, *parsed, caller, nil, nil).CallRaw(opts, input)
+	if err != nil {
+		return err
+	}
+	unpacked, err := parsed.Unpack("aggregate3", output)
+	if err != nil {
+		return err
+	}
+	results := *abi.ConvertType(unpacked[0], new([]multicallResult)).(*[]multicallResult)
+	if len(results) != len(b.calls) {
+		return fmt.Errorf("multicall result count mismatch: have %d, want %d", len(results), len(b.calls))
+	}
+	b.executed = true
+	for i, res := range results {
+		if !res.Success {
+			b.deliver[i](nil, &RevertError{Data: res.ReturnData})
+			continue
+		}
+		b.deliver[i](res.ReturnData, nil)
+	}
+	return nil
+}
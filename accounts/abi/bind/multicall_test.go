@@ -0,0 +1,128 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/rajchain/go-rajchain"
+	"github.com/rajchain/go-rajchain/accounts/abi"
+	"github.com/rajchain/go-rajchain/common"
+)
+
+const tokenABI = `[{"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"inputs":[{"name":"caller","type":"address"}],"name":"Unauthorized","type":"error"}]`
+
+// multicallBackend is a contract caller emulating a Multicall3 contract batching
+// calls to a token contract, whose balance query reverts for unknown owners.
+type multicallBackend struct {
+	token    abi.ABI
+	balances map[common.Address]*big.Int
+	calls    int
+}
+
+func (b *multicallBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return []byte{0x1}, nil
+}
+
+func (b *multicallBackend) CallContract(ctx context.Context, call rajchain.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	b.calls++
+
+	multicall, _ := multicallMetaData.GetAbi()
+	method := multicall.Methods["aggregate3"]
+	if *call.To != Multicall3Address || !bytes.Equal(call.Data[:4], method.ID) {
+		return nil, errors.New("not a multicall")
+	}
+	args, err := method.Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	var results []multicallResult
+	for _, c := range *abi.ConvertType(args[0], new([]multicallCall)).(*[]multicallCall) {
+		args, err := b.token.Methods["balanceOf"].Inputs.Unpack(c.CallData[4:])
+		if err != nil {
+			return nil, err
+		}
+		owner := args[0].(common.Address)
+		if balance, ok := b.balances[owner]; ok {
+			output, _ := b.token.Methods["balanceOf"].Outputs.Pack(balance)
+			results = append(results, multicallResult{Success: true, ReturnData: output})
+		} else {
+			revert, _ := b.token.Errors["Unauthorized"].Inputs.Pack(owner)
+			results = append(results, multicallResult{ReturnData: append(b.token.Errors["Unauthorized"].ID.Bytes()[:4], revert...)})
+		}
+	}
+	return method.Outputs.Pack(results)
+}
+
+func TestBatch(t *testing.T) {
+	token, err := abi.JSON(strings.NewReader(tokenABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		alice   = common.Address{0xa}
+		bob     = common.Address{0xb}
+		backend = &multicallBackend{token: token, balances: map[common.Address]*big.Int{alice: big.NewInt(100), bob: big.NewInt(200)}}
+
+		contract = NewBoundContract(common.Address{0xc}, token, backend, nil, nil)
+		unpack   = func(output []byte) (*big.Int, error) {
+			res, err := token.Unpack("balanceOf", output)
+			if err != nil {
+				return nil, err
+			}
+			return res[0].(*big.Int), nil
+		}
+		batch   = new(Batch)
+		results []*BatchResult[*big.Int]
+	)
+	for _, owner := range []common.Address{alice, bob, {0xd}} {
+		input, _ := token.Pack("balanceOf", owner)
+		results = append(results, AddCall(batch, contract, input, unpack))
+	}
+	if _, err := results[0].Result(); err != ErrBatchPending {
+		t.Fatalf("Result before execution: have %v, want %v", err, ErrBatchPending)
+	}
+	if err := batch.Execute(backend, nil, Multicall3Address); err != nil {
+		t.Fatalf("Failed to execute batch: %v", err)
+	}
+	if backend.calls != 1 {
+		t.Fatalf("Batch executed in %d calls, want 1", backend.calls)
+	}
+	for i, want := range []int64{100, 200} {
+		balance, err := results[i].Result()
+		if err != nil || balance.Int64() != want {
+			t.Errorf("call %d: have %v, %v, want %d", i, balance, err, want)
+		}
+	}
+	// The reverted call fails alone, with the revert data available for decoding
+	_, err = results[2].Result()
+	data, ok := RevertData(err)
+	if !ok {
+		t.Fatalf("No revert data in error %v", err)
+	}
+	if !bytes.Equal(data[:4], token.Errors["Unauthorized"].ID.Bytes()[:4]) {
+		t.Fatalf("Wrong revert data: %x", data)
+	}
+	if err := batch.Execute(backend, nil, Multicall3Address); err != errBatchExecuted {
+		t.Fatalf("Executing batch twice: have %v, want %v", err, errBatchExecuted)
+	}
+}
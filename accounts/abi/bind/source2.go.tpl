@@ -0,0 +1,257 @@
+// Code generated via abigen V2 - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package {{.Package}}
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"github.com/rajchain/go-rajchain/accounts/abi"
+	"github.com/rajchain/go-rajchain/accounts/abi/bind"
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = bytes.Equal
+	_ = errors.New
+	_ = big.NewInt
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+	_ = abi.ConvertType
+)
+
+{{$structs := .Structs}}
+{{range $structs}}
+	// {{.Name}} is an auto generated low-level Go binding around an user-defined struct.
+	type {{.Name}} struct {
+	{{range $field := .Fields}}
+	{{$field.Name}} {{$field.Type}}{{end}}
+	}
+{{end}}
+
+{{range $contract := .Contracts}}
+	// {{.Type}}MetaData contains all meta data concerning the {{.Type}} contract.
+	var {{.Type}}MetaData = &bind.MetaData{
+		ABI: "{{.InputABI}}",
+		{{if .InputBin -}}
+		Bin: "0x{{.InputBin}}",
+		{{end}}
+	}
+
+	// {{.Type}} is an auto generated Go binding around a rajchain contract. It is
+	// stateless, packing and unpacking the inputs and outputs of the contract methods,
+	// events and errors, to be used with the generic helpers of the bind package.
+	type {{.Type}} struct {
+		abi abi.ABI
+	}
+
+	// New{{.Type}} creates a new instance of {{.Type}}.
+	func New{{.Type}}() *{{.Type}} {
+		parsed, err := {{.Type}}MetaData.GetAbi()
+		if err != nil {
+			panic(errors.New("invalid ABI: " + err.Error()))
+		}
+		return &{{.Type}}{abi: *parsed}
+	}
+
+	// Instance creates a wrapper for a deployed contract instance at the given address,
+	// to be passed to bind.Call, bind.Transact, bind.FilterEvents and the others.
+	func (_{{$contract.Type}} *{{$contract.Type}}) Instance(backend bind.ContractBackend, addr common.Address) *bind.BoundContract {
+		return bind.NewBoundContract(addr, _{{$contract.Type}}.abi, backend, backend, backend)
+	}
+
+	{{if .InputBin}}
+		// PackConstructor is the Go binding used to pack the parameters required for
+		// contract deployment, to be appended to the bytecode.
+		//
+		// Solidity: {{.Constructor.String}}
+		func (_{{$contract.Type}} *{{$contract.Type}}) PackConstructor({{range $i, $_ := .Constructor.Inputs}}{{if ne $i 0}}, {{end}}{{.Name}} {{bindtype .Type $structs}}{{end}}) []byte {
+			enc, err := _{{$contract.Type}}.abi.Pack(""{{range .Constructor.Inputs}}, {{.Name}}{{end}})
+			if err != nil {
+				panic(err)
+			}
+			return enc
+		}
+	{{end}}
+
+	{{range methods $contract}}
+		// Pack{{.Normalized.Name}} is the Go binding used to pack the parameters required for calling
+		// the contract method with ID 0x{{printf "%x" .Original.ID}}.
+		//
+		// Solidity: {{.Original.String}}
+		func (_{{$contract.Type}} *{{$contract.Type}}) Pack{{.Normalized.Name}}({{range $i, $_ := .Normalized.Inputs}}{{if ne $i 0}}, {{end}}{{.Name}} {{bindtype .Type $structs}}{{end}}) []byte {
+			enc, err := _{{$contract.Type}}.abi.Pack("{{.Original.Name}}"{{range .Normalized.Inputs}}, {{.Name}}{{end}})
+			if err != nil {
+				panic(err)
+			}
+			return enc
+		}
+
+		{{if gt (len .Normalized.Outputs) 1}}
+			// {{$contract.Type}}{{.Normalized.Name}}Output serves as a container for the return parameters
+			// of contract method {{.Normalized.Name}}.
+			type {{$contract.Type}}{{.Normalized.Name}}Output struct {
+			{{range .Normalized.Outputs}}
+				{{.Name}} {{bindtype .Type $structs}}{{end}}
+			}
+
+			// Unpack{{.Normalized.Name}} is the Go binding that unpacks the parameters returned
+			// from invoking the contract method with ID 0x{{printf "%x" .Original.ID}}.
+			//
+			// Solidity: {{.Original.String}}
+			func (_{{$contract.Type}} *{{$contract.Type}}) Unpack{{.Normalized.Name}}(data []byte) ({{$contract.Type}}{{.Normalized.Name}}Output, error) {
+				out, err := _{{$contract.Type}}.abi.Unpack("{{.Original.Name}}", data)
+				outstruct := new({{$contract.Type}}{{.Normalized.Name}}Output)
+				if err != nil {
+					return *outstruct, err
+				}
+				{{range $i, $t := .Normalized.Outputs}}
+				outstruct.{{.Name}} = *abi.ConvertType(out[{{$i}}], new({{bindtype .Type $structs}})).(*{{bindtype .Type $structs}}){{end}}
+
+				return *outstruct, nil
+			}
+		{{else if .Normalized.Outputs}}
+			// Unpack{{.Normalized.Name}} is the Go binding that unpacks the parameters returned
+			// from invoking the contract method with ID 0x{{printf "%x" .Original.ID}}.
+			//
+			// Solidity: {{.Original.String}}
+			func (_{{$contract.Type}} *{{$contract.Type}}) Unpack{{.Normalized.Name}}(data []byte) ({{range .Normalized.Outputs}}{{bindtype .Type $structs}}{{end}}, error) {
+				out, err := _{{$contract.Type}}.abi.Unpack("{{.Original.Name}}", data)
+				if err != nil {
+					return {{range .Normalized.Outputs}}*new({{bindtype .Type $structs}}){{end}}, err
+				}
+				{{range .Normalized.Outputs}}
+				out0 := *abi.ConvertType(out[0], new({{bindtype .Type $structs}})).(*{{bindtype .Type $structs}}){{end}}
+
+				return out0, nil
+			}
+		{{end}}
+	{{end}}
+
+	{{range .Events}}
+		// {{$contract.Type}}{{.Normalized.Name}} represents a {{.Original.Name}} event raised by the {{$contract.Type}} contract.
+		type {{$contract.Type}}{{.Normalized.Name}} struct { {{range .Normalized.Inputs}}
+			{{capitalise .Name}} {{if .Indexed}}{{bindtopictype .Type $structs}}{{else}}{{bindtype .Type $structs}}{{end}}; {{end}}
+			Raw *types.Log // Blockchain specific contextual infos
+		}
+
+		// {{$contract.Type}}{{.Normalized.Name}}EventName is the name of the {{.Original.Name}} event in the contract ABI.
+		const {{$contract.Type}}{{.Normalized.Name}}EventName = "{{.Original.Name}}"
+
+		// ContractEventName returns the name of the {{.Original.Name}} event in the contract ABI.
+		func ({{$contract.Type}}{{.Normalized.Name}}) ContractEventName() string {
+			return {{$contract.Type}}{{.Normalized.Name}}EventName
+		}
+
+		// Unpack{{.Normalized.Name}}Event is the Go binding that unpacks the event data emitted
+		// by the contract event 0x{{printf "%x" .Original.ID}}.
+		//
+		// Solidity: {{.Original.String}}
+		func (_{{$contract.Type}} *{{$contract.Type}}) Unpack{{.Normalized.Name}}Event(log *types.Log) (*{{$contract.Type}}{{.Normalized.Name}}, error) {
+			event := "{{.Original.Name}}"
+			if len(log.Topics) == 0 || log.Topics[0] != _{{$contract.Type}}.abi.Events[event].ID {
+				return nil, errors.New("event signature mismatch")
+			}
+			out := new({{$contract.Type}}{{.Normalized.Name}})
+			if len(log.Data) > 0 {
+				if err := _{{$contract.Type}}.abi.UnpackIntoInterface(out, event, log.Data); err != nil {
+					return nil, err
+				}
+			}
+			var indexed abi.Arguments
+			for _, arg := range _{{$contract.Type}}.abi.Events[event].Inputs {
+				if arg.Indexed {
+					indexed = append(indexed, arg)
+				}
+			}
+			if err := abi.ParseTopics(out, indexed, log.Topics[1:]); err != nil {
+				return nil, err
+			}
+			out.Raw = log
+			return out, nil
+		}
+
+		// Filter{{.Normalized.Name}}Events is a free log retrieval operation binding the contract event 0x{{printf "%x" .Original.ID}}.
+		//
+		// Solidity: {{.Original.String}}
+		func (_{{$contract.Type}} *{{$contract.Type}}) Filter{{.Normalized.Name}}Events(instance *bind.BoundContract, opts *bind.FilterOpts{{range .Normalized.Inputs}}{{if .Indexed}}, {{.Name}} []{{bindtype .Type $structs}}{{end}}{{end}}) (*bind.EventIterator[{{$contract.Type}}{{.Normalized.Name}}], error) {
+			{{range .Normalized.Inputs}}
+			{{if .Indexed}}var {{.Name}}Rule []interface{}
+			for _, {{.Name}}Item := range {{.Name}} {
+				{{.Name}}Rule = append({{.Name}}Rule, {{.Name}}Item)
+			}{{end}}{{end}}
+
+			return bind.FilterEvents(instance, opts, _{{$contract.Type}}.Unpack{{.Normalized.Name}}Event{{range .Normalized.Inputs}}{{if .Indexed}}, {{.Name}}Rule{{end}}{{end}})
+		}
+
+		// Watch{{.Normalized.Name}}Events is a free log subscription operation binding the contract event 0x{{printf "%x" .Original.ID}}.
+		//
+		// Solidity: {{.Original.String}}
+		func (_{{$contract.Type}} *{{$contract.Type}}) Watch{{.Normalized.Name}}Events(instance *bind.BoundContract, opts *bind.WatchOpts, sink chan<- *{{$contract.Type}}{{.Normalized.Name}}{{range .Normalized.Inputs}}{{if .Indexed}}, {{.Name}} []{{bindtype .Type $structs}}{{end}}{{end}}) (event.Subscription, error) {
+			{{range .Normalized.Inputs}}
+			{{if .Indexed}}var {{.Name}}Rule []interface{}
+			for _, {{.Name}}Item := range {{.Name}} {
+				{{.Name}}Rule = append({{.Name}}Rule, {{.Name}}Item)
+			}{{end}}{{end}}
+
+			return bind.WatchEvents(instance, opts, _{{$contract.Type}}.Unpack{{.Normalized.Name}}Event, sink{{range .Normalized.Inputs}}{{if .Indexed}}, {{.Name}}Rule{{end}}{{end}})
+		}
+	{{end}}
+
+	{{if .Errors}}
+		// UnpackError attempts to decode the revert data of a call into one of the custom
+		// errors of the {{$contract.Type}} contract, returning a pointer to the typed error.
+		func (_{{$contract.Type}} *{{$contract.Type}}) UnpackError(raw []byte) (any, error) {
+			if len(raw) < 4 {
+				return nil, errors.New("invalid error data")
+			}
+			{{range .Errors}}
+			if bytes.Equal(raw[:4], _{{$contract.Type}}.abi.Errors["{{.Original.Name}}"].ID.Bytes()[:4]) {
+				return _{{$contract.Type}}.Unpack{{.Normalized.Name}}Error(raw[4:])
+			}{{end}}
+			return nil, errors.New("unknown error")
+		}
+	{{end}}
+
+	{{range .Errors}}
+		// {{$contract.Type}}{{.Normalized.Name}} represents a {{.Original.Name}} error raised by the {{$contract.Type}} contract.
+		type {{$contract.Type}}{{.Normalized.Name}} struct { {{range .Normalized.Inputs}}
+			{{capitalise .Name}} {{bindtype .Type $structs}}; {{end}}
+		}
+
+		// {{$contract.Type}}{{.Normalized.Name}}ErrorID returns the hash of the canonical signature
+		// of the {{.Original.Name}} error.
+		//
+		// Solidity: {{.Original.String}}
+		func {{$contract.Type}}{{.Normalized.Name}}ErrorID() common.Hash {
+			return common.HexToHash("{{.Original.ID.Hex}}")
+		}
+
+		// Error implements the error interface.
+		func (e *{{$contract.Type}}{{.Normalized.Name}}) Error() string {
+			return "execution reverted: {{.Original.Sig}}"
+		}
+
+		// Unpack{{.Normalized.Name}}Error is the Go binding used to decode the arguments of the
+		// {{.Original.Name}} error, excluding the selector.
+		//
+		// Solidity: {{.Original.String}}
+		func (_{{$contract.Type}} *{{$contract.Type}}) Unpack{{.Normalized.Name}}Error(raw []byte) (*{{$contract.Type}}{{.Normalized.Name}}, error) {
+			{{if .Normalized.Inputs}}out{{else}}_{{end}}, err := _{{$contract.Type}}.abi.Errors["{{.Original.Name}}"].Inputs.Unpack(raw)
+			if err != nil {
+				return nil, err
+			}
+			outstruct := new({{$contract.Type}}{{.Normalized.Name}})
+			{{range $i, $t := .Normalized.Inputs}}
+			outstruct.{{capitalise .Name}} = *abi.ConvertType(out[{{$i}}], new({{bindtype .Type $structs}})).(*{{bindtype .Type $structs}}){{end}}
+
+			return outstruct, nil
+		}
+	{{end}}
+{{end}}
@@ -42,6 +42,7 @@ type tmplContract struct {
 	Fallback    *tmplMethod            // Additional special fallback function
 	Receive     *tmplMethod            // Additional special receive function
 	Events      map[string]*tmplEvent  // Contract events accessors
+	Errors      map[string]*tmplError  // Contract custom errors
 	Libraries   map[string]string      // Same as tmplData, but filtered to only keep what the contract needs
 	Library     bool                   // Indicator whether the contract is a library
 }
@@ -61,6 +62,13 @@ type tmplEvent struct {
 	Normalized abi.Event // Normalized version of the parsed fields
 }
 
+// tmplError is a wrapper around an abi.Error that contains a few preprocessed
+// and cached data fields.
+type tmplError struct {
+	Original   abi.Error // Original error as parsed by the abi package
+	Normalized abi.Error // Normalized version of the parsed fields
+}
+
 // tmplField is a wrapper around a struct field with binding language
 // struct type definition and relative filed name.
 type tmplField struct {
@@ -87,3 +95,14 @@ var tmplSource = map[Lang]string{
 //
 //go:embed source.go.tpl
 var tmplSourceGo string
+
+// tmplSourceV2 is language to template mapping of the v2 bindings.
+var tmplSourceV2 = map[Lang]string{
+	LangGo: tmplSourceV2Go,
+}
+
+// tmplSourceV2Go is the Go source template that the generated v2 Go contract
+// binding is based on.
+//
+//go:embed source2.go.tpl
+var tmplSourceV2Go string
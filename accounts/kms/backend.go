@@ -0,0 +1,245 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package kms
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/rajchain/go-rajchain"
+	"github.com/rajchain/go-rajchain/accounts"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/event"
+	"github.com/rajchain/go-rajchain/log"
+)
+
+// Scheme is the URL scheme of the accounts held by key services.
+const Scheme = "kms"
+
+// requestTimeout is the maximum time to wait for a key service to respond.
+const requestTimeout = 30 * time.Second
+
+// Backend is an accounts backend exposing the keys of a key service as a
+// single wallet.
+type Backend struct {
+	wallet *Wallet
+}
+
+// NewBackend creates a backend for the key service, identified by name in the
+// account URLs. The keys of the service are listed upfront, failing if the
+// service is unreachable.
+func NewBackend(name string, service KeyService) (*Backend, error) {
+	w := &Wallet{
+		url:     accounts.URL{Scheme: Scheme, Path: name},
+		service: service,
+	}
+	if err := w.Refresh(); err != nil {
+		return nil, err
+	}
+	return &Backend{wallet: w}, nil
+}
+
+// Wallets implements accounts.Backend, returning the wallet of the key service.
+func (b *Backend) Wallets() []accounts.Wallet {
+	return []accounts.Wallet{b.wallet}
+}
+
+// Subscribe implements accounts.Backend. The wallet of a key service is static,
+// so no events are ever sent.
+func (b *Backend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// account is a signing account along with the key backing it.
+type account struct {
+	accounts.Account
+	key Key
+}
+
+// Wallet is the set of accounts backed by the keys of a key service.
+type Wallet struct {
+	url     accounts.URL
+	service KeyService
+
+	accounts []account
+	err      error // Failure of the last key listing, if any
+	lock     sync.RWMutex
+}
+
+// Refresh reloads the list of keys from the key service.
+func (w *Wallet) Refresh() error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	keys, err := w.service.Keys(ctx)
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.err = err
+	if err != nil {
+		return err
+	}
+	accs := make([]account, 0, len(keys))
+	for _, key := range keys {
+		pub, err := crypto.UnmarshalPubkey(key.PublicKey)
+		if err != nil {
+			log.Warn("Skipping invalid key service key", "wallet", w.url, "id", key.ID, "err", err)
+			continue
+		}
+		accs = append(accs, account{
+			Account: accounts.Account{
+				Address: crypto.PubkeyToAddress(*pub),
+				URL:     accounts.URL{Scheme: Scheme, Path: w.url.Path + "/" + key.ID},
+			},
+			key: key,
+		})
+	}
+	w.accounts = accs
+	return nil
+}
+
+// URL implements accounts.Wallet, returning the URL of the key service.
+func (w *Wallet) URL() accounts.URL {
+	return w.url
+}
+
+// Status implements accounts.Wallet, returning whether the key service could
+// be reached the last time keys were listed.
+func (w *Wallet) Status() (string, error) {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	if w.err != nil {
+		return "Unavailable", w.err
+	}
+	return fmt.Sprintf("Online, %d keys", len(w.accounts)), nil
+}
+
+// Open implements accounts.Wallet, but is a noop for key services, the keys
+// are authorized by the service itself.
+func (w *Wallet) Open(passphrase string) error { return nil }
+
+// Close implements accounts.Wallet, releasing the key service connection.
+func (w *Wallet) Close() error {
+	return w.service.Close()
+}
+
+// Accounts implements accounts.Wallet, returning the accounts of the keys
+// held by the key service.
+func (w *Wallet) Accounts() []accounts.Account {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	accs := make([]accounts.Account, len(w.accounts))
+	for i, acc := range w.accounts {
+		accs[i] = acc.Account
+	}
+	return accs
+}
+
+// Contains implements accounts.Wallet, returning whether a particular account
+// is backed by a key of the key service.
+func (w *Wallet) Contains(acc accounts.Account) bool {
+	_, ok := w.find(acc)
+	return ok
+}
+
+// find returns the key backing the given account.
+func (w *Wallet) find(acc accounts.Account) (Key, bool) {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	for _, a := range w.accounts {
+		if a.Address == acc.Address && (acc.URL == (accounts.URL{}) || acc.URL == a.URL) {
+			return a.key, true
+		}
+	}
+	return Key{}, false
+}
+
+// Derive implements accounts.Wallet, but is not supported by key services.
+func (w *Wallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+// SelfDerive implements accounts.Wallet, but is not supported by key services.
+func (w *Wallet) SelfDerive(bases []accounts.DerivationPath, chain rajchain.ChainStateReader) {}
+
+// signHash requests the key service to sign the hash with the account's key.
+func (w *Wallet) signHash(acc accounts.Account, hash []byte) ([]byte, error) {
+	key, ok := w.find(acc)
+	if !ok {
+		return nil, accounts.ErrUnknownAccount
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	sig, err := w.service.Sign(ctx, key.ID, hash)
+	if err != nil {
+		return nil, err
+	}
+	return canonicalSignature(sig, hash, key.PublicKey)
+}
+
+// SignData implements accounts.Wallet, signing keccak256(data). It covers the
+// EIP-712 typed data too, whose data is the encoded domain and message.
+func (w *Wallet) SignData(acc accounts.Account, mimeType string, data []byte) ([]byte, error) {
+	return w.signHash(acc, crypto.Keccak256(data))
+}
+
+// SignDataWithPassphrase implements accounts.Wallet. Since key services don't
+// rely on passphrases, these are silently ignored.
+func (w *Wallet) SignDataWithPassphrase(acc accounts.Account, passphrase, mimeType string, data []byte) ([]byte, error) {
+	return w.SignData(acc, mimeType, data)
+}
+
+// SignText implements accounts.Wallet, signing the hash of the given text with
+// the rajchain prefix scheme.
+func (w *Wallet) SignText(acc accounts.Account, text []byte) ([]byte, error) {
+	return w.signHash(acc, accounts.TextHash(text))
+}
+
+// SignTextWithPassphrase implements accounts.Wallet. Since key services don't
+// rely on passphrases, these are silently ignored.
+func (w *Wallet) SignTextWithPassphrase(acc accounts.Account, passphrase string, text []byte) ([]byte, error) {
+	return w.SignText(acc, text)
+}
+
+// SignTx implements accounts.Wallet, signing the transaction with EIP-155
+// replay protection if a chain ID is given.
+func (w *Wallet) SignTx(acc accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signer := types.LatestSignerForChainID(chainID)
+	sig, err := w.signHash(acc, signer.Hash(tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(signer, sig)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet. Since key services don't
+// rely on passphrases, these are silently ignored.
+func (w *Wallet) SignTxWithPassphrase(acc accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return w.SignTx(acc, tx, chainID)
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rajchain/go-rajchain/common/hexutil"
+)
+
+// maxResponseSize is the maximum size of a key service response.
+const maxResponseSize = 1024 * 1024
+
+// httpKey is the JSON encoding of a key in the HTTP KMS protocol.
+type httpKey struct {
+	ID        string        `json:"id"`
+	PublicKey hexutil.Bytes `json:"publicKey"`
+}
+
+// httpSignRequest is the JSON body of a signing request in the HTTP KMS protocol.
+type httpSignRequest struct {
+	Digest hexutil.Bytes `json:"digest"`
+}
+
+// httpSignResponse is the JSON body of a signing response in the HTTP KMS protocol.
+type httpSignResponse struct {
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+// HTTPService is a key service speaking a generic HTTP KMS protocol, meant to be
+// fronted by a thin adapter to the cloud KMS of choice:
+//
+//	GET  <endpoint>/keys            -> [{"id": "...", "publicKey": "0x04..."}]
+//	POST <endpoint>/keys/<id>/sign  {"digest": "0x..."} -> {"signature": "0x..."}
+//
+// Signatures may be DER encoded or raw r||s. If a token is configured, requests
+// carry it as a bearer token.
+type HTTPService struct {
+	endpoint string
+	token    string
+	client   *http.Client
+}
+
+// NewHTTPService creates a client for the key service at the given endpoint.
+func NewHTTPService(endpoint string, token string) (*HTTPService, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported key service scheme %q", u.Scheme)
+	}
+	return &HTTPService{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		token:    token,
+		client:   new(http.Client),
+	}, nil
+}
+
+// Keys implements KeyService, listing the keys of the service.
+func (s *HTTPService) Keys(ctx context.Context) ([]Key, error) {
+	var res []httpKey
+	if err := s.call(ctx, http.MethodGet, "/keys", nil, &res); err != nil {
+		return nil, err
+	}
+	keys := make([]Key, len(res))
+	for i, key := range res {
+		keys[i] = Key{ID: key.ID, PublicKey: key.PublicKey}
+	}
+	return keys, nil
+}
+
+// Sign implements KeyService, requesting the service to sign the digest.
+func (s *HTTPService) Sign(ctx context.Context, id string, digest []byte) ([]byte, error) {
+	var res httpSignResponse
+	if err := s.call(ctx, http.MethodPost, "/keys/"+url.PathEscape(id)+"/sign", &httpSignRequest{Digest: digest}, &res); err != nil {
+		return nil, err
+	}
+	return res.Signature, nil
+}
+
+// Close implements KeyService, releasing idle connections.
+func (s *HTTPService) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// call sends a request to the key service, decoding the JSON response.
+func (s *HTTPService) call(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		blob, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(blob)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	blob, err := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("key service error: %s: %s", res.Status, strings.TrimSpace(string(blob)))
+	}
+	return json.Unmarshal(blob, result)
+}
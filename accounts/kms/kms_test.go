@@ -0,0 +1,310 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package kms
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rajchain/go-rajchain/accounts"
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/math"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/signer/core/apitypes"
+)
+
+// newSoftBackend creates a backend over an in-memory service with one key.
+func newSoftBackend(t *testing.T) (*Backend, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, _ := crypto.GenerateKey()
+	service := NewSoftService()
+	service.AddKey("treasury", key)
+
+	backend, err := NewBackend("soft", service)
+	if err != nil {
+		t.Fatalf("Failed to create backend: %v", err)
+	}
+	return backend, key
+}
+
+func TestWalletAccounts(t *testing.T) {
+	backend, key := newSoftBackend(t)
+
+	wallet := backend.Wallets()[0]
+	accs := wallet.Accounts()
+	if len(accs) != 1 {
+		t.Fatalf("Wrong number of accounts: have %d, want 1", len(accs))
+	}
+	want := accounts.Account{
+		Address: crypto.PubkeyToAddress(key.PublicKey),
+		URL:     accounts.URL{Scheme: Scheme, Path: "soft/treasury"},
+	}
+	if accs[0] != want {
+		t.Fatalf("Wrong account: have %v, want %v", accs[0], want)
+	}
+	if !wallet.Contains(accounts.Account{Address: want.Address}) {
+		t.Fatal("Account not contained by address")
+	}
+	if wallet.Contains(accounts.Account{Address: common.Address{1}}) {
+		t.Fatal("Unknown account contained")
+	}
+	if _, err := wallet.SignText(accounts.Account{Address: common.Address{1}}, []byte("hello")); err != accounts.ErrUnknownAccount {
+		t.Fatalf("Signing with unknown account: have %v, want %v", err, accounts.ErrUnknownAccount)
+	}
+}
+
+func TestWalletSignTx(t *testing.T) {
+	backend, key := newSoftBackend(t)
+
+	var (
+		wallet  = backend.Wallets()[0]
+		acc     = wallet.Accounts()[0]
+		chainID = big.NewInt(1337)
+		signer  = types.LatestSignerForChainID(chainID)
+	)
+	// Sign a batch of transactions, covering both S halves returned by the service
+	for nonce := uint64(0); nonce < 16; nonce++ {
+		tx := types.NewTx(&types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     nonce,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(100),
+			Gas:       21000,
+			To:        &common.Address{0xaa},
+			Value:     big.NewInt(1),
+		})
+		signed, err := wallet.SignTx(acc, tx, chainID)
+		if err != nil {
+			t.Fatalf("nonce %d: failed to sign: %v", nonce, err)
+		}
+		sender, err := types.Sender(signer, signed)
+		if err != nil {
+			t.Fatalf("nonce %d: invalid signature: %v", nonce, err)
+		}
+		if sender != crypto.PubkeyToAddress(key.PublicKey) {
+			t.Fatalf("nonce %d: wrong sender: have %v, want %v", nonce, sender, crypto.PubkeyToAddress(key.PublicKey))
+		}
+		if _, _, s := signed.RawSignatureValues(); s.Cmp(secp256k1HalfN) > 0 {
+			t.Fatalf("nonce %d: high S value", nonce)
+		}
+	}
+}
+
+func TestWalletSignTypedData(t *testing.T) {
+	backend, key := newSoftBackend(t)
+
+	typed := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {{Name: "name", Type: "string"}, {Name: "chainId", Type: "uint256"}},
+			"Mail":         {{Name: "contents", Type: "string"}},
+		},
+		PrimaryType: "Mail",
+		Domain:      apitypes.TypedDataDomain{Name: "Test", ChainId: math.NewHexOrDecimal256(1)},
+		Message:     apitypes.TypedDataMessage{"contents": "hello"},
+	}
+	hash, raw, err := apitypes.TypedDataAndHash(typed)
+	if err != nil {
+		t.Fatalf("Failed to hash typed data: %v", err)
+	}
+	wallet := backend.Wallets()[0]
+	sig, err := wallet.SignData(wallet.Accounts()[0], accounts.MimetypeTypedData, []byte(raw))
+	if err != nil {
+		t.Fatalf("Failed to sign typed data: %v", err)
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		t.Fatalf("Failed to recover signer: %v", err)
+	}
+	if crypto.PubkeyToAddress(*pub) != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatal("Typed data signed by wrong key")
+	}
+}
+
+func TestHTTPService(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	soft := NewSoftService()
+	soft.AddKey("hot/1", key)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		keys, _ := soft.Keys(r.Context())
+		res := make([]httpKey, len(keys))
+		for i, key := range keys {
+			res[i] = httpKey{ID: key.ID, PublicKey: key.PublicKey}
+		}
+		json.NewEncoder(w).Encode(res)
+	})
+	mux.HandleFunc("/keys/{id}/sign", func(w http.ResponseWriter, r *http.Request) {
+		var req httpSignRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sig, err := soft.Sign(r.Context(), r.PathValue("id"), req.Digest)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(&httpSignResponse{Signature: sig})
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	// Unauthorized clients must fail upfront
+	service, _ := NewHTTPService(srv.URL, "wrong")
+	if _, err := NewBackend("remote", service); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("Unauthorized backend: have %v, want 401 error", err)
+	}
+	service, err := NewHTTPService(srv.URL+"/", "secret")
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	backend, err := NewBackend("remote", service)
+	if err != nil {
+		t.Fatalf("Failed to create backend: %v", err)
+	}
+	wallet := backend.Wallets()[0]
+	sig, err := wallet.SignText(wallet.Accounts()[0], []byte("hello"))
+	if err != nil {
+		t.Fatalf("Failed to sign text: %v", err)
+	}
+	pub, err := crypto.SigToPub(accounts.TextHash([]byte("hello")), sig)
+	if err != nil || crypto.PubkeyToAddress(*pub) != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("Text signed by wrong key: %v", err)
+	}
+}
+
+// testToken is a PKCS#11 token holding keys in memory, returning DER wrapped EC
+// points and raw r||s signatures as the standard mandates.
+type testToken struct {
+	keys []*ecdsa.PrivateKey
+}
+
+func (t *testToken) FindKeys() ([]TokenKey, error) {
+	keys := make([]TokenKey, len(t.keys))
+	for i, key := range t.keys {
+		point, _ := asn1.Marshal(crypto.FromECDSAPub(&key.PublicKey))
+		keys[i] = TokenKey{Handle: uint(i + 100), Label: string(rune('a' + i)), ECPoint: point}
+	}
+	return keys, nil
+}
+
+func (t *testToken) Sign(handle uint, digest []byte) ([]byte, error) {
+	sig, err := crypto.Sign(digest, t.keys[handle-100])
+	if err != nil {
+		return nil, err
+	}
+	return sig[:64], nil
+}
+
+func (t *testToken) Close() error { return nil }
+
+func TestPKCS11Service(t *testing.T) {
+	token := new(testToken)
+	for i := 0; i < 2; i++ {
+		key, _ := crypto.GenerateKey()
+		token.keys = append(token.keys, key)
+	}
+	backend, err := NewBackend("hsm", NewPKCS11Service(token))
+	if err != nil {
+		t.Fatalf("Failed to create backend: %v", err)
+	}
+	wallet := backend.Wallets()[0]
+	for i, acc := range wallet.Accounts() {
+		if acc.Address != crypto.PubkeyToAddress(token.keys[i].PublicKey) {
+			t.Fatalf("account %d: wrong address", i)
+		}
+		sig, err := wallet.SignData(acc, accounts.MimetypeTextPlain, []byte("hello"))
+		if err != nil {
+			t.Fatalf("account %d: failed to sign: %v", i, err)
+		}
+		pub, err := crypto.SigToPub(crypto.Keccak256([]byte("hello")), sig)
+		if err != nil || crypto.PubkeyToAddress(*pub) != acc.Address {
+			t.Fatalf("account %d: signed by wrong key: %v", i, err)
+		}
+	}
+}
+
+func TestCanonicalSignatureErrors(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		other, _ = crypto.GenerateKey()
+		digest   = crypto.Keccak256([]byte("digest"))
+		pubkey   = crypto.FromECDSAPub(&key.PublicKey)
+	)
+	foreign, _ := crypto.Sign(digest, other)
+	for i, input := range [][]byte{
+		nil,
+		{0x30, 0x00},
+		make([]byte, 64),
+		foreign,
+	} {
+		if _, err := canonicalSignature(input, digest, pubkey); err == nil {
+			t.Errorf("input %d: no error", i)
+		}
+	}
+}
+
+// Tests that DER signatures as long as raw ones are not mistaken for them.
+func TestCanonicalSignatureShortDER(t *testing.T) {
+	// Pick a 26 byte R on the curve and derive the public key signing with it,
+	// which gives a 64 byte DER encoding along with the 32 byte S
+	var (
+		digest = crypto.Keccak256([]byte("digest"))
+		s      = new(big.Int).SetBytes(crypto.Keccak256([]byte("s"))[:32])
+		r      = new(big.Int).Lsh(big.NewInt(1), 200)
+		pubkey []byte
+	)
+	s.SetBit(s.Rsh(s, 2), 253, 1) // full length S in the lower half, without a sign byte
+	for pubkey == nil {
+		r.Add(r, common.Big1)
+
+		raw := make([]byte, crypto.SignatureLength)
+		r.FillBytes(raw[:32])
+		s.FillBytes(raw[32:64])
+		pubkey, _ = crypto.Ecrecover(digest, raw)
+	}
+	der, err := asn1.Marshal(ecdsaSignature{R: r, S: s})
+	if err != nil {
+		t.Fatalf("Failed to encode signature: %v", err)
+	}
+	if len(der) != 64 {
+		t.Fatalf("Unexpected DER signature length: %d", len(der))
+	}
+	sig, err := canonicalSignature(der, digest, pubkey)
+	if err != nil {
+		t.Fatalf("Failed to convert DER signature: %v", err)
+	}
+	if recovered, err := crypto.Ecrecover(digest, sig); err != nil || !bytes.Equal(recovered, pubkey) {
+		t.Fatalf("Converted signature recovers wrong key: %v", err)
+	}
+}
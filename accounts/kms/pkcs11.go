@@ -0,0 +1,119 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package kms
+
+import (
+	"context"
+	"encoding/asn1"
+	"fmt"
+	"sync"
+)
+
+// Token is the subset of a logged in PKCS#11 session needed to sign with the
+// secp256k1 keys of a token. It is to be implemented over the PKCS#11 binding
+// and module of choice, keeping this package free of cgo.
+type Token interface {
+	// FindKeys returns the secp256k1 key pairs on the token, as located with
+	// C_FindObjects on the CKA_EC_PARAMS of the curve.
+	FindKeys() ([]TokenKey, error)
+
+	// Sign signs the digest with the private key of the given handle, through
+	// C_SignInit and C_Sign with the CKM_ECDSA mechanism.
+	Sign(handle uint, digest []byte) ([]byte, error)
+
+	// Close logs out and closes the session.
+	Close() error
+}
+
+// TokenKey is a key pair stored on a PKCS#11 token.
+type TokenKey struct {
+	Handle  uint   // Object handle of the private key
+	Label   string // CKA_LABEL of the key pair, used as key ID
+	ECPoint []byte // CKA_EC_POINT of the public key
+}
+
+// PKCS11Service is a key service signing with the keys of a PKCS#11 token. Since
+// PKCS#11 sessions don't support concurrent operations, requests are serialized.
+type PKCS11Service struct {
+	token Token
+	keys  map[string]uint // Private key handles by label
+	lock  sync.Mutex
+}
+
+// NewPKCS11Service creates a key service over a PKCS#11 token session.
+func NewPKCS11Service(token Token) *PKCS11Service {
+	return &PKCS11Service{
+		token: token,
+		keys:  make(map[string]uint),
+	}
+}
+
+// Keys implements KeyService, listing the keys on the token.
+func (s *PKCS11Service) Keys(ctx context.Context) ([]Key, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	found, err := s.token.FindKeys()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]Key, 0, len(found))
+	for _, key := range found {
+		point, err := decodeECPoint(key.ECPoint)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %v", key.Label, err)
+		}
+		s.keys[key.Label] = key.Handle
+		keys = append(keys, Key{ID: key.Label, PublicKey: point})
+	}
+	return keys, nil
+}
+
+// Sign implements KeyService, signing the digest on the token.
+func (s *PKCS11Service) Sign(ctx context.Context, id string, digest []byte) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	handle, ok := s.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", id)
+	}
+	return s.token.Sign(handle, digest)
+}
+
+// Close implements KeyService, closing the token session.
+func (s *PKCS11Service) Close() error {
+	return s.token.Close()
+}
+
+// decodeECPoint returns the uncompressed public key of a CKA_EC_POINT value,
+// which the standard mandates to be a DER encoded octet string, but some
+// tokens return raw.
+func decodeECPoint(point []byte) ([]byte, error) {
+	if len(point) == 65 && point[0] == 0x04 {
+		return point, nil
+	}
+	var raw []byte
+	rest, err := asn1.Unmarshal(point, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EC point: %v", err)
+	}
+	if len(rest) != 0 || len(raw) != 65 || raw[0] != 0x04 {
+		return nil, fmt.Errorf("unsupported EC point encoding")
+	}
+	return raw, nil
+}
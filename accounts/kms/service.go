@@ -0,0 +1,119 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package kms implements an accounts backend whose keys never leave a remote key
+// service, such as a PKCS#11 hardware security module or a cloud KMS.
+//
+// The key services only need to hold secp256k1 keys and sign digests with them.
+// Signatures are accepted in the formats these services produce (ASN.1 DER or
+// raw r||s, with any S value) and are converted into canonical rajchain ones.
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+
+	"github.com/rajchain/go-rajchain/crypto"
+)
+
+// Key is a secp256k1 key held by a key service.
+type Key struct {
+	ID        string // Identifier of the key within the service
+	PublicKey []byte // Uncompressed public key, 65 bytes starting with 0x04
+}
+
+// KeyService is a remote service holding secp256k1 keys and signing with them.
+type KeyService interface {
+	// Keys lists the signing keys available in the service.
+	Keys(ctx context.Context) ([]Key, error)
+
+	// Sign signs a 32 byte digest with the given key. The signature may be ASN.1
+	// DER encoded, or the raw 64 byte r||s concatenation optionally followed by
+	// a recovery id.
+	Sign(ctx context.Context, id string, digest []byte) ([]byte, error)
+
+	// Close releases the resources held by the service connection.
+	Close() error
+}
+
+var (
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// ecdsaSignature is the ASN.1 structure of DER encoded ECDSA signatures.
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// canonicalSignature converts a signature returned by a key service into the
+// 65 byte [R || S || V] format, with S in the lower half of the curve order and
+// V the recovery id (0 or 1) matching the expected public key.
+//
+// A DER encoded signature with short R or S values may be 64 or 65 bytes long,
+// the same as a raw one. Inputs looking like DER are thus parsed as such first,
+// falling back to the raw format if they don't match the public key.
+func canonicalSignature(sig []byte, digest []byte, pubkey []byte) ([]byte, error) {
+	var err error
+	if isDER(sig) {
+		var parsed ecdsaSignature
+		if _, err = asn1.Unmarshal(sig, &parsed); err == nil {
+			var out []byte
+			if out, err = recoverableSignature(parsed.R, parsed.S, digest, pubkey); err == nil {
+				return out, nil
+			}
+		}
+	}
+	if len(sig) == 64 || len(sig) == crypto.SignatureLength {
+		return recoverableSignature(new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64]), digest, pubkey)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, errors.New("invalid signature encoding")
+}
+
+// isDER reports whether a signature is framed as a DER encoded sequence whose
+// length covers the whole input.
+func isDER(sig []byte) bool {
+	return len(sig) >= 2 && sig[0] == 0x30 && sig[1] < 0x80 && int(sig[1]) == len(sig)-2
+}
+
+// recoverableSignature builds the canonical signature from its R and S values,
+// finding the recovery id matching the expected public key.
+func recoverableSignature(r, s *big.Int, digest []byte, pubkey []byte) ([]byte, error) {
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(secp256k1N) >= 0 || s.Cmp(secp256k1N) >= 0 {
+		return nil, errors.New("signature values out of range")
+	}
+	// Flip S into the lower half, as required for transaction signatures
+	if s.Cmp(secp256k1HalfN) > 0 {
+		s = new(big.Int).Sub(secp256k1N, s)
+	}
+	out := make([]byte, crypto.SignatureLength)
+	r.FillBytes(out[:32])
+	s.FillBytes(out[32:64])
+
+	for v := byte(0); v < 2; v++ {
+		out[crypto.RecoveryIDOffset] = v
+		if recovered, err := crypto.Ecrecover(digest, out); err == nil && bytes.Equal(recovered, pubkey) {
+			return out, nil
+		}
+	}
+	return nil, errors.New("signature does not match the public key")
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package kms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/rajchain/go-rajchain/crypto"
+)
+
+// SoftService is a key service holding its keys in memory. It stands in for a
+// real key service in tests and development setups, mimicking their behavior:
+// signatures are DER encoded, without recovery id and with unrestricted S.
+type SoftService struct {
+	keys map[string]*ecdsa.PrivateKey
+	lock sync.RWMutex
+}
+
+// NewSoftService creates an in-memory key service.
+func NewSoftService() *SoftService {
+	return &SoftService{keys: make(map[string]*ecdsa.PrivateKey)}
+}
+
+// AddKey adds a key to the service under the given ID.
+func (s *SoftService) AddKey(id string, key *ecdsa.PrivateKey) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.keys[id] = key
+}
+
+// Keys implements KeyService, listing the keys of the service ordered by ID.
+func (s *SoftService) Keys(ctx context.Context) ([]Key, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	keys := make([]Key, 0, len(s.keys))
+	for id, key := range s.keys {
+		keys = append(keys, Key{ID: id, PublicKey: crypto.FromECDSAPub(&key.PublicKey)})
+	}
+	slices.SortFunc(keys, func(a, b Key) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return keys, nil
+}
+
+// Sign implements KeyService, signing the digest with the given key.
+func (s *SoftService) Sign(ctx context.Context, id string, digest []byte) ([]byte, error) {
+	s.lock.RLock()
+	key, ok := s.keys[id]
+	s.lock.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", id)
+	}
+	sig, err := crypto.Sign(digest, key)
+	if err != nil {
+		return nil, err
+	}
+	r, sv := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64])
+
+	// Return the high S half of the time, as services not enforcing low S would
+	if digest[len(digest)-1]&1 == 1 {
+		sv.Sub(secp256k1N, sv)
+	}
+	return asn1.Marshal(ecdsaSignature{R: r, S: sv})
+}
+
+// Close implements KeyService.
+func (s *SoftService) Close() error {
+	return nil
+}
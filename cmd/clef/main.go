@@ -28,6 +28,7 @@ import (
 	"io"
 	"math/big"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -40,6 +41,7 @@ import (
 	"github.com/rajchain/go-rajchain/cmd/utils"
 	"github.com/rajchain/go-rajchain/common"
 	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/common/math"
 	"github.com/rajchain/go-rajchain/core/types"
 	"github.com/rajchain/go-rajchain/crypto"
 	"github.com/rajchain/go-rajchain/internal/ethapi"
@@ -49,9 +51,11 @@ import (
 	"github.com/rajchain/go-rajchain/params"
 	"github.com/rajchain/go-rajchain/rlp"
 	"github.com/rajchain/go-rajchain/rpc"
+	"github.com/rajchain/go-rajchain/signer/approval"
 	"github.com/rajchain/go-rajchain/signer/core"
 	"github.com/rajchain/go-rajchain/signer/core/apitypes"
 	"github.com/rajchain/go-rajchain/signer/fourbyte"
+	"github.com/rajchain/go-rajchain/signer/policy"
 	"github.com/rajchain/go-rajchain/signer/rules"
 	"github.com/rajchain/go-rajchain/signer/storage"
 	"github.com/mattn/go-colorable"
@@ -126,6 +130,31 @@ var (
 		Name:  "rules",
 		Usage: "Path to the rule file to auto-authorize requests with",
 	}
+	policyFlag = &cli.StringFlag{
+		Name:  "policy",
+		Usage: "Path to the declarative policy file to auto-authorize requests with (exclusive with --rules)",
+	}
+	approvalThresholdFlag = &cli.IntFlag{
+		Name:  "approval.threshold",
+		Usage: "Number of approvers needed to confirm signing requests, on top of the UI, rules or policy (0 = disabled)",
+	}
+	approvalApproversFlag = &cli.StringFlag{
+		Name:  "approval.approvers",
+		Usage: "Comma separated addresses of the approvers confirming signing requests",
+	}
+	approvalTimeoutFlag = &cli.DurationFlag{
+		Name:  "approval.timeout",
+		Usage: "Time after which signing requests without enough approvals are rejected",
+		Value: time.Hour,
+	}
+	approvalMinValueFlag = &cli.StringFlag{
+		Name:  "approval.minvalue",
+		Usage: "Transaction value (in wei) from which approvers are needed for plain transfers, contract interactions always need them",
+	}
+	approvalHTTPFlag = &cli.StringFlag{
+		Name:  "approval.http",
+		Usage: "Listening address for the signed approval HTTP callbacks (empty = disabled)",
+	}
 	stdiouiFlag = &cli.BoolFlag{
 		Name: "stdio-ui",
 		Usage: "Use STDIN/STDOUT as a channel for an external UI. " +
@@ -152,7 +181,7 @@ the rule-engine to work.`,
 	attestCommand = &cli.Command{
 		Action:    attestFile,
 		Name:      "attest",
-		Usage:     "Attest that a js-file is to be used",
+		Usage:     "Attest that a js-file or policy file is to be used",
 		ArgsUsage: "<sha256sum>",
 		Flags: []cli.Flag{
 			logLevelFlag,
@@ -160,10 +189,10 @@ the rule-engine to work.`,
 			signerSecretFlag,
 		},
 		Description: `
-The attest command stores the sha256 of the rule.js-file that you want to use for automatic processing of
-incoming requests.
+The attest command stores the sha256 of the rule.js-file or the policy file that you want to use for
+automatic processing of incoming requests.
 
-Whenever you make an edit to the rule file, you need to use attestation to tell
+Whenever you make an edit to the rule or policy file, you need to use attestation to tell
 Clef that the file is 'safe' to execute.`,
 	}
 	setCredentialCommand = &cli.Command{
@@ -271,6 +300,8 @@ func init() {
 		utils.LightKDFFlag,
 		utils.NoUSBFlag,
 		utils.SmartCardDaemonPathFlag,
+		utils.KeyServiceFlag,
+		utils.KeyServiceTokenFlag,
 		utils.HTTPListenAddrFlag,
 		utils.HTTPVirtualHostsFlag,
 		utils.IPCDisabledFlag,
@@ -281,6 +312,12 @@ func init() {
 		customDBFlag,
 		auditLogFlag,
 		ruleFlag,
+		policyFlag,
+		approvalThresholdFlag,
+		approvalApproversFlag,
+		approvalTimeoutFlag,
+		approvalMinValueFlag,
+		approvalHTTPFlag,
 		stdiouiFlag,
 		testFlag,
 		advancedMode,
@@ -296,6 +333,7 @@ func init() {
 		gendocCommand,
 		listAccountsCommand,
 		listWalletsCommand,
+		policyCommand,
 	}
 }
 
@@ -630,6 +668,9 @@ func signer(c *cli.Context) error {
 	if err := initialize(c); err != nil {
 		return err
 	}
+	if c.IsSet(ruleFlag.Name) && c.IsSet(policyFlag.Name) {
+		return fmt.Errorf("flags --%s and --%s are mutually exclusive", ruleFlag.Name, policyFlag.Name)
+	}
 	var (
 		ui core.UIClientAPI
 	)
@@ -662,11 +703,13 @@ func signer(c *cli.Context) error {
 		// Generate domain specific keys
 		pwkey := crypto.Keccak256([]byte("credentials"), stretchedKey)
 		jskey := crypto.Keccak256([]byte("jsstorage"), stretchedKey)
+		policykey := crypto.Keccak256([]byte("policystorage"), stretchedKey)
 		confkey := crypto.Keccak256([]byte("config"), stretchedKey)
 
 		// Initialize the encrypted storages
 		pwStorage = storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "credentials.json"), pwkey)
 		jsStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "jsstorage.json"), jskey)
+		policyStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "policystorage.json"), policykey)
 		configStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "config.json"), confkey)
 
 		// Do we have a rule-file?
@@ -692,6 +735,39 @@ func signer(c *cli.Context) error {
 				}
 			}
 		}
+		// Do we have a policy file?
+		if policyFile := c.String(policyFlag.Name); policyFile != "" {
+			blob, err := os.ReadFile(policyFile)
+			if err != nil {
+				log.Warn("Could not load policy, disabling", "file", policyFile, "err", err)
+			} else {
+				shasum := sha256.Sum256(blob)
+				foundShaSum := hex.EncodeToString(shasum[:])
+				storedShasum, _ := configStorage.Get("ruleset_sha256")
+				if storedShasum != foundShaSum {
+					log.Warn("Policy hash not attested, disabling", "hash", foundShaSum, "attested", storedShasum)
+				} else {
+					p, err := policy.Parse(blob)
+					if err != nil {
+						utils.Fatalf("Invalid policy %s: %v", policyFile, err)
+					}
+					ui = policy.NewUI(ui, policy.NewEngine(p, db, policyStorage))
+					log.Info("Policy engine configured", "file", policyFile)
+				}
+			}
+		}
+	}
+	// Multi-party approval, wrapping the rules and policies so that nothing is
+	// signed without the quorum
+	var approvals *approval.Queue
+	if c.Int(approvalThresholdFlag.Name) > 0 {
+		config, err := approvalConfig(c)
+		if err != nil {
+			utils.Fatalf("Invalid approval configuration: %v", err)
+		}
+		approvals = approval.NewQueue(config)
+		ui = approval.NewUI(ui, approvals)
+		log.Info("Multi-party approval configured", "threshold", config.Threshold, "approvers", len(config.Approvers), "timeout", config.Timeout)
 	}
 	var (
 		chainId  = c.Int64(chainIdFlag.Name)
@@ -705,6 +781,14 @@ func signer(c *cli.Context) error {
 		"light-kdf", lightKdf, "advanced", advanced)
 	am := core.StartClefAccountManager(ksLoc, nousb, lightKdf, scpath)
 	defer am.Close()
+	if endpoint := c.String(utils.KeyServiceFlag.Name); endpoint != "" {
+		backend, err := utils.MakeKeyServiceBackend(endpoint, c.String(utils.KeyServiceTokenFlag.Name))
+		if err != nil {
+			utils.Fatalf("Failed to connect to key service: %v", err)
+		}
+		am.AddBackend(backend)
+		log.Info("Key service configured", "url", endpoint)
+	}
 	apiImpl := core.NewSignerAPI(am, chainId, nousb, ui, db, advanced, pwStorage)
 
 	// Establish the bidirectional communication, by creating a new UI backend and registering
@@ -714,11 +798,26 @@ func signer(c *cli.Context) error {
 
 	// Audit logging
 	if logfile := c.String(auditLogFlag.Name); logfile != "" {
-		api, err = core.NewAuditLogger(logfile, api)
+		auditLogger, err := core.NewAuditLogger(logfile, api)
 		if err != nil {
 			utils.Fatalf(err.Error())
 		}
+		api = auditLogger
 		log.Info("Audit logs configured", "file", logfile)
+		if approvals != nil {
+			approvals.SetAuditLog(auditLogger.Logger())
+		}
+	}
+	if addr := c.String(approvalHTTPFlag.Name); addr != "" && approvals != nil {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			utils.Fatalf("Could not start approval endpoint: %v", err)
+		}
+		server := &http.Server{Handler: approval.NewAPI(approvals).Handler(), ReadHeaderTimeout: rpc.DefaultHTTPTimeouts.ReadHeaderTimeout}
+		go server.Serve(listener)
+		log.Info("Approval endpoint opened", "url", fmt.Sprintf("http://%v/", listener.Addr()))
+
+		defer server.Close()
 	}
 	// register signer API with server
 	var (
@@ -795,6 +894,28 @@ func signer(c *cli.Context) error {
 	return nil
 }
 
+// approvalConfig assembles the multi-party approval configuration from the flags.
+func approvalConfig(c *cli.Context) (approval.Config, error) {
+	config := approval.Config{
+		Threshold: c.Int(approvalThresholdFlag.Name),
+		Timeout:   c.Duration(approvalTimeoutFlag.Name),
+	}
+	for _, addr := range utils.SplitAndTrim(c.String(approvalApproversFlag.Name)) {
+		if !common.IsHexAddress(addr) {
+			return config, fmt.Errorf("invalid approver address %q", addr)
+		}
+		config.Approvers = append(config.Approvers, common.HexToAddress(addr))
+	}
+	if value := c.String(approvalMinValueFlag.Name); value != "" {
+		min, ok := math.ParseBig256(value)
+		if !ok {
+			return config, fmt.Errorf("invalid minimum value %q", value)
+		}
+		config.MinValue = min
+	}
+	return config, config.Validate()
+}
+
 // DefaultConfigDir is the default config directory to use for the vaults and other
 // persistence requirements.
 func DefaultConfigDir() string {
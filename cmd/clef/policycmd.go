@@ -0,0 +1,136 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of go-rajchain.
+//
+// go-rajchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-rajchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-rajchain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rajchain/go-rajchain/signer/core"
+	"github.com/rajchain/go-rajchain/signer/fourbyte"
+	"github.com/rajchain/go-rajchain/signer/policy"
+	"github.com/rajchain/go-rajchain/signer/storage"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	policyCommand = &cli.Command{
+		Name:  "policy",
+		Usage: "Manage declarative signing policies",
+		Subcommands: []*cli.Command{
+			{
+				Action:    testPolicy,
+				Name:      "test",
+				Usage:     "Replay recorded requests against a policy",
+				ArgsUsage: "<policy file> <requests file>",
+				Flags: []cli.Flag{
+					logLevelFlag,
+					customDBFlag,
+				},
+				Description: `
+The policy test command evaluates a stream of recorded requests against a policy
+and prints the decision taken on each of them. Requests are JSON objects of the
+form
+
+  {"method": "ui_approveTx", "params": [<request>], "time": "2024-01-01T00:00:00Z"}
+
+where method is one of ui_approveTx, ui_approveSignData or ui_approveListing, and
+the optional time is used to evaluate spend limits. Spending is tracked in memory,
+the persistent policy storage of Clef is left untouched.`,
+			},
+		},
+	}
+)
+
+// policyRecord is a recorded request to evaluate against a policy.
+type policyRecord struct {
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	Time   *time.Time        `json:"time,omitempty"`
+}
+
+// testPolicy replays the recorded requests against the policy.
+func testPolicy(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return errors.New("need policy and requests files")
+	}
+	p, err := policy.Load(ctx.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("invalid policy: %v", err)
+	}
+	db, err := fourbyte.NewWithFile(ctx.String(customDBFlag.Name))
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(ctx.Args().Get(1))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var (
+		engine  = policy.NewEngine(p, db, storage.NewEphemeralStorage())
+		dec     = json.NewDecoder(file)
+		actions = make(map[policy.Action]int)
+	)
+	for i := 0; ; i++ {
+		var record policyRecord
+		if err := dec.Decode(&record); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("request %d: %v", i, err)
+		}
+		if len(record.Params) != 1 {
+			return fmt.Errorf("request %d: need 1 param, have %d", i, len(record.Params))
+		}
+		now := time.Now()
+		if record.Time != nil {
+			now = *record.Time
+		}
+		var res policy.Result
+		switch record.Method {
+		case "ui_approveTx":
+			var req core.SignTxRequest
+			if err := json.Unmarshal(record.Params[0], &req); err != nil {
+				return fmt.Errorf("request %d: %v", i, err)
+			}
+			res = engine.CheckTx(&req, now)
+		case "ui_approveSignData":
+			var req core.SignDataRequest
+			if err := json.Unmarshal(record.Params[0], &req); err != nil {
+				return fmt.Errorf("request %d: %v", i, err)
+			}
+			res = engine.CheckSignData(&req)
+		case "ui_approveListing":
+			var req core.ListRequest
+			if err := json.Unmarshal(record.Params[0], &req); err != nil {
+				return fmt.Errorf("request %d: %v", i, err)
+			}
+			res = engine.CheckListing(&req)
+		default:
+			return fmt.Errorf("request %d: unsupported method %q", i, record.Method)
+		}
+		actions[res.Action]++
+		fmt.Printf("%4d %-20s %v\n", i, record.Method, res)
+	}
+	fmt.Printf("\n%d approved, %d rejected, %d manual\n", actions[policy.Approve], actions[policy.Reject], actions[policy.Manual])
+	return nil
+}
@@ -0,0 +1,426 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of go-rajchain.
+//
+// go-rajchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-rajchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-rajchain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rajchain/go-rajchain/log"
+	"github.com/rajchain/go-rajchain/p2p/dnsdisc"
+	"github.com/rajchain/go-rajchain/p2p/enode"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+var (
+	dnsServeAddrFlag = &cli.StringFlag{
+		Name:  "dns-addr",
+		Usage: "Listening address of the DNS server (UDP and TCP)",
+		Value: "0.0.0.0:53",
+	}
+	dnsCrawlIntervalFlag = &cli.DurationFlag{
+		Name:  "crawl-interval",
+		Usage: "Time between crawls updating the tree (0 disables crawling)",
+		Value: time.Hour,
+	}
+	dnsNodeFilterFlag = &cli.StringFlag{
+		Name:  "crawl-filter",
+		Usage: "Filters applied to the crawled nodes, as accepted by 'devp2p nodeset filter'",
+	}
+	dnsZoneFileFlag = &cli.StringFlag{
+		Name:  "zonefile",
+		Usage: "Zone file updated with the records of the tree on every update",
+	}
+)
+
+const (
+	// dnsUDPSize is the size limit of UDP responses to queries without EDNS.
+	dnsUDPSize = 512
+	// dnsMaxUDPSize caps the UDP payload size announced by EDNS queries.
+	dnsMaxUDPSize = 4096
+	// dnsTCPTimeout is the idle timeout of TCP connections.
+	dnsTCPTimeout = 10 * time.Second
+)
+
+// dnsServer is an authoritative DNS server for the TXT records of a discovery
+// tree. It serves queries over UDP and TCP.
+type dnsServer struct {
+	domain string // lowercase, without trailing dot
+
+	mu      sync.RWMutex
+	records map[string]string // names are lowercase
+
+	udp  net.PacketConn
+	tcp  net.Listener
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newDNSServer(domain string) *dnsServer {
+	return &dnsServer{
+		domain: strings.ToLower(strings.TrimSuffix(domain, ".")),
+		quit:   make(chan struct{}),
+	}
+}
+
+// setTree replaces the served records with those of the given tree.
+func (s *dnsServer) setTree(t *dnsdisc.Tree) {
+	records := make(map[string]string)
+	for name, value := range t.ToTXT(s.domain) {
+		records[strings.ToLower(name)] = value
+	}
+	s.mu.Lock()
+	s.records = records
+	s.mu.Unlock()
+}
+
+// start listens for queries on the UDP and TCP endpoints of the given address.
+func (s *dnsServer) start(addr string) error {
+	udp, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	// Use the same port for TCP if it was chosen by the system.
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	if err != nil {
+		udp.Close()
+		return err
+	}
+	s.udp, s.tcp = udp, tcp
+	s.wg.Add(2)
+	go s.serveUDP()
+	go s.serveTCP()
+	return nil
+}
+
+// stop closes the server endpoints.
+func (s *dnsServer) stop() {
+	close(s.quit)
+	s.udp.Close()
+	s.tcp.Close()
+	s.wg.Wait()
+}
+
+func (s *dnsServer) serveUDP() {
+	defer s.wg.Done()
+
+	buf := make([]byte, dnsMaxUDPSize)
+	for {
+		n, addr, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Warn("DNS server read error", "err", err)
+			}
+			return
+		}
+		if resp := s.handle(buf[:n], true); resp != nil {
+			s.udp.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (s *dnsServer) serveTCP() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Warn("DNS server accept error", "err", err)
+			}
+			return
+		}
+		s.wg.Add(1)
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn handles the queries of a TCP connection, which are prefixed by
+// their length.
+func (s *dnsServer) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-s.quit:
+			conn.Close()
+		case <-done:
+		}
+	}()
+	var size [2]byte
+	for {
+		conn.SetDeadline(time.Now().Add(dnsTCPTimeout))
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return
+		}
+		req := make([]byte, binary.BigEndian.Uint16(size[:]))
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+		resp := s.handle(req, false)
+		if resp == nil {
+			return
+		}
+		resp = append(binary.BigEndian.AppendUint16(nil, uint16(len(resp))), resp...)
+		if _, err := conn.Write(resp); err != nil {
+			return
+		}
+	}
+}
+
+// handle answers a query. It returns nil for invalid messages, which are not
+// answered.
+func (s *dnsServer) handle(req []byte, udp bool) []byte {
+	var p dnsmessage.Parser
+	h, err := p.Start(req)
+	if err != nil || h.Response {
+		return nil
+	}
+	q, err := p.Question()
+	if err != nil {
+		return nil
+	}
+	// Find the response size limit announced by EDNS.
+	var (
+		edns    bool
+		maxSize = dnsUDPSize
+	)
+	if p.SkipAllQuestions() == nil && p.SkipAllAnswers() == nil && p.SkipAllAuthorities() == nil {
+		for {
+			rh, err := p.AdditionalHeader()
+			if err != nil {
+				break
+			}
+			if rh.Type == dnsmessage.TypeOPT {
+				edns = true
+				maxSize = min(max(int(rh.Class), dnsUDPSize), dnsMaxUDPSize)
+			}
+			if p.SkipAdditional() != nil {
+				break
+			}
+		}
+	}
+	if !udp {
+		maxSize = 65535
+	}
+
+	var (
+		rh     = dnsmessage.Header{ID: h.ID, Response: true, OpCode: h.OpCode, RecursionDesired: h.RecursionDesired}
+		name   = strings.ToLower(strings.TrimSuffix(q.Name.String(), "."))
+		answer []string
+		ttl    = uint32(treeNodeTTL)
+	)
+	if name == s.domain {
+		ttl = rootTTL
+	}
+	switch {
+	case h.OpCode != 0:
+		rh.RCode = dnsmessage.RCodeNotImplemented
+	case q.Class != dnsmessage.ClassINET && q.Class != dnsmessage.ClassANY:
+		rh.RCode = dnsmessage.RCodeRefused
+	case name != s.domain && !strings.HasSuffix(name, "."+s.domain):
+		rh.RCode = dnsmessage.RCodeRefused
+	default:
+		rh.Authoritative = true
+		s.mu.RLock()
+		value, ok := s.records[name]
+		s.mu.RUnlock()
+		switch {
+		case !ok:
+			rh.RCode = dnsmessage.RCodeNameError
+		case q.Type == dnsmessage.TypeTXT || q.Type == dnsmessage.TypeALL:
+			answer = splitTXTStrings(value)
+		}
+	}
+	resp, err := buildDNSResponse(rh, q, answer, ttl, edns)
+	if err != nil {
+		log.Warn("Failed to build DNS response", "name", q.Name, "err", err)
+		return nil
+	}
+	if len(resp) > maxSize {
+		// The client has to retry over TCP.
+		rh.Truncated = true
+		resp, _ = buildDNSResponse(rh, q, nil, 0, edns)
+	}
+	return resp
+}
+
+func buildDNSResponse(h dnsmessage.Header, q dnsmessage.Question, answer []string, ttl uint32, edns bool) ([]byte, error) {
+	b := dnsmessage.NewBuilder(nil, h)
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(q); err != nil {
+		return nil, err
+	}
+	if answer != nil {
+		if err := b.StartAnswers(); err != nil {
+			return nil, err
+		}
+		rh := dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeTXT, Class: dnsmessage.ClassINET, TTL: ttl}
+		if err := b.TXTResource(rh, dnsmessage.TXTResource{TXT: answer}); err != nil {
+			return nil, err
+		}
+	}
+	if edns {
+		if err := b.StartAdditionals(); err != nil {
+			return nil, err
+		}
+		var rh dnsmessage.ResourceHeader
+		if err := rh.SetEDNS0(dnsMaxUDPSize, dnsmessage.RCodeSuccess, false); err != nil {
+			return nil, err
+		}
+		if err := b.OPTResource(rh, dnsmessage.OPTResource{}); err != nil {
+			return nil, err
+		}
+	}
+	return b.Finish()
+}
+
+// treePublisher updates a discovery tree with the results of network crawls.
+// Every update is signed and written to the tree definition directory before
+// it is served.
+type treePublisher struct {
+	dir      string
+	domain   string
+	key      *ecdsa.PrivateKey
+	def      *dnsDefinition
+	filter   nodeFilter
+	limit    int
+	zonefile string
+	server   *dnsServer
+}
+
+// init serves the tree of the definition directory. It is signed again if the
+// signature doesn't match the definition or the key.
+func (p *treePublisher) init() error {
+	if t, err := p.signedTree(); err == nil {
+		p.server.setTree(t)
+		if p.zonefile != "" {
+			return writeZoneFile(p.zonefile, p.domain, t)
+		}
+		return nil
+	}
+	_, nodesFile := treeDefinitionFiles(p.dir)
+	return p.publish(loadNodesJSON(nodesFile))
+}
+
+// signedTree returns the tree of the definition if it carries a valid signature
+// of the publisher key.
+func (p *treePublisher) signedTree() (*dnsdisc.Tree, error) {
+	if p.def.Meta.URL == "" {
+		return nil, errors.New("tree not signed")
+	}
+	domain, pubkey, err := dnsdisc.ParseURL(p.def.Meta.URL)
+	if err != nil {
+		return nil, err
+	}
+	if domain != p.domain || !pubkey.Equal(&p.key.PublicKey) {
+		return nil, errors.New("tree signed by other key")
+	}
+	t, err := dnsdisc.MakeTree(p.def.Meta.Seq, p.def.Nodes, p.def.Meta.Links)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureValidTreeSignature(t, pubkey, p.def.Meta.Sig); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// update publishes the live nodes of a crawl which pass the filter. The tree is
+// left unchanged if no node is left or the nodes didn't change.
+func (p *treePublisher) update(crawled nodeSet) error {
+	nodes := make(nodeSet)
+	for id, n := range crawled {
+		// Only nodes which answered the last liveness check are included.
+		if n.LastResponse.Before(n.LastCheck) {
+			continue
+		}
+		if p.filter == nil || p.filter(n) {
+			nodes[id] = n
+		}
+	}
+	if p.limit >= 0 {
+		nodes = nodes.topN(p.limit)
+	}
+	if len(nodes) == 0 {
+		log.Warn("No live nodes found, keeping DNS discovery tree", "crawled", len(crawled))
+		return nil
+	}
+	current := make(nodeSet, len(p.def.Nodes))
+	current.add(p.def.Nodes...)
+	sameNode := func(a, b *enode.Node) bool { return a.ID() == b.ID() && a.Seq() == b.Seq() }
+	if slices.EqualFunc(nodes.nodes(), current.nodes(), sameNode) {
+		log.Info("DNS discovery tree is up to date", "seq", p.def.Meta.Seq, "nodes", len(nodes))
+		return nil
+	}
+	return p.publish(nodes)
+}
+
+// publish signs the next version of the tree with the given nodes and serves it.
+func (p *treePublisher) publish(nodes nodeSet) error {
+	seq := p.def.Meta.Seq + 1
+	t, err := dnsdisc.MakeTree(seq, nodes.nodes(), p.def.Meta.Links)
+	if err != nil {
+		return err
+	}
+	url, err := t.Sign(p.key, p.domain)
+	if err != nil {
+		return err
+	}
+	def := treeToDefinition(url, t)
+	def.Meta.LastModified = time.Now()
+	writeTreeMetadata(p.dir, def)
+	_, nodesFile := treeDefinitionFiles(p.dir)
+	writeNodesJSON(nodesFile, nodes)
+	if p.zonefile != "" {
+		if err := writeZoneFile(p.zonefile, p.domain, t); err != nil {
+			return err
+		}
+	}
+	p.def = def
+	p.server.setTree(t)
+	log.Info("Published DNS discovery tree", "url", url, "seq", seq, "nodes", len(nodes))
+	return nil
+}
+
+// parseNodeFilterFlag parses node filters given as a single string.
+func parseNodeFilterFlag(spec string) (nodeFilter, int, error) {
+	args := strings.Fields(spec)
+	limit, err := parseFilterLimit(args)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter, err := andFilter(args)
+	if err != nil {
+		return nil, 0, err
+	}
+	return filter, limit, nil
+}
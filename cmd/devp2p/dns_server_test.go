@@ -0,0 +1,200 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of go-rajchain.
+//
+// go-rajchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-rajchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-rajchain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"net"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/p2p/dnsdisc"
+	"github.com/rajchain/go-rajchain/p2p/enode"
+	"github.com/rajchain/go-rajchain/p2p/enr"
+)
+
+func TestDNSServer(t *testing.T) {
+	t.Parallel()
+
+	key := testKey(t)
+	tree, url := signedTestTree(t, key, "nodes.example.org", testNodes(t, 20))
+
+	srv := newDNSServer("nodes.example.org")
+	srv.setTree(tree)
+	if err := srv.start("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.stop()
+
+	for _, network := range []string{"udp", "tcp"} {
+		resolver := &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, srv.udp.LocalAddr().String())
+			},
+		}
+		client := dnsdisc.NewClient(dnsdisc.Config{Resolver: resolver, RateLimit: 1000})
+		synced, err := client.SyncTree(url)
+		if err != nil {
+			t.Fatalf("%s: sync failed: %v", network, err)
+		}
+		if !reflect.DeepEqual(synced.ToTXT("nodes.example.org"), tree.ToTXT("nodes.example.org")) {
+			t.Fatalf("%s: synced tree mismatch", network)
+		}
+
+		// Names in the zone which don't exist are reported as such.
+		_, err = resolver.LookupTXT(context.Background(), "missing.nodes.example.org")
+		var dnsErr *net.DNSError
+		if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+			t.Fatalf("%s: wrong error for missing name: %v", network, err)
+		}
+		// Names outside of the zone are refused.
+		_, err = resolver.LookupTXT(context.Background(), "example.org")
+		if err == nil || errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			t.Fatalf("%s: wrong error for name outside of zone: %v", network, err)
+		}
+	}
+}
+
+func TestTreePublisher(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir   = filepath.Join(t.TempDir(), "nodes.example.org")
+		nodes = testNodes(t, 3)
+		key   = testKey(t)
+		srv   = newDNSServer("nodes.example.org")
+	)
+	def := &dnsDefinition{Meta: dnsMetaJSON{Links: []string{}}, Nodes: nodes[:2]}
+	writeTreeMetadata(dir, def)
+	writeTreeNodes(dir, def)
+
+	pub := &treePublisher{
+		dir:    dir,
+		domain: "nodes.example.org",
+		key:    key,
+		def:    loadTreeDefinition(dir),
+		limit:  -1,
+		server: srv,
+	}
+	// The unsigned tree is signed on startup.
+	if err := pub.init(); err != nil {
+		t.Fatal(err)
+	}
+	if _, tree, err := loadTreeDefinitionForExport(dir); err != nil || tree.Seq() != 1 {
+		t.Fatalf("tree not signed on startup: %v", err)
+	}
+	// Nodes which failed the last liveness check are left out.
+	now := time.Now()
+	crawled := make(nodeSet)
+	for i, n := range nodes {
+		crawled[n.ID()] = nodeJSON{N: n, Seq: n.Seq(), Score: 1, LastCheck: now, LastResponse: now}
+		if i == 0 {
+			crawled[n.ID()] = nodeJSON{N: n, Seq: n.Seq(), Score: 1, LastCheck: now, LastResponse: now.Add(-time.Hour)}
+		}
+	}
+	if err := pub.update(crawled); err != nil {
+		t.Fatal(err)
+	}
+	_, tree, err := loadTreeDefinitionForExport(dir)
+	if err != nil {
+		t.Fatalf("invalid tree after update: %v", err)
+	}
+	have, want := make(nodeSet), make(nodeSet)
+	have.add(tree.Nodes()...)
+	want.add(nodes[1:]...)
+	if tree.Seq() != 2 || !reflect.DeepEqual(have.nodes(), want.nodes()) {
+		t.Fatalf("wrong tree after update: seq %d, nodes %v", tree.Seq(), tree.Nodes())
+	}
+	// Unchanged node sets don't create a new version.
+	if err := pub.update(crawled); err != nil {
+		t.Fatal(err)
+	}
+	if pub.def.Meta.Seq != 2 {
+		t.Fatalf("tree updated without changes, seq %d", pub.def.Meta.Seq)
+	}
+}
+
+func TestZoneFile(t *testing.T) {
+	t.Parallel()
+
+	key := testKey(t)
+	tree, _ := signedTestTree(t, key, "nodes.example.org", testNodes(t, 1))
+
+	var b strings.Builder
+	if err := encodeZoneFile(&b, "nodes.example.org", tree); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("wrong number of lines in zone file:\n%s", b.String())
+	}
+	if lines[1] != "$ORIGIN nodes.example.org." {
+		t.Errorf("wrong origin line %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "@\t1800\tIN\tTXT\t\"enrtree-root:v1 ") {
+		t.Errorf("wrong root record %q", lines[2])
+	}
+	for _, line := range lines[3:] {
+		if strings.Contains(line, ".nodes.example.org") || !strings.Contains(line, "\t2419200\tIN\tTXT\t\"") {
+			t.Errorf("wrong record %q", line)
+		}
+	}
+}
+
+func signedTestTree(t *testing.T, key *ecdsa.PrivateKey, domain string, nodes []*enode.Node) (*dnsdisc.Tree, string) {
+	tree, err := dnsdisc.MakeTree(1, nodes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, err := tree.Sign(key, domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree, url
+}
+
+func testKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func testNodes(t *testing.T, n int) []*enode.Node {
+	nodes := make([]*enode.Node, n)
+	for i := range nodes {
+		var r enr.Record
+		r.Set(enr.IPv4{127, 0, 0, byte(i + 1)})
+		r.Set(enr.UDP(30303))
+		enode.SignV4(&r, testKey(t))
+		node, err := enode.New(enode.ValidSchemes, &r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes[i] = node
+	}
+	return nodes
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of go-rajchain.
+//
+// go-rajchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-rajchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-rajchain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rajchain/go-rajchain/p2p/dnsdisc"
+)
+
+// maxTXTStringLength is the length limit of the character strings a TXT record
+// consists of.
+const maxTXTStringLength = 255
+
+// writeZoneFile writes the TXT records of a tree to the given file in master
+// file format (RFC 1035). The output only contains the records of the tree, it
+// is meant to be included into the zone of the domain, which defines the SOA and
+// NS records.
+func writeZoneFile(file string, domain string, t *dnsdisc.Tree) error {
+	if file == "-" {
+		return encodeZoneFile(os.Stdout, domain, t)
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := encodeZoneFile(f, domain, t); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// encodeZoneFile writes the records of a tree in master file format.
+func encodeZoneFile(w io.Writer, domain string, t *dnsdisc.Tree) error {
+	var (
+		records = t.ToTXT(domain)
+		names   = make([]string, 0, len(records))
+		b       strings.Builder
+	)
+	for name := range records {
+		if name != domain {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	fmt.Fprintf(&b, "; DNS discovery tree of %s, sequence number %d\n", domain, t.Seq())
+	fmt.Fprintf(&b, "$ORIGIN %s.\n", strings.TrimSuffix(domain, "."))
+	writeZoneRecord(&b, "@", rootTTL, records[domain])
+	for _, name := range names {
+		writeZoneRecord(&b, strings.TrimSuffix(name, "."+domain), treeNodeTTL, records[name])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeZoneRecord(b *strings.Builder, name string, ttl int, value string) {
+	quoted := make([]string, 0, 2)
+	for _, s := range splitTXTStrings(value) {
+		quoted = append(quoted, strconv.Quote(s))
+	}
+	fmt.Fprintf(b, "%s\t%d\tIN\tTXT\t%s\n", name, ttl, strings.Join(quoted, " "))
+}
+
+// splitTXTStrings splits a record value into the character strings of a TXT
+// record.
+func splitTXTStrings(value string) []string {
+	var result []string
+	for len(value) > maxTXTStringLength {
+		result = append(result, value[:maxTXTStringLength])
+		value = value[maxTXTStringLength:]
+	}
+	return append(result, value)
+}
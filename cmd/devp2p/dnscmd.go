@@ -23,11 +23,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
 	"github.com/rajchain/go-rajchain/accounts/keystore"
 	"github.com/rajchain/go-rajchain/common"
 	"github.com/rajchain/go-rajchain/console/prompt"
+	"github.com/rajchain/go-rajchain/log"
 	"github.com/rajchain/go-rajchain/p2p/dnsdisc"
 	"github.com/rajchain/go-rajchain/p2p/enode"
 	"github.com/urfave/cli/v2"
@@ -41,9 +43,11 @@ var (
 			dnsSyncCommand,
 			dnsSignCommand,
 			dnsTXTCommand,
+			dnsZoneFileCommand,
 			dnsCloudflareCommand,
 			dnsRoute53Command,
 			dnsRoute53NukeCommand,
+			dnsServeCommand,
 		},
 	}
 	dnsSyncCommand = &cli.Command{
@@ -66,6 +70,12 @@ var (
 		ArgsUsage: "<tree-directory> <output-file>",
 		Action:    dnsToTXT,
 	}
+	dnsZoneFileCommand = &cli.Command{
+		Name:      "to-zonefile",
+		Usage:     "Create a DNS zone file for a discovery tree",
+		ArgsUsage: "<tree-directory> <output-file>",
+		Action:    dnsToZoneFile,
+	}
 	dnsCloudflareCommand = &cli.Command{
 		Name:      "to-cloudflare",
 		Usage:     "Deploy DNS TXT records to CloudFlare",
@@ -97,6 +107,24 @@ var (
 			route53RegionFlag,
 		},
 	}
+	dnsServeCommand = &cli.Command{
+		Name:      "serve",
+		Usage:     "Serve a DNS discovery tree as authoritative DNS server",
+		ArgsUsage: "<tree-directory> [ <key-file> ]",
+		Description: `Serves the TXT records of a discovery tree over UDP and TCP. When a key
+file is given, the network is crawled periodically and the tree is updated with
+the nodes that passed the last liveness check, re-signing it with the key.`,
+		Action: dnsServe,
+		Flags: slices.Concat(discoveryNodeFlags, []cli.Flag{
+			dnsServeAddrFlag,
+			dnsDomainFlag,
+			dnsCrawlIntervalFlag,
+			dnsNodeFilterFlag,
+			dnsZoneFileFlag,
+			crawlTimeoutFlag,
+			crawlParallelismFlag,
+		}),
+	}
 )
 
 var (
@@ -154,17 +182,10 @@ func dnsSign(ctx *cli.Context) error {
 		defdir  = ctx.Args().Get(0)
 		keyfile = ctx.Args().Get(1)
 		def     = loadTreeDefinition(defdir)
-		domain  = directoryName(defdir)
 	)
-	if def.Meta.URL != "" {
-		d, _, err := dnsdisc.ParseURL(def.Meta.URL)
-		if err != nil {
-			return fmt.Errorf("invalid 'url' field: %v", err)
-		}
-		domain = d
-	}
-	if ctx.IsSet(dnsDomainFlag.Name) {
-		domain = ctx.String(dnsDomainFlag.Name)
+	domain, err := treeDomain(ctx, defdir, def)
+	if err != nil {
+		return err
 	}
 	if ctx.IsSet(dnsSeqFlag.Name) {
 		def.Meta.Seq = ctx.Uint(dnsSeqFlag.Name)
@@ -188,6 +209,22 @@ func dnsSign(ctx *cli.Context) error {
 	return nil
 }
 
+// treeDomain returns the domain name of a tree definition. It is taken from the
+// domain flag, the URL of the tree or the directory name, in that order.
+func treeDomain(ctx *cli.Context, defdir string, def *dnsDefinition) (string, error) {
+	if ctx.IsSet(dnsDomainFlag.Name) {
+		return ctx.String(dnsDomainFlag.Name), nil
+	}
+	if def.Meta.URL != "" {
+		d, _, err := dnsdisc.ParseURL(def.Meta.URL)
+		if err != nil {
+			return "", fmt.Errorf("invalid 'url' field: %v", err)
+		}
+		return d, nil
+	}
+	return directoryName(defdir), nil
+}
+
 // directoryName returns the directory name of the given path.
 // For example, when dir is "foo/bar", it returns "bar".
 // When dir is ".", and the working directory is "example/foo", it returns "foo".
@@ -216,6 +253,22 @@ func dnsToTXT(ctx *cli.Context) error {
 	return nil
 }
 
+// dnsToZoneFile performs dnsZoneFileCommand.
+func dnsToZoneFile(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return errors.New("need tree definition directory as argument")
+	}
+	output := ctx.Args().Get(1)
+	if output == "" {
+		output = "-" // default to stdout
+	}
+	domain, t, err := loadTreeDefinitionForExport(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	return writeZoneFile(output, domain, t)
+}
+
 // dnsToCloudflare performs dnsCloudflareCommand.
 func dnsToCloudflare(ctx *cli.Context) error {
 	if ctx.NArg() != 1 {
@@ -251,6 +304,84 @@ func dnsNukeRoute53(ctx *cli.Context) error {
 	return client.deleteDomain(ctx.Args().First())
 }
 
+// dnsServe performs dnsServeCommand.
+func dnsServe(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return errors.New("need tree definition directory as argument")
+	}
+	defdir := ctx.Args().Get(0)
+	if ctx.NArg() < 2 {
+		// Without a key, the signed tree is served as is.
+		domain, t, err := loadTreeDefinitionForExport(defdir)
+		if err != nil {
+			return err
+		}
+		srv := newDNSServer(domain)
+		srv.setTree(t)
+		if err := srv.start(ctx.String(dnsServeAddrFlag.Name)); err != nil {
+			return err
+		}
+		defer srv.stop()
+		log.Info("Serving DNS discovery tree", "domain", domain, "seq", t.Seq(), "addr", srv.udp.LocalAddr())
+		select {}
+	}
+
+	def := loadTreeDefinition(defdir)
+	domain, err := treeDomain(ctx, defdir, def)
+	if err != nil {
+		return err
+	}
+	filter, limit, err := parseNodeFilterFlag(ctx.String(dnsNodeFilterFlag.Name))
+	if err != nil {
+		return fmt.Errorf("-%s: %v", dnsNodeFilterFlag.Name, err)
+	}
+	srv := newDNSServer(domain)
+	pub := &treePublisher{
+		dir:      defdir,
+		domain:   domain,
+		key:      loadSigningKey(ctx.Args().Get(1)),
+		def:      def,
+		filter:   filter,
+		limit:    limit,
+		zonefile: ctx.String(dnsZoneFileFlag.Name),
+		server:   srv,
+	}
+	if err := pub.init(); err != nil {
+		return err
+	}
+	if err := srv.start(ctx.String(dnsServeAddrFlag.Name)); err != nil {
+		return err
+	}
+	defer srv.stop()
+	log.Info("Serving DNS discovery tree", "domain", domain, "seq", pub.def.Meta.Seq, "addr", srv.udp.LocalAddr())
+
+	interval := ctx.Duration(dnsCrawlIntervalFlag.Name)
+	if interval == 0 {
+		select {}
+	}
+	disc, config := startV4(ctx)
+	defer disc.Close()
+
+	// The crawler state is kept across rounds, so nodes which were filtered
+	// out of the tree are revalidated as well.
+	crawled := make(nodeSet)
+	if _, nodesFile := treeDefinitionFiles(defdir); common.FileExist(nodesFile) {
+		crawled = loadNodesJSON(nodesFile)
+	}
+	for {
+		c, err := newCrawler(crawled, config.Bootnodes, disc, disc.RandomNodes())
+		if err != nil {
+			return err
+		}
+		c.revalidateInterval = min(interval, 10*time.Minute)
+		crawled = c.run(ctx.Duration(crawlTimeoutFlag.Name), ctx.Int(crawlParallelismFlag.Name))
+		if err := pub.update(crawled); err != nil {
+			log.Error("Failed to update DNS discovery tree", "err", err)
+		}
+		time.Sleep(interval)
+	}
+}
+
 // loadSigningKey loads a private key in rajchain keystore format.
 func loadSigningKey(keyfile string) *ecdsa.PrivateKey {
 	keyjson, err := os.ReadFile(keyfile)
@@ -0,0 +1,105 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of go-rajchain.
+//
+// go-rajchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-rajchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-rajchain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rajchain/go-rajchain/cmd/evm/internal/debugger"
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/urfave/cli/v2"
+)
+
+var debugCommand = &cli.Command{
+	Action:    debugCmd,
+	Name:      "debug",
+	Usage:     "Debugs a transaction trace in the interactive debugger",
+	ArgsUsage: "<trace.json>",
+	Description: `The debug command steps through the struct logger output of debug_traceTransaction.
+The trace does not contain the executed code, so the contracts called must be named
+with --contract, and the transaction receiver and input be given for decoding them.`,
+	Flags: []cli.Flag{
+		CombinedJSONFlag,
+		SourceDirFlag,
+		ContractFlag,
+		ReceiverFlag,
+		InputFlag,
+	},
+}
+
+func debugCmd(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		return errors.New("missing trace file")
+	}
+	data, err := os.ReadFile(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	var (
+		receiver = common.HexToAddress(ctx.String(ReceiverFlag.Name))
+		input    = common.FromHex(ctx.String(InputFlag.Name))
+	)
+	steps, err := debugger.ParseTrace(data, receiver, input)
+	if err != nil {
+		return fmt.Errorf("failed to parse trace: %v", err)
+	}
+	artifacts, err := loadArtifacts(ctx)
+	if err != nil {
+		return err
+	}
+	d := debugger.New(steps, artifacts, os.Stdout)
+	for _, binding := range ctx.StringSlice(ContractFlag.Name) {
+		addr, name, ok := strings.Cut(binding, "=")
+		if !ok || !common.IsHexAddress(addr) {
+			return fmt.Errorf("invalid contract %q, want address=Name", binding)
+		}
+		if artifacts == nil {
+			return errors.New("contracts need --combined-json")
+		}
+		contract, err := artifacts.Lookup(name)
+		if err != nil {
+			return err
+		}
+		d.Bind(common.HexToAddress(addr), contract)
+	}
+	return d.Run()
+}
+
+// loadArtifacts loads the compiled contracts given by --combined-json, if any.
+func loadArtifacts(ctx *cli.Context) (*debugger.Artifacts, error) {
+	path := ctx.String(CombinedJSONFlag.Name)
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	srcdir := ctx.String(SourceDirFlag.Name)
+	if srcdir == "" {
+		srcdir = filepath.Dir(path)
+	}
+	artifacts, err := debugger.LoadArtifacts(data, srcdir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %v", path, err)
+	}
+	return artifacts, nil
+}
@@ -0,0 +1,252 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of go-rajchain.
+//
+// go-rajchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-rajchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-rajchain. If not, see <http://www.gnu.org/licenses/>.
+
+package debugger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rajchain/go-rajchain/accounts/abi"
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/compiler"
+)
+
+// StateVariable is a contract state variable from the solc storage layout.
+type StateVariable struct {
+	Name   string
+	Type   string // Solidity type, e.g. uint256 or mapping(address => uint256)
+	Slot   common.Hash
+	Offset int // Byte offset of the variable within the slot, from the right
+	Size   int // Number of bytes the variable occupies
+	Inline bool
+}
+
+// Contract is a compiled contract with the debug information emitted by solc.
+type Contract struct {
+	Name          string
+	Code          []byte
+	RuntimeCode   []byte
+	SrcMap        []Location
+	SrcMapRuntime []Location
+	ABI           *abi.ABI        // Contract ABI, nil if not available
+	Storage       []StateVariable // State variables, nil if no storage layout available
+
+	indices        map[uint64]int
+	runtimeIndices map[uint64]int
+}
+
+// Artifacts holds the contracts and source files of a solc compilation.
+type Artifacts struct {
+	Contracts map[string]*Contract
+	Sources   []*Source // Source files by index in the source map, nil if not found
+}
+
+// combinedJSON contains the fields of the solc --combined-json output not parsed
+// by the compiler package.
+type combinedJSON struct {
+	SourceList []string `json:"sourceList"`
+	Contracts  map[string]struct {
+		StorageLayout json.RawMessage `json:"storage-layout"`
+	} `json:"contracts"`
+}
+
+// storageLayout is the solc storage layout of a contract.
+type storageLayout struct {
+	Storage []struct {
+		Label  string `json:"label"`
+		Slot   string `json:"slot"`
+		Offset int    `json:"offset"`
+		Type   string `json:"type"`
+	} `json:"storage"`
+	Types map[string]struct {
+		Encoding      string `json:"encoding"`
+		Label         string `json:"label"`
+		NumberOfBytes string `json:"numberOfBytes"`
+	} `json:"types"`
+}
+
+// LoadArtifacts parses the output of solc --combined-json, which should at least
+// include bin, bin-runtime, srcmap and srcmap-runtime, and optionally abi and
+// storage-layout. The source files are looked up relative to srcdir.
+func LoadArtifacts(data []byte, srcdir string) (*Artifacts, error) {
+	parsed, err := compiler.ParseCombinedJSON(data, "", "", "", "")
+	if err != nil {
+		return nil, err
+	}
+	var extra combinedJSON
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return nil, err
+	}
+	artifacts := &Artifacts{Contracts: make(map[string]*Contract)}
+	for name, info := range parsed {
+		contract := &Contract{
+			Name:        name,
+			Code:        common.FromHex(info.Code),
+			RuntimeCode: common.FromHex(info.RuntimeCode),
+		}
+		srcmap, _ := info.Info.SrcMap.(string)
+		if contract.SrcMap, err = ParseSourceMap(srcmap); err != nil {
+			return nil, fmt.Errorf("contract %s: invalid source map: %v", name, err)
+		}
+		if contract.SrcMapRuntime, err = ParseSourceMap(info.Info.SrcMapRuntime); err != nil {
+			return nil, fmt.Errorf("contract %s: invalid runtime source map: %v", name, err)
+		}
+		if info.Info.AbiDefinition != nil {
+			blob, err := json.Marshal(info.Info.AbiDefinition)
+			if err != nil {
+				return nil, err
+			}
+			parsed, err := abi.JSON(bytes.NewReader(blob))
+			if err != nil {
+				return nil, fmt.Errorf("contract %s: invalid ABI: %v", name, err)
+			}
+			contract.ABI = &parsed
+		}
+		if layout := extra.Contracts[name].StorageLayout; len(layout) > 0 {
+			if contract.Storage, err = parseStorageLayout(layout); err != nil {
+				return nil, fmt.Errorf("contract %s: invalid storage layout: %v", name, err)
+			}
+		}
+		contract.indices = instructionIndices(contract.Code)
+		contract.runtimeIndices = instructionIndices(contract.RuntimeCode)
+		artifacts.Contracts[name] = contract
+	}
+	for _, name := range extra.SourceList {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(srcdir, path)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			artifacts.Sources = append(artifacts.Sources, nil)
+			continue
+		}
+		artifacts.Sources = append(artifacts.Sources, NewSource(name, string(content)))
+	}
+	return artifacts, nil
+}
+
+// parseStorageLayout parses the storage layout of a contract, which older solc
+// versions emit as a JSON encoded string.
+func parseStorageLayout(data json.RawMessage) ([]StateVariable, error) {
+	var encoded string
+	if err := json.Unmarshal(data, &encoded); err == nil {
+		data = json.RawMessage(encoded)
+	}
+	var layout storageLayout
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return nil, err
+	}
+	vars := make([]StateVariable, 0, len(layout.Storage))
+	for _, item := range layout.Storage {
+		slot, ok := parseSlot(item.Slot)
+		if !ok {
+			return nil, fmt.Errorf("variable %s: invalid slot %q", item.Label, item.Slot)
+		}
+		typ := layout.Types[item.Type]
+		size, _ := strconv.Atoi(typ.NumberOfBytes)
+		vars = append(vars, StateVariable{
+			Name:   item.Label,
+			Type:   typ.Label,
+			Slot:   slot,
+			Offset: item.Offset,
+			Size:   size,
+			Inline: typ.Encoding == "inplace" && size <= 32,
+		})
+	}
+	return vars, nil
+}
+
+// parseSlot parses a decimal slot number into a storage key.
+func parseSlot(s string) (common.Hash, bool) {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok || n.Sign() < 0 || n.BitLen() > 256 {
+		return common.Hash{}, false
+	}
+	return common.BigToHash(n), true
+}
+
+// Names returns the sorted names of the contracts.
+func (a *Artifacts) Names() []string {
+	names := make([]string, 0, len(a.Contracts))
+	for name := range a.Contracts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the contract with the given name, which may be either fully
+// qualified (path:Name) or the bare contract name if unambiguous.
+func (a *Artifacts) Lookup(name string) (*Contract, error) {
+	if contract, ok := a.Contracts[name]; ok {
+		return contract, nil
+	}
+	var found *Contract
+	for _, full := range a.Names() {
+		if strings.HasSuffix(full, ":"+name) {
+			if found != nil {
+				return nil, fmt.Errorf("ambiguous contract name %q", name)
+			}
+			found = a.Contracts[full]
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("unknown contract %q", name)
+	}
+	return found, nil
+}
+
+// Match finds the contract whose code is executed. Contract creations match
+// the creation code, which the constructor arguments are appended to, calls
+// the runtime code.
+func (a *Artifacts) Match(code []byte, create bool) *Contract {
+	if len(code) == 0 {
+		return nil
+	}
+	for _, name := range a.Names() {
+		contract := a.Contracts[name]
+		if create {
+			if len(contract.Code) > 0 && bytes.HasPrefix(code, contract.Code) {
+				return contract
+			}
+		} else if bytes.Equal(code, contract.RuntimeCode) {
+			return contract
+		}
+	}
+	return nil
+}
+
+// location returns the source location of the instruction at pc.
+func (c *Contract) location(pc uint64, create bool) (Location, bool) {
+	locs, indices := c.SrcMapRuntime, c.runtimeIndices
+	if create {
+		locs, indices = c.SrcMap, c.indices
+	}
+	i, ok := indices[pc]
+	if !ok || i >= len(locs) {
+		return Location{}, false
+	}
+	return locs[i], true
+}
@@ -0,0 +1,459 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of go-rajchain.
+//
+// go-rajchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-rajchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-rajchain. If not, see <http://www.gnu.org/licenses/>.
+
+// Package debugger implements a source level debugger for recorded EVM
+// executions of contracts compiled by solc.
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/console/prompt"
+)
+
+// errInvalidArgs is returned for commands invoked with malformed arguments.
+var errInvalidArgs = errors.New("invalid arguments, see help")
+
+// position is a line in a source file.
+type position struct {
+	source *Source
+	line   int
+}
+
+// Debugger steps back and forth through the steps of a recorded execution.
+type Debugger struct {
+	steps     []*Step
+	artifacts *Artifacts
+	bindings  map[common.Address]*Contract
+	out       io.Writer
+
+	cur         int        // Index of the current step
+	breakpoints []position // Breakpoints in order of creation
+	last        string     // Last command executed, repeated on empty input
+}
+
+// New creates a debugger over the recorded steps, printing to out. Artifacts
+// may be nil, in which case the execution is debugged on the opcode level.
+func New(steps []*Step, artifacts *Artifacts, out io.Writer) *Debugger {
+	if artifacts == nil {
+		artifacts = &Artifacts{Contracts: make(map[string]*Contract)}
+	}
+	return &Debugger{
+		steps:     steps,
+		artifacts: artifacts,
+		bindings:  make(map[common.Address]*Contract),
+		out:       out,
+	}
+}
+
+// Bind associates the code at an address with a contract. This is needed for
+// attached traces, which do not contain the executed code.
+func (d *Debugger) Bind(addr common.Address, contract *Contract) {
+	d.bindings[addr] = contract
+}
+
+// Run executes commands read from the user until the debugger is quit.
+func (d *Debugger) Run() error {
+	fmt.Fprintf(d.out, "Recorded %d steps, type 'help' for the available commands\n", len(d.steps))
+	d.printStep()
+	for {
+		line, err := prompt.Stdin.PromptInput("(debug) ")
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		quit, err := d.Exec(line)
+		if err != nil {
+			fmt.Fprintf(d.out, "Error: %v\n", err)
+		}
+		if quit {
+			return nil
+		}
+	}
+}
+
+// Exec executes a single debugger command, returning whether the debugger
+// should be quit. An empty command repeats the last one.
+func (d *Debugger) Exec(line string) (bool, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		line = d.last
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false, nil
+	}
+	d.last = line
+	if len(d.steps) == 0 && fields[0] != "help" && fields[0] != "quit" && fields[0] != "q" {
+		return false, errors.New("no steps recorded")
+	}
+	cmd, args := fields[0], fields[1:]
+	switch cmd {
+	case "step", "s":
+		n, err := count(args)
+		if err != nil {
+			return false, err
+		}
+		d.seek(d.cur + n)
+	case "back", "b":
+		n, err := count(args)
+		if err != nil {
+			return false, err
+		}
+		d.seek(d.cur - n)
+	case "next", "n":
+		d.next()
+	case "continue", "c":
+		d.cont()
+	case "break":
+		return false, d.addBreakpoint(args)
+	case "delete":
+		return false, d.deleteBreakpoint(args)
+	case "breakpoints":
+		for i, bp := range d.breakpoints {
+			fmt.Fprintf(d.out, "%d: %s:%d\n", i, bp.source.Name, bp.line)
+		}
+	case "where", "w":
+		d.printStep()
+	case "list", "l":
+		d.printSource()
+	case "stack":
+		d.printStack()
+	case "memory":
+		d.printMemory()
+	case "storage":
+		d.printStorage()
+	case "locals":
+		d.printLocals()
+	case "help", "h":
+		fmt.Fprint(d.out, usage)
+	case "quit", "q":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown command %q, see help", cmd)
+	}
+	return false, nil
+}
+
+const usage = `step [n]         execute n instructions (default 1)
+back [n]         go back n instructions (default 1)
+next             run to the next source line, stepping over calls
+continue         run to the next breakpoint or the end of the execution
+break file:line  set a breakpoint on a source line
+delete [n]       delete breakpoint n, or all breakpoints
+breakpoints      list the breakpoints
+where            show the current instruction and source line
+list             show the source around the current line
+stack            show the stack
+memory           show the memory
+storage          show the storage slots accessed so far
+locals           show the function arguments and state variables
+quit             exit the debugger
+`
+
+// count parses the optional repeat count of a command.
+func count(args []string) (int, error) {
+	if len(args) == 0 {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, errInvalidArgs
+	}
+	return n, nil
+}
+
+// seek moves to the given step, clamped to the recorded ones, and prints it.
+func (d *Debugger) seek(i int) {
+	d.cur = max(0, min(i, len(d.steps)-1))
+	d.printStep()
+}
+
+// next runs until a different source line is reached in the current or a
+// calling frame.
+func (d *Debugger) next() {
+	var (
+		depth   = d.steps[d.cur].Depth
+		from, _ = d.position(d.cur)
+	)
+	for i := d.cur + 1; i < len(d.steps); i++ {
+		if d.steps[i].Depth > depth {
+			continue
+		}
+		if pos, ok := d.position(i); ok && pos != from {
+			d.seek(i)
+			return
+		}
+	}
+	d.seek(len(d.steps) - 1)
+}
+
+// cont runs until a breakpoint is hit. Breakpoints are hit when execution
+// enters their line, so the line of the current step does not stop again.
+func (d *Debugger) cont() {
+	from, _ := d.position(d.cur)
+	for i := d.cur + 1; i < len(d.steps); i++ {
+		pos, ok := d.position(i)
+		if !ok || pos == from {
+			continue
+		}
+		from = pos
+		for n, bp := range d.breakpoints {
+			if bp == pos {
+				fmt.Fprintf(d.out, "Breakpoint %d at %s:%d\n", n, pos.source.Name, pos.line)
+				d.seek(i)
+				return
+			}
+		}
+	}
+	fmt.Fprintln(d.out, "Execution finished")
+	d.seek(len(d.steps) - 1)
+}
+
+// addBreakpoint sets a breakpoint on a line of a source file, which may be
+// identified by a suffix of its path.
+func (d *Debugger) addBreakpoint(args []string) error {
+	if len(args) != 1 {
+		return errInvalidArgs
+	}
+	idx := strings.LastIndex(args[0], ":")
+	if idx < 0 {
+		return errInvalidArgs
+	}
+	line, err := strconv.Atoi(args[0][idx+1:])
+	if err != nil || line < 1 {
+		return errInvalidArgs
+	}
+	file := filepath.ToSlash(args[0][:idx])
+
+	var found *Source
+	for _, src := range d.artifacts.Sources {
+		if src == nil {
+			continue
+		}
+		if name := filepath.ToSlash(src.Name); name == file || strings.HasSuffix(name, "/"+file) {
+			if found != nil {
+				return fmt.Errorf("ambiguous source file %q", file)
+			}
+			found = src
+		}
+	}
+	if found == nil {
+		return fmt.Errorf("unknown source file %q", file)
+	}
+	if line > found.Lines() {
+		return fmt.Errorf("%s has only %d lines", found.Name, found.Lines())
+	}
+	d.breakpoints = append(d.breakpoints, position{found, line})
+	fmt.Fprintf(d.out, "Breakpoint %d at %s:%d\n", len(d.breakpoints)-1, found.Name, line)
+	return nil
+}
+
+// deleteBreakpoint deletes a breakpoint by index, or all without arguments.
+func (d *Debugger) deleteBreakpoint(args []string) error {
+	if len(args) == 0 {
+		d.breakpoints = nil
+		return nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 0 || n >= len(d.breakpoints) {
+		return errInvalidArgs
+	}
+	d.breakpoints = append(d.breakpoints[:n], d.breakpoints[n+1:]...)
+	return nil
+}
+
+// contract returns the compiled contract executing in a frame, if known.
+func (d *Debugger) contract(frame *Frame) *Contract {
+	if !frame.resolved {
+		frame.resolved = true
+		if contract, ok := d.bindings[frame.CodeAddress]; ok && !frame.Create {
+			frame.contract = contract
+		} else {
+			frame.contract = d.artifacts.Match(frame.Code, frame.Create)
+		}
+	}
+	return frame.contract
+}
+
+// location returns the source location of a step, if known.
+func (d *Debugger) location(i int) (Location, *Source, bool) {
+	step := d.steps[i]
+	contract := d.contract(step.Frame)
+	if contract == nil {
+		return Location{}, nil, false
+	}
+	loc, ok := contract.location(step.PC, step.Frame.Create)
+	if !ok || loc.File < 0 || loc.File >= len(d.artifacts.Sources) || d.artifacts.Sources[loc.File] == nil {
+		return loc, nil, false
+	}
+	return loc, d.artifacts.Sources[loc.File], true
+}
+
+// position returns the source line of a step, if known.
+func (d *Debugger) position(i int) (position, bool) {
+	loc, src, ok := d.location(i)
+	if !ok {
+		return position{}, false
+	}
+	line, _ := src.Position(loc.Start)
+	return position{src, line}, true
+}
+
+func (d *Debugger) printStep() {
+	if len(d.steps) == 0 {
+		return
+	}
+	step := d.steps[d.cur]
+	fmt.Fprintf(d.out, "[%d/%d] depth %d  pc %d  %v  gas %d  cost %d", d.cur+1, len(d.steps), step.Depth, step.PC, step.Op, step.Gas, step.Cost)
+	if step.Err != nil {
+		fmt.Fprintf(d.out, "  error: %v", step.Err)
+	}
+	fmt.Fprintln(d.out)
+
+	loc, src, ok := d.location(d.cur)
+	switch {
+	case ok:
+		line, col := src.Position(loc.Start)
+		fmt.Fprintf(d.out, "%s:%d:%d  %s\n", src.Name, line, col, strings.TrimSpace(src.Line(line)))
+	case d.contract(step.Frame) != nil:
+		fmt.Fprintf(d.out, "%s: no source location\n", d.contract(step.Frame).Name)
+	}
+}
+
+func (d *Debugger) printSource() {
+	loc, src, ok := d.location(d.cur)
+	if !ok {
+		fmt.Fprintln(d.out, "No source location")
+		return
+	}
+	line, _ := src.Position(loc.Start)
+	end, _ := src.Position(loc.Start + max(loc.Length-1, 0))
+	for n := max(1, line-5); n <= min(src.Lines(), end+5); n++ {
+		marker := "  "
+		if n >= line && n <= end {
+			marker = "=>"
+		}
+		fmt.Fprintf(d.out, "%s %4d  %s\n", marker, n, src.Line(n))
+	}
+}
+
+func (d *Debugger) printStack() {
+	stack := d.steps[d.cur].Stack
+	for i := len(stack) - 1; i >= 0; i-- {
+		fmt.Fprintf(d.out, "%4d  %#x\n", len(stack)-1-i, stack[i].Bytes32())
+	}
+}
+
+func (d *Debugger) printMemory() {
+	memory := d.steps[d.cur].Memory
+	for i := 0; i < len(memory); i += 32 {
+		fmt.Fprintf(d.out, "%#06x  %x\n", i, memory[i:min(i+32, len(memory))])
+	}
+}
+
+func (d *Debugger) printStorage() {
+	storage := d.steps[d.cur].Storage
+	slots := make([]common.Hash, 0, len(storage))
+	for slot := range storage {
+		slots = append(slots, slot)
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Cmp(slots[j]) < 0 })
+	for _, slot := range slots {
+		fmt.Fprintf(d.out, "%x: %x\n", slot, storage[slot])
+	}
+}
+
+// printLocals shows the arguments of the called function, decoded from the
+// call data by the contract ABI, and the values of the state variables stored
+// in the slots accessed so far. Solidity keeps the local variables on the
+// stack without debug information on their positions, so they can not be
+// recovered.
+func (d *Debugger) printLocals() {
+	var (
+		step     = d.steps[d.cur]
+		contract = d.contract(step.Frame)
+	)
+	if contract == nil {
+		fmt.Fprintln(d.out, "Unknown contract")
+		return
+	}
+	if contract.ABI != nil && !step.Frame.Create && len(step.Frame.Input) >= 4 {
+		if method, err := contract.ABI.MethodById(step.Frame.Input[:4]); err == nil {
+			fmt.Fprintf(d.out, "function %s\n", method.Sig)
+			values, err := method.Inputs.Unpack(step.Frame.Input[4:])
+			if err != nil {
+				fmt.Fprintf(d.out, "  failed to decode arguments: %v\n", err)
+			}
+			for i, value := range values {
+				name := method.Inputs[i].Name
+				if name == "" {
+					name = fmt.Sprintf("arg%d", i)
+				}
+				fmt.Fprintf(d.out, "  %s %s = %v\n", method.Inputs[i].Type, name, value)
+			}
+		}
+	}
+	if contract.Storage != nil {
+		fmt.Fprintln(d.out, "state")
+	}
+	for _, v := range contract.Storage {
+		value, ok := step.Storage[v.Slot]
+		switch {
+		case !v.Inline:
+			fmt.Fprintf(d.out, "  %s %s = <slot %d>\n", v.Type, v.Name, v.Slot.Big())
+		case !ok:
+			fmt.Fprintf(d.out, "  %s %s = <not accessed>\n", v.Type, v.Name)
+		default:
+			fmt.Fprintf(d.out, "  %s %s = %s\n", v.Type, v.Name, formatValue(v, value))
+		}
+	}
+}
+
+// formatValue extracts a state variable from its slot and formats it by type.
+func formatValue(v StateVariable, slot common.Hash) string {
+	end := common.HashLength - v.Offset
+	if v.Size <= 0 || end-v.Size < 0 || end > common.HashLength {
+		return fmt.Sprintf("%#x", slot)
+	}
+	data := slot[end-v.Size : end]
+	switch {
+	case v.Type == "bool":
+		return strconv.FormatBool(data[len(data)-1] != 0)
+	case v.Type == "address" || v.Type == "address payable" || strings.HasPrefix(v.Type, "contract "):
+		return common.BytesToAddress(data).Hex()
+	case strings.HasPrefix(v.Type, "uint"), strings.HasPrefix(v.Type, "enum "):
+		return new(big.Int).SetBytes(data).String()
+	case strings.HasPrefix(v.Type, "int"):
+		n := new(big.Int).SetBytes(data)
+		if data[0]&0x80 != 0 {
+			n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(8*len(data))))
+		}
+		return n.String()
+	default:
+		return fmt.Sprintf("%#x", data)
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of go-rajchain.
+//
+// go-rajchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-rajchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-rajchain. If not, see <http://www.gnu.org/licenses/>.
+
+package debugger
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/core/vm"
+	"github.com/rajchain/go-rajchain/core/vm/runtime"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/eth/tracers/logger"
+)
+
+func TestParseSourceMap(t *testing.T) {
+	locs, err := ParseSourceMap("1:2:1;:9;2:1:2;;5::-1:o:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Location{
+		{Start: 1, Length: 2, File: 1, Jump: '-'},
+		{Start: 1, Length: 9, File: 1, Jump: '-'},
+		{Start: 2, Length: 1, File: 2, Jump: '-'},
+		{Start: 2, Length: 1, File: 2, Jump: '-'},
+		{Start: 5, Length: 1, File: -1, Jump: 'o'},
+	}
+	if !reflect.DeepEqual(locs, want) {
+		t.Fatalf("wrong locations:\nhave %+v\nwant %+v", locs, want)
+	}
+	for _, invalid := range []string{"a:1:0", "1:1:0:x"} {
+		if _, err := ParseSourceMap(invalid); err == nil {
+			t.Errorf("no error for invalid source map %q", invalid)
+		}
+	}
+}
+
+// testSource is the Solidity source the test bytecode pretends to be compiled
+// from, with the runtime code
+//
+//	PUSH1 1, PUSH1 2, ADD, PUSH1 0, SSTORE, STOP
+//
+// storing 1+2 into x.
+const testSource = `contract C {
+  uint256 x;
+  function f() public {
+    uint256 y = 1 + 2;
+    x = y;
+  }
+}
+`
+
+var testCode = common.FromHex("600160020160005500")
+
+// loadTestArtifacts writes the test contract as solc combined-json output with
+// the source into a temporary directory and loads it.
+func loadTestArtifacts(t *testing.T) *Artifacts {
+	t.Helper()
+
+	loc := func(s string) string {
+		return fmt.Sprintf("%d:%d:0", strings.Index(testSource, s), len(s))
+	}
+	var (
+		srcmap = strings.Join([]string{loc("1 + 2"), "", "", loc("x = y"), "", loc("}\n}")}, ";")
+		layout = `{"storage":[{"label":"x","offset":0,"slot":"0","type":"t_uint256"}],"types":{"t_uint256":{"encoding":"inplace","label":"uint256","numberOfBytes":"32"}}}`
+		abi    = `[{"inputs":[],"name":"f","outputs":[],"stateMutability":"nonpayable","type":"function"}]`
+		output = fmt.Sprintf(`{"contracts":{"C.sol:C":{"abi":%s,"bin":"%x","bin-runtime":"%x","srcmap":"","srcmap-runtime":"%s","storage-layout":%s}},"sourceList":["C.sol"],"version":"0.8.26"}`,
+			abi, testCode, testCode, srcmap, layout)
+	)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "C.sol"), []byte(testSource), 0600); err != nil {
+		t.Fatal(err)
+	}
+	artifacts, err := LoadArtifacts([]byte(output), dir)
+	if err != nil {
+		t.Fatalf("failed to load artifacts: %v", err)
+	}
+	return artifacts
+}
+
+// exec runs a debugger command and returns its output.
+func exec(t *testing.T, d *Debugger, out *bytes.Buffer, cmd string) string {
+	t.Helper()
+
+	out.Reset()
+	if _, err := d.Exec(cmd); err != nil {
+		t.Fatalf("command %q failed: %v", cmd, err)
+	}
+	return out.String()
+}
+
+func testDebugger(t *testing.T, d *Debugger, out *bytes.Buffer) {
+	if res := exec(t, d, out, "where"); !strings.Contains(res, "C.sol:4:17  uint256 y = 1 + 2;") {
+		t.Errorf("wrong initial location:\n%s", res)
+	}
+	exec(t, d, out, "break C.sol:5")
+	if res := exec(t, d, out, "continue"); !strings.Contains(res, "pc 5  PUSH1") || !strings.Contains(res, "C.sol:5:5  x = y;") {
+		t.Errorf("continue did not stop at breakpoint:\n%s", res)
+	}
+	if res := exec(t, d, out, "stack"); !strings.Contains(res, "0x0000000000000000000000000000000000000000000000000000000000000003") {
+		t.Errorf("wrong stack:\n%s", res)
+	}
+	if res := exec(t, d, out, "next"); !strings.Contains(res, "STOP") || !strings.Contains(res, "C.sol:6:3") {
+		t.Errorf("next did not stop at the next line:\n%s", res)
+	}
+	if res := exec(t, d, out, "locals"); !strings.Contains(res, "function f()") || !strings.Contains(res, "uint256 x = 3") {
+		t.Errorf("wrong locals:\n%s", res)
+	}
+	if res := exec(t, d, out, "back 5"); !strings.Contains(res, "[1/6]") {
+		t.Errorf("back did not return to the first step:\n%s", res)
+	}
+	if res := exec(t, d, out, "locals"); !strings.Contains(res, "uint256 x = <not accessed>") {
+		t.Errorf("wrong locals before the store:\n%s", res)
+	}
+	exec(t, d, out, "delete")
+	if res := exec(t, d, out, "continue"); !strings.Contains(res, "Execution finished") {
+		t.Errorf("continue without breakpoints did not run to the end:\n%s", res)
+	}
+}
+
+// Tests debugging an execution recorded by the tracer.
+func TestDebugRecorded(t *testing.T) {
+	var (
+		recorder = NewRecorder()
+		input    = crypto.Keccak256([]byte("f()"))[:4]
+	)
+	_, _, err := runtime.Execute(testCode, input, &runtime.Config{
+		EVMConfig: vm.Config{Tracer: recorder.Hooks()},
+	})
+	if err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	out := new(bytes.Buffer)
+	testDebugger(t, New(recorder.Steps(), loadTestArtifacts(t), out), out)
+}
+
+// Tests debugging an execution attached to from the struct logger output.
+func TestDebugAttached(t *testing.T) {
+	var (
+		tracer = logger.NewStructLogger(&logger.Config{EnableMemory: true})
+		input  = crypto.Keccak256([]byte("f()"))[:4]
+		addr   = common.BytesToAddress([]byte("contract"))
+	)
+	_, _, err := runtime.Execute(testCode, input, &runtime.Config{
+		EVMConfig: vm.Config{Tracer: tracer.Hooks()},
+	})
+	if err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	result, err := tracer.GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, err := ParseTrace([]byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"result":%s}`, result)), addr, input)
+	if err != nil {
+		t.Fatalf("failed to parse trace: %v", err)
+	}
+	artifacts := loadTestArtifacts(t)
+	out := new(bytes.Buffer)
+	d := New(steps, artifacts, out)
+	d.Bind(addr, artifacts.Contracts["C.sol:C"])
+	testDebugger(t, d, out)
+}
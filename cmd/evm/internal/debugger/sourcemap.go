@@ -0,0 +1,139 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of go-rajchain.
+//
+// go-rajchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-rajchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-rajchain. If not, see <http://www.gnu.org/licenses/>.
+
+package debugger
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rajchain/go-rajchain/core/vm"
+)
+
+// Location is the source range an instruction was generated from, as encoded
+// in the solc source maps.
+type Location struct {
+	Start  int  // Byte offset of the range in the source file
+	Length int  // Byte length of the range
+	File   int  // Index of the source file in the source list, -1 if generated
+	Jump   byte // 'i' for jumps into functions, 'o' for returns, '-' otherwise
+}
+
+// ParseSourceMap decompresses a solc source map into the locations of the
+// instructions of the code. Entries are separated by ';' and hold the fields
+// s:l:f:j:m, any of which may be left empty or omitted to repeat the value of
+// the previous entry.
+func ParseSourceMap(srcmap string) ([]Location, error) {
+	if srcmap == "" {
+		return nil, nil
+	}
+	var (
+		entries = strings.Split(srcmap, ";")
+		locs    = make([]Location, len(entries))
+		prev    = Location{File: -1, Jump: '-'}
+	)
+	for i, entry := range entries {
+		loc := prev
+		for j, field := range strings.Split(entry, ":") {
+			if field == "" {
+				continue
+			}
+			switch j {
+			case 0, 1, 2:
+				n, err := strconv.Atoi(field)
+				if err != nil {
+					return nil, fmt.Errorf("entry %d: invalid field %q", i, field)
+				}
+				switch j {
+				case 0:
+					loc.Start = n
+				case 1:
+					loc.Length = n
+				case 2:
+					loc.File = n
+				}
+			case 3:
+				if len(field) != 1 || !strings.Contains("io-", field) {
+					return nil, fmt.Errorf("entry %d: invalid jump type %q", i, field)
+				}
+				loc.Jump = field[0]
+			}
+			// The modifier depth and any later fields are not needed
+		}
+		locs[i] = loc
+		prev = loc
+	}
+	return locs, nil
+}
+
+// instructionIndices maps the offsets of the instructions in the code to their
+// index, which is what source map entries are numbered by.
+func instructionIndices(code []byte) map[uint64]int {
+	indices := make(map[uint64]int)
+	for pc, i := uint64(0), 0; pc < uint64(len(code)); i++ {
+		indices[pc] = i
+
+		op := vm.OpCode(code[pc])
+		pc++
+		if op.IsPush() {
+			pc += uint64(op - vm.PUSH0)
+		}
+	}
+	return indices
+}
+
+// Source is a source file referenced by the source maps.
+type Source struct {
+	Name    string
+	Content string
+	lines   []int // Offsets of the line starts
+}
+
+// NewSource creates a source file with the given name and content.
+func NewSource(name, content string) *Source {
+	lines := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	return &Source{Name: name, Content: content, lines: lines}
+}
+
+// Position returns the 1-based line and column of a byte offset.
+func (s *Source) Position(offset int) (int, int) {
+	line := sort.Search(len(s.lines), func(i int) bool { return s.lines[i] > offset })
+	return line, offset - s.lines[line-1] + 1
+}
+
+// Lines returns the number of lines of the source.
+func (s *Source) Lines() int {
+	return len(s.lines)
+}
+
+// Line returns the content of a 1-based line, without the line break.
+func (s *Source) Line(n int) string {
+	if n < 1 || n > len(s.lines) {
+		return ""
+	}
+	end := len(s.Content)
+	if n < len(s.lines) {
+		end = s.lines[n] - 1
+	}
+	return strings.TrimSuffix(s.Content[s.lines[n-1]:end], "\r")
+}
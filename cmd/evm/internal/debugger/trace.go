@@ -0,0 +1,285 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of go-rajchain.
+//
+// go-rajchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-rajchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-rajchain. If not, see <http://www.gnu.org/licenses/>.
+
+package debugger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+
+	"github.com/holiman/uint256"
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/core/tracing"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/core/vm"
+	"github.com/rajchain/go-rajchain/eth/tracers/logger"
+)
+
+// Frame is a call frame of the debugged execution.
+type Frame struct {
+	Address     common.Address // Address whose storage the frame operates on
+	CodeAddress common.Address // Address the executed code was loaded from
+	Code        []byte         // Executed code, nil if not known (attached traces)
+	Input       []byte         // Call data, or the init code of contract creations
+	Create      bool           // Whether the frame is a contract creation
+	Parent      *Frame         // Calling frame, nil for the outermost frame
+
+	contract *Contract      // Compiled contract matched to the code, resolved lazily
+	indices  map[uint64]int // Instruction indices of the code, resolved lazily
+	resolved bool
+}
+
+// Step is a snapshot of the machine state before an instruction is executed.
+type Step struct {
+	PC      uint64
+	Op      vm.OpCode
+	Gas     uint64
+	Cost    uint64
+	Depth   int
+	Stack   []uint256.Int
+	Memory  []byte
+	Storage map[common.Hash]common.Hash // Storage slots of the frame seen so far, shared between steps
+	Frame   *Frame
+	Err     error
+}
+
+// Recorder is an EVM tracer recording the steps of an execution for debugging
+// it afterwards.
+type Recorder struct {
+	env     *tracing.VMContext
+	steps   []*Step
+	frames  []*Frame
+	storage map[common.Address]map[common.Hash]common.Hash
+	pending *Frame // Frame entered, but not yet executing
+}
+
+// NewRecorder creates a tracer recording the steps of an execution.
+func NewRecorder() *Recorder {
+	return &Recorder{storage: make(map[common.Address]map[common.Hash]common.Hash)}
+}
+
+// Hooks returns the tracing hooks of the recorder.
+func (r *Recorder) Hooks() *tracing.Hooks {
+	return &tracing.Hooks{
+		OnTxStart: r.OnTxStart,
+		OnEnter:   r.OnEnter,
+		OnExit:    r.OnExit,
+		OnOpcode:  r.OnOpcode,
+	}
+}
+
+// Steps returns the recorded steps.
+func (r *Recorder) Steps() []*Step {
+	return r.steps
+}
+
+func (r *Recorder) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	r.env = env
+}
+
+func (r *Recorder) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	op := vm.OpCode(typ)
+	frame := &Frame{
+		CodeAddress: to,
+		Input:       common.CopyBytes(input),
+		Create:      op == vm.CREATE || op == vm.CREATE2,
+	}
+	if len(r.frames) > 0 {
+		frame.Parent = r.frames[len(r.frames)-1]
+	}
+	if frame.Create {
+		frame.Code = frame.Input
+	}
+	r.frames = append(r.frames, frame)
+}
+
+func (r *Recorder) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if len(r.frames) > 0 {
+		r.frames = r.frames[:len(r.frames)-1]
+	}
+}
+
+func (r *Recorder) OnOpcode(pc uint64, opcode byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if len(r.frames) == 0 {
+		return
+	}
+	var (
+		op    = vm.OpCode(opcode)
+		frame = r.frames[len(r.frames)-1]
+		stack = scope.StackData()
+	)
+	if frame.Code == nil {
+		frame.Address = scope.Address()
+		frame.Code = common.CopyBytes(scope.ContractCode())
+	}
+	// Track the storage accesses the same way the struct logger does, replacing
+	// the storage map on modification so earlier steps keep their view.
+	if (op == vm.SLOAD && len(stack) >= 1) || (op == vm.SSTORE && len(stack) >= 2) {
+		var (
+			slot  = common.Hash(stack[len(stack)-1].Bytes32())
+			value common.Hash
+		)
+		if op == vm.SLOAD {
+			value = r.env.StateDB.GetState(frame.Address, slot)
+		} else {
+			value = common.Hash(stack[len(stack)-2].Bytes32())
+		}
+		storage := maps.Clone(r.storage[frame.Address])
+		if storage == nil {
+			storage = make(map[common.Hash]common.Hash)
+		}
+		storage[slot] = value
+		r.storage[frame.Address] = storage
+	}
+	r.steps = append(r.steps, &Step{
+		PC:      pc,
+		Op:      op,
+		Gas:     gas,
+		Cost:    cost,
+		Depth:   depth,
+		Stack:   append([]uint256.Int(nil), stack...),
+		Memory:  common.CopyBytes(scope.MemoryData()),
+		Storage: r.storage[frame.Address],
+		Frame:   frame,
+		Err:     err,
+	})
+}
+
+// ParseTrace reconstructs the steps of an execution from the output of the
+// struct logger, as returned by debug_traceTransaction. Both the bare trace and
+// a full JSON-RPC response are accepted.
+//
+// The trace does not contain the addresses and code of the call frames. They
+// are recovered from the stack and memory of the calling instructions where
+// possible, the outermost frame being given by to and input.
+func ParseTrace(data []byte, to common.Address, input []byte) ([]*Step, error) {
+	var response struct {
+		Result *logger.ExecutionResult `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+	if response.Error != nil {
+		return nil, errors.New(response.Error.Message)
+	}
+	result := response.Result
+	if result == nil {
+		result = new(logger.ExecutionResult)
+		if err := json.Unmarshal(data, result); err != nil {
+			return nil, err
+		}
+	}
+	var (
+		steps   = make([]*Step, 0, len(result.StructLogs))
+		frame   = &Frame{Address: to, CodeAddress: to, Input: input}
+		storage = make(map[common.Address]map[common.Hash]common.Hash)
+	)
+	for i, log := range result.StructLogs {
+		step := &Step{
+			PC:    log.Pc,
+			Op:    vm.StringToOp(log.Op),
+			Gas:   log.Gas,
+			Cost:  log.GasCost,
+			Depth: log.Depth,
+		}
+		if log.Error != "" {
+			step.Err = errors.New(log.Error)
+		}
+		if log.Stack != nil {
+			step.Stack = make([]uint256.Int, len(*log.Stack))
+			for j, item := range *log.Stack {
+				b := common.FromHex(item)
+				if len(b) > 32 {
+					return nil, fmt.Errorf("step %d: invalid stack item %q", i, item)
+				}
+				step.Stack[j].SetBytes(b)
+			}
+		}
+		if log.Memory != nil {
+			for _, word := range *log.Memory {
+				step.Memory = append(step.Memory, common.FromHex(word)...)
+			}
+		}
+		// Follow the call frames by the depth changes
+		if i > 0 {
+			prev := steps[i-1]
+			switch {
+			case step.Depth > prev.Depth:
+				frame = enterFrame(prev, frame)
+			case step.Depth < prev.Depth:
+				for d := prev.Depth; d > step.Depth && frame.Parent != nil; d-- {
+					frame = frame.Parent
+				}
+			}
+		}
+		if log.Storage != nil {
+			slots := make(map[common.Hash]common.Hash, len(*log.Storage))
+			for k, v := range *log.Storage {
+				slots[common.HexToHash(k)] = common.HexToHash(v)
+			}
+			storage[frame.Address] = slots
+		}
+		step.Frame = frame
+		step.Storage = storage[frame.Address]
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// enterFrame creates the frame entered by the instruction of the given step,
+// recovering its addresses and input from the stack and memory of the caller.
+func enterFrame(call *Step, parent *Frame) *Frame {
+	frame := &Frame{Parent: parent}
+
+	peek := func(n int) *uint256.Int {
+		if n >= len(call.Stack) {
+			return new(uint256.Int)
+		}
+		return &call.Stack[len(call.Stack)-1-n]
+	}
+	memory := func(offset, size *uint256.Int) []byte {
+		if !offset.IsUint64() || !size.IsUint64() || offset.Uint64()+size.Uint64() > uint64(len(call.Memory)) {
+			return nil
+		}
+		return common.CopyBytes(call.Memory[offset.Uint64() : offset.Uint64()+size.Uint64()])
+	}
+	switch call.Op {
+	case vm.CALL, vm.CALLCODE:
+		frame.CodeAddress = common.Address(peek(1).Bytes20())
+		frame.Input = memory(peek(3), peek(4))
+	case vm.DELEGATECALL, vm.STATICCALL:
+		frame.CodeAddress = common.Address(peek(1).Bytes20())
+		frame.Input = memory(peek(2), peek(3))
+	case vm.CREATE, vm.CREATE2:
+		// The address of the created contract is not known without the state
+		frame.Create = true
+		frame.Code = memory(peek(1), peek(2))
+		frame.Input = frame.Code
+	}
+	switch call.Op {
+	case vm.CALLCODE, vm.DELEGATECALL:
+		frame.Address = parent.Address
+	default:
+		frame.Address = frame.CodeAddress
+	}
+	return frame
+}
@@ -139,6 +139,26 @@ var (
 		Usage:    "enable return data output",
 		Category: flags.VMCategory,
 	}
+	DebuggerFlag = &cli.BoolFlag{
+		Name:     "debugger",
+		Usage:    "record the execution and step through it in the interactive debugger",
+		Category: flags.VMCategory,
+	}
+	CombinedJSONFlag = &cli.StringFlag{
+		Name:     "combined-json",
+		Usage:    "solc --combined-json output with source maps of the debugged contracts",
+		Category: flags.VMCategory,
+	}
+	SourceDirFlag = &cli.StringFlag{
+		Name:     "sourcedir",
+		Usage:    "directory the source files of the combined-json output are relative to (default = its directory)",
+		Category: flags.VMCategory,
+	}
+	ContractFlag = &cli.StringSliceFlag{
+		Name:     "contract",
+		Usage:    "contract deployed at an address of an attached trace, as address=Name",
+		Category: flags.VMCategory,
+	}
 	refTestFlag = &cli.StringFlag{
 		Name:  "test",
 		Usage: "Path to EOF validation reference test.",
@@ -252,6 +272,13 @@ var traceFlags = []cli.Flag{
 	DisableReturnDataFlag,
 }
 
+// debuggerFlags contains flags that configure the interactive debugger.
+var debuggerFlags = []cli.Flag{
+	DebuggerFlag,
+	CombinedJSONFlag,
+	SourceDirFlag,
+}
+
 var app = flags.NewApp("the evm command line interface")
 
 func init() {
@@ -260,6 +287,8 @@ func init() {
 		compileCommand,
 		disasmCommand,
 		runCommand,
+		debugCommand,
+		replayCommand,
 		blockTestCommand,
 		stateTestCommand,
 		stateTransitionCommand,
@@ -0,0 +1,82 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of go-rajchain.
+//
+// go-rajchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-rajchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-rajchain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/core"
+	"github.com/rajchain/go-rajchain/core/tracing"
+	"github.com/rajchain/go-rajchain/eth/tracers/logger"
+	"github.com/urfave/cli/v2"
+)
+
+var replayCommand = &cli.Command{
+	Action:    replayCmd,
+	Name:      "replay",
+	Usage:     "Re-executes a bad block bundle exported by geth",
+	ArgsUsage: "<bundle.json>",
+	Description: `
+The replay command re-executes the block of a bundle exported with 'geth db
+export-bad-block' on top of the witness recorded with it, without needing access
+to the chain. It reports whether the validation failure seen by the exporting
+node is reproduced.`,
+}
+
+func replayCmd(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		return errors.New("path-to-bundle argument required")
+	}
+	src, err := os.ReadFile(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	var report core.BadBlockReport
+	if err := json.Unmarshal(src, &report); err != nil {
+		return fmt.Errorf("invalid bundle: %v", err)
+	}
+	var tracer *tracing.Hooks
+	if ctx.Bool(MachineFlag.Name) {
+		tracer = logger.NewJSONLogger(&logger.Config{
+			EnableMemory:     !ctx.Bool(DisableMemoryFlag.Name),
+			DisableStack:     ctx.Bool(DisableStackFlag.Name),
+			DisableStorage:   ctx.Bool(DisableStorageFlag.Name),
+			EnableReturnData: !ctx.Bool(DisableReturnDataFlag.Name),
+		}, os.Stderr)
+	}
+	block := report.Block
+	fmt.Printf("Block:    %d (%x)\n", block.NumberU64(), block.Hash())
+	fmt.Printf("Recorded: %s\n", report.Error)
+
+	replayErr := report.Replay(tracer)
+	if replayErr == nil {
+		fmt.Println("Replayed: block is valid")
+		return errors.New("failure not reproduced")
+	}
+	fmt.Printf("Replayed: %v\n", replayErr)
+	if report.StateRoot != (common.Hash{}) && report.StateRoot != block.Root() {
+		fmt.Printf("State root: have %x, want %x\n", report.StateRoot, block.Root())
+	}
+	if report.ReceiptRoot != (common.Hash{}) && report.ReceiptRoot != block.ReceiptHash() {
+		fmt.Printf("Receipt root: have %x, want %x\n", report.ReceiptRoot, block.ReceiptHash())
+	}
+	return nil
+}
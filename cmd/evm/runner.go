@@ -29,6 +29,7 @@ import (
 	"time"
 
 	"github.com/rajchain/go-rajchain/cmd/evm/internal/compiler"
+	"github.com/rajchain/go-rajchain/cmd/evm/internal/debugger"
 	"github.com/rajchain/go-rajchain/cmd/utils"
 	"github.com/rajchain/go-rajchain/common"
 	"github.com/rajchain/go-rajchain/core"
@@ -51,7 +52,7 @@ var runCommand = &cli.Command{
 	Usage:       "Run arbitrary evm binary",
 	ArgsUsage:   "<code>",
 	Description: `The run command runs arbitrary EVM code.`,
-	Flags:       slices.Concat(vmFlags, traceFlags),
+	Flags:       slices.Concat(vmFlags, traceFlags, debuggerFlags),
 }
 
 // readGenesis will read the given JSON format genesis file and return
@@ -137,6 +138,7 @@ func runCmd(ctx *cli.Context) error {
 	var (
 		tracer      *tracing.Hooks
 		debugLogger *logger.StructLogger
+		recorder    *debugger.Recorder
 		statedb     *state.StateDB
 		chainConfig *params.ChainConfig
 		sender      = common.BytesToAddress([]byte("sender"))
@@ -145,7 +147,10 @@ func runCmd(ctx *cli.Context) error {
 		blobHashes  []common.Hash  // TODO (MariusVanDerWijden) implement blob hashes in state tests
 		blobBaseFee = new(big.Int) // TODO (MariusVanDerWijden) implement blob fee in state tests
 	)
-	if ctx.Bool(MachineFlag.Name) {
+	if ctx.Bool(DebuggerFlag.Name) {
+		recorder = debugger.NewRecorder()
+		tracer = recorder.Hooks()
+	} else if ctx.Bool(MachineFlag.Name) {
 		tracer = logger.NewJSONLogger(logconfig, os.Stdout)
 	} else if ctx.Bool(DebugFlag.Name) {
 		debugLogger = logger.NewStructLogger(logconfig)
@@ -320,7 +325,16 @@ allocations:     %d
 allocated bytes: %d
 `, stats.GasUsed, stats.Time, stats.Allocs, stats.BytesAllocated)
 	}
-	if tracer == nil {
+	if recorder != nil {
+		artifacts, err := loadArtifacts(ctx)
+		if err != nil {
+			return err
+		}
+		if err := debugger.New(recorder.Steps(), artifacts, os.Stdout).Run(); err != nil {
+			return err
+		}
+	}
+	if tracer == nil || recorder != nil {
 		fmt.Printf("%#x\n", output)
 		if err != nil {
 			fmt.Printf(" error: %v\n", err)
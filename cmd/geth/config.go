@@ -215,6 +215,10 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 	if ctx.IsSet(utils.GraphQLEnabledFlag.Name) {
 		utils.RegisterGraphQLService(stack, backend, filterSystem, &cfg.Node)
 	}
+	// Add the ERC-4337 bundler if requested.
+	if ctx.IsSet(utils.BundlerEnabledFlag.Name) {
+		utils.RegisterBundlerService(ctx, stack, backend)
+	}
 	// Add the rajchain Stats daemon if requested.
 	if cfg.Ethstats.URL != "" {
 		utils.RegisterEthStatsService(stack, backend, cfg.Ethstats.URL)
@@ -227,6 +231,10 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 		}
 		utils.RegisterFullSyncTester(stack, eth, common.BytesToHash(hex))
 	}
+	// Configure checkpoint sync service if requested
+	if ctx.IsSet(utils.CheckpointSignerFlag.Name) || ctx.IsSet(utils.CheckpointKeyFlag.Name) {
+		utils.RegisterCheckpointSyncer(ctx, stack, eth)
+	}
 
 	if ctx.IsSet(utils.DeveloperFlag.Name) {
 		// Start dev mode.
@@ -379,6 +387,14 @@ func setAccountManagerBackends(conf *node.Config, am *accounts.Manager, keydir s
 			am.AddBackend(schub)
 		}
 	}
+	if len(conf.KeyService) > 0 {
+		// Expose the keys of a remote key management service
+		backend, err := utils.MakeKeyServiceBackend(conf.KeyService, conf.KeyServiceTokenFile)
+		if err != nil {
+			return fmt.Errorf("error connecting to key service: %v", err)
+		}
+		am.AddBackend(backend)
+	}
 
 	return nil
 }
@@ -18,6 +18,7 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
@@ -32,6 +33,7 @@ import (
 	"github.com/rajchain/go-rajchain/common"
 	"github.com/rajchain/go-rajchain/common/hexutil"
 	"github.com/rajchain/go-rajchain/console/prompt"
+	"github.com/rajchain/go-rajchain/core"
 	"github.com/rajchain/go-rajchain/core/rawdb"
 	"github.com/rajchain/go-rajchain/core/state/snapshot"
 	"github.com/rajchain/go-rajchain/core/types"
@@ -81,6 +83,8 @@ Remove blockchain and state databases`,
 			dbImportCmd,
 			dbExportCmd,
 			dbMetadataCmd,
+			dbBadBlocksCmd,
+			dbExportBadBlockCmd,
 			dbCheckStateContentCmd,
 			dbInspectHistoryCmd,
 		},
@@ -207,6 +211,27 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 		}, utils.NetworkFlags, utils.DatabaseFlags),
 		Description: "Shows metadata about the chain status.",
 	}
+	dbBadBlocksCmd = &cli.Command{
+		Action: showBadBlocks,
+		Name:   "bad-blocks",
+		Usage:  "Lists the forensics reports of the blocks which failed validation",
+		Flags: slices.Concat([]cli.Flag{
+			utils.SyncModeFlag,
+		}, utils.NetworkFlags, utils.DatabaseFlags),
+		Description: "Lists the blocks which failed validation, along with the failure and the data captured about it.",
+	}
+	dbExportBadBlockCmd = &cli.Command{
+		Action:    exportBadBlock,
+		Name:      "export-bad-block",
+		Usage:     "Exports the forensics report of a bad block into a reproducible bundle",
+		ArgsUsage: "<hex-encoded block hash> <bundle file>",
+		Flags: slices.Concat([]cli.Flag{
+			utils.SyncModeFlag,
+		}, utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `Exports the forensics report of a block which failed validation as JSON. The
+bundle holds the block, the parent state it accessed and the execution trace, and
+can be replayed offline with 'evm replay'.`,
+	}
 	dbInspectHistoryCmd = &cli.Command{
 		Action:    inspectHistory,
 		Name:      "inspect-history",
@@ -789,6 +814,68 @@ func showMetaData(ctx *cli.Context) error {
 	return nil
 }
 
+func showBadBlocks(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	defer db.Close()
+
+	summaries, err := core.ReadBadBlockSummaries(db)
+	if err != nil {
+		return err
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Number", "Hash", "Time", "Stage", "Witness", "Trace", "Error"})
+	for _, summary := range summaries {
+		trace := fmt.Sprintf("%d steps", summary.TraceSteps)
+		if summary.TraceTruncated {
+			trace += " (truncated)"
+		}
+		table.Append([]string{
+			fmt.Sprint(summary.Number),
+			summary.Hash.Hex(),
+			time.Unix(int64(summary.Time), 0).Format(time.RFC3339),
+			summary.Stage,
+			fmt.Sprint(summary.Witness),
+			trace,
+			summary.Error,
+		})
+	}
+	table.Render()
+	return nil
+}
+
+func exportBadBlock(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	hash, err := hexutil.Decode(ctx.Args().Get(0))
+	if err != nil || len(hash) != common.HashLength {
+		return fmt.Errorf("invalid block hash %q", ctx.Args().Get(0))
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	defer db.Close()
+
+	report, err := core.ReadBadBlockReport(db, common.BytesToHash(hash))
+	if err != nil {
+		return err
+	}
+	if report == nil {
+		return fmt.Errorf("no report of bad block %x", hash)
+	}
+	blob, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(ctx.Args().Get(1), blob, 0644); err != nil {
+		return err
+	}
+	log.Info("Exported bad block report", "number", report.Block.Number(), "hash", report.Block.Hash(), "file", ctx.Args().Get(1))
+	return nil
+}
+
 func inspectAccount(db *triedb.Database, start uint64, end uint64, address common.Address, raw bool) error {
 	stats, err := db.AccountHistory(address, start, end)
 	if err != nil {
@@ -18,6 +18,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"slices"
@@ -36,6 +37,7 @@ import (
 	"github.com/rajchain/go-rajchain/log"
 	"github.com/rajchain/go-rajchain/metrics"
 	"github.com/rajchain/go-rajchain/node"
+	"github.com/rajchain/go-rajchain/rpc"
 	"go.uber.org/automaxprocs/maxprocs"
 
 	// Force-load the tracer engines to trigger registration
@@ -63,6 +65,8 @@ var (
 		utils.NoUSBFlag, // deprecated
 		utils.USBFlag,
 		utils.SmartCardDaemonPathFlag,
+		utils.KeyServiceFlag,
+		utils.KeyServiceTokenFlag,
 		utils.OverrideCancun,
 		utils.OverrideVerkle,
 		utils.EnablePersonal, // deprecated
@@ -77,17 +81,25 @@ var (
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolStemLengthFlag,
+		utils.TxPoolStemEmbargoFlag,
 		utils.BlobPoolDataDirFlag,
 		utils.BlobPoolDataCapFlag,
 		utils.BlobPoolPriceBumpFlag,
 		utils.SyncModeFlag,
 		utils.SyncTargetFlag,
+		utils.CheckpointSignerFlag,
+		utils.CheckpointFileFlag,
+		utils.CheckpointURLFlag,
+		utils.CheckpointKeyFlag,
 		utils.ExitWhenSyncedFlag,
+		utils.SyncProgressFlag,
 		utils.GCModeFlag,
 		utils.SnapshotFlag,
 		utils.TxLookupLimitFlag, // deprecated
 		utils.TransactionHistoryFlag,
 		utils.StateHistoryFlag,
+		utils.SnapHistoryDepthFlag,
 		utils.LightServeFlag,    // deprecated
 		utils.LightIngressFlag,  // deprecated
 		utils.LightEgressFlag,   // deprecated
@@ -112,6 +124,7 @@ var (
 		utils.CryptoKZGFlag,
 		utils.ListenPortFlag,
 		utils.DiscoveryPortFlag,
+		utils.QUICPortFlag,
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
 		utils.MiningEnabledFlag, // deprecated
@@ -128,12 +141,22 @@ var (
 		utils.DiscoveryV5Flag,
 		utils.LegacyDiscoveryV5Flag, // deprecated
 		utils.NetrestrictFlag,
+		utils.PermissionedFlag,
+		utils.PermissionedAllowlistFlag,
+		utils.PermissionedRegistryFlag,
+		utils.BandwidthIngressFlag,
+		utils.BandwidthEgressFlag,
+		utils.BandwidthPeerIngressFlag,
+		utils.BandwidthPeerEgressFlag,
+		utils.BandwidthProtocolIngressFlag,
+		utils.BandwidthProtocolEgressFlag,
 		utils.NodeKeyFileFlag,
 		utils.NodeKeyHexFlag,
 		utils.DNSDiscoveryFlag,
 		utils.DeveloperFlag,
 		utils.DeveloperGasLimitFlag,
 		utils.DeveloperPeriodFlag,
+		utils.DeveloperVerkleFlag,
 		utils.VMEnableDebugFlag,
 		utils.VMTraceFlag,
 		utils.VMTraceJsonConfigFlag,
@@ -144,6 +167,12 @@ var (
 		utils.GpoPercentileFlag,
 		utils.GpoMaxGasPriceFlag,
 		utils.GpoIgnoreGasPriceFlag,
+		utils.BundlerEnabledFlag,
+		utils.BundlerEntryPointFlag,
+		utils.BundlerSignerFlag,
+		utils.BundlerBeneficiaryFlag,
+		utils.BundlerMaxBundleSizeFlag,
+		utils.BundlerMempoolSizeFlag,
 		configFileFlag,
 		utils.LogDebugFlag,
 		utils.LogBacktraceAtFlag,
@@ -244,6 +273,8 @@ func init() {
 		snapshotCommand,
 		// See verkle.go
 		verkleCommand,
+		// See statelesscmd.go
+		statelessCommand,
 	}
 	if logTestCommand != nil {
 		app.Commands = append(app.Commands, logTestCommand)
@@ -424,4 +455,75 @@ func startNode(ctx *cli.Context, stack *node.Node, isConsole bool) {
 			}
 		}()
 	}
+	// Spawn a standalone goroutine for logging the detailed sync progress if
+	// user required.
+	if ctx.Bool(utils.SyncProgressFlag.Name) {
+		go reportSyncProgress(rpcClient)
+	}
+}
+
+// reportSyncProgress periodically logs the progress, throughput and estimated
+// completion time of the sync phases still in progress.
+func reportSyncProgress(client *rpc.Client) {
+	ticker := time.NewTicker(8 * time.Second)
+	defer ticker.Stop()
+
+	var syncing bool
+	for range ticker.C {
+		var progress *downloader.SyncPhases
+		if err := client.Call(&progress, "debug_syncProgress"); err != nil {
+			if errors.Is(err, rpc.ErrClientQuit) {
+				return
+			}
+			log.Debug("Failed to retrieve sync progress", "err", err)
+			continue
+		}
+		var active []*downloader.Phase
+		for _, phase := range progress.Phases {
+			if phase.Status == downloader.PhaseActive {
+				active = append(active, phase)
+			}
+		}
+		if len(active) == 0 {
+			if syncing {
+				log.Info("Sync phases completed", "mode", progress.Mode)
+			}
+			syncing = false
+			continue
+		}
+		syncing = true
+
+		log.Info("Sync progress", "mode", progress.Mode, "active", len(active), "eta", formatETA(progress.ETA))
+		for _, phase := range active {
+			var done string
+			switch phase.Unit {
+			case "bytes":
+				done = common.StorageSize(phase.Current).TerminalString()
+				if phase.Total > 0 {
+					done += "/" + common.StorageSize(phase.Total).TerminalString()
+				}
+			default:
+				done = log.FormatLogfmtUint64(phase.Current)
+				if phase.Total > 0 {
+					done += "/" + log.FormatLogfmtUint64(phase.Total)
+				}
+				done += " " + phase.Unit
+			}
+			percent := "n/a"
+			if phase.Total > 0 {
+				percent = fmt.Sprintf("%.2f%%", float64(phase.Current)*100/float64(phase.Total))
+			}
+			log.Info("Sync phase", "phase", phase.Name, "progress", percent, "done", done,
+				"rate", fmt.Sprintf("%.1f/s", phase.Rate), "eta", formatETA(phase.ETA))
+		}
+	}
+}
+
+// formatETA formats an estimated completion time given in seconds, or n/a if
+// it is unknown.
+func formatETA(seconds float64) string {
+	if seconds <= 0 {
+		return "n/a"
+	}
+	return common.PrettyDuration(time.Duration(seconds * float64(time.Second))).String()
 }
@@ -0,0 +1,337 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of go-rajchain.
+//
+// go-rajchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-rajchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-rajchain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/rajchain/go-rajchain/cmd/utils"
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/core"
+	"github.com/rajchain/go-rajchain/core/stateless"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/internal/flags"
+	"github.com/rajchain/go-rajchain/log"
+	"github.com/rajchain/go-rajchain/params"
+	"github.com/rajchain/go-rajchain/rlp"
+	"github.com/rajchain/go-rajchain/rpc"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	statelessRPCFlag = &cli.StringFlag{
+		Name:     "rpc",
+		Usage:    "RPC endpoint of a node serving blocks and their execution witnesses (debug API)",
+		Category: flags.MiscCategory,
+	}
+	statelessFromFlag = &cli.Uint64Flag{
+		Name:     "from",
+		Usage:    "First block to retrieve from the RPC endpoint",
+		Value:    1,
+		Category: flags.MiscCategory,
+	}
+	statelessToFlag = &cli.Uint64Flag{
+		Name:     "to",
+		Usage:    "Last block to retrieve from the RPC endpoint (0 = head of the node)",
+		Category: flags.MiscCategory,
+	}
+	statelessFollowFlag = &cli.BoolFlag{
+		Name:     "follow",
+		Usage:    "Keep verifying the new blocks imported by the node at the RPC endpoint",
+		Category: flags.MiscCategory,
+	}
+	statelessGenesisFlag = &cli.StringFlag{
+		Name:     "genesis",
+		Usage:    "Genesis file of the chain the blocks belong to, if not a preset network",
+		Category: flags.MiscCategory,
+	}
+
+	statelessCommand = &cli.Command{
+		Name:  "stateless",
+		Usage: "Stateless block validation using execution witnesses",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "verify",
+				Usage:     "Re-execute blocks with nothing but their execution witnesses",
+				ArgsUsage: "[<witness file>]",
+				Action:    verifyStateless,
+				Flags: slices.Concat([]cli.Flag{
+					statelessRPCFlag,
+					statelessFromFlag,
+					statelessToFlag,
+					statelessFollowFlag,
+					statelessGenesisFlag,
+				}, utils.NetworkFlags),
+				Description: `
+geth stateless verify <witness file>
+geth stateless verify --rpc <endpoint> [--from <number>] [--to <number>] [--follow]
+
+Re-executes blocks without any state database, using only the state contained
+in their execution witnesses, and reports the blocks whose execution fails or
+derives a state or receipt root different from the one in their header.
+
+The blocks and witnesses are either read from a file created by the fetch
+command, or retrieved from a node through the debug_getRawBlock and the
+debug_executionWitness RPC methods. In the latter case, the verifier can keep
+following the chain of the node.`,
+			},
+			{
+				Name:      "fetch",
+				Usage:     "Download blocks along with their execution witnesses into a file",
+				ArgsUsage: "<witness file>",
+				Action:    fetchStateless,
+				Flags: []cli.Flag{
+					statelessRPCFlag,
+					statelessFromFlag,
+					statelessToFlag,
+				},
+				Description: `
+geth stateless fetch --rpc <endpoint> [--from <number>] [--to <number>] <witness file>
+
+Retrieves a range of blocks and their execution witnesses from a node, storing
+them in a file for later verification with the verify command.`,
+			},
+		},
+	}
+)
+
+// statelessTask is a block bundled with the execution witness needed to run
+// it statelessly, the unit stored in witness files.
+type statelessTask struct {
+	Block   *types.Block
+	Witness *stateless.Witness
+}
+
+// witnessSource is a sequence of blocks and their execution witnesses.
+type witnessSource interface {
+	// next retrieves the next block to verify, returning io.EOF once the
+	// source is exhausted.
+	next(ctx context.Context) (*statelessTask, error)
+}
+
+// fileSource reads the blocks and witnesses from a witness file.
+type fileSource struct {
+	stream *rlp.Stream
+}
+
+func (s *fileSource) next(ctx context.Context) (*statelessTask, error) {
+	task := new(statelessTask)
+	if err := s.stream.Decode(task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// rpcSource retrieves the blocks and witnesses from a node's debug API.
+type rpcSource struct {
+	client *rpc.Client
+	number uint64 // Next block to retrieve
+	last   uint64 // Last block to retrieve, 0 for the head of the node
+	follow bool   // Whether to wait for new blocks once the head is reached
+}
+
+func (s *rpcSource) next(ctx context.Context) (*statelessTask, error) {
+	for {
+		if s.last != 0 && s.number > s.last {
+			return nil, io.EOF
+		}
+		var head hexutil.Uint64
+		if err := s.client.CallContext(ctx, &head, "eth_blockNumber"); err != nil {
+			return nil, err
+		}
+		if s.number <= uint64(head) {
+			break
+		}
+		if !s.follow {
+			return nil, io.EOF
+		}
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	var (
+		blob    hexutil.Bytes
+		witness hexutil.Bytes
+		number  = hexutil.Uint64(s.number)
+		task    = &statelessTask{Block: new(types.Block), Witness: new(stateless.Witness)}
+	)
+	if err := s.client.CallContext(ctx, &blob, "debug_getRawBlock", number); err != nil {
+		return nil, fmt.Errorf("failed to retrieve block %d: %v", s.number, err)
+	}
+	if err := rlp.DecodeBytes(blob, task.Block); err != nil {
+		return nil, fmt.Errorf("invalid block %d: %v", s.number, err)
+	}
+	if err := s.client.CallContext(ctx, &witness, "debug_executionWitness", number); err != nil {
+		return nil, fmt.Errorf("failed to retrieve witness of block %d: %v", s.number, err)
+	}
+	if err := rlp.DecodeBytes(witness, task.Witness); err != nil {
+		return nil, fmt.Errorf("invalid witness of block %d: %v", s.number, err)
+	}
+	s.number++
+	return task, nil
+}
+
+// newRPCSource connects to the node serving the blocks and witnesses.
+func newRPCSource(ctx *cli.Context, follow bool) (*rpcSource, error) {
+	client, err := rpc.DialContext(ctx.Context, ctx.String(statelessRPCFlag.Name))
+	if err != nil {
+		return nil, err
+	}
+	from := ctx.Uint64(statelessFromFlag.Name)
+	if from == 0 {
+		return nil, errors.New("genesis is not executable")
+	}
+	return &rpcSource{
+		client: client,
+		number: from,
+		last:   ctx.Uint64(statelessToFlag.Name),
+		follow: follow,
+	}, nil
+}
+
+// statelessConfig resolves the chain config the blocks are executed with.
+func statelessConfig(ctx *cli.Context) (*params.ChainConfig, error) {
+	if path := ctx.String(statelessGenesisFlag.Name); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		genesis := new(core.Genesis)
+		if err := json.NewDecoder(file).Decode(genesis); err != nil {
+			return nil, fmt.Errorf("invalid genesis file: %v", err)
+		}
+		if genesis.Config == nil {
+			return nil, errors.New("genesis file without chain config")
+		}
+		return genesis.Config, nil
+	}
+	if genesis := utils.MakeGenesis(ctx); genesis != nil {
+		return genesis.Config, nil
+	}
+	return params.MainnetChainConfig, nil
+}
+
+func verifyStateless(ctx *cli.Context) error {
+	config, err := statelessConfig(ctx)
+	if err != nil {
+		return err
+	}
+	var source witnessSource
+	switch {
+	case ctx.IsSet(statelessRPCFlag.Name):
+		if ctx.NArg() != 0 {
+			return errors.New("witness file and RPC endpoint are mutually exclusive")
+		}
+		src, err := newRPCSource(ctx, ctx.Bool(statelessFollowFlag.Name))
+		if err != nil {
+			return err
+		}
+		defer src.client.Close()
+		source = src
+
+	case ctx.NArg() == 1:
+		file, err := os.Open(ctx.Args().First())
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		source = &fileSource{stream: rlp.NewStream(file, 0)}
+
+	default:
+		return errors.New("need a witness file or an RPC endpoint")
+	}
+	var (
+		verified int
+		failed   int
+		start    = time.Now()
+	)
+	for {
+		task, err := source.next(ctx.Context)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		var (
+			block  = task.Block
+			tstart = time.Now()
+		)
+		if err := core.VerifyStateless(config, block, task.Witness); err != nil {
+			log.Error("Stateless verification failed", "number", block.Number(), "hash", block.Hash(), "err", err)
+			failed++
+			continue
+		}
+		log.Info("Verified block statelessly", "number", block.Number(), "hash", block.Hash(), "txs", len(block.Transactions()), "elapsed", common.PrettyDuration(time.Since(tstart)))
+		verified++
+	}
+	log.Info("Stateless verification done", "verified", verified, "failed", failed, "elapsed", common.PrettyDuration(time.Since(start)))
+	if failed > 0 {
+		return fmt.Errorf("%d blocks failed stateless verification", failed)
+	}
+	return nil
+}
+
+func fetchStateless(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("need the witness file to write as the only argument")
+	}
+	if !ctx.IsSet(statelessRPCFlag.Name) {
+		return errors.New("need the RPC endpoint to fetch from")
+	}
+	source, err := newRPCSource(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer source.client.Close()
+
+	file, err := os.Create(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var fetched int
+	for {
+		task, err := source.next(ctx.Context)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := rlp.Encode(file, task); err != nil {
+			return err
+		}
+		fetched++
+		log.Info("Fetched block and witness", "number", task.Block.Number(), "hash", task.Block.Hash())
+	}
+	log.Info("Fetched execution witnesses", "blocks", fetched, "file", ctx.Args().First())
+	return nil
+}
@@ -28,6 +28,7 @@ import (
 	"math/big"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	godebug "runtime/debug"
@@ -37,6 +38,7 @@ import (
 
 	"github.com/rajchain/go-rajchain/accounts"
 	"github.com/rajchain/go-rajchain/accounts/keystore"
+	"github.com/rajchain/go-rajchain/accounts/kms"
 	bparams "github.com/rajchain/go-rajchain/beacon/params"
 	"github.com/rajchain/go-rajchain/common"
 	"github.com/rajchain/go-rajchain/common/fdlimit"
@@ -49,11 +51,13 @@ import (
 	"github.com/rajchain/go-rajchain/crypto"
 	"github.com/rajchain/go-rajchain/crypto/kzg4844"
 	"github.com/rajchain/go-rajchain/eth"
+	"github.com/rajchain/go-rajchain/eth/bundler"
 	"github.com/rajchain/go-rajchain/eth/catalyst"
 	"github.com/rajchain/go-rajchain/eth/downloader"
 	"github.com/rajchain/go-rajchain/eth/ethconfig"
 	"github.com/rajchain/go-rajchain/eth/filters"
 	"github.com/rajchain/go-rajchain/eth/gasprice"
+	"github.com/rajchain/go-rajchain/eth/syncer"
 	"github.com/rajchain/go-rajchain/eth/tracers"
 	"github.com/rajchain/go-rajchain/ethdb"
 	"github.com/rajchain/go-rajchain/ethdb/remotedb"
@@ -133,6 +137,17 @@ var (
 		Value:    pcsclite.PCSCDSockName,
 		Category: flags.AccountCategory,
 	}
+	KeyServiceFlag = &cli.StringFlag{
+		Name:     "kms.url",
+		Usage:    "Endpoint of a remote key management service (HTTP KMS protocol) holding signing keys",
+		Category: flags.AccountCategory,
+	}
+	KeyServiceTokenFlag = &cli.StringFlag{
+		Name:      "kms.tokenfile",
+		Usage:     "File containing the bearer token to authenticate against the key management service",
+		TakesFile: true,
+		Category:  flags.AccountCategory,
+	}
 	NetworkIdFlag = &cli.Uint64Flag{
 		Name:     "networkid",
 		Usage:    "Explicitly set network id (integer)(For testnets: use --sepolia, --holesky instead)",
@@ -171,6 +186,11 @@ var (
 		Value:    11500000,
 		Category: flags.DevCategory,
 	}
+	DeveloperVerkleFlag = &cli.BoolFlag{
+		Name:     "dev.verkle",
+		Usage:    "Start the developer network with a verkle state tree (requires path state scheme)",
+		Category: flags.DevCategory,
+	}
 
 	IdentityFlag = &cli.StringFlag{
 		Name:     "identity",
@@ -182,6 +202,11 @@ var (
 		Usage:    "Exits after block synchronisation completes",
 		Category: flags.EthCategory,
 	}
+	SyncProgressFlag = &cli.BoolFlag{
+		Name:     "syncprogress",
+		Usage:    "Periodically logs the progress, throughput and ETA of each sync phase",
+		Category: flags.EthCategory,
+	}
 
 	// Dump command options.
 	IterativeOutputFlag = &cli.BoolFlag{
@@ -267,6 +292,12 @@ var (
 		Value:    ethconfig.Defaults.StateHistory,
 		Category: flags.StateCategory,
 	}
+	SnapHistoryDepthFlag = &cli.Uint64Flag{
+		Name:     "history.snapdepth",
+		Usage:    "Number of state histories replayed to serve snap sync ranges at older state roots (0 = disabled)",
+		Value:    ethconfig.Defaults.SnapHistoryDepth,
+		Category: flags.StateCategory,
+	}
 	TransactionHistoryFlag = &cli.Uint64Flag{
 		Name:     "history.transactions",
 		Usage:    "Number of recent blocks to maintain transactions index for (default = about one year, 0 = entire chain)",
@@ -390,6 +421,18 @@ var (
 		Value:    ethconfig.Defaults.TxPool.Lifetime,
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolStemLengthFlag = &cli.IntFlag{
+		Name:     "txpool.stemlength",
+		Usage:    "Expected number of hops local transactions are relayed along before broadcast (0 = disabled)",
+		Value:    ethconfig.Defaults.TxStemLength,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolStemEmbargoFlag = &cli.DurationFlag{
+		Name:     "txpool.stemembargo",
+		Usage:    "Minimum time to wait for relayed transactions to be broadcast before broadcasting them locally",
+		Value:    ethconfig.Defaults.TxStemEmbargo,
+		Category: flags.TxPoolCategory,
+	}
 	// Blob transaction pool settings
 	BlobPoolDataDirFlag = &cli.StringFlag{
 		Name:     "blobpool.datadir",
@@ -584,6 +627,30 @@ var (
 		Category: flags.LoggingCategory,
 	}
 
+	// Checkpoint sync settings
+	CheckpointSignerFlag = &cli.StringFlag{
+		Name:     "checkpoint.signer",
+		Usage:    "Address of the account trusted to sign sync checkpoints (syncs without a consensus client)",
+		Category: flags.EthCategory,
+	}
+	CheckpointFileFlag = &cli.StringFlag{
+		Name:      "checkpoint.file",
+		Usage:     "File containing the signed checkpoint to start the sync from",
+		TakesFile: true,
+		Category:  flags.EthCategory,
+	}
+	CheckpointURLFlag = &cli.StringFlag{
+		Name:     "checkpoint.url",
+		Usage:    "Trusted RPC endpoint announcing signed checkpoints of the chain head to follow",
+		Category: flags.EthCategory,
+	}
+	CheckpointKeyFlag = &cli.StringFlag{
+		Name:      "checkpoint.key",
+		Usage:     "File containing the signer key, to serve signed checkpoints of the local head over the checkpoint RPC API",
+		TakesFile: true,
+		Category:  flags.EthCategory,
+	}
+
 	// MISC settings
 	SyncTargetFlag = &cli.StringFlag{
 		Name:      "synctarget",
@@ -800,6 +867,56 @@ var (
 		Value:    30303,
 		Category: flags.NetworkingCategory,
 	}
+	QUICPortFlag = &cli.IntFlag{
+		Name:     "quic.port",
+		Usage:    "UDP port for RLPx connections over QUIC, must differ from the discovery port (experimental, 0 = disabled)",
+		Category: flags.NetworkingCategory,
+	}
+	PermissionedFlag = &cli.BoolFlag{
+		Name:     "permissioned",
+		Usage:    "Only accepts connections from and dials nodes in the node allowlist",
+		Category: flags.NetworkingCategory,
+	}
+	PermissionedAllowlistFlag = &cli.StringFlag{
+		Name:     "permissioned.allowlist",
+		Usage:    "File listing the allowed nodes, reloaded on modification",
+		Category: flags.NetworkingCategory,
+	}
+	PermissionedRegistryFlag = &cli.StringFlag{
+		Name:     "permissioned.registry",
+		Usage:    "Address of the on-chain registry contract listing the allowed nodes",
+		Category: flags.NetworkingCategory,
+	}
+	BandwidthIngressFlag = &cli.Uint64Flag{
+		Name:     "bandwidth.ingress",
+		Usage:    "Maximum inbound traffic from all peers in KB/s (0 = unlimited)",
+		Category: flags.NetworkingCategory,
+	}
+	BandwidthEgressFlag = &cli.Uint64Flag{
+		Name:     "bandwidth.egress",
+		Usage:    "Maximum outbound traffic to all peers in KB/s (0 = unlimited)",
+		Category: flags.NetworkingCategory,
+	}
+	BandwidthPeerIngressFlag = &cli.Uint64Flag{
+		Name:     "bandwidth.peer.ingress",
+		Usage:    "Maximum inbound traffic from each peer in KB/s (0 = unlimited)",
+		Category: flags.NetworkingCategory,
+	}
+	BandwidthPeerEgressFlag = &cli.Uint64Flag{
+		Name:     "bandwidth.peer.egress",
+		Usage:    "Maximum outbound traffic to each peer in KB/s (0 = unlimited)",
+		Category: flags.NetworkingCategory,
+	}
+	BandwidthProtocolIngressFlag = &cli.StringFlag{
+		Name:     "bandwidth.protocol.ingress",
+		Usage:    "Comma separated protocol=KB/s pairs capping the inbound traffic of protocols (e.g. snap=1024)",
+		Category: flags.NetworkingCategory,
+	}
+	BandwidthProtocolEgressFlag = &cli.StringFlag{
+		Name:     "bandwidth.protocol.egress",
+		Usage:    "Comma separated protocol=KB/s pairs capping the outbound traffic of protocols (e.g. snap=1024)",
+		Category: flags.NetworkingCategory,
+	}
 
 	// Console
 	JSpathFlag = &flags.DirectoryFlag{
@@ -841,6 +958,41 @@ var (
 		Category: flags.GasPriceCategory,
 	}
 
+	// ERC-4337 bundler settings
+	BundlerEnabledFlag = &cli.BoolFlag{
+		Name:     "bundler",
+		Usage:    "Enable the built-in ERC-4337 bundler (eth_sendUserOperation and related APIs)",
+		Category: flags.BundlerCategory,
+	}
+	BundlerEntryPointFlag = &cli.StringFlag{
+		Name:     "bundler.entrypoint",
+		Usage:    "Address of the EntryPoint contract served by the bundler",
+		Value:    bundler.DefaultConfig.EntryPoint.Hex(),
+		Category: flags.BundlerCategory,
+	}
+	BundlerSignerFlag = &cli.StringFlag{
+		Name:     "bundler.signer",
+		Usage:    "Unlocked account signing the bundle transactions",
+		Category: flags.BundlerCategory,
+	}
+	BundlerBeneficiaryFlag = &cli.StringFlag{
+		Name:     "bundler.beneficiary",
+		Usage:    "Address receiving the bundle fees (default = bundler signer)",
+		Category: flags.BundlerCategory,
+	}
+	BundlerMaxBundleSizeFlag = &cli.IntFlag{
+		Name:     "bundler.maxbundlesize",
+		Usage:    "Maximum number of user operations in a bundle",
+		Value:    bundler.DefaultConfig.MaxBundleSize,
+		Category: flags.BundlerCategory,
+	}
+	BundlerMempoolSizeFlag = &cli.IntFlag{
+		Name:     "bundler.mempool",
+		Usage:    "Maximum number of user operations in the bundler mempool",
+		Value:    bundler.DefaultConfig.MempoolSize,
+		Category: flags.BundlerCategory,
+	}
+
 	// Metrics flags
 	MetricsEnabledFlag = &cli.BoolFlag{
 		Name:     "metrics",
@@ -1075,6 +1227,9 @@ func setListenAddress(ctx *cli.Context, cfg *p2p.Config) {
 	if ctx.IsSet(DiscoveryPortFlag.Name) {
 		cfg.DiscAddr = fmt.Sprintf(":%d", ctx.Int(DiscoveryPortFlag.Name))
 	}
+	if port := ctx.Int(QUICPortFlag.Name); port != 0 {
+		cfg.QUICAddr = fmt.Sprintf(":%d", port)
+	}
 }
 
 // setNAT creates a port mapper from command line flags.
@@ -1309,16 +1464,68 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 		cfg.NetRestrict = list
 	}
 
+	if ctx.IsSet(PermissionedFlag.Name) {
+		cfg.Permissioned = ctx.Bool(PermissionedFlag.Name)
+	}
+	if ctx.IsSet(PermissionedAllowlistFlag.Name) {
+		cfg.AllowlistFile = ctx.String(PermissionedAllowlistFlag.Name)
+	}
+	setBandwidth(ctx, &cfg.Bandwidth)
+
 	if ctx.Bool(DeveloperFlag.Name) {
 		// --dev mode can't use p2p networking.
 		cfg.MaxPeers = 0
 		cfg.ListenAddr = ""
+		cfg.QUICAddr = ""
 		cfg.NoDial = true
 		cfg.NoDiscovery = true
 		cfg.DiscoveryV5 = false
 	}
 }
 
+// setBandwidth applies the bandwidth caps given in KB/s on the command line.
+func setBandwidth(ctx *cli.Context, cfg *p2p.BandwidthConfig) {
+	if ctx.IsSet(BandwidthIngressFlag.Name) {
+		cfg.MaxIngress = ctx.Uint64(BandwidthIngressFlag.Name) * 1024
+	}
+	if ctx.IsSet(BandwidthEgressFlag.Name) {
+		cfg.MaxEgress = ctx.Uint64(BandwidthEgressFlag.Name) * 1024
+	}
+	if ctx.IsSet(BandwidthPeerIngressFlag.Name) {
+		cfg.MaxPeerIngress = ctx.Uint64(BandwidthPeerIngressFlag.Name) * 1024
+	}
+	if ctx.IsSet(BandwidthPeerEgressFlag.Name) {
+		cfg.MaxPeerEgress = ctx.Uint64(BandwidthPeerEgressFlag.Name) * 1024
+	}
+	if ctx.IsSet(BandwidthProtocolIngressFlag.Name) {
+		cfg.MaxProtocolIngress = parseProtocolBandwidth(BandwidthProtocolIngressFlag.Name, ctx.String(BandwidthProtocolIngressFlag.Name))
+	}
+	if ctx.IsSet(BandwidthProtocolEgressFlag.Name) {
+		cfg.MaxProtocolEgress = parseProtocolBandwidth(BandwidthProtocolEgressFlag.Name, ctx.String(BandwidthProtocolEgressFlag.Name))
+	}
+}
+
+// parseProtocolBandwidth parses comma separated protocol=KB/s pairs into caps
+// in bytes per second.
+func parseProtocolBandwidth(flag string, value string) map[string]uint64 {
+	caps := make(map[string]uint64)
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		name, rate, ok := strings.Cut(entry, "=")
+		if !ok {
+			Fatalf("Invalid protocol cap in --%s: %s", flag, entry)
+		}
+		kbps, err := strconv.ParseUint(strings.TrimSpace(rate), 10, 64)
+		if err != nil {
+			Fatalf("Invalid protocol cap in --%s: %s", flag, entry)
+		}
+		caps[strings.TrimSpace(name)] = kbps * 1024
+	}
+	return caps
+}
+
 // SetNodeConfig applies node-related command line flags to the config.
 func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	SetP2PConfig(ctx, &cfg.P2P)
@@ -1344,6 +1551,12 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(KeyStoreDirFlag.Name) {
 		cfg.KeyStoreDir = ctx.String(KeyStoreDirFlag.Name)
 	}
+	if ctx.IsSet(KeyServiceFlag.Name) {
+		cfg.KeyService = ctx.String(KeyServiceFlag.Name)
+	}
+	if ctx.IsSet(KeyServiceTokenFlag.Name) {
+		cfg.KeyServiceTokenFile = ctx.String(KeyServiceTokenFlag.Name)
+	}
 	if ctx.IsSet(DeveloperFlag.Name) {
 		cfg.UseLightweightKDF = true
 	}
@@ -1396,6 +1609,25 @@ func setSmartCard(ctx *cli.Context, cfg *node.Config) {
 	cfg.SmartCardDaemonPath = path
 }
 
+// MakeKeyServiceBackend creates an accounts backend for the remote key management
+// service at the given endpoint, authenticating with the token in tokenFile if set.
+func MakeKeyServiceBackend(endpoint string, tokenFile string) (*kms.Backend, error) {
+	var token string
+	if tokenFile != "" {
+		blob, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key service token: %v", err)
+		}
+		token = strings.TrimSpace(string(blob))
+	}
+	service, err := kms.NewHTTPService(endpoint, token)
+	if err != nil {
+		return nil, err
+	}
+	u, _ := url.Parse(endpoint) // validated by the service
+	return kms.NewBackend(u.Host+strings.TrimSuffix(u.Path, "/"), service)
+}
+
 func SetDataDir(ctx *cli.Context, cfg *node.Config) {
 	switch {
 	case ctx.IsSet(DataDirFlag.Name):
@@ -1501,6 +1733,33 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	}
 }
 
+func setNodeRegistry(ctx *cli.Context, cfg *ethconfig.Config) {
+	if !ctx.IsSet(PermissionedRegistryFlag.Name) {
+		return
+	}
+	addr := ctx.String(PermissionedRegistryFlag.Name)
+	if !common.IsHexAddress(addr) {
+		Fatalf("Invalid address in --%s: %s", PermissionedRegistryFlag.Name, addr)
+	}
+	registry := common.HexToAddress(addr)
+	cfg.NodeRegistry = &registry
+}
+
+func setTxStem(ctx *cli.Context, cfg *ethconfig.Config) {
+	if ctx.IsSet(TxPoolStemLengthFlag.Name) {
+		cfg.TxStemLength = ctx.Int(TxPoolStemLengthFlag.Name)
+	}
+	if ctx.IsSet(TxPoolStemEmbargoFlag.Name) {
+		cfg.TxStemEmbargo = ctx.Duration(TxPoolStemEmbargoFlag.Name)
+	}
+	if cfg.TxStemLength < 0 {
+		Fatalf("Invalid --%s: %d", TxPoolStemLengthFlag.Name, cfg.TxStemLength)
+	}
+	if cfg.TxStemLength > 0 && cfg.TxStemEmbargo <= 0 {
+		Fatalf("Invalid --%s: %v", TxPoolStemEmbargoFlag.Name, cfg.TxStemEmbargo)
+	}
+}
+
 func setRequiredBlocks(ctx *cli.Context, cfg *ethconfig.Config) {
 	requiredBlocks := ctx.String(EthRequiredBlocksFlag.Name)
 	if requiredBlocks == "" {
@@ -1583,6 +1842,8 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	setBlobPool(ctx, &cfg.BlobPool)
 	setMiner(ctx, &cfg.Miner)
 	setRequiredBlocks(ctx, cfg)
+	setNodeRegistry(ctx, cfg)
+	setTxStem(ctx, cfg)
 	setLes(ctx, cfg)
 
 	// Cap the cache allowance and tune the garbage collector
@@ -1641,6 +1902,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(StateHistoryFlag.Name) {
 		cfg.StateHistory = ctx.Uint64(StateHistoryFlag.Name)
 	}
+	if ctx.IsSet(SnapHistoryDepthFlag.Name) {
+		cfg.SnapHistoryDepth = ctx.Uint64(SnapHistoryDepthFlag.Name)
+	}
 	if ctx.IsSet(StateSchemeFlag.Name) {
 		cfg.StateScheme = ctx.String(StateSchemeFlag.Name)
 	}
@@ -1791,6 +2055,13 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 
 		// Create a new developer genesis block or reuse existing one
 		cfg.Genesis = core.DeveloperGenesisBlock(ctx.Uint64(DeveloperGasLimitFlag.Name), &developer.Address)
+		if ctx.Bool(DeveloperVerkleFlag.Name) {
+			if cfg.StateScheme == rawdb.HashScheme {
+				Fatalf("Verkle developer mode requires the path state scheme")
+			}
+			cfg.StateScheme = rawdb.PathScheme
+			cfg.Genesis.Config.VerkleTime = new(uint64)
+		}
 		if ctx.IsSet(DataDirFlag.Name) {
 			chaindb := tryMakeReadOnlyDatabase(ctx, stack)
 			if rawdb.ReadCanonicalHash(chaindb, 0) != (common.Hash{}) {
@@ -1943,6 +2214,35 @@ func RegisterEthStatsService(stack *node.Node, backend *eth.EthAPIBackend, url s
 	}
 }
 
+// RegisterBundlerService adds the ERC-4337 bundler and its API to the node.
+func RegisterBundlerService(ctx *cli.Context, stack *node.Node, backend ethapi.Backend) {
+	cfg := bundler.DefaultConfig
+	parseAddress := func(flag *cli.StringFlag) common.Address {
+		addr := ctx.String(flag.Name)
+		if !common.IsHexAddress(addr) {
+			Fatalf("-%s: invalid address %q", flag.Name, addr)
+		}
+		return common.HexToAddress(addr)
+	}
+	cfg.EntryPoint = parseAddress(BundlerEntryPointFlag)
+	if !ctx.IsSet(BundlerSignerFlag.Name) {
+		Fatalf("The bundler requires a signer account (--%s)", BundlerSignerFlag.Name)
+	}
+	cfg.Signer = parseAddress(BundlerSignerFlag)
+	if ctx.IsSet(BundlerBeneficiaryFlag.Name) {
+		cfg.Beneficiary = parseAddress(BundlerBeneficiaryFlag)
+	}
+	if ctx.IsSet(BundlerMaxBundleSizeFlag.Name) {
+		cfg.MaxBundleSize = ctx.Int(BundlerMaxBundleSizeFlag.Name)
+	}
+	if ctx.IsSet(BundlerMempoolSizeFlag.Name) {
+		cfg.MempoolSize = ctx.Int(BundlerMempoolSizeFlag.Name)
+	}
+	if _, err := bundler.New(stack, backend, cfg); err != nil {
+		Fatalf("Failed to register the bundler service: %v", err)
+	}
+}
+
 // RegisterGraphQLService adds the GraphQL API to the node.
 func RegisterGraphQLService(stack *node.Node, backend ethapi.Backend, filterSystem *filters.FilterSystem, cfg *node.Config) {
 	err := graphql.New(stack, backend, filterSystem, cfg.GraphQLCors, cfg.GraphQLVirtualHosts)
@@ -1963,6 +2263,35 @@ func RegisterFilterAPI(stack *node.Node, backend ethapi.Backend, ethcfg *ethconf
 	return filterSystem
 }
 
+// RegisterCheckpointSyncer adds the checkpoint sync service into node.
+func RegisterCheckpointSyncer(ctx *cli.Context, stack *node.Node, eth *eth.rajchain) {
+	CheckExclusive(ctx, SyncTargetFlag, CheckpointSignerFlag)
+	CheckExclusive(ctx, SyncTargetFlag, CheckpointKeyFlag)
+
+	var cfg syncer.Config
+	if ctx.IsSet(CheckpointSignerFlag.Name) {
+		addr := ctx.String(CheckpointSignerFlag.Name)
+		if !common.IsHexAddress(addr) {
+			Fatalf("-%s: invalid address %q", CheckpointSignerFlag.Name, addr)
+		}
+		cfg.Signer = common.HexToAddress(addr)
+	}
+	if ctx.IsSet(CheckpointKeyFlag.Name) {
+		key, err := crypto.LoadECDSA(ctx.String(CheckpointKeyFlag.Name))
+		if err != nil {
+			Fatalf("Option %q: %v", CheckpointKeyFlag.Name, err)
+		}
+		cfg.Key = key
+	}
+	cfg.File = ctx.String(CheckpointFileFlag.Name)
+	cfg.URL = ctx.String(CheckpointURLFlag.Name)
+
+	if _, err := syncer.New(stack, eth, cfg); err != nil {
+		Fatalf("Failed to register the checkpoint syncer: %v", err)
+	}
+	log.Info("Registered checkpoint syncer", "signer", cfg.Signer, "file", cfg.File, "url", cfg.URL, "signing", cfg.Key != nil)
+}
+
 // RegisterFullSyncTester adds the full-sync tester service into node.
 func RegisterFullSyncTester(stack *node.Node, eth *eth.rajchain, target common.Hash) {
 	catalyst.RegisterFullSyncTester(stack, eth, target)
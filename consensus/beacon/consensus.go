@@ -405,19 +405,19 @@ func (beacon *Beacon) FinalizeAndAssemble(chain consensus.ChainHeaderReader, hea
 		vktPreTrie, okpre := preTrie.(*trie.VerkleTrie)
 		vktPostTrie, okpost := state.GetTrie().(*trie.VerkleTrie)
 
-		// The witness is only attached iff both parent and current block are
-		// using verkle tree.
+		// The witness is attached iff both parent and current block are using
+		// verkle tree, it's mandatory for such blocks. The ones not accessing
+		// any state carry an empty one, there's nothing to prove.
 		if okpre && okpost {
+			witness := new(types.ExecutionWitness)
 			if len(keys) > 0 {
 				verkleProof, stateDiff, err := vktPreTrie.Proof(vktPostTrie, keys)
 				if err != nil {
 					return nil, fmt.Errorf("error generating verkle proof for block %d: %w", header.Number, err)
 				}
-				block = block.WithWitness(&types.ExecutionWitness{
-					StateDiff:   stateDiff,
-					VerkleProof: verkleProof,
-				})
+				witness.StateDiff, witness.VerkleProof = stateDiff, verkleProof
 			}
+			block = block.WithWitness(witness)
 		}
 	}
 
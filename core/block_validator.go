@@ -22,6 +22,7 @@ import (
 
 	"github.com/rajchain/go-rajchain/consensus"
 	"github.com/rajchain/go-rajchain/core/state"
+	"github.com/rajchain/go-rajchain/core/stateless"
 	"github.com/rajchain/go-rajchain/core/types"
 	"github.com/rajchain/go-rajchain/params"
 	"github.com/rajchain/go-rajchain/trie"
@@ -107,6 +108,23 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 		}
 	}
 
+	// Verkle blocks carry the execution witness used to run them statelessly.
+	// It's not part of the body, but it's mandatory once the parent state is
+	// held by a verkle tree alone, the witness being proven against it.
+	if witness := block.ExecutionWitness(); witness != nil || v.config.IsVerkle(header.Number, header.Time) {
+		parent := v.bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
+		if parent == nil {
+			return consensus.ErrUnknownAncestor
+		}
+		if witness == nil {
+			if v.bc.witnessRequired(parent) {
+				return errMissingWitness
+			}
+		} else if err := stateless.VerifyVerkleWitness(witness, parent.Root, header.Root); err != nil {
+			return fmt.Errorf("invalid execution witness: %w", err)
+		}
+	}
+
 	// Ancestor block must be known.
 	if !v.bc.HasBlockAndState(block.ParentHash(), block.NumberU64()-1) {
 		if !v.bc.HasBlock(block.ParentHash(), block.NumberU64()-1) {
@@ -96,6 +96,9 @@ var (
 	errChainStopped         = errors.New("blockchain is stopped")
 	errInvalidOldChain      = errors.New("invalid old chain")
 	errInvalidNewChain      = errors.New("invalid new chain")
+
+	errVerkleTransitionScheme    = errors.New("verkle transition requires the path state scheme")
+	errVerkleTransitionPreimages = errors.New("verkle transition requires preimage recording")
 )
 
 const (
@@ -219,6 +222,7 @@ type BlockChain struct {
 	lastWrite     uint64                           // Last block when the state was flushed
 	flushInterval atomic.Int64                     // Time interval (processing time) after which to flush a state
 	triedb        *triedb.Database                 // The database handler for maintaining trie nodes.
+	verkledb      *triedb.Database                 // The database handler for the verkle tree the state is converted into, nil if no conversion
 	statedb       *state.CachingDB                 // State database to reuse between imports (contains state cache)
 	txIndexer     *txIndexer                       // Transaction indexer, might be nil if not enabled
 
@@ -259,6 +263,38 @@ type BlockChain struct {
 	processor  Processor // Block transaction processor interface
 	vmConfig   vm.Config
 	logger     *tracing.Hooks
+
+	badBlockHook atomic.Pointer[BadBlockHook] // Callback invoked with the blocks failing validation
+}
+
+// verkleSchedule reports whether the chain starts out with a verkle tree, and
+// whether it converts its merkle state into one at a later verkle fork. The
+// chain configuration is taken from the genesis if given, from the database
+// otherwise.
+func verkleSchedule(db ethdb.Database, genesis *Genesis, overrides *ChainOverrides) (bool, bool) {
+	var (
+		config       *params.ChainConfig
+		number, time uint64
+	)
+	if genesis != nil {
+		config, number, time = genesis.Config, genesis.Number, genesis.Timestamp
+	} else if hash := rawdb.ReadCanonicalHash(db, 0); hash != (common.Hash{}) {
+		config = rawdb.ReadChainConfig(db, hash)
+		if header := rawdb.ReadHeader(db, hash, 0); header != nil {
+			time = header.Time
+		}
+	}
+	if config == nil {
+		return false, false
+	}
+	cpy := *config
+	if overrides != nil && overrides.OverrideVerkle != nil {
+		cpy.VerkleTime = overrides.OverrideVerkle
+	}
+	if cpy.IsVerkle(new(big.Int).SetUint64(number), time) {
+		return true, false
+	}
+	return false, cpy.VerkleTime != nil
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -268,8 +304,30 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	if cacheConfig == nil {
 		cacheConfig = defaultCacheConfig
 	}
-	// Open trie database with provided config
-	triedb := triedb.NewDatabase(db, cacheConfig.triedbConfig(genesis != nil && genesis.IsVerkle()))
+	// Open trie database with provided config. Chains switching to a verkle
+	// tree at a later fork need the merkle state to be kept in path mode, with
+	// all the preimages needed to convert it.
+	isVerkle, inTransition := verkleSchedule(db, genesis, overrides)
+	if inTransition {
+		if cacheConfig.StateScheme != rawdb.PathScheme {
+			return nil, errVerkleTransitionScheme
+		}
+		if !cacheConfig.Preimages {
+			return nil, errVerkleTransitionPreimages
+		}
+	}
+	if (isVerkle || inTransition) && cacheConfig.SnapshotLimit > 0 {
+		// The snapshot only covers the merkle tree, turn it off
+		log.Warn("Disabling state snapshot for verkle chain")
+		cpy := *cacheConfig
+		cpy.SnapshotLimit = 0
+		cacheConfig = &cpy
+	}
+	var verkledb *triedb.Database
+	if inTransition {
+		verkledb = triedb.NewDatabase(db, cacheConfig.triedbConfig(true))
+	}
+	triedb := triedb.NewDatabase(db, cacheConfig.triedbConfig(isVerkle))
 
 	// Setup the genesis block, commit the provided genesis specification
 	// to database if the genesis block is not present yet, or load the
@@ -291,6 +349,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 		cacheConfig:   cacheConfig,
 		db:            db,
 		triedb:        triedb,
+		verkledb:      verkledb,
 		triegc:        prque.New[int64, common.Hash](nil),
 		quit:          make(chan struct{}),
 		chainmu:       syncx.NewClosableMutex(),
@@ -310,6 +369,9 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	}
 	bc.flushInterval.Store(int64(cacheConfig.TrieTimeLimit))
 	bc.statedb = state.NewDatabase(bc.triedb, nil)
+	if inTransition {
+		bc.statedb = state.NewTransitionDatabase(bc.triedb, bc.verkledb, nil)
+	}
 	bc.validator = NewBlockValidator(chainConfig, bc)
 	bc.prefetcher = newStatePrefetcher(chainConfig, bc.hc)
 	bc.processor = NewStateProcessor(chainConfig, bc.hc)
@@ -1117,7 +1179,18 @@ func (bc *BlockChain) Stop() {
 	}
 	if bc.triedb.Scheme() == rawdb.PathScheme {
 		// Ensure that the in-memory trie nodes are journaled to disk properly.
-		if err := bc.triedb.Journal(bc.CurrentBlock().Root); err != nil {
+		// Past the verkle fork, the merkle tree is frozen at the base of the
+		// transition, the head state being in the verkle tree.
+		root := bc.CurrentBlock().Root
+		if bc.verkledb != nil {
+			if ts := bc.statedb.TransitionState(root); ts != nil {
+				if err := bc.verkledb.Journal(root); err != nil {
+					log.Info("Failed to journal in-memory verkle nodes", "err", err)
+				}
+				root = ts.BaseRoot
+			}
+		}
+		if err := bc.triedb.Journal(root); err != nil {
 			log.Info("Failed to journal in-memory trie nodes", "err", err)
 		}
 	} else {
@@ -1161,6 +1234,11 @@ func (bc *BlockChain) Stop() {
 	if err := bc.triedb.Close(); err != nil {
 		log.Error("Failed to close trie database", "err", err)
 	}
+	if bc.verkledb != nil {
+		if err := bc.verkledb.Close(); err != nil {
+			log.Error("Failed to close verkle trie database", "err", err)
+		}
+	}
 	log.Info("Blockchain stopped")
 }
 
@@ -1415,6 +1493,21 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 	return 0, nil
 }
 
+// witnessRequired reports whether the blocks on top of the given parent must
+// carry their execution witness, which is the case once the state is held by a
+// verkle tree alone: from the verkle fork on for chains starting out on verkle,
+// past the end of the conversion of the merkle state otherwise.
+func (bc *BlockChain) witnessRequired(parent *types.Header) bool {
+	if !bc.chainConfig.IsVerkle(parent.Number, parent.Time) {
+		return false
+	}
+	if bc.verkledb == nil {
+		return true
+	}
+	ts := bc.statedb.TransitionState(parent.Root)
+	return ts != nil && ts.Ended
+}
+
 // writeBlockWithoutState writes only the block and its metadata to the database,
 // but does not write any state. This is used to construct competing side forks
 // up to the point where they exceed the canonical total difficulty.
@@ -1425,6 +1518,9 @@ func (bc *BlockChain) writeBlockWithoutState(block *types.Block, td *big.Int) (e
 	batch := bc.db.NewBatch()
 	rawdb.WriteTd(batch, block.Hash(), block.NumberU64(), td)
 	rawdb.WriteBlock(batch, block)
+	if witness := block.ExecutionWitness(); witness != nil {
+		rawdb.WriteExecutionWitness(batch, block.Hash(), block.NumberU64(), witness)
+	}
 	if err := batch.Write(); err != nil {
 		log.Crit("Failed to write block into disk", "err", err)
 	}
@@ -1462,8 +1558,14 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	blockBatch := bc.db.NewBatch()
 	rawdb.WriteTd(blockBatch, block.Hash(), block.NumberU64(), externTd)
 	rawdb.WriteBlock(blockBatch, block)
+	if witness := block.ExecutionWitness(); witness != nil {
+		rawdb.WriteExecutionWitness(blockBatch, block.Hash(), block.NumberU64(), witness)
+	}
 	rawdb.WriteReceipts(blockBatch, block.Hash(), block.NumberU64(), receipts)
 	rawdb.WritePreimages(blockBatch, statedb.Preimages())
+	if ts := statedb.TransitionState(); ts != nil {
+		state.WriteTransitionState(blockBatch, block.Root(), ts)
+	}
 	if err := blockBatch.Write(); err != nil {
 		log.Crit("Failed to write block into disk", "err", err)
 	}
@@ -1694,7 +1796,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool, makeWitness
 	// still need re-execution to generate snapshots that are missing
 	case err != nil && !errors.Is(err, ErrKnownBlock):
 		stats.ignored += len(it.chain)
-		bc.reportBlock(block, nil, err)
+		bc.reportBlock(block, BadBlockStageBody, nil, err)
 		return nil, it.index, err
 	}
 	// No validation errors for the first block (or chain prefix skipped)
@@ -1897,14 +1999,14 @@ func (bc *BlockChain) processBlock(block *types.Block, statedb *state.StateDB, s
 	pstart := time.Now()
 	res, err := bc.processor.Process(block, statedb, bc.vmConfig)
 	if err != nil {
-		bc.reportBlock(block, res, err)
+		bc.reportBlock(block, BadBlockStageExecution, res, err)
 		return nil, err
 	}
 	ptime := time.Since(pstart)
 
 	vstart := time.Now()
 	if err := bc.validator.ValidateState(block, statedb, res, false); err != nil {
-		bc.reportBlock(block, res, err)
+		bc.reportBlock(block, BadBlockStageState, res, err)
 		return nil, err
 	}
 	vtime := time.Since(vstart)
@@ -1918,23 +2020,8 @@ func (bc *BlockChain) processBlock(block *types.Block, statedb *state.StateDB, s
 	if witness := statedb.Witness(); witness != nil && bc.vmConfig.StatelessSelfValidation {
 		log.Warn("Running stateless self-validation", "block", block.Number(), "hash", block.Hash())
 
-		// Remove critical computed fields from the block to force true recalculation
-		context := block.Header()
-		context.Root = common.Hash{}
-		context.ReceiptHash = common.Hash{}
-
-		task := types.NewBlockWithHeader(context).WithBody(*block.Body())
-
-		// Run the stateless self-cross-validation
-		crossStateRoot, crossReceiptRoot, err := ExecuteStateless(bc.chainConfig, task, witness)
-		if err != nil {
-			return nil, fmt.Errorf("stateless self-validation failed: %v", err)
-		}
-		if crossStateRoot != block.Root() {
-			return nil, fmt.Errorf("stateless self-validation root mismatch (cross: %x local: %x)", crossStateRoot, block.Root())
-		}
-		if crossReceiptRoot != block.ReceiptHash() {
-			return nil, fmt.Errorf("stateless self-validation receipt root mismatch (cross: %x local: %x)", crossReceiptRoot, block.ReceiptHash())
+		if err := VerifyStateless(bc.chainConfig, block, witness); err != nil {
+			return nil, fmt.Errorf("stateless self-validation failed: %w", err)
 		}
 	}
 	xvtime := time.Since(xvstart)
@@ -2466,12 +2553,15 @@ func (bc *BlockChain) skipBlock(err error, it *insertIterator) bool {
 }
 
 // reportBlock logs a bad block error.
-func (bc *BlockChain) reportBlock(block *types.Block, res *ProcessResult, err error) {
+func (bc *BlockChain) reportBlock(block *types.Block, stage string, res *ProcessResult, err error) {
 	var receipts types.Receipts
 	if res != nil {
 		receipts = res.Receipts
 	}
 	rawdb.WriteBadBlock(bc.db, block)
+	if hook := bc.badBlockHook.Load(); hook != nil {
+		(*hook)(block, stage, res, err)
+	}
 	log.Error(summarizeBadBlock(block, receipts, bc.Config(), err))
 }
 
@@ -166,6 +166,13 @@ func (bc *BlockChain) GetBlock(hash common.Hash, number uint64) *types.Block {
 	if block == nil {
 		return nil
 	}
+	// The execution witness of verkle blocks is not part of the body, attach
+	// it back so the block can be validated again on reorgs.
+	if bc.chainConfig.IsVerkle(block.Number(), block.Time()) {
+		if witness := rawdb.ReadExecutionWitness(bc.db, hash, number); witness != nil {
+			block = block.WithWitness(witness)
+		}
+	}
 	// Cache the found block for next time and return
 	bc.blockCache.Add(block.Hash(), block)
 	return block
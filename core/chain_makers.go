@@ -32,6 +32,7 @@ import (
 	"github.com/rajchain/go-rajchain/ethdb"
 	"github.com/rajchain/go-rajchain/params"
 	"github.com/rajchain/go-rajchain/triedb"
+	"github.com/rajchain/go-rajchain/triedb/hashdb"
 	"github.com/rajchain/go-verkle"
 	"github.com/holiman/uint256"
 )
@@ -318,6 +319,10 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, engine conse
 		b := &BlockGen{i: i, cm: cm, parent: parent, statedb: statedb, engine: engine}
 		b.header = cm.makeHeader(parent, statedb, b.engine)
 
+		// Switch over to the verkle tree past the verkle fork
+		if err := ProcessVerkleTransition(statedb, config, b.header); err != nil {
+			panic(err)
+		}
 		// Set the difficulty for clique block. The chain maker doesn't have access
 		// to a chain, so the difficulty will be left unset (nil). Set it here to the
 		// correct value.
@@ -388,15 +393,26 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, engine conse
 		if err = triedb.Commit(root, false); err != nil {
 			panic(fmt.Sprintf("trie write error: %v", err))
 		}
+		if ts := statedb.TransitionState(); ts != nil {
+			state.WriteTransitionState(db, root, ts)
+		}
 		return block, b.receipts
 	}
 
+	// Chains scheduling the verkle fork convert the merkle state into a verkle
+	// tree, kept in memory only.
+	var verkledb *triedb.Database
+	if config.VerkleTime != nil {
+		verkledb = triedb.NewDatabase(db, triedb.VerkleDefaults)
+		defer verkledb.Close()
+	}
 	// Forcibly use hash-based state scheme for retaining all nodes in disk.
-	triedb := triedb.NewDatabase(db, triedb.HashDefaults)
+	triedb := triedb.NewDatabase(db, generatorTrieConfig(config))
 	defer triedb.Close()
 
+	sdb := state.NewTransitionDatabase(triedb, verkledb, nil)
 	for i := 0; i < n; i++ {
-		statedb, err := state.New(parent.Root(), state.NewDatabase(triedb, nil))
+		statedb, err := state.New(parent.Root(), sdb)
 		if err != nil {
 			panic(err)
 		}
@@ -432,12 +448,22 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, engine conse
 	return cm.chain, cm.receipts
 }
 
+// generatorTrieConfig returns the config of the hash-based trie database used
+// to generate a chain. Chains scheduling the verkle fork retain the preimages
+// of the merkle state, needed to convert it into a verkle tree.
+func generatorTrieConfig(config *params.ChainConfig) *triedb.Config {
+	if config == nil || config.VerkleTime == nil {
+		return triedb.HashDefaults
+	}
+	return &triedb.Config{Preimages: true, HashDB: hashdb.Defaults}
+}
+
 // GenerateChainWithGenesis is a wrapper of GenerateChain which will initialize
 // genesis block to database first according to the provided genesis specification
 // then generate chain on top.
 func GenerateChainWithGenesis(genesis *Genesis, engine consensus.Engine, n int, gen func(int, *BlockGen)) (ethdb.Database, []*types.Block, []types.Receipts) {
 	db := rawdb.NewMemoryDatabase()
-	triedb := triedb.NewDatabase(db, triedb.HashDefaults)
+	triedb := triedb.NewDatabase(db, generatorTrieConfig(genesis.Config))
 	defer triedb.Close()
 	_, err := genesis.Commit(db, triedb)
 	if err != nil {
@@ -30,6 +30,10 @@ var (
 	ErrNoGenesis = errors.New("genesis not found in chain")
 
 	errSideChainReceipts = errors.New("side blocks can't be accepted as ancient chain data")
+
+	// errMissingWitness is returned if a block on top of a verkle state doesn't
+	// carry the execution witness proving its state accesses.
+	errMissingWitness = errors.New("missing execution witness")
 )
 
 // List of evm-call-message pre-checking errors. All state transition messages will
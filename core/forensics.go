@@ -0,0 +1,283 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/core/rawdb"
+	"github.com/rajchain/go-rajchain/core/state"
+	"github.com/rajchain/go-rajchain/core/stateless"
+	"github.com/rajchain/go-rajchain/core/tracing"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/core/vm"
+	"github.com/rajchain/go-rajchain/ethdb"
+	"github.com/rajchain/go-rajchain/params"
+	"github.com/rajchain/go-rajchain/rlp"
+	"github.com/rajchain/go-rajchain/trie"
+)
+
+// Validation stages at which a block can fail, recorded in its report.
+const (
+	BadBlockStageBody      = "body"      // Header and body checks, before execution
+	BadBlockStageExecution = "execution" // Transaction processing
+	BadBlockStageState     = "state"     // State and receipt checks, after execution
+)
+
+// errReportWithoutWitness is returned when replaying a bad block report which
+// could not capture the parent state of the block.
+var errReportWithoutWitness = errors.New("bad block report has no witness")
+
+// BadBlockReport is the forensics record of a block failing validation, holding
+// everything needed to reproduce the failure away from the node which saw it.
+type BadBlockReport struct {
+	Config   *params.ChainConfig // Chain configuration the block was validated with
+	Block    *types.Block        // Block failing validation
+	Witness  *stateless.Witness  // Parent state accessed by the block, nil if unavailable
+	Receipts types.Receipts      // Receipts produced by the local execution, if it finished
+	Error    string              // Validation failure of the block
+	Stage    string              // Validation stage the block failed at
+	Time     uint64              // Unix time the failure was seen at
+
+	StateRoot   common.Hash // State root derived by the local execution, if it finished
+	ReceiptRoot common.Hash // Receipt root derived by the local execution, if it finished
+
+	Trace          []json.RawMessage // Execution trace of the block, one entry per opcode
+	TraceTruncated bool              // Whether the trace was cut at the size limit
+}
+
+// badBlockReportJSON is the JSON encoding of a bad block report, which is also
+// the format of exported bundles.
+type badBlockReportJSON struct {
+	Config         *params.ChainConfig `json:"config"`
+	Block          hexutil.Bytes       `json:"block"`
+	Witness        hexutil.Bytes       `json:"witness,omitempty"`
+	Receipts       []*types.Receipt    `json:"receipts"`
+	Error          string              `json:"error"`
+	Stage          string              `json:"stage,omitempty"`
+	Time           hexutil.Uint64      `json:"time"`
+	StateRoot      common.Hash         `json:"stateRoot"`
+	ReceiptRoot    common.Hash         `json:"receiptsRoot"`
+	Trace          []json.RawMessage   `json:"trace"`
+	TraceTruncated bool                `json:"traceTruncated,omitempty"`
+}
+
+// MarshalJSON encodes the report with the block and witness in their RLP form.
+func (r *BadBlockReport) MarshalJSON() ([]byte, error) {
+	block, err := rlp.EncodeToBytes(r.Block)
+	if err != nil {
+		return nil, err
+	}
+	enc := &badBlockReportJSON{
+		Config:         r.Config,
+		Block:          block,
+		Receipts:       make([]*types.Receipt, len(r.Receipts)),
+		Error:          r.Error,
+		Stage:          r.Stage,
+		Time:           hexutil.Uint64(r.Time),
+		StateRoot:      r.StateRoot,
+		ReceiptRoot:    r.ReceiptRoot,
+		Trace:          r.Trace,
+		TraceTruncated: r.TraceTruncated,
+	}
+	if r.Witness != nil {
+		if enc.Witness, err = rlp.EncodeToBytes(r.Witness); err != nil {
+			return nil, err
+		}
+	}
+	for i, receipt := range r.Receipts {
+		// Logs are mandatory in the JSON encoding, even if empty
+		cpy := *receipt
+		if cpy.Logs == nil {
+			cpy.Logs = []*types.Log{}
+		}
+		enc.Receipts[i] = &cpy
+	}
+	return json.Marshal(enc)
+}
+
+// UnmarshalJSON decodes a report encoded by MarshalJSON.
+func (r *BadBlockReport) UnmarshalJSON(input []byte) error {
+	var dec badBlockReportJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if dec.Config == nil {
+		return errors.New("missing chain config")
+	}
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(dec.Block, block); err != nil {
+		return err
+	}
+	var witness *stateless.Witness
+	if len(dec.Witness) > 0 {
+		witness = new(stateless.Witness)
+		if err := rlp.DecodeBytes(dec.Witness, witness); err != nil {
+			return err
+		}
+	}
+	*r = BadBlockReport{
+		Config:         dec.Config,
+		Block:          block,
+		Witness:        witness,
+		Receipts:       dec.Receipts,
+		Error:          dec.Error,
+		Stage:          dec.Stage,
+		Time:           uint64(dec.Time),
+		StateRoot:      dec.StateRoot,
+		ReceiptRoot:    dec.ReceiptRoot,
+		Trace:          dec.Trace,
+		TraceTruncated: dec.TraceTruncated,
+	}
+	return nil
+}
+
+// Replay re-executes the block of the report statelessly on top of its witness,
+// returning the validation failure it runs into, if any. The execution is traced
+// with the given hooks if non-nil.
+func (r *BadBlockReport) Replay(tracer *tracing.Hooks) error {
+	if r.Witness == nil {
+		return errReportWithoutWitness
+	}
+	return verifyStateless(r.Config, vm.Config{Tracer: tracer}, r.Block, r.Witness)
+}
+
+// BadBlockSummary is the metadata of a bad block report, stored apart from it to
+// list the reports without decoding their witness and trace.
+type BadBlockSummary struct {
+	Number         uint64
+	Hash           common.Hash
+	Time           uint64
+	Error          string
+	Witness        bool
+	TraceSteps     uint64
+	TraceTruncated bool
+	Stage          string `rlp:"optional"`
+}
+
+// ReadBadBlockReport retrieves the forensics report of the bad block with the
+// given hash, or nil if there's none.
+func ReadBadBlockReport(db ethdb.KeyValueStore, hash common.Hash) (*BadBlockReport, error) {
+	blob := rawdb.ReadBadBlockReport(db, hash)
+	if blob == nil {
+		return nil, nil
+	}
+	report := new(BadBlockReport)
+	if err := json.Unmarshal(blob, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// ReadBadBlockSummaries retrieves the summaries of all the bad block forensics
+// reports, sorted in reverse order by number.
+func ReadBadBlockSummaries(db ethdb.KeyValueStore) ([]*BadBlockSummary, error) {
+	var summaries []*BadBlockSummary
+	for _, blob := range rawdb.ReadAllBadBlockSummaries(db) {
+		summary := new(BadBlockSummary)
+		if err := rlp.DecodeBytes(blob, summary); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// WriteBadBlockReport stores the forensics report of a bad block along with its
+// summary.
+func WriteBadBlockReport(db ethdb.KeyValueStore, report *BadBlockReport) error {
+	blob, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	summary, err := rlp.EncodeToBytes(&BadBlockSummary{
+		Number:         report.Block.NumberU64(),
+		Hash:           report.Block.Hash(),
+		Time:           report.Time,
+		Error:          report.Error,
+		Witness:        report.Witness != nil,
+		TraceSteps:     uint64(len(report.Trace)),
+		TraceTruncated: report.TraceTruncated,
+		Stage:          report.Stage,
+	})
+	if err != nil {
+		return err
+	}
+	rawdb.WriteBadBlockReport(db, report.Block.NumberU64(), report.Block.Hash(), summary, blob)
+	return nil
+}
+
+// BadBlockHook is invoked with the blocks failing validation, along with the
+// stage they failed at and the result of their execution, nil if they failed
+// before or during it.
+type BadBlockHook func(block *types.Block, stage string, res *ProcessResult, err error)
+
+// SetBadBlockHook installs a callback invoked with every block failing validation.
+// The hook runs on the import path, so it must not block.
+func (bc *BlockChain) SetBadBlockHook(hook BadBlockHook) {
+	bc.badBlockHook.Store(&hook)
+}
+
+// NewBadBlockReport re-executes a block which failed validation at the given
+// stage on top of its parent state, creating a report of the failure with the
+// state accessed. The execution is traced with the given hooks if non-nil.
+//
+// The parent state is needed to re-execute the block, which might no longer be
+// available, in which case the report is returned without a witness.
+func (bc *BlockChain) NewBadBlockReport(block *types.Block, stage string, failure error, tracer *tracing.Hooks) (*BadBlockReport, error) {
+	report := &BadBlockReport{
+		Config: bc.chainConfig,
+		Block:  block,
+		Error:  failure.Error(),
+		Stage:  stage,
+		Time:   uint64(time.Now().Unix()),
+	}
+	parent := bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil || !bc.HasState(parent.Root) {
+		return report, nil
+	}
+	statedb, err := state.New(parent.Root, bc.statedb)
+	if err != nil {
+		return report, err
+	}
+	witness, err := stateless.NewWitness(block.Header(), bc)
+	if err != nil {
+		return report, err
+	}
+	statedb.StartPrefetcher("forensics", witness)
+	defer statedb.StopPrefetcher()
+
+	vmConfig := bc.vmConfig
+	vmConfig.Tracer = tracer
+
+	res, err := bc.processor.Process(block, statedb, vmConfig)
+
+	// Hashing the state pulls the trie nodes of the mutations into the witness,
+	// even if the execution stopped halfway
+	root := statedb.IntermediateRoot(bc.chainConfig.IsEIP158(block.Number()))
+	if err == nil {
+		report.Receipts = res.Receipts
+		report.StateRoot = root
+		report.ReceiptRoot = types.DeriveSha(res.Receipts, trie.NewStackTrie(nil))
+	}
+	report.Witness = witness
+	return report, nil
+}
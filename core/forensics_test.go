@@ -0,0 +1,133 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/consensus/beacon"
+	"github.com/rajchain/go-rajchain/core/rawdb"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/core/vm"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/params"
+)
+
+// Tests that a block failing validation is passed to the hook, and its report
+// can be stored, exported and replayed to reproduce the failure.
+func TestBadBlockReport(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		config  = *params.MergedTestChainConfig
+		signer  = types.LatestSigner(&config)
+		engine  = beacon.NewFaker()
+		gspec   = &Genesis{
+			Config:  &config,
+			Alloc:   types.GenesisAlloc{address: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 2, func(i int, gen *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(gen.TxNonce(address), common.Address{0x01}, big.NewInt(1000), params.TxGas, gen.header.BaseFee, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		gen.AddTx(tx)
+	})
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, DefaultCacheConfigWithScheme(rawdb.PathScheme), gspec, nil, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks[:1]); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	var (
+		reported *types.Block
+		stage    string
+		result   *ProcessResult
+		failure  error
+	)
+	chain.SetBadBlockHook(func(block *types.Block, s string, res *ProcessResult, err error) {
+		reported, stage, result, failure = block, s, res, err
+	})
+	// Tamper with the state root of the last block and import it
+	header := blocks[1].Header()
+	header.Root = common.Hash{0xde, 0xad}
+	bad := blocks[1].WithSeal(header)
+	if _, err := chain.InsertChain(types.Blocks{bad}); err == nil {
+		t.Fatalf("bad block imported")
+	}
+	if reported == nil || reported.Hash() != bad.Hash() {
+		t.Fatalf("bad block not passed to the hook")
+	}
+	if result == nil {
+		t.Fatalf("execution result not passed to the hook")
+	}
+	if stage != BadBlockStageState {
+		t.Fatalf("stage mismatch: have %q, want %q", stage, BadBlockStageState)
+	}
+	report, err := chain.NewBadBlockReport(bad, stage, failure, nil)
+	if err != nil {
+		t.Fatalf("failed to create report: %v", err)
+	}
+	if report.Witness == nil {
+		t.Fatalf("report without witness")
+	}
+	if report.StateRoot != blocks[1].Root() {
+		t.Fatalf("state root mismatch: have %x, want %x", report.StateRoot, blocks[1].Root())
+	}
+	if len(report.Receipts) != 1 {
+		t.Fatalf("receipt count mismatch: have %d, want 1", len(report.Receipts))
+	}
+	if err := WriteBadBlockReport(db, report); err != nil {
+		t.Fatalf("failed to store report: %v", err)
+	}
+	summaries, err := ReadBadBlockSummaries(db)
+	if err != nil {
+		t.Fatalf("failed to read summaries: %v", err)
+	}
+	if len(summaries) != 1 || summaries[0].Hash != bad.Hash() || !summaries[0].Witness || summaries[0].Error != failure.Error() {
+		t.Fatalf("unexpected summaries: %+v", summaries)
+	}
+	// Export the stored report and replay the bundle away from the chain
+	stored, err := ReadBadBlockReport(db, bad.Hash())
+	if err != nil || stored == nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	blob, err := json.Marshal(stored)
+	if err != nil {
+		t.Fatalf("failed to encode report: %v", err)
+	}
+	var bundle BadBlockReport
+	if err := json.Unmarshal(blob, &bundle); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	if bundle.Block.Hash() != bad.Hash() {
+		t.Fatalf("block mismatch: have %x, want %x", bundle.Block.Hash(), bad.Hash())
+	}
+	if err := bundle.Replay(nil); !errors.Is(err, ErrStatelessStateRoot) {
+		t.Fatalf("failure not reproduced: have %v, want %v", err, ErrStatelessStateRoot)
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package overlay implements the conversion of the merkle state into the verkle
+// overlay tree stacked onto it at the verkle fork (EIP-7612, EIP-7748).
+package overlay
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/core/state"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/rlp"
+	"github.com/rajchain/go-rajchain/trie"
+)
+
+// errMissingPreimage is returned if the conversion reaches a merkle leaf whose
+// key preimage is unknown. The preimages are needed to derive the verkle keys,
+// so they must have been recorded for the entire merkle state.
+var errMissingPreimage = errors.New("missing preimage")
+
+// Convert moves up to stride leaves of the frozen merkle tree into the verkle
+// overlay of a state in transition, advancing its transition progress. The
+// storage slots of an account are moved before the account itself. Leaves
+// already present in the overlay were written after the fork and are kept.
+//
+// It must be invoked at the start of the block, before any transaction is
+// executed. States not in transition are left untouched.
+func Convert(statedb *state.StateDB, stride int) error {
+	ts := statedb.TransitionState()
+	if ts == nil || ts.Ended {
+		return nil
+	}
+	tr, ok := statedb.GetTrie().(*trie.TransitionTrie)
+	if !ok {
+		return fmt.Errorf("unexpected trie %T in verkle transition", statedb.GetTrie())
+	}
+	left := stride
+	nodeIt, err := tr.Base().NodeIterator(ts.CurrentAccount[:])
+	if err != nil {
+		return err
+	}
+	it := trie.NewIterator(nodeIt)
+	for left > 0 {
+		if !it.Next() {
+			if it.Err != nil {
+				return it.Err
+			}
+			ts.Ended = true
+			return nil
+		}
+		hash := common.BytesToHash(it.Key)
+		if hash != ts.CurrentAccount {
+			ts.CurrentAccount, ts.CurrentSlot, ts.StorageDone = hash, common.Hash{}, false
+		}
+		preimage := tr.Base().GetKey(it.Key)
+		if len(preimage) != common.AddressLength {
+			return fmt.Errorf("%w of account %x", errMissingPreimage, hash)
+		}
+		addr := common.BytesToAddress(preimage)
+
+		var acc types.StateAccount
+		if err := rlp.DecodeBytes(it.Value, &acc); err != nil {
+			return fmt.Errorf("invalid account %x: %v", hash, err)
+		}
+		// Move the storage slots first, resuming where the last block stopped
+		if !ts.StorageDone {
+			done, err := convertStorage(tr, addr, ts, &left)
+			if err != nil {
+				return err
+			}
+			if !done {
+				return nil
+			}
+			ts.StorageDone = true
+		}
+		if left == 0 {
+			return nil
+		}
+		// Move the account along with its code
+		if err := convertAccount(tr, statedb.Database(), addr, &acc); err != nil {
+			return err
+		}
+		left--
+
+		next, overflow := increment(hash)
+		if overflow {
+			ts.Ended = true
+			return nil
+		}
+		ts.CurrentAccount, ts.CurrentSlot, ts.StorageDone = next, common.Hash{}, false
+	}
+	return nil
+}
+
+// convertStorage moves the storage slots of an account, starting from the
+// current slot of the transition, into the overlay until the stride runs out.
+// It returns whether all the slots of the account have been moved.
+func convertStorage(tr *trie.TransitionTrie, addr common.Address, ts *state.TransitionState, left *int) (bool, error) {
+	st, err := tr.BaseStorage(addr)
+	if err != nil {
+		return false, err
+	}
+	if st == nil {
+		return true, nil
+	}
+	nodeIt, err := st.NodeIterator(ts.CurrentSlot[:])
+	if err != nil {
+		return false, err
+	}
+	it := trie.NewIterator(nodeIt)
+	for it.Next() {
+		hash := common.BytesToHash(it.Key)
+		if *left == 0 {
+			ts.CurrentSlot = hash
+			return false, nil
+		}
+		key := st.GetKey(it.Key)
+		if len(key) != common.HashLength {
+			return false, fmt.Errorf("%w of slot %x of account %x", errMissingPreimage, hash, addr)
+		}
+		current, err := tr.Overlay().GetStorage(addr, key)
+		if err != nil {
+			return false, err
+		}
+		if current == nil {
+			_, value, _, err := rlp.Split(it.Value)
+			if err != nil {
+				return false, fmt.Errorf("invalid slot %x of account %x: %v", hash, addr, err)
+			}
+			if err := tr.Overlay().UpdateStorage(addr, key, value); err != nil {
+				return false, err
+			}
+		}
+		*left--
+	}
+	return true, it.Err
+}
+
+// convertAccount moves an account and its code into the overlay, unless the
+// account was already written there after the fork.
+func convertAccount(tr *trie.TransitionTrie, db state.Database, addr common.Address, acc *types.StateAccount) error {
+	current, err := tr.Overlay().GetAccount(addr)
+	if err != nil || current != nil {
+		return err
+	}
+	var code []byte
+	if codeHash := common.BytesToHash(acc.CodeHash); codeHash != types.EmptyCodeHash {
+		if code, err = db.ContractCode(addr, codeHash); err != nil {
+			return err
+		}
+	}
+	if err := tr.Overlay().UpdateAccount(addr, acc, len(code)); err != nil {
+		return err
+	}
+	if len(code) > 0 {
+		return tr.Overlay().UpdateContractCode(addr, common.BytesToHash(acc.CodeHash), code)
+	}
+	return nil
+}
+
+// increment returns the hash following the given one, and whether it overflowed.
+func increment(hash common.Hash) (common.Hash, bool) {
+	next := new(big.Int).Add(hash.Big(), common.Big1)
+	if next.BitLen() > 8*common.HashLength {
+		return common.Hash{}, true
+	}
+	return common.BigToHash(next), false
+}
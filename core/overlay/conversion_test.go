@@ -0,0 +1,111 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package overlay
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/core/rawdb"
+	"github.com/rajchain/go-rajchain/core/state"
+	"github.com/rajchain/go-rajchain/core/tracing"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/triedb"
+	"github.com/rajchain/go-rajchain/triedb/pathdb"
+	"github.com/holiman/uint256"
+)
+
+// Tests that the merkle state is converted into the verkle overlay across many
+// blocks, without overwriting anything written after the fork.
+func TestConvert(t *testing.T) {
+	var (
+		disk     = rawdb.NewMemoryDatabase()
+		mptdb    = triedb.NewDatabase(disk, &triedb.Config{Preimages: true, PathDB: pathdb.Defaults})
+		verkledb = triedb.NewDatabase(disk, triedb.VerkleDefaults)
+		sdb      = state.NewTransitionDatabase(mptdb, verkledb, nil)
+
+		plain    = common.HexToAddress("0x01")
+		contract = common.HexToAddress("0x02")
+		modified = common.HexToAddress("0x03")
+		code     = []byte{0x60, 0x00, 0x54, 0x00}
+		slots    = map[common.Hash]common.Hash{{0x1}: {0x1}, {0x2}: {0x2}, {0x3}: {0x3}}
+	)
+	// Create the merkle state to convert
+	statedb, _ := state.New(types.EmptyRootHash, sdb)
+	statedb.SetBalance(plain, uint256.NewInt(1), tracing.BalanceChangeUnspecified)
+	statedb.SetNonce(contract, 1)
+	statedb.SetCode(contract, code)
+	for key, val := range slots {
+		statedb.SetState(contract, key, val)
+	}
+	statedb.SetBalance(modified, uint256.NewInt(3), tracing.BalanceChangeUnspecified)
+	root, err := statedb.Commit(0, true)
+	if err != nil {
+		t.Fatalf("failed to commit merkle state: %v", err)
+	}
+	// Convert a single leaf per block, modifying an account at the fork
+	var blocks int
+	for ; ; blocks++ {
+		statedb, err = state.New(root, sdb)
+		if err != nil {
+			t.Fatalf("block %d: failed to open state: %v", blocks, err)
+		}
+		if blocks == 0 {
+			if err := statedb.StartVerkleTransition(); err != nil {
+				t.Fatalf("failed to start transition: %v", err)
+			}
+		}
+		if ts := statedb.TransitionState(); ts.Ended {
+			break
+		}
+		if err := Convert(statedb, 1); err != nil {
+			t.Fatalf("block %d: failed to convert: %v", blocks, err)
+		}
+		if blocks == 0 {
+			statedb.SetBalance(modified, uint256.NewInt(4), tracing.BalanceChangeUnspecified)
+		}
+		if root, err = statedb.Commit(uint64(blocks+1), true); err != nil {
+			t.Fatalf("block %d: failed to commit: %v", blocks, err)
+		}
+		state.WriteTransitionState(disk, root, statedb.TransitionState())
+	}
+	// Every leaf takes a block, plus one to notice the end of the merkle tree
+	if want := len(slots) + 3 + 1; blocks != want {
+		t.Errorf("conversion block count mismatch: have %d, want %d", blocks, want)
+	}
+	if !statedb.GetTrie().IsVerkle() {
+		t.Fatal("converted state not opened as verkle tree")
+	}
+	if balance := statedb.GetBalance(plain); balance.Uint64() != 1 {
+		t.Errorf("plain account balance mismatch: have %v, want 1", balance)
+	}
+	if balance := statedb.GetBalance(modified); balance.Uint64() != 4 {
+		t.Errorf("modified account balance mismatch: have %v, want 4", balance)
+	}
+	if nonce := statedb.GetNonce(contract); nonce != 1 {
+		t.Errorf("contract nonce mismatch: have %d, want 1", nonce)
+	}
+	if have := statedb.GetCode(contract); !bytes.Equal(have, code) {
+		t.Errorf("contract code mismatch: have %x, want %x", have, code)
+	}
+	for key, want := range slots {
+		if have := statedb.GetState(contract, key); have != want {
+			t.Errorf("slot %x mismatch: have %x, want %x", key, have, want)
+		}
+	}
+}
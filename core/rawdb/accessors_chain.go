@@ -19,6 +19,7 @@ package rawdb
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"slices"
@@ -556,6 +557,40 @@ func DeleteTd(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
 	}
 }
 
+// ReadExecutionWitness retrieves the verkle execution witness of a block, nil
+// if the block didn't carry any.
+func ReadExecutionWitness(db ethdb.KeyValueReader, hash common.Hash, number uint64) *types.ExecutionWitness {
+	data, _ := db.Get(executionWitnessKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	witness := new(types.ExecutionWitness)
+	if err := json.Unmarshal(data, witness); err != nil {
+		log.Error("Invalid execution witness JSON", "hash", hash, "err", err)
+		return nil
+	}
+	return witness
+}
+
+// WriteExecutionWitness stores the verkle execution witness of a block, which
+// is not part of its body.
+func WriteExecutionWitness(db ethdb.KeyValueWriter, hash common.Hash, number uint64, witness *types.ExecutionWitness) {
+	data, err := json.Marshal(witness)
+	if err != nil {
+		log.Crit("Failed to JSON encode execution witness", "err", err)
+	}
+	if err := db.Put(executionWitnessKey(number, hash), data); err != nil {
+		log.Crit("Failed to store execution witness", "err", err)
+	}
+}
+
+// DeleteExecutionWitness removes the verkle execution witness of a block.
+func DeleteExecutionWitness(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(executionWitnessKey(number, hash)); err != nil {
+		log.Crit("Failed to delete execution witness", "err", err)
+	}
+}
+
 // HasReceipts verifies the existence of all the transaction receipts belonging
 // to a block.
 func HasReceipts(db ethdb.Reader, hash common.Hash, number uint64) bool {
@@ -790,6 +825,7 @@ func DeleteBlock(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
 	DeleteReceipts(db, hash, number)
 	DeleteHeader(db, hash, number)
 	DeleteBody(db, hash, number)
+	DeleteExecutionWitness(db, hash, number)
 	DeleteTd(db, hash, number)
 }
 
@@ -799,6 +835,7 @@ func DeleteBlockWithoutNumber(db ethdb.KeyValueWriter, hash common.Hash, number
 	DeleteReceipts(db, hash, number)
 	deleteHeaderWithoutNumber(db, hash, number)
 	DeleteBody(db, hash, number)
+	DeleteExecutionWitness(db, hash, number)
 	DeleteTd(db, hash, number)
 }
 
@@ -951,3 +988,98 @@ func ReadHeadBlock(db ethdb.Reader) *types.Block {
 	}
 	return ReadBlock(db, headBlockHash, *headBlockNumber)
 }
+
+// badBlockReportsToKeep is the number of bad block forensics reports retained,
+// the reports of the lowest blocks being dropped first.
+const badBlockReportsToKeep = 16
+
+// ReadBadBlockReport retrieves the forensics report of the bad block with the
+// given hash.
+func ReadBadBlockReport(db ethdb.KeyValueStore, hash common.Hash) []byte {
+	number := ReadBadBlockReportNumber(db, hash)
+	if number == nil {
+		return nil
+	}
+	data, _ := db.Get(badBlockReportKey(*number, hash))
+	return data
+}
+
+// ReadBadBlockReportNumber returns the number of the bad block with the given
+// hash if it has a forensics report, nil otherwise.
+func ReadBadBlockReportNumber(db ethdb.KeyValueStore, hash common.Hash) *uint64 {
+	it := db.NewIterator(badBlockSummaryPrefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		if key := it.Key(); len(key) == len(badBlockSummaryPrefix)+8+common.HashLength && common.BytesToHash(key[len(key)-common.HashLength:]) == hash {
+			number := binary.BigEndian.Uint64(key[len(badBlockSummaryPrefix):])
+			return &number
+		}
+	}
+	return nil
+}
+
+// ReadAllBadBlockSummaries retrieves the summaries of all the bad block forensics
+// reports in the database, sorted in reverse order by number.
+func ReadAllBadBlockSummaries(db ethdb.Iteratee) [][]byte {
+	it := db.NewIterator(badBlockSummaryPrefix, nil)
+	defer it.Release()
+
+	var summaries [][]byte
+	for it.Next() {
+		if len(it.Key()) == len(badBlockSummaryPrefix)+8+common.HashLength {
+			summaries = append(summaries, common.CopyBytes(it.Value()))
+		}
+	}
+	slices.Reverse(summaries)
+	return summaries
+}
+
+// WriteBadBlockReport stores the forensics report of a bad block, along with
+// the summary used to list it. If the number of reports exceeds the limitation,
+// the ones of the lowest blocks are dropped.
+func WriteBadBlockReport(db ethdb.KeyValueStore, number uint64, hash common.Hash, summary []byte, report []byte) {
+	if err := db.Put(badBlockReportKey(number, hash), report); err != nil {
+		log.Crit("Failed to store bad block report", "err", err)
+	}
+	if err := db.Put(badBlockSummaryKey(number, hash), summary); err != nil {
+		log.Crit("Failed to store bad block summary", "err", err)
+	}
+	var keys [][]byte
+	it := db.NewIterator(badBlockSummaryPrefix, nil)
+	for it.Next() {
+		if len(it.Key()) == len(badBlockSummaryPrefix)+8+common.HashLength {
+			keys = append(keys, common.CopyBytes(it.Key()))
+		}
+	}
+	it.Release()
+
+	for len(keys) > badBlockReportsToKeep {
+		deleteBadBlockReport(db, keys[0][len(badBlockSummaryPrefix):])
+		keys = keys[1:]
+	}
+}
+
+// DeleteBadBlockReports deletes all the bad block forensics reports from the
+// database.
+func DeleteBadBlockReports(db ethdb.KeyValueStore) {
+	it := db.NewIterator(badBlockSummaryPrefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		if key := it.Key(); len(key) == len(badBlockSummaryPrefix)+8+common.HashLength {
+			deleteBadBlockReport(db, key[len(badBlockSummaryPrefix):])
+		}
+	}
+}
+
+// deleteBadBlockReport deletes the forensics report and summary of a bad block,
+// identified by its number and hash.
+func deleteBadBlockReport(db ethdb.KeyValueWriter, id []byte) {
+	if err := db.Delete(append(common.CopyBytes(badBlockReportPrefix), id...)); err != nil {
+		log.Crit("Failed to delete bad block report", "err", err)
+	}
+	if err := db.Delete(append(common.CopyBytes(badBlockSummaryPrefix), id...)); err != nil {
+		log.Crit("Failed to delete bad block summary", "err", err)
+	}
+}
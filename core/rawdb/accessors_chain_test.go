@@ -258,6 +258,50 @@ func TestBadBlockStorage(t *testing.T) {
 	}
 }
 
+// Tests bad block report storage and retrieval operations.
+func TestBadBlockReportStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	hash := common.Hash{0x01}
+	if entry := ReadBadBlockReport(db, hash); entry != nil {
+		t.Fatalf("Non existent report returned: %x", entry)
+	}
+	WriteBadBlockReport(db, 1, hash, []byte("summary"), []byte("report"))
+	if entry := ReadBadBlockReport(db, hash); !bytes.Equal(entry, []byte("report")) {
+		t.Fatalf("Retrieved report mismatch: have %x, want %x", entry, []byte("report"))
+	}
+	if number := ReadBadBlockReportNumber(db, hash); number == nil || *number != 1 {
+		t.Fatalf("Retrieved report number mismatch: have %v, want 1", number)
+	}
+	// Write a bunch of reports, their summaries should be sorted in reverse order
+	// by number with the lowest ones dropped.
+	for _, n := range rand.Perm(100) {
+		WriteBadBlockReport(db, uint64(n), common.Hash{byte(n), 0xff}, []byte{byte(n)}, []byte{byte(n), 0xff})
+	}
+	summaries := ReadAllBadBlockSummaries(db)
+	if len(summaries) != badBlockReportsToKeep {
+		t.Fatalf("The number of persisted reports is incorrect %d", len(summaries))
+	}
+	for i, summary := range summaries {
+		if want := byte(99 - i); summary[0] != want {
+			t.Fatalf("Summary %d mismatch: have %x, want %x", i, summary, want)
+		}
+	}
+	if entry := ReadBadBlockReport(db, hash); entry != nil {
+		t.Fatalf("Dropped report returned: %x", entry)
+	}
+	if entry, _ := db.Get(badBlockReportKey(1, hash)); entry != nil {
+		t.Fatalf("Dropped report retained: %x", entry)
+	}
+	DeleteBadBlockReports(db)
+	if summaries := ReadAllBadBlockSummaries(db); len(summaries) != 0 {
+		t.Fatalf("Failed to delete reports")
+	}
+	if entry, _ := db.Get(badBlockReportKey(99, common.Hash{99, 0xff})); entry != nil {
+		t.Fatalf("Deleted report retained: %x", entry)
+	}
+}
+
 // Tests block total difficulty storage and retrieval operations.
 func TestTdStorage(t *testing.T) {
 	db := NewMemoryDatabase()
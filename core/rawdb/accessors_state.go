@@ -121,6 +121,21 @@ func DeleteStateID(db ethdb.KeyValueWriter, root common.Hash) {
 	}
 }
 
+// ReadTransitionState retrieves the encoded verkle transition progress of the
+// state with the provided root.
+func ReadTransitionState(db ethdb.KeyValueReader, root common.Hash) []byte {
+	data, _ := db.Get(transitionStateKey(root))
+	return data
+}
+
+// WriteTransitionState stores the encoded verkle transition progress of the
+// state with the provided root.
+func WriteTransitionState(db ethdb.KeyValueWriter, root common.Hash, state []byte) {
+	if err := db.Put(transitionStateKey(root), state); err != nil {
+		log.Crit("Failed to store verkle transition state", "err", err)
+	}
+}
+
 // ReadPersistentStateID retrieves the id of the persistent state from the database.
 func ReadPersistentStateID(db ethdb.KeyValueReader) uint64 {
 	data, _ := db.Get(persistentStateIDKey)
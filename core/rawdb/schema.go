@@ -102,8 +102,9 @@ var (
 	headerHashSuffix   = []byte("n") // headerPrefix + num (uint64 big endian) + headerHashSuffix -> hash
 	headerNumberPrefix = []byte("H") // headerNumberPrefix + hash -> num (uint64 big endian)
 
-	blockBodyPrefix     = []byte("b") // blockBodyPrefix + num (uint64 big endian) + hash -> block body
-	blockReceiptsPrefix = []byte("r") // blockReceiptsPrefix + num (uint64 big endian) + hash -> block receipts
+	blockBodyPrefix        = []byte("b") // blockBodyPrefix + num (uint64 big endian) + hash -> block body
+	blockReceiptsPrefix    = []byte("r") // blockReceiptsPrefix + num (uint64 big endian) + hash -> block receipts
+	executionWitnessPrefix = []byte("w") // executionWitnessPrefix + num (uint64 big endian) + hash -> verkle execution witness
 
 	txLookupPrefix        = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix       = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
@@ -124,6 +125,18 @@ var (
 	// (d) State ID lookups, etc.
 	VerklePrefix = []byte("v")
 
+	// transitionStatePrefix + state root -> progress of the conversion of the
+	// merkle state into a verkle tree, for every state past the verkle fork.
+	transitionStatePrefix = []byte("transition-state-")
+
+	// badBlockReportPrefix + num (uint64 big endian) + hash -> forensics report
+	// of a block which failed validation.
+	badBlockReportPrefix = []byte("BadBlockReport-")
+
+	// badBlockSummaryPrefix + num (uint64 big endian) + hash -> summary of the
+	// forensics report of a bad block.
+	badBlockSummaryPrefix = []byte("BadBlockSummary-")
+
 	PreimagePrefix = []byte("secure-key-")       // PreimagePrefix + hash -> preimage
 	configPrefix   = []byte("rajchain-config-")  // config prefix for the db
 	genesisPrefix  = []byte("rajchain-genesis-") // genesis state prefix for the db
@@ -199,6 +212,11 @@ func blockReceiptsKey(number uint64, hash common.Hash) []byte {
 	return append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
 
+// executionWitnessKey = executionWitnessPrefix + num (uint64 big endian) + hash
+func executionWitnessKey(number uint64, hash common.Hash) []byte {
+	return append(append(executionWitnessPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
 // txLookupKey = txLookupPrefix + hash
 func txLookupKey(hash common.Hash) []byte {
 	return append(txLookupPrefix, hash.Bytes()...)
@@ -272,6 +290,21 @@ func stateIDKey(root common.Hash) []byte {
 	return append(stateIDPrefix, root.Bytes()...)
 }
 
+// transitionStateKey = transitionStatePrefix + root (32 bytes)
+func transitionStateKey(root common.Hash) []byte {
+	return append(transitionStatePrefix, root.Bytes()...)
+}
+
+// badBlockReportKey = badBlockReportPrefix + num (uint64 big endian) + hash
+func badBlockReportKey(number uint64, hash common.Hash) []byte {
+	return append(append(badBlockReportPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// badBlockSummaryKey = badBlockSummaryPrefix + num (uint64 big endian) + hash
+func badBlockSummaryKey(number uint64, hash common.Hash) []byte {
+	return append(append(badBlockSummaryPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
 // accountTrieNodeKey = TrieNodeAccountPrefix + nodePath.
 func accountTrieNodeKey(path []byte) []byte {
 	return append(TrieNodeAccountPrefix, path...)
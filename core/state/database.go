@@ -67,6 +67,15 @@ type Database interface {
 	// TrieDB returns the underlying trie database for managing trie nodes.
 	TrieDB() *triedb.Database
 
+	// VerkleTrieDB returns the trie database holding the verkle tree the merkle
+	// state is converted into at the verkle fork, nil if there's no conversion.
+	VerkleTrieDB() *triedb.Database
+
+	// TransitionState returns the progress of the conversion of the merkle state
+	// into a verkle tree at the given root, nil if the state is not past the
+	// verkle fork of a converted chain.
+	TransitionState(root common.Hash) *TransitionState
+
 	// Snapshot returns the underlying state snapshot.
 	Snapshot() *snapshot.Tree
 }
@@ -154,6 +163,7 @@ type Trie interface {
 type CachingDB struct {
 	disk          ethdb.KeyValueStore
 	triedb        *triedb.Database
+	verkledb      *triedb.Database // Verkle tree the merkle state is converted into, if any
 	snap          *snapshot.Tree
 	codeCache     *lru.SizeConstrainedCache[common.Hash, []byte]
 	codeSizeCache *lru.Cache[common.Hash, int]
@@ -172,6 +182,16 @@ func NewDatabase(triedb *triedb.Database, snap *snapshot.Tree) *CachingDB {
 	}
 }
 
+// NewTransitionDatabase creates a state database for a chain converting its
+// merkle state into a verkle tree at the verkle fork. States before the fork
+// are held by triedb, the ones past it by the verkle tree in verkledb, built
+// on top of the frozen merkle state.
+func NewTransitionDatabase(triedb *triedb.Database, verkledb *triedb.Database, snap *snapshot.Tree) *CachingDB {
+	db := NewDatabase(triedb, snap)
+	db.verkledb = verkledb
+	return db
+}
+
 // NewDatabaseForTesting is similar to NewDatabase, but it initializes the caching
 // db by using an ephemeral memory db with default config for testing.
 func NewDatabaseForTesting() *CachingDB {
@@ -193,11 +213,11 @@ func (db *CachingDB) Reader(stateRoot common.Hash) (Reader, error) {
 	}
 	// Set up the trie reader, which is expected to always be available
 	// as the gatekeeper unless the state is corrupted.
-	tr, err := newTrieReader(stateRoot, db.triedb, db.pointCache)
+	tr, err := db.OpenTrie(stateRoot)
 	if err != nil {
 		return nil, err
 	}
-	readers = append(readers, tr)
+	readers = append(readers, newTrieReader(stateRoot, db.triedb, tr))
 
 	return newMultiReader(readers...)
 }
@@ -207,6 +227,18 @@ func (db *CachingDB) OpenTrie(root common.Hash) (Trie, error) {
 	if db.triedb.IsVerkle() {
 		return trie.NewVerkleTrie(root, db.triedb, db.pointCache)
 	}
+	// States past the verkle fork of a converted chain are held by the verkle
+	// tree, stacked onto the frozen merkle state until it is fully converted.
+	if ts := db.TransitionState(root); ts != nil {
+		overlay, err := trie.NewVerkleTrie(root, db.verkledb, db.pointCache)
+		if err != nil {
+			return nil, err
+		}
+		if ts.Ended {
+			return overlay, nil
+		}
+		return trie.NewTransitionTrie(overlay, ts.BaseRoot, db.triedb)
+	}
 	tr, err := trie.NewStateTrie(trie.StateTrieID(root), db.triedb)
 	if err != nil {
 		return nil, err
@@ -219,7 +251,7 @@ func (db *CachingDB) OpenStorageTrie(stateRoot common.Hash, address common.Addre
 	// In the verkle case, there is only one tree. But the two-tree structure
 	// is hardcoded in the codebase. So we need to return the same trie in this
 	// case.
-	if db.triedb.IsVerkle() {
+	if db.triedb.IsVerkle() || (self != nil && self.IsVerkle()) {
 		return self, nil
 	}
 	tr, err := trie.NewStateTrie(trie.StorageTrieID(stateRoot, crypto.Keccak256Hash(address.Bytes()), root), db.triedb)
@@ -275,6 +307,21 @@ func (db *CachingDB) TrieDB() *triedb.Database {
 	return db.triedb
 }
 
+// VerkleTrieDB retrieves the trie database holding the verkle tree the merkle
+// state is converted into, if any.
+func (db *CachingDB) VerkleTrieDB() *triedb.Database {
+	return db.verkledb
+}
+
+// TransitionState retrieves the progress of the conversion of the merkle state
+// into a verkle tree at the given root.
+func (db *CachingDB) TransitionState(root common.Hash) *TransitionState {
+	if db.verkledb == nil {
+		return nil
+	}
+	return readTransitionState(db.disk, root)
+}
+
 // PointCache returns the cache of evaluated curve points.
 func (db *CachingDB) PointCache() *utils.PointCache {
 	return db.pointCache
@@ -292,6 +339,8 @@ func mustCopyTrie(t Trie) Trie {
 		return t.Copy()
 	case *trie.VerkleTrie:
 		return t.Copy()
+	case *trie.TransitionTrie:
+		return t.Copy()
 	default:
 		panic(fmt.Errorf("unknown trie type %T", t))
 	}
@@ -25,7 +25,6 @@ import (
 	"github.com/rajchain/go-rajchain/crypto"
 	"github.com/rajchain/go-rajchain/rlp"
 	"github.com/rajchain/go-rajchain/trie"
-	"github.com/rajchain/go-rajchain/trie/utils"
 	"github.com/rajchain/go-rajchain/triedb"
 	"github.com/rajchain/go-rajchain/triedb/database"
 )
@@ -142,21 +141,9 @@ type trieReader struct {
 	subTries map[common.Address]Trie        // Group of storage tries, cached when it's resolved
 }
 
-// trieReader constructs a trie reader of the specific state. An error will be
-// returned if the associated trie specified by root is not existent.
-func newTrieReader(root common.Hash, db *triedb.Database, cache *utils.PointCache) (*trieReader, error) {
-	var (
-		tr  Trie
-		err error
-	)
-	if !db.IsVerkle() {
-		tr, err = trie.NewStateTrie(trie.StateTrieID(root), db)
-	} else {
-		tr, err = trie.NewVerkleTrie(root, db, cache)
-	}
-	if err != nil {
-		return nil, err
-	}
+// newTrieReader constructs a trie reader of the specific state on top of its
+// opened main trie.
+func newTrieReader(root common.Hash, db *triedb.Database, tr Trie) *trieReader {
 	return &trieReader{
 		root:     root,
 		db:       db,
@@ -164,7 +151,7 @@ func newTrieReader(root common.Hash, db *triedb.Database, cache *utils.PointCach
 		mainTrie: tr,
 		subRoots: make(map[common.Address]common.Hash),
 		subTries: make(map[common.Address]Trie),
-	}, nil
+	}
 }
 
 // Account implements Reader, retrieving the account specified by the address.
@@ -195,7 +182,7 @@ func (r *trieReader) Storage(addr common.Address, key common.Hash) (common.Hash,
 		found bool
 		value common.Hash
 	)
-	if r.db.IsVerkle() {
+	if r.mainTrie.IsVerkle() {
 		tr = r.mainTrie
 	} else {
 		tr, found = r.subTries[addr]
@@ -138,6 +138,10 @@ type StateDB struct {
 	// State witness if cross validation is needed
 	witness *stateless.Witness
 
+	// Progress of the conversion of the merkle state into a verkle tree, set
+	// for states past the verkle fork of a converted chain.
+	transition *TransitionState
+
 	// Measurements gathered during execution for debugging purposes
 	AccountReads    time.Duration
 	AccountHashes   time.Duration
@@ -180,8 +184,9 @@ func New(root common.Hash, db Database) (*StateDB, error) {
 		journal:              newJournal(),
 		accessList:           newAccessList(),
 		transientStorage:     newTransientStorage(),
+		transition:           db.TransitionState(root),
 	}
-	if db.TrieDB().IsVerkle() {
+	if tr.IsVerkle() {
 		sdb.accessEvents = NewAccessEvents(db.PointCache())
 	}
 	return sdb, nil
@@ -197,6 +202,12 @@ func (s *StateDB) StartPrefetcher(namespace string, witness *stateless.Witness)
 	// Enable witness collection if requested
 	s.witness = witness
 
+	// The states past the verkle fork of a converted chain are held by a trie
+	// database the prefetcher is not aware of, don't bother prefetching.
+	if s.transition != nil {
+		return
+	}
+
 	// With the switch to the Proof-of-Stake consensus algorithm, block production
 	// rewards are now handled at the consensus layer. Consequently, a block may
 	// have no state transitions if it contains no transactions and no withdrawals.
@@ -679,6 +690,9 @@ func (s *StateDB) Copy() *StateDB {
 	if s.witness != nil {
 		state.witness = s.witness.Copy()
 	}
+	if s.transition != nil {
+		state.transition = s.transition.Copy()
+	}
 	if s.accessEvents != nil {
 		state.accessEvents = s.accessEvents.Copy()
 	}
@@ -787,7 +801,7 @@ func (s *StateDB) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
 		start   = time.Now()
 		workers errgroup.Group
 	)
-	if s.db.TrieDB().IsVerkle() {
+	if s.trie.IsVerkle() {
 		// Whilst MPT storage tries are independent, Verkle has one single trie
 		// for all the accounts and all the storage slots merged together. The
 		// former can thus be simply parallelized, but updating the latter will
@@ -801,7 +815,7 @@ func (s *StateDB) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
 		}
 		obj := s.stateObjects[addr] // closure for the task runner below
 		workers.Go(func() error {
-			if s.db.TrieDB().IsVerkle() {
+			if s.trie.IsVerkle() {
 				obj.updateTrie()
 			} else {
 				obj.updateRoot()
@@ -818,7 +832,7 @@ func (s *StateDB) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
 	// If witness building is enabled, gather all the read-only accesses.
 	// Skip witness collection in Verkle mode, they will be gathered
 	// together at the end.
-	if s.witness != nil && !s.db.TrieDB().IsVerkle() {
+	if s.witness != nil && !s.trie.IsVerkle() {
 		// Pull in anything that has been accessed before destruction
 		for _, obj := range s.stateObjectsDestruct {
 			// Skip any objects that haven't touched their storage
@@ -908,8 +922,9 @@ func (s *StateDB) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
 
 	hash := s.trie.Hash()
 
-	// If witness building is enabled, gather the account trie witness
-	if s.witness != nil {
+	// If witness building is enabled, gather the account trie witness. Verkle
+	// blocks carry their own execution witness instead.
+	if s.witness != nil && !s.trie.IsVerkle() {
 		s.witness.AddState(s.trie.Witness())
 	}
 	return hash
@@ -1075,7 +1090,7 @@ func (s *StateDB) handleDestruction() (map[common.Hash]*accountDelete, []*trieno
 		deletes[addrHash] = op
 
 		// Short circuit if the origin storage was empty.
-		if prev.Root == types.EmptyRootHash || s.db.TrieDB().IsVerkle() {
+		if prev.Root == types.EmptyRootHash || s.trie.IsVerkle() {
 			continue
 		}
 		// Remove storage slots belonging to the account.
@@ -1270,9 +1285,23 @@ func (s *StateDB) commitAndFlush(block uint64, deleteEmptyObjects bool) (*stateU
 			return nil, err
 		}
 	}
+	// The states of a tree in transition are persisted into the verkle tree.
+	// The first one is built on top of an empty verkle tree, rather than on the
+	// merkle state. The progress of the conversion is left to the caller.
+	var (
+		tdb    = s.db.TrieDB()
+		origin = ret.originRoot
+	)
+	if s.transition != nil && !tdb.IsVerkle() {
+		tdb = s.db.VerkleTrieDB()
+		if origin == s.transition.BaseRoot {
+			origin = types.EmptyRootHash
+		}
+	}
 	if !ret.empty() {
-		// If snapshotting is enabled, update the snapshot tree with this new version
-		if snap := s.db.Snapshot(); snap != nil && snap.Snapshot(ret.originRoot) != nil {
+		// If snapshotting is enabled, update the snapshot tree with this new version.
+		// The snapshots are only maintained for merkle states.
+		if snap := s.db.Snapshot(); snap != nil && !s.trie.IsVerkle() && snap.Snapshot(ret.originRoot) != nil {
 			start := time.Now()
 			if err := snap.Update(ret.root, ret.originRoot, ret.accounts, ret.storages); err != nil {
 				log.Warn("Failed to update snapshot tree", "from", ret.originRoot, "to", ret.root, "err", err)
@@ -1287,9 +1316,9 @@ func (s *StateDB) commitAndFlush(block uint64, deleteEmptyObjects bool) (*stateU
 			s.SnapshotCommits += time.Since(start)
 		}
 		// If trie database is enabled, commit the state update as a new layer
-		if db := s.db.TrieDB(); db != nil {
+		if tdb != nil {
 			start := time.Now()
-			if err := db.Update(ret.root, ret.originRoot, block, ret.nodes, ret.stateSet()); err != nil {
+			if err := tdb.Update(ret.root, origin, block, ret.nodes, ret.stateSet()); err != nil {
 				return nil, err
 			}
 			s.TrieDBCommits += time.Since(start)
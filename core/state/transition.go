@@ -0,0 +1,114 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"errors"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/core/rawdb"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/ethdb"
+	"github.com/rajchain/go-rajchain/log"
+	"github.com/rajchain/go-rajchain/rlp"
+	"github.com/rajchain/go-rajchain/trie"
+)
+
+// errNoVerkleTransition is returned if a merkle state is switched to a verkle
+// tree, but the database was not set up with a verkle tree to convert into.
+var errNoVerkleTransition = errors.New("verkle transition not configured")
+
+// TransitionState tracks the progress of the conversion of the merkle state
+// into a verkle tree (EIP-7748). It is stored alongside every state root past
+// the verkle fork of a chain that started out with a merkle tree.
+type TransitionState struct {
+	BaseRoot       common.Hash // Root of the merkle tree, frozen at the fork
+	CurrentAccount common.Hash // Hash of the account being converted
+	CurrentSlot    common.Hash // Hash of the next storage slot of the current account to convert
+	StorageDone    bool        // Whether all the storage slots of the current account are converted
+	Ended          bool        // Whether the entire merkle tree has been converted
+}
+
+// Copy returns a deep-copied transition state.
+func (ts *TransitionState) Copy() *TransitionState {
+	cpy := *ts
+	return &cpy
+}
+
+// readTransitionState retrieves the transition progress of the state with the
+// given root, nil if the state is not past the verkle fork of a converted chain.
+func readTransitionState(db ethdb.KeyValueReader, root common.Hash) *TransitionState {
+	blob := rawdb.ReadTransitionState(db, root)
+	if len(blob) == 0 {
+		return nil
+	}
+	ts := new(TransitionState)
+	if err := rlp.DecodeBytes(blob, ts); err != nil {
+		log.Error("Invalid verkle transition state", "root", root, "err", err)
+		return nil
+	}
+	return ts
+}
+
+// WriteTransitionState stores the transition progress of the state with the
+// given root. It is written along with the block committing the state, rather
+// than with the state itself, so that only imported blocks leave it behind.
+func WriteTransitionState(db ethdb.KeyValueWriter, root common.Hash, ts *TransitionState) {
+	blob, err := rlp.EncodeToBytes(ts)
+	if err != nil {
+		log.Crit("Failed to encode verkle transition state", "err", err)
+	}
+	rawdb.WriteTransitionState(db, root, blob)
+}
+
+// TransitionState returns the progress of the conversion of the merkle state
+// into a verkle tree, nil if the state is not in, or past, a transition. The
+// returned object is live, the conversion advances it in place.
+func (s *StateDB) TransitionState() *TransitionState {
+	return s.transition
+}
+
+// StartVerkleTransition switches a merkle state over to a verkle tree at the
+// verkle fork. All the mutations from here on are applied to an empty verkle
+// overlay tree, which the merkle state is gradually converted into.
+//
+// It must be invoked on a fresh state, before any state is accessed.
+func (s *StateDB) StartVerkleTransition() error {
+	if s.trie.IsVerkle() {
+		return nil
+	}
+	verkledb := s.db.VerkleTrieDB()
+	if verkledb == nil {
+		return errNoVerkleTransition
+	}
+	overlay, err := trie.NewVerkleTrie(types.EmptyRootHash, verkledb, s.db.PointCache())
+	if err != nil {
+		return err
+	}
+	tr, err := trie.NewTransitionTrie(overlay, s.originalRoot, s.db.TrieDB())
+	if err != nil {
+		return err
+	}
+	// The prefetcher is tracking the merkle tree, which is not going to be
+	// updated anymore. Drop it before it gets a chance to replace the overlay.
+	s.StopPrefetcher()
+
+	s.trie = tr
+	s.transition = &TransitionState{BaseRoot: s.originalRoot}
+	s.accessEvents = NewAccessEvents(s.db.PointCache())
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/rajchain/go-rajchain/common"
 	"github.com/rajchain/go-rajchain/consensus/misc"
+	"github.com/rajchain/go-rajchain/core/overlay"
 	"github.com/rajchain/go-rajchain/core/state"
 	"github.com/rajchain/go-rajchain/core/types"
 	"github.com/rajchain/go-rajchain/core/vm"
@@ -64,6 +65,11 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 		gp          = new(GasPool).AddGas(block.GasLimit())
 	)
 
+	// Switch the state over to the verkle tree past the verkle fork, moving the
+	// next batch of the merkle state into it. This must precede any state access.
+	if err := ProcessVerkleTransition(statedb, p.config, header); err != nil {
+		return nil, err
+	}
 	// Mutate the block and state according to any hard-fork specs
 	if p.config.DAOForkSupport && p.config.DAOForkBlock != nil && p.config.DAOForkBlock.Cmp(block.Number()) == 0 {
 		misc.ApplyDAOHardFork(statedb)
@@ -243,6 +249,23 @@ func ProcessBeaconBlockRoot(beaconRoot common.Hash, evm *vm.EVM) {
 	evm.StateDB.Finalise(true)
 }
 
+// ProcessVerkleTransition switches a merkle state over to the verkle overlay
+// tree at the verkle fork, then moves the next batch of leaves of the frozen
+// merkle tree into the overlay, as per EIP-7612 and EIP-7748. Chains starting
+// out with a verkle tree are left untouched.
+func ProcessVerkleTransition(statedb *state.StateDB, config *params.ChainConfig, header *types.Header) error {
+	if !config.IsVerkle(header.Number, header.Time) {
+		return nil
+	}
+	if err := statedb.StartVerkleTransition(); err != nil {
+		return fmt.Errorf("failed to start verkle transition: %w", err)
+	}
+	if err := overlay.Convert(statedb, params.VerkleConversionStride); err != nil {
+		return fmt.Errorf("failed to convert state into verkle tree: %w", err)
+	}
+	return nil
+}
+
 // ProcessParentBlockHash stores the parent block hash in the history storage contract
 // as per EIP-2935.
 func ProcessParentBlockHash(prevHash common.Hash, evm *vm.EVM) {
@@ -17,6 +17,9 @@
 package core
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/rajchain/go-rajchain/common"
 	"github.com/rajchain/go-rajchain/common/lru"
 	"github.com/rajchain/go-rajchain/consensus/beacon"
@@ -31,6 +34,20 @@ import (
 	"github.com/rajchain/go-rajchain/triedb"
 )
 
+var (
+	// ErrWitnessParentMismatch is returned if an execution witness is not built
+	// on top of the parent of the block it is used to execute.
+	ErrWitnessParentMismatch = errors.New("witness parent mismatch")
+
+	// ErrStatelessStateRoot is returned if the stateless execution of a block
+	// derives a different state root than the one in its header.
+	ErrStatelessStateRoot = errors.New("state root mismatch")
+
+	// ErrStatelessReceiptRoot is returned if the stateless execution of a block
+	// derives a different receipt root than the one in its header.
+	ErrStatelessReceiptRoot = errors.New("receipt root mismatch")
+)
+
 // ExecuteStateless runs a stateless execution based on a witness, verifies
 // everything it can locally and returns the state root and receipt root, that
 // need the other side to explicitly check.
@@ -41,6 +58,12 @@ import (
 //
 // TODO(karalabe): Would be nice to resolve both issues above somehow and move it.
 func ExecuteStateless(config *params.ChainConfig, block *types.Block, witness *stateless.Witness) (common.Hash, common.Hash, error) {
+	return executeStateless(config, vm.Config{}, block, witness)
+}
+
+// executeStateless runs a stateless execution like ExecuteStateless, with the
+// given EVM configuration.
+func executeStateless(config *params.ChainConfig, vmConfig vm.Config, block *types.Block, witness *stateless.Witness) (common.Hash, common.Hash, error) {
 	// Sanity check if the supplied block accidentally contains a set root or
 	// receipt hash. If so, be very loud, but still continue.
 	if block.Root() != (common.Hash{}) {
@@ -66,7 +89,7 @@ func ExecuteStateless(config *params.ChainConfig, block *types.Block, witness *s
 	validator := NewBlockValidator(config, nil) // No chain, we only validate the state, not the block
 
 	// Run the stateless blocks processing and self-validate certain fields
-	res, err := processor.Process(block, db, vm.Config{})
+	res, err := processor.Process(block, db, vmConfig)
 	if err != nil {
 		return common.Hash{}, common.Hash{}, err
 	}
@@ -78,3 +101,39 @@ func ExecuteStateless(config *params.ChainConfig, block *types.Block, witness *s
 	stateRoot := db.IntermediateRoot(config.IsEIP158(block.Number()))
 	return stateRoot, receiptRoot, nil
 }
+
+// VerifyStateless re-executes a sealed block using nothing but its execution
+// witness, and checks the derived state and receipt roots against the ones in
+// its header.
+func VerifyStateless(config *params.ChainConfig, block *types.Block, witness *stateless.Witness) error {
+	return verifyStateless(config, vm.Config{}, block, witness)
+}
+
+// verifyStateless re-executes a sealed block like VerifyStateless, with the
+// given EVM configuration.
+func verifyStateless(config *params.ChainConfig, vmConfig vm.Config, block *types.Block, witness *stateless.Witness) error {
+	if len(witness.Headers) == 0 {
+		return errors.New("witness without parent header")
+	}
+	if parent := witness.Headers[0]; parent.Hash() != block.ParentHash() {
+		return fmt.Errorf("%w (block parent: %x witness: %x)", ErrWitnessParentMismatch, block.ParentHash(), parent.Hash())
+	}
+	// Remove critical computed fields from the block to force true recalculation
+	context := block.Header()
+	context.Root = common.Hash{}
+	context.ReceiptHash = common.Hash{}
+
+	task := types.NewBlockWithHeader(context).WithBody(*block.Body())
+
+	stateRoot, receiptRoot, err := executeStateless(config, vmConfig, task, witness)
+	if err != nil {
+		return err
+	}
+	if stateRoot != block.Root() {
+		return fmt.Errorf("%w (remote: %x local: %x)", ErrStatelessStateRoot, block.Root(), stateRoot)
+	}
+	if receiptRoot != block.ReceiptHash() {
+		return fmt.Errorf("%w (remote: %x local: %x)", ErrStatelessReceiptRoot, block.ReceiptHash(), receiptRoot)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package stateless
+
+import (
+	"errors"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-verkle"
+)
+
+// VerifyVerkleWitness checks that the execution witness of a verkle block is a
+// valid proof of the state transition from the parent root to the block root.
+//
+// Blocks not accessing any state carry an empty witness, they can't have
+// modified the state either.
+func VerifyVerkleWitness(witness *types.ExecutionWitness, parentRoot common.Hash, root common.Hash) error {
+	if witness.VerkleProof == nil && len(witness.StateDiff) == 0 {
+		if parentRoot != root {
+			return errors.New("state modified without any access")
+		}
+		return nil
+	}
+	if witness.VerkleProof == nil {
+		return errors.New("missing verkle proof")
+	}
+	return verkle.Verify(witness.VerkleProof, parentRoot[:], root[:], witness.StateDiff)
+}
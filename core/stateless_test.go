@@ -0,0 +1,84 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/consensus/beacon"
+	"github.com/rajchain/go-rajchain/core/rawdb"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/core/vm"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/params"
+)
+
+// Tests that a block can be verified statelessly with the witness collected
+// while importing it, and that mismatching blocks are rejected.
+func TestVerifyStateless(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		config  = *params.MergedTestChainConfig
+		signer  = types.LatestSigner(&config)
+		engine  = beacon.NewFaker()
+		gspec   = &Genesis{
+			Config:  &config,
+			Alloc:   types.GenesisAlloc{address: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 4, func(i int, gen *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(gen.TxNonce(address), common.Address{0x01}, big.NewInt(1000), params.TxGas, gen.header.BaseFee, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		gen.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.PathScheme), gspec, nil, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(blocks[:len(blocks)-1]); err != nil {
+		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
+	}
+	last := blocks[len(blocks)-1]
+	witness, err := chain.InsertBlockWithoutSetHead(last, true)
+	if err != nil {
+		t.Fatalf("failed to insert block with witness: %v", err)
+	}
+	if err := VerifyStateless(&config, last, witness); err != nil {
+		t.Fatalf("failed to verify valid block: %v", err)
+	}
+	// Tamper with the state root of the block
+	header := last.Header()
+	header.Root = common.Hash{0xde, 0xad}
+	if err := VerifyStateless(&config, last.WithSeal(header), witness); !errors.Is(err, ErrStatelessStateRoot) {
+		t.Fatalf("state root mismatch not detected: have %v, want %v", err, ErrStatelessStateRoot)
+	}
+	// Execute the block against the witness of a different parent
+	header = last.Header()
+	header.ParentHash = blocks[0].Hash()
+	if err := VerifyStateless(&config, last.WithSeal(header), witness); !errors.Is(err, ErrWitnessParentMismatch) {
+		t.Fatalf("parent mismatch not detected: have %v, want %v", err, ErrWitnessParentMismatch)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/consensus/beacon"
+	"github.com/rajchain/go-rajchain/consensus/ethash"
+	"github.com/rajchain/go-rajchain/core/rawdb"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/core/vm"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/params"
+)
+
+// Tests that a chain starting out with a merkle tree switches over to a verkle
+// tree at the verkle fork, converting its state, and that the node can be
+// restarted in the middle of it.
+func TestVerkleTransition(t *testing.T) {
+	var (
+		config    = *testVerkleChainConfig
+		key, _    = crypto.GenerateKey()
+		sender    = crypto.PubkeyToAddress(key.PublicKey)
+		recipient = common.HexToAddress("0xbeef")
+		contract  = common.HexToAddress("0xc0de")
+		engine    = beacon.New(ethash.NewFaker())
+	)
+	config.VerkleTime = u64(30) // Blocks are 10 seconds apart, the fork is at block 3
+	gspec := &Genesis{
+		Config: &config,
+		Alloc: GenesisAlloc{
+			sender: {Balance: big.NewInt(params.Ether)},
+			contract: {
+				// sstore(1, 42)
+				Code:    []byte{byte(vm.PUSH1), 42, byte(vm.PUSH1), 1, byte(vm.SSTORE), byte(vm.STOP)},
+				Storage: map[common.Hash]common.Hash{{0}: {1}, {2}: {3}},
+				Balance: common.Big0,
+			},
+		},
+	}
+	signer := types.LatestSigner(&config)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 7, func(i int, gen *BlockGen) {
+		gen.SetPoS()
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(sender), recipient, common.Big1, params.TxGas*2, big.NewInt(params.InitialBaseFee), nil), signer, key)
+		gen.AddTx(tx)
+		if i == 3 {
+			tx, _ = types.SignTx(types.NewTransaction(gen.TxNonce(sender), contract, common.Big0, 100000, big.NewInt(params.InitialBaseFee), nil), signer, key)
+			gen.AddTx(tx)
+		}
+	})
+	// The merkle state can only be converted in path mode, with all its preimages
+	db := rawdb.NewMemoryDatabase()
+	cacheConfig := DefaultCacheConfigWithScheme(rawdb.PathScheme)
+	if _, err := NewBlockChain(db, cacheConfig, gspec, nil, engine, vm.Config{}, nil); !errors.Is(err, errVerkleTransitionPreimages) {
+		t.Fatalf("unexpected error without preimages: have %v, want %v", err, errVerkleTransitionPreimages)
+	}
+	cacheConfig.Preimages = true
+	chain, err := NewBlockChain(db, cacheConfig, gspec, nil, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if n, err := chain.InsertChain(blocks[:5]); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	for i, block := range blocks[:5] {
+		statedb, err := chain.StateAt(block.Root())
+		if err != nil {
+			t.Fatalf("block %d: state unavailable: %v", i+1, err)
+		}
+		ts := statedb.TransitionState()
+		if have, want := ts != nil, block.Time() >= *config.VerkleTime; have != want {
+			t.Fatalf("block %d: transition mismatch: have %t, want %t", i+1, have, want)
+		}
+		if ts != nil && !ts.Ended {
+			t.Fatalf("block %d: state not fully converted", i+1)
+		}
+	}
+	// The witnesses of the blocks fully on verkle tree are verified on import
+	if blocks[4].ExecutionWitness() == nil {
+		t.Fatal("missing execution witness past the verkle transition")
+	}
+	// Restart the node and import the rest of the chain
+	chain.Stop()
+	chain, err = NewBlockChain(db, cacheConfig, nil, nil, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if head := chain.CurrentBlock(); head.Hash() != blocks[4].Hash() {
+		t.Fatalf("head mismatch after restart: have %d, want %d", head.Number, blocks[4].Number())
+	}
+	// Past the conversion, the blocks must carry their execution witness
+	if _, err := chain.InsertChain(types.Blocks{blocks[5].WithWitness(nil)}); !errors.Is(err, errMissingWitness) {
+		t.Fatalf("unexpected error importing block without witness: have %v, want %v", err, errMissingWitness)
+	}
+	if n, err := chain.InsertChain(blocks[5:]); err != nil {
+		t.Fatalf("failed to insert block %d after restart: %v", n+5, err)
+	}
+	statedb, err := chain.State()
+	if err != nil {
+		t.Fatalf("head state unavailable: %v", err)
+	}
+	if balance := statedb.GetBalance(recipient); balance.Uint64() != 7 {
+		t.Errorf("recipient balance mismatch: have %v, want %d", balance, 7)
+	}
+	for slot, want := range map[common.Hash]common.Hash{{0}: {1}, common.BigToHash(common.Big1): common.BigToHash(big.NewInt(42)), {2}: {3}} {
+		if have := statedb.GetState(contract, slot); have != want {
+			t.Errorf("slot %x mismatch: have %x, want %x", slot, have, want)
+		}
+	}
+}
+
+// Tests that the blocks of a chain starting out on verkle are rejected if they
+// don't carry a valid execution witness, and that the witness is kept along
+// with the block.
+func TestVerkleWitnessRequired(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		signer = types.LatestSigner(testVerkleChainConfig)
+		gspec  = verkleTestGenesis(testVerkleChainConfig)
+	)
+	gspec.Alloc[sender] = GenesisAccount{Balance: big.NewInt(params.Ether)}
+	_, blocks, _, _, _ := GenerateVerkleChainWithGenesis(gspec, beacon.New(ethash.NewFaker()), 2, func(i int, gen *BlockGen) {
+		gen.SetPoS()
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(sender), common.HexToAddress("0xbeef"), common.Big1, params.TxGas*2, big.NewInt(params.InitialBaseFee), nil), signer, key)
+		gen.AddTx(tx)
+	})
+	cacheConfig := DefaultCacheConfigWithScheme(rawdb.PathScheme)
+	cacheConfig.SnapshotLimit = 0
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), cacheConfig, gspec, nil, beacon.New(ethash.NewFaker()), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	// Reject the block without witness
+	if _, err := chain.InsertChain(types.Blocks{blocks[0].WithWitness(nil)}); !errors.Is(err, errMissingWitness) {
+		t.Fatalf("unexpected error importing block without witness: have %v, want %v", err, errMissingWitness)
+	}
+	// Reject the block with a witness not matching the parent state
+	witness := blocks[0].ExecutionWitness()
+	tampered := &types.ExecutionWitness{
+		StateDiff:   witness.StateDiff.Copy(),
+		VerkleProof: witness.VerkleProof,
+	}
+	var done bool
+	for i := range tampered.StateDiff {
+		for j := range tampered.StateDiff[i].SuffixDiffs {
+			if value := tampered.StateDiff[i].SuffixDiffs[j].CurrentValue; value != nil && !done {
+				value[0]++
+				done = true
+			}
+		}
+	}
+	if !done {
+		t.Fatal("no state read in the witness to tamper with")
+	}
+	if _, err := chain.InsertChain(types.Blocks{blocks[0].WithWitness(tampered)}); err == nil {
+		t.Fatal("block with tampered witness imported")
+	}
+	// Import the untouched blocks, their witness being stored along
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	for i, block := range blocks {
+		chain.blockCache.Purge()
+		if stored := chain.GetBlock(block.Hash(), block.NumberU64()); stored.ExecutionWitness() == nil {
+			t.Fatalf("block %d: execution witness not stored", i+1)
+		}
+	}
+}
@@ -24,10 +24,14 @@ import (
 
 	"github.com/rajchain/go-rajchain/common"
 	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/core"
 	"github.com/rajchain/go-rajchain/core/rawdb"
 	"github.com/rajchain/go-rajchain/core/state"
+	"github.com/rajchain/go-rajchain/core/stateless"
 	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/core/vm"
 	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/eth/downloader"
 	"github.com/rajchain/go-rajchain/internal/ethapi"
 	"github.com/rajchain/go-rajchain/log"
 	"github.com/rajchain/go-rajchain/rlp"
@@ -35,6 +39,10 @@ import (
 	"github.com/rajchain/go-rajchain/trie"
 )
 
+// witnessReexec is the number of blocks the execution witness generation is
+// allowed to reexecute to regenerate the parent state of the requested block.
+const witnessReexec = 128
+
 // DebugAPI is the collection of rajchain full node APIs for debugging the
 // protocol.
 type DebugAPI struct {
@@ -129,6 +137,54 @@ func (api *DebugAPI) GetBadBlocks(ctx context.Context) ([]*BadBlockArgs, error)
 	return results, nil
 }
 
+// BadBlockReportArgs summarizes the forensics report of a bad block.
+type BadBlockReportArgs struct {
+	Hash           common.Hash    `json:"hash"`
+	Number         hexutil.Uint64 `json:"number"`
+	Time           hexutil.Uint64 `json:"time"`
+	Error          string         `json:"error"`
+	Stage          string         `json:"stage"`
+	Witness        bool           `json:"witness"`
+	TraceSteps     hexutil.Uint64 `json:"traceSteps"`
+	TraceTruncated bool           `json:"traceTruncated"`
+}
+
+// GetBadBlockReports returns a summary of the forensics reports of the last blocks
+// which failed validation.
+func (api *DebugAPI) GetBadBlockReports(ctx context.Context) ([]*BadBlockReportArgs, error) {
+	summaries, err := core.ReadBadBlockSummaries(api.eth.chainDb)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*BadBlockReportArgs, 0, len(summaries))
+	for _, summary := range summaries {
+		results = append(results, &BadBlockReportArgs{
+			Hash:           summary.Hash,
+			Number:         hexutil.Uint64(summary.Number),
+			Time:           hexutil.Uint64(summary.Time),
+			Error:          summary.Error,
+			Stage:          summary.Stage,
+			Witness:        summary.Witness,
+			TraceSteps:     hexutil.Uint64(summary.TraceSteps),
+			TraceTruncated: summary.TraceTruncated,
+		})
+	}
+	return results, nil
+}
+
+// GetBadBlockReport returns the forensics report of a block which failed
+// validation, in the bundle format replayable with 'evm replay'.
+func (api *DebugAPI) GetBadBlockReport(ctx context.Context, hash common.Hash) (*core.BadBlockReport, error) {
+	report, err := core.ReadBadBlockReport(api.eth.chainDb, hash)
+	if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, fmt.Errorf("no report of bad block %x", hash)
+	}
+	return report, nil
+}
+
 // AccountRangeMaxResults is the maximum number of results to be returned per call
 const AccountRangeMaxResults = 256
 
@@ -229,6 +285,48 @@ func (api *DebugAPI) StorageRangeAt(ctx context.Context, blockNrOrHash rpc.Block
 	return storageRangeAt(statedb, block.Root(), contractAddress, keyStart, maxResult)
 }
 
+// ExecutionWitness reexecutes a block on top of its parent state, returning the
+// RLP encoded execution witness needed to execute it statelessly.
+func (api *DebugAPI) ExecutionWitness(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	block, err := api.eth.APIBackend.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %v not found", blockNrOrHash)
+	}
+	if block.NumberU64() == 0 {
+		return nil, errors.New("genesis is not executable")
+	}
+	chain := api.eth.blockchain
+	parent := chain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %x not found", block.ParentHash())
+	}
+	statedb, release, err := api.eth.stateAtBlock(ctx, parent, witnessReexec, nil, true, false)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	witness, err := stateless.NewWitness(block.Header(), chain)
+	if err != nil {
+		return nil, err
+	}
+	statedb.StartPrefetcher("debug", witness)
+	defer statedb.StopPrefetcher()
+
+	res, err := chain.Processor().Process(block, statedb, vm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	// Validating the state root pulls the trie nodes of the mutations in
+	if err := chain.Validator().ValidateState(block, statedb, res, false); err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(witness)
+}
+
 func storageRangeAt(statedb *state.StateDB, root common.Hash, address common.Address, start []byte, maxResult int) (StorageRangeResult, error) {
 	storageRoot := statedb.GetStorageRoot(address)
 	if storageRoot == types.EmptyRootHash || storageRoot == (common.Hash{}) {
@@ -443,3 +541,13 @@ func (api *DebugAPI) GetTrieFlushInterval() (string, error) {
 	}
 	return api.eth.blockchain.GetTrieFlushInterval().String(), nil
 }
+
+// SyncProgress returns the progress of the individual sync phases, along with
+// their throughput and estimated time to completion.
+func (api *DebugAPI) SyncProgress() *downloader.SyncPhases {
+	var txIndex *core.TxIndexProgress
+	if progress, err := api.eth.blockchain.TxIndexProgress(); err == nil {
+		txIndex = &progress
+	}
+	return api.eth.Downloader().PhaseProgress(txIndex)
+}
@@ -19,6 +19,7 @@ package eth
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"runtime"
@@ -70,6 +71,7 @@ type rajchain struct {
 	config     *ethconfig.Config
 	txPool     *txpool.TxPool
 	blockchain *core.BlockChain
+	badBlocks  *badBlockTracer // Forensics tracer of the blocks failing validation
 
 	handler *handler
 	discmix *enode.FairMix
@@ -94,6 +96,7 @@ type rajchain struct {
 	netRPCService *ethapi.NetAPI
 
 	p2pServer *p2p.Server
+	registry  *registrySync // Node allowlist reader, nil unless a registry is configured
 
 	lock sync.RWMutex // Protects the variadic fields (e.g. gas price and etherbase)
 
@@ -223,6 +226,20 @@ func New(stack *node.Node, config *ethconfig.Config) (*rajchain, error) {
 	}
 	eth.bloomIndexer.Start(eth.blockchain)
 
+	eth.badBlocks = newBadBlockTracer(eth.blockchain, chainDb)
+	eth.blockchain.SetBadBlockHook(eth.badBlocks.report)
+
+	// The p2p server starts before the node registry is first read, hold back
+	// the peers other than static and trusted ones until then.
+	if config.NodeRegistry != nil {
+		allowlist := eth.p2pServer.Allowlist()
+		if allowlist == nil {
+			return nil, errors.New("node registry requires a permissioned p2p server")
+		}
+		allowlist.Expect(p2p.AllowlistSourceContract)
+		eth.registry = newRegistrySync(*config.NodeRegistry, eth.blockchain, allowlist)
+	}
+
 	if config.BlobPool.Datadir != "" {
 		config.BlobPool.Datadir = stack.ResolvePath(config.BlobPool.Datadir)
 	}
@@ -237,6 +254,9 @@ func New(stack *node.Node, config *ethconfig.Config) (*rajchain, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Limit the state histories replayed to serve lagging snap peers
+	snap.SetHistoricDepth(config.SnapHistoryDepth)
+
 	// Permit the downloader to use the trie cache allowance during fast sync
 	cacheLimit := cacheConfig.TrieCleanLimit + cacheConfig.TrieDirtyLimit + cacheConfig.SnapshotLimit
 	if eth.handler, err = newHandler(&handlerConfig{
@@ -249,6 +269,8 @@ func New(stack *node.Node, config *ethconfig.Config) (*rajchain, error) {
 		BloomCache:     uint64(cacheLimit),
 		EventMux:       eth.eventMux,
 		RequiredBlocks: config.RequiredBlocks,
+		TxStemLength:   config.TxStemLength,
+		TxStemEmbargo:  config.TxStemEmbargo,
 	}); err != nil {
 		return nil, err
 	}
@@ -362,6 +384,10 @@ func (s *rajchain) Start() error {
 	// Regularly update shutdown marker
 	s.shutdownTracker.Start()
 
+	// Follow the on-chain node registry, completing the allowlist once read
+	if s.registry != nil {
+		s.registry.start()
+	}
 	// Start the networking layer
 	s.handler.Start(s.p2pServer.MaxPeers)
 	return nil
@@ -405,11 +431,15 @@ func (s *rajchain) Stop() error {
 	// Stop all the peer-related stuff first.
 	s.discmix.Close()
 	s.handler.Stop()
+	if s.registry != nil {
+		s.registry.stop()
+	}
 
 	// Then stop everything else.
 	s.bloomIndexer.Close()
 	close(s.closeBloomHandler)
 	s.txPool.Close()
+	s.badBlocks.close()
 	s.blockchain.Stop()
 	s.engine.Close()
 
@@ -0,0 +1,247 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bundler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/internal/ethapi"
+	"github.com/rajchain/go-rajchain/rpc"
+)
+
+// API exposes the ERC-4337 bundler RPC methods in the eth namespace.
+type API struct {
+	b *Bundler
+}
+
+// NewAPI creates the RPC API of the bundler.
+func NewAPI(b *Bundler) *API {
+	return &API{b: b}
+}
+
+// checkEntryPoint returns an error if the requested EntryPoint is not the one
+// served by the bundler.
+func (api *API) checkEntryPoint(entryPoint common.Address) error {
+	if entryPoint != api.b.config.EntryPoint {
+		return fmt.Errorf("unsupported entry point %v", entryPoint)
+	}
+	return nil
+}
+
+// SupportedEntryPoints returns the EntryPoint contracts served by the bundler.
+func (api *API) SupportedEntryPoints() []common.Address {
+	return []common.Address{api.b.config.EntryPoint}
+}
+
+// SendUserOperation validates a user operation and inserts it into the
+// bundler mempool, returning its hash.
+func (api *API) SendUserOperation(ctx context.Context, op UserOperation, entryPoint common.Address) (common.Hash, error) {
+	if err := api.checkEntryPoint(entryPoint); err != nil {
+		return common.Hash{}, err
+	}
+	return api.b.add(ctx, &op)
+}
+
+// UserOperationGasEstimate is the result of EstimateUserOperationGas.
+type UserOperationGasEstimate struct {
+	PreVerificationGas   hexutil.Uint64 `json:"preVerificationGas"`
+	VerificationGasLimit hexutil.Uint64 `json:"verificationGasLimit"`
+	CallGasLimit         hexutil.Uint64 `json:"callGasLimit"`
+}
+
+// EstimateUserOperationGas estimates the gas limits of a user operation. The
+// gas limit and fee fields of the operation are ignored, the signature only
+// needs to be of the right length.
+func (api *API) EstimateUserOperationGas(ctx context.Context, op UserOperation, entryPoint common.Address) (*UserOperationGasEstimate, error) {
+	if err := api.checkEntryPoint(entryPoint); err != nil {
+		return nil, err
+	}
+	if op.Nonce == nil {
+		return nil, errors.New("missing nonce")
+	}
+	op.VerificationGasLimit = new(big.Int).SetUint64(api.b.config.MaxVerificationGas)
+	op.CallGasLimit = new(big.Int)
+	op.MaxFeePerGas, op.MaxPriorityFeePerGas = new(big.Int), new(big.Int)
+	op.PreVerificationGas = new(big.Int)
+	op.PreVerificationGas.SetUint64(preVerificationGas(&op))
+
+	res, _, err := api.b.validator.simulate(ctx, &op)
+	if err != nil {
+		return nil, err
+	}
+	estimate := &UserOperationGasEstimate{
+		PreVerificationGas:   hexutil.Uint64(op.PreVerificationGas.Uint64()),
+		VerificationGasLimit: hexutil.Uint64(new(big.Int).Sub(res.ReturnInfo.PreOpGas, op.PreVerificationGas).Uint64()),
+	}
+	if len(op.CallData) > 0 {
+		if len(op.InitCode) > 0 {
+			return nil, errors.New("call gas can't be estimated before the sender is deployed")
+		}
+		var (
+			data = hexutil.Bytes(op.CallData)
+			args = ethapi.TransactionArgs{From: &entryPoint, To: &op.Sender, Input: &data}
+		)
+		gas, err := ethapi.DoEstimateGas(ctx, api.b.backend, args, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil, api.b.backend.RPCGasCap())
+		if err != nil {
+			return nil, err
+		}
+		estimate.CallGasLimit = gas
+	}
+	return estimate, nil
+}
+
+// UserOperationReceipt is the outcome of an included user operation.
+type UserOperationReceipt struct {
+	UserOpHash    common.Hash    `json:"userOpHash"`
+	EntryPoint    common.Address `json:"entryPoint"`
+	Sender        common.Address `json:"sender"`
+	Nonce         *hexutil.Big   `json:"nonce"`
+	Paymaster     common.Address `json:"paymaster"`
+	ActualGasCost *hexutil.Big   `json:"actualGasCost"`
+	ActualGasUsed *hexutil.Big   `json:"actualGasUsed"`
+	Success       bool           `json:"success"`
+	Reason        hexutil.Bytes  `json:"reason,omitempty"`
+	Logs          []*types.Log   `json:"logs"`
+	Receipt       *types.Receipt `json:"receipt"`
+}
+
+// GetUserOperationReceipt returns the receipt of an included user operation,
+// or nil if the operation is unknown or not yet included.
+func (api *API) GetUserOperationReceipt(ctx context.Context, hash common.Hash) (*UserOperationReceipt, error) {
+	bundle, ok := api.b.included.Get(hash)
+	if !ok {
+		return nil, nil
+	}
+	found, _, blockHash, _, index, err := api.b.backend.GetTransaction(ctx, bundle)
+	if err != nil || !found {
+		return nil, err
+	}
+	receipts, err := api.b.backend.GetReceipts(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(receipts)) <= index {
+		return nil, fmt.Errorf("receipt of bundle %v not found", bundle)
+	}
+	return parseUserOperationReceipt(receipts[index], api.b.config.EntryPoint, hash)
+}
+
+// parseUserOperationReceipt extracts the outcome of a user operation from the
+// receipt of the bundle transaction including it.
+func parseUserOperationReceipt(receipt *types.Receipt, entry common.Address, hash common.Hash) (*UserOperationReceipt, error) {
+	var (
+		opEvent     = entryPoint.Events["UserOperationEvent"]
+		revertEvent = entryPoint.Events["UserOperationRevertReason"]
+		beforeEvent = entryPoint.Events["BeforeExecution"]
+		start       int
+	)
+	for i, log := range receipt.Logs {
+		if log.Address != entry || len(log.Topics) == 0 {
+			continue
+		}
+		switch log.Topics[0] {
+		case beforeEvent.ID:
+			start = i + 1
+
+		case opEvent.ID:
+			if len(log.Topics) < 4 || log.Topics[1] != hash {
+				start = i + 1
+				continue
+			}
+			values, err := opEvent.Inputs.NonIndexed().Unpack(log.Data)
+			if err != nil {
+				return nil, err
+			}
+			res := &UserOperationReceipt{
+				UserOpHash:    hash,
+				EntryPoint:    entry,
+				Sender:        common.BytesToAddress(log.Topics[2].Bytes()),
+				Paymaster:     common.BytesToAddress(log.Topics[3].Bytes()),
+				Nonce:         (*hexutil.Big)(values[0].(*big.Int)),
+				Success:       values[1].(bool),
+				ActualGasCost: (*hexutil.Big)(values[2].(*big.Int)),
+				ActualGasUsed: (*hexutil.Big)(values[3].(*big.Int)),
+				Logs:          []*types.Log{},
+				Receipt:       receipt,
+			}
+			for _, opLog := range receipt.Logs[start:i] {
+				if opLog.Address == entry && len(opLog.Topics) > 1 && opLog.Topics[0] == revertEvent.ID && opLog.Topics[1] == hash {
+					if values, err := revertEvent.Inputs.NonIndexed().Unpack(opLog.Data); err == nil {
+						res.Reason = values[1].([]byte)
+					}
+					continue
+				}
+				res.Logs = append(res.Logs, opLog)
+			}
+			return res, nil
+		}
+	}
+	return nil, fmt.Errorf("user operation %v not found in bundle %v", hash, receipt.TxHash)
+}
+
+// UserOperationByHash is a user operation along with its inclusion details.
+type UserOperationByHash struct {
+	UserOperation   *UserOperation  `json:"userOperation"`
+	EntryPoint      common.Address  `json:"entryPoint"`
+	TransactionHash *common.Hash    `json:"transactionHash"`
+	BlockHash       *common.Hash    `json:"blockHash"`
+	BlockNumber     *hexutil.Uint64 `json:"blockNumber"`
+}
+
+// GetUserOperationByHash returns a pending or included user operation, or nil
+// if the operation is unknown.
+func (api *API) GetUserOperationByHash(ctx context.Context, hash common.Hash) (*UserOperationByHash, error) {
+	if entry := api.b.pool.get(hash); entry != nil {
+		return &UserOperationByHash{UserOperation: entry.op, EntryPoint: api.b.config.EntryPoint}, nil
+	}
+	bundle, ok := api.b.included.Get(hash)
+	if !ok {
+		return nil, nil
+	}
+	found, tx, blockHash, blockNumber, _, err := api.b.backend.GetTransaction(ctx, bundle)
+	if err != nil || !found {
+		return nil, err
+	}
+	values, err := entryPoint.Methods["handleOps"].Inputs.Unpack(tx.Data()[4:])
+	if err != nil {
+		return nil, err
+	}
+	var ops []UserOperation
+	if err := entryPoint.Methods["handleOps"].Inputs[:1].Copy(&ops, values[:1]); err != nil {
+		return nil, err
+	}
+	chainID := api.b.backend.ChainConfig().ChainID
+	for i := range ops {
+		if ops[i].Hash(api.b.config.EntryPoint, chainID) == hash {
+			number := hexutil.Uint64(blockNumber)
+			return &UserOperationByHash{
+				UserOperation:   &ops[i],
+				EntryPoint:      api.b.config.EntryPoint,
+				TransactionHash: &bundle,
+				BlockHash:       &blockHash,
+				BlockNumber:     &number,
+			}, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,452 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package bundler implements an ERC-4337 bundler running inside the node.
+//
+// User operations submitted over RPC are validated against the local state by
+// simulating them through the EntryPoint with the ERC-7562 tracer, kept in a
+// dedicated mempool and periodically packed into handleOps transactions which
+// are signed with a local account and sent to the node's own transaction pool.
+package bundler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/rajchain/go-rajchain/accounts"
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/common/lru"
+	"github.com/rajchain/go-rajchain/core"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/event"
+	"github.com/rajchain/go-rajchain/internal/ethapi"
+	"github.com/rajchain/go-rajchain/log"
+	"github.com/rajchain/go-rajchain/node"
+	"github.com/rajchain/go-rajchain/params"
+	"github.com/rajchain/go-rajchain/rpc"
+)
+
+const (
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
+
+	// includedCacheSize is the number of included operations whose bundle
+	// transaction is remembered for receipt lookups.
+	includedCacheSize = 16384
+
+	// bundleTimeout is the maximum time spent assembling a single bundle.
+	bundleTimeout = 10 * time.Second
+)
+
+var (
+	// errInvalidFailedOp is returned if a bundle is rejected with a FailedOp
+	// error pointing outside the bundle.
+	errInvalidFailedOp = errors.New("bundle rejected with invalid operation index")
+
+	// errBundleReverted is returned if a bundle reverts without a FailedOp error
+	// identifying the operation at fault.
+	errBundleReverted = errors.New("bundle reverted")
+)
+
+// Backend is the node functionality needed by the bundler.
+type Backend interface {
+	ethapi.Backend
+}
+
+// Config contains the settings of the bundler.
+type Config struct {
+	EntryPoint         common.Address // EntryPoint contract the bundler serves
+	Signer             common.Address // Account signing the bundle transactions, must be unlocked
+	Beneficiary        common.Address // Recipient of the bundle fees, defaults to the signer
+	MaxBundleSize      int            // Maximum number of operations in a bundle
+	MaxBundleGas       uint64         // Maximum gas of a bundle transaction
+	MaxVerificationGas uint64         // Maximum verificationGasLimit accepted for an operation
+	MinStake           *big.Int       // Minimum stake for an entity to be considered staked
+	MinUnstakeDelay    uint64         // Minimum unstake delay (seconds) for an entity to be considered staked
+	MempoolSize        int            // Maximum number of operations in the mempool
+	MaxOpsPerSender    int            // Maximum number of pending operations per sender
+	PriceBump          uint64         // Minimum fee bump percentage to replace an operation
+}
+
+// DefaultConfig contains the default bundler settings.
+var DefaultConfig = Config{
+	EntryPoint:         DefaultEntryPoint,
+	MaxBundleSize:      10,
+	MaxBundleGas:       10_000_000,
+	MaxVerificationGas: 5_000_000,
+	MinStake:           big.NewInt(params.Ether),
+	MinUnstakeDelay:    86400,
+	MempoolSize:        4096,
+	MaxOpsPerSender:    4,
+	PriceBump:          10,
+}
+
+// sanitize checks the provided user configurations and changes anything that's
+// unreasonable or unworkable.
+func (config *Config) sanitize() Config {
+	conf := *config
+	if conf.EntryPoint == (common.Address{}) {
+		conf.EntryPoint = DefaultConfig.EntryPoint
+	}
+	if conf.Beneficiary == (common.Address{}) {
+		conf.Beneficiary = conf.Signer
+	}
+	if conf.MaxBundleSize < 1 {
+		log.Warn("Sanitizing invalid bundler max bundle size", "provided", conf.MaxBundleSize, "updated", DefaultConfig.MaxBundleSize)
+		conf.MaxBundleSize = DefaultConfig.MaxBundleSize
+	}
+	if conf.MaxBundleGas == 0 {
+		conf.MaxBundleGas = DefaultConfig.MaxBundleGas
+	}
+	if conf.MaxVerificationGas == 0 {
+		conf.MaxVerificationGas = DefaultConfig.MaxVerificationGas
+	}
+	if conf.MinStake == nil {
+		conf.MinStake = DefaultConfig.MinStake
+	}
+	if conf.MempoolSize < 1 {
+		log.Warn("Sanitizing invalid bundler mempool size", "provided", conf.MempoolSize, "updated", DefaultConfig.MempoolSize)
+		conf.MempoolSize = DefaultConfig.MempoolSize
+	}
+	if conf.MaxOpsPerSender < 1 {
+		log.Warn("Sanitizing invalid bundler per-sender limit", "provided", conf.MaxOpsPerSender, "updated", DefaultConfig.MaxOpsPerSender)
+		conf.MaxOpsPerSender = DefaultConfig.MaxOpsPerSender
+	}
+	if conf.PriceBump == 0 {
+		conf.PriceBump = DefaultConfig.PriceBump
+	}
+	return conf
+}
+
+// Bundler is the ERC-4337 bundler service.
+type Bundler struct {
+	config    Config
+	backend   Backend
+	validator *validator
+	pool      *mempool
+	included  *lru.Cache[common.Hash, common.Hash] // user operation hash -> bundle transaction hash
+
+	trigger chan struct{}      // signals new operations waiting to be bundled
+	quit    chan struct{}      // closed when the service is stopped
+	wg      sync.WaitGroup     // tracks the background loop
+	lock    sync.Mutex         // serializes bundle creation
+	headSub event.Subscription // subscription for chain head events
+}
+
+// New creates the bundler service and registers it, along with its RPC API,
+// with the node.
+func New(stack *node.Node, backend Backend, config Config) (*Bundler, error) {
+	config = config.sanitize()
+	if config.Signer == (common.Address{}) {
+		return nil, errors.New("bundler signer account not specified")
+	}
+	b := &Bundler{
+		config:   config,
+		backend:  backend,
+		pool:     newMempool(config.MempoolSize, config.MaxOpsPerSender, config.PriceBump),
+		included: lru.NewCache[common.Hash, common.Hash](includedCacheSize),
+		trigger:  make(chan struct{}, 1),
+		quit:     make(chan struct{}),
+	}
+	b.validator = &validator{backend: backend, config: &b.config}
+
+	stack.RegisterAPIs([]rpc.API{{
+		Namespace: "eth",
+		Service:   NewAPI(b),
+	}})
+	stack.RegisterLifecycle(b)
+	return b, nil
+}
+
+// Start implements node.Lifecycle, starting the bundling loop.
+func (b *Bundler) Start() error {
+	heads := make(chan core.ChainHeadEvent, chainHeadChanSize)
+	b.headSub = b.backend.SubscribeChainHeadEvent(heads)
+
+	b.wg.Add(1)
+	go b.loop(heads)
+
+	log.Info("Started ERC-4337 bundler", "entrypoint", b.config.EntryPoint, "signer", b.config.Signer)
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the bundling loop.
+func (b *Bundler) Stop() error {
+	b.headSub.Unsubscribe()
+	close(b.quit)
+	b.wg.Wait()
+
+	log.Info("Stopped ERC-4337 bundler")
+	return nil
+}
+
+// loop creates a new bundle whenever the chain progresses or new operations
+// arrive, as long as no previous bundle is still waiting for inclusion.
+func (b *Bundler) loop(heads chan core.ChainHeadEvent) {
+	defer b.wg.Done()
+
+	for {
+		select {
+		case <-heads:
+		case <-b.trigger:
+		case <-b.quit:
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), bundleTimeout)
+		if err := b.bundle(ctx); err != nil {
+			log.Warn("Failed to create bundle", "err", err)
+		}
+		cancel()
+	}
+}
+
+// add validates a user operation and inserts it into the mempool.
+func (b *Bundler) add(ctx context.Context, op *UserOperation) (common.Hash, error) {
+	if _, err := b.validator.validate(ctx, op); err != nil {
+		return common.Hash{}, err
+	}
+	hash := op.Hash(b.config.EntryPoint, b.backend.ChainConfig().ChainID)
+	if err := b.pool.add(op, hash); err != nil {
+		return common.Hash{}, err
+	}
+	log.Debug("Accepted user operation", "hash", hash, "sender", op.Sender, "nonce", op.Nonce)
+
+	select {
+	case b.trigger <- struct{}{}:
+	default:
+	}
+	return hash, nil
+}
+
+// reconcile processes the bundles previously sent to the txpool: operations in
+// mined bundles are dropped from the mempool, whereas those of bundles which
+// vanished from the txpool are made available for bundling again. It returns
+// whether any bundle is still waiting for inclusion.
+func (b *Bundler) reconcile(ctx context.Context) (bool, error) {
+	var inflight bool
+	for bundle, hashes := range b.pool.submitted() {
+		found, _, _, _, _, err := b.backend.GetTransaction(ctx, bundle)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case found:
+			for _, hash := range hashes {
+				b.included.Add(hash, bundle)
+			}
+			b.pool.remove(hashes...)
+			log.Debug("Bundle included", "hash", bundle, "ops", len(hashes))
+
+		case b.backend.GetPoolTransaction(bundle) == nil:
+			b.pool.resubmit(hashes)
+			log.Debug("Bundle dropped from txpool", "hash", bundle, "ops", len(hashes))
+
+		default:
+			inflight = true
+		}
+	}
+	return inflight, nil
+}
+
+// bundle assembles the pending operations into a handleOps transaction and
+// sends it to the local transaction pool.
+func (b *Bundler) bundle(ctx context.Context) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	inflight, err := b.reconcile(ctx)
+	if err != nil || inflight {
+		return err
+	}
+	head := b.backend.CurrentHeader()
+
+	// Revalidate the candidates against the latest state, dropping the invalid
+	// ones and the ones not paying the current base fee.
+	var (
+		ops    []*UserOperation
+		hashes []common.Hash
+		gas    uint64
+	)
+	for _, entry := range b.pool.pending(b.config.MaxBundleSize) {
+		if head.BaseFee != nil && entry.op.MaxFeePerGas.Cmp(head.BaseFee) < 0 {
+			continue
+		}
+		if _, err := b.validator.validate(ctx, entry.op); err != nil {
+			log.Debug("Dropping invalidated user operation", "hash", entry.hash, "err", err)
+			b.pool.remove(entry.hash)
+			continue
+		}
+		if gas+entry.op.requiredGas() > b.config.MaxBundleGas {
+			continue
+		}
+		gas += entry.op.requiredGas()
+		ops, hashes = append(ops, entry.op), append(hashes, entry.hash)
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+	// Simulate the bundle, dropping operations rejected by the EntryPoint
+	var data []byte
+	for len(ops) > 0 {
+		if data, err = entryPoint.Pack("handleOps", ops, b.config.Beneficiary); err != nil {
+			return err
+		}
+		index, err := b.simulateBundle(ctx, data, len(ops))
+		if errors.Is(err, errInvalidFailedOp) || errors.Is(err, errBundleReverted) {
+			// The revert doesn't identify the operation at fault, which might
+			// even have forged it. Bisect the bundle to find the culprit.
+			log.Debug("Bisecting rejected bundle", "ops", len(ops), "err", err)
+			index, err = b.isolate(ctx, ops)
+		}
+		if err != nil {
+			return err
+		}
+		if index < 0 {
+			break
+		}
+		log.Debug("Dropping user operation rejected in bundle", "hash", hashes[index])
+		b.pool.remove(hashes[index])
+		ops, hashes = append(ops[:index], ops[index+1:]...), append(hashes[:index], hashes[index+1:]...)
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+	return b.submit(ctx, head, data, hashes)
+}
+
+// simulateBundle executes a handleOps call of a bundle with the given number of
+// operations, returning the index of the first operation rejected by the
+// EntryPoint, or -1 if the bundle is valid.
+func (b *Bundler) simulateBundle(ctx context.Context, data []byte, count int) (int, error) {
+	var (
+		input = hexutil.Bytes(data)
+		args  = ethapi.TransactionArgs{From: &b.config.Signer, To: &b.config.EntryPoint, Input: &input}
+	)
+	result, err := ethapi.DoCall(ctx, b.backend, args, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil, nil, b.backend.RPCEVMTimeout(), b.backend.RPCGasCap())
+	if err != nil {
+		return 0, err
+	}
+	if !result.Failed() {
+		return -1, nil
+	}
+	failed := entryPoint.Errors["FailedOp"]
+	values, err := failed.Unpack(result.Revert())
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errBundleReverted, result.Err)
+	}
+	// The revert data is controlled by the operations, so an index outside the
+	// bundle can't be trusted to identify the culprit
+	index := values.([]interface{})[0].(*big.Int)
+	if !index.IsInt64() || index.Sign() < 0 || index.Int64() >= int64(count) {
+		return 0, fmt.Errorf("%w: %v", errInvalidFailedOp, index)
+	}
+	return int(index.Int64()), nil
+}
+
+// isolate finds the operation making a bundle revert, if the revert itself does
+// not identify it, by simulating ever smaller prefixes of the bundle.
+func (b *Bundler) isolate(ctx context.Context, ops []*UserOperation) (int, error) {
+	return bisect(len(ops), func(n int) (bool, error) {
+		data, err := entryPoint.Pack("handleOps", ops[:n], b.config.Beneficiary)
+		if err != nil {
+			return false, err
+		}
+		index, err := b.simulateBundle(ctx, data, n)
+		if errors.Is(err, errInvalidFailedOp) || errors.Is(err, errBundleReverted) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return index >= 0, nil
+	})
+}
+
+// bisect returns the index of the operation making a bundle of the given size
+// revert: the first one whose addition makes the prefix of the bundle ending
+// with it revert. The bundle itself is known to revert, and reverts reports
+// whether its prefix of n operations does.
+func bisect(count int, reverts func(n int) (bool, error)) (int, error) {
+	valid, invalid := 0, count
+	for invalid-valid > 1 {
+		mid := (valid + invalid) / 2
+		failed, err := reverts(mid)
+		if err != nil {
+			return 0, err
+		}
+		if failed {
+			invalid = mid
+		} else {
+			valid = mid
+		}
+	}
+	return invalid - 1, nil
+}
+
+// submit signs a bundle transaction with the configured account and sends it
+// to the local transaction pool.
+func (b *Bundler) submit(ctx context.Context, head *types.Header, data []byte, hashes []common.Hash) error {
+	var (
+		input = hexutil.Bytes(data)
+		args  = ethapi.TransactionArgs{From: &b.config.Signer, To: &b.config.EntryPoint, Input: &input}
+	)
+	gas, err := ethapi.DoEstimateGas(ctx, b.backend, args, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil, b.backend.RPCGasCap())
+	if err != nil {
+		return err
+	}
+	tip, err := b.backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return err
+	}
+	feeCap := new(big.Int).Set(tip)
+	if head.BaseFee != nil {
+		feeCap.Add(feeCap, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+	}
+	nonce, err := b.backend.GetPoolNonce(ctx, b.config.Signer)
+	if err != nil {
+		return err
+	}
+	chainID := b.backend.ChainConfig().ChainID
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+		Gas:       uint64(gas),
+		To:        &b.config.EntryPoint,
+		Data:      data,
+	})
+	account := accounts.Account{Address: b.config.Signer}
+	wallet, err := b.backend.AccountManager().Find(account)
+	if err != nil {
+		return err
+	}
+	signed, err := wallet.SignTx(account, tx, chainID)
+	if err != nil {
+		return err
+	}
+	if err := b.backend.SendTx(ctx, signed); err != nil {
+		return err
+	}
+	b.pool.markSubmitted(signed.Hash(), hashes)
+	log.Info("Submitted user operation bundle", "hash", signed.Hash(), "ops", len(hashes), "gas", uint64(gas))
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bundler
+
+import (
+	"errors"
+	"math/bits"
+	"testing"
+)
+
+// Tests that bisecting a reverting bundle isolates the operation at fault with a
+// logarithmic number of simulations.
+func TestBisect(t *testing.T) {
+	for count := 1; count <= 16; count++ {
+		for culprit := 0; culprit < count; culprit++ {
+			var sims int
+			index, err := bisect(count, func(n int) (bool, error) {
+				sims++
+				if n <= 0 || n >= count {
+					t.Fatalf("bundle of %d: simulated prefix of %d", count, n)
+				}
+				return n > culprit, nil
+			})
+			if err != nil {
+				t.Fatalf("bundle of %d: bisection failed: %v", count, err)
+			}
+			if index != culprit {
+				t.Errorf("bundle of %d: isolated operation %d, want %d", count, index, culprit)
+			}
+			if limit := bits.Len(uint(count)); sims > limit {
+				t.Errorf("bundle of %d: %d simulations, want at most %d", count, sims, limit)
+			}
+		}
+	}
+	// Simulation failures abort the bisection
+	fail := errors.New("simulation failed")
+	if _, err := bisect(4, func(int) (bool, error) { return false, fail }); !errors.Is(err, fail) {
+		t.Errorf("simulation failure not propagated: %v", err)
+	}
+}
+
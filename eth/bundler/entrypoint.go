@@ -0,0 +1,199 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bundler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/rajchain/go-rajchain/accounts/abi"
+	"github.com/rajchain/go-rajchain/common"
+)
+
+// entryPointABI is the subset of the version 0.6 EntryPoint interface used by
+// the bundler.
+const entryPointABI = `[
+	{"type":"function","name":"handleOps","stateMutability":"nonpayable","inputs":[
+		{"name":"ops","type":"tuple[]","components":[
+			{"name":"sender","type":"address"},
+			{"name":"nonce","type":"uint256"},
+			{"name":"initCode","type":"bytes"},
+			{"name":"callData","type":"bytes"},
+			{"name":"callGasLimit","type":"uint256"},
+			{"name":"verificationGasLimit","type":"uint256"},
+			{"name":"preVerificationGas","type":"uint256"},
+			{"name":"maxFeePerGas","type":"uint256"},
+			{"name":"maxPriorityFeePerGas","type":"uint256"},
+			{"name":"paymasterAndData","type":"bytes"},
+			{"name":"signature","type":"bytes"}]},
+		{"name":"beneficiary","type":"address"}],"outputs":[]},
+	{"type":"function","name":"simulateValidation","stateMutability":"nonpayable","inputs":[
+		{"name":"userOp","type":"tuple","components":[
+			{"name":"sender","type":"address"},
+			{"name":"nonce","type":"uint256"},
+			{"name":"initCode","type":"bytes"},
+			{"name":"callData","type":"bytes"},
+			{"name":"callGasLimit","type":"uint256"},
+			{"name":"verificationGasLimit","type":"uint256"},
+			{"name":"preVerificationGas","type":"uint256"},
+			{"name":"maxFeePerGas","type":"uint256"},
+			{"name":"maxPriorityFeePerGas","type":"uint256"},
+			{"name":"paymasterAndData","type":"bytes"},
+			{"name":"signature","type":"bytes"}]}],"outputs":[]},
+	{"type":"function","name":"depositTo","stateMutability":"payable","inputs":[
+		{"name":"account","type":"address"}],"outputs":[]},
+	{"type":"error","name":"FailedOp","inputs":[
+		{"name":"opIndex","type":"uint256"},
+		{"name":"reason","type":"string"}]},
+	{"type":"error","name":"ValidationResult","inputs":[
+		{"name":"returnInfo","type":"tuple","components":[
+			{"name":"preOpGas","type":"uint256"},
+			{"name":"prefund","type":"uint256"},
+			{"name":"sigFailed","type":"bool"},
+			{"name":"validAfter","type":"uint48"},
+			{"name":"validUntil","type":"uint48"},
+			{"name":"paymasterContext","type":"bytes"}]},
+		{"name":"senderInfo","type":"tuple","components":[
+			{"name":"stake","type":"uint256"},
+			{"name":"unstakeDelaySec","type":"uint256"}]},
+		{"name":"factoryInfo","type":"tuple","components":[
+			{"name":"stake","type":"uint256"},
+			{"name":"unstakeDelaySec","type":"uint256"}]},
+		{"name":"paymasterInfo","type":"tuple","components":[
+			{"name":"stake","type":"uint256"},
+			{"name":"unstakeDelaySec","type":"uint256"}]}]},
+	{"type":"event","name":"UserOperationEvent","anonymous":false,"inputs":[
+		{"name":"userOpHash","type":"bytes32","indexed":true},
+		{"name":"sender","type":"address","indexed":true},
+		{"name":"paymaster","type":"address","indexed":true},
+		{"name":"nonce","type":"uint256","indexed":false},
+		{"name":"success","type":"bool","indexed":false},
+		{"name":"actualGasCost","type":"uint256","indexed":false},
+		{"name":"actualGasUsed","type":"uint256","indexed":false}]},
+	{"type":"event","name":"UserOperationRevertReason","anonymous":false,"inputs":[
+		{"name":"userOpHash","type":"bytes32","indexed":true},
+		{"name":"sender","type":"address","indexed":true},
+		{"name":"nonce","type":"uint256","indexed":false},
+		{"name":"revertReason","type":"bytes","indexed":false}]},
+	{"type":"event","name":"BeforeExecution","anonymous":false,"inputs":[]}
+]`
+
+var (
+	// entryPoint is the parsed EntryPoint interface.
+	entryPoint abi.ABI
+
+	// userOpHashArgs is the encoding of a user operation hashed by the
+	// EntryPoint, with the dynamic fields replaced by their hashes.
+	userOpHashArgs abi.Arguments
+
+	// userOpHashDomain binds the packed user operation hash to an EntryPoint
+	// and chain.
+	userOpHashDomain abi.Arguments
+)
+
+func init() {
+	var err error
+	if entryPoint, err = abi.JSON(strings.NewReader(entryPointABI)); err != nil {
+		panic(err)
+	}
+	newArgs := func(types ...string) abi.Arguments {
+		args := make(abi.Arguments, len(types))
+		for i, t := range types {
+			typ, err := abi.NewType(t, "", nil)
+			if err != nil {
+				panic(err)
+			}
+			args[i] = abi.Argument{Type: typ}
+		}
+		return args
+	}
+	userOpHashArgs = newArgs("address", "uint256", "bytes32", "bytes32", "uint256", "uint256", "uint256", "uint256", "uint256", "bytes32")
+	userOpHashDomain = newArgs("bytes32", "address", "uint256")
+}
+
+// DefaultEntryPoint is the canonical deployment address of the version 0.6
+// EntryPoint contract.
+var DefaultEntryPoint = common.HexToAddress("0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789")
+
+// stakeInfo is the stake an entity has deposited in the EntryPoint.
+type stakeInfo struct {
+	Stake           *big.Int
+	UnstakeDelaySec *big.Int
+}
+
+// returnInfo is the gas and validity information returned by the EntryPoint
+// from a successful simulateValidation.
+type returnInfo struct {
+	PreOpGas         *big.Int
+	Prefund          *big.Int
+	SigFailed        bool
+	ValidAfter       *big.Int
+	ValidUntil       *big.Int
+	PaymasterContext []byte
+}
+
+// validationResult is the decoded ValidationResult error of simulateValidation.
+type validationResult struct {
+	ReturnInfo    returnInfo
+	SenderInfo    stakeInfo
+	FactoryInfo   stakeInfo
+	PaymasterInfo stakeInfo
+}
+
+// errFailedOp is returned if the EntryPoint rejected an operation.
+var errFailedOp = errors.New("operation rejected by entry point")
+
+// decodeSimulateValidation decodes the revert data of a simulateValidation
+// call. The call always reverts, with ValidationResult on success and with
+// FailedOp if the operation was rejected.
+func decodeSimulateValidation(revert []byte) (*validationResult, error) {
+	if len(revert) < 4 {
+		return nil, fmt.Errorf("unexpected simulation result %#x", revert)
+	}
+	var (
+		failed = entryPoint.Errors["FailedOp"]
+		result = entryPoint.Errors["ValidationResult"]
+	)
+	switch {
+	case bytes.Equal(revert[:4], failed.ID[:4]):
+		values, err := failed.Inputs.Unpack(revert[4:])
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", errFailedOp, values[1])
+
+	case bytes.Equal(revert[:4], result.ID[:4]):
+		values, err := result.Inputs.Unpack(revert[4:])
+		if err != nil {
+			return nil, err
+		}
+		res := new(validationResult)
+		if err := result.Inputs.Copy(res, values); err != nil {
+			return nil, err
+		}
+		return res, nil
+
+	default:
+		if reason, err := abi.UnpackRevert(revert); err == nil {
+			return nil, fmt.Errorf("simulation reverted: %s", reason)
+		}
+		return nil, fmt.Errorf("unexpected simulation result %#x", revert)
+	}
+}
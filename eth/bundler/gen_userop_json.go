@@ -0,0 +1,104 @@
+// Code generated by github.com/fjl/gencodec. DO NOT EDIT.
+
+package bundler
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
+)
+
+var _ = (*userOperationMarshaling)(nil)
+
+// MarshalJSON marshals as JSON.
+func (u UserOperation) MarshalJSON() ([]byte, error) {
+	type UserOperation struct {
+		Sender               common.Address `json:"sender"               gencodec:"required"`
+		Nonce                *hexutil.Big   `json:"nonce"                gencodec:"required"`
+		InitCode             hexutil.Bytes  `json:"initCode"             gencodec:"required"`
+		CallData             hexutil.Bytes  `json:"callData"             gencodec:"required"`
+		CallGasLimit         *hexutil.Big   `json:"callGasLimit"`
+		VerificationGasLimit *hexutil.Big   `json:"verificationGasLimit"`
+		PreVerificationGas   *hexutil.Big   `json:"preVerificationGas"`
+		MaxFeePerGas         *hexutil.Big   `json:"maxFeePerGas"`
+		MaxPriorityFeePerGas *hexutil.Big   `json:"maxPriorityFeePerGas"`
+		PaymasterAndData     hexutil.Bytes  `json:"paymasterAndData"`
+		Signature            hexutil.Bytes  `json:"signature"            gencodec:"required"`
+	}
+	var enc UserOperation
+	enc.Sender = u.Sender
+	enc.Nonce = (*hexutil.Big)(u.Nonce)
+	enc.InitCode = u.InitCode
+	enc.CallData = u.CallData
+	enc.CallGasLimit = (*hexutil.Big)(u.CallGasLimit)
+	enc.VerificationGasLimit = (*hexutil.Big)(u.VerificationGasLimit)
+	enc.PreVerificationGas = (*hexutil.Big)(u.PreVerificationGas)
+	enc.MaxFeePerGas = (*hexutil.Big)(u.MaxFeePerGas)
+	enc.MaxPriorityFeePerGas = (*hexutil.Big)(u.MaxPriorityFeePerGas)
+	enc.PaymasterAndData = u.PaymasterAndData
+	enc.Signature = u.Signature
+	return json.Marshal(&enc)
+}
+
+// UnmarshalJSON unmarshals from JSON.
+func (u *UserOperation) UnmarshalJSON(input []byte) error {
+	type UserOperation struct {
+		Sender               *common.Address `json:"sender"               gencodec:"required"`
+		Nonce                *hexutil.Big    `json:"nonce"                gencodec:"required"`
+		InitCode             *hexutil.Bytes  `json:"initCode"             gencodec:"required"`
+		CallData             *hexutil.Bytes  `json:"callData"             gencodec:"required"`
+		CallGasLimit         *hexutil.Big    `json:"callGasLimit"`
+		VerificationGasLimit *hexutil.Big    `json:"verificationGasLimit"`
+		PreVerificationGas   *hexutil.Big    `json:"preVerificationGas"`
+		MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+		MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+		PaymasterAndData     *hexutil.Bytes  `json:"paymasterAndData"`
+		Signature            *hexutil.Bytes  `json:"signature"            gencodec:"required"`
+	}
+	var dec UserOperation
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if dec.Sender == nil {
+		return errors.New("missing required field 'sender' for UserOperation")
+	}
+	u.Sender = *dec.Sender
+	if dec.Nonce == nil {
+		return errors.New("missing required field 'nonce' for UserOperation")
+	}
+	u.Nonce = (*big.Int)(dec.Nonce)
+	if dec.InitCode == nil {
+		return errors.New("missing required field 'initCode' for UserOperation")
+	}
+	u.InitCode = *dec.InitCode
+	if dec.CallData == nil {
+		return errors.New("missing required field 'callData' for UserOperation")
+	}
+	u.CallData = *dec.CallData
+	if dec.CallGasLimit != nil {
+		u.CallGasLimit = (*big.Int)(dec.CallGasLimit)
+	}
+	if dec.VerificationGasLimit != nil {
+		u.VerificationGasLimit = (*big.Int)(dec.VerificationGasLimit)
+	}
+	if dec.PreVerificationGas != nil {
+		u.PreVerificationGas = (*big.Int)(dec.PreVerificationGas)
+	}
+	if dec.MaxFeePerGas != nil {
+		u.MaxFeePerGas = (*big.Int)(dec.MaxFeePerGas)
+	}
+	if dec.MaxPriorityFeePerGas != nil {
+		u.MaxPriorityFeePerGas = (*big.Int)(dec.MaxPriorityFeePerGas)
+	}
+	if dec.PaymasterAndData != nil {
+		u.PaymasterAndData = *dec.PaymasterAndData
+	}
+	if dec.Signature == nil {
+		return errors.New("missing required field 'signature' for UserOperation")
+	}
+	u.Signature = *dec.Signature
+	return nil
+}
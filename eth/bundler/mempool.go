@@ -0,0 +1,244 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bundler
+
+import (
+	"errors"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common"
+)
+
+var (
+	// errAlreadyKnown is returned if the operation is already in the mempool.
+	errAlreadyKnown = errors.New("already known")
+
+	// errReplaceUnderpriced is returned if an operation replacing another one
+	// with the same sender and nonce does not bump its fees sufficiently.
+	errReplaceUnderpriced = errors.New("replacement operation underpriced")
+
+	// errSenderLimit is returned if a sender has too many pending operations.
+	errSenderLimit = errors.New("too many pending operations for sender")
+
+	// errMempoolFull is returned if the mempool has reached its capacity.
+	errMempoolFull = errors.New("mempool full")
+)
+
+// opState is the lifecycle stage of a pooled user operation.
+type opState int
+
+const (
+	opPending   opState = iota // waiting for inclusion in a bundle
+	opSubmitted                // included in a bundle transaction sent to the txpool
+)
+
+// poolEntry is a user operation tracked by the mempool.
+type poolEntry struct {
+	op     *UserOperation
+	hash   common.Hash
+	state  opState
+	bundle common.Hash // hash of the bundle transaction once submitted
+	added  time.Time
+}
+
+// opKey uniquely identifies the slot an operation occupies in the mempool.
+type opKey struct {
+	sender common.Address
+	nonce  string // nonces are 256 bit, with the upper 192 bits being a key
+}
+
+// mempool holds validated user operations waiting to be bundled.
+type mempool struct {
+	entries   map[common.Hash]*poolEntry // all tracked operations by hash
+	slots     map[opKey]common.Hash      // operation occupying a sender/nonce slot
+	senders   map[common.Address]int     // number of operations per sender
+	priceBump uint64                     // minimum fee bump percentage for replacements
+	perSender int                        // maximum number of operations per sender
+	capacity  int                        // maximum number of operations in total
+	lock      sync.RWMutex
+}
+
+// newMempool creates an empty user operation mempool.
+func newMempool(capacity, perSender int, priceBump uint64) *mempool {
+	return &mempool{
+		entries:   make(map[common.Hash]*poolEntry),
+		slots:     make(map[opKey]common.Hash),
+		senders:   make(map[common.Address]int),
+		priceBump: priceBump,
+		perSender: perSender,
+		capacity:  capacity,
+	}
+}
+
+// add inserts a validated operation into the mempool, replacing any pending
+// operation with the same sender and nonce if the fees are bumped enough.
+func (p *mempool) add(op *UserOperation, hash common.Hash) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, ok := p.entries[hash]; ok {
+		return errAlreadyKnown
+	}
+	key := opKey{sender: op.Sender, nonce: op.Nonce.String()}
+	if prevHash, ok := p.slots[key]; ok {
+		prev := p.entries[prevHash]
+		if prev.state != opPending {
+			return errReplaceUnderpriced
+		}
+		if !bumped(prev.op.MaxFeePerGas, op.MaxFeePerGas, p.priceBump) || !bumped(prev.op.MaxPriorityFeePerGas, op.MaxPriorityFeePerGas, p.priceBump) {
+			return errReplaceUnderpriced
+		}
+		p.removeLocked(prevHash)
+	} else {
+		if p.senders[op.Sender] >= p.perSender {
+			return errSenderLimit
+		}
+		if len(p.entries) >= p.capacity {
+			return errMempoolFull
+		}
+	}
+	p.entries[hash] = &poolEntry{op: op, hash: hash, added: time.Now()}
+	p.slots[key] = hash
+	p.senders[op.Sender]++
+	return nil
+}
+
+// bumped returns whether next is at least bump percent higher than prev.
+func bumped(prev, next *big.Int, bump uint64) bool {
+	threshold := new(big.Int).Mul(prev, new(big.Int).SetUint64(100+bump))
+	threshold.Div(threshold, big.NewInt(100))
+	return next.Cmp(threshold) >= 0
+}
+
+// get returns the tracked operation with the given hash.
+func (p *mempool) get(hash common.Hash) *poolEntry {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.entries[hash]
+}
+
+// remove drops the given operations from the mempool.
+func (p *mempool) remove(hashes ...common.Hash) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, hash := range hashes {
+		p.removeLocked(hash)
+	}
+}
+
+func (p *mempool) removeLocked(hash common.Hash) {
+	entry, ok := p.entries[hash]
+	if !ok {
+		return
+	}
+	delete(p.entries, hash)
+	delete(p.slots, opKey{sender: entry.op.Sender, nonce: entry.op.Nonce.String()})
+	if p.senders[entry.op.Sender]--; p.senders[entry.op.Sender] <= 0 {
+		delete(p.senders, entry.op.Sender)
+	}
+}
+
+// pending returns the operations waiting to be bundled, ordered by priority
+// fee. At most one operation per sender is returned, as operations of the
+// same sender may depend on each other's execution.
+func (p *mempool) pending(max int) []*poolEntry {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	var entries []*poolEntry
+	for _, entry := range p.entries {
+		if entry.state == opPending {
+			entries = append(entries, entry)
+		}
+	}
+	slices.SortFunc(entries, func(a, b *poolEntry) int {
+		if c := b.op.MaxPriorityFeePerGas.Cmp(a.op.MaxPriorityFeePerGas); c != 0 {
+			return c
+		}
+		if c := a.op.Nonce.Cmp(b.op.Nonce); c != 0 {
+			return c
+		}
+		return a.added.Compare(b.added)
+	})
+	var (
+		result []*poolEntry
+		seen   = make(map[common.Address]struct{})
+	)
+	for _, entry := range entries {
+		if len(result) >= max {
+			break
+		}
+		if _, ok := seen[entry.op.Sender]; ok {
+			continue
+		}
+		seen[entry.op.Sender] = struct{}{}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// markSubmitted flags the given operations as included in a bundle.
+func (p *mempool) markSubmitted(bundle common.Hash, hashes []common.Hash) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, hash := range hashes {
+		if entry, ok := p.entries[hash]; ok {
+			entry.state, entry.bundle = opSubmitted, bundle
+		}
+	}
+}
+
+// submitted returns the hashes of the operations included in each of the
+// bundles sent to the txpool.
+func (p *mempool) submitted() map[common.Hash][]common.Hash {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	bundles := make(map[common.Hash][]common.Hash)
+	for hash, entry := range p.entries {
+		if entry.state == opSubmitted {
+			bundles[entry.bundle] = append(bundles[entry.bundle], hash)
+		}
+	}
+	return bundles
+}
+
+// resubmit flags the operations of a dropped bundle as pending again.
+func (p *mempool) resubmit(hashes []common.Hash) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, hash := range hashes {
+		if entry, ok := p.entries[hash]; ok {
+			entry.state, entry.bundle = opPending, common.Hash{}
+		}
+	}
+}
+
+// size returns the number of operations tracked by the mempool.
+func (p *mempool) size() int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return len(p.entries)
+}
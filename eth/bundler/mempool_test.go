@@ -0,0 +1,153 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bundler
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/rajchain/go-rajchain/common"
+)
+
+// newTestOp creates a user operation with the given sender, nonce and fees.
+func newTestOp(sender byte, nonce, maxFee, tip int64) *UserOperation {
+	return &UserOperation{
+		Sender:               common.Address{sender},
+		Nonce:                big.NewInt(nonce),
+		CallGasLimit:         big.NewInt(100000),
+		VerificationGasLimit: big.NewInt(100000),
+		PreVerificationGas:   big.NewInt(50000),
+		MaxFeePerGas:         big.NewInt(maxFee),
+		MaxPriorityFeePerGas: big.NewInt(tip),
+		Signature:            []byte{0x01},
+	}
+}
+
+func TestMempoolReplacement(t *testing.T) {
+	var (
+		pool    = newMempool(16, 4, 10)
+		chainID = big.NewInt(1)
+		op      = newTestOp(1, 0, 100, 10)
+		hash    = op.Hash(DefaultEntryPoint, chainID)
+	)
+	if err := pool.add(op, hash); err != nil {
+		t.Fatalf("failed to add operation: %v", err)
+	}
+	if err := pool.add(op, hash); !errors.Is(err, errAlreadyKnown) {
+		t.Fatalf("duplicate error mismatch: have %v, want %v", err, errAlreadyKnown)
+	}
+	// A replacement needs to bump both fees by the configured percentage
+	under := newTestOp(1, 0, 110, 10)
+	if err := pool.add(under, under.Hash(DefaultEntryPoint, chainID)); !errors.Is(err, errReplaceUnderpriced) {
+		t.Fatalf("underpriced replacement error mismatch: have %v, want %v", err, errReplaceUnderpriced)
+	}
+	replacement := newTestOp(1, 0, 110, 11)
+	replacementHash := replacement.Hash(DefaultEntryPoint, chainID)
+	if err := pool.add(replacement, replacementHash); err != nil {
+		t.Fatalf("failed to replace operation: %v", err)
+	}
+	if pool.get(hash) != nil {
+		t.Fatal("replaced operation still tracked")
+	}
+	if pool.get(replacementHash) == nil || pool.size() != 1 {
+		t.Fatal("replacement operation not tracked")
+	}
+	// Submitted operations can't be replaced anymore
+	pool.markSubmitted(common.Hash{0x01}, []common.Hash{replacementHash})
+	again := newTestOp(1, 0, 200, 20)
+	if err := pool.add(again, again.Hash(DefaultEntryPoint, chainID)); !errors.Is(err, errReplaceUnderpriced) {
+		t.Fatalf("submitted replacement error mismatch: have %v, want %v", err, errReplaceUnderpriced)
+	}
+}
+
+func TestMempoolLimits(t *testing.T) {
+	var (
+		pool    = newMempool(3, 2, 10)
+		chainID = big.NewInt(1)
+	)
+	for i := int64(0); i < 2; i++ {
+		op := newTestOp(1, i, 100, 10)
+		if err := pool.add(op, op.Hash(DefaultEntryPoint, chainID)); err != nil {
+			t.Fatalf("failed to add operation %d: %v", i, err)
+		}
+	}
+	op := newTestOp(1, 2, 100, 10)
+	if err := pool.add(op, op.Hash(DefaultEntryPoint, chainID)); !errors.Is(err, errSenderLimit) {
+		t.Fatalf("sender limit error mismatch: have %v, want %v", err, errSenderLimit)
+	}
+	op = newTestOp(2, 0, 100, 10)
+	if err := pool.add(op, op.Hash(DefaultEntryPoint, chainID)); err != nil {
+		t.Fatalf("failed to add operation: %v", err)
+	}
+	op = newTestOp(3, 0, 100, 10)
+	if err := pool.add(op, op.Hash(DefaultEntryPoint, chainID)); !errors.Is(err, errMempoolFull) {
+		t.Fatalf("capacity error mismatch: have %v, want %v", err, errMempoolFull)
+	}
+}
+
+func TestMempoolPending(t *testing.T) {
+	var (
+		pool    = newMempool(16, 4, 10)
+		chainID = big.NewInt(1)
+		ops     = []*UserOperation{
+			newTestOp(1, 1, 100, 5),
+			newTestOp(1, 0, 100, 5),
+			newTestOp(2, 0, 100, 20),
+			newTestOp(3, 0, 100, 1),
+		}
+		hashes []common.Hash
+	)
+	for _, op := range ops {
+		hash := op.Hash(DefaultEntryPoint, chainID)
+		if err := pool.add(op, hash); err != nil {
+			t.Fatalf("failed to add operation: %v", err)
+		}
+		hashes = append(hashes, hash)
+	}
+	// Operations are ordered by tip, with a single operation per sender
+	want := []common.Hash{hashes[2], hashes[1], hashes[3]}
+	pending := pool.pending(10)
+	if len(pending) != len(want) {
+		t.Fatalf("pending count mismatch: have %d, want %d", len(pending), len(want))
+	}
+	for i, entry := range pending {
+		if entry.hash != want[i] {
+			t.Errorf("pending %d mismatch: have %v, want %v", i, entry.hash, want[i])
+		}
+	}
+	if pending := pool.pending(1); len(pending) != 1 || pending[0].hash != hashes[2] {
+		t.Errorf("limited pending mismatch: have %d operations", len(pending))
+	}
+	// Submitted operations are skipped until resubmitted
+	bundle := common.Hash{0x01}
+	pool.markSubmitted(bundle, []common.Hash{hashes[2]})
+	if pending := pool.pending(10); len(pending) != 2 || pending[0].hash != hashes[1] {
+		t.Fatalf("pending mismatch after submission")
+	}
+	if submitted := pool.submitted(); len(submitted[bundle]) != 1 || submitted[bundle][0] != hashes[2] {
+		t.Fatalf("submitted mismatch: have %v", submitted)
+	}
+	pool.resubmit([]common.Hash{hashes[2]})
+	if pending := pool.pending(10); len(pending) != 3 {
+		t.Fatalf("pending count mismatch after resubmission: have %d, want 3", len(pending))
+	}
+	pool.remove(hashes...)
+	if pool.size() != 0 {
+		t.Fatalf("mempool not empty after removal: %d", pool.size())
+	}
+}
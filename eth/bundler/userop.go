@@ -0,0 +1,129 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bundler
+
+import (
+	"math/big"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/crypto"
+)
+
+//go:generate go run github.com/fjl/gencodec -type UserOperation -field-override userOperationMarshaling -out gen_userop_json.go
+
+// UserOperation is an ERC-4337 user operation, as accepted by version 0.6 of
+// the EntryPoint contract.
+type UserOperation struct {
+	Sender               common.Address `json:"sender"               gencodec:"required"`
+	Nonce                *big.Int       `json:"nonce"                gencodec:"required"`
+	InitCode             []byte         `json:"initCode"             gencodec:"required"`
+	CallData             []byte         `json:"callData"             gencodec:"required"`
+	CallGasLimit         *big.Int       `json:"callGasLimit"`
+	VerificationGasLimit *big.Int       `json:"verificationGasLimit"`
+	PreVerificationGas   *big.Int       `json:"preVerificationGas"`
+	MaxFeePerGas         *big.Int       `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *big.Int       `json:"maxPriorityFeePerGas"`
+	PaymasterAndData     []byte         `json:"paymasterAndData"`
+	Signature            []byte         `json:"signature"            gencodec:"required"`
+}
+
+// field type overrides for gencodec
+type userOperationMarshaling struct {
+	Nonce                *hexutil.Big
+	InitCode             hexutil.Bytes
+	CallData             hexutil.Bytes
+	CallGasLimit         *hexutil.Big
+	VerificationGasLimit *hexutil.Big
+	PreVerificationGas   *hexutil.Big
+	MaxFeePerGas         *hexutil.Big
+	MaxPriorityFeePerGas *hexutil.Big
+	PaymasterAndData     hexutil.Bytes
+	Signature            hexutil.Bytes
+}
+
+// Hash returns the hash of the user operation as computed by the EntryPoint:
+// the hash of the packed operation, bound to the EntryPoint and chain.
+func (op *UserOperation) Hash(entryPoint common.Address, chainID *big.Int) common.Hash {
+	packed, err := userOpHashArgs.Pack(
+		op.Sender,
+		op.Nonce,
+		crypto.Keccak256Hash(op.InitCode),
+		crypto.Keccak256Hash(op.CallData),
+		op.CallGasLimit,
+		op.VerificationGasLimit,
+		op.PreVerificationGas,
+		op.MaxFeePerGas,
+		op.MaxPriorityFeePerGas,
+		crypto.Keccak256Hash(op.PaymasterAndData),
+	)
+	if err != nil {
+		panic(err) // all the arguments are statically typed
+	}
+	packed, err = userOpHashDomain.Pack(crypto.Keccak256Hash(packed), entryPoint, chainID)
+	if err != nil {
+		panic(err)
+	}
+	return crypto.Keccak256Hash(packed)
+}
+
+// Factory returns the address of the account factory, or the zero address
+// if the operation does not deploy the sender.
+func (op *UserOperation) Factory() common.Address {
+	if len(op.InitCode) < common.AddressLength {
+		return common.Address{}
+	}
+	return common.BytesToAddress(op.InitCode[:common.AddressLength])
+}
+
+// Paymaster returns the address of the paymaster, or the zero address if the
+// operation pays for itself.
+func (op *UserOperation) Paymaster() common.Address {
+	if len(op.PaymasterAndData) < common.AddressLength {
+		return common.Address{}
+	}
+	return common.BytesToAddress(op.PaymasterAndData[:common.AddressLength])
+}
+
+// requiredGas returns the maximum amount of gas the EntryPoint may consume
+// processing the operation. The verification gas limit applies three times
+// when a paymaster is used: for the account, the paymaster validation and the
+// paymaster postOp.
+func (op *UserOperation) requiredGas() uint64 {
+	mul := uint64(1)
+	if op.Paymaster() != (common.Address{}) {
+		mul = 3
+	}
+	return op.CallGasLimit.Uint64() + op.VerificationGasLimit.Uint64()*mul + op.PreVerificationGas.Uint64()
+}
+
+// Copy returns a deep copy of the user operation.
+func (op *UserOperation) Copy() *UserOperation {
+	return &UserOperation{
+		Sender:               op.Sender,
+		Nonce:                new(big.Int).Set(op.Nonce),
+		InitCode:             common.CopyBytes(op.InitCode),
+		CallData:             common.CopyBytes(op.CallData),
+		CallGasLimit:         new(big.Int).Set(op.CallGasLimit),
+		VerificationGasLimit: new(big.Int).Set(op.VerificationGasLimit),
+		PreVerificationGas:   new(big.Int).Set(op.PreVerificationGas),
+		MaxFeePerGas:         new(big.Int).Set(op.MaxFeePerGas),
+		MaxPriorityFeePerGas: new(big.Int).Set(op.MaxPriorityFeePerGas),
+		PaymasterAndData:     common.CopyBytes(op.PaymasterAndData),
+		Signature:            common.CopyBytes(op.Signature),
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bundler
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/crypto"
+)
+
+func TestUserOperationHash(t *testing.T) {
+	op := newTestOp(1, 7, 100, 10)
+	op.InitCode = []byte{0x01, 0x02}
+	op.CallData = []byte{0x03}
+	op.PaymasterAndData = common.HexToAddress("0xbeef").Bytes()
+
+	// Build the packed encoding by hand, all fields are static 32 byte words
+	word := func(v *big.Int) []byte { return common.LeftPadBytes(v.Bytes(), 32) }
+	var packed []byte
+	packed = append(packed, common.LeftPadBytes(op.Sender.Bytes(), 32)...)
+	packed = append(packed, word(op.Nonce)...)
+	packed = append(packed, crypto.Keccak256(op.InitCode)...)
+	packed = append(packed, crypto.Keccak256(op.CallData)...)
+	packed = append(packed, word(op.CallGasLimit)...)
+	packed = append(packed, word(op.VerificationGasLimit)...)
+	packed = append(packed, word(op.PreVerificationGas)...)
+	packed = append(packed, word(op.MaxFeePerGas)...)
+	packed = append(packed, word(op.MaxPriorityFeePerGas)...)
+	packed = append(packed, crypto.Keccak256(op.PaymasterAndData)...)
+
+	var (
+		chainID = big.NewInt(1337)
+		domain  []byte
+	)
+	domain = append(domain, crypto.Keccak256(packed)...)
+	domain = append(domain, common.LeftPadBytes(DefaultEntryPoint.Bytes(), 32)...)
+	domain = append(domain, word(chainID)...)
+
+	if have, want := op.Hash(DefaultEntryPoint, chainID), crypto.Keccak256Hash(domain); have != want {
+		t.Fatalf("hash mismatch: have %v, want %v", have, want)
+	}
+	// The signature is not part of the hash, the chain is
+	signed := op.Copy()
+	signed.Signature = []byte{0xff}
+	if op.Hash(DefaultEntryPoint, chainID) != signed.Hash(DefaultEntryPoint, chainID) {
+		t.Error("signature changed the operation hash")
+	}
+	if op.Hash(DefaultEntryPoint, chainID) == op.Hash(DefaultEntryPoint, big.NewInt(1)) {
+		t.Error("chain id did not change the operation hash")
+	}
+	if op.Paymaster() != common.HexToAddress("0xbeef") || op.Factory() != (common.Address{}) {
+		t.Errorf("entity mismatch: paymaster %v, factory %v", op.Paymaster(), op.Factory())
+	}
+}
+
+func TestUserOperationJSON(t *testing.T) {
+	op := newTestOp(1, 7, 100, 10)
+	op.InitCode, op.CallData = []byte{}, []byte{0x03}
+	op.PaymasterAndData = []byte{}
+
+	enc, err := json.Marshal(op)
+	if err != nil {
+		t.Fatalf("failed to encode operation: %v", err)
+	}
+	var dec UserOperation
+	if err := json.Unmarshal(enc, &dec); err != nil {
+		t.Fatalf("failed to decode operation: %v", err)
+	}
+	if !reflect.DeepEqual(op, &dec) {
+		t.Errorf("operation mismatch after round trip:\nhave %+v\nwant %+v", &dec, op)
+	}
+	// Required fields must be present
+	if err := json.Unmarshal([]byte(`{"sender":"0x0000000000000000000000000000000000000001"}`), &dec); err == nil {
+		t.Error("expected error for missing fields")
+	}
+}
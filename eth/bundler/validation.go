@@ -0,0 +1,379 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bundler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/core"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/core/vm"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/eth/tracers"
+	"github.com/rajchain/go-rajchain/internal/ethapi"
+	"github.com/rajchain/go-rajchain/params"
+	"github.com/rajchain/go-rajchain/rpc"
+
+	// Force-load the native tracers to make the ERC-7562 tracer available
+	_ "github.com/rajchain/go-rajchain/eth/tracers/native"
+)
+
+const (
+	// validUntilMargin is the minimum remaining validity an operation needs
+	// to be accepted, leaving time for it to be bundled and included.
+	validUntilMargin = 30 * time.Second
+
+	// associatedSlotRange is the number of slots following a mapping entry
+	// which are considered associated with the mapping key, allowing accounts
+	// to keep small structs in mappings keyed by their address.
+	associatedSlotRange = 128
+)
+
+// depositToSelector is the selector of EntryPoint.depositTo, the only method
+// validation frames may call on the EntryPoint.
+var depositToSelector = entryPoint.Methods["depositTo"].ID
+
+// Phases of the validation, as separated by the EntryPoint's NUMBER markers.
+const (
+	phaseFactory = iota
+	phaseAccount
+	phasePaymaster
+)
+
+var phaseNames = []string{"factory", "account", "paymaster"}
+
+// traceResult mirrors the output of the erc7562Tracer.
+type traceResult struct {
+	Phases []*tracePhase   `json:"phases"`
+	Keccak []hexutil.Bytes `json:"keccak"`
+}
+
+type tracePhase struct {
+	Opcodes      map[string]int                  `json:"opcodes"`
+	Access       map[common.Address]*traceAccess `json:"access"`
+	ContractSize map[common.Address]*struct {
+		Size   int    `json:"contractSize"`
+		Opcode string `json:"opcode"`
+	} `json:"contractSize"`
+	Calls []*struct {
+		Type  string         `json:"type"`
+		From  common.Address `json:"from"`
+		To    common.Address `json:"to"`
+		Input hexutil.Bytes  `json:"input"`
+	} `json:"calls"`
+	OOG bool `json:"oog"`
+}
+
+type traceAccess struct {
+	Reads  map[common.Hash]int `json:"reads"`
+	Writes map[common.Hash]int `json:"writes"`
+}
+
+// validator checks user operations against the current chain state by running
+// the EntryPoint's simulateValidation under the ERC-7562 tracer.
+type validator struct {
+	backend Backend
+	config  *Config
+}
+
+// validate runs the full validation of an operation, returning the simulation
+// results if it is acceptable for the mempool.
+func (v *validator) validate(ctx context.Context, op *UserOperation) (*validationResult, error) {
+	if err := v.checkStatic(op); err != nil {
+		return nil, err
+	}
+	res, trace, err := v.simulate(ctx, op)
+	if err != nil {
+		return nil, err
+	}
+	if res.ReturnInfo.SigFailed {
+		return nil, errors.New("invalid user operation signature")
+	}
+	now := uint64(time.Now().Unix())
+	if until := res.ReturnInfo.ValidUntil.Uint64(); until != 0 && until < now+uint64(validUntilMargin/time.Second) {
+		return nil, fmt.Errorf("user operation expires too soon: valid until %d", until)
+	}
+	if after := res.ReturnInfo.ValidAfter.Uint64(); after > now {
+		return nil, fmt.Errorf("user operation not yet valid: valid after %d", after)
+	}
+	if err := v.checkRules(op, res, trace); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// checkStatic performs the sanity checks which don't require execution.
+func (v *validator) checkStatic(op *UserOperation) error {
+	for name, val := range map[string]*big.Int{
+		"nonce":                op.Nonce,
+		"callGasLimit":         op.CallGasLimit,
+		"verificationGasLimit": op.VerificationGasLimit,
+		"preVerificationGas":   op.PreVerificationGas,
+		"maxFeePerGas":         op.MaxFeePerGas,
+		"maxPriorityFeePerGas": op.MaxPriorityFeePerGas,
+	} {
+		if val == nil {
+			return fmt.Errorf("missing %s", name)
+		}
+		if val.Sign() < 0 || val.BitLen() > 256 {
+			return fmt.Errorf("invalid %s", name)
+		}
+	}
+	if op.Sender == (common.Address{}) {
+		return errors.New("missing sender")
+	}
+	if len(op.InitCode) != 0 && len(op.InitCode) < common.AddressLength {
+		return errors.New("initCode too short")
+	}
+	if len(op.PaymasterAndData) != 0 && len(op.PaymasterAndData) < common.AddressLength {
+		return errors.New("paymasterAndData too short")
+	}
+	if op.MaxPriorityFeePerGas.Cmp(op.MaxFeePerGas) > 0 {
+		return errors.New("maxPriorityFeePerGas higher than maxFeePerGas")
+	}
+	if !op.VerificationGasLimit.IsUint64() || op.VerificationGasLimit.Uint64() > v.config.MaxVerificationGas {
+		return fmt.Errorf("verificationGasLimit too high: max %d", v.config.MaxVerificationGas)
+	}
+	if !op.CallGasLimit.IsUint64() || !op.PreVerificationGas.IsUint64() || op.requiredGas() > v.config.MaxBundleGas {
+		return fmt.Errorf("user operation gas exceeds bundle gas limit %d", v.config.MaxBundleGas)
+	}
+	if min := preVerificationGas(op); op.PreVerificationGas.Uint64() < min {
+		return fmt.Errorf("preVerificationGas too low: have %d, want at least %d", op.PreVerificationGas, min)
+	}
+	return nil
+}
+
+// simulate executes simulateValidation on the latest state with the ERC-7562
+// tracer attached, returning the decoded validation result and the trace.
+func (v *validator) simulate(ctx context.Context, op *UserOperation) (*validationResult, *traceResult, error) {
+	statedb, header, err := v.backend.StateAndHeaderByNumberOrHash(ctx, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(op.InitCode) == 0 && len(statedb.GetCode(op.Sender)) == 0 {
+		return nil, nil, errors.New("sender not deployed and no initCode provided")
+	}
+	if len(op.InitCode) != 0 && len(statedb.GetCode(op.Sender)) != 0 {
+		return nil, nil, errors.New("sender already deployed but initCode provided")
+	}
+	data, err := entryPoint.Pack("simulateValidation", op)
+	if err != nil {
+		return nil, nil, err
+	}
+	tracer, err := tracers.DefaultDirectory.New("erc7562Tracer", new(tracers.Context), nil, v.backend.ChainConfig())
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		entry = v.config.EntryPoint
+		msg   = &core.Message{
+			From:             common.Address{},
+			To:               &entry,
+			Value:            new(big.Int),
+			GasLimit:         v.backend.RPCGasCap(),
+			GasPrice:         new(big.Int),
+			GasFeeCap:        new(big.Int),
+			GasTipCap:        new(big.Int),
+			Data:             data,
+			SkipNonceChecks:  true,
+			SkipFromEOACheck: true,
+		}
+		blockCtx = core.NewEVMBlockContext(header, ethapi.NewChainContext(ctx, v.backend), nil)
+	)
+	if msg.GasLimit == 0 {
+		msg.GasLimit = header.GasLimit
+	}
+	blockCtx.BaseFee = new(big.Int)
+	evm := v.backend.GetEVM(ctx, statedb, header, &vm.Config{Tracer: tracer.Hooks, NoBaseFee: true}, &blockCtx)
+	evm.SetTxContext(core.NewEVMTxContext(msg))
+
+	tx := types.NewTx(&types.LegacyTx{To: msg.To, Gas: msg.GasLimit, GasPrice: msg.GasPrice, Data: msg.Data})
+	tracer.OnTxStart(evm.GetVMContext(), tx, msg.From)
+	result, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(msg.GasLimit))
+	if err != nil {
+		return nil, nil, err
+	}
+	if !result.Failed() {
+		return nil, nil, errors.New("simulateValidation did not revert")
+	}
+	res, err := decodeSimulateValidation(result.Revert())
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, err := tracer.GetResult()
+	if err != nil {
+		return nil, nil, err
+	}
+	trace := new(traceResult)
+	if err := json.Unmarshal(raw, trace); err != nil {
+		return nil, nil, err
+	}
+	return res, trace, nil
+}
+
+// checkRules enforces the ERC-7562 opcode, storage and call rules on the
+// trace of a simulateValidation call.
+func (v *validator) checkRules(op *UserOperation, res *validationResult, trace *traceResult) error {
+	var (
+		sender   = op.Sender
+		entities = []common.Address{op.Factory(), sender, op.Paymaster()}
+		stakes   = []stakeInfo{res.FactoryInfo, res.SenderInfo, res.PaymasterInfo}
+		rules    = v.backend.ChainConfig().Rules(v.backend.CurrentHeader().Number, true, v.backend.CurrentHeader().Time)
+		precomps = vm.ActivePrecompiles(rules)
+	)
+	associated := func(addr common.Address, slot common.Hash) bool {
+		return associatedSlot(addr, slot, trace.Keccak)
+	}
+	for i, phase := range trace.Phases {
+		if i > phasePaymaster {
+			break
+		}
+		var (
+			name   = phaseNames[i]
+			entity = entities[i]
+			staked = v.isStaked(stakes[i])
+		)
+		if entity == (common.Address{}) {
+			continue
+		}
+		// Banned opcodes, CREATE2 is only allowed once while deploying the sender
+		for opcode, count := range phase.Opcodes {
+			if opcode == vm.CREATE2.String() && i == phaseFactory && count == 1 {
+				continue
+			}
+			return fmt.Errorf("%s %v uses banned opcode %s", name, entity, opcode)
+		}
+		if phase.OOG {
+			return fmt.Errorf("%s %v ran out of gas during validation", name, entity)
+		}
+		// Storage may only be accessed if associated with the sender, or with a
+		// staked entity
+		for addr, access := range phase.Access {
+			if addr == sender {
+				continue
+			}
+			slots := make([]common.Hash, 0, len(access.Reads)+len(access.Writes))
+			for slot := range access.Reads {
+				slots = append(slots, slot)
+			}
+			for slot := range access.Writes {
+				slots = append(slots, slot)
+			}
+			for _, slot := range slots {
+				if associated(sender, slot) {
+					continue
+				}
+				if staked && (addr == entity || associated(entity, slot)) {
+					continue
+				}
+				return fmt.Errorf("%s %v accesses forbidden storage of %v at slot %v", name, entity, addr, slot)
+			}
+		}
+		// Calls into the EntryPoint are restricted to deposits
+		for _, call := range phase.Calls {
+			if call.To != v.config.EntryPoint || call.From == v.config.EntryPoint {
+				continue
+			}
+			if len(call.Input) != 0 && (len(call.Input) < 4 || !bytes.Equal(call.Input[:4], depositToSelector)) {
+				return fmt.Errorf("%s %v calls entry point method %#x", name, entity, call.Input[:min(4, len(call.Input))])
+			}
+		}
+		// Accessed accounts must have code, except for the sender being deployed
+		for addr, info := range phase.ContractSize {
+			if info.Size > 0 || slices.Contains(precomps, addr) {
+				continue
+			}
+			if i == phaseFactory && addr == sender {
+				continue
+			}
+			return fmt.Errorf("%s %v accesses account %v without code via %s", name, entity, addr, info.Opcode)
+		}
+	}
+	return nil
+}
+
+// isStaked returns whether an entity's stake satisfies the configured minimum.
+func (v *validator) isStaked(info stakeInfo) bool {
+	if info.Stake == nil || info.UnstakeDelaySec == nil {
+		return false
+	}
+	return info.Stake.Cmp(v.config.MinStake) >= 0 && info.UnstakeDelaySec.Uint64() >= v.config.MinUnstakeDelay
+}
+
+// associatedSlot returns whether a storage slot is associated with an address:
+// either the slot equals the address, or it is within range of a mapping entry
+// keyed by the address, as detected from the recorded KECCAK256 preimages.
+func associatedSlot(addr common.Address, slot common.Hash, preimages []hexutil.Bytes) bool {
+	key := common.BytesToHash(addr.Bytes())
+	if slot == key {
+		return true
+	}
+	target := new(big.Int).SetBytes(slot[:])
+	for _, preimage := range preimages {
+		if len(preimage) < common.HashLength || !bytes.Equal(preimage[:common.HashLength], key[:]) {
+			continue
+		}
+		base := new(big.Int).SetBytes(crypto.Keccak256(preimage))
+		if diff := new(big.Int).Sub(target, base); diff.Sign() >= 0 && diff.Cmp(big.NewInt(associatedSlotRange)) < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Gas overheads used to calculate the minimum preVerificationGas, covering the
+// calldata cost and the EntryPoint overhead not metered by the other limits.
+const (
+	preVerificationFixed    = 21000 // transaction overhead, assuming a single operation bundle
+	preVerificationPerOp    = 18300 // EntryPoint overhead per operation
+	preVerificationPerWord  = 4     // cost of copying an operation word
+	preVerificationSigBytes = 65    // signature size assumed when the signature is missing
+)
+
+// preVerificationGas calculates the minimum preVerificationGas for an operation.
+func preVerificationGas(op *UserOperation) uint64 {
+	op = op.Copy()
+	op.PreVerificationGas = new(big.Int).SetUint64(params.MaxGasLimit)
+	if len(op.Signature) == 0 {
+		op.Signature = bytes.Repeat([]byte{0x01}, preVerificationSigBytes)
+	}
+	packed, err := entryPoint.Methods["simulateValidation"].Inputs.Pack(op)
+	if err != nil {
+		return 0
+	}
+	// Drop the offset of the tuple to get the bare encoding of the operation
+	packed = packed[common.HashLength:]
+
+	gas := uint64(preVerificationFixed + preVerificationPerOp)
+	for _, b := range packed {
+		if b == 0 {
+			gas += params.TxDataZeroGas
+		} else {
+			gas += params.TxDataNonZeroGasEIP2028
+		}
+	}
+	return gas + preVerificationPerWord*uint64((len(packed)+31)/32)
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bundler
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/crypto"
+)
+
+func TestAssociatedSlot(t *testing.T) {
+	var (
+		addr  = common.HexToAddress("0x1234")
+		other = common.HexToAddress("0x5678")
+		key   = common.LeftPadBytes(addr.Bytes(), 32)
+
+		// preimage of mapping(address => ...) at slot 3
+		preimage = append(common.CopyBytes(key), common.LeftPadBytes([]byte{3}, 32)...)
+		base     = new(big.Int).SetBytes(crypto.Keccak256(preimage))
+	)
+	slot := func(offset int64) common.Hash {
+		return common.BigToHash(new(big.Int).Add(base, big.NewInt(offset)))
+	}
+	tests := []struct {
+		addr common.Address
+		slot common.Hash
+		want bool
+	}{
+		{addr, common.BytesToHash(key), true},
+		{addr, slot(0), true},
+		{addr, slot(associatedSlotRange - 1), true},
+		{addr, slot(associatedSlotRange), false},
+		{addr, slot(-1), false},
+		{other, slot(0), false},
+		{other, common.BytesToHash(key), false},
+	}
+	preimages := []hexutil.Bytes{preimage}
+	for i, tt := range tests {
+		if have := associatedSlot(tt.addr, tt.slot, preimages); have != tt.want {
+			t.Errorf("test %d: association mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+func TestDecodeSimulateValidation(t *testing.T) {
+	// Successful validations revert with ValidationResult
+	want := validationResult{
+		ReturnInfo: returnInfo{
+			PreOpGas:         big.NewInt(80000),
+			Prefund:          big.NewInt(1000000),
+			SigFailed:        false,
+			ValidAfter:       big.NewInt(0),
+			ValidUntil:       big.NewInt(1700000000),
+			PaymasterContext: []byte{},
+		},
+		SenderInfo:    stakeInfo{big.NewInt(1), big.NewInt(2)},
+		FactoryInfo:   stakeInfo{big.NewInt(3), big.NewInt(4)},
+		PaymasterInfo: stakeInfo{big.NewInt(5), big.NewInt(6)},
+	}
+	result := entryPoint.Errors["ValidationResult"]
+	data, err := result.Inputs.Pack(want.ReturnInfo, want.SenderInfo, want.FactoryInfo, want.PaymasterInfo)
+	if err != nil {
+		t.Fatalf("failed to pack result: %v", err)
+	}
+	have, err := decodeSimulateValidation(append(result.ID[:4], data...))
+	if err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if have.ReturnInfo.PreOpGas.Cmp(want.ReturnInfo.PreOpGas) != 0 || have.ReturnInfo.ValidUntil.Cmp(want.ReturnInfo.ValidUntil) != 0 {
+		t.Errorf("return info mismatch: have %+v, want %+v", have.ReturnInfo, want.ReturnInfo)
+	}
+	if have.PaymasterInfo.Stake.Cmp(want.PaymasterInfo.Stake) != 0 || have.FactoryInfo.UnstakeDelaySec.Cmp(want.FactoryInfo.UnstakeDelaySec) != 0 {
+		t.Errorf("stake info mismatch: have %+v, want %+v", have, want)
+	}
+	// Rejected operations revert with FailedOp
+	failed := entryPoint.Errors["FailedOp"]
+	data, err = failed.Inputs.Pack(big.NewInt(0), "AA21 didn't pay prefund")
+	if err != nil {
+		t.Fatalf("failed to pack failure: %v", err)
+	}
+	if _, err := decodeSimulateValidation(append(failed.ID[:4], data...)); !errors.Is(err, errFailedOp) {
+		t.Errorf("failure error mismatch: have %v, want %v", err, errFailedOp)
+	}
+	// Anything else is unexpected
+	if _, err := decodeSimulateValidation([]byte{0x01, 0x02}); err == nil {
+		t.Error("expected error for short revert data")
+	}
+}
+
+func TestPreVerificationGas(t *testing.T) {
+	op := newTestOp(1, 0, 100, 10)
+	short := preVerificationGas(op)
+	if short <= preVerificationFixed+preVerificationPerOp {
+		t.Fatalf("preVerificationGas %d doesn't cover the fixed overhead", short)
+	}
+	// Calldata is charged for
+	op.CallData = make([]byte, 64)
+	for i := range op.CallData {
+		op.CallData[i] = 0xff
+	}
+	if long := preVerificationGas(op); long <= short {
+		t.Errorf("preVerificationGas not increased by calldata: %d <= %d", long, short)
+	}
+}
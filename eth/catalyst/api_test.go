@@ -36,6 +36,7 @@ import (
 	beaconConsensus "github.com/rajchain/go-rajchain/consensus/beacon"
 	"github.com/rajchain/go-rajchain/consensus/ethash"
 	"github.com/rajchain/go-rajchain/core"
+	"github.com/rajchain/go-rajchain/core/stateless"
 	"github.com/rajchain/go-rajchain/core/types"
 	"github.com/rajchain/go-rajchain/crypto"
 	"github.com/rajchain/go-rajchain/crypto/kzg4844"
@@ -47,6 +48,7 @@ import (
 	"github.com/rajchain/go-rajchain/node"
 	"github.com/rajchain/go-rajchain/p2p"
 	"github.com/rajchain/go-rajchain/params"
+	"github.com/rajchain/go-rajchain/rlp"
 	"github.com/rajchain/go-rajchain/rpc"
 	"github.com/rajchain/go-rajchain/trie"
 )
@@ -1821,3 +1823,29 @@ func TestGetClientVersion(t *testing.T) {
 		t.Fatalf("client info does match expected, got %s", info.String())
 	}
 }
+
+// Tests that the execution witness served over debug_executionWitness is enough
+// to verify an already imported block statelessly.
+func TestExecutionWitness(t *testing.T) {
+	genesis, blocks := generateMergeChain(10, true)
+	n, ethservice := startEthService(t, genesis, blocks)
+	defer n.Close()
+
+	api := eth.NewDebugAPI(ethservice)
+	for _, block := range blocks[len(blocks)-3:] {
+		blob, err := api.ExecutionWitness(context.Background(), rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(block.NumberU64())))
+		if err != nil {
+			t.Fatalf("block %d: failed to retrieve witness: %v", block.NumberU64(), err)
+		}
+		witness := new(stateless.Witness)
+		if err := rlp.DecodeBytes(blob, witness); err != nil {
+			t.Fatalf("block %d: failed to decode witness: %v", block.NumberU64(), err)
+		}
+		if err := core.VerifyStateless(genesis.Config, block, witness); err != nil {
+			t.Fatalf("block %d: stateless verification failed: %v", block.NumberU64(), err)
+		}
+	}
+	if _, err := api.ExecutionWitness(context.Background(), rpc.BlockNumberOrHashWithNumber(0)); err == nil {
+		t.Fatal("witness of the genesis block served")
+	}
+}
@@ -71,6 +71,22 @@ func (w *withdrawalQueue) pop(count int) types.Withdrawals {
 	return popped
 }
 
+// snapshot returns a copy of the withdrawals currently pending inclusion.
+func (w *withdrawalQueue) snapshot() types.Withdrawals {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append(types.Withdrawals{}, w.pending...)
+}
+
+// restore replaces the pending withdrawals with the given set.
+func (w *withdrawalQueue) restore(withdrawals types.Withdrawals) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending = append(types.Withdrawals{}, withdrawals...)
+}
+
 // subscribe allows a listener to be updated when new withdrawals are added to
 // the queue.
 func (w *withdrawalQueue) subscribe(ch chan<- newWithdrawalsEvent) event.Subscription {
@@ -93,6 +109,21 @@ type SimulatedBeacon struct {
 	engineAPI          *ConsensusAPI
 	curForkchoiceState engine.ForkchoiceStateV1
 	lastBlockTime      uint64
+	sealLock           sync.Mutex // lock gates block production against chain reverts
+
+	snapshots    map[uint64]*devSnapshot // snapshots taken via Snapshot, keyed by id
+	nextSnapshot uint64                  // id to assign to the next snapshot
+}
+
+// devSnapshot is a point-in-time capture of the simulated chain, restorable
+// via Revert.
+type devSnapshot struct {
+	head          *types.Header
+	forkchoice    engine.ForkchoiceStateV1
+	lastBlockTime uint64
+	withdrawals   types.Withdrawals
+	locals        []*types.Transaction // pool content of local accounts
+	remotes       []*types.Transaction // pool content of remote accounts
 }
 
 // NewSimulatedBeacon constructs a new simulated beacon chain.
@@ -118,6 +149,8 @@ func NewSimulatedBeacon(period uint64, eth *eth.rajchain) (*SimulatedBeacon, err
 		engineAPI:          engineAPI,
 		lastBlockTime:      block.Time,
 		curForkchoiceState: current,
+		snapshots:          make(map[uint64]*devSnapshot),
+		nextSnapshot:       1,
 	}, nil
 }
 
@@ -148,6 +181,9 @@ func (c *SimulatedBeacon) Stop() error {
 // sealBlock initiates payload building for a new block and creates a new block
 // with the completed payload.
 func (c *SimulatedBeacon) sealBlock(withdrawals []*types.Withdrawal, timestamp uint64) error {
+	c.sealLock.Lock()
+	defer c.sealLock.Unlock()
+
 	if timestamp <= c.lastBlockTime {
 		timestamp = c.lastBlockTime + 1
 	}
@@ -317,6 +353,109 @@ func (c *SimulatedBeacon) AdjustTime(adjustment time.Duration) error {
 	return c.sealBlock(withdrawals, parent.Time+uint64(adjustment/time.Second))
 }
 
+// Snapshot captures the current head, the transaction pool contents and the
+// simulated beacon state, returning an id which can be passed to Revert.
+func (c *SimulatedBeacon) Snapshot() (uint64, error) {
+	c.sealLock.Lock()
+	defer c.sealLock.Unlock()
+
+	// Ensure the pool reflects the current head before capturing it
+	if err := c.eth.TxPool().Sync(); err != nil {
+		return 0, fmt.Errorf("failed to sync txpool: %w", err)
+	}
+	snap := &devSnapshot{
+		head:          c.eth.BlockChain().CurrentBlock(),
+		forkchoice:    c.curForkchoiceState,
+		lastBlockTime: c.lastBlockTime,
+		withdrawals:   c.withdrawals.snapshot(),
+	}
+	locals := make(map[common.Address]struct{})
+	for _, addr := range c.eth.TxPool().Locals() {
+		locals[addr] = struct{}{}
+	}
+	pending, queued := c.eth.TxPool().Content()
+	for _, content := range []map[common.Address][]*types.Transaction{pending, queued} {
+		for addr, txs := range content {
+			if _, ok := locals[addr]; ok {
+				snap.locals = append(snap.locals, txs...)
+			} else {
+				snap.remotes = append(snap.remotes, txs...)
+			}
+		}
+	}
+	id := c.nextSnapshot
+	c.nextSnapshot++
+	c.snapshots[id] = snap
+
+	log.Info("Created dev chain snapshot", "id", id, "number", snap.head.Number, "hash", snap.head.Hash(), "txs", len(snap.locals)+len(snap.remotes))
+	return id, nil
+}
+
+// Revert rewinds the chain to the head captured by the given snapshot and
+// restores the transaction pool and simulated beacon state. The snapshot and
+// all the ones taken after it are discarded.
+func (c *SimulatedBeacon) Revert(id uint64) error {
+	c.sealLock.Lock()
+	defer c.sealLock.Unlock()
+
+	snap, ok := c.snapshots[id]
+	if !ok {
+		return fmt.Errorf("unknown snapshot %d", id)
+	}
+	for sid := range c.snapshots {
+		if sid >= id {
+			delete(c.snapshots, sid)
+		}
+	}
+	var (
+		chain  = c.eth.BlockChain()
+		number = snap.head.Number.Uint64()
+		hash   = snap.head.Hash()
+	)
+	if chain.CurrentBlock().Hash() != hash {
+		if chain.GetCanonicalHash(number) == hash {
+			if err := chain.SetHead(number); err != nil {
+				return err
+			}
+		} else {
+			// The snapshot head was reorged out, make it canonical again
+			block := chain.GetBlock(hash, number)
+			if block == nil {
+				return fmt.Errorf("snapshot head #%d [%x..] not found", number, hash.Bytes()[:4])
+			}
+			if _, err := chain.SetCanonical(block); err != nil {
+				return err
+			}
+		}
+		if head := chain.CurrentBlock(); head.Hash() != hash {
+			return fmt.Errorf("failed to revert to #%d [%x..], state unavailable, head at #%d", number, hash.Bytes()[:4], head.Number)
+		}
+	}
+	// Wait for the pool to process the head change, then replace its content
+	// with the captured transactions.
+	pool := c.eth.TxPool()
+	if err := pool.Sync(); err != nil {
+		return fmt.Errorf("failed to sync txpool: %w", err)
+	}
+	pool.Clear()
+	for _, err := range pool.Add(snap.locals, true, true) {
+		if err != nil {
+			log.Warn("Failed to restore local transaction", "err", err)
+		}
+	}
+	for _, err := range pool.Add(snap.remotes, false, true) {
+		if err != nil {
+			log.Warn("Failed to restore remote transaction", "err", err)
+		}
+	}
+	c.curForkchoiceState = snap.forkchoice
+	c.lastBlockTime = snap.lastBlockTime
+	c.withdrawals.restore(snap.withdrawals)
+
+	log.Info("Reverted dev chain to snapshot", "id", id, "number", number, "hash", hash)
+	return nil
+}
+
 // RegisterSimulatedBeaconAPIs registers the simulated beacon's API with the
 // stack.
 func RegisterSimulatedBeaconAPIs(stack *node.Node, sim *SimulatedBeacon) {
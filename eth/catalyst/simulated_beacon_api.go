@@ -20,6 +20,7 @@ import (
 	"context"
 
 	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
 	"github.com/rajchain/go-rajchain/core"
 	"github.com/rajchain/go-rajchain/core/types"
 )
@@ -103,3 +104,16 @@ func (a *simulatedBeaconAPI) AddWithdrawal(ctx context.Context, withdrawal *type
 func (a *simulatedBeaconAPI) SetFeeRecipient(ctx context.Context, feeRecipient common.Address) {
 	a.sim.setFeeRecipient(feeRecipient)
 }
+
+// Snapshot captures the current chain head, transaction pool contents and
+// simulated beacon state, returning an id usable with Revert.
+func (a *simulatedBeaconAPI) Snapshot(ctx context.Context) (hexutil.Uint64, error) {
+	id, err := a.sim.Snapshot()
+	return hexutil.Uint64(id), err
+}
+
+// Revert restores the chain to a snapshot previously taken via Snapshot. The
+// snapshot, and all the ones taken after it, can't be used afterwards.
+func (a *simulatedBeaconAPI) Revert(ctx context.Context, id hexutil.Uint64) error {
+	return a.sim.Revert(uint64(id))
+}
@@ -203,3 +203,72 @@ func TestOnDemandSpam(t *testing.T) {
 		}
 	}
 }
+
+// Tests that reverting to a snapshot restores the chain head, the pending
+// transactions and the simulated beacon timestamps.
+func TestSimulatedBeaconSnapshotRevert(t *testing.T) {
+	var (
+		testKey, _             = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		testAddr               = crypto.PubkeyToAddress(testKey.PublicKey)
+		gasLimit        uint64 = 10_000_000
+		genesis                = core.DeveloperGenesisBlock(gasLimit, &testAddr)
+		node, eth, mock        = startSimulatedBeaconEthService(t, genesis, 0)
+		signer                 = types.LatestSigner(eth.BlockChain().Config())
+	)
+	defer node.Close()
+
+	sendTx := func(nonce uint64) *types.Transaction {
+		tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{0x01}, big.NewInt(1000), params.TxGas, big.NewInt(params.InitialBaseFee*2), nil), signer, testKey)
+		if err != nil {
+			t.Fatal("error signing transaction", err)
+		}
+		if err := eth.APIBackend.SendTx(context.Background(), tx); err != nil {
+			t.Fatal("error adding tx to pool", err)
+		}
+		return tx
+	}
+	sendTx(0)
+	mock.Commit()
+	pendingTx := sendTx(1)
+
+	head := eth.BlockChain().CurrentBlock()
+	lastBlockTime := mock.lastBlockTime
+	id, err := mock.Snapshot()
+	if err != nil {
+		t.Fatal("failed to take snapshot", err)
+	}
+	// Mine the pending transaction and a few more blocks on top
+	mock.Commit()
+	sendTx(2)
+	mock.Commit()
+	eth.TxPool().Sync()
+	if err := mock.AdjustTime(time.Hour); err != nil {
+		t.Fatal("failed to adjust time", err)
+	}
+	if number := eth.BlockChain().CurrentBlock().Number.Uint64(); number != head.Number.Uint64()+3 {
+		t.Fatalf("unexpected head number: have %d, want %d", number, head.Number.Uint64()+3)
+	}
+	if err := mock.Revert(id); err != nil {
+		t.Fatal("failed to revert", err)
+	}
+	if have := eth.BlockChain().CurrentBlock().Hash(); have != head.Hash() {
+		t.Fatalf("head mismatch after revert: have %x, want %x", have, head.Hash())
+	}
+	if mock.lastBlockTime != lastBlockTime {
+		t.Fatalf("block time mismatch after revert: have %d, want %d", mock.lastBlockTime, lastBlockTime)
+	}
+	if mock.curForkchoiceState.HeadBlockHash != head.Hash() {
+		t.Fatalf("forkchoice head mismatch after revert: have %x, want %x", mock.curForkchoiceState.HeadBlockHash, head.Hash())
+	}
+	pending, queued := eth.TxPool().Content()
+	if len(pending[testAddr]) != 1 || len(queued) != 0 {
+		t.Fatalf("unexpected pool content after revert: pending %d, queued %d", len(pending[testAddr]), len(queued))
+	}
+	if pending[testAddr][0].Hash() != pendingTx.Hash() {
+		t.Fatalf("unexpected pending transaction after revert: have %x, want %x", pending[testAddr][0].Hash(), pendingTx.Hash())
+	}
+	// The snapshot is consumed by the revert
+	if err := mock.Revert(id); err == nil {
+		t.Fatal("expected error reverting to consumed snapshot")
+	}
+}
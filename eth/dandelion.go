@@ -0,0 +1,320 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/mclock"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/eth/protocols/eth"
+	"github.com/rajchain/go-rajchain/log"
+	"github.com/rajchain/go-rajchain/metrics"
+)
+
+const (
+	// stemEpoch is the time the stem relays and the role of the node stay the
+	// same, so repeated transactions can't be used to probe the graph.
+	stemEpoch = 10 * time.Minute
+
+	// stemRelays is the number of peers picked as stem relays in an epoch.
+	stemRelays = 2
+
+	// localSource is the route key of locally submitted transactions.
+	localSource = ""
+)
+
+var (
+	stemLocalMeter   = metrics.NewRegisteredMeter("eth/stem/local", nil)
+	stemRelayMeter   = metrics.NewRegisteredMeter("eth/stem/relay", nil)
+	stemFluffMeter   = metrics.NewRegisteredMeter("eth/stem/fluff", nil)
+	stemEmbargoMeter = metrics.NewRegisteredMeter("eth/stem/embargo", nil)
+)
+
+// dandelion implements Dandelion++ style propagation of transactions, hiding
+// the node they originate from. Local transactions are not broadcast, but sent
+// along the stem: a chain of relays, each forwarding the transaction to a
+// single peer supporting stem relays on the `eth` protocol. Every epoch, relays
+// decide to be diffusers with probability 1/length, which end the stem by
+// broadcasting (fluffing) the transactions. Peers without stem support are
+// never picked as relays and only receive the fluffed transactions.
+//
+// Stem transactions are embargoed: they are held back from broadcasts until the
+// embargo timer expires, at which point the node fluffs them itself. This
+// guarantees propagation if a relay drops the transaction or disconnects.
+type dandelion struct {
+	length  int           // Expected length of the stem
+	embargo time.Duration // Minimum time to wait for a stem transaction to be fluffed
+	clock   mclock.Clock
+	txpool  txPool
+	fluff   func(types.Transactions) // Broadcasts transactions to all peers
+
+	lock      sync.Mutex
+	peers     map[string]*eth.Peer         // Peers supporting stem relays
+	relays    []*eth.Peer                  // Stem relays of the current epoch
+	routes    map[string]*eth.Peer         // Relay assigned to each source
+	diffuser  bool                         // Whether stem transactions are fluffed in this epoch
+	epochEnd  mclock.AbsTime               // End of the current epoch, zero if routes are invalid
+	embargoes map[common.Hash]mclock.Timer // Embargo timers of the stem transactions
+}
+
+func newDandelion(length int, embargo time.Duration, clock mclock.Clock, txpool txPool, fluff func(types.Transactions)) *dandelion {
+	return &dandelion{
+		length:    length,
+		embargo:   embargo,
+		clock:     clock,
+		txpool:    txpool,
+		fluff:     fluff,
+		peers:     make(map[string]*eth.Peer),
+		routes:    make(map[string]*eth.Peer),
+		embargoes: make(map[common.Hash]mclock.Timer),
+	}
+}
+
+// register adds a peer as candidate relay, if it supports stem relays.
+func (d *dandelion) register(peer *eth.Peer) {
+	if !peer.SupportsStem() {
+		return
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.peers[peer.ID()] = peer
+}
+
+// unregister removes a peer, picking new relays if it was one of them.
+// Transactions relayed to it before are fluffed by the embargo timers.
+func (d *dandelion) unregister(id string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	delete(d.peers, id)
+	for _, relay := range d.relays {
+		if relay.ID() == id {
+			d.epochEnd = 0
+		}
+	}
+}
+
+// submit adds a local transaction to the pool and sends it along the stem.
+func (d *dandelion) submit(tx *types.Transaction) error {
+	if !d.hold(tx.Hash()) {
+		// The transaction is already on its way.
+		return d.txpool.Add([]*types.Transaction{tx}, true, false)[0]
+	}
+	if err := d.txpool.Add([]*types.Transaction{tx}, true, false)[0]; err != nil {
+		d.release(tx.Hash())
+		return err
+	}
+	stemLocalMeter.Mark(1)
+	d.forward(localSource, types.Transactions{tx})
+	return nil
+}
+
+// relay adds the transactions received on the stem from a peer to the pool,
+// and relays the new ones along the stem.
+func (d *dandelion) relay(from string, txs []*types.Transaction) {
+	var fresh types.Transactions
+	for _, tx := range txs {
+		if !d.txpool.Has(tx.Hash()) && d.hold(tx.Hash()) {
+			fresh = append(fresh, tx)
+		}
+	}
+	if len(fresh) == 0 {
+		return
+	}
+	var added types.Transactions
+	for i, err := range d.txpool.Add(fresh, false, false) {
+		if err != nil {
+			d.release(fresh[i].Hash())
+			continue
+		}
+		added = append(added, fresh[i])
+	}
+	if len(added) == 0 {
+		return
+	}
+	stemRelayMeter.Mark(int64(len(added)))
+	d.forward(from, added)
+}
+
+// forward sends embargoed transactions to the relay of their source, or fluffs
+// them if the node is a diffuser or there is no relay.
+func (d *dandelion) forward(from string, txs types.Transactions) {
+	d.lock.Lock()
+	d.updateEpoch()
+	var (
+		relay   = d.route(from)
+		diffuse = relay == nil || (d.diffuser && from != localSource)
+	)
+	if diffuse {
+		for _, tx := range txs {
+			d.releaseLocked(tx.Hash())
+		}
+	}
+	d.lock.Unlock()
+
+	if diffuse {
+		stemFluffMeter.Mark(int64(len(txs)))
+		d.fluff(txs)
+		return
+	}
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+	}
+	relay.AsyncSendStemTransactions(hashes)
+}
+
+// updateEpoch picks new relays and decides the role of the node, if the epoch
+// is over or the routes were invalidated.
+func (d *dandelion) updateEpoch() {
+	now := d.clock.Now()
+	if d.epochEnd != 0 && now < d.epochEnd {
+		return
+	}
+	peers := make([]*eth.Peer, 0, len(d.peers))
+	for _, peer := range d.peers {
+		peers = append(peers, peer)
+	}
+	rand.Shuffle(len(peers), func(i, j int) { peers[i], peers[j] = peers[j], peers[i] })
+
+	d.relays = peers[:min(len(peers), stemRelays)]
+	d.routes = make(map[string]*eth.Peer)
+	d.diffuser = rand.Intn(d.length) == 0
+	d.epochEnd = 0
+	if len(d.relays) > 0 {
+		d.epochEnd = now.Add(stemEpoch)
+	}
+	log.Debug("Started new stem epoch", "relays", len(d.relays), "diffuser", d.diffuser)
+}
+
+// route returns the relay for the transactions of a source. Sources keep their
+// relay during an epoch, and transactions are never sent back to their source.
+func (d *dandelion) route(from string) *eth.Peer {
+	if relay, ok := d.routes[from]; ok {
+		return relay
+	}
+	var candidates []*eth.Peer
+	for _, relay := range d.relays {
+		if relay.ID() != from {
+			candidates = append(candidates, relay)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	relay := candidates[rand.Intn(len(candidates))]
+	d.routes[from] = relay
+	return relay
+}
+
+// hold puts a transaction under embargo. It returns false if the transaction
+// is already embargoed.
+func (d *dandelion) hold(hash common.Hash) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if _, ok := d.embargoes[hash]; ok {
+		return false
+	}
+	// Randomize the embargo, so the first node to fluff the transaction is
+	// not necessarily the one closest to the origin.
+	timeout := d.embargo + time.Duration(rand.Int63n(int64(d.embargo)+1))
+	d.embargoes[hash] = d.clock.AfterFunc(timeout, func() { d.expire(hash) })
+	return true
+}
+
+// release lifts the embargo of a transaction.
+func (d *dandelion) release(hash common.Hash) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.releaseLocked(hash)
+}
+
+func (d *dandelion) releaseLocked(hash common.Hash) {
+	if timer, ok := d.embargoes[hash]; ok {
+		timer.Stop()
+		delete(d.embargoes, hash)
+	}
+}
+
+// expire fluffs a transaction whose embargo ran out.
+func (d *dandelion) expire(hash common.Hash) {
+	d.lock.Lock()
+	_, ok := d.embargoes[hash]
+	delete(d.embargoes, hash)
+	d.lock.Unlock()
+
+	if !ok {
+		return
+	}
+	if tx := d.txpool.Get(hash); tx != nil {
+		log.Debug("Stem transaction embargo expired", "hash", hash)
+		stemEmbargoMeter.Mark(1)
+		d.fluff(types.Transactions{tx})
+	}
+}
+
+// seen lifts the embargo of transactions received from the broadcast phase,
+// as they are public already.
+func (d *dandelion) seen(hashes []common.Hash) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for _, hash := range hashes {
+		d.releaseLocked(hash)
+	}
+}
+
+// embargoed returns whether a transaction is held back from broadcasts.
+func (d *dandelion) embargoed(hash common.Hash) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	_, ok := d.embargoes[hash]
+	return ok
+}
+
+// filter removes the embargoed transactions from a batch to broadcast.
+func (d *dandelion) filter(txs types.Transactions) types.Transactions {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	var public types.Transactions
+	for _, tx := range txs {
+		if _, ok := d.embargoes[tx.Hash()]; !ok {
+			public = append(public, tx)
+		}
+	}
+	return public
+}
+
+// stop cancels all embargo timers.
+func (d *dandelion) stop() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for hash, timer := range d.embargoes {
+		timer.Stop()
+		delete(d.embargoes, hash)
+	}
+}
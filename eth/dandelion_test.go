@@ -0,0 +1,305 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/mclock"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/eth/protocols/eth"
+	"github.com/rajchain/go-rajchain/p2p"
+	"github.com/rajchain/go-rajchain/p2p/enode"
+)
+
+// testDandelion is a stem propagator recording the fluffed transactions.
+type testDandelion struct {
+	*dandelion
+	clock  *mclock.Simulated
+	fluffs types.Transactions
+}
+
+func newTestDandelion(length int) *testDandelion {
+	td := &testDandelion{clock: new(mclock.Simulated)}
+	td.dandelion = newDandelion(length, time.Second, td.clock, newTestTxPool(), func(txs types.Transactions) {
+		td.fluffs = append(td.fluffs, txs...)
+	})
+	return td
+}
+
+// testStemPeer is a registered `eth` peer, reading the transactions relayed
+// along the stem from the remote side of its pipe.
+type testStemPeer struct {
+	*eth.Peer
+	net    *p2p.MsgPipeRW
+	relays chan []*types.Transaction
+}
+
+func newTestStemPeer(td *testDandelion, id enode.ID, version uint) *testStemPeer {
+	app, net := p2p.MsgPipe()
+	peer := &testStemPeer{
+		Peer:   eth.NewPeer(version, p2p.NewPeer(id, "", nil), app, td.txpool),
+		net:    net,
+		relays: make(chan []*types.Transaction, 16),
+	}
+	go func() {
+		for {
+			msg, err := net.ReadMsg()
+			if err != nil {
+				return
+			}
+			var txs eth.StemTransactionsPacket
+			if msg.Code == eth.StemTransactionsMsg && msg.Decode(&txs) == nil {
+				peer.relays <- txs
+			}
+			msg.Discard()
+		}
+	}()
+	td.register(peer.Peer)
+	return peer
+}
+
+func (p *testStemPeer) close() {
+	p.Peer.Close()
+	p.net.Close()
+}
+
+// relayed waits a bit for transactions relayed to the peer.
+func (p *testStemPeer) relayed() []*types.Transaction {
+	select {
+	case txs := <-p.relays:
+		return txs
+	case <-time.After(100 * time.Millisecond):
+		return nil
+	}
+}
+
+func newStemTx(nonce uint64) *types.Transaction {
+	tx := types.NewTransaction(nonce, common.Address{}, big.NewInt(0), 100000, big.NewInt(0), nil)
+	tx, _ = types.SignTx(tx, types.HomesteadSigner{}, testKey)
+	return tx
+}
+
+// Tests that local transactions are sent to a single stem relay and held back
+// from broadcasts until their embargo expires.
+func TestDandelionLocalStem(t *testing.T) {
+	td := newTestDandelion(1 << 30)
+	defer td.stop()
+
+	peers := []*testStemPeer{newTestStemPeer(td, enode.ID{1}, eth.ETH68Stem), newTestStemPeer(td, enode.ID{2}, eth.ETH68Stem), newTestStemPeer(td, enode.ID{3}, eth.ETH68Stem)}
+	for _, peer := range peers {
+		defer peer.close()
+	}
+	tx := newStemTx(0)
+	if err := td.submit(tx); err != nil {
+		t.Fatalf("failed to submit transaction: %v", err)
+	}
+	var relays int
+	for _, peer := range peers {
+		if txs := peer.relayed(); len(txs) > 0 {
+			if len(txs) != 1 || txs[0].Hash() != tx.Hash() {
+				t.Fatalf("relayed transactions mismatch: have %d, want 1", len(txs))
+			}
+			relays++
+		}
+	}
+	if relays != 1 {
+		t.Fatalf("stem relays mismatch: have %d, want 1", relays)
+	}
+	if len(td.fluffs) != 0 {
+		t.Fatalf("stem transaction fluffed before embargo")
+	}
+	if !td.embargoed(tx.Hash()) {
+		t.Fatalf("stem transaction not embargoed")
+	}
+	if public := td.filter(types.Transactions{tx}); len(public) != 0 {
+		t.Fatalf("embargoed transaction passed broadcast filter")
+	}
+	// Fluff the transaction once the embargo expires
+	td.clock.Run(2 * time.Second)
+	if len(td.fluffs) != 1 || td.fluffs[0].Hash() != tx.Hash() {
+		t.Fatalf("fluffed transactions mismatch: have %d, want 1", len(td.fluffs))
+	}
+	if td.embargoed(tx.Hash()) {
+		t.Fatalf("expired transaction still embargoed")
+	}
+}
+
+// Tests that diffusers fluff the transactions received on the stem, but still
+// relay their local transactions.
+func TestDandelionDiffuse(t *testing.T) {
+	td := newTestDandelion(1)
+	defer td.stop()
+
+	src, dst := newTestStemPeer(td, enode.ID{1}, eth.ETH68Stem), newTestStemPeer(td, enode.ID{2}, eth.ETH68Stem)
+	defer src.close()
+	defer dst.close()
+
+	tx := newStemTx(0)
+	td.relay(src.ID(), []*types.Transaction{tx})
+	if len(td.fluffs) != 1 || td.fluffs[0].Hash() != tx.Hash() {
+		t.Fatalf("fluffed transactions mismatch: have %d, want 1", len(td.fluffs))
+	}
+	if td.embargoed(tx.Hash()) {
+		t.Fatalf("fluffed transaction still embargoed")
+	}
+	if txs := dst.relayed(); len(txs) != 0 {
+		t.Fatalf("diffuser relayed %d transactions", len(txs))
+	}
+	// Relaying the same transaction again should be a noop
+	td.relay(src.ID(), []*types.Transaction{tx})
+	if len(td.fluffs) != 1 {
+		t.Fatalf("known transaction fluffed again")
+	}
+	// Local transactions are never fluffed directly
+	local := newStemTx(1)
+	if err := td.submit(local); err != nil {
+		t.Fatalf("failed to submit transaction: %v", err)
+	}
+	if len(td.fluffs) != 1 {
+		t.Fatalf("local transaction fluffed by diffuser")
+	}
+	if txs := append(src.relayed(), dst.relayed()...); len(txs) != 1 || txs[0].Hash() != local.Hash() {
+		t.Fatalf("relayed transactions mismatch: have %d, want 1", len(txs))
+	}
+}
+
+// Tests that transactions are fluffed directly if there is no stem relay, and
+// that they are never sent back to the peer they came from.
+func TestDandelionNoRelay(t *testing.T) {
+	td := newTestDandelion(1 << 30)
+	defer td.stop()
+
+	local := newStemTx(0)
+	if err := td.submit(local); err != nil {
+		t.Fatalf("failed to submit transaction: %v", err)
+	}
+	if len(td.fluffs) != 1 || td.fluffs[0].Hash() != local.Hash() {
+		t.Fatalf("fluffed transactions mismatch: have %d, want 1", len(td.fluffs))
+	}
+	src := newTestStemPeer(td, enode.ID{1}, eth.ETH68Stem)
+	defer src.close()
+
+	tx := newStemTx(1)
+	td.relay(src.ID(), []*types.Transaction{tx})
+	if txs := src.relayed(); len(txs) != 0 {
+		t.Fatalf("transactions relayed back to their source")
+	}
+	if len(td.fluffs) != 2 || td.fluffs[1].Hash() != tx.Hash() {
+		t.Fatalf("fluffed transactions mismatch: have %d, want 2", len(td.fluffs))
+	}
+}
+
+// Tests that transactions dropped by a disconnecting relay are still fluffed,
+// and that new relays are picked for subsequent transactions.
+func TestDandelionRelayDrop(t *testing.T) {
+	td := newTestDandelion(1 << 30)
+	defer td.stop()
+
+	first := newTestStemPeer(td, enode.ID{1}, eth.ETH68Stem)
+	tx1 := newStemTx(0)
+	if err := td.submit(tx1); err != nil {
+		t.Fatalf("failed to submit transaction: %v", err)
+	}
+	if txs := first.relayed(); len(txs) != 1 {
+		t.Fatalf("relayed transactions mismatch: have %d, want 1", len(txs))
+	}
+	td.unregister(first.ID())
+	first.close()
+
+	second := newTestStemPeer(td, enode.ID{2}, eth.ETH68Stem)
+	defer second.close()
+
+	tx2 := newStemTx(1)
+	if err := td.submit(tx2); err != nil {
+		t.Fatalf("failed to submit transaction: %v", err)
+	}
+	if txs := second.relayed(); len(txs) != 1 || txs[0].Hash() != tx2.Hash() {
+		t.Fatalf("relayed transactions mismatch: have %d, want 1", len(txs))
+	}
+	if len(td.fluffs) != 0 {
+		t.Fatalf("stem transactions fluffed before embargo")
+	}
+	td.clock.Run(2 * time.Second)
+	if len(td.fluffs) != 2 {
+		t.Fatalf("fluffed transactions mismatch: have %d, want 2", len(td.fluffs))
+	}
+}
+
+// Tests that transactions broadcast by other nodes are released from the
+// embargo without being fluffed again.
+func TestDandelionSeen(t *testing.T) {
+	td := newTestDandelion(1 << 30)
+	defer td.stop()
+
+	peer := newTestStemPeer(td, enode.ID{1}, eth.ETH68Stem)
+	defer peer.close()
+
+	tx := newStemTx(0)
+	if err := td.submit(tx); err != nil {
+		t.Fatalf("failed to submit transaction: %v", err)
+	}
+	td.seen([]common.Hash{tx.Hash()})
+	if td.embargoed(tx.Hash()) {
+		t.Fatalf("seen transaction still embargoed")
+	}
+	td.clock.Run(2 * time.Second)
+	if len(td.fluffs) != 0 {
+		t.Fatalf("seen transaction fluffed")
+	}
+}
+
+// Tests that peers without stem support are never picked as relays, and that
+// transactions are fluffed directly if no peer supports it.
+func TestDandelionFallback(t *testing.T) {
+	td := newTestDandelion(1 << 30)
+	defer td.stop()
+
+	legacy := newTestStemPeer(td, enode.ID{1}, eth.ETH68)
+	defer legacy.close()
+
+	tx1 := newStemTx(0)
+	if err := td.submit(tx1); err != nil {
+		t.Fatalf("failed to submit transaction: %v", err)
+	}
+	if txs := legacy.relayed(); len(txs) != 0 {
+		t.Fatalf("transactions relayed to peer without stem support")
+	}
+	if len(td.fluffs) != 1 || td.fluffs[0].Hash() != tx1.Hash() {
+		t.Fatalf("fluffed transactions mismatch: have %d, want 1", len(td.fluffs))
+	}
+	// Once a peer supporting it connects, it becomes the only relay
+	relay := newTestStemPeer(td, enode.ID{2}, eth.ETH68Stem)
+	defer relay.close()
+
+	tx2 := newStemTx(1)
+	if err := td.submit(tx2); err != nil {
+		t.Fatalf("failed to submit transaction: %v", err)
+	}
+	if txs := relay.relayed(); len(txs) != 1 || txs[0].Hash() != tx2.Hash() {
+		t.Fatalf("relayed transactions mismatch: have %d, want 1", len(txs))
+	}
+	if txs := legacy.relayed(); len(txs) != 0 {
+		t.Fatalf("transactions relayed to peer without stem support")
+	}
+	if len(td.fluffs) != 1 {
+		t.Fatalf("stem transaction fluffed before embargo")
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/eth/protocols/eth"
+	"github.com/rajchain/go-rajchain/log"
+)
+
+var (
+	errNoPeers           = errors.New("no peers to retrieve header from")
+	errHeaderUnavailable = errors.New("header unavailable from peers")
+)
+
+// RetrieveHeader retrieves a header trusted by its hash, e.g. the block of a
+// signed checkpoint, to run the beacon sync against without a consensus client.
+// The local chain is checked first, then the peers are asked in turn until one
+// delivers the header or the stop channel is closed.
+func (d *Downloader) RetrieveHeader(hash common.Hash, stop chan struct{}) (*types.Header, error) {
+	if header := d.blockchain.GetHeaderByHash(hash); header != nil {
+		return header, nil
+	}
+	peers := d.peers.AllPeers()
+	if len(peers) == 0 {
+		return nil, errNoPeers
+	}
+	for _, peer := range peers {
+		header, err := d.fetchHeader(peer, hash, stop)
+		switch {
+		case err == nil:
+			return header, nil
+		case errors.Is(err, errCanceled):
+			return nil, err
+		default:
+			peer.log.Debug("Failed to retrieve trusted header", "hash", hash, "err", err)
+		}
+	}
+	return nil, errHeaderUnavailable
+}
+
+// fetchHeader retrieves a single header by hash from a peer, checking that the
+// delivered header matches the requested hash.
+func (d *Downloader) fetchHeader(p *peerConnection, hash common.Hash, stop chan struct{}) (*types.Header, error) {
+	resCh := make(chan *eth.Response)
+
+	req, err := p.peer.RequestHeadersByHash(hash, 1, 0, false, resCh)
+	if err != nil {
+		return nil, err
+	}
+	defer req.Close()
+
+	timeout := d.peers.rates.TargetTimeout()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-stop:
+		return nil, errCanceled
+
+	case <-timer.C:
+		p.log.Debug("Header request timed out", "elapsed", timeout)
+		headerTimeoutMeter.Mark(1)
+		return nil, errTimeout
+
+	case res := <-resCh:
+		res.Done <- nil
+
+		headers := *res.Res.(*eth.BlockHeadersRequest)
+		if len(headers) != 1 {
+			return nil, fmt.Errorf("%w: %d headers delivered", errBadPeer, len(headers))
+		}
+		if have := res.Meta.([]common.Hash)[0]; have != hash {
+			log.Warn("Peer delivered mismatching header", "peer", p.id, "want", hash, "have", have)
+			return nil, fmt.Errorf("%w: mismatching header", errBadPeer)
+		}
+		return headers[0], nil
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/eth/protocols/eth"
+)
+
+// Tests that trusted headers are retrieved from the local chain or the peers.
+func TestRetrieveHeader(t *testing.T) {
+	tester := newTester(t)
+	defer tester.terminate()
+
+	var (
+		short = testChainBase.shorten(MaxHeaderFetch)
+		chain = testChainBase.shorten(800 / 2)
+	)
+	stop := make(chan struct{})
+
+	// Without peers, only the local headers are available
+	if _, err := tester.downloader.RetrieveHeader(chain.blocks[300].Hash(), stop); !errors.Is(err, errNoPeers) {
+		t.Fatalf("wrong error without peers: have %v, want %v", err, errNoPeers)
+	}
+	genesis := tester.chain.Genesis().Hash()
+	if header, err := tester.downloader.RetrieveHeader(genesis, stop); err != nil || header.Hash() != genesis {
+		t.Fatalf("failed to retrieve local header: %v", err)
+	}
+	// Headers known by a peer are retrieved from it
+	tester.newPeer("short", eth.ETH68, short.blocks[1:])
+	tester.newPeer("full", eth.ETH68, chain.blocks[1:])
+
+	want := chain.blocks[300].Header()
+	header, err := tester.downloader.RetrieveHeader(want.Hash(), stop)
+	if err != nil {
+		t.Fatalf("failed to retrieve header: %v", err)
+	}
+	if header.Hash() != want.Hash() {
+		t.Fatalf("wrong header retrieved: have %v, want %v", header.Hash(), want.Hash())
+	}
+	// Headers unknown to all peers are unavailable
+	if _, err := tester.downloader.RetrieveHeader(common.Hash{0x01}, stop); !errors.Is(err, errHeaderUnavailable) {
+		t.Fatalf("wrong error for unknown header: have %v, want %v", err, errHeaderUnavailable)
+	}
+}
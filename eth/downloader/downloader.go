@@ -139,6 +139,9 @@ type Downloader struct {
 	syncStartBlock uint64    // Head snap block when Geth was started
 	syncStartTime  time.Time // Time instance when chain sync started
 	syncLogTime    time.Time // Time instance when status was last reported
+
+	progressMeters map[string]*progressMeter // Throughput meters of the sync phases
+	progressLock   sync.Mutex                // Lock protecting the phase progress meters
 }
 
 // BlockChain encapsulates functions required to sync a (full or snap) blockchain.
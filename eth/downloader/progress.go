@@ -0,0 +1,203 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"time"
+
+	"github.com/rajchain/go-rajchain/core"
+)
+
+// Sync phases reported by PhaseProgress.
+const (
+	PhaseHeaders = "headers" // Skeleton header chain filled backwards from the head
+	PhaseBlocks  = "blocks"  // Block bodies and receipts, or full blocks in full sync
+	PhaseState   = "state"   // Account and storage ranges of the pivot state
+	PhaseHealing = "healing" // Trie nodes and bytecodes healed after the ranges
+	PhaseTxIndex = "txindex" // Transaction lookup indices of the chain
+)
+
+// Status of a sync phase.
+const (
+	PhasePending = "pending" // Phase did not make any progress yet
+	PhaseActive  = "active"  // Phase is in progress
+	PhaseDone    = "done"    // Phase completed
+)
+
+const (
+	// progressSampleInterval is the minimum time between two throughput samples
+	// of a phase, shorter intervals measure mostly noise.
+	progressSampleInterval = time.Second
+
+	// progressRateWeight is the weight of the latest sample in the exponential
+	// moving average of the phase throughput.
+	progressRateWeight = 0.2
+)
+
+// Phase is the progress of a single sync phase.
+type Phase struct {
+	Name    string  `json:"name"`
+	Status  string  `json:"status"`
+	Unit    string  `json:"unit"`    // Unit of the progress counters
+	Current uint64  `json:"current"` // Units processed
+	Total   uint64  `json:"total"`   // Units expected in total, zero if unknown
+	Rate    float64 `json:"rate"`    // Units processed per second
+	ETA     float64 `json:"eta"`     // Seconds until completion, zero if unknown
+}
+
+// SyncPhases is the detailed progress of the chain sync.
+type SyncPhases struct {
+	Mode   SyncMode `json:"mode"`
+	Phases []*Phase `json:"phases"`
+	ETA    float64  `json:"eta"` // Seconds until all known phases complete, zero if unknown
+}
+
+// progressMeter measures the throughput of a sync phase between the progress
+// queries.
+type progressMeter struct {
+	current uint64    // Progress at the last sample
+	time    time.Time // Time of the last sample
+	rate    float64   // Moving average of the units processed per second
+	primed  bool      // Whether the rate was measured at least once
+}
+
+// update samples the progress of the phase and returns the current throughput.
+func (m *progressMeter) update(current uint64, now time.Time) float64 {
+	// Restart measuring if this is the first sample or the phase restarted
+	if m.time.IsZero() || current < m.current {
+		*m = progressMeter{current: current, time: now}
+		return 0
+	}
+	elapsed := now.Sub(m.time)
+	if elapsed < progressSampleInterval {
+		return m.rate
+	}
+	rate := float64(current-m.current) / elapsed.Seconds()
+	if m.primed {
+		rate = progressRateWeight*rate + (1-progressRateWeight)*m.rate
+	}
+	m.current, m.time, m.rate, m.primed = current, now, rate, true
+	return m.rate
+}
+
+// newPhase creates the progress report of a phase, measuring its throughput
+// with the given meter.
+func newPhase(name string, unit string, current uint64, total uint64, meter *progressMeter, now time.Time) *Phase {
+	phase := &Phase{
+		Name:    name,
+		Status:  PhaseActive,
+		Unit:    unit,
+		Current: current,
+		Total:   total,
+		Rate:    meter.update(current, now),
+	}
+	switch {
+	case total > 0 && current >= total:
+		phase.Status = PhaseDone
+	case current == 0:
+		phase.Status = PhasePending
+	case total > 0 && phase.Rate > 0:
+		phase.ETA = float64(total-current) / phase.Rate
+	}
+	return phase
+}
+
+// PhaseProgress returns the progress of the individual sync phases, along with
+// their throughput and estimated time to completion. The throughput is measured
+// between calls, so it only becomes available from the second query on.
+//
+// The transaction indexing progress is tracked by the chain and needs to be
+// passed in by the caller, nil if the indexer is disabled.
+func (d *Downloader) PhaseProgress(txIndex *core.TxIndexProgress) *SyncPhases {
+	d.progressLock.Lock()
+	defer d.progressLock.Unlock()
+
+	if d.progressMeters == nil {
+		d.progressMeters = make(map[string]*progressMeter)
+	}
+	meter := func(name string) *progressMeter {
+		if d.progressMeters[name] == nil {
+			d.progressMeters[name] = new(progressMeter)
+		}
+		return d.progressMeters[name]
+	}
+	var (
+		now    = time.Now()
+		mode   = d.getMode()
+		report = &SyncPhases{Mode: mode}
+		local  = d.blockchain.CurrentHeader().Number.Uint64()
+		block  = d.blockchain.CurrentBlock().Number.Uint64()
+	)
+	if mode == SnapSync {
+		block = d.blockchain.CurrentSnapBlock().Number.Uint64()
+	}
+	// The skeleton is filled from the announced head backwards, until it links
+	// up with the local header chain
+	var target uint64
+	if head, tail, _, err := d.skeleton.Bounds(); err == nil {
+		target = head.Number.Uint64()
+		headers := target - tail.Number.Uint64() + 1
+
+		var missing uint64
+		if tail.Number.Uint64() > local+1 {
+			missing = tail.Number.Uint64() - local - 1
+		}
+		report.Phases = append(report.Phases, newPhase(PhaseHeaders, "headers", headers, headers+missing, meter(PhaseHeaders), now))
+	} else {
+		report.Phases = append(report.Phases, newPhase(PhaseHeaders, "headers", 0, 0, meter(PhaseHeaders), now))
+	}
+	report.Phases = append(report.Phases, newPhase(PhaseBlocks, "blocks", block, target, meter(PhaseBlocks), now))
+
+	// The state ranges are measured in bytes, with the total extrapolated from
+	// the covered share of the account hash space. Healing has no known total,
+	// so the pending tasks are used as a lower bound.
+	if mode == SnapSync {
+		progress, pending := d.SnapSyncer.Progress()
+
+		synced := uint64(progress.AccountBytes + progress.BytecodeBytes + progress.StorageBytes)
+		var total uint64
+		if pending.AccountCoverage > 0 {
+			total = uint64(float64(synced) / pending.AccountCoverage)
+		}
+		state := newPhase(PhaseState, "bytes", synced, total, meter(PhaseState), now)
+		if pending.AccountCoverage >= 1 {
+			state.Status, state.ETA = PhaseDone, 0
+		}
+		report.Phases = append(report.Phases, state)
+
+		healed := progress.TrienodeHealSynced + progress.BytecodeHealSynced
+		healing := newPhase(PhaseHealing, "nodes", healed, healed+pending.TrienodeHeal+pending.BytecodeHeal, meter(PhaseHealing), now)
+		if state.Status != PhaseDone {
+			healing.Status, healing.ETA = PhasePending, 0
+		}
+		report.Phases = append(report.Phases, healing)
+	}
+	if txIndex != nil {
+		indexing := newPhase(PhaseTxIndex, "blocks", txIndex.Indexed, txIndex.Indexed+txIndex.Remaining, meter(PhaseTxIndex), now)
+		if txIndex.Done() {
+			indexing.Status = PhaseDone
+		}
+		report.Phases = append(report.Phases, indexing)
+	}
+	// The phases run concurrently, so the sync completes with the slowest one
+	for _, phase := range report.Phases {
+		if phase.ETA > report.ETA {
+			report.ETA = phase.ETA
+		}
+	}
+	return report
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rajchain/go-rajchain/core"
+	"github.com/rajchain/go-rajchain/eth/protocols/eth"
+)
+
+// Tests that the phase throughput is averaged across samples and restarted if
+// the phase goes backwards.
+func TestProgressMeter(t *testing.T) {
+	var (
+		meter progressMeter
+		start = time.Now()
+	)
+	if rate := meter.update(100, start); rate != 0 {
+		t.Fatalf("rate measured on first sample: %v", rate)
+	}
+	// Samples too close to each other are ignored
+	if rate := meter.update(200, start.Add(time.Millisecond)); rate != 0 {
+		t.Fatalf("rate measured on short interval: %v", rate)
+	}
+	if rate := meter.update(300, start.Add(2*time.Second)); rate != 100 {
+		t.Fatalf("wrong first rate: have %v, want %v", rate, 100)
+	}
+	// Later samples are averaged with the previous rate
+	want := progressRateWeight*200 + (1-progressRateWeight)*100
+	if rate := meter.update(500, start.Add(3*time.Second)); rate != want {
+		t.Fatalf("wrong averaged rate: have %v, want %v", rate, want)
+	}
+	// Going backwards restarts the measurement
+	if rate := meter.update(10, start.Add(4*time.Second)); rate != 0 {
+		t.Fatalf("rate measured after restart: %v", rate)
+	}
+}
+
+// Tests the status and completion estimate of the phases.
+func TestPhaseStatus(t *testing.T) {
+	start := time.Now()
+
+	tests := []struct {
+		current, total uint64
+		status         string
+		eta            float64
+	}{
+		{0, 0, PhasePending, 0},
+		{0, 1000, PhasePending, 0},
+		{500, 0, PhaseActive, 0},
+		{500, 1000, PhaseActive, 5},
+		{1000, 1000, PhaseDone, 0},
+		{1200, 1000, PhaseDone, 0},
+	}
+	for i, tt := range tests {
+		meter := &progressMeter{current: tt.current - min(tt.current, 100), time: start.Add(-time.Second)}
+		phase := newPhase("test", "blocks", tt.current, tt.total, meter, start)
+		if phase.Status != tt.status {
+			t.Errorf("test %d: wrong status: have %s, want %s", i, phase.Status, tt.status)
+		}
+		if phase.ETA != tt.eta {
+			t.Errorf("test %d: wrong eta: have %v, want %v", i, phase.ETA, tt.eta)
+		}
+	}
+}
+
+// Tests that the phase progress reports the skeleton and block download along
+// with the transaction indexing.
+func TestPhaseProgress(t *testing.T) {
+	success := make(chan struct{})
+	tester := newTesterWithNotification(t, func() {
+		close(success)
+	})
+	defer tester.terminate()
+
+	chain := testChainBase.shorten(blockCacheMaxItems - 15)
+	tester.newPeer("peer", eth.ETH68, chain.blocks[1:])
+
+	// Without a beacon sync, only the local chain is known
+	progress := tester.downloader.PhaseProgress(&core.TxIndexProgress{Indexed: 10, Remaining: 30})
+	if len(progress.Phases) != 3 {
+		t.Fatalf("wrong number of phases: have %d, want %d", len(progress.Phases), 3)
+	}
+	if phase := progress.Phases[0]; phase.Name != PhaseHeaders || phase.Status != PhasePending {
+		t.Fatalf("wrong header phase before sync: %+v", phase)
+	}
+	if phase := progress.Phases[2]; phase.Name != PhaseTxIndex || phase.Status != PhaseActive || phase.Current != 10 || phase.Total != 40 {
+		t.Fatalf("wrong tx indexing phase: %+v", phase)
+	}
+	// Once synced, the header and block phases are done
+	if err := tester.downloader.BeaconSync(FullSync, chain.blocks[len(chain.blocks)-1].Header(), nil); err != nil {
+		t.Fatalf("failed to start beacon sync: %v", err)
+	}
+	select {
+	case <-success:
+	case <-time.NewTimer(3 * time.Second).C:
+		t.Fatalf("Failed to sync chain in three seconds")
+	}
+	progress = tester.downloader.PhaseProgress(nil)
+	if len(progress.Phases) != 2 {
+		t.Fatalf("wrong number of phases: have %d, want %d", len(progress.Phases), 2)
+	}
+	for _, phase := range progress.Phases {
+		if phase.Status != PhaseDone {
+			t.Errorf("phase %s not done after sync: %+v", phase.Name, phase)
+		}
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/rajchain/go-rajchain/core/txpool/legacypool"
 	"github.com/rajchain/go-rajchain/eth/downloader"
 	"github.com/rajchain/go-rajchain/eth/gasprice"
+	"github.com/rajchain/go-rajchain/eth/protocols/snap"
 	"github.com/rajchain/go-rajchain/ethdb"
 	"github.com/rajchain/go-rajchain/log"
 	"github.com/rajchain/go-rajchain/miner"
@@ -54,6 +55,7 @@ var Defaults = Config{
 	TxLookupLimit:      2350000,
 	TransactionHistory: 2350000,
 	StateHistory:       params.FullImmutabilityThreshold,
+	SnapHistoryDepth:   snap.DefaultHistoricDepth,
 	DatabaseCache:      512,
 	TrieCleanCache:     154,
 	TrieDirtyCache:     256,
@@ -67,6 +69,7 @@ var Defaults = Config{
 	RPCEVMTimeout:      5 * time.Second,
 	GPO:                FullNodeGPO,
 	RPCTxFeeCap:        1, // 1 ether
+	TxStemEmbargo:      30 * time.Second,
 }
 
 //go:generate go run github.com/fjl/gencodec -type Config -formats toml -out gen_config.go
@@ -96,6 +99,7 @@ type Config struct {
 
 	TransactionHistory uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
 	StateHistory       uint64 `toml:",omitempty"` // The maximum number of blocks from head whose state histories are reserved.
+	SnapHistoryDepth   uint64 `toml:",omitempty"` // The maximum number of state histories replayed to serve snap ranges at older roots.
 
 	// State scheme represents the scheme used to store rajchain states and trie
 	// nodes on top. It can be 'hash', 'path', or none which means use the scheme
@@ -107,6 +111,17 @@ type Config struct {
 	// presence of these blocks for every new peer connection.
 	RequiredBlocks map[uint64]common.Hash `toml:"-"`
 
+	// TxStemLength enables stem propagation of local transactions, hiding the
+	// node they originate from. It is the expected number of hops along the stem
+	// before the transactions are broadcast, zero disables stem propagation.
+	TxStemLength  int           `toml:",omitempty"`
+	TxStemEmbargo time.Duration `toml:",omitempty"` // Minimum time before stem transactions are broadcast by the local node
+
+	// NodeRegistry is the address of the on-chain registry listing the nodes
+	// allowed to connect to a permissioned p2p server. The registry is read on
+	// every new head.
+	NodeRegistry *common.Address `toml:",omitempty"`
+
 	// Database options
 	SkipBcVersionCheck bool `toml:"-"`
 	DatabaseHandles    int  `toml:"-"`
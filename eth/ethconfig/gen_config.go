@@ -27,8 +27,12 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		TxLookupLimit           uint64                 `toml:",omitempty"`
 		TransactionHistory      uint64                 `toml:",omitempty"`
 		StateHistory            uint64                 `toml:",omitempty"`
+		SnapHistoryDepth        uint64                 `toml:",omitempty"`
 		StateScheme             string                 `toml:",omitempty"`
 		RequiredBlocks          map[uint64]common.Hash `toml:"-"`
+		TxStemLength            int                    `toml:",omitempty"`
+		TxStemEmbargo           time.Duration          `toml:",omitempty"`
+		NodeRegistry            *common.Address        `toml:",omitempty"`
 		SkipBcVersionCheck      bool                   `toml:"-"`
 		DatabaseHandles         int                    `toml:"-"`
 		DatabaseCache           int
@@ -63,8 +67,12 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.TxLookupLimit = c.TxLookupLimit
 	enc.TransactionHistory = c.TransactionHistory
 	enc.StateHistory = c.StateHistory
+	enc.SnapHistoryDepth = c.SnapHistoryDepth
 	enc.StateScheme = c.StateScheme
 	enc.RequiredBlocks = c.RequiredBlocks
+	enc.TxStemLength = c.TxStemLength
+	enc.TxStemEmbargo = c.TxStemEmbargo
+	enc.NodeRegistry = c.NodeRegistry
 	enc.SkipBcVersionCheck = c.SkipBcVersionCheck
 	enc.DatabaseHandles = c.DatabaseHandles
 	enc.DatabaseCache = c.DatabaseCache
@@ -103,8 +111,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		TxLookupLimit           *uint64                `toml:",omitempty"`
 		TransactionHistory      *uint64                `toml:",omitempty"`
 		StateHistory            *uint64                `toml:",omitempty"`
+		SnapHistoryDepth        *uint64                `toml:",omitempty"`
 		StateScheme             *string                `toml:",omitempty"`
 		RequiredBlocks          map[uint64]common.Hash `toml:"-"`
+		TxStemLength            *int                   `toml:",omitempty"`
+		TxStemEmbargo           *time.Duration         `toml:",omitempty"`
+		NodeRegistry            *common.Address        `toml:",omitempty"`
 		SkipBcVersionCheck      *bool                  `toml:"-"`
 		DatabaseHandles         *int                   `toml:"-"`
 		DatabaseCache           *int
@@ -162,12 +174,24 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.StateHistory != nil {
 		c.StateHistory = *dec.StateHistory
 	}
+	if dec.SnapHistoryDepth != nil {
+		c.SnapHistoryDepth = *dec.SnapHistoryDepth
+	}
 	if dec.StateScheme != nil {
 		c.StateScheme = *dec.StateScheme
 	}
 	if dec.RequiredBlocks != nil {
 		c.RequiredBlocks = dec.RequiredBlocks
 	}
+	if dec.TxStemLength != nil {
+		c.TxStemLength = *dec.TxStemLength
+	}
+	if dec.TxStemEmbargo != nil {
+		c.TxStemEmbargo = *dec.TxStemEmbargo
+	}
+	if dec.NodeRegistry != nil {
+		c.NodeRegistry = dec.NodeRegistry
+	}
 	if dec.SkipBcVersionCheck != nil {
 		c.SkipBcVersionCheck = *dec.SkipBcVersionCheck
 	}
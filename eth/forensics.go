@@ -0,0 +1,172 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/rajchain/go-rajchain/core"
+	"github.com/rajchain/go-rajchain/core/rawdb"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/eth/tracers/logger"
+	"github.com/rajchain/go-rajchain/ethdb"
+	"github.com/rajchain/go-rajchain/log"
+)
+
+const (
+	// badBlockQueueSize is the number of bad blocks waiting to be traced, past
+	// which further ones are reported without a trace.
+	badBlockQueueSize = 4
+
+	// badBlockTraceLimit is the maximum size of the execution trace kept in a
+	// bad block report, past which the trace is truncated.
+	badBlockTraceLimit = 32 * 1024 * 1024
+)
+
+// badBlock is a block failing validation, queued for tracing.
+type badBlock struct {
+	block *types.Block
+	stage string
+	err   error
+}
+
+// badBlockTracer re-executes the blocks failing validation in the background,
+// storing a forensics report with the execution trace of each. It is installed
+// as the bad block hook of the chain, keeping the import path unblocked.
+//
+// Every bad block gets a report with its failure, whatever the stage it failed
+// at. Only the trace is skipped if the parent state is unavailable, or if the
+// tracer is too busy to re-execute the block.
+type badBlockTracer struct {
+	chain *core.BlockChain
+	db    ethdb.KeyValueStore
+
+	queue chan *badBlock
+	quit  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// newBadBlockTracer creates a bad block tracer and starts its worker.
+func newBadBlockTracer(chain *core.BlockChain, db ethdb.KeyValueStore) *badBlockTracer {
+	t := &badBlockTracer{
+		chain: chain,
+		db:    db,
+		queue: make(chan *badBlock, badBlockQueueSize),
+		quit:  make(chan struct{}),
+	}
+	t.wg.Add(1)
+	go t.loop()
+	return t
+}
+
+// report queues a bad block for tracing, storing its report right away without
+// a trace if the worker is busy. It implements core.BadBlockHook.
+//
+// The execution result is not needed: even blocks failing before or during their
+// execution are re-executed on top of their parent state for the trace.
+func (t *badBlockTracer) report(block *types.Block, stage string, res *core.ProcessResult, err error) {
+	select {
+	case t.queue <- &badBlock{block: block, stage: stage, err: err}:
+	default:
+		log.Warn("Skipping bad block trace, tracer busy", "number", block.Number(), "hash", block.Hash())
+		t.store(&core.BadBlockReport{
+			Config: t.chain.Config(),
+			Block:  block,
+			Error:  err.Error(),
+			Stage:  stage,
+			Time:   uint64(time.Now().Unix()),
+		})
+	}
+}
+
+// loop traces the queued bad blocks one by one until the tracer is closed.
+func (t *badBlockTracer) loop() {
+	defer t.wg.Done()
+
+	for {
+		select {
+		case bad := <-t.queue:
+			t.trace(bad)
+		case <-t.quit:
+			return
+		}
+	}
+}
+
+// trace re-executes a bad block on top of its parent state and stores the report
+// of the failure with the execution trace. The report is stored without a trace
+// if the parent state is unavailable.
+func (t *badBlockTracer) trace(bad *badBlock) {
+	block := bad.block
+	if rawdb.ReadBadBlockReportNumber(t.db, block.Hash()) != nil {
+		return // Block reported multiple times, e.g. by the consensus client
+	}
+	trace := &traceBuffer{limit: badBlockTraceLimit}
+	report, err := t.chain.NewBadBlockReport(block, bad.stage, bad.err, logger.NewJSONLoggerWithCallFrames(nil, trace))
+	if err != nil {
+		log.Warn("Failed to trace bad block", "number", block.Number(), "hash", block.Hash(), "err", err)
+	}
+	report.Trace, report.TraceTruncated = trace.entries(), trace.truncated
+	t.store(report)
+}
+
+// store persists the report of a bad block, unless the block was reported before.
+func (t *badBlockTracer) store(report *core.BadBlockReport) {
+	block := report.Block
+	if rawdb.ReadBadBlockReportNumber(t.db, block.Hash()) != nil {
+		return
+	}
+	if err := core.WriteBadBlockReport(t.db, report); err != nil {
+		log.Error("Failed to store bad block report", "number", block.Number(), "hash", block.Hash(), "err", err)
+	}
+}
+
+// close stops the worker, waiting for the trace in progress to finish.
+func (t *badBlockTracer) close() {
+	close(t.quit)
+	t.wg.Wait()
+}
+
+// traceBuffer collects a JSON lines trace up to a size limit.
+type traceBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write appends a trace line, or drops it if the buffer is full.
+func (b *traceBuffer) Write(p []byte) (int, error) {
+	if b.truncated || b.buf.Len()+len(p) > b.limit {
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// entries splits the trace into its JSON entries.
+func (b *traceBuffer) entries() []json.RawMessage {
+	var entries []json.RawMessage
+	for _, line := range bytes.Split(b.buf.Bytes(), []byte{'\n'}) {
+		if len(line) > 0 {
+			entries = append(entries, json.RawMessage(line))
+		}
+	}
+	return entries
+}
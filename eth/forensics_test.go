@@ -0,0 +1,158 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/consensus/beacon"
+	"github.com/rajchain/go-rajchain/core"
+	"github.com/rajchain/go-rajchain/core/rawdb"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/core/vm"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/params"
+)
+
+// Tests that every block failing validation gets a report with its failure stage,
+// traced in the background if its parent state is available.
+func TestBadBlockTracer(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		config  = *params.MergedTestChainConfig
+		signer  = types.LatestSigner(&config)
+		engine  = beacon.NewFaker()
+		gspec   = &core.Genesis{
+			Config:  &config,
+			Alloc:   types.GenesisAlloc{address: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+	)
+	generate := func(invalid bool) []*types.Block {
+		_, blocks, _ := core.GenerateChainWithGenesis(gspec, engine, 2, func(i int, gen *core.BlockGen) {
+			tx, err := types.SignTx(types.NewTransaction(gen.TxNonce(address), common.Address{0x01}, big.NewInt(1000), params.TxGas, gen.BaseFee(), nil), signer, key)
+			if err != nil {
+				panic(err)
+			}
+			gen.AddTx(tx)
+			if invalid && i == 1 {
+				// Include a transaction with a nonce gap, failing the execution
+				tx, err := types.SignTx(types.NewTransaction(100, common.Address{0x01}, big.NewInt(1000), params.TxGas, gen.BaseFee(), nil), signer, key)
+				if err != nil {
+					panic(err)
+				}
+				gen.AddUncheckedTx(tx)
+			}
+		})
+		return blocks
+	}
+	var (
+		blocks  = generate(false)
+		invalid = generate(true)
+	)
+	db := rawdb.NewMemoryDatabase()
+	chain, err := core.NewBlockChain(db, core.DefaultCacheConfigWithScheme(rawdb.PathScheme), gspec, nil, engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	tracer := newBadBlockTracer(chain, db)
+	chain.SetBadBlockHook(tracer.report)
+
+	if _, err := chain.InsertChain(blocks[:1]); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	// Report a block failing before execution, without any parent state
+	orphan := blocks[1].WithSeal(&types.Header{Number: blocks[1].Number()})
+	tracer.report(orphan, core.BadBlockStageBody, nil, errors.New("invalid block"))
+
+	// Import a block failing its execution
+	if _, err := chain.InsertChain(types.Blocks{invalid[1]}); err == nil {
+		t.Fatalf("block with invalid transaction imported")
+	}
+	// Tamper with the state root of the last block and import it
+	header := blocks[1].Header()
+	header.Root = common.Hash{0xde, 0xad}
+	bad := blocks[1].WithSeal(header)
+	if _, err := chain.InsertChain(types.Blocks{bad}); err == nil {
+		t.Fatalf("bad block imported")
+	}
+	wait := func(block *types.Block) *core.BadBlockReport {
+		t.Helper()
+
+		var report *core.BadBlockReport
+		for deadline := time.Now().Add(5 * time.Second); report == nil && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if report, err = core.ReadBadBlockReport(db, block.Hash()); err != nil {
+				t.Fatalf("failed to read report: %v", err)
+			}
+		}
+		if report == nil {
+			t.Fatalf("block %x not reported", block.Hash())
+		}
+		return report
+	}
+	var (
+		orphanReport    = wait(orphan)
+		executionReport = wait(invalid[1])
+		stateReport     = wait(bad)
+	)
+	tracer.close()
+
+	// The block without parent state is reported untraced
+	if orphanReport.Stage != core.BadBlockStageBody || orphanReport.Error != "invalid block" {
+		t.Fatalf("unexpected orphan report: stage %q, error %q", orphanReport.Stage, orphanReport.Error)
+	}
+	if orphanReport.Witness != nil || len(orphanReport.Trace) != 0 {
+		t.Fatalf("orphan block traced")
+	}
+	// The blocks failing during and after execution are traced
+	for _, tt := range []struct {
+		report *core.BadBlockReport
+		stage  string
+	}{
+		{executionReport, core.BadBlockStageExecution},
+		{stateReport, core.BadBlockStageState},
+	} {
+		if tt.report.Stage != tt.stage {
+			t.Fatalf("stage mismatch: have %q, want %q", tt.report.Stage, tt.stage)
+		}
+		if tt.report.Witness == nil {
+			t.Fatalf("%s report without witness", tt.stage)
+		}
+		if len(tt.report.Trace) == 0 || tt.report.TraceTruncated {
+			t.Fatalf("unexpected %s trace: %d entries, truncated %v", tt.stage, len(tt.report.Trace), tt.report.TraceTruncated)
+		}
+	}
+	summaries, err := core.ReadBadBlockSummaries(db)
+	if err != nil || len(summaries) != 3 {
+		t.Fatalf("unexpected summaries: %d, %v", len(summaries), err)
+	}
+	for _, summary := range summaries {
+		if summary.Hash == bad.Hash() && summary.TraceSteps != uint64(len(stateReport.Trace)) {
+			t.Fatalf("trace steps mismatch: have %d, want %d", summary.TraceSteps, len(stateReport.Trace))
+		}
+		if summary.Stage == "" {
+			t.Fatalf("summary of %x without stage", summary.Hash)
+		}
+	}
+}
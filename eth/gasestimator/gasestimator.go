@@ -43,6 +43,8 @@ type Options struct {
 	Header *types.Header       // Header defining the block context to execute in
 	State  *state.StateDB      // Pre-state on top of which to estimate the gas
 
+	BlobBaseFee *big.Int // Blob base fee overriding the one derived from the header, if set
+
 	ErrorRatio float64 // Allowed overestimation ratio for faster estimation termination
 }
 
@@ -222,6 +224,9 @@ func run(ctx context.Context, call *core.Message, opts *Options) (*core.Executio
 
 		dirtyState = opts.State.Copy()
 	)
+	if opts.BlobBaseFee != nil {
+		evmContext.BlobBaseFee = opts.BlobBaseFee
+	}
 	// Lower the basefee to 0 to avoid breaking EVM
 	// invariants (basefee < feecap).
 	if msgContext.GasPrice.Sign() == 0 {
@@ -0,0 +1,256 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"slices"
+
+	"github.com/rajchain/go-rajchain/consensus/misc/eip4844"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/params"
+	"github.com/rajchain/go-rajchain/rpc"
+)
+
+const (
+	estimateBlocks       = 20 // Number of recent blocks sampled for the fee estimates
+	blobProjectionBlocks = 6  // Number of future blocks to project the blob base fee for
+)
+
+var errMissingHead = errors.New("missing head header")
+
+// estimatePercentiles are the reward percentiles sampled from each block. The
+// first one is the cheapest tip included, against which the confidence of the
+// estimates is measured, the rest are the low, medium and high levels.
+var estimatePercentiles = []float64{0, 10, 50, 90}
+
+// feeCapMultiplier is the multiple of the next base fee the suggested fee caps
+// allow for, letting the base fee double before the transaction is priced out.
+var feeCapMultiplier = big.NewInt(2)
+
+// blockCapacities are the multiples of the block gas limit worth of pending
+// transactions outbidding the low, medium and high estimates respectively.
+var blockCapacities = []uint64{4, 2, 1}
+
+// FeeEstimate is a priority fee suggestion along with the chance of it being
+// sufficient for inclusion.
+type FeeEstimate struct {
+	TipCap     *big.Int // Suggested priority fee per gas
+	FeeCap     *big.Int // Suggested fee cap, leaving room for base fee increases
+	Confidence float64  // Fraction of recent blocks the tip would have been included in
+}
+
+// FeeEstimates is the set of fee suggestions made on top of a given head.
+type FeeEstimates struct {
+	Number  uint64   // Number of the head block the estimates are made on
+	BaseFee *big.Int // Base fee of the next block
+
+	Low    FeeEstimate
+	Medium FeeEstimate
+	High   FeeEstimate
+
+	PendingGas uint64  // Gas of the pending transactions able to pay the next base fee
+	Pressure   float64 // Pending gas relative to the gas limit of the next block
+
+	BlobBaseFee           *big.Int   // Blob base fee of the next block, nil before Cancun
+	BlobBaseFeeProjection []*big.Int // Blob base fees of the blocks after, at the recent blob usage
+}
+
+// EstimateFees returns low, medium and high priority fee suggestions. They are
+// taken from the tips recently included in blocks and raised to outbid the
+// pending transactions filling the next blocks. The blob base fee is projected
+// for the upcoming blocks assuming the blob usage stays at its recent average.
+func (oracle *Oracle) EstimateFees(ctx context.Context) (*FeeEstimates, error) {
+	head, _ := oracle.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if head == nil {
+		return nil, errMissingHead
+	}
+	blocks := min(uint64(estimateBlocks), oracle.maxBlockHistory, head.Number.Uint64()+1)
+	_, rewards, baseFees, gasUsed, _, blobGasUsed, err := oracle.FeeHistory(ctx, blocks, rpc.BlockNumber(head.Number.Uint64()), estimatePercentiles)
+	if err != nil {
+		return nil, err
+	}
+	estimates := &FeeEstimates{
+		Number:  head.Number.Uint64(),
+		BaseFee: baseFees[len(baseFees)-1],
+	}
+	// Gather the tips of the blocks which had transactions to measure
+	var included [][]*big.Int
+	for i, reward := range rewards {
+		if gasUsed[i] > 0 {
+			included = append(included, reward)
+		}
+	}
+	var fallback *big.Int
+	if len(included) == 0 {
+		if fallback, err = oracle.SuggestTipCap(ctx); err != nil {
+			return nil, err
+		}
+	}
+	tips := make([]*big.Int, len(blockCapacities))
+	for i := range tips {
+		if len(included) == 0 {
+			tips[i] = fallback
+			continue
+		}
+		samples := make([]*big.Int, len(included))
+		for j, reward := range included {
+			samples[j] = reward[i+1]
+		}
+		slices.SortFunc(samples, func(a, b *big.Int) int { return a.Cmp(b) })
+		tips[i] = samples[len(samples)/2]
+	}
+	// Raise the tips to outbid the transactions waiting in the pool
+	pending, err := oracle.pendingFees(head, estimates.BaseFee)
+	if err != nil {
+		return nil, err
+	}
+	estimates.PendingGas = pending.gas
+	if head.GasLimit > 0 {
+		estimates.Pressure = float64(estimates.PendingGas) / float64(head.GasLimit)
+	}
+	for i := range blockCapacities {
+		if outbid := pending.tips[i]; outbid != nil && outbid.Cmp(tips[i]) > 0 {
+			tips[i] = outbid
+		}
+		if i > 0 && tips[i].Cmp(tips[i-1]) < 0 {
+			tips[i] = tips[i-1]
+		}
+		if tips[i].Cmp(oracle.maxPrice) > 0 {
+			tips[i] = new(big.Int).Set(oracle.maxPrice)
+		}
+	}
+	levels := []*FeeEstimate{&estimates.Low, &estimates.Medium, &estimates.High}
+	for i, level := range levels {
+		level.TipCap = new(big.Int).Set(tips[i])
+		level.FeeCap = new(big.Int).Add(tips[i], new(big.Int).Mul(estimates.BaseFee, feeCapMultiplier))
+		level.Confidence = 1
+		if len(included) > 0 {
+			var hits int
+			for _, reward := range included {
+				if tips[i].Cmp(reward[0]) >= 0 {
+					hits++
+				}
+			}
+			level.Confidence = float64(hits) / float64(len(included))
+		}
+	}
+	// Project the blob base fee if blobs are already live
+	if head.ExcessBlobGas != nil && head.BlobGasUsed != nil {
+		excess := eip4844.CalcExcessBlobGas(*head.ExcessBlobGas, *head.BlobGasUsed)
+		estimates.BlobBaseFee = eip4844.CalcBlobFee(excess)
+
+		var ratio float64
+		for _, used := range blobGasUsed {
+			ratio += used
+		}
+		if len(blobGasUsed) > 0 {
+			ratio /= float64(len(blobGasUsed))
+		}
+		used := uint64(ratio*float64(params.MaxBlobGasPerBlock)) / params.BlobTxBlobGasPerBlob * params.BlobTxBlobGasPerBlob
+		for i := 0; i < blobProjectionBlocks; i++ {
+			excess = eip4844.CalcExcessBlobGas(excess, used)
+			estimates.BlobBaseFeeProjection = append(estimates.BlobBaseFeeProjection, eip4844.CalcBlobFee(excess))
+		}
+	}
+	return estimates, nil
+}
+
+// pendingTip is the effective tip and gas of a pending transaction.
+type pendingTip struct {
+	tip *big.Int
+	gas uint64
+}
+
+// pendingFees summarizes the pending pool transactions able to pay the base
+// fee of the block after a head.
+type pendingFees struct {
+	gas  uint64     // Total gas of the transactions
+	tips []*big.Int // Tips outbidding each of the blockCapacities worth of gas, nil if not pending
+}
+
+// pendingFees returns the summary of the pending transactions on top of the
+// given head, paying the given base fee. The summary is computed once per head
+// block, so changes to the pool are only picked up with the next block.
+func (oracle *Oracle) pendingFees(head *types.Header, baseFee *big.Int) (*pendingFees, error) {
+	headHash := head.Hash()
+
+	// If the pending summary of the head is still available, return it.
+	oracle.cacheLock.RLock()
+	lastHead, lastPending := oracle.lastPendingHead, oracle.lastPending
+	oracle.cacheLock.RUnlock()
+	if headHash == lastHead && lastPending != nil {
+		return lastPending, nil
+	}
+	oracle.fetchLock.Lock()
+	defer oracle.fetchLock.Unlock()
+
+	// Try checking the cache again, maybe the last fetch fetched what we need
+	oracle.cacheLock.RLock()
+	lastHead, lastPending = oracle.lastPendingHead, oracle.lastPending
+	oracle.cacheLock.RUnlock()
+	if headHash == lastHead && lastPending != nil {
+		return lastPending, nil
+	}
+	txs, err := oracle.pendingTips(baseFee)
+	if err != nil {
+		return nil, err
+	}
+	pending := &pendingFees{tips: make([]*big.Int, len(blockCapacities))}
+	for _, tx := range txs {
+		pending.gas += tx.gas
+	}
+	for i, capacity := range blockCapacities {
+		var cumulative uint64
+		for _, tx := range txs {
+			if cumulative += tx.gas; cumulative > capacity*head.GasLimit {
+				pending.tips[i] = tx.tip
+				break
+			}
+		}
+	}
+	oracle.cacheLock.Lock()
+	oracle.lastPendingHead = headHash
+	oracle.lastPending = pending
+	oracle.cacheLock.Unlock()
+
+	return pending, nil
+}
+
+// pendingTips returns the effective tips of the pending pool transactions able
+// to pay the given base fee, highest first.
+func (oracle *Oracle) pendingTips(baseFee *big.Int) ([]pendingTip, error) {
+	txs, err := oracle.backend.GetPoolTransactions()
+	if err != nil {
+		return nil, err
+	}
+	tips := make([]pendingTip, 0, len(txs))
+	for _, tx := range txs {
+		if tx.GasFeeCapIntCmp(baseFee) < 0 {
+			continue
+		}
+		tip, err := tx.EffectiveGasTip(baseFee)
+		if err != nil {
+			continue
+		}
+		tips = append(tips, pendingTip{tip: tip, gas: tx.Gas()})
+	}
+	slices.SortStableFunc(tips, func(a, b pendingTip) int { return b.tip.Cmp(a.tip) })
+	return tips, nil
+}
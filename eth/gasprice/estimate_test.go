@@ -0,0 +1,145 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/consensus/misc/eip4844"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/params"
+)
+
+func TestEstimateFees(t *testing.T) {
+	config := Config{
+		MaxHeaderHistory: 1000,
+		MaxBlockHistory:  1000,
+	}
+	backend := newTestBackend(t, big.NewInt(16), big.NewInt(28), false)
+	defer backend.teardown()
+	oracle := NewOracle(backend, config, nil)
+
+	// Blocks 13..32 include a single tip of their number in gwei, the median
+	// of which is 23 gwei, enough for the 11 blocks up to 23.
+	estimates, err := oracle.EstimateFees(context.Background())
+	if err != nil {
+		t.Fatalf("failed to estimate fees: %v", err)
+	}
+	if estimates.Number != testHead {
+		t.Fatalf("wrong head: have %d, want %d", estimates.Number, testHead)
+	}
+	want := big.NewInt(23 * params.GWei)
+	for i, level := range []FeeEstimate{estimates.Low, estimates.Medium, estimates.High} {
+		if level.TipCap.Cmp(want) != 0 {
+			t.Errorf("level %d: tip mismatch: have %v, want %v", i, level.TipCap, want)
+		}
+		if level.Confidence != 0.55 {
+			t.Errorf("level %d: confidence mismatch: have %v, want %v", i, level.Confidence, 0.55)
+		}
+		feeCap := new(big.Int).Add(want, new(big.Int).Mul(estimates.BaseFee, big.NewInt(2)))
+		if level.FeeCap.Cmp(feeCap) != 0 {
+			t.Errorf("level %d: fee cap mismatch: have %v, want %v", i, level.FeeCap, feeCap)
+		}
+	}
+	if estimates.PendingGas != 0 || estimates.Pressure != 0 {
+		t.Errorf("pressure without pending transactions: %d gas, %v", estimates.PendingGas, estimates.Pressure)
+	}
+	// Only the last 5 of the sampled blocks carry blobs, 6 each, so the blob
+	// usage projected is 1 blob per block, dropping the blob base fee.
+	head := backend.chain.GetHeaderByNumber(testHead)
+	excess := eip4844.CalcExcessBlobGas(*head.ExcessBlobGas, *head.BlobGasUsed)
+	if have, want := estimates.BlobBaseFee, eip4844.CalcBlobFee(excess); have.Cmp(want) != 0 {
+		t.Errorf("blob base fee mismatch: have %v, want %v", have, want)
+	}
+	if len(estimates.BlobBaseFeeProjection) != blobProjectionBlocks {
+		t.Fatalf("projected %d blob base fees, want %d", len(estimates.BlobBaseFeeProjection), blobProjectionBlocks)
+	}
+	for i, have := range estimates.BlobBaseFeeProjection {
+		excess = eip4844.CalcExcessBlobGas(excess, params.BlobTxBlobGasPerBlob)
+		if want := eip4844.CalcBlobFee(excess); have.Cmp(want) != 0 {
+			t.Errorf("projected blob base fee %d mismatch: have %v, want %v", i, have, want)
+		}
+	}
+}
+
+func TestEstimateFeesPending(t *testing.T) {
+	config := Config{
+		MaxHeaderHistory: 1000,
+		MaxBlockHistory:  1000,
+	}
+	backend := newTestBackend(t, big.NewInt(0), nil, false)
+	defer backend.teardown()
+	oracle := NewOracle(backend, config, nil)
+
+	// Fill the pool with three blocks worth of transactions outbidding the
+	// recent tips and some unable to pay the base fee.
+	limit := backend.chain.GetHeaderByNumber(testHead).GasLimit
+	for i := 0; i < 3; i++ {
+		backend.pool = append(backend.pool, types.NewTx(&types.DynamicFeeTx{
+			Nonce:     uint64(i),
+			To:        &common.Address{},
+			Gas:       limit,
+			GasFeeCap: big.NewInt(100 * params.GWei),
+			GasTipCap: big.NewInt(40 * params.GWei),
+		}))
+	}
+	backend.pool = append(backend.pool, types.NewTx(&types.DynamicFeeTx{
+		Nonce:     3,
+		To:        &common.Address{},
+		Gas:       limit,
+		GasFeeCap: big.NewInt(1),
+		GasTipCap: big.NewInt(1),
+	}))
+	estimates, err := oracle.EstimateFees(context.Background())
+	if err != nil {
+		t.Fatalf("failed to estimate fees: %v", err)
+	}
+	if estimates.PendingGas != 3*limit || estimates.Pressure != 3 {
+		t.Errorf("pressure mismatch: have %d gas, %v", estimates.PendingGas, estimates.Pressure)
+	}
+	// The high and medium levels are outbid by the pending transactions filling
+	// one and two blocks, the low one is not reached.
+	var (
+		recent  = big.NewInt(23 * params.GWei)
+		outbid  = big.NewInt(40 * params.GWei)
+		levels  = []FeeEstimate{estimates.Low, estimates.Medium, estimates.High}
+		tips    = []*big.Int{recent, outbid, outbid}
+		confids = []float64{0.55, 1, 1}
+	)
+	for i, level := range levels {
+		if level.TipCap.Cmp(tips[i]) != 0 {
+			t.Errorf("level %d: tip mismatch: have %v, want %v", i, level.TipCap, tips[i])
+		}
+		if level.Confidence != confids[i] {
+			t.Errorf("level %d: confidence mismatch: have %v, want %v", i, level.Confidence, confids[i])
+		}
+	}
+	if estimates.BlobBaseFee != nil || estimates.BlobBaseFeeProjection != nil {
+		t.Errorf("blob base fee projected before Cancun")
+	}
+	// The pending pool is summarized once per head block
+	backend.pool = backend.pool[:1]
+	if estimates, err = oracle.EstimateFees(context.Background()); err != nil {
+		t.Fatalf("failed to estimate fees: %v", err)
+	}
+	if estimates.PendingGas != 3*limit {
+		t.Errorf("pending pool summarized again on the same head: have %d gas", estimates.PendingGas)
+	}
+}
@@ -45,6 +45,7 @@ const testHead = 32
 type testBackend struct {
 	chain   *core.BlockChain
 	pending bool // pending block available
+	pool    types.Transactions
 }
 
 func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
@@ -112,6 +113,10 @@ func (b *testBackend) Pending() (*types.Block, types.Receipts, *state.StateDB) {
 	return nil, nil, nil
 }
 
+func (b *testBackend) GetPoolTransactions() (types.Transactions, error) {
+	return b.pool, nil
+}
+
 func (b *testBackend) ChainConfig() *params.ChainConfig {
 	return b.chain.Config()
 }
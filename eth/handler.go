@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/mclock"
 	"github.com/rajchain/go-rajchain/core"
 	"github.com/rajchain/go-rajchain/core/forkid"
 	"github.com/rajchain/go-rajchain/core/txpool"
@@ -91,6 +92,8 @@ type handlerConfig struct {
 	BloomCache     uint64                 // Megabytes to alloc for snap sync bloom
 	EventMux       *event.TypeMux         // Legacy event mux, deprecate for `feed`
 	RequiredBlocks map[uint64]common.Hash // Hard coded map of required block hashes for sync challenges
+	TxStemLength   int                    // Expected stem length of local transactions, zero disables stem propagation
+	TxStemEmbargo  time.Duration          // Time to wait for stem transactions to be fluffed before broadcasting them
 }
 
 type handler struct {
@@ -109,6 +112,7 @@ type handler struct {
 	downloader *downloader.Downloader
 	txFetcher  *fetcher.TxFetcher
 	peers      *peerSet
+	dandelion  *dandelion // Stem propagation of local transactions, nil if disabled
 
 	eventMux *event.TypeMux
 	txsCh    chan core.NewTxsEvent
@@ -173,6 +177,15 @@ func newHandler(config *handlerConfig) (*handler, error) {
 			log.Info("Enabled snap sync", "head", head.Number, "hash", head.Hash())
 		}
 	}
+	// Verkle states can't be snap synced, the conversion of the merkle state
+	// needs the preimages of all its keys, which snap sync doesn't deliver.
+	// Chains which didn't reach the verkle fork yet still snap sync.
+	if h.snapSync.Load() {
+		if head := h.chain.CurrentBlock(); h.chain.Config().IsVerkle(head.Number, head.Time) {
+			log.Warn("Switching to full sync for verkle chain", "head", head.Number)
+			h.snapSync.Store(false)
+		}
+	}
 	// If snap sync is requested but snapshots are disabled, fail loudly
 	if h.snapSync.Load() && config.Chain.Snapshots() == nil {
 		return nil, errors.New("snap sync not supported with snapshots disabled")
@@ -191,6 +204,10 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		return h.txpool.Add(txs, false, false)
 	}
 	h.txFetcher = fetcher.NewTxFetcher(h.txpool.Has, addTxs, fetchTx, h.removePeer)
+
+	if config.TxStemLength > 0 {
+		h.dandelion = newDandelion(config.TxStemLength, config.TxStemEmbargo, mclock.System{}, h.txpool, h.BroadcastTransactions)
+	}
 	return h, nil
 }
 
@@ -289,6 +306,9 @@ func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 	if p == nil {
 		return errors.New("peer dropped during handling")
 	}
+	if h.dandelion != nil {
+		h.dandelion.register(peer)
+	}
 	// Register the peer in the downloader. If the downloader considers it banned, we disconnect
 	if err := h.downloader.RegisterPeer(peer.ID(), peer.Version(), peer); err != nil {
 		peer.Log().Error("Failed to register peer in eth syncer", "err", err)
@@ -378,10 +398,12 @@ func (h *handler) runSnapExtension(peer *snap.Peer, handler snap.Handler) error
 	return handler(peer)
 }
 
-// removePeer requests disconnection of a peer.
+// removePeer requests disconnection of a peer which misbehaved, penalizing its
+// reputation.
 func (h *handler) removePeer(id string) {
 	peer := h.peers.peer(id)
 	if peer != nil {
+		peer.Peer.ScoreInvalid()
 		peer.Peer.Disconnect(p2p.DiscUselessPeer)
 	}
 }
@@ -411,6 +433,9 @@ func (h *handler) unregisterPeer(id string) {
 	}
 	h.downloader.UnregisterPeer(id)
 	h.txFetcher.Drop(id)
+	if h.dandelion != nil {
+		h.dandelion.unregister(id)
+	}
 
 	if err := h.peers.unregisterPeer(id); err != nil {
 		logger.Error("rajchain peer removal failed", "err", err)
@@ -437,6 +462,9 @@ func (h *handler) Start(maxPeers int) {
 func (h *handler) Stop() {
 	h.txsSub.Unsubscribe() // quits txBroadcastLoop
 	h.txFetcher.Stop()
+	if h.dandelion != nil {
+		h.dandelion.stop()
+	}
 	h.downloader.Terminate()
 
 	// Quit chainSync and txsync64.
@@ -537,7 +565,14 @@ func (h *handler) txBroadcastLoop() {
 	for {
 		select {
 		case event := <-h.txsCh:
-			h.BroadcastTransactions(event.Txs)
+			txs := event.Txs
+			if h.dandelion != nil {
+				// Stem transactions are only broadcast when fluffed
+				txs = h.dandelion.filter(txs)
+			}
+			if len(txs) > 0 {
+				h.BroadcastTransactions(txs)
+			}
 		case <-h.txsSub.Err():
 			return
 		}
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/rajchain/go-rajchain/common"
 	"github.com/rajchain/go-rajchain/core"
 	"github.com/rajchain/go-rajchain/core/types"
 	"github.com/rajchain/go-rajchain/eth/protocols/eth"
@@ -66,12 +67,54 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 				return errors.New("disallowed broadcast blob transaction")
 			}
 		}
-		return h.txFetcher.Enqueue(peer.ID(), *packet, false)
+		return h.enqueueTxs(peer, *packet, false)
 
 	case *eth.PooledTransactionsResponse:
-		return h.txFetcher.Enqueue(peer.ID(), *packet, true)
+		return h.enqueueTxs(peer, *packet, true)
+
+	case *eth.StemTransactionsPacket:
+		for _, tx := range *packet {
+			if tx.Type() == types.BlobTxType {
+				return errors.New("disallowed stem blob transaction")
+			}
+		}
+		// Without stem propagation, end the stem by broadcasting them
+		if h.dandelion == nil {
+			return h.enqueueTxs(peer, *packet, false)
+		}
+		h.dandelion.relay(peer.ID(), *packet)
+		return nil
 
 	default:
 		return fmt.Errorf("unexpected eth packet type: %T", packet)
 	}
 }
+
+// enqueueTxs hands the transactions delivered by a peer over to the fetcher,
+// rating the peer as useful if any of them was new and made it into the pool.
+func (h *ethHandler) enqueueTxs(peer *eth.Peer, txs []*types.Transaction, direct bool) error {
+	var (
+		fresh  []common.Hash
+		hashes = make([]common.Hash, len(txs))
+	)
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+		if !h.txpool.Has(tx.Hash()) {
+			fresh = append(fresh, tx.Hash())
+		}
+	}
+	if h.dandelion != nil {
+		// Transactions broadcast by others have left the stem
+		h.dandelion.seen(hashes)
+	}
+	if err := h.txFetcher.Enqueue(peer.ID(), txs, direct); err != nil {
+		return err
+	}
+	for _, hash := range fresh {
+		if h.txpool.Has(hash) {
+			peer.ScoreUseful()
+			break
+		}
+	}
+	return nil
+}
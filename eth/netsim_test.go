@@ -0,0 +1,274 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/mclock"
+	"github.com/rajchain/go-rajchain/consensus/beacon"
+	"github.com/rajchain/go-rajchain/core"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/eth/downloader"
+	"github.com/rajchain/go-rajchain/eth/ethconfig"
+	"github.com/rajchain/go-rajchain/eth/protocols/eth"
+	"github.com/rajchain/go-rajchain/node"
+	"github.com/rajchain/go-rajchain/p2p"
+	"github.com/rajchain/go-rajchain/p2p/netsim"
+	"github.com/rajchain/go-rajchain/params"
+)
+
+// simNode is an eth node running on a simulated network.
+type simNode struct {
+	*node.Node
+	eth *rajchain
+}
+
+// simStart returns the function starting eth nodes on the given genesis, with
+// the given blocks imported.
+func simStart(genesis *core.Genesis, blocks []*types.Block) netsim.StartFunc {
+	return func(config p2p.Config) (netsim.Service, error) {
+		stack, err := node.New(&node.Config{P2P: config, Logger: config.Logger})
+		if err != nil {
+			return nil, err
+		}
+		ethcfg := ethconfig.Defaults
+		ethcfg.Genesis = genesis
+		ethcfg.SyncMode = downloader.SnapSync
+		ethcfg.TrieCleanCache, ethcfg.TrieDirtyCache, ethcfg.SnapshotCache = 16, 16, 16
+		backend, err := New(stack, &ethcfg)
+		if err != nil {
+			stack.Close()
+			return nil, err
+		}
+		if err := stack.Start(); err != nil {
+			stack.Close()
+			return nil, err
+		}
+		if _, err := backend.BlockChain().InsertChain(blocks); err != nil {
+			stack.Close()
+			return nil, err
+		}
+		return &simNode{stack, backend}, nil
+	}
+}
+
+// simChain creates a post-merge chain of the given length, with a transfer
+// from the test account in every block.
+func simChain(n int) (*core.Genesis, []*types.Block) {
+	config := *params.MergedTestChainConfig
+	config.PragueTime = nil
+
+	genesis := &core.Genesis{
+		Config:     &config,
+		Alloc:      types.GenesisAlloc{testAddr: {Balance: big.NewInt(params.Ether)}},
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+		Difficulty: common.Big0,
+	}
+	signer := types.LatestSigner(&config)
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, beacon.NewFaker(), n, func(i int, g *core.BlockGen) {
+		tx, _ := types.SignNewTx(testKey, signer, &types.DynamicFeeTx{
+			Nonce:     uint64(i),
+			To:        &common.Address{byte(i)},
+			Value:     big.NewInt(1),
+			Gas:       params.TxGas,
+			GasFeeCap: g.BaseFee(),
+		})
+		g.AddTx(tx)
+	})
+	return genesis, blocks
+}
+
+func simEth(n *netsim.Node) *rajchain {
+	return n.Service().(*simNode).eth
+}
+
+// Tests that a transaction is gossiped to all nodes of a simulated network.
+func TestSimTxGossip(t *testing.T) {
+	const count = 6
+
+	genesis, _ := simChain(0)
+	net := netsim.New(netsim.Config{
+		Seed: 1,
+		Link: netsim.LinkConfig{Latency: 10 * time.Millisecond, Bandwidth: 1 << 20, Loss: 0.01},
+	})
+	defer net.Close()
+
+	tx, _ := types.SignNewTx(testKey, types.LatestSigner(genesis.Config), &types.DynamicFeeTx{
+		Nonce:     0,
+		To:        &common.Address{1},
+		Gas:       params.TxGas,
+		GasTipCap: big.NewInt(params.GWei),
+		GasFeeCap: big.NewInt(params.InitialBaseFee * 2),
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	err := net.Run(ctx,
+		netsim.AddNodes(count, netsim.NodeConfig{Start: simStart(genesis, nil)}),
+		netsim.Do("mark synced", func(net *netsim.Network) error {
+			for _, n := range net.Nodes() {
+				simEth(n).SetSynced()
+			}
+			return nil
+		}),
+		netsim.ConnectRing(),
+		netsim.WaitPeers(2),
+		netsim.Do("submit transaction", func(net *netsim.Network) error {
+			return simEth(net.Node(0)).TxPool().Add([]*types.Transaction{tx}, true, false)[0]
+		}),
+		netsim.Until("transaction gossiped", func(net *netsim.Network) bool {
+			for _, n := range net.Nodes() {
+				if simEth(n).TxPool().Has(tx.Hash()) {
+					net.Metrics().Mark(tx.Hash().Hex(), n)
+				}
+			}
+			return net.Metrics().Seen(tx.Hash().Hex()) == count
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := net.Metrics().Messages("eth", eth.TransactionsMsg); stats.Count == 0 {
+		t.Fatal("no transaction broadcasts counted")
+	}
+}
+
+// Tests that a transaction reaches all nodes of a 50 node network with a random
+// topology, through both direct broadcasts and announcements. The links run on
+// a simulated clock, so the propagation times are measured in link time.
+func TestSimTxGossip50(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping 50 node simulation in short mode")
+	}
+	const count = 50
+
+	genesis, _ := simChain(0)
+	net := netsim.New(netsim.Config{
+		Seed:  1,
+		Clock: new(mclock.Simulated),
+		Link:  netsim.LinkConfig{Latency: 20 * time.Millisecond, Bandwidth: 1 << 20, Loss: 0.01},
+	})
+	defer net.Close()
+
+	tx, _ := types.SignNewTx(testKey, types.LatestSigner(genesis.Config), &types.DynamicFeeTx{
+		Nonce:     0,
+		To:        &common.Address{1},
+		Gas:       params.TxGas,
+		GasTipCap: big.NewInt(params.GWei),
+		GasFeeCap: big.NewInt(params.InitialBaseFee * 2),
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	err := net.Run(ctx,
+		netsim.AddNodes(count, netsim.NodeConfig{MaxPeers: 12, Start: simStart(genesis, nil)}),
+		netsim.Do("mark synced", func(net *netsim.Network) error {
+			for _, n := range net.Nodes() {
+				simEth(n).SetSynced()
+			}
+			return nil
+		}),
+		netsim.ConnectRandom(4),
+		netsim.WaitPeers(4),
+		netsim.Do("submit transaction", func(net *netsim.Network) error {
+			return simEth(net.Node(0)).TxPool().Add([]*types.Transaction{tx}, true, false)[0]
+		}),
+		netsim.Until("transaction gossiped", func(net *netsim.Network) bool {
+			for _, n := range net.Nodes() {
+				if simEth(n).TxPool().Has(tx.Hash()) {
+					net.Metrics().Mark(tx.Hash().Hex(), n)
+				}
+			}
+			return net.Metrics().Seen(tx.Hash().Hex()) == count
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Reaching the farthest node takes at least a few hops
+	if spread := net.Metrics().Spread(tx.Hash().Hex()); spread[count-1] < 40*time.Millisecond {
+		t.Fatalf("implausible propagation time: %v", spread[count-1])
+	}
+	if stats := net.Metrics().Messages("eth", eth.TransactionsMsg); stats.Count == 0 {
+		t.Fatal("no transaction broadcasts counted")
+	}
+	if stats := net.Metrics().Messages("eth", eth.NewPooledTransactionHashesMsg); stats.Count == 0 {
+		t.Fatal("no transaction announcements counted")
+	}
+}
+
+// Tests that nodes snap sync a chain over lossy links, from a single node
+// holding it.
+func TestSimSnapSync(t *testing.T) {
+	const count = 3
+
+	genesis, blocks := simChain(128)
+	head := blocks[len(blocks)-1].Header()
+
+	net := netsim.New(netsim.Config{
+		Seed: 1,
+		Link: netsim.LinkConfig{Latency: 5 * time.Millisecond, Bandwidth: 4 << 20, Loss: 0.01},
+	})
+	defer net.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	err := net.Run(ctx,
+		netsim.AddNodes(1, netsim.NodeConfig{Start: simStart(genesis, blocks)}),
+		netsim.AddNodes(count, netsim.NodeConfig{Start: simStart(genesis, nil)}),
+		netsim.Do("connect to source", func(net *netsim.Network) error {
+			for _, n := range net.Nodes()[1:] {
+				net.Connect(n, net.Node(0))
+			}
+			return nil
+		}),
+		netsim.Until("wait for source peers", func(net *netsim.Network) bool {
+			return net.Node(0).Server().PeerCount() == count
+		}),
+		netsim.Do("start sync", func(net *netsim.Network) error {
+			for _, n := range net.Nodes()[1:] {
+				if err := simEth(n).Downloader().BeaconSync(downloader.SnapSync, head, nil); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+		netsim.Until("sync done", func(net *netsim.Network) bool {
+			for _, n := range net.Nodes() {
+				if simEth(n).BlockChain().CurrentBlock().Hash() == head.Hash() {
+					net.Metrics().Mark("synced", n)
+				}
+			}
+			return net.Metrics().Seen("synced") == count+1
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range net.Nodes()[1:] {
+		chain := simEth(n).BlockChain()
+		if !chain.HasState(chain.CurrentBlock().Root) {
+			t.Fatalf("%v: state of head missing after sync", n)
+		}
+	}
+	if stats := net.Metrics().Protocol("snap"); stats.Count == 0 {
+		t.Fatal("no snap messages counted")
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/rajchain/go-rajchain/accounts/abi"
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/core"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/core/vm"
+	"github.com/rajchain/go-rajchain/log"
+	"github.com/rajchain/go-rajchain/p2p"
+	"github.com/rajchain/go-rajchain/p2p/enode"
+)
+
+// nodeRegistryABI is the interface of the on-chain registry of the nodes
+// allowed to join a permissioned network. Nodes are listed by their ID, the
+// keccak256 hash of their public key.
+const nodeRegistryABI = `[
+	{"type":"function","name":"allowedNodes","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bytes32[]"}]}
+]`
+
+// nodeRegistryGas is the gas allowance for reading the node registry.
+const nodeRegistryGas = 50_000_000
+
+// nodeRegistry is the parsed node registry interface.
+var nodeRegistry abi.ABI
+
+func init() {
+	var err error
+	if nodeRegistry, err = abi.JSON(strings.NewReader(nodeRegistryABI)); err != nil {
+		panic(err)
+	}
+}
+
+// registrySync keeps the contract source of the node allowlist in sync with the
+// on-chain node registry, reading it on every new head.
+type registrySync struct {
+	address   common.Address
+	chain     *core.BlockChain
+	allowlist *p2p.Allowlist
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newRegistrySync creates a node registry reader feeding the given allowlist.
+func newRegistrySync(address common.Address, chain *core.BlockChain, allowlist *p2p.Allowlist) *registrySync {
+	return &registrySync{
+		address:   address,
+		chain:     chain,
+		allowlist: allowlist,
+		quit:      make(chan struct{}),
+	}
+}
+
+// start reads the registry at the current head and keeps following the chain.
+func (r *registrySync) start() {
+	r.update(r.chain.CurrentBlock())
+
+	r.wg.Add(1)
+	go r.loop()
+}
+
+// stop terminates following the chain.
+func (r *registrySync) stop() {
+	close(r.quit)
+	r.wg.Wait()
+}
+
+func (r *registrySync) loop() {
+	defer r.wg.Done()
+
+	heads := make(chan core.ChainHeadEvent, 10)
+	sub := r.chain.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-heads:
+			r.update(ev.Header)
+		case <-sub.Err():
+			return
+		case <-r.quit:
+			return
+		}
+	}
+}
+
+// update reads the nodes listed in the registry at the given head, replacing
+// the contract source of the allowlist. On failure the previous list is kept,
+// so a broken registry doesn't cut the node off the network.
+func (r *registrySync) update(head *types.Header) {
+	ids, err := r.read(head)
+	if err != nil {
+		log.Warn("Failed to read node registry", "address", r.address, "number", head.Number, "err", err)
+		return
+	}
+	r.allowlist.Set(p2p.AllowlistSourceContract, ids)
+}
+
+// read calls the registry contract on the state of the given head.
+func (r *registrySync) read(head *types.Header) ([]enode.ID, error) {
+	statedb, err := r.chain.StateAt(head.Root)
+	if err != nil {
+		return nil, err
+	}
+	input, err := nodeRegistry.Pack("allowedNodes")
+	if err != nil {
+		return nil, err
+	}
+	blockCtx := core.NewEVMBlockContext(head, r.chain, nil)
+	blockCtx.BaseFee = new(big.Int)
+
+	evm := vm.NewEVM(blockCtx, statedb, r.chain.Config(), vm.Config{NoBaseFee: true})
+	ret, _, err := evm.StaticCall(vm.AccountRef(common.Address{}), r.address, input, nodeRegistryGas)
+	if err != nil {
+		return nil, err
+	}
+	out, err := nodeRegistry.Unpack("allowedNodes", ret)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := out[0].([][32]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected registry output %T", out[0])
+	}
+	ids := make([]enode.ID, len(list))
+	for i, id := range list {
+		ids[i] = enode.ID(id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"testing"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/consensus/ethash"
+	"github.com/rajchain/go-rajchain/core"
+	"github.com/rajchain/go-rajchain/core/rawdb"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/core/vm"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/p2p/enode"
+	"github.com/rajchain/go-rajchain/params"
+)
+
+// Tests that the allowed nodes are read from the registry contract.
+func TestNodeRegistryRead(t *testing.T) {
+	var (
+		registry = common.HexToAddress("0x1000")
+		id       = enode.ID(crypto.Keccak256Hash([]byte("node")))
+	)
+	// The registry returns a static list of a single node
+	code := []byte{
+		byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x00, byte(vm.MSTORE), // array offset
+		byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x20, byte(vm.MSTORE), // array length
+		byte(vm.PUSH32),
+	}
+	code = append(code, id[:]...)
+	code = append(code,
+		byte(vm.PUSH1), 0x40, byte(vm.MSTORE), // node id
+		byte(vm.PUSH1), 0x60, byte(vm.PUSH1), 0x00, byte(vm.RETURN),
+	)
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  types.GenesisAlloc{registry: {Code: code}},
+	}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+
+	ids, err := newRegistrySync(registry, chain, nil).read(chain.CurrentBlock())
+	if err != nil {
+		t.Fatalf("failed to read registry: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != id {
+		t.Fatalf("node list mismatch: have %v, want [%v]", ids, id)
+	}
+	// Reading an account without code fails instead of emptying the list
+	if _, err := newRegistrySync(common.HexToAddress("0x2000"), chain, nil).read(chain.CurrentBlock()); err == nil {
+		t.Fatal("empty registry read without error")
+	}
+}
@@ -29,8 +29,9 @@ const (
 
 // broadcastTransactions is a write loop that schedules transaction broadcasts
 // to the remote peer. The goal is to have an async writer that does not lock up
-// node internals and at the same time rate limits queued data.
-func (p *Peer) broadcastTransactions() {
+// node internals and at the same time rate limits queued data. It serves both
+// the broadcasts and the stem relays, each with its own queue and message.
+func (p *Peer) broadcastTransactions(requests chan []common.Hash, send func(types.Transactions) error) {
 	var (
 		queue  []common.Hash         // Queue of hashes to broadcast as full transactions
 		done   chan struct{}         // Non-nil if background broadcaster is running
@@ -59,7 +60,7 @@ func (p *Peer) broadcastTransactions() {
 			if len(txs) > 0 {
 				done = make(chan struct{})
 				go func() {
-					if err := p.SendTransactions(txs); err != nil {
+					if err := send(txs); err != nil {
 						fail <- err
 						return
 					}
@@ -70,7 +71,7 @@ func (p *Peer) broadcastTransactions() {
 		}
 		// Transfer goroutine may or may not have been started, listen for events
 		select {
-		case hashes := <-p.txBroadcast:
+		case hashes := <-requests:
 			// If the connection failed, discard all transaction events
 			if failed {
 				continue
@@ -194,7 +194,7 @@ func (p *Peer) dispatcher() {
 			req := reqOp.req
 			req.Sent = time.Now()
 
-			requestTracker.Track(p.id, p.version, req.code, req.want, req.id)
+			requestTracker.Track(p.id, p.Peer, p.version, req.code, req.want, req.id)
 			err := p2p.Send(p.rw, req.code, req.data)
 			reqOp.fail <- err
 
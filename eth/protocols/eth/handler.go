@@ -18,6 +18,7 @@ package eth
 
 import (
 	"fmt"
+	"maps"
 	"math/big"
 	"time"
 
@@ -101,6 +102,13 @@ func MakeProtocols(backend Backend, network uint64, dnsdisc enode.Iterator) []p2
 				peer := NewPeer(version, p, rw, backend.TxPool())
 				defer peer.Close()
 
+				// Penalize the requests left unanswered before the reputation
+				// of the peer is persisted on disconnect, unless the local node
+				// tore the connection down
+				defer func() {
+					requestTracker.Flush(peer.id, !p.DisconnectedLocally())
+				}()
+
 				return backend.RunPeer(peer, func(peer *Peer) error {
 					return Handle(backend, peer)
 				})
@@ -111,12 +119,38 @@ func MakeProtocols(backend Backend, network uint64, dnsdisc enode.Iterator) []p2
 			PeerInfo: func(id enode.ID) interface{} {
 				return backend.PeerInfo(id)
 			},
-			Attributes: []enr.Entry{currentENREntry(backend.Chain())},
+			TrafficClass: trafficClass,
+			Attributes:   []enr.Entry{currentENREntry(backend.Chain())},
 		})
 	}
 	return protocols
 }
 
+// trafficClass prioritizes block propagation over syncing, syncing over serving
+// chain data, and serving chain data over transaction gossip when bandwidth is
+// capped. Chain data requests sent and their responses received are the local
+// node syncing, the ones received and responses sent are serving.
+func trafficClass(code uint64, egress bool) p2p.TrafficClass {
+	switch code {
+	case StatusMsg, NewBlockHashesMsg, NewBlockMsg:
+		return p2p.TrafficBlocks
+	case TransactionsMsg, NewPooledTransactionHashesMsg, GetPooledTransactionsMsg, PooledTransactionsMsg, StemTransactionsMsg:
+		return p2p.TrafficGossip
+	case GetBlockHeadersMsg, GetBlockBodiesMsg, GetReceiptsMsg:
+		if egress {
+			return p2p.TrafficSync
+		}
+		return p2p.TrafficServing
+	case BlockHeadersMsg, BlockBodiesMsg, ReceiptsMsg:
+		if egress {
+			return p2p.TrafficServing
+		}
+		return p2p.TrafficSync
+	default:
+		return p2p.TrafficServing
+	}
+}
+
 // NodeInfo represents a short summary of the `eth` sub-protocol metadata
 // known about the host peer.
 type NodeInfo struct {
@@ -174,20 +208,37 @@ var eth68 = map[uint64]msgHandler{
 	PooledTransactionsMsg:         handlePooledTransactions,
 }
 
+// eth68Stem extends eth68 with the stem phase of the transaction relay.
+var eth68Stem = func() map[uint64]msgHandler {
+	handlers := maps.Clone(eth68)
+	handlers[StemTransactionsMsg] = handleStemTransactions
+	return handlers
+}()
+
 // handleMessage is invoked whenever an inbound message is received from a remote
 // peer. The remote connection is torn down upon returning any error.
-func handleMessage(backend Backend, peer *Peer) error {
+func handleMessage(backend Backend, peer *Peer) (err error) {
 	// Read the next message from the remote peer, and ensure it's fully consumed
 	msg, err := peer.rw.ReadMsg()
 	if err != nil {
 		return err
 	}
+	// Any failure past reading the message is caused by its contents, except for
+	// replies failing to be sent on a closing connection
+	defer func() {
+		if err != nil && !p2p.IsDisconnectError(err) {
+			peer.ScoreInvalid()
+		}
+	}()
 	if msg.Size > maxMessageSize {
 		return fmt.Errorf("%w: %v > %v", errMsgTooLarge, msg.Size, maxMessageSize)
 	}
 	defer msg.Discard()
 
 	var handlers = eth68
+	if peer.Version() == ETH68Stem {
+		handlers = eth68Stem
+	}
 
 	// Track the amount of time it takes to serve the request and run the handler
 	if metrics.Enabled {
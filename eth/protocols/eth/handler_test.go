@@ -18,6 +18,7 @@ package eth
 
 import (
 	"bytes"
+	"errors"
 	"math"
 	"math/big"
 	"math/rand"
@@ -504,6 +505,57 @@ func testGetBlockReceipts(t *testing.T, protocol uint) {
 	}
 }
 
+// Tests that stem transactions are only accepted from peers which negotiated
+// the stem extension of the protocol.
+func TestStemTransactionsNegotiation(t *testing.T) {
+	t.Parallel()
+
+	backend := newTestBackend(0)
+	defer backend.close()
+
+	tx, _ := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(0), params.TxGas, big.NewInt(0), nil), types.HomesteadSigner{}, testKey)
+
+	// Peers on plain eth/68 don't support stem relays and are dropped on them
+	legacy, errc := newTestPeer("legacy", ETH68, backend)
+	defer legacy.close()
+
+	if legacy.SupportsStem() {
+		t.Fatalf("eth/68 peer supports stem relays")
+	}
+	if err := p2p.Send(legacy.app, StemTransactionsMsg, StemTransactionsPacket{tx}); err != nil {
+		t.Fatalf("failed to send stem transactions: %v", err)
+	}
+	select {
+	case err := <-errc:
+		if !errors.Is(err, errInvalidMsgCode) {
+			t.Fatalf("wrong error for stem transactions on eth/68: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("eth/68 peer not dropped on stem transactions")
+	}
+	// Peers on the stem extension accept them
+	peer, errc := newTestPeer("stem", ETH68Stem, backend)
+	defer peer.close()
+
+	if !peer.SupportsStem() {
+		t.Fatalf("stem peer doesn't support stem relays")
+	}
+	if err := p2p.Send(peer.app, StemTransactionsMsg, StemTransactionsPacket{tx}); err != nil {
+		t.Fatalf("failed to send stem transactions: %v", err)
+	}
+	for i := 0; i < 100 && !peer.KnownTransaction(tx.Hash()); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !peer.KnownTransaction(tx.Hash()) {
+		t.Fatalf("stem transaction not accepted")
+	}
+	select {
+	case err := <-errc:
+		t.Fatalf("stem peer dropped: %v", err)
+	default:
+	}
+}
+
 type decoder struct {
 	msg []byte
 }
@@ -57,6 +57,7 @@ type Peer struct {
 	knownTxs    *knownCache        // Set of transaction hashes known to be known by this peer
 	txBroadcast chan []common.Hash // Channel used to queue transaction propagation requests
 	txAnnounce  chan []common.Hash // Channel used to queue transaction announcement requests
+	txStem      chan []common.Hash // Channel used to queue transaction stem relay requests
 
 	reqDispatch chan *request  // Dispatch channel to send requests and track then until fulfillment
 	reqCancel   chan *cancel   // Dispatch channel to cancel pending requests and untrack them
@@ -77,6 +78,7 @@ func NewPeer(version uint, p *p2p.Peer, rw p2p.MsgReadWriter, txpool TxPool) *Pe
 		knownTxs:    newKnownCache(maxKnownTxs),
 		txBroadcast: make(chan []common.Hash),
 		txAnnounce:  make(chan []common.Hash),
+		txStem:      make(chan []common.Hash),
 		reqDispatch: make(chan *request),
 		reqCancel:   make(chan *cancel),
 		resDispatch: make(chan *response),
@@ -84,7 +86,10 @@ func NewPeer(version uint, p *p2p.Peer, rw p2p.MsgReadWriter, txpool TxPool) *Pe
 		term:        make(chan struct{}),
 	}
 	// Start up all the broadcasters
-	go peer.broadcastTransactions()
+	go peer.broadcastTransactions(peer.txBroadcast, peer.SendTransactions)
+	if peer.SupportsStem() {
+		go peer.broadcastTransactions(peer.txStem, peer.SendStemTransactions)
+	}
 	go peer.announceTransactions()
 	go peer.dispatcher()
 
@@ -108,6 +113,12 @@ func (p *Peer) Version() uint {
 	return p.version
 }
 
+// SupportsStem reports whether the peer accepts transactions relayed along the
+// stem.
+func (p *Peer) SupportsStem() bool {
+	return p.version == ETH68Stem
+}
+
 // Head retrieves the current head hash and total difficulty of the peer.
 func (p *Peer) Head() (hash common.Hash, td *big.Int) {
 	p.lock.RLock()
@@ -168,6 +179,33 @@ func (p *Peer) AsyncSendTransactions(hashes []common.Hash) {
 	}
 }
 
+// SendStemTransactions relays transactions along the stem to the peer and
+// includes the hashes in its transaction hash set for future reference.
+//
+// This method is a helper used by the async stem relayer. Don't call it directly
+// as the queueing (memory) and transmission (bandwidth) costs should not be
+// managed directly.
+func (p *Peer) SendStemTransactions(txs types.Transactions) error {
+	// Mark all the transactions as known, but ensure we don't overflow our limits
+	for _, tx := range txs {
+		p.knownTxs.Add(tx.Hash())
+	}
+	return p2p.Send(p.rw, StemTransactionsMsg, txs)
+}
+
+// AsyncSendStemTransactions queues a list of transactions (by hash) to relay
+// along the stem to the peer. It must only be called on peers supporting stem
+// relaying.
+func (p *Peer) AsyncSendStemTransactions(hashes []common.Hash) {
+	select {
+	case p.txStem <- hashes:
+		// Mark all the transactions as known, but ensure we don't overflow our limits
+		p.knownTxs.Add(hashes...)
+	case <-p.term:
+		p.Log().Debug("Dropping stem transaction relay", "count", len(hashes))
+	}
+}
+
 // sendPooledTransactionHashes sends transaction hashes (tagged with their type
 // and size) to the peer and includes them in its transaction hash set for future
 // reference.
@@ -360,7 +398,7 @@ func (p *Peer) RequestTxs(hashes []common.Hash) error {
 	p.Log().Debug("Fetching batch of transactions", "count", len(hashes))
 	id := rand.Uint64()
 
-	requestTracker.Track(p.id, p.version, GetPooledTransactionsMsg, PooledTransactionsMsg, id)
+	requestTracker.Track(p.id, p.Peer, p.version, GetPooledTransactionsMsg, PooledTransactionsMsg, id)
 	return p2p.Send(p.rw, GetPooledTransactionsMsg, &GetPooledTransactionsPacket{
 		RequestId:                    id,
 		GetPooledTransactionsRequest: hashes,
@@ -31,6 +31,12 @@ import (
 // Constants to match up protocol versions and messages
 const (
 	ETH68 = 68
+
+	// ETH68Stem is eth/68 extended with the stem phase of Dandelion++ style
+	// transaction propagation. It is specific to this network, numbered out of
+	// the range of the upstream versions, and negotiated alongside eth/68 so
+	// peers lacking it fall back to plain broadcasts.
+	ETH68Stem = 1068
 )
 
 // ProtocolName is the official short name of the `eth` protocol used during
@@ -39,11 +45,11 @@ const ProtocolName = "eth"
 
 // ProtocolVersions are the supported versions of the `eth` protocol (first
 // is primary).
-var ProtocolVersions = []uint{ETH68}
+var ProtocolVersions = []uint{ETH68Stem, ETH68}
 
 // protocolLengths are the number of implemented message corresponding to
 // different protocol versions.
-var protocolLengths = map[uint]uint64{ETH68: 17}
+var protocolLengths = map[uint]uint64{ETH68Stem: 18, ETH68: 17}
 
 // maxMessageSize is the maximum cap on the size of a protocol message.
 const maxMessageSize = 10 * 1024 * 1024
@@ -62,6 +68,7 @@ const (
 	PooledTransactionsMsg         = 0x0a
 	GetReceiptsMsg                = 0x0f
 	ReceiptsMsg                   = 0x10
+	StemTransactionsMsg           = 0x11
 )
 
 var (
@@ -114,6 +121,10 @@ func (p *NewBlockHashesPacket) Unpack() ([]common.Hash, []uint64) {
 // TransactionsPacket is the network packet for broadcasting new transactions.
 type TransactionsPacket []*types.Transaction
 
+// StemTransactionsPacket is the network packet for relaying transactions along
+// the stem, to a single peer, before they are broadcast.
+type StemTransactionsPacket []*types.Transaction
+
 // GetBlockHeadersRequest represents a block header query.
 type GetBlockHeadersRequest struct {
 	Origin  HashOrNumber // Block from which to retrieve headers
@@ -342,3 +353,6 @@ func (*GetReceiptsRequest) Kind() byte   { return GetReceiptsMsg }
 
 func (*ReceiptsResponse) Name() string { return "Receipts" }
 func (*ReceiptsResponse) Kind() byte   { return ReceiptsMsg }
+
+func (*StemTransactionsPacket) Name() string { return "StemTransactions" }
+func (*StemTransactionsPacket) Kind() byte   { return StemTransactionsMsg }
@@ -23,6 +23,7 @@ import (
 
 	"github.com/rajchain/go-rajchain/common"
 	"github.com/rajchain/go-rajchain/core"
+	"github.com/rajchain/go-rajchain/core/state/snapshot"
 	"github.com/rajchain/go-rajchain/core/types"
 	"github.com/rajchain/go-rajchain/log"
 	"github.com/rajchain/go-rajchain/metrics"
@@ -31,6 +32,7 @@ import (
 	"github.com/rajchain/go-rajchain/p2p/enr"
 	"github.com/rajchain/go-rajchain/trie"
 	"github.com/rajchain/go-rajchain/trie/trienode"
+	"github.com/rajchain/go-rajchain/triedb/pathdb"
 )
 
 const (
@@ -90,7 +92,16 @@ func MakeProtocols(backend Backend) []p2p.Protocol {
 			Version: version,
 			Length:  protocolLengths[version],
 			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
-				return backend.RunPeer(NewPeer(version, p, rw), func(peer *Peer) error {
+				peer := NewPeer(version, p, rw)
+
+				// Penalize the requests left unanswered before the reputation
+				// of the peer is persisted on disconnect, unless the local node
+				// tore the connection down
+				defer func() {
+					requestTracker.Flush(peer.id, !p.DisconnectedLocally())
+				}()
+
+				return backend.RunPeer(peer, func(peer *Peer) error {
 					return Handle(backend, peer)
 				})
 			},
@@ -100,12 +111,23 @@ func MakeProtocols(backend Backend) []p2p.Protocol {
 			PeerInfo: func(id enode.ID) interface{} {
 				return backend.PeerInfo(id)
 			},
-			Attributes: []enr.Entry{&enrEntry{}},
+			TrafficClass: trafficClass,
+			Attributes:   []enr.Entry{&enrEntry{}},
 		}
 	}
 	return protocols
 }
 
+// trafficClass classes the requests sent and responses received as the local node
+// syncing, and the requests received and responses sent as serving.
+func trafficClass(code uint64, egress bool) p2p.TrafficClass {
+	request := code == GetAccountRangeMsg || code == GetStorageRangesMsg || code == GetByteCodesMsg || code == GetTrieNodesMsg
+	if request == egress {
+		return p2p.TrafficSync
+	}
+	return p2p.TrafficServing
+}
+
 // Handle is the callback invoked to manage the life cycle of a `snap` peer.
 // When this function terminates, the peer is disconnected.
 func Handle(backend Backend, peer *Peer) error {
@@ -120,12 +142,19 @@ func Handle(backend Backend, peer *Peer) error {
 // HandleMessage is invoked whenever an inbound message is received from a
 // remote peer on the `snap` protocol. The remote connection is torn down upon
 // returning any error.
-func HandleMessage(backend Backend, peer *Peer) error {
+func HandleMessage(backend Backend, peer *Peer) (err error) {
 	// Read the next message from the remote peer, and ensure it's fully consumed
 	msg, err := peer.rw.ReadMsg()
 	if err != nil {
 		return err
 	}
+	// Any failure past reading the message is caused by its contents, except for
+	// replies failing to be sent on a closing connection
+	defer func() {
+		if err != nil && !p2p.IsDisconnectError(err) {
+			peer.ScoreInvalid()
+		}
+	}()
 	if msg.Size > maxMessageSize {
 		return fmt.Errorf("%w: %v > %v", errMsgTooLarge, msg.Size, maxMessageSize)
 	}
@@ -274,14 +303,25 @@ func ServiceGetAccountRangeQuery(chain *core.BlockChain, req *GetAccountRangePac
 	if req.Bytes > softResponseLimit {
 		req.Bytes = softResponseLimit
 	}
-	// Retrieve the requested state and bail out if non existent
-	tr, err := trie.New(trie.StateTrieID(req.Root), chain.TrieDB())
-	if err != nil {
-		return nil, nil
-	}
-	it, err := chain.Snapshots().AccountIterator(req.Root, req.Origin)
-	if err != nil {
-		return nil, nil
+	// Retrieve the requested state, falling back to reconstructing it from the
+	// state history if it's not live anymore. Bail out if neither works.
+	var (
+		tr *trie.Trie
+		it snapshot.AccountIterator
+	)
+	if historic, release := historicState(chain, req.Root); historic != nil {
+		defer release()
+
+		historicAccountServeMeter.Mark(1)
+		tr, it = historic.AccountTrie(), newHistoricIterator(historic.AccountTrie(), req.Origin, true)
+	} else {
+		var err error
+		if tr, err = trie.New(trie.StateTrieID(req.Root), chain.TrieDB()); err != nil {
+			return nil, nil
+		}
+		if it, err = chain.Snapshots().AccountIterator(req.Root, req.Origin); err != nil {
+			return nil, nil
+		}
 	}
 	// Iterate over the requested range and pile accounts up
 	var (
@@ -337,6 +377,13 @@ func ServiceGetStorageRangesQuery(chain *core.BlockChain, req *GetStorageRangesP
 	// Calculate the hard limit at which to abort, even if mid storage trie
 	hardLimit := uint64(float64(req.Bytes) * (1 + stateLookupSlack))
 
+	// Serve the ranges from the state history if the state is not live anymore
+	historic, release := historicState(chain, req.Root)
+	defer release()
+
+	if historic != nil {
+		historicStorageServeMeter.Mark(1)
+	}
 	// Retrieve storage ranges until the packet limit is reached
 	var (
 		slots  [][]*StorageData
@@ -359,9 +406,18 @@ func ServiceGetStorageRangesQuery(chain *core.BlockChain, req *GetStorageRangesP
 			limit, req.Limit = common.BytesToHash(req.Limit), nil
 		}
 		// Retrieve the requested state and bail out if non existent
-		it, err := chain.Snapshots().StorageIterator(req.Root, account, origin)
-		if err != nil {
-			return nil, nil
+		var it snapshot.StorageIterator
+		if historic != nil {
+			stTrie, err := historic.StorageTrie(account)
+			if err != nil {
+				return nil, nil
+			}
+			it = newHistoricIterator(stTrie, origin, false)
+		} else {
+			var err error
+			if it, err = chain.Snapshots().StorageIterator(req.Root, account, origin); err != nil {
+				return nil, nil
+			}
 		}
 		// Iterate over the requested range and pile slots up
 		var (
@@ -401,17 +457,8 @@ func ServiceGetStorageRangesQuery(chain *core.BlockChain, req *GetStorageRangesP
 		if origin != (common.Hash{}) || (abort && len(storage) > 0) {
 			// Request started at a non-zero hash or was capped prematurely, add
 			// the endpoint Merkle proofs
-			accTrie, err := trie.NewStateTrie(trie.StateTrieID(req.Root), chain.TrieDB())
-			if err != nil {
-				return nil, nil
-			}
-			acc, err := accTrie.GetAccountByHash(account)
-			if err != nil || acc == nil {
-				return nil, nil
-			}
-			id := trie.StorageTrieID(req.Root, account, acc.Root)
-			stTrie, err := trie.NewStateTrie(id, chain.TrieDB())
-			if err != nil {
+			stTrie, err := storageTrie(chain, historic, req.Root, account)
+			if err != nil || stTrie == nil {
 				return nil, nil
 			}
 			proof := trienode.NewProofSet()
@@ -435,6 +482,24 @@ func ServiceGetStorageRangesQuery(chain *core.BlockChain, req *GetStorageRangesP
 	return slots, proofs
 }
 
+// storageTrie returns the storage trie of an account to prove storage ranges
+// with, either from the live state or from the reconstructed historic one. Nil
+// is returned if the account doesn't exist.
+func storageTrie(chain *core.BlockChain, historic *pathdb.HistoricState, root common.Hash, account common.Hash) (*trie.Trie, error) {
+	if historic != nil {
+		return historic.StorageTrie(account)
+	}
+	accTrie, err := trie.NewStateTrie(trie.StateTrieID(root), chain.TrieDB())
+	if err != nil {
+		return nil, err
+	}
+	acc, err := accTrie.GetAccountByHash(account)
+	if err != nil || acc == nil {
+		return nil, err
+	}
+	return trie.New(trie.StorageTrieID(root, account, acc.Root), chain.TrieDB())
+}
+
 // ServiceGetByteCodesQuery assembles the response to a byte codes query.
 // It is exposed to allow external packages to test protocol behavior.
 func ServiceGetByteCodesQuery(chain *core.BlockChain, req *GetByteCodesPacket) [][]byte {
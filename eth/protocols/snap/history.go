@@ -0,0 +1,205 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"sync"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/lru"
+	"github.com/rajchain/go-rajchain/core"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/log"
+	"github.com/rajchain/go-rajchain/rlp"
+	"github.com/rajchain/go-rajchain/trie"
+	"github.com/rajchain/go-rajchain/triedb"
+	"github.com/rajchain/go-rajchain/triedb/pathdb"
+)
+
+const (
+	// DefaultHistoricDepth is the default maximum number of state histories to
+	// apply in reverse onto the persistent state, to serve ranges at an older
+	// root. It caps how far behind a syncing peer can lag without restarting
+	// healing, and how much work a single request can trigger.
+	DefaultHistoricDepth = 32
+
+	// historicCacheSize is the number of reconstructed states to keep around,
+	// since a lagging peer requests a lot of ranges at the same root.
+	historicCacheSize = 4
+
+	// maxHistoricBuilds is the maximum number of states reconstructed at the
+	// same time. Requests for further roots are not served until one finishes.
+	maxHistoricBuilds = 1
+)
+
+// historicStates caches the states reconstructed from the state history. The
+// cache is shared by all peers.
+var historicStates = &historicCache{
+	depth:    DefaultHistoricDepth,
+	states:   lru.NewBasicLRU[common.Hash, *historicEntry](historicCacheSize),
+	building: make(map[common.Hash]struct{}),
+}
+
+// SetHistoricDepth sets the maximum number of state histories applied to serve
+// ranges at roots below the persistent state. Zero disables serving them.
+func SetHistoricDepth(depth uint64) {
+	historicStates.lock.Lock()
+	defer historicStates.lock.Unlock()
+
+	historicStates.depth = depth
+}
+
+// historicEntry is a reconstructed state along with the database it belongs to.
+// The state is not thread-safe, access is serialized by the entry lock.
+type historicEntry struct {
+	db    *triedb.Database
+	state *pathdb.HistoricState
+	lock  sync.Mutex
+}
+
+// historicCache is a set of states reconstructed from the state history.
+type historicCache struct {
+	depth    uint64                                    // Maximum number of histories to apply
+	states   lru.BasicLRU[common.Hash, *historicEntry] // Reconstructed states ready to serve
+	building map[common.Hash]struct{}                  // Roots currently being reconstructed
+	lock     sync.Mutex                                // Lock protecting the fields above
+}
+
+// historicState returns the state with the given root reconstructed from the
+// state history, or nil if the state is live or not reconstructed yet. Unless
+// nil, the state is locked for exclusive access until the returned function is
+// invoked.
+//
+// States missing from the cache are reconstructed in the background, so that
+// the request handlers never block on replaying histories. Requests at the
+// root are served once the state is ready.
+func historicState(chain *core.BlockChain, root common.Hash) (*pathdb.HistoricState, func()) {
+	db := chain.TrieDB()
+	if _, err := db.NodeReader(root); err == nil {
+		return nil, func() {}
+	}
+	c := historicStates
+	c.lock.Lock()
+	if entry, ok := c.states.Get(root); ok && entry.db == db {
+		c.lock.Unlock()
+
+		entry.lock.Lock()
+		if !entry.state.Stale() {
+			return entry.state, entry.lock.Unlock
+		}
+		entry.lock.Unlock()
+
+		c.lock.Lock()
+		if cur, ok := c.states.Peek(root); ok && cur == entry {
+			c.states.Remove(root)
+		}
+	}
+	defer c.lock.Unlock()
+
+	historicMissMeter.Mark(1)
+	if _, ok := c.building[root]; ok || c.depth == 0 || len(c.building) >= maxHistoricBuilds {
+		return nil, func() {}
+	}
+	c.building[root] = struct{}{}
+	go c.build(db, root, c.depth)
+
+	return nil, func() {}
+}
+
+// build reconstructs the state with the given root and adds it to the cache.
+func (c *historicCache) build(db *triedb.Database, root common.Hash, depth uint64) {
+	state, err := db.HistoricState(root, depth)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.building, root)
+	if err != nil {
+		log.Trace("Failed to reconstruct historic state", "root", root, "err", err)
+		return
+	}
+	c.states.Add(root, &historicEntry{db: db, state: state})
+}
+
+// historicIterator is an iterator over the leaves of a reconstructed account
+// or storage trie, implementing the snapshot iterator interfaces.
+type historicIterator struct {
+	it      *trie.Iterator
+	account bool // Whether leaves are accounts, which are returned in slim format
+	value   []byte
+	err     error
+}
+
+// newHistoricIterator creates an iterator over the leaves of the given trie,
+// starting at origin. A nil trie is treated as an empty one.
+func newHistoricIterator(tr *trie.Trie, origin common.Hash, account bool) *historicIterator {
+	it := &historicIterator{account: account}
+	if tr == nil {
+		return it
+	}
+	nodeIt, err := tr.NodeIterator(origin[:])
+	if err != nil {
+		it.err = err
+		return it
+	}
+	it.it = trie.NewIterator(nodeIt)
+	return it
+}
+
+// Next steps the iterator forward one element, returning false if exhausted.
+func (it *historicIterator) Next() bool {
+	if it.it == nil || it.err != nil {
+		return false
+	}
+	if !it.it.Next() {
+		it.err = it.it.Err
+		return false
+	}
+	it.value = it.it.Value
+	if it.account {
+		var acc types.StateAccount
+		if err := rlp.DecodeBytes(it.it.Value, &acc); err != nil {
+			it.err = err
+			return false
+		}
+		it.value = types.SlimAccountRLP(acc)
+	}
+	return true
+}
+
+// Error returns any failure that occurred during iteration.
+func (it *historicIterator) Error() error {
+	return it.err
+}
+
+// Hash returns the hash of the account or storage slot the iterator is at.
+func (it *historicIterator) Hash() common.Hash {
+	return common.BytesToHash(it.it.Key)
+}
+
+// Account returns the slim RLP encoded account the iterator is at.
+func (it *historicIterator) Account() []byte {
+	return it.value
+}
+
+// Slot returns the RLP encoded storage slot the iterator is at.
+func (it *historicIterator) Slot() []byte {
+	return it.value
+}
+
+// Release is a noop, the iterated trie is held in memory.
+func (it *historicIterator) Release() {}
@@ -0,0 +1,197 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/consensus/ethash"
+	"github.com/rajchain/go-rajchain/core"
+	"github.com/rajchain/go-rajchain/core/rawdb"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/core/vm"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/ethdb"
+	"github.com/rajchain/go-rajchain/params"
+	"github.com/rajchain/go-rajchain/trie"
+	"github.com/rajchain/go-rajchain/trie/trienode"
+)
+
+// Tests that account and storage ranges are served at roots below the
+// persistent state, reconstructed from the state history, and that they
+// can be verified against the historic root.
+func TestServeHistoricRanges(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address  = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+		gspec    = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				address: {Balance: big.NewInt(params.Ether)},
+				// NUMBER DUP1 SSTORE: stores the block number at its own slot
+				contract: {Balance: common.Big0, Code: common.FromHex("43805500")},
+			},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 160, func(i int, gen *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(address), contract, big.NewInt(1), 100000, gen.BaseFee(), nil), signer, key)
+		gen.AddTx(tx)
+		tx, _ = types.SignTx(types.NewTransaction(gen.TxNonce(address), common.Address{byte(i)}, big.NewInt(1), params.TxGas, gen.BaseFee(), nil), signer, key)
+		gen.AddTx(tx)
+	})
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), "", "", false)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	chain, err := core.NewBlockChain(db, core.DefaultCacheConfigWithScheme(rawdb.PathScheme), gspec, nil, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("Failed to import chain: %v", err)
+	}
+	root := blocks[10].Root()
+	if _, err := trie.New(trie.StateTrieID(root), chain.TrieDB()); err == nil {
+		t.Fatalf("State %x is unexpectedly live", root)
+	}
+	// The state is reconstructed in the background, nothing is served until
+	// it's ready
+	SetHistoricDepth(uint64(len(blocks)))
+	defer SetHistoricDepth(DefaultHistoricDepth)
+
+	if accounts, _ := ServiceGetAccountRangeQuery(chain, &GetAccountRangePacket{Root: root, Limit: common.MaxHash, Bytes: 100}); len(accounts) != 0 {
+		t.Fatalf("Account range served before the state was reconstructed")
+	}
+	waitHistoricState(t, chain, root)
+
+	// Request a capped account range, which must be proven
+	accounts, proof := ServiceGetAccountRangeQuery(chain, &GetAccountRangePacket{
+		Root:  root,
+		Limit: common.MaxHash,
+		Bytes: 100,
+	})
+	if len(accounts) == 0 || len(proof) == 0 {
+		t.Fatalf("No account range served: %d accounts, %d proof nodes", len(accounts), len(proof))
+	}
+	hashes, values, err := (&AccountRangePacket{Accounts: accounts}).Unpack()
+	if err != nil {
+		t.Fatalf("Failed to unpack accounts: %v", err)
+	}
+	keys := make([][]byte, len(hashes))
+	for i, hash := range hashes {
+		keys[i] = common.CopyBytes(hash[:])
+	}
+	if _, err := trie.VerifyRangeProof(root, common.Hash{}.Bytes(), keys, values, proofSet(proof)); err != nil {
+		t.Fatalf("Invalid account range: %v", err)
+	}
+	// Request a storage range of the contract from a non-zero origin
+	var (
+		contractHash = crypto.Keccak256Hash(contract.Bytes())
+		storageRoot  common.Hash
+	)
+	historic, release := historicState(chain, root)
+	if historic == nil {
+		t.Fatal("Failed to reconstruct historic state")
+	}
+	storage, err := historic.StorageTrie(contractHash)
+	if err != nil || storage == nil {
+		t.Fatalf("Failed to reconstruct storage: %v", err)
+	}
+	storageRoot = storage.Hash()
+	release()
+
+	origin := common.Hash{0x01}
+	slots, proof := ServiceGetStorageRangesQuery(chain, &GetStorageRangesPacket{
+		Root:     root,
+		Accounts: []common.Hash{contractHash},
+		Origin:   origin[:],
+		Bytes:    softResponseLimit,
+	})
+	if len(slots) != 1 || len(proof) == 0 {
+		t.Fatalf("No storage range served: %d ranges, %d proof nodes", len(slots), len(proof))
+	}
+	keys, values = make([][]byte, len(slots[0])), make([][]byte, len(slots[0]))
+	for i, slot := range slots[0] {
+		keys[i], values[i] = common.CopyBytes(slot.Hash[:]), slot.Body
+	}
+	if _, err := trie.VerifyRangeProof(storageRoot, origin[:], keys, values, proofSet(proof)); err != nil {
+		t.Fatalf("Invalid storage range: %v", err)
+	}
+	// The slots stored past the historic root must not be served
+	if got, want := len(slots[0]), int(blocks[10].NumberU64()); got > want {
+		t.Fatalf("Too many slots served: have %d, want at most %d", got, want)
+	}
+}
+
+// waitHistoricState waits until the state with the given root is reconstructed
+// in the background.
+func waitHistoricState(t *testing.T, chain *core.BlockChain, root common.Hash) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if historic, release := historicState(chain, root); historic != nil {
+			release()
+			return
+		}
+	}
+	t.Fatalf("State %x not reconstructed in time", root)
+}
+
+// Tests that only a bounded number of states are reconstructed at the same
+// time, and not beyond the configured depth.
+func TestHistoricStateLimits(t *testing.T) {
+	c := historicStates
+	c.lock.Lock()
+	c.building[common.Hash{0x01}] = struct{}{}
+	c.lock.Unlock()
+	defer func() {
+		c.lock.Lock()
+		delete(c.building, common.Hash{0x01})
+		c.lock.Unlock()
+	}()
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), core.DefaultCacheConfigWithScheme(rawdb.PathScheme), &core.Genesis{Config: params.TestChainConfig}, nil, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if historic, _ := historicState(chain, common.Hash{0x02}); historic != nil {
+		t.Fatal("Historic state served while reconstruction is busy")
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.building[common.Hash{0x02}]; ok {
+		t.Fatal("Reconstruction started beyond the concurrency limit")
+	}
+}
+
+// proofSet assembles the proof nodes of a range response into a database.
+func proofSet(proof [][]byte) ethdb.KeyValueReader {
+	nodes := make(trienode.ProofList, len(proof))
+	for i, node := range proof {
+		nodes[i] = node
+	}
+	return nodes.Set()
+}
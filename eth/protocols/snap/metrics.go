@@ -66,4 +66,11 @@ var (
 	// discarded during the snap sync.
 	largeStorageDiscardGauge = metrics.NewRegisteredGauge("eth/protocols/snap/sync/storage/chunk/discard", nil)
 	largeStorageResumedGauge = metrics.NewRegisteredGauge("eth/protocols/snap/sync/storage/chunk/resume", nil)
+
+	// historicAccountServeMeter and historicStorageServeMeter track the number of
+	// ranges served from states reconstructed from the state history, whereas
+	// historicMissMeter tracks the requested states that couldn't be rebuilt.
+	historicAccountServeMeter = metrics.NewRegisteredMeter("eth/protocols/snap/serve/history/account", nil)
+	historicStorageServeMeter = metrics.NewRegisteredMeter("eth/protocols/snap/serve/history/storage", nil)
+	historicMissMeter         = metrics.NewRegisteredMeter("eth/protocols/snap/serve/history/miss", nil)
 )
@@ -401,6 +401,8 @@ type SyncProgress struct {
 type SyncPending struct {
 	TrienodeHeal uint64 // Number of state trie nodes pending
 	BytecodeHeal uint64 // Number of bytecodes pending
+
+	AccountCoverage float64 // Fraction of the account hash space downloaded
 }
 
 // SyncPeer abstracts out the methods required for a peer to be synced against
@@ -474,6 +476,7 @@ type Syncer struct {
 	storageBytes   common.StorageSize // Number of storage trie bytes persisted to disk
 
 	extProgress *SyncProgress // progress that can be exposed to external caller.
+	extCoverage float64       // account hash space coverage exposed to external caller.
 
 	// Request tracking during healing phase
 	trienodeHealIdlers map[string]struct{} // Peers that aren't serving trie node requests
@@ -711,6 +714,7 @@ func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
 			BytecodeHealSynced: s.bytecodeHealSynced,
 			BytecodeHealBytes:  s.bytecodeHealBytes,
 		}
+		s.extCoverage = s.accountCoverage()
 		s.lock.Unlock()
 		// Wait for something to happen
 		select {
@@ -930,6 +934,7 @@ func (s *Syncer) Progress() (*SyncProgress, *SyncPending) {
 		pending.TrienodeHeal = uint64(len(s.healer.trieTasks))
 		pending.BytecodeHeal = uint64(len(s.healer.codeTasks))
 	}
+	pending.AccountCoverage = s.extCoverage
 	return s.extProgress, pending
 }
 
@@ -3096,11 +3101,7 @@ func (s *Syncer) reportSyncProgress(force bool) {
 	if synced == 0 {
 		return
 	}
-	accountGaps := new(big.Int)
-	for _, task := range s.tasks {
-		accountGaps.Add(accountGaps, new(big.Int).Sub(task.Last.Big(), task.Next.Big()))
-	}
-	accountFills := new(big.Int).Sub(hashSpace, accountGaps)
+	accountFills := s.accountFills()
 	if accountFills.BitLen() == 0 {
 		return
 	}
@@ -3127,6 +3128,26 @@ func (s *Syncer) reportSyncProgress(force bool) {
 		"accounts", accounts, "slots", storage, "codes", bytecode, "eta", common.PrettyDuration(estTime-elapsed))
 }
 
+// accountFills returns the size of the account hash space already covered by
+// the downloaded account ranges.
+func (s *Syncer) accountFills() *big.Int {
+	accountGaps := new(big.Int)
+	for _, task := range s.tasks {
+		accountGaps.Add(accountGaps, new(big.Int).Sub(task.Last.Big(), task.Next.Big()))
+	}
+	return new(big.Int).Sub(hashSpace, accountGaps)
+}
+
+// accountCoverage returns the fraction of the account hash space already
+// covered by the downloaded account ranges.
+func (s *Syncer) accountCoverage() float64 {
+	if len(s.tasks) == 0 {
+		return 1
+	}
+	coverage, _ := new(big.Float).Quo(new(big.Float).SetInt(s.accountFills()), new(big.Float).SetInt(hashSpace)).Float64()
+	return coverage
+}
+
 // reportHealProgress calculates various status reports and provides it to the user.
 func (s *Syncer) reportHealProgress(force bool) {
 	// Don't report all the events, just occasionally
@@ -0,0 +1,63 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package syncer
+
+import (
+	"errors"
+
+	"github.com/rajchain/go-rajchain/common"
+)
+
+var errNotSigner = errors.New("node is not the checkpoint signer")
+
+// API exposes the checkpoint syncer over RPC.
+type API struct {
+	s *Syncer
+}
+
+// NewAPI creates the checkpoint API.
+func NewAPI(s *Syncer) *API {
+	return &API{s: s}
+}
+
+// Latest returns a checkpoint of the local chain head, signed with the signer
+// key. It is only available on the signer node, and is polled by the nodes
+// following the chain.
+func (api *API) Latest() (*Checkpoint, error) {
+	if api.s.config.Key == nil {
+		return nil, errNotSigner
+	}
+	chain := api.s.chain
+	return SignCheckpoint(chain.CurrentBlock(), chain.Config().ChainID, api.s.config.Key)
+}
+
+// Status is the progress of the checkpoint sync.
+type Status struct {
+	Signer  common.Address `json:"signer"`  // Address of the trusted signer
+	Target  *Checkpoint    `json:"target"`  // Latest checkpoint to sync to, nil if none
+	Reached bool           `json:"reached"` // Whether the local chain reached the target
+}
+
+// Status returns the checkpoint the node syncs to and whether it reached it.
+func (api *API) Status() *Status {
+	target, reached := api.s.status()
+	return &Status{
+		Signer:  api.s.config.Signer,
+		Target:  target,
+		Reached: reached,
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package syncer
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/crypto"
+)
+
+// checkpointDomain separates checkpoint signatures from any other signature
+// made with the signer key.
+var checkpointDomain = []byte("rajchain checkpoint")
+
+var (
+	errMissingSignature = errors.New("checkpoint signature missing")
+	errUntrustedSigner  = errors.New("checkpoint not signed by the trusted signer")
+)
+
+// Checkpoint is a block trusted by its hash and state root, signed by the
+// trusted signer of the chain. Checkpoints are loaded from a file to start the
+// sync and announced over RPC by the signer to follow the chain head.
+type Checkpoint struct {
+	Number    hexutil.Uint64 `json:"number"`
+	Hash      common.Hash    `json:"hash"`
+	Root      common.Hash    `json:"stateRoot"`
+	Signature hexutil.Bytes  `json:"signature"`
+}
+
+// SignCheckpoint creates a checkpoint of a header, signed with the given key.
+func SignCheckpoint(header *types.Header, chainID *big.Int, key *ecdsa.PrivateKey) (*Checkpoint, error) {
+	cp := &Checkpoint{
+		Number: hexutil.Uint64(header.Number.Uint64()),
+		Hash:   header.Hash(),
+		Root:   header.Root,
+	}
+	sig, err := crypto.Sign(cp.sigHash(chainID).Bytes(), key)
+	if err != nil {
+		return nil, err
+	}
+	cp.Signature = sig
+	return cp, nil
+}
+
+// LoadCheckpoint reads a JSON encoded checkpoint from a file.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cp := new(Checkpoint)
+	if err := json.Unmarshal(blob, cp); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint file %s: %v", path, err)
+	}
+	return cp, nil
+}
+
+// sigHash returns the hash signed by the signer. The chain ID is included to
+// prevent replaying checkpoints across chains sharing a signer.
+func (cp *Checkpoint) sigHash(chainID *big.Int) common.Hash {
+	var number [8]byte
+	binary.BigEndian.PutUint64(number[:], uint64(cp.Number))
+
+	return crypto.Keccak256Hash(checkpointDomain, common.BigToHash(chainID).Bytes(), number[:], cp.Hash.Bytes(), cp.Root.Bytes())
+}
+
+// Signer recovers the address of the account which signed the checkpoint.
+func (cp *Checkpoint) Signer(chainID *big.Int) (common.Address, error) {
+	if len(cp.Signature) == 0 {
+		return common.Address{}, errMissingSignature
+	}
+	pubkey, err := crypto.SigToPub(cp.sigHash(chainID).Bytes(), cp.Signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubkey), nil
+}
+
+// Verify checks that the checkpoint was signed by the trusted signer.
+func (cp *Checkpoint) Verify(chainID *big.Int, signer common.Address) error {
+	have, err := cp.Signer(chainID)
+	if err != nil {
+		return err
+	}
+	if have != signer {
+		return fmt.Errorf("%w: have %v, want %v", errUntrustedSigner, have, signer)
+	}
+	return nil
+}
+
+// verifyHeader checks that a header retrieved by the checkpoint hash matches
+// the signed number and state root.
+func (cp *Checkpoint) verifyHeader(header *types.Header) error {
+	if header.Hash() != cp.Hash {
+		return fmt.Errorf("checkpoint hash mismatch: have %v, want %v", header.Hash(), cp.Hash)
+	}
+	if header.Number.Uint64() != uint64(cp.Number) {
+		return fmt.Errorf("checkpoint number mismatch: have %d, want %d", header.Number, cp.Number)
+	}
+	if header.Root != cp.Root {
+		return fmt.Errorf("checkpoint state root mismatch: have %v, want %v", header.Root, cp.Root)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package syncer
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/crypto"
+)
+
+func TestCheckpointSignature(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		other, _ = crypto.GenerateKey()
+		signer   = crypto.PubkeyToAddress(key.PublicKey)
+		chainID  = big.NewInt(1337)
+		header   = &types.Header{Number: big.NewInt(42), Root: common.Hash{0x01}}
+	)
+	cp, err := SignCheckpoint(header, chainID, key)
+	if err != nil {
+		t.Fatalf("failed to sign checkpoint: %v", err)
+	}
+	if err := cp.Verify(chainID, signer); err != nil {
+		t.Fatalf("failed to verify checkpoint: %v", err)
+	}
+	if err := cp.verifyHeader(header); err != nil {
+		t.Fatalf("failed to verify checkpoint header: %v", err)
+	}
+	// Checkpoints of other signers or chains must be rejected
+	if err := cp.Verify(chainID, crypto.PubkeyToAddress(other.PublicKey)); err == nil {
+		t.Fatal("checkpoint verified against wrong signer")
+	}
+	if err := cp.Verify(big.NewInt(1), signer); err == nil {
+		t.Fatal("checkpoint verified on wrong chain")
+	}
+	// Tampering with any signed field must invalidate the signature
+	for name, tamper := range map[string]func(cp *Checkpoint){
+		"number": func(cp *Checkpoint) { cp.Number++ },
+		"hash":   func(cp *Checkpoint) { cp.Hash[0]++ },
+		"root":   func(cp *Checkpoint) { cp.Root[0]++ },
+	} {
+		tampered := *cp
+		tamper(&tampered)
+		if err := tampered.Verify(chainID, signer); err == nil {
+			t.Errorf("checkpoint with tampered %s verified", name)
+		}
+	}
+	unsigned := *cp
+	unsigned.Signature = nil
+	if err := unsigned.Verify(chainID, signer); err != errMissingSignature {
+		t.Fatalf("wrong error for unsigned checkpoint: have %v, want %v", err, errMissingSignature)
+	}
+	// Headers not matching the checkpoint must be rejected
+	if err := cp.verifyHeader(&types.Header{Number: big.NewInt(42), Root: common.Hash{0x02}}); err == nil {
+		t.Fatal("mismatching header verified")
+	}
+}
+
+func TestLoadCheckpoint(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	cp, err := SignCheckpoint(&types.Header{Number: big.NewInt(7), Root: common.Hash{0x07}}, big.NewInt(1), key)
+	if err != nil {
+		t.Fatalf("failed to sign checkpoint: %v", err)
+	}
+	blob, err := json.Marshal(cp)
+	if err != nil {
+		t.Fatalf("failed to encode checkpoint: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	if err := os.WriteFile(path, blob, 0600); err != nil {
+		t.Fatalf("failed to write checkpoint: %v", err)
+	}
+	loaded, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("failed to load checkpoint: %v", err)
+	}
+	if loaded.Number != cp.Number || loaded.Hash != cp.Hash || loaded.Root != cp.Root || string(loaded.Signature) != string(cp.Signature) {
+		t.Fatalf("loaded checkpoint mismatch: have %+v, want %+v", loaded, cp)
+	}
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatalf("failed to write checkpoint: %v", err)
+	}
+	if _, err := LoadCheckpoint(path); err == nil {
+		t.Fatal("invalid checkpoint file loaded")
+	}
+}
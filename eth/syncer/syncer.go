@@ -0,0 +1,349 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package syncer implements chain synchronization from trusted checkpoints,
+// for post-merge chains without a consensus client.
+//
+// A checkpoint is a block hash and state root signed by the trusted signer of
+// the chain, typically its sequencer. The syncer retrieves the header of the
+// checkpoint from the eth peers, checks it against the signed state root and
+// drives the beacon sync toward it. The signer node serves checkpoints of its
+// chain head over RPC, which the syncer polls to keep following the head.
+package syncer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/core"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/eth/downloader"
+	"github.com/rajchain/go-rajchain/log"
+	"github.com/rajchain/go-rajchain/node"
+	"github.com/rajchain/go-rajchain/params"
+	"github.com/rajchain/go-rajchain/rpc"
+)
+
+const (
+	// syncInterval is the time between polls of the trusted RPC endpoint for
+	// new checkpoints, and between attempts to sync to the latest checkpoint.
+	syncInterval = 2 * time.Second
+
+	// announceTimeout is the maximum time to wait for the trusted RPC endpoint
+	// to announce a checkpoint.
+	announceTimeout = 10 * time.Second
+)
+
+// Backend is the node functionality needed by the checkpoint syncer.
+type Backend interface {
+	BlockChain() *core.BlockChain
+	Downloader() *downloader.Downloader
+	SyncMode() downloader.SyncMode
+	SetSynced()
+}
+
+// Config contains the settings of the checkpoint syncer.
+type Config struct {
+	Signer common.Address    // Account trusted to sign checkpoints
+	File   string            // Path of the checkpoint to start the sync from, optional
+	URL    string            // Trusted RPC endpoint announcing checkpoints of the head, optional
+	Key    *ecdsa.PrivateKey // Key signing checkpoints of the local head, set on the signer node
+}
+
+// blockChain is the local chain functionality needed by the syncer.
+type blockChain interface {
+	Config() *params.ChainConfig
+	CurrentBlock() *types.Header
+	GetBlockByHash(hash common.Hash) *types.Block
+	GetCanonicalHash(number uint64) common.Hash
+	HasState(root common.Hash) bool
+	SetCanonical(head *types.Block) (common.Hash, error)
+}
+
+// beaconSyncer is the downloader functionality needed by the syncer.
+type beaconSyncer interface {
+	RetrieveHeader(hash common.Hash, stop chan struct{}) (*types.Header, error)
+	BeaconSync(mode downloader.SyncMode, head *types.Header, final *types.Header) error
+	BeaconExtend(mode downloader.SyncMode, head *types.Header) error
+}
+
+// announcer retrieves the latest checkpoint announced by the trusted signer.
+type announcer func(ctx context.Context) (*Checkpoint, error)
+
+// Syncer is the checkpoint sync service.
+type Syncer struct {
+	config    Config
+	chainID   *big.Int
+	chain     blockChain
+	sync      beaconSyncer
+	mode      func() downloader.SyncMode
+	setSynced func()
+	announce  announcer
+	interval  time.Duration
+
+	lock      sync.Mutex
+	target    *Checkpoint // Latest verified checkpoint to sync to
+	requested common.Hash // Checkpoint hash last handed to the downloader
+	reached   bool        // Whether the local chain reached the target
+	synced    bool        // Whether the local chain reached any target yet
+
+	closed chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New creates the checkpoint syncer and registers it, along with its RPC API,
+// with the node.
+func New(stack *node.Node, backend Backend, config Config) (*Syncer, error) {
+	s, err := newSyncer(config, backend.BlockChain(), backend.Downloader(), backend.SyncMode, backend.SetSynced)
+	if err != nil {
+		return nil, err
+	}
+	stack.RegisterAPIs([]rpc.API{{
+		Namespace: "checkpoint",
+		Service:   NewAPI(s),
+	}})
+	stack.RegisterLifecycle(s)
+	return s, nil
+}
+
+func newSyncer(config Config, chain blockChain, sync beaconSyncer, mode func() downloader.SyncMode, synced func()) (*Syncer, error) {
+	if config.Key != nil {
+		signer := crypto.PubkeyToAddress(config.Key.PublicKey)
+		if config.Signer == (common.Address{}) {
+			config.Signer = signer
+		}
+		if config.Signer != signer {
+			return nil, fmt.Errorf("checkpoint key of %v does not belong to the trusted signer %v", signer, config.Signer)
+		}
+	}
+	if config.Signer == (common.Address{}) {
+		return nil, errors.New("trusted checkpoint signer not specified")
+	}
+	s := &Syncer{
+		config:    config,
+		chainID:   chain.Config().ChainID,
+		chain:     chain,
+		sync:      sync,
+		mode:      mode,
+		setSynced: synced,
+		interval:  syncInterval,
+		closed:    make(chan struct{}),
+	}
+	if config.File != "" {
+		cp, err := LoadCheckpoint(config.File)
+		if err != nil {
+			return nil, err
+		}
+		if err := cp.Verify(s.chainID, config.Signer); err != nil {
+			return nil, fmt.Errorf("invalid checkpoint %s: %w", config.File, err)
+		}
+		s.target = cp
+	}
+	return s, nil
+}
+
+// Start implements node.Lifecycle, starting the sync loop if there is a
+// checkpoint to sync to or a trusted endpoint to follow.
+func (s *Syncer) Start() error {
+	if s.config.URL != "" {
+		client, err := rpc.Dial(s.config.URL)
+		if err != nil {
+			return fmt.Errorf("failed to connect to trusted checkpoint endpoint: %v", err)
+		}
+		s.announce = rpcAnnouncer(client)
+	}
+	if s.target == nil && s.announce == nil {
+		// Signer node only serving checkpoints
+		return nil
+	}
+	s.wg.Add(1)
+	go s.loop()
+
+	log.Info("Started checkpoint sync", "signer", s.config.Signer, "file", s.config.File, "url", s.config.URL)
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the sync loop.
+func (s *Syncer) Stop() error {
+	close(s.closed)
+	s.wg.Wait()
+	return nil
+}
+
+// rpcAnnouncer retrieves the checkpoints announced by a trusted RPC endpoint.
+func rpcAnnouncer(client *rpc.Client) announcer {
+	return func(ctx context.Context) (*Checkpoint, error) {
+		var cp *Checkpoint
+		if err := client.CallContext(ctx, &cp, "checkpoint_latest"); err != nil {
+			return nil, err
+		}
+		if cp == nil {
+			return nil, errors.New("no checkpoint announced")
+		}
+		return cp, nil
+	}
+}
+
+// loop polls the trusted endpoint for new checkpoints and syncs toward the
+// latest one, until the syncer is stopped.
+func (s *Syncer) loop() {
+	defer s.wg.Done()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if s.announce != nil {
+				s.poll()
+			}
+			s.step()
+			timer.Reset(s.interval)
+
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+// poll retrieves the latest checkpoint from the trusted endpoint.
+func (s *Syncer) poll() {
+	ctx, cancel := context.WithTimeout(context.Background(), announceTimeout)
+	defer cancel()
+
+	cp, err := s.announce(ctx)
+	if err != nil {
+		log.Warn("Failed to retrieve announced checkpoint", "url", s.config.URL, "err", err)
+		return
+	}
+	s.track(cp)
+}
+
+// track sets a checkpoint as the sync target if it is signed by the trusted
+// signer and newer than the current target. It reports whether the target was
+// updated.
+func (s *Syncer) track(cp *Checkpoint) bool {
+	if err := cp.Verify(s.chainID, s.config.Signer); err != nil {
+		log.Warn("Rejected untrusted checkpoint", "number", uint64(cp.Number), "hash", cp.Hash, "err", err)
+		return false
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.target != nil && (cp.Hash == s.target.Hash || cp.Number < s.target.Number) {
+		return false
+	}
+	log.Debug("New checkpoint announced", "number", uint64(cp.Number), "hash", cp.Hash)
+	s.target, s.reached = cp, false
+	return true
+}
+
+// step moves the sync toward the current target: it marks the node synced if
+// the local chain reached the target, and hands the target header over to the
+// beacon sync otherwise.
+func (s *Syncer) step() {
+	s.lock.Lock()
+	target, requested := s.target, s.requested
+	s.lock.Unlock()
+
+	if target == nil {
+		return
+	}
+	if s.reach(target) {
+		return
+	}
+	if target.Hash == requested {
+		return // Downloader is working on it
+	}
+	header, err := s.sync.RetrieveHeader(target.Hash, s.closed)
+	if err != nil {
+		log.Debug("Checkpoint header unavailable", "number", uint64(target.Number), "hash", target.Hash, "err", err)
+		return
+	}
+	if err := target.verifyHeader(header); err != nil {
+		log.Error("Rejected inconsistent checkpoint", "number", uint64(target.Number), "hash", target.Hash, "err", err)
+		s.lock.Lock()
+		if s.target == target {
+			s.target = nil
+		}
+		s.lock.Unlock()
+		return
+	}
+	// The first checkpoint is signed as final, use it to limit the freezer. The
+	// later ones extend the sync, or restart it if the signer reorged.
+	mode := s.mode()
+	if requested == (common.Hash{}) {
+		log.Info("Syncing to trusted checkpoint", "number", header.Number, "hash", header.Hash(), "root", header.Root)
+		err = s.sync.BeaconSync(mode, header, header)
+	} else if err = s.sync.BeaconExtend(mode, header); err != nil {
+		log.Debug("Restarting sync to checkpoint", "number", header.Number, "hash", header.Hash(), "err", err)
+		err = s.sync.BeaconSync(mode, header, nil)
+	}
+	if err != nil {
+		log.Warn("Failed to sync to checkpoint", "number", header.Number, "hash", header.Hash(), "err", err)
+		return
+	}
+	s.lock.Lock()
+	s.requested = target.Hash
+	s.lock.Unlock()
+}
+
+// reach checks whether the local chain reached a checkpoint, setting it as the
+// head if the block is known but not canonical, e.g. after the signer reorged.
+func (s *Syncer) reach(target *Checkpoint) bool {
+	block := s.chain.GetBlockByHash(target.Hash)
+	if block == nil || !s.chain.HasState(block.Root()) {
+		return false
+	}
+	if s.chain.GetCanonicalHash(block.NumberU64()) != target.Hash {
+		if _, err := s.chain.SetCanonical(block); err != nil {
+			log.Warn("Failed to set checkpoint as head", "number", block.NumberU64(), "hash", target.Hash, "err", err)
+			return false
+		}
+	}
+	if s.chain.CurrentBlock().Number.Uint64() < block.NumberU64() {
+		return false
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.reached && s.target == target {
+		if !s.synced {
+			log.Info("Reached trusted checkpoint", "number", block.NumberU64(), "hash", target.Hash)
+		} else {
+			log.Debug("Reached announced checkpoint", "number", block.NumberU64(), "hash", target.Hash)
+		}
+		s.reached, s.synced = true, true
+		s.setSynced()
+	}
+	return true
+}
+
+// status returns the current sync target and whether the chain reached it.
+func (s *Syncer) status() (*Checkpoint, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.target, s.reached
+}
@@ -0,0 +1,391 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package syncer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/eth/downloader"
+	"github.com/rajchain/go-rajchain/params"
+	"github.com/rajchain/go-rajchain/rpc"
+)
+
+// testChain is a mock local chain, where blocks are imported by hand.
+type testChain struct {
+	lock   sync.Mutex
+	blocks map[common.Hash]*types.Block
+	canon  map[uint64]common.Hash
+	head   *types.Header
+}
+
+func newTestChain() *testChain {
+	genesis := types.NewBlockWithHeader(&types.Header{Number: new(big.Int)})
+	return &testChain{
+		blocks: map[common.Hash]*types.Block{genesis.Hash(): genesis},
+		canon:  map[uint64]common.Hash{0: genesis.Hash()},
+		head:   genesis.Header(),
+	}
+}
+
+func (c *testChain) Config() *params.ChainConfig { return params.TestChainConfig }
+
+func (c *testChain) CurrentBlock() *types.Header {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.head
+}
+
+func (c *testChain) GetBlockByHash(hash common.Hash) *types.Block {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.blocks[hash]
+}
+
+func (c *testChain) GetCanonicalHash(number uint64) common.Hash {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.canon[number]
+}
+
+func (c *testChain) HasState(root common.Hash) bool { return true }
+
+func (c *testChain) SetCanonical(head *types.Block) (common.Hash, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for number := range c.canon {
+		if number > head.NumberU64() {
+			delete(c.canon, number)
+		}
+	}
+	c.canon[head.NumberU64()] = head.Hash()
+	c.head = head.Header()
+	return head.Hash(), nil
+}
+
+// insert imports blocks, setting the last one as the head.
+func (c *testChain) insert(headers ...*types.Header) {
+	for _, header := range headers {
+		block := types.NewBlockWithHeader(header)
+
+		c.lock.Lock()
+		c.blocks[block.Hash()] = block
+		c.lock.Unlock()
+
+		c.SetCanonical(block)
+	}
+}
+
+// testSyncCall is a beacon sync request handed to the mock downloader.
+type testSyncCall struct {
+	head   common.Hash
+	final  *common.Hash
+	extend bool
+}
+
+// testDownloader is a mock downloader serving headers as the network would.
+type testDownloader struct {
+	headers   map[common.Hash]*types.Header
+	calls     []testSyncCall
+	extendErr error
+}
+
+func (d *testDownloader) RetrieveHeader(hash common.Hash, stop chan struct{}) (*types.Header, error) {
+	if header := d.headers[hash]; header != nil {
+		return header, nil
+	}
+	return nil, errors.New("unavailable")
+}
+
+func (d *testDownloader) BeaconSync(mode downloader.SyncMode, head *types.Header, final *types.Header) error {
+	call := testSyncCall{head: head.Hash()}
+	if final != nil {
+		hash := final.Hash()
+		call.final = &hash
+	}
+	d.calls = append(d.calls, call)
+	return nil
+}
+
+func (d *testDownloader) BeaconExtend(mode downloader.SyncMode, head *types.Header) error {
+	if d.extendErr != nil {
+		return d.extendErr
+	}
+	d.calls = append(d.calls, testSyncCall{head: head.Hash(), extend: true})
+	return nil
+}
+
+// makeHeaders creates a chain of headers on top of a parent, with the seed
+// distinguishing forks.
+func makeHeaders(parent *types.Header, n int, seed byte) []*types.Header {
+	headers := make([]*types.Header, n)
+	for i := range headers {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+			Root:       common.Hash{seed, byte(i)},
+		}
+		headers[i], parent = header, header
+	}
+	return headers
+}
+
+// testSyncer is a checkpoint syncer running against mocks.
+type testSyncer struct {
+	*Syncer
+	chain   *testChain
+	dl      *testDownloader
+	headers []*types.Header // Chain of the signer, headers[i] is block i+1
+	key     *ecdsa.PrivateKey
+	synced  int
+}
+
+func newTestSyncer(t *testing.T, config Config) *testSyncer {
+	ts := &testSyncer{
+		chain: newTestChain(),
+		dl:    &testDownloader{headers: make(map[common.Hash]*types.Header)},
+		key:   config.Key,
+	}
+	ts.headers = makeHeaders(ts.chain.CurrentBlock(), 10, 0)
+	for _, header := range ts.headers {
+		ts.dl.headers[header.Hash()] = header
+	}
+	s, err := newSyncer(config, ts.chain, ts.dl, func() downloader.SyncMode { return downloader.SnapSync }, func() { ts.synced++ })
+	if err != nil {
+		t.Fatalf("failed to create syncer: %v", err)
+	}
+	ts.Syncer = s
+	return ts
+}
+
+func signTestCheckpoint(t *testing.T, header *types.Header, key *ecdsa.PrivateKey) *Checkpoint {
+	cp, err := SignCheckpoint(header, params.TestChainConfig.ChainID, key)
+	if err != nil {
+		t.Fatalf("failed to sign checkpoint: %v", err)
+	}
+	return cp
+}
+
+func writeTestCheckpoint(t *testing.T, cp *Checkpoint) string {
+	blob, err := json.Marshal(cp)
+	if err != nil {
+		t.Fatalf("failed to encode checkpoint: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	if err := os.WriteFile(path, blob, 0600); err != nil {
+		t.Fatalf("failed to write checkpoint: %v", err)
+	}
+	return path
+}
+
+func (ts *testSyncer) checkCalls(t *testing.T, want ...testSyncCall) {
+	t.Helper()
+
+	if len(ts.dl.calls) != len(want) {
+		t.Fatalf("sync call count mismatch: have %d, want %d", len(ts.dl.calls), len(want))
+	}
+	for i, call := range ts.dl.calls {
+		if call.head != want[i].head || call.extend != want[i].extend || (call.final == nil) != (want[i].final == nil) {
+			t.Fatalf("sync call %d mismatch: have %+v, want %+v", i, call, want[i])
+		}
+		if call.final != nil && *call.final != *want[i].final {
+			t.Fatalf("sync call %d final mismatch: have %v, want %v", i, *call.final, *want[i].final)
+		}
+	}
+}
+
+// Tests that the syncer starts the beacon sync from a checkpoint file, marking
+// the node synced once the chain reaches it.
+func TestSyncerCheckpointFile(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+
+	// Sign the checkpoint with a chain generated the same way as the tester's
+	cp := signTestCheckpoint(t, makeHeaders(newTestChain().CurrentBlock(), 5, 0)[4], key)
+	ts := newTestSyncer(t, Config{Signer: signer, File: writeTestCheckpoint(t, cp)})
+
+	ts.step()
+	final := cp.Hash
+	ts.checkCalls(t, testSyncCall{head: cp.Hash, final: &final})
+
+	// The checkpoint is only handed to the downloader once
+	ts.step()
+	ts.checkCalls(t, testSyncCall{head: cp.Hash, final: &final})
+	if ts.synced != 0 {
+		t.Fatal("node synced before reaching the checkpoint")
+	}
+	// Reaching the checkpoint marks the node synced, once
+	ts.chain.insert(ts.headers[:5]...)
+	ts.step()
+	ts.step()
+	if ts.synced != 1 {
+		t.Fatalf("synced callback count mismatch: have %d, want 1", ts.synced)
+	}
+	if target, reached := ts.status(); target.Hash != cp.Hash || !reached {
+		t.Fatalf("wrong status: target %v, reached %v", target.Hash, reached)
+	}
+}
+
+// Tests that checkpoint files not signed by the trusted signer are rejected.
+func TestSyncerUntrustedFile(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		other, _ = crypto.GenerateKey()
+		header   = &types.Header{Number: big.NewInt(1)}
+	)
+	file := writeTestCheckpoint(t, signTestCheckpoint(t, header, other))
+	config := Config{Signer: crypto.PubkeyToAddress(key.PublicKey), File: file}
+	if _, err := newSyncer(config, newTestChain(), new(testDownloader), nil, nil); !errors.Is(err, errUntrustedSigner) {
+		t.Fatalf("wrong error for untrusted checkpoint: have %v, want %v", err, errUntrustedSigner)
+	}
+	// The signer key must belong to the trusted signer
+	config = Config{Signer: crypto.PubkeyToAddress(key.PublicKey), Key: other}
+	if _, err := newSyncer(config, newTestChain(), new(testDownloader), nil, nil); err == nil {
+		t.Fatal("syncer created with key of untrusted signer")
+	}
+	if _, err := newSyncer(Config{}, newTestChain(), new(testDownloader), nil, nil); err == nil {
+		t.Fatal("syncer created without trusted signer")
+	}
+}
+
+// Tests that the syncer follows the checkpoints announced by the signer,
+// ignoring untrusted and stale ones.
+func TestSyncerFollow(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		other, _ = crypto.GenerateKey()
+		ts       = newTestSyncer(t, Config{Signer: crypto.PubkeyToAddress(key.PublicKey)})
+	)
+	announce := func(header *types.Header, key *ecdsa.PrivateKey) {
+		ts.announce = func(ctx context.Context) (*Checkpoint, error) {
+			return signTestCheckpoint(t, header, key), nil
+		}
+		ts.poll()
+		ts.step()
+	}
+	first := ts.headers[2].Hash()
+	announce(ts.headers[2], key)
+	ts.checkCalls(t, testSyncCall{head: first, final: &first})
+
+	// Untrusted and stale announcements are ignored
+	announce(ts.headers[8], other)
+	announce(ts.headers[1], key)
+	ts.checkCalls(t, testSyncCall{head: first, final: &first})
+
+	// New announcements extend the sync
+	announce(ts.headers[5], key)
+	ts.checkCalls(t,
+		testSyncCall{head: first, final: &first},
+		testSyncCall{head: ts.headers[5].Hash(), extend: true},
+	)
+	// Announcements which can't extend the sync restart it
+	ts.dl.extendErr = errors.New("reorged")
+	announce(ts.headers[7], key)
+	ts.checkCalls(t,
+		testSyncCall{head: first, final: &first},
+		testSyncCall{head: ts.headers[5].Hash(), extend: true},
+		testSyncCall{head: ts.headers[7].Hash()},
+	)
+	// Reaching the latest announcement marks the node synced
+	ts.chain.insert(ts.headers[:8]...)
+	ts.step()
+	if ts.synced != 1 {
+		t.Fatalf("synced callback count mismatch: have %d, want 1", ts.synced)
+	}
+	// Announcing a block known locally on a side chain sets it as head
+	fork := makeHeaders(ts.headers[5], 3, 1)
+	ts.chain.lock.Lock()
+	for _, header := range fork {
+		ts.chain.blocks[header.Hash()] = types.NewBlockWithHeader(header)
+	}
+	ts.chain.lock.Unlock()
+
+	announce(fork[2], key)
+	if head := ts.chain.CurrentBlock(); head.Hash() != fork[2].Hash() {
+		t.Fatalf("head mismatch after reorg: have %v, want %v", head.Hash(), fork[2].Hash())
+	}
+	if ts.synced != 2 {
+		t.Fatalf("synced callback count mismatch: have %d, want 2", ts.synced)
+	}
+	if len(ts.dl.calls) != 3 {
+		t.Fatalf("known block handed to downloader")
+	}
+}
+
+// Tests that checkpoints signed with a state root not matching the block are
+// dropped.
+func TestSyncerInconsistentCheckpoint(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	ts := newTestSyncer(t, Config{Signer: crypto.PubkeyToAddress(key.PublicKey)})
+
+	cp := signTestCheckpoint(t, ts.headers[3], key)
+	cp.Root = common.Hash{0xff}
+	sig, _ := crypto.Sign(cp.sigHash(params.TestChainConfig.ChainID).Bytes(), key)
+	cp.Signature = sig
+
+	if !ts.track(cp) {
+		t.Fatal("signed checkpoint not tracked")
+	}
+	ts.step()
+	ts.checkCalls(t)
+	if target, _ := ts.status(); target != nil {
+		t.Fatal("inconsistent checkpoint kept as target")
+	}
+}
+
+// Tests that the signer serves checkpoints of its head over RPC, which are
+// accepted by the nodes following it.
+func TestSignerAPI(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := newTestSyncer(t, Config{Key: key})
+	signer.chain.insert(signer.headers[:4]...)
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("checkpoint", NewAPI(signer.Syncer)); err != nil {
+		t.Fatalf("failed to register API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	cp, err := rpcAnnouncer(client)(context.Background())
+	if err != nil {
+		t.Fatalf("failed to retrieve checkpoint: %v", err)
+	}
+	if cp.Hash != signer.headers[3].Hash() || cp.Root != signer.headers[3].Root {
+		t.Fatalf("checkpoint of wrong block served: have %v, want %v", cp.Hash, signer.headers[3].Hash())
+	}
+	follower := newTestSyncer(t, Config{Signer: crypto.PubkeyToAddress(key.PublicKey)})
+	if !follower.track(cp) {
+		t.Fatal("served checkpoint rejected")
+	}
+	// Nodes without the signer key don't serve checkpoints
+	if _, err := NewAPI(follower.Syncer).Latest(); err != errNotSigner {
+		t.Fatalf("wrong error for non-signer: have %v, want %v", err, errNotSigner)
+	}
+}
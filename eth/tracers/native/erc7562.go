@@ -0,0 +1,297 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/core/tracing"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/core/vm"
+	"github.com/rajchain/go-rajchain/eth/tracers"
+	"github.com/rajchain/go-rajchain/eth/tracers/internal"
+	"github.com/rajchain/go-rajchain/params"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("erc7562Tracer", newERC7562Tracer, false)
+}
+
+// erc7562MaxKeccakSize is the largest KECCAK256 input that is retained. The
+// preimages are only needed to detect mapping slots associated with an
+// address, so anything longer than a few words is not interesting.
+const erc7562MaxKeccakSize = 128
+
+// erc7562Opcodes is the set of opcodes whose use is tracked on behalf of the
+// ERC-7562 validation rules. Besides the banned opcodes, CREATE2 is tracked as
+// it is allowed once during the factory phase.
+var erc7562Opcodes = map[vm.OpCode]bool{
+	vm.GASPRICE:     true,
+	vm.GASLIMIT:     true,
+	vm.DIFFICULTY:   true,
+	vm.TIMESTAMP:    true,
+	vm.BASEFEE:      true,
+	vm.BLOCKHASH:    true,
+	vm.NUMBER:       true,
+	vm.SELFBALANCE:  true,
+	vm.BALANCE:      true,
+	vm.ORIGIN:       true,
+	vm.CREATE:       true,
+	vm.CREATE2:      true,
+	vm.COINBASE:     true,
+	vm.SELFDESTRUCT: true,
+	vm.BLOBHASH:     true,
+	vm.BLOBBASEFEE:  true,
+	vm.INVALID:      true,
+}
+
+// erc7562Phase contains the data collected for one validation phase of the
+// EntryPoint. Phases are delimited by the NUMBER opcode executed by the
+// EntryPoint itself, resulting in the factory, account and paymaster phases.
+type erc7562Phase struct {
+	Opcodes      map[string]int                          `json:"opcodes"`
+	Access       map[common.Address]*erc7562Access       `json:"access"`
+	ContractSize map[common.Address]*erc7562ContractSize `json:"contractSize"`
+	Calls        []*erc7562Call                          `json:"calls"`
+	OOG          bool                                    `json:"oog"`
+}
+
+// erc7562Access lists the storage slots read and written on a single account.
+type erc7562Access struct {
+	Reads  map[common.Hash]int `json:"reads"`
+	Writes map[common.Hash]int `json:"writes"`
+}
+
+// erc7562ContractSize records the code size of an account touched by a call
+// or EXTCODE* opcode, along with the opcode that touched it.
+type erc7562ContractSize struct {
+	Size   int    `json:"contractSize"`
+	Opcode string `json:"opcode"`
+}
+
+// erc7562Call is a call made from within a validation frame.
+type erc7562Call struct {
+	Type   string         `json:"type"`
+	From   common.Address `json:"from"`
+	To     common.Address `json:"to"`
+	Input  hexutil.Bytes  `json:"input"`
+	Value  *hexutil.Big   `json:"value,omitempty"`
+	Depth  int            `json:"depth"`
+	Revert bool           `json:"revert,omitempty"`
+}
+
+// erc7562Result is the output of the tracer.
+type erc7562Result struct {
+	Phases []*erc7562Phase `json:"phases"`
+	Keccak []hexutil.Bytes `json:"keccak"`
+}
+
+// erc7562Tracer collects the information needed by an ERC-4337 bundler to
+// enforce the ERC-7562 validation rules on a simulateValidation call made to
+// the EntryPoint. It records, per validation phase, the tracked opcodes, the
+// storage accessed, the calls made and the accounts whose code was touched.
+// Only frames below the EntryPoint (depth > 1) are accounted for.
+type erc7562Tracer struct {
+	env       *tracing.VMContext
+	phases    []*erc7562Phase
+	keccak    []hexutil.Bytes
+	lastOp    vm.OpCode
+	lastDepth int
+	calls     []*erc7562Call // stack of open calls, nil for untracked frames
+	interrupt atomic.Bool    // Atomic flag to signal execution interruption
+	reason    error          // Textual reason for the interruption
+}
+
+// newERC7562Tracer returns a native go tracer which collects the data needed
+// for ERC-7562 rule validation.
+func newERC7562Tracer(ctx *tracers.Context, cfg json.RawMessage, chainConfig *params.ChainConfig) (*tracers.Tracer, error) {
+	t := &erc7562Tracer{
+		phases: []*erc7562Phase{newERC7562Phase()},
+		keccak: []hexutil.Bytes{},
+	}
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: t.OnTxStart,
+			OnOpcode:  t.OnOpcode,
+			OnEnter:   t.OnEnter,
+			OnExit:    t.OnExit,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+func newERC7562Phase() *erc7562Phase {
+	return &erc7562Phase{
+		Opcodes:      make(map[string]int),
+		Access:       make(map[common.Address]*erc7562Access),
+		ContractSize: make(map[common.Address]*erc7562ContractSize),
+		Calls:        []*erc7562Call{},
+	}
+}
+
+// phase returns the validation phase currently being executed.
+func (t *erc7562Tracer) phase() *erc7562Phase {
+	return t.phases[len(t.phases)-1]
+}
+
+func (t *erc7562Tracer) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	t.env = env
+}
+
+// OnOpcode implements the EVMLogger interface to trace a single step of VM execution.
+func (t *erc7562Tracer) OnOpcode(pc uint64, opcode byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if err != nil || t.interrupt.Load() {
+		return
+	}
+	op := vm.OpCode(opcode)
+
+	// The EntryPoint itself marks phase boundaries with the NUMBER opcode
+	if depth == 1 {
+		if op == vm.NUMBER {
+			t.phases = append(t.phases, newERC7562Phase())
+		}
+		t.lastOp, t.lastDepth = op, depth
+		return
+	}
+	var (
+		phase    = t.phase()
+		stack    = scope.StackData()
+		stackLen = len(stack)
+		addr     = scope.Address()
+	)
+	// GAS is allowed only if immediately followed by a call
+	if t.lastOp == vm.GAS && t.lastDepth == depth && !isCallOp(op) {
+		phase.Opcodes[vm.GAS.String()]++
+	}
+	t.lastOp, t.lastDepth = op, depth
+
+	if erc7562Opcodes[op] {
+		phase.Opcodes[op.String()]++
+	}
+	switch {
+	case stackLen >= 1 && (op == vm.SLOAD || op == vm.SSTORE):
+		slot := common.Hash(stack[stackLen-1].Bytes32())
+		access := phase.Access[addr]
+		if access == nil {
+			access = &erc7562Access{Reads: make(map[common.Hash]int), Writes: make(map[common.Hash]int)}
+			phase.Access[addr] = access
+		}
+		if op == vm.SLOAD {
+			access.Reads[slot]++
+		} else {
+			access.Writes[slot]++
+		}
+	case stackLen >= 1 && (op == vm.EXTCODESIZE || op == vm.EXTCODECOPY || op == vm.EXTCODEHASH):
+		t.recordContractSize(common.Address(stack[stackLen-1].Bytes20()), op)
+	case stackLen >= 2 && op == vm.KECCAK256:
+		offset, size := stack[stackLen-1], stack[stackLen-2]
+		if size.Uint64() > erc7562MaxKeccakSize {
+			return
+		}
+		data, err := internal.GetMemoryCopyPadded(scope.MemoryData(), int64(offset.Uint64()), int64(size.Uint64()))
+		if err != nil {
+			return
+		}
+		t.keccak = append(t.keccak, data)
+	}
+}
+
+// OnEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *erc7562Tracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.interrupt.Load() {
+		return
+	}
+	// Calls made by the EntryPoint and above are not validation frames
+	if depth == 0 {
+		t.calls = append(t.calls, nil)
+		return
+	}
+	call := &erc7562Call{
+		Type:  vm.OpCode(typ).String(),
+		From:  from,
+		To:    to,
+		Input: common.CopyBytes(input),
+		Depth: depth,
+	}
+	if value != nil {
+		call.Value = (*hexutil.Big)(new(big.Int).Set(value))
+	}
+	t.calls = append(t.calls, call)
+
+	phase := t.phase()
+	phase.Calls = append(phase.Calls, call)
+	if op := vm.OpCode(typ); isCallOp(op) {
+		t.recordContractSize(to, op)
+	}
+}
+
+// OnExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *erc7562Tracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if t.interrupt.Load() || len(t.calls) == 0 {
+		return
+	}
+	call := t.calls[len(t.calls)-1]
+	t.calls = t.calls[:len(t.calls)-1]
+	if call == nil {
+		return
+	}
+	call.Revert = reverted
+	if errors.Is(err, vm.ErrOutOfGas) || errors.Is(err, vm.ErrCodeStoreOutOfGas) {
+		t.phase().OOG = true
+	}
+}
+
+// recordContractSize tracks the code size of an account accessed in the
+// current phase.
+func (t *erc7562Tracer) recordContractSize(addr common.Address, op vm.OpCode) {
+	phase := t.phase()
+	if _, ok := phase.ContractSize[addr]; ok || t.env == nil {
+		return
+	}
+	phase.ContractSize[addr] = &erc7562ContractSize{
+		Size:   len(t.env.StateDB.GetCode(addr)),
+		Opcode: op.String(),
+	}
+}
+
+// GetResult returns the json-encoded collected validation data, and any
+// error arising from the encoding or forceful termination (via `Stop`).
+func (t *erc7562Tracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(&erc7562Result{Phases: t.phases, Keccak: t.keccak})
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *erc7562Tracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
+
+// isCallOp returns whether op is one of the message call opcodes.
+func isCallOp(op vm.OpCode) bool {
+	return op == vm.CALL || op == vm.CALLCODE || op == vm.DELEGATECALL || op == vm.STATICCALL
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package native_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/core/state"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/core/vm"
+	"github.com/rajchain/go-rajchain/core/vm/program"
+	"github.com/rajchain/go-rajchain/core/vm/runtime"
+	"github.com/rajchain/go-rajchain/eth/tracers"
+	"github.com/rajchain/go-rajchain/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestERC7562Tracer(t *testing.T) {
+	var (
+		entry   = common.HexToAddress("0xe0")
+		account = common.HexToAddress("0xaa")
+		missing = common.HexToAddress("0xdead")
+	)
+	// The account reads the timestamp, one of its storage slots, hashes a word
+	// of memory and queries the code size of an account without code. It also
+	// uses GAS without a subsequent call, which is banned.
+	accountCode := program.New().
+		Op(vm.TIMESTAMP, vm.POP).
+		Push(0).Op(vm.SLOAD, vm.POP).
+		Mstore(common.LeftPadBytes(account.Bytes(), 32), 0).
+		Push(32).Push(0).Op(vm.KECCAK256, vm.POP).
+		Push(missing).Op(vm.EXTCODESIZE, vm.POP).
+		Op(vm.GAS, vm.POP).
+		Op(vm.STOP).Bytes()
+
+	// The entry point starts a new phase and calls the account twice
+	entryCode := program.New().
+		Op(vm.NUMBER, vm.POP).
+		Call(nil, account, 0, 0, 0, 0, 0).Op(vm.POP).
+		Op(vm.NUMBER, vm.POP).
+		Call(nil, account, 0, 0, 0, 0, 0).Op(vm.POP).
+		Op(vm.STOP).Bytes()
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	statedb.SetCode(entry, entryCode)
+	statedb.SetCode(account, accountCode)
+
+	tracer, err := tracers.DefaultDirectory.New("erc7562Tracer", &tracers.Context{}, nil, params.MainnetChainConfig)
+	require.NoError(t, err)
+
+	_, _, err = runtime.Call(entry, nil, &runtime.Config{
+		State:     statedb,
+		GasLimit:  1_000_000,
+		EVMConfig: vm.Config{Tracer: tracer.Hooks},
+	})
+	require.NoError(t, err)
+
+	out, err := tracer.GetResult()
+	require.NoError(t, err)
+
+	var res struct {
+		Phases []struct {
+			Opcodes map[string]int `json:"opcodes"`
+			Access  map[common.Address]struct {
+				Reads  map[common.Hash]int `json:"reads"`
+				Writes map[common.Hash]int `json:"writes"`
+			} `json:"access"`
+			ContractSize map[common.Address]struct {
+				Size   int    `json:"contractSize"`
+				Opcode string `json:"opcode"`
+			} `json:"contractSize"`
+			Calls []struct {
+				Type string         `json:"type"`
+				From common.Address `json:"from"`
+				To   common.Address `json:"to"`
+			} `json:"calls"`
+			OOG bool `json:"oog"`
+		} `json:"phases"`
+		Keccak []hexutil.Bytes `json:"keccak"`
+	}
+	require.NoError(t, json.Unmarshal(out, &res))
+
+	// The phase before the first NUMBER is empty, the other two identical
+	require.Len(t, res.Phases, 3)
+	require.Empty(t, res.Phases[0].Opcodes)
+	require.Empty(t, res.Phases[0].Calls)
+	for i, phase := range res.Phases[1:] {
+		require.Equal(t, map[string]int{"TIMESTAMP": 1, "GAS": 1}, phase.Opcodes, "phase %d", i+1)
+		require.Equal(t, 1, phase.Access[account].Reads[common.Hash{}], "phase %d", i+1)
+		require.Empty(t, phase.Access[account].Writes, "phase %d", i+1)
+
+		require.Len(t, phase.Calls, 1, "phase %d", i+1)
+		require.Equal(t, "CALL", phase.Calls[0].Type)
+		require.Equal(t, entry, phase.Calls[0].From)
+		require.Equal(t, account, phase.Calls[0].To)
+
+		require.Equal(t, len(accountCode), phase.ContractSize[account].Size)
+		require.Equal(t, 0, phase.ContractSize[missing].Size)
+		require.Equal(t, "EXTCODESIZE", phase.ContractSize[missing].Opcode)
+		require.False(t, phase.OOG)
+	}
+	require.Len(t, res.Keccak, 2)
+	require.Equal(t, hexutil.Bytes(common.LeftPadBytes(account.Bytes(), 32)), res.Keccak[0])
+}
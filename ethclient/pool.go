@@ -0,0 +1,685 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rajchain/go-rajchain"
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/event"
+	"github.com/rajchain/go-rajchain/log"
+	"github.com/rajchain/go-rajchain/rpc"
+)
+
+const (
+	defaultHealthCheckInterval = 5 * time.Second
+	defaultHealthCheckTimeout  = 5 * time.Second
+	defaultMaxBlockLag         = 2
+
+	// latencyWeight is the weight of a new latency sample in the moving average.
+	latencyWeight = 0.2
+)
+
+// errNoEndpoints is returned when creating a pool without endpoints.
+var errNoEndpoints = errors.New("no endpoints")
+
+// PoolConfig configures a client over multiple endpoints. The zero value, as
+// well as nil, selects the defaults.
+type PoolConfig struct {
+	// HealthCheckInterval is the time between checking the endpoints' heads.
+	// It is also the longest time waited between attempts to move a failed
+	// subscription to another endpoint.
+	HealthCheckInterval time.Duration
+
+	// HealthCheckTimeout is the time an endpoint has to answer a health check.
+	HealthCheckTimeout time.Duration
+
+	// MaxBlockLag is the number of blocks an endpoint may lag behind the
+	// highest head seen to still be considered in sync.
+	MaxBlockLag uint64
+}
+
+func (c *PoolConfig) withDefaults() PoolConfig {
+	var config PoolConfig
+	if c != nil {
+		config = *c
+	}
+	if config.HealthCheckInterval == 0 {
+		config.HealthCheckInterval = defaultHealthCheckInterval
+	}
+	if config.HealthCheckTimeout == 0 {
+		config.HealthCheckTimeout = defaultHealthCheckTimeout
+	}
+	if config.MaxBlockLag == 0 {
+		config.MaxBlockLag = defaultMaxBlockLag
+	}
+	return config
+}
+
+// EndpointStatus is the health of an endpoint of a pooled client.
+type EndpointStatus struct {
+	Name    string
+	Head    uint64        // Block number of the last head reported
+	Latency time.Duration // Moving average of the response times
+	Healthy bool          // Whether the endpoint answered the last request
+	InSync  bool          // Whether the endpoint's head is close to the highest head seen
+}
+
+// endpoint is a node of the pool.
+type endpoint struct {
+	name    string
+	client  *Client
+	head    uint64
+	latency time.Duration
+	healthy bool
+}
+
+// PoolClient provides the API of Client over multiple endpoints.
+//
+// Reads are sent to the healthy endpoint with the lowest latency whose head is
+// in sync, failing over to the next one on connection errors. Subscriptions
+// are established on the preferred endpoint and moved to the next healthy one
+// when it fails, delivering on the same channel. Transactions are sent to all
+// endpoints.
+type PoolClient struct {
+	config    PoolConfig
+	endpoints []*endpoint
+	lock      sync.RWMutex
+
+	closeOnce sync.Once
+	closeCh   chan struct{}
+	wg        sync.WaitGroup
+}
+
+// DialPool connects a client to several endpoints, routing its requests to
+// the healthy and in-sync ones.
+func DialPool(ctx context.Context, urls []string, config *PoolConfig) (*PoolClient, error) {
+	if len(urls) == 0 {
+		return nil, errNoEndpoints
+	}
+	clients := make([]*rpc.Client, 0, len(urls))
+	for _, url := range urls {
+		c, err := rpc.DialContext(ctx, url)
+		if err != nil {
+			for _, c := range clients {
+				c.Close()
+			}
+			return nil, fmt.Errorf("failed to dial %s: %w", url, err)
+		}
+		clients = append(clients, c)
+	}
+	return newPoolClient(clients, urls, config), nil
+}
+
+// NewPoolClient creates a client routing its requests over the given RPC
+// clients. The clients are closed when the pooled client is closed.
+func NewPoolClient(clients []*rpc.Client, config *PoolConfig) (*PoolClient, error) {
+	if len(clients) == 0 {
+		return nil, errNoEndpoints
+	}
+	names := make([]string, len(clients))
+	for i := range clients {
+		names[i] = fmt.Sprintf("#%d", i)
+	}
+	return newPoolClient(clients, names, config), nil
+}
+
+func newPoolClient(clients []*rpc.Client, names []string, config *PoolConfig) *PoolClient {
+	pc := &PoolClient{
+		config:  config.withDefaults(),
+		closeCh: make(chan struct{}),
+	}
+	for i, c := range clients {
+		pc.endpoints = append(pc.endpoints, &endpoint{name: names[i], client: NewClient(c), healthy: true})
+	}
+	pc.check()
+
+	pc.wg.Add(1)
+	go pc.loop()
+	return pc
+}
+
+// Close stops the health checks and closes the connections to all endpoints.
+func (pc *PoolClient) Close() {
+	pc.closeOnce.Do(func() {
+		close(pc.closeCh)
+		pc.wg.Wait()
+		for _, ep := range pc.endpoints {
+			ep.client.Close()
+		}
+	})
+}
+
+// Client gets the RPC client of the currently preferred endpoint.
+func (pc *PoolClient) Client() *rpc.Client {
+	return pc.candidates()[0].client.Client()
+}
+
+// Status returns the health of the endpoints.
+func (pc *PoolClient) Status() []EndpointStatus {
+	pc.lock.RLock()
+	defer pc.lock.RUnlock()
+
+	var highest uint64
+	for _, ep := range pc.endpoints {
+		if ep.healthy {
+			highest = max(highest, ep.head)
+		}
+	}
+	status := make([]EndpointStatus, len(pc.endpoints))
+	for i, ep := range pc.endpoints {
+		status[i] = EndpointStatus{
+			Name:    ep.name,
+			Head:    ep.head,
+			Latency: ep.latency,
+			Healthy: ep.healthy,
+			InSync:  ep.healthy && pc.inSync(ep, highest),
+		}
+	}
+	return status
+}
+
+// loop checks the health of the endpoints periodically.
+func (pc *PoolClient) loop() {
+	defer pc.wg.Done()
+
+	ticker := time.NewTicker(pc.config.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			pc.check()
+		case <-pc.closeCh:
+			return
+		}
+	}
+}
+
+// check queries the head of all endpoints, updating their health and latency.
+func (pc *PoolClient) check() {
+	var wg sync.WaitGroup
+	for _, ep := range pc.endpoints {
+		wg.Add(1)
+		go func(ep *endpoint) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), pc.config.HealthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			head, err := ep.client.BlockNumber(ctx)
+
+			pc.lock.Lock()
+			defer pc.lock.Unlock()
+
+			if err != nil {
+				if ep.healthy {
+					log.Debug("RPC endpoint unhealthy", "endpoint", ep.name, "err", err)
+				}
+				ep.healthy = false
+				return
+			}
+			ep.head = head
+			ep.healthy = true
+			pc.observe(ep, time.Since(start))
+		}(ep)
+	}
+	wg.Wait()
+}
+
+// observe folds a response time of an endpoint into its latency. The lock must
+// be held.
+func (pc *PoolClient) observe(ep *endpoint, elapsed time.Duration) {
+	if ep.latency == 0 {
+		ep.latency = elapsed
+	} else {
+		ep.latency = time.Duration((1-latencyWeight)*float64(ep.latency) + latencyWeight*float64(elapsed))
+	}
+}
+
+// inSync reports whether an endpoint's head is close to the highest head. The
+// lock must be held.
+func (pc *PoolClient) inSync(ep *endpoint, highest uint64) bool {
+	return ep.head+pc.config.MaxBlockLag >= highest
+}
+
+// candidates returns the endpoints in order of preference: healthy and in sync
+// first, then healthy ones, then the rest, each by latency.
+func (pc *PoolClient) candidates() []*endpoint {
+	pc.lock.RLock()
+	defer pc.lock.RUnlock()
+
+	var highest uint64
+	for _, ep := range pc.endpoints {
+		if ep.healthy {
+			highest = max(highest, ep.head)
+		}
+	}
+	rank := func(ep *endpoint) int {
+		switch {
+		case ep.healthy && pc.inSync(ep, highest):
+			return 0
+		case ep.healthy:
+			return 1
+		default:
+			return 2
+		}
+	}
+	eps := slices.Clone(pc.endpoints)
+	slices.SortStableFunc(eps, func(a, b *endpoint) int {
+		if ra, rb := rank(a), rank(b); ra != rb {
+			return ra - rb
+		}
+		return cmp.Compare(a.latency, b.latency)
+	})
+	return eps
+}
+
+// failed marks an endpoint unhealthy after a connection error.
+func (pc *PoolClient) failed(ep *endpoint, err error) {
+	pc.lock.Lock()
+	defer pc.lock.Unlock()
+
+	if ep.healthy {
+		log.Debug("RPC endpoint failed, failing over", "endpoint", ep.name, "err", err)
+	}
+	ep.healthy = false
+}
+
+// succeeded records the response time of a successful request.
+func (pc *PoolClient) succeeded(ep *endpoint, elapsed time.Duration) {
+	pc.lock.Lock()
+	defer pc.lock.Unlock()
+
+	ep.healthy = true
+	pc.observe(ep, elapsed)
+}
+
+// isConnectionError reports whether a request failed to reach the endpoint,
+// as opposed to being rejected by it.
+func isConnectionError(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || errors.Is(err, rpc.ErrNoResult) || errors.Is(err, rajchain.NotFound) {
+		return false
+	}
+	var rpcErr rpc.Error
+	return !errors.As(err, &rpcErr)
+}
+
+// do runs a request on the preferred endpoint, failing over to the next ones
+// on connection errors. It returns the endpoint that served the request.
+func (pc *PoolClient) do(ctx context.Context, fn func(*Client) error) (*endpoint, error) {
+	var err error
+	for _, ep := range pc.candidates() {
+		start := time.Now()
+		if err = fn(ep.client); !isConnectionError(ctx, err) {
+			if err == nil {
+				pc.succeeded(ep, time.Since(start))
+			}
+			return ep, err
+		}
+		pc.failed(ep, err)
+	}
+	return nil, err
+}
+
+// read runs a request returning a result through do.
+func read[T any](ctx context.Context, pc *PoolClient, fn func(*Client) (T, error)) (T, error) {
+	var result T
+	_, err := pc.do(ctx, func(c *Client) (err error) {
+		result, err = fn(c)
+		return err
+	})
+	return result, err
+}
+
+// broadcast sends a request to all endpoints, succeeding if any of them
+// accepted it.
+func (pc *PoolClient) broadcast(ctx context.Context, fn func(*Client) error) error {
+	var (
+		eps  = pc.candidates()
+		errs = make([]error, len(eps))
+		wg   sync.WaitGroup
+	)
+	for i, ep := range eps {
+		wg.Add(1)
+		go func(i int, ep *endpoint) {
+			defer wg.Done()
+
+			start := time.Now()
+			errs[i] = fn(ep.client)
+			switch {
+			case errs[i] == nil:
+				pc.succeeded(ep, time.Since(start))
+			case isConnectionError(ctx, errs[i]):
+				pc.failed(ep, errs[i])
+			}
+		}(i, ep)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err == nil {
+			return nil
+		}
+	}
+	return errs[0]
+}
+
+// subscribe establishes a subscription on the preferred endpoint. When the
+// endpoint fails, the subscription is established again on the next healthy
+// one, delivering on the same channel as before. Items sent between the
+// failure and the new subscription are lost.
+//
+// The subscription only fails if it can not be established at all. It ends
+// when unsubscribed or when the client is closed.
+func (pc *PoolClient) subscribe(ctx context.Context, fn func(*Client) (rajchain.Subscription, error)) (rajchain.Subscription, error) {
+	var sub rajchain.Subscription
+	current, err := pc.do(ctx, func(c *Client) (err error) {
+		sub, err = fn(c)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	first := sub
+	return event.ResubscribeErr(pc.config.HealthCheckInterval, func(ctx context.Context, lastErr error) (event.Subscription, error) {
+		if first != nil {
+			sub := first
+			first = nil
+			return sub, nil
+		}
+		select {
+		case <-pc.closeCh:
+			// End the subscription successfully, stopping the resubscription.
+			return event.NewSubscription(func(<-chan struct{}) error { return nil }), nil
+		default:
+		}
+		pc.failed(current, lastErr)
+
+		var sub rajchain.Subscription
+		ep, err := pc.do(ctx, func(c *Client) (err error) {
+			sub, err = fn(c)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		log.Debug("Moved subscription to another RPC endpoint", "from", current.name, "to", ep.name, "err", lastErr)
+		current = ep
+		return sub, nil
+	}), nil
+}
+
+// ethSubscribe creates a subscription on the preferred endpoint, without
+// moving it on failure. It is used by the resilient subscriptions, which
+// backfill the items missed when resubscribing.
+func (pc *PoolClient) ethSubscribe(ctx context.Context, channel interface{}, args ...interface{}) (*rpc.ClientSubscription, error) {
+	return read(ctx, pc, func(c *Client) (*rpc.ClientSubscription, error) {
+		return c.c.EthSubscribe(ctx, channel, args...)
+	})
+}
+
+// Blockchain Access
+
+// ChainID retrieves the current chain ID for transaction replay protection.
+func (pc *PoolClient) ChainID(ctx context.Context) (*big.Int, error) {
+	return read(ctx, pc, func(c *Client) (*big.Int, error) { return c.ChainID(ctx) })
+}
+
+// BlockByHash returns the given full block.
+func (pc *PoolClient) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return read(ctx, pc, func(c *Client) (*types.Block, error) { return c.BlockByHash(ctx, hash) })
+}
+
+// BlockByNumber returns a block from the current canonical chain. If number is
+// nil, the latest known block is returned.
+func (pc *PoolClient) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	return read(ctx, pc, func(c *Client) (*types.Block, error) { return c.BlockByNumber(ctx, number) })
+}
+
+// BlockNumber returns the most recent block number.
+func (pc *PoolClient) BlockNumber(ctx context.Context) (uint64, error) {
+	return read(ctx, pc, func(c *Client) (uint64, error) { return c.BlockNumber(ctx) })
+}
+
+// PeerCount returns the number of p2p peers of the preferred endpoint.
+func (pc *PoolClient) PeerCount(ctx context.Context) (uint64, error) {
+	return read(ctx, pc, func(c *Client) (uint64, error) { return c.PeerCount(ctx) })
+}
+
+// BlockReceipts returns the receipts of a given block number or hash.
+func (pc *PoolClient) BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	return read(ctx, pc, func(c *Client) ([]*types.Receipt, error) { return c.BlockReceipts(ctx, blockNrOrHash) })
+}
+
+// HeaderByHash returns the block header with the given hash.
+func (pc *PoolClient) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return read(ctx, pc, func(c *Client) (*types.Header, error) { return c.HeaderByHash(ctx, hash) })
+}
+
+// HeaderByNumber returns a block header from the current canonical chain. If
+// number is nil, the latest known header is returned.
+func (pc *PoolClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return read(ctx, pc, func(c *Client) (*types.Header, error) { return c.HeaderByNumber(ctx, number) })
+}
+
+// TransactionByHash returns the transaction with the given hash.
+func (pc *PoolClient) TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error) {
+	_, err = pc.do(ctx, func(c *Client) (err error) {
+		tx, isPending, err = c.TransactionByHash(ctx, hash)
+		return err
+	})
+	return tx, isPending, err
+}
+
+// TransactionSender returns the sender address of the given transaction.
+func (pc *PoolClient) TransactionSender(ctx context.Context, tx *types.Transaction, block common.Hash, index uint) (common.Address, error) {
+	return read(ctx, pc, func(c *Client) (common.Address, error) { return c.TransactionSender(ctx, tx, block, index) })
+}
+
+// TransactionCount returns the total number of transactions in the given block.
+func (pc *PoolClient) TransactionCount(ctx context.Context, blockHash common.Hash) (uint, error) {
+	return read(ctx, pc, func(c *Client) (uint, error) { return c.TransactionCount(ctx, blockHash) })
+}
+
+// TransactionInBlock returns a single transaction at index in the given block.
+func (pc *PoolClient) TransactionInBlock(ctx context.Context, blockHash common.Hash, index uint) (*types.Transaction, error) {
+	return read(ctx, pc, func(c *Client) (*types.Transaction, error) { return c.TransactionInBlock(ctx, blockHash, index) })
+}
+
+// TransactionReceipt returns the receipt of a transaction by transaction hash.
+func (pc *PoolClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return read(ctx, pc, func(c *Client) (*types.Receipt, error) { return c.TransactionReceipt(ctx, txHash) })
+}
+
+// SyncProgress retrieves the current progress of the sync algorithm of the
+// preferred endpoint.
+func (pc *PoolClient) SyncProgress(ctx context.Context) (*rajchain.SyncProgress, error) {
+	return read(ctx, pc, func(c *Client) (*rajchain.SyncProgress, error) { return c.SyncProgress(ctx) })
+}
+
+// SubscribeNewHead subscribes to notifications about the current blockchain head
+// on the given channel.
+func (pc *PoolClient) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (rajchain.Subscription, error) {
+	return pc.subscribe(ctx, func(c *Client) (rajchain.Subscription, error) { return c.SubscribeNewHead(ctx, ch) })
+}
+
+// ResubscribeNewHead subscribes to notifications about the current blockchain
+// head on the given channel, surviving connection failures. See
+// Client.ResubscribeNewHead.
+func (pc *PoolClient) ResubscribeNewHead(ctx context.Context, ch chan<- SubscriptionEvent[*types.Header], config *ResubscribeConfig) (rajchain.Subscription, error) {
+	return resubscribeNewHead(ctx, pc, pc.ethSubscribe, ch, config)
+}
+
+// State Access
+
+// NetworkID returns the network ID.
+func (pc *PoolClient) NetworkID(ctx context.Context) (*big.Int, error) {
+	return read(ctx, pc, func(c *Client) (*big.Int, error) { return c.NetworkID(ctx) })
+}
+
+// BalanceAt returns the wei balance of the given account.
+func (pc *PoolClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return read(ctx, pc, func(c *Client) (*big.Int, error) { return c.BalanceAt(ctx, account, blockNumber) })
+}
+
+// BalanceAtHash returns the wei balance of the given account.
+func (pc *PoolClient) BalanceAtHash(ctx context.Context, account common.Address, blockHash common.Hash) (*big.Int, error) {
+	return read(ctx, pc, func(c *Client) (*big.Int, error) { return c.BalanceAtHash(ctx, account, blockHash) })
+}
+
+// StorageAt returns the value of key in the contract storage of the given account.
+func (pc *PoolClient) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	return read(ctx, pc, func(c *Client) ([]byte, error) { return c.StorageAt(ctx, account, key, blockNumber) })
+}
+
+// StorageAtHash returns the value of key in the contract storage of the given account.
+func (pc *PoolClient) StorageAtHash(ctx context.Context, account common.Address, key common.Hash, blockHash common.Hash) ([]byte, error) {
+	return read(ctx, pc, func(c *Client) ([]byte, error) { return c.StorageAtHash(ctx, account, key, blockHash) })
+}
+
+// CodeAt returns the contract code of the given account.
+func (pc *PoolClient) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	return read(ctx, pc, func(c *Client) ([]byte, error) { return c.CodeAt(ctx, account, blockNumber) })
+}
+
+// CodeAtHash returns the contract code of the given account.
+func (pc *PoolClient) CodeAtHash(ctx context.Context, account common.Address, blockHash common.Hash) ([]byte, error) {
+	return read(ctx, pc, func(c *Client) ([]byte, error) { return c.CodeAtHash(ctx, account, blockHash) })
+}
+
+// NonceAt returns the account nonce of the given account.
+func (pc *PoolClient) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return read(ctx, pc, func(c *Client) (uint64, error) { return c.NonceAt(ctx, account, blockNumber) })
+}
+
+// NonceAtHash returns the account nonce of the given account.
+func (pc *PoolClient) NonceAtHash(ctx context.Context, account common.Address, blockHash common.Hash) (uint64, error) {
+	return read(ctx, pc, func(c *Client) (uint64, error) { return c.NonceAtHash(ctx, account, blockHash) })
+}
+
+// Filters
+
+// FilterLogs executes a filter query.
+func (pc *PoolClient) FilterLogs(ctx context.Context, q rajchain.FilterQuery) ([]types.Log, error) {
+	return read(ctx, pc, func(c *Client) ([]types.Log, error) { return c.FilterLogs(ctx, q) })
+}
+
+// SubscribeFilterLogs subscribes to the results of a streaming filter query.
+func (pc *PoolClient) SubscribeFilterLogs(ctx context.Context, q rajchain.FilterQuery, ch chan<- types.Log) (rajchain.Subscription, error) {
+	return pc.subscribe(ctx, func(c *Client) (rajchain.Subscription, error) { return c.SubscribeFilterLogs(ctx, q, ch) })
+}
+
+// ResubscribeFilterLogs subscribes to the results of a streaming filter query,
+// surviving connection failures. See Client.ResubscribeFilterLogs.
+func (pc *PoolClient) ResubscribeFilterLogs(ctx context.Context, q rajchain.FilterQuery, ch chan<- SubscriptionEvent[types.Log], config *ResubscribeConfig) (rajchain.Subscription, error) {
+	return resubscribeFilterLogs(ctx, pc, pc.ethSubscribe, q, ch, config)
+}
+
+// ResubscribePendingTransactions subscribes to the hashes of the transactions
+// entering the transaction pool, surviving connection failures. See
+// Client.ResubscribePendingTransactions.
+func (pc *PoolClient) ResubscribePendingTransactions(ctx context.Context, ch chan<- SubscriptionEvent[common.Hash], config *ResubscribeConfig) (rajchain.Subscription, error) {
+	return resubscribePendingTransactions(ctx, pc.ethSubscribe, ch, config)
+}
+
+// Pending State
+
+// PendingBalanceAt returns the wei balance of the given account in the pending state.
+func (pc *PoolClient) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
+	return read(ctx, pc, func(c *Client) (*big.Int, error) { return c.PendingBalanceAt(ctx, account) })
+}
+
+// PendingStorageAt returns the value of key in the contract storage of the given account in the pending state.
+func (pc *PoolClient) PendingStorageAt(ctx context.Context, account common.Address, key common.Hash) ([]byte, error) {
+	return read(ctx, pc, func(c *Client) ([]byte, error) { return c.PendingStorageAt(ctx, account, key) })
+}
+
+// PendingCodeAt returns the contract code of the given account in the pending state.
+func (pc *PoolClient) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return read(ctx, pc, func(c *Client) ([]byte, error) { return c.PendingCodeAt(ctx, account) })
+}
+
+// PendingNonceAt returns the account nonce of the given account in the pending state.
+func (pc *PoolClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return read(ctx, pc, func(c *Client) (uint64, error) { return c.PendingNonceAt(ctx, account) })
+}
+
+// PendingTransactionCount returns the total number of transactions in the pending state.
+func (pc *PoolClient) PendingTransactionCount(ctx context.Context) (uint, error) {
+	return read(ctx, pc, func(c *Client) (uint, error) { return c.PendingTransactionCount(ctx) })
+}
+
+// Contract Calling
+
+// CallContract executes a message call transaction.
+func (pc *PoolClient) CallContract(ctx context.Context, msg rajchain.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return read(ctx, pc, func(c *Client) ([]byte, error) { return c.CallContract(ctx, msg, blockNumber) })
+}
+
+// CallContractAtHash is almost the same as CallContract except that it selects
+// the block by block hash instead of block height.
+func (pc *PoolClient) CallContractAtHash(ctx context.Context, msg rajchain.CallMsg, blockHash common.Hash) ([]byte, error) {
+	return read(ctx, pc, func(c *Client) ([]byte, error) { return c.CallContractAtHash(ctx, msg, blockHash) })
+}
+
+// PendingCallContract executes a message call transaction using the EVM.
+// The state seen by the contract call is the pending state.
+func (pc *PoolClient) PendingCallContract(ctx context.Context, msg rajchain.CallMsg) ([]byte, error) {
+	return read(ctx, pc, func(c *Client) ([]byte, error) { return c.PendingCallContract(ctx, msg) })
+}
+
+// SuggestGasPrice retrieves the currently suggested gas price to allow a timely
+// execution of a transaction.
+func (pc *PoolClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return read(ctx, pc, func(c *Client) (*big.Int, error) { return c.SuggestGasPrice(ctx) })
+}
+
+// SuggestGasTipCap retrieves the currently suggested gas tip cap after 1559 to
+// allow a timely execution of a transaction.
+func (pc *PoolClient) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return read(ctx, pc, func(c *Client) (*big.Int, error) { return c.SuggestGasTipCap(ctx) })
+}
+
+// FeeHistory retrieves the fee market history.
+func (pc *PoolClient) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*rajchain.FeeHistory, error) {
+	return read(ctx, pc, func(c *Client) (*rajchain.FeeHistory, error) {
+		return c.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
+	})
+}
+
+// EstimateGas tries to estimate the gas needed to execute a specific transaction
+// based on the current pending state of the preferred endpoint.
+func (pc *PoolClient) EstimateGas(ctx context.Context, msg rajchain.CallMsg) (uint64, error) {
+	return read(ctx, pc, func(c *Client) (uint64, error) { return c.EstimateGas(ctx, msg) })
+}
+
+// SendTransaction injects a signed transaction into the pending pool of all
+// endpoints, succeeding if any of them accepted it.
+func (pc *PoolClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return pc.broadcast(ctx, func(c *Client) error { return c.SendTransaction(ctx, tx) })
+}
@@ -0,0 +1,292 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/rpc"
+)
+
+// poolNode is an eth namespace reporting a configurable head and counting the
+// requests served.
+type poolNode struct {
+	mu    sync.Mutex
+	head  uint64
+	delay time.Duration
+	calls int
+	txs   []hexutil.Bytes
+	fail  bool // Whether transactions are rejected
+
+	notifier   *rpc.Notifier
+	sub        rpc.ID
+	subscribed chan struct{} // Signaled on new head subscriptions if set
+}
+
+func (n *poolNode) BlockNumber() hexutil.Uint64 {
+	n.mu.Lock()
+	head, delay := n.head, n.delay
+	n.mu.Unlock()
+
+	time.Sleep(delay)
+	return hexutil.Uint64(head)
+}
+
+func (n *poolNode) ChainId() *hexutil.Big {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.calls++
+	return (*hexutil.Big)(common.Big1)
+}
+
+func (n *poolNode) SendRawTransaction(tx hexutil.Bytes) (common.Hash, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.fail {
+		return common.Hash{}, errors.New("rejected")
+	}
+	n.txs = append(n.txs, tx)
+	return crypto.Keccak256Hash(tx), nil
+}
+
+func (n *poolNode) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+
+	n.mu.Lock()
+	n.notifier, n.sub = notifier, sub.ID
+	n.mu.Unlock()
+
+	if n.subscribed != nil {
+		n.subscribed <- struct{}{}
+	}
+	return sub, nil
+}
+
+// announce notifies the head subscription of the node.
+func (n *poolNode) announce(header *types.Header) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.notifier == nil {
+		return errors.New("not subscribed")
+	}
+	return n.notifier.Notify(n.sub, header)
+}
+
+func (n *poolNode) stats() (int, int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.calls, len(n.txs)
+}
+
+// newTestPool creates a pooled client over the given nodes, returning their
+// servers to allow stopping them.
+func newTestPool(t *testing.T, nodes ...*poolNode) (*PoolClient, []*rpc.Server) {
+	var (
+		clients []*rpc.Client
+		servers []*rpc.Server
+	)
+	for _, node := range nodes {
+		server := rpc.NewServer()
+		if err := server.RegisterName("eth", node); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(server.Stop)
+		servers = append(servers, server)
+		clients = append(clients, rpc.DialInProc(server))
+	}
+	client, err := NewPoolClient(clients, &PoolConfig{HealthCheckInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return client, servers
+}
+
+func TestPoolRouting(t *testing.T) {
+	var (
+		slow    = &poolNode{head: 100, delay: 20 * time.Millisecond}
+		fast    = &poolNode{head: 100}
+		lagging = &poolNode{head: 90}
+	)
+	client, servers := newTestPool(t, slow, fast, lagging)
+
+	status := client.Status()
+	if !status[0].InSync || !status[1].InSync || status[2].InSync {
+		t.Fatalf("wrong sync status: %+v", status)
+	}
+	// Reads go to the fastest in-sync endpoint
+	for i := 0; i < 5; i++ {
+		if _, err := client.ChainID(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls, _ := fast.stats(); calls != 5 {
+		t.Fatalf("fast endpoint served %d reads, want 5", calls)
+	}
+	// Reads fail over to the next in-sync endpoint when the preferred one is down
+	servers[1].Stop()
+	if _, err := client.ChainID(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if calls, _ := slow.stats(); calls != 1 {
+		t.Fatalf("slow endpoint served %d reads, want 1", calls)
+	}
+	if status := client.Status(); status[1].Healthy {
+		t.Fatalf("failed endpoint still healthy: %+v", status[1])
+	}
+	// The lagging endpoint is only used once no in-sync one is left
+	servers[0].Stop()
+	if _, err := client.ChainID(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if calls, _ := lagging.stats(); calls != 1 {
+		t.Fatalf("lagging endpoint served %d reads, want 1", calls)
+	}
+	servers[2].Stop()
+	if _, err := client.ChainID(context.Background()); err == nil {
+		t.Fatal("read succeeded without endpoints")
+	}
+}
+
+func TestPoolHealthCheck(t *testing.T) {
+	var (
+		a = &poolNode{head: 10}
+		b = &poolNode{head: 10}
+	)
+	p, _ := newTestPool(t, a, b)
+
+	// Endpoints falling behind are taken out of rotation until they catch up
+	a.mu.Lock()
+	a.head = 20
+	a.mu.Unlock()
+	p.check()
+	if eps := p.candidates(); eps[0] != p.endpoints[0] {
+		t.Fatalf("lagging endpoint preferred")
+	}
+	b.mu.Lock()
+	b.head = 19
+	b.mu.Unlock()
+	p.check()
+	if status := p.Status(); !status[1].InSync {
+		t.Fatalf("caught up endpoint not in sync: %+v", status[1])
+	}
+}
+
+func TestPoolSendTransaction(t *testing.T) {
+	var (
+		a = &poolNode{head: 1}
+		b = &poolNode{head: 1, fail: true}
+		c = &poolNode{head: 1}
+	)
+	client, servers := newTestPool(t, a, b, c)
+	servers[2].Stop()
+
+	tx := types.NewTx(&types.LegacyTx{Nonce: 1})
+	if err := client.SendTransaction(context.Background(), tx); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	if _, txs := a.stats(); txs != 1 {
+		t.Fatalf("transaction sent to %d healthy endpoints, want 1", txs)
+	}
+	// Sending fails only if no endpoint accepts the transaction
+	servers[0].Stop()
+	if err := client.SendTransaction(context.Background(), tx); err == nil {
+		t.Fatal("transaction sent without accepting endpoints")
+	}
+}
+
+func TestPoolSubscriptionFailover(t *testing.T) {
+	var (
+		slow = &poolNode{head: 1, delay: 20 * time.Millisecond, subscribed: make(chan struct{}, 1)}
+		fast = &poolNode{head: 1, subscribed: make(chan struct{}, 1)}
+	)
+	client, servers := newTestPool(t, slow, fast)
+
+	ch := make(chan *types.Header, 1)
+	sub, err := client.SubscribeNewHead(context.Background(), ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	// The subscription is established on the preferred endpoint
+	<-fast.subscribed
+	header := &types.Header{Number: big.NewInt(1), Difficulty: common.Big1}
+	if err := fast.announce(header); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-ch; got.Hash() != header.Hash() {
+		t.Fatalf("wrong header delivered: %v", got.Number)
+	}
+	// When the endpoint fails, the subscription moves to the other one and
+	// keeps delivering on the same channel
+	servers[1].Stop()
+	select {
+	case <-slow.subscribed:
+	case err := <-sub.Err():
+		t.Fatalf("subscription failed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription not moved to the healthy endpoint")
+	}
+	header = &types.Header{Number: big.NewInt(2), Difficulty: common.Big1}
+	if err := slow.announce(header); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-ch; got.Hash() != header.Hash() {
+		t.Fatalf("wrong header delivered: %v", got.Number)
+	}
+	if status := client.Status(); status[1].Healthy {
+		t.Fatalf("failed endpoint still healthy: %+v", status[1])
+	}
+}
+
+// Tests that the pooled client provides all methods of Client.
+func TestPoolClientAPI(t *testing.T) {
+	var (
+		single = reflect.TypeOf(new(Client))
+		pooled = reflect.TypeOf(new(PoolClient))
+	)
+	for i := 0; i < single.NumMethod(); i++ {
+		method := single.Method(i)
+		other, ok := pooled.MethodByName(method.Name)
+		if !ok {
+			t.Errorf("PoolClient lacks method %s", method.Name)
+			continue
+		}
+		// Compare the signatures without the receivers
+		if a, b := method.Type.String()[len("func(*ethclient.Client"):], other.Type.String()[len("func(*ethclient.PoolClient"):]; a != b {
+			t.Errorf("method %s has signature %s, want %s", method.Name, b, a)
+		}
+	}
+}
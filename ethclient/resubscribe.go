@@ -0,0 +1,487 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rajchain/go-rajchain"
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/lru"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/event"
+	"github.com/rajchain/go-rajchain/log"
+	"github.com/rajchain/go-rajchain/rpc"
+)
+
+const (
+	defaultBackoffMax   = 30 * time.Second
+	defaultHistoryLimit = 256
+	pendingDedupLimit   = 16384 // Number of pending transaction hashes remembered for deduplication
+)
+
+// ResubscribeConfig configures the subscriptions kept alive across connection
+// failures. The zero value, as well as nil, selects the defaults.
+type ResubscribeConfig struct {
+	// BackoffMax is the maximum time waited between reconnection attempts.
+	BackoffMax time.Duration
+
+	// HistoryLimit is the number of recent blocks tracked for detecting
+	// reorgs, which is also the maximum number of headers backfilled.
+	HistoryLimit uint64
+}
+
+func (c *ResubscribeConfig) withDefaults() ResubscribeConfig {
+	var config ResubscribeConfig
+	if c != nil {
+		config = *c
+	}
+	if config.BackoffMax == 0 {
+		config.BackoffMax = defaultBackoffMax
+	}
+	if config.HistoryLimit == 0 {
+		config.HistoryLimit = defaultHistoryLimit
+	}
+	return config
+}
+
+// SubscriptionEvent is an item delivered by a resilient subscription.
+type SubscriptionEvent[T any] struct {
+	Item       T
+	Removed    bool // Whether the item was dropped from the canonical chain by a reorg
+	Backfilled bool // Whether the item was missed by the live subscription and fetched afterwards
+}
+
+// ResubscribeNewHead subscribes to notifications about the current blockchain
+// head on the given channel, surviving connection failures.
+//
+// After reconnecting, the headers missed in the meantime are backfilled. Headers
+// dropped from the canonical chain by a reorg are announced as removed before
+// the headers of the new chain, which are delivered in ascending order. Headers
+// are delivered only once.
+//
+// The subscription only fails if it can not be established at all. Connection
+// failures afterwards are retried until the subscription is unsubscribed.
+func (ec *Client) ResubscribeNewHead(ctx context.Context, ch chan<- SubscriptionEvent[*types.Header], config *ResubscribeConfig) (rajchain.Subscription, error) {
+	return resubscribeNewHead(ctx, ec, ec.c.EthSubscribe, ch, config)
+}
+
+func resubscribeNewHead(ctx context.Context, ec chainReader, subscribe ethSubscribeFunc, ch chan<- SubscriptionEvent[*types.Header], config *ResubscribeConfig) (rajchain.Subscription, error) {
+	cfg := config.withDefaults()
+	tracker := &headTracker{
+		ec:        ec,
+		limit:     cfg.HistoryLimit,
+		canonical: make(map[uint64]*types.Header),
+	}
+	return resubscribe(ctx, cfg, ch, tracker, func(ctx context.Context, raw chan *types.Header) (*rpc.ClientSubscription, error) {
+		return subscribe(ctx, raw, "newHeads")
+	})
+}
+
+// ResubscribeFilterLogs subscribes to the results of a streaming filter query,
+// surviving connection failures.
+//
+// After reconnecting, the logs missed in the meantime are backfilled with
+// eth_getLogs from the last block seen. Logs dropped from the canonical chain
+// by a reorg are announced as removed, both when reported by the node and when
+// detected after reconnecting. Logs are delivered only once.
+//
+// If the query starts at a block, the logs before the subscription are
+// backfilled from there.
+func (ec *Client) ResubscribeFilterLogs(ctx context.Context, q rajchain.FilterQuery, ch chan<- SubscriptionEvent[types.Log], config *ResubscribeConfig) (rajchain.Subscription, error) {
+	return resubscribeFilterLogs(ctx, ec, ec.c.EthSubscribe, q, ch, config)
+}
+
+func resubscribeFilterLogs(ctx context.Context, ec chainReader, subscribe ethSubscribeFunc, q rajchain.FilterQuery, ch chan<- SubscriptionEvent[types.Log], config *ResubscribeConfig) (rajchain.Subscription, error) {
+	if q.BlockHash != nil {
+		return nil, errors.New("cannot subscribe to the logs of a single block")
+	}
+	arg, err := toFilterArg(rajchain.FilterQuery{Addresses: q.Addresses, Topics: q.Topics})
+	if err != nil {
+		return nil, err
+	}
+	cfg := config.withDefaults()
+	tracker := &logTracker{
+		ec:        ec,
+		query:     q,
+		limit:     cfg.HistoryLimit,
+		delivered: make(map[logKey]types.Log),
+		blocks:    make(map[uint64]common.Hash),
+	}
+	if q.FromBlock != nil {
+		tracker.from = q.FromBlock.Uint64()
+	}
+	return resubscribe(ctx, cfg, ch, tracker, func(ctx context.Context, raw chan types.Log) (*rpc.ClientSubscription, error) {
+		return subscribe(ctx, raw, "logs", arg)
+	})
+}
+
+// ResubscribePendingTransactions subscribes to the hashes of the transactions
+// entering the transaction pool, surviving connection failures.
+//
+// The transactions announced while disconnected can not be recovered. Hashes
+// are delivered only once, unless forgotten after a large number of others.
+func (ec *Client) ResubscribePendingTransactions(ctx context.Context, ch chan<- SubscriptionEvent[common.Hash], config *ResubscribeConfig) (rajchain.Subscription, error) {
+	return resubscribePendingTransactions(ctx, ec.c.EthSubscribe, ch, config)
+}
+
+func resubscribePendingTransactions(ctx context.Context, subscribe ethSubscribeFunc, ch chan<- SubscriptionEvent[common.Hash], config *ResubscribeConfig) (rajchain.Subscription, error) {
+	tracker := &pendingTracker{seen: lru.NewBasicLRU[common.Hash, struct{}](pendingDedupLimit)}
+	return resubscribe(ctx, config.withDefaults(), ch, tracker, func(ctx context.Context, raw chan common.Hash) (*rpc.ClientSubscription, error) {
+		return subscribe(ctx, raw, "newPendingTransactions")
+	})
+}
+
+// ethSubscribeFunc creates a subscription in the eth namespace, like
+// rpc.Client.EthSubscribe.
+type ethSubscribeFunc func(ctx context.Context, channel interface{}, args ...interface{}) (*rpc.ClientSubscription, error)
+
+// chainReader is the chain access the trackers need for backfilling, provided
+// by both Client and PoolClient.
+type chainReader interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	FilterLogs(ctx context.Context, q rajchain.FilterQuery) ([]types.Log, error)
+}
+
+// tracker processes the items of a resilient subscription, turning the raw
+// items of the node into the events delivered to the subscriber.
+type tracker[R, T any] interface {
+	// process handles an item received from the live subscription.
+	process(ctx context.Context, item R, emit func(SubscriptionEvent[T]) error) error
+
+	// backfill fetches the items missed before the subscription was
+	// (re)established.
+	backfill(ctx context.Context, emit func(SubscriptionEvent[T]) error) error
+}
+
+// resubscribe keeps a subscription established through a tracker. The first
+// subscription is made synchronously to report unsupported subscriptions.
+func resubscribe[R, T any](ctx context.Context, config ResubscribeConfig, sink chan<- SubscriptionEvent[T], t tracker[R, T], subscribe func(context.Context, chan R) (*rpc.ClientSubscription, error)) (rajchain.Subscription, error) {
+	raw := make(chan R)
+	first, err := subscribe(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	// The tracker is used both by the subscription loop for the live items and
+	// by the resubscription callback for the backfill, so access is serialized.
+	var lock sync.Mutex
+
+	emitter := func(ctx context.Context) func(SubscriptionEvent[T]) error {
+		return func(ev SubscriptionEvent[T]) error {
+			select {
+			case sink <- ev:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-quit:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		sub := event.ResubscribeErr(config.BackoffMax, func(ctx context.Context, lastErr error) (event.Subscription, error) {
+			sub := first
+			if sub != nil {
+				first = nil
+			} else {
+				var err error
+				log.Debug("Resubscribing after subscription failure", "err", lastErr)
+				if sub, err = subscribe(ctx, raw); err != nil {
+					return nil, err
+				}
+			}
+			lock.Lock()
+			defer lock.Unlock()
+
+			if err := t.backfill(ctx, emitter(ctx)); err != nil {
+				sub.Unsubscribe()
+				return nil, err
+			}
+			return sub, nil
+		})
+		defer sub.Unsubscribe()
+
+		emit := emitter(ctx)
+		for {
+			select {
+			case item := <-raw:
+				lock.Lock()
+				err := t.process(ctx, item, emit)
+				lock.Unlock()
+				if err != nil && ctx.Err() == nil {
+					// The state of the tracker is repaired by the backfill
+					// on the next item or reconnection.
+					log.Debug("Failed to process subscription item", "err", err)
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// headTracker follows the canonical chain through the announced heads.
+type headTracker struct {
+	ec        chainReader
+	limit     uint64
+	head      *types.Header
+	canonical map[uint64]*types.Header // Recent canonical headers by number
+}
+
+func (t *headTracker) process(ctx context.Context, header *types.Header, emit func(SubscriptionEvent[*types.Header]) error) error {
+	return t.update(ctx, header, true, emit)
+}
+
+func (t *headTracker) backfill(ctx context.Context, emit func(SubscriptionEvent[*types.Header]) error) error {
+	if t.head == nil {
+		return nil // Nothing seen yet, so nothing missed
+	}
+	head, err := t.ec.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	return t.update(ctx, head, false, emit)
+}
+
+// update moves the tracked chain to a new head, announced live or fetched
+// after reconnecting.
+func (t *headTracker) update(ctx context.Context, header *types.Header, live bool, emit func(SubscriptionEvent[*types.Header]) error) error {
+	number := header.Number.Uint64()
+	if known, ok := t.canonical[number]; ok && known.Hash() == header.Hash() {
+		return nil // Already delivered
+	}
+	// Walk back from the new head until reaching a known canonical header,
+	// collecting the headers missed or replacing the current chain.
+	branch := []*types.Header{header}
+	if t.head != nil {
+		oldest := uint64(0)
+		if head := t.head.Number.Uint64(); head > t.limit {
+			oldest = head - t.limit
+		}
+		for cur := header; ; {
+			n := cur.Number.Uint64()
+			if n == 0 || n-1 < oldest || uint64(len(branch)) >= t.limit {
+				break
+			}
+			if parent, ok := t.canonical[n-1]; ok && parent.Hash() == cur.ParentHash {
+				break
+			}
+			parent, err := t.ec.HeaderByHash(ctx, cur.ParentHash)
+			if err != nil {
+				return err
+			}
+			branch = append(branch, parent)
+			cur = parent
+		}
+		slices.Reverse(branch)
+
+		// Retract the headers replaced by the new branch, newest first
+		first := branch[0].Number.Uint64()
+		for n := t.head.Number.Uint64() + 1; n > first; n-- {
+			old, ok := t.canonical[n-1]
+			if !ok {
+				continue
+			}
+			delete(t.canonical, n-1)
+			if err := emit(SubscriptionEvent[*types.Header]{Item: old, Removed: true}); err != nil {
+				return err
+			}
+		}
+	}
+	for i, h := range branch {
+		t.canonical[h.Number.Uint64()] = h
+		t.head = h
+		if err := emit(SubscriptionEvent[*types.Header]{Item: h, Backfilled: !live || i < len(branch)-1}); err != nil {
+			return err
+		}
+	}
+	for n := range t.canonical {
+		if n+t.limit < number {
+			delete(t.canonical, n)
+		}
+	}
+	return nil
+}
+
+// logKey identifies a log in a block.
+type logKey struct {
+	block common.Hash
+	index uint
+}
+
+// logTracker deduplicates the logs of the live subscription and the backfill.
+type logTracker struct {
+	ec      chainReader
+	query   rajchain.FilterQuery
+	limit   uint64
+	started bool   // Whether the first subscription was backfilled
+	from    uint64 // Block to backfill from, all logs before having been delivered
+
+	delivered map[logKey]types.Log   // Logs delivered from recent blocks
+	blocks    map[uint64]common.Hash // Hashes of the recent blocks with delivered logs
+}
+
+func (t *logTracker) process(ctx context.Context, l types.Log, emit func(SubscriptionEvent[types.Log]) error) error {
+	key := logKey{l.BlockHash, l.Index}
+	if l.Removed {
+		if _, ok := t.delivered[key]; !ok {
+			return nil
+		}
+		delete(t.delivered, key)
+		return emit(SubscriptionEvent[types.Log]{Item: l, Removed: true})
+	}
+	if _, ok := t.delivered[key]; ok {
+		return nil
+	}
+	t.deliver(l)
+	return emit(SubscriptionEvent[types.Log]{Item: l})
+}
+
+// deliver records a log as delivered.
+func (t *logTracker) deliver(l types.Log) {
+	t.delivered[logKey{l.BlockHash, l.Index}] = l
+	t.blocks[l.BlockNumber] = l.BlockHash
+	if l.BlockNumber > t.from {
+		t.from = l.BlockNumber
+		t.prune()
+	}
+}
+
+// prune forgets the logs delivered from blocks too old to be reorged.
+func (t *logTracker) prune() {
+	if t.from <= t.limit {
+		return
+	}
+	for key, l := range t.delivered {
+		if l.BlockNumber < t.from-t.limit {
+			delete(t.delivered, key)
+			delete(t.blocks, l.BlockNumber)
+		}
+	}
+}
+
+func (t *logTracker) backfill(ctx context.Context, emit func(SubscriptionEvent[types.Log]) error) error {
+	head, err := t.ec.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	if !t.started {
+		t.started = true
+		if t.query.FromBlock == nil {
+			// Nothing to backfill before the first subscription
+			t.from = head
+			return nil
+		}
+	}
+	// Retract the delivered logs of the blocks reorged out while disconnected
+	numbers := make([]uint64, 0, len(t.blocks))
+	for n := range t.blocks {
+		if n >= t.from-min(t.from, t.limit) {
+			numbers = append(numbers, n)
+		}
+	}
+	slices.Sort(numbers)
+	slices.Reverse(numbers)
+	for _, n := range numbers {
+		header, err := t.ec.HeaderByNumber(ctx, new(big.Int).SetUint64(n))
+		if err != nil && !errors.Is(err, rajchain.NotFound) {
+			return err
+		}
+		if header != nil && header.Hash() == t.blocks[n] {
+			continue
+		}
+		if err := t.retract(n, emit); err != nil {
+			return err
+		}
+		t.from = min(t.from, n)
+	}
+	if t.from > head {
+		return nil
+	}
+	q := t.query
+	q.FromBlock, q.ToBlock = new(big.Int).SetUint64(t.from), new(big.Int).SetUint64(head)
+	logs, err := t.ec.FilterLogs(ctx, q)
+	if err != nil {
+		return err
+	}
+	for _, l := range logs {
+		if _, ok := t.delivered[logKey{l.BlockHash, l.Index}]; ok {
+			continue
+		}
+		t.deliver(l)
+		if err := emit(SubscriptionEvent[types.Log]{Item: l, Backfilled: true}); err != nil {
+			return err
+		}
+	}
+	t.from = max(t.from, head)
+	t.prune()
+	return nil
+}
+
+// retract announces the delivered logs of a block dropped from the chain as
+// removed, in reverse order.
+func (t *logTracker) retract(number uint64, emit func(SubscriptionEvent[types.Log]) error) error {
+	var removed []types.Log
+	for key, l := range t.delivered {
+		if key.block == t.blocks[number] {
+			removed = append(removed, l)
+		}
+	}
+	slices.SortFunc(removed, func(a, b types.Log) int { return int(b.Index) - int(a.Index) })
+	for _, l := range removed {
+		delete(t.delivered, logKey{l.BlockHash, l.Index})
+		l.Removed = true
+		if err := emit(SubscriptionEvent[types.Log]{Item: l, Removed: true}); err != nil {
+			return err
+		}
+	}
+	delete(t.blocks, number)
+	return nil
+}
+
+// pendingTracker deduplicates the announced pending transactions.
+type pendingTracker struct {
+	seen lru.BasicLRU[common.Hash, struct{}]
+}
+
+func (t *pendingTracker) process(ctx context.Context, hash common.Hash, emit func(SubscriptionEvent[common.Hash]) error) error {
+	if t.seen.Contains(hash) {
+		return nil
+	}
+	t.seen.Add(hash, struct{}{})
+	return emit(SubscriptionEvent[common.Hash]{Item: hash})
+}
+
+func (t *pendingTracker) backfill(ctx context.Context, emit func(SubscriptionEvent[common.Hash]) error) error {
+	return nil // The transaction pool can not be replayed
+}
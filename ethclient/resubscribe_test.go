@@ -0,0 +1,375 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient_test
+
+import (
+	"context"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rajchain/go-rajchain"
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/ethclient"
+	"github.com/rajchain/go-rajchain/rpc"
+)
+
+// fakeChain is an eth namespace serving a scripted chain, whose subscriptions
+// are notified explicitly by the test.
+type fakeChain struct {
+	mu         sync.Mutex
+	headers    []*types.Header // Canonical chain by number
+	logs       []types.Log
+	subs       map[string][]fakeSub
+	subscribed chan string
+}
+
+type fakeSub struct {
+	notifier *rpc.Notifier
+	id       rpc.ID
+}
+
+func newFakeChain() *fakeChain {
+	return &fakeChain{
+		headers:    []*types.Header{fakeHeader(nil, 0)},
+		subs:       make(map[string][]fakeSub),
+		subscribed: make(chan string, 16),
+	}
+}
+
+// fakeHeader creates a header on top of the parent, the salt telling apart
+// the headers of different branches.
+func fakeHeader(parent *types.Header, salt byte) *types.Header {
+	header := &types.Header{Number: new(big.Int), Difficulty: big.NewInt(1), Extra: []byte{salt}}
+	if parent != nil {
+		header.Number.Add(parent.Number, common.Big1)
+		header.ParentHash = parent.Hash()
+	}
+	return header
+}
+
+// extend replaces the chain after the given number with a new branch of n
+// headers, returning them.
+func (c *fakeChain) extend(after uint64, n int, salt byte) []*types.Header {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.headers = c.headers[:after+1]
+	for i := 0; i < n; i++ {
+		c.headers = append(c.headers, fakeHeader(c.headers[len(c.headers)-1], salt))
+	}
+	return slices.Clone(c.headers[after+1:])
+}
+
+func (c *fakeChain) publish(kind string, item any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	subs := c.subs[kind][:0]
+	for _, sub := range c.subs[kind] {
+		if sub.notifier.Notify(sub.id, item) == nil {
+			subs = append(subs, sub)
+		}
+	}
+	c.subs[kind] = subs
+}
+
+func (c *fakeChain) subscribe(ctx context.Context, kind string) (*rpc.Subscription, error) {
+	notifier, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+
+	c.mu.Lock()
+	c.subs[kind] = append(c.subs[kind], fakeSub{notifier, sub.ID})
+	c.mu.Unlock()
+
+	c.subscribed <- kind
+	return sub, nil
+}
+
+func (c *fakeChain) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	return c.subscribe(ctx, "newHeads")
+}
+
+func (c *fakeChain) Logs(ctx context.Context, crit map[string]any) (*rpc.Subscription, error) {
+	return c.subscribe(ctx, "logs")
+}
+
+func (c *fakeChain) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	return c.subscribe(ctx, "newPendingTransactions")
+}
+
+func (c *fakeChain) BlockNumber() hexutil.Uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return hexutil.Uint64(len(c.headers) - 1)
+}
+
+func (c *fakeChain) GetBlockByNumber(number rpc.BlockNumber, full bool) *types.Header {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if number == rpc.LatestBlockNumber {
+		return c.headers[len(c.headers)-1]
+	}
+	if number < 0 || int(number) >= len(c.headers) {
+		return nil
+	}
+	return c.headers[number]
+}
+
+func (c *fakeChain) GetBlockByHash(hash common.Hash, full bool) *types.Header {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, header := range c.headers {
+		if header.Hash() == hash {
+			return header
+		}
+	}
+	return nil
+}
+
+func (c *fakeChain) GetLogs(crit map[string]any) []types.Log {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	from := hexutil.MustDecodeUint64(crit["fromBlock"].(string))
+	to := hexutil.MustDecodeUint64(crit["toBlock"].(string))
+
+	logs := []types.Log{}
+	for _, l := range c.logs {
+		if l.BlockNumber >= from && l.BlockNumber <= to {
+			logs = append(logs, l)
+		}
+	}
+	return logs
+}
+
+// newFakeClient serves the chain over websocket, returning a client whose
+// connections can be dropped.
+func newFakeClient(t *testing.T, chain *fakeChain) (*ethclient.Client, func()) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", chain); err != nil {
+		t.Fatal(err)
+	}
+	httpsrv := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	t.Cleanup(httpsrv.Close)
+	t.Cleanup(server.Stop)
+
+	var (
+		mu    sync.Mutex
+		conns []net.Conn
+	)
+	dialer := websocket.Dialer{
+		NetDialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := new(net.Dialer).DialContext(ctx, network, addr)
+			if err == nil {
+				mu.Lock()
+				conns = append(conns, conn)
+				mu.Unlock()
+			}
+			return conn, err
+		},
+	}
+	client, err := rpc.DialOptions(context.Background(), "ws"+strings.TrimPrefix(httpsrv.URL, "http"), rpc.WithWebsocketDialer(dialer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+
+	drop := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+		conns = nil
+	}
+	return ethclient.NewClient(client), drop
+}
+
+func waitSubscribed(t *testing.T, chain *fakeChain) {
+	t.Helper()
+	select {
+	case <-chain.subscribed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for subscription")
+	}
+}
+
+// expect checks the next events of a subscription, comparing headers and logs
+// by their identity.
+func expect[T any](t *testing.T, ch <-chan ethclient.SubscriptionEvent[T], want ...ethclient.SubscriptionEvent[T]) {
+	t.Helper()
+	for i, w := range want {
+		select {
+		case ev := <-ch:
+			if ev.Removed != w.Removed || ev.Backfilled != w.Backfilled || !sameItem(ev.Item, w.Item) {
+				t.Fatalf("event %d: have %+v, want %+v", i, ev, w)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("event %d: timeout, want %+v", i, w)
+		}
+	}
+}
+
+func sameItem(have, want any) bool {
+	switch w := want.(type) {
+	case *types.Header:
+		return have.(*types.Header).Hash() == w.Hash()
+	case types.Log:
+		h := have.(types.Log)
+		return h.BlockHash == w.BlockHash && h.Index == w.Index
+	default:
+		return have == want
+	}
+}
+
+func TestResubscribeNewHead(t *testing.T) {
+	chain := newFakeChain()
+	client, drop := newFakeClient(t, chain)
+
+	ch := make(chan ethclient.SubscriptionEvent[*types.Header])
+	sub, err := client.ResubscribeNewHead(context.Background(), ch, &ethclient.ResubscribeConfig{BackoffMax: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+	waitSubscribed(t, chain)
+
+	type event = ethclient.SubscriptionEvent[*types.Header]
+
+	// Live heads are delivered as announced
+	a := chain.extend(0, 2, 0)
+	chain.publish("newHeads", a[0])
+	chain.publish("newHeads", a[1])
+	expect(t, ch, event{Item: a[0]}, event{Item: a[1]})
+
+	// Heads missed while disconnected are backfilled after reconnecting
+	drop()
+	b := chain.extend(2, 2, 0)
+	waitSubscribed(t, chain)
+	expect(t, ch, event{Item: b[0], Backfilled: true}, event{Item: b[1], Backfilled: true})
+
+	// A reorg retracts the replaced heads before delivering the new branch
+	c := chain.extend(2, 3, 1)
+	chain.publish("newHeads", c[2])
+	expect(t, ch,
+		event{Item: b[1], Removed: true},
+		event{Item: b[0], Removed: true},
+		event{Item: c[0], Backfilled: true},
+		event{Item: c[1], Backfilled: true},
+		event{Item: c[2]},
+	)
+	// Duplicate announcements are dropped
+	d := chain.extend(5, 1, 1)
+	chain.publish("newHeads", c[2])
+	chain.publish("newHeads", d[0])
+	expect(t, ch, event{Item: d[0]})
+}
+
+func TestResubscribeFilterLogs(t *testing.T) {
+	chain := newFakeChain()
+	client, drop := newFakeClient(t, chain)
+
+	headers := chain.extend(0, 3, 0)
+	newLog := func(header *types.Header, index uint) types.Log {
+		return types.Log{BlockNumber: header.Number.Uint64(), BlockHash: header.Hash(), Index: index, Topics: []common.Hash{}}
+	}
+	a, b := newLog(headers[1], 0), newLog(headers[2], 0)
+	chain.logs = []types.Log{a, b}
+
+	ch := make(chan ethclient.SubscriptionEvent[types.Log])
+	q := rajchain.FilterQuery{FromBlock: big.NewInt(3)}
+	sub, err := client.ResubscribeFilterLogs(context.Background(), q, ch, &ethclient.ResubscribeConfig{BackoffMax: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+	waitSubscribed(t, chain)
+
+	type event = ethclient.SubscriptionEvent[types.Log]
+
+	// Logs from the start of the query are backfilled first, duplicates dropped
+	expect(t, ch, event{Item: b, Backfilled: true})
+	chain.publish("logs", b)
+
+	c := newLog(chain.extend(3, 1, 0)[0], 0)
+	chain.mu.Lock()
+	chain.logs = append(chain.logs, c)
+	chain.mu.Unlock()
+	chain.publish("logs", c)
+	expect(t, ch, event{Item: c})
+
+	// Logs missed while disconnected are backfilled, and logs of blocks
+	// reorged out in the meantime retracted
+	drop()
+	reorged := chain.extend(3, 2, 1)
+	d, e := newLog(reorged[0], 0), newLog(reorged[1], 0)
+	chain.mu.Lock()
+	chain.logs = []types.Log{a, b, d, e}
+	chain.mu.Unlock()
+
+	waitSubscribed(t, chain)
+	expect(t, ch,
+		event{Item: c, Removed: true},
+		event{Item: d, Backfilled: true},
+		event{Item: e, Backfilled: true},
+	)
+	// Removals announced by the node are passed on once
+	e.Removed = true
+	chain.publish("logs", e)
+	chain.publish("logs", e)
+	expect(t, ch, event{Item: e, Removed: true})
+}
+
+func TestResubscribePendingTransactions(t *testing.T) {
+	chain := newFakeChain()
+	client, drop := newFakeClient(t, chain)
+
+	ch := make(chan ethclient.SubscriptionEvent[common.Hash])
+	sub, err := client.ResubscribePendingTransactions(context.Background(), ch, &ethclient.ResubscribeConfig{BackoffMax: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+	waitSubscribed(t, chain)
+
+	type event = ethclient.SubscriptionEvent[common.Hash]
+
+	chain.publish("newPendingTransactions", common.Hash{1})
+	chain.publish("newPendingTransactions", common.Hash{1})
+	chain.publish("newPendingTransactions", common.Hash{2})
+	expect(t, ch, event{Item: common.Hash{1}}, event{Item: common.Hash{2}})
+
+	drop()
+	waitSubscribed(t, chain)
+	chain.publish("newPendingTransactions", common.Hash{2})
+	chain.publish("newPendingTransactions", common.Hash{3})
+	expect(t, ch, event{Item: common.Hash{3}})
+}
@@ -17,8 +17,10 @@
 package ethapi
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	gomath "math"
@@ -28,6 +30,7 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/rajchain/go-rajchain/accounts"
+	"github.com/rajchain/go-rajchain/accounts/abi"
 	"github.com/rajchain/go-rajchain/common"
 	"github.com/rajchain/go-rajchain/common/hexutil"
 	"github.com/rajchain/go-rajchain/common/math"
@@ -40,6 +43,7 @@ import (
 	"github.com/rajchain/go-rajchain/core/vm"
 	"github.com/rajchain/go-rajchain/crypto"
 	"github.com/rajchain/go-rajchain/eth/gasestimator"
+	"github.com/rajchain/go-rajchain/eth/gasprice"
 	"github.com/rajchain/go-rajchain/eth/tracers/logger"
 	"github.com/rajchain/go-rajchain/log"
 	"github.com/rajchain/go-rajchain/p2p"
@@ -138,6 +142,56 @@ func (api *rajchainAPI) BlobBaseFee(ctx context.Context) *hexutil.Big {
 	return (*hexutil.Big)(api.b.BlobBaseFee(ctx))
 }
 
+// feeEstimateResult is a priority fee suggestion of eth_feeEstimates.
+type feeEstimateResult struct {
+	MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas"`
+	MaxFeePerGas         *hexutil.Big `json:"maxFeePerGas"`
+	Confidence           float64      `json:"confidence"`
+}
+
+type feeEstimatesResult struct {
+	BlockNumber           hexutil.Uint64    `json:"blockNumber"`
+	BaseFee               *hexutil.Big      `json:"baseFeePerGas"`
+	Low                   feeEstimateResult `json:"low"`
+	Medium                feeEstimateResult `json:"medium"`
+	High                  feeEstimateResult `json:"high"`
+	PendingGas            hexutil.Uint64    `json:"pendingGas"`
+	Pressure              float64           `json:"pressure"`
+	BlobBaseFee           *hexutil.Big      `json:"baseFeePerBlobGas,omitempty"`
+	BlobBaseFeeProjection []*hexutil.Big    `json:"baseFeePerBlobGasProjection,omitempty"`
+}
+
+// FeeEstimates returns low, medium and high priority fee suggestions for the
+// next block, the pressure of the pending transactions on it and the projected
+// blob base fees.
+func (api *rajchainAPI) FeeEstimates(ctx context.Context) (*feeEstimatesResult, error) {
+	estimates, err := api.b.FeeEstimates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	level := func(estimate gasprice.FeeEstimate) feeEstimateResult {
+		return feeEstimateResult{
+			MaxPriorityFeePerGas: (*hexutil.Big)(estimate.TipCap),
+			MaxFeePerGas:         (*hexutil.Big)(estimate.FeeCap),
+			Confidence:           estimate.Confidence,
+		}
+	}
+	results := &feeEstimatesResult{
+		BlockNumber: hexutil.Uint64(estimates.Number),
+		BaseFee:     (*hexutil.Big)(estimates.BaseFee),
+		Low:         level(estimates.Low),
+		Medium:      level(estimates.Medium),
+		High:        level(estimates.High),
+		PendingGas:  hexutil.Uint64(estimates.PendingGas),
+		Pressure:    estimates.Pressure,
+		BlobBaseFee: (*hexutil.Big)(estimates.BlobBaseFee),
+	}
+	for _, fee := range estimates.BlobBaseFeeProjection {
+		results.BlobBaseFeeProjection = append(results.BlobBaseFeeProjection, (*hexutil.Big)(fee))
+	}
+	return results, nil
+}
+
 // Syncing returns false in case the node is currently not syncing with the network. It can be up-to-date or has not
 // yet received the latest block headers from its peers. In case it is synchronizing:
 // - startingBlock: block number this node started to synchronize from
@@ -1025,6 +1079,61 @@ func (api *BlockChainAPI) EstimateGas(ctx context.Context, args TransactionArgs,
 	return DoEstimateGas(ctx, api.b, args, bNrOrHash, overrides, api.b.RPCGasCap())
 }
 
+// estimateSequenceOpts are the inputs to eth_estimateGasSequence.
+type estimateSequenceOpts struct {
+	BlockOverrides *BlockOverrides
+	StateOverrides *StateOverride
+	Calls          []TransactionArgs
+	Validation     bool
+	ABI            json.RawMessage // Contract ABI declaring the custom errors to decode reverts with
+}
+
+// EstimateGasSequence estimates the gas of an ordered list of calls, each on top
+// of the state left by the previous ones, such as a token approval followed by a
+// swap spending it. The calls are executed in a single block on top of
+// `blockNrOrHash`, or the latest block if unspecified, with the given block and
+// state overrides. Failing calls are reported in their result, with reverts
+// decoded using the custom errors of the optional ABI.
+func (api *BlockChainAPI) EstimateGasSequence(ctx context.Context, opts estimateSequenceOpts, blockNrOrHash *rpc.BlockNumberOrHash) ([]simEstimateResult, error) {
+	if len(opts.Calls) == 0 {
+		return nil, &invalidParamsError{message: "empty input"}
+	}
+	var errs *abi.ABI
+	if len(opts.ABI) > 0 {
+		parsed, err := abi.JSON(bytes.NewReader(opts.ABI))
+		if err != nil {
+			return nil, &invalidParamsError{message: fmt.Sprintf("invalid ABI: %v", err)}
+		}
+		errs = &parsed
+	}
+	if blockNrOrHash == nil {
+		n := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &n
+	}
+	state, base, err := api.b.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	gasCap := api.b.RPCGasCap()
+	if gasCap == 0 {
+		gasCap = gomath.MaxUint64
+	}
+	sim := &simulator{
+		b:           api.b,
+		state:       state,
+		base:        base,
+		chainConfig: api.b.ChainConfig(),
+		gp:          new(core.GasPool).AddGas(gasCap),
+		validate:    opts.Validation,
+	}
+	block := simBlock{
+		BlockOverrides: opts.BlockOverrides,
+		StateOverrides: opts.StateOverrides,
+		Calls:          opts.Calls,
+	}
+	return sim.estimate(ctx, block, errs)
+}
+
 // RPCMarshalHeader converts the given header to the RPC output .
 func RPCMarshalHeader(head *types.Header) map[string]interface{} {
 	result := map[string]interface{}{
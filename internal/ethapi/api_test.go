@@ -50,6 +50,7 @@ import (
 	"github.com/rajchain/go-rajchain/core/vm"
 	"github.com/rajchain/go-rajchain/crypto"
 	"github.com/rajchain/go-rajchain/crypto/kzg4844"
+	"github.com/rajchain/go-rajchain/eth/gasprice"
 	"github.com/rajchain/go-rajchain/ethdb"
 	"github.com/rajchain/go-rajchain/event"
 	"github.com/rajchain/go-rajchain/internal/blocktest"
@@ -477,6 +478,9 @@ func (b testBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
 func (b testBackend) FeeHistory(ctx context.Context, blockCount uint64, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, []*big.Int, []float64, error) {
 	return nil, nil, nil, nil, nil, nil, nil
 }
+func (b testBackend) FeeEstimates(ctx context.Context) (*gasprice.FeeEstimates, error) {
+	return nil, nil
+}
 func (b testBackend) BlobBaseFee(ctx context.Context) *big.Int { return new(big.Int) }
 func (b testBackend) ChainDb() ethdb.Database                  { return b.db }
 func (b testBackend) AccountManager() *accounts.Manager        { return b.accman }
@@ -781,6 +785,88 @@ func TestEstimateGas(t *testing.T) {
 	}
 }
 
+func TestEstimateGasSequence(t *testing.T) {
+	t.Parallel()
+	// Initialize test accounts
+	var (
+		accounts = newAccounts(2)
+		dex      = common.HexToAddress("0xdec")
+		genesis  = &core.Genesis{
+			Config: params.MergedTestChainConfig,
+			Alloc: types.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+				// An empty call approves (sets slot 0), any other one swaps (sets
+				// slot 1) if approved, and reverts with NotApproved(7) otherwise.
+				dex: {Code: common.FromHex("361560275760005460205763676e2fd460e01b600052600760045260246000fd5b6001600155005b600160005500")},
+			},
+		}
+		errorABI = json.RawMessage(`[{"type":"error","name":"NotApproved","inputs":[{"name":"code","type":"uint256"}]}]`)
+		approve  = TransactionArgs{From: &accounts[0].addr, To: &dex}
+		swap     = TransactionArgs{From: &accounts[0].addr, To: &dex, Input: &hexutil.Bytes{0x01}}
+	)
+	api := NewBlockChainAPI(newTestBackend(t, 1, genesis, beacon.New(ethash.NewFaker()), func(i int, b *core.BlockGen) {
+		b.SetPoS()
+	}))
+	var testSuite = []struct {
+		opts    estimateSequenceOpts
+		failing []bool
+		reason  string
+	}{
+		// The swap succeeds once approved earlier in the sequence
+		{
+			opts:    estimateSequenceOpts{Calls: []TransactionArgs{approve, swap}},
+			failing: []bool{false, false},
+		},
+		// The swap reverts on its own, decoded if the error ABI is given
+		{
+			opts:    estimateSequenceOpts{Calls: []TransactionArgs{swap}},
+			failing: []bool{true},
+			reason:  "execution reverted",
+		},
+		{
+			opts:    estimateSequenceOpts{Calls: []TransactionArgs{swap, approve}, ABI: errorABI},
+			failing: []bool{true, false},
+			reason:  "execution reverted: NotApproved(7)",
+		},
+		// The swap succeeds if approved by a state override
+		{
+			opts: estimateSequenceOpts{
+				Calls: []TransactionArgs{swap},
+				StateOverrides: &StateOverride{
+					dex: OverrideAccount{StateDiff: map[common.Hash]common.Hash{{}: common.BigToHash(common.Big1)}},
+				},
+			},
+			failing: []bool{false},
+		},
+	}
+	for i, tc := range testSuite {
+		results, err := api.EstimateGasSequence(context.Background(), tc.opts, nil)
+		if err != nil {
+			t.Errorf("test %d: want no error, have %v", i, err)
+			continue
+		}
+		if len(results) != len(tc.failing) {
+			t.Errorf("test %d: result count mismatch, have %d, want %d", i, len(results), len(tc.failing))
+			continue
+		}
+		for j, res := range results {
+			if failing := res.Error != nil; failing != tc.failing[j] {
+				t.Errorf("test %d, call %d: failure mismatch, have %v, want %v", i, j, res.Error, tc.failing[j])
+				continue
+			}
+			if res.Error != nil {
+				if res.Error.Message != tc.reason || res.Error.Code != errCodeReverted {
+					t.Errorf("test %d, call %d: revert mismatch, have %q (%d), want %q", i, j, res.Error.Message, res.Error.Code, tc.reason)
+				}
+				continue
+			}
+			if res.Gas < res.GasUsed || res.GasUsed <= hexutil.Uint64(params.TxGas) {
+				t.Errorf("test %d, call %d: estimate %d below gas used %d", i, j, res.Gas, res.GasUsed)
+			}
+		}
+	}
+}
+
 func TestCall(t *testing.T) {
 	t.Parallel()
 
@@ -31,6 +31,7 @@ import (
 	"github.com/rajchain/go-rajchain/core/state"
 	"github.com/rajchain/go-rajchain/core/types"
 	"github.com/rajchain/go-rajchain/core/vm"
+	"github.com/rajchain/go-rajchain/eth/gasprice"
 	"github.com/rajchain/go-rajchain/ethdb"
 	"github.com/rajchain/go-rajchain/event"
 	"github.com/rajchain/go-rajchain/params"
@@ -46,6 +47,7 @@ type Backend interface {
 	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
 	FeeHistory(ctx context.Context, blockCount uint64, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, []*big.Int, []float64, error)
 	BlobBaseFee(ctx context.Context) *big.Int
+	FeeEstimates(ctx context.Context) (*gasprice.FeeEstimates, error)
 	ChainDb() ethdb.Database
 	AccountManager() *accounts.Manager
 	ExtRPCEnabled() bool
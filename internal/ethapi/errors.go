@@ -19,6 +19,7 @@ package ethapi
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/rajchain/go-rajchain/accounts/abi"
 	"github.com/rajchain/go-rajchain/common/hexutil"
@@ -58,6 +59,33 @@ func newRevertError(revert []byte) *revertError {
 	}
 }
 
+// newRevertErrorWithABI creates a revertError instance like newRevertError, but
+// also decodes the revert data as one of the custom errors of the given ABI.
+func newRevertErrorWithABI(revert []byte, errs *abi.ABI) *revertError {
+	if errs == nil || len(revert) < 4 {
+		return newRevertError(revert)
+	}
+	if _, err := abi.UnpackRevert(revert); err == nil {
+		return newRevertError(revert)
+	}
+	custom, err := errs.ErrorByID([4]byte(revert[:4]))
+	if err != nil {
+		return newRevertError(revert)
+	}
+	values, err := custom.Unpack(revert)
+	if err != nil {
+		return newRevertError(revert)
+	}
+	args := make([]string, len(values.([]interface{})))
+	for i, value := range values.([]interface{}) {
+		args[i] = fmt.Sprint(value)
+	}
+	return &revertError{
+		error:  fmt.Errorf("%w: %s(%s)", vm.ErrExecutionReverted, custom.Name, strings.Join(args, ", ")),
+		reason: hexutil.Encode(revert),
+	}
+}
+
 // TxIndexingError is an API error that indicates the transaction indexing is not
 // fully finished yet with JSON error code and a binary data blob.
 type TxIndexingError struct{}
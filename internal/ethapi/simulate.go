@@ -24,6 +24,7 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/rajchain/go-rajchain/accounts/abi"
 	"github.com/rajchain/go-rajchain/common"
 	"github.com/rajchain/go-rajchain/common/hexutil"
 	"github.com/rajchain/go-rajchain/consensus"
@@ -33,6 +34,7 @@ import (
 	"github.com/rajchain/go-rajchain/core/state"
 	"github.com/rajchain/go-rajchain/core/types"
 	"github.com/rajchain/go-rajchain/core/vm"
+	"github.com/rajchain/go-rajchain/eth/gasestimator"
 	"github.com/rajchain/go-rajchain/params"
 	"github.com/rajchain/go-rajchain/rpc"
 	"github.com/rajchain/go-rajchain/trie"
@@ -72,6 +74,14 @@ func (r *simCallResult) MarshalJSON() ([]byte, error) {
 	return json.Marshal((*callResultAlias)(r))
 }
 
+// simEstimateResult is the gas estimate of a call in a sequence.
+type simEstimateResult struct {
+	Gas         hexutil.Uint64 `json:"gas"`
+	GasUsed     hexutil.Uint64 `json:"gasUsed"`
+	ReturnValue hexutil.Bytes  `json:"returnData"`
+	Error       *callError     `json:"error,omitempty"`
+}
+
 // simOpts are the inputs to eth_simulateV1.
 type simOpts struct {
 	BlockStateCalls        []simBlock
@@ -140,37 +150,8 @@ func (sim *simulator) execute(ctx context.Context, blocks []simBlock) ([]map[str
 }
 
 func (sim *simulator) processBlock(ctx context.Context, block *simBlock, header, parent *types.Header, headers []*types.Header, timeout time.Duration) (*types.Block, []simCallResult, error) {
-	// Set header fields that depend only on parent block.
-	// Parent hash is needed for evm.GetHashFn to work.
-	header.ParentHash = parent.Hash()
-	if sim.chainConfig.IsLondon(header.Number) {
-		// In non-validation mode base fee is set to 0 if it is not overridden.
-		// This is because it creates an edge case in EVM where gasPrice < baseFee.
-		// Base fee could have been overridden.
-		if header.BaseFee == nil {
-			if sim.validate {
-				header.BaseFee = eip1559.CalcBaseFee(sim.chainConfig, parent)
-			} else {
-				header.BaseFee = big.NewInt(0)
-			}
-		}
-	}
-	if sim.chainConfig.IsCancun(header.Number, header.Time) {
-		var excess uint64
-		if sim.chainConfig.IsCancun(parent.Number, parent.Time) {
-			excess = eip4844.CalcExcessBlobGas(*parent.ExcessBlobGas, *parent.BlobGasUsed)
-		} else {
-			excess = eip4844.CalcExcessBlobGas(0, 0)
-		}
-		header.ExcessBlobGas = &excess
-	}
-	blockContext := core.NewEVMBlockContext(header, sim.newSimulatedChainContext(ctx, headers), nil)
-	if block.BlockOverrides.BlobBaseFee != nil {
-		blockContext.BlobBaseFee = block.BlockOverrides.BlobBaseFee.ToInt()
-	}
-	precompiles := sim.activePrecompiles(sim.base)
-	// State overrides are applied prior to execution of a block
-	if err := block.StateOverrides.Apply(sim.state, precompiles); err != nil {
+	blockContext, precompiles, err := sim.prepareBlock(ctx, block, header, parent, headers)
+	if err != nil {
 		return nil, nil, err
 	}
 	var (
@@ -253,6 +234,145 @@ func (sim *simulator) processBlock(ctx context.Context, block *simBlock, header,
 	return b, callResults, nil
 }
 
+// estimate estimates the gas of the calls of a block in order, each on top of
+// the state left by the ones before. Failing calls are reported in their result
+// and still applied if includable, as they would be when sent in sequence.
+func (sim *simulator) estimate(ctx context.Context, block simBlock, errs *abi.ABI) ([]simEstimateResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var (
+		cancel  context.CancelFunc
+		timeout = sim.b.RPCEVMTimeout()
+	)
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	blocks, err := sim.sanitizeChain([]simBlock{block})
+	if err != nil {
+		return nil, err
+	}
+	headers, err := sim.makeHeaders(blocks)
+	if err != nil {
+		return nil, err
+	}
+	// Overriding the block number may leave a gap to the base block, which is
+	// filled with empty blocks to make their hashes available to the calls.
+	var (
+		last   = len(blocks) - 1
+		header = headers[last]
+		parent = sim.base
+	)
+	for i := 0; i < last; i++ {
+		if _, _, err := sim.processBlock(ctx, &blocks[i], headers[i], parent, headers[:i], timeout); err != nil {
+			return nil, err
+		}
+		parent = headers[i]
+	}
+	blockContext, precompiles, err := sim.prepareBlock(ctx, &blocks[last], header, parent, headers[:last])
+	if err != nil {
+		return nil, err
+	}
+	evm := vm.NewEVM(blockContext, sim.state, sim.chainConfig, vm.Config{NoBaseFee: !sim.validate})
+	if precompiles != nil {
+		evm.SetPrecompiles(precompiles)
+	}
+	opts := &gasestimator.Options{
+		Config:      sim.chainConfig,
+		Chain:       sim.newSimulatedChainContext(ctx, headers[:last]),
+		Header:      header,
+		State:       sim.state,
+		BlobBaseFee: blockContext.BlobBaseFee,
+		ErrorRatio:  estimateGasErrorRatio,
+	}
+	var (
+		gasUsed uint64
+		results = make([]simEstimateResult, len(blocks[last].Calls))
+	)
+	for i, call := range blocks[last].Calls {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := sim.sanitizeCall(&call, sim.state, header, blockContext, &gasUsed); err != nil {
+			return nil, err
+		}
+		msg := call.ToMessage(header.BaseFee, !sim.validate, true)
+		gas, revert, err := gasestimator.Estimate(ctx, msg, opts, sim.gp.Gas())
+		switch {
+		case err == nil:
+			results[i].Gas = hexutil.Uint64(gas)
+			msg.GasLimit = gas
+		case len(revert) > 0:
+			revertErr := newRevertErrorWithABI(revert, errs)
+			results[i].Error = &callError{Message: revertErr.Error(), Code: errCodeReverted, Data: revertErr.ErrorData().(string)}
+		default:
+			results[i].Error = &callError{Message: err.Error(), Code: errCodeVMError}
+		}
+		// Apply the call with the estimated gas for the next ones to see its effects
+		evm.SetTxContext(core.NewEVMTxContext(msg))
+		result, err := applyMessageWithEVM(ctx, evm, msg, timeout, sim.gp)
+		if err != nil {
+			if results[i].Error == nil {
+				txErr := txValidationError(err)
+				results[i].Error = &callError{Message: txErr.Message, Code: txErr.Code}
+			}
+			continue
+		}
+		if sim.chainConfig.IsByzantium(blockContext.BlockNumber) {
+			sim.state.Finalise(true)
+		} else {
+			sim.state.IntermediateRoot(sim.chainConfig.IsEIP158(blockContext.BlockNumber))
+		}
+		gasUsed += result.UsedGas
+		results[i].GasUsed = hexutil.Uint64(result.UsedGas)
+		results[i].ReturnValue = result.Return()
+	}
+	return results, nil
+}
+
+// prepareBlock sets the header fields depending on the parent block and applies
+// the state overrides of the block, returning the context to execute it in.
+func (sim *simulator) prepareBlock(ctx context.Context, block *simBlock, header, parent *types.Header, headers []*types.Header) (vm.BlockContext, vm.PrecompiledContracts, error) {
+	// Set header fields that depend only on parent block.
+	// Parent hash is needed for evm.GetHashFn to work.
+	header.ParentHash = parent.Hash()
+	if sim.chainConfig.IsLondon(header.Number) {
+		// In non-validation mode base fee is set to 0 if it is not overridden.
+		// This is because it creates an edge case in EVM where gasPrice < baseFee.
+		// Base fee could have been overridden.
+		if header.BaseFee == nil {
+			if sim.validate {
+				header.BaseFee = eip1559.CalcBaseFee(sim.chainConfig, parent)
+			} else {
+				header.BaseFee = big.NewInt(0)
+			}
+		}
+	}
+	if sim.chainConfig.IsCancun(header.Number, header.Time) {
+		var excess uint64
+		if sim.chainConfig.IsCancun(parent.Number, parent.Time) {
+			excess = eip4844.CalcExcessBlobGas(*parent.ExcessBlobGas, *parent.BlobGasUsed)
+		} else {
+			excess = eip4844.CalcExcessBlobGas(0, 0)
+		}
+		header.ExcessBlobGas = &excess
+	}
+	blockContext := core.NewEVMBlockContext(header, sim.newSimulatedChainContext(ctx, headers), nil)
+	if block.BlockOverrides.BlobBaseFee != nil {
+		blockContext.BlobBaseFee = block.BlockOverrides.BlobBaseFee.ToInt()
+	}
+	precompiles := sim.activePrecompiles(sim.base)
+	// State overrides are applied prior to execution of a block
+	if err := block.StateOverrides.Apply(sim.state, precompiles); err != nil {
+		return vm.BlockContext{}, nil, err
+	}
+	return blockContext, precompiles, nil
+}
+
 // repairLogs updates the block hash in the logs present in the result of
 // a simulated block. This is needed as during execution when logs are collected
 // the block hash is not known.
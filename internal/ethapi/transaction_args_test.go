@@ -34,6 +34,7 @@ import (
 	"github.com/rajchain/go-rajchain/core/state"
 	"github.com/rajchain/go-rajchain/core/types"
 	"github.com/rajchain/go-rajchain/core/vm"
+	"github.com/rajchain/go-rajchain/eth/gasprice"
 	"github.com/rajchain/go-rajchain/ethdb"
 	"github.com/rajchain/go-rajchain/event"
 	"github.com/rajchain/go-rajchain/params"
@@ -326,6 +327,9 @@ func (b *backendMock) SyncProgress() rajchain.SyncProgress { return rajchain.Syn
 func (b *backendMock) FeeHistory(ctx context.Context, blockCount uint64, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, []*big.Int, []float64, error) {
 	return nil, nil, nil, nil, nil, nil, nil
 }
+func (b *backendMock) FeeEstimates(ctx context.Context) (*gasprice.FeeEstimates, error) {
+	return nil, nil
+}
 func (b *backendMock) ChainDb() ethdb.Database           { return nil }
 func (b *backendMock) AccountManager() *accounts.Manager { return nil }
 func (b *backendMock) ExtRPCEnabled() bool               { return false }
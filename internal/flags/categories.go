@@ -31,6 +31,7 @@ const (
 	NetworkingCategory = "NETWORKING"
 	MinerCategory      = "MINER"
 	GasPriceCategory   = "GAS PRICE ORACLE"
+	BundlerCategory    = "ACCOUNT ABSTRACTION BUNDLER"
 	VMCategory         = "VIRTUAL MACHINE"
 	LoggingCategory    = "LOGGING AND DEBUGGING"
 	MetricsCategory    = "METRICS AND STATS"
@@ -18,15 +18,16 @@
 package web3ext
 
 var Modules = map[string]string{
-	"admin":  AdminJs,
-	"clique": CliqueJs,
-	"debug":  DebugJs,
-	"eth":    EthJs,
-	"miner":  MinerJs,
-	"net":    NetJs,
-	"rpc":    RpcJs,
-	"txpool": TxpoolJs,
-	"dev":    DevJs,
+	"admin":      AdminJs,
+	"clique":     CliqueJs,
+	"debug":      DebugJs,
+	"eth":        EthJs,
+	"miner":      MinerJs,
+	"net":        NetJs,
+	"rpc":        RpcJs,
+	"txpool":     TxpoolJs,
+	"dev":        DevJs,
+	"checkpoint": CheckpointJs,
 }
 
 const CliqueJs = `
@@ -110,6 +111,16 @@ web3._extend({
 			call: 'admin_removeTrustedPeer',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'addAllowedNode',
+			call: 'admin_addAllowedNode',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'removeAllowedNode',
+			call: 'admin_removeAllowedNode',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'exportChain',
 			call: 'admin_exportChain',
@@ -168,6 +179,18 @@ web3._extend({
 			name: 'peers',
 			getter: 'admin_peers'
 		}),
+		new web3._extend.Property({
+			name: 'allowedNodes',
+			getter: 'admin_allowedNodes'
+		}),
+		new web3._extend.Property({
+			name: 'rejectedNodes',
+			getter: 'admin_rejectedNodes'
+		}),
+		new web3._extend.Property({
+			name: 'bandwidth',
+			getter: 'admin_bandwidth'
+		}),
 		new web3._extend.Property({
 			name: 'datadir',
 			getter: 'admin_datadir'
@@ -212,6 +235,16 @@ web3._extend({
 			call: 'debug_getRawTransaction',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'executionWitness',
+			call: 'debug_executionWitness',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'syncProgress',
+			call: 'debug_syncProgress',
+			params: 0
+		}),
 		new web3._extend.Method({
 			name: 'setHead',
 			call: 'debug_setHead',
@@ -415,6 +448,16 @@ web3._extend({
 			call: 'debug_getBadBlocks',
 			params: 0,
 		}),
+		new web3._extend.Method({
+			name: 'getBadBlockReports',
+			call: 'debug_getBadBlockReports',
+			params: 0,
+		}),
+		new web3._extend.Method({
+			name: 'getBadBlockReport',
+			call: 'debug_getBadBlockReport',
+			params: 1,
+		}),
 		new web3._extend.Method({
 			name: 'storageRangeAt',
 			call: 'debug_storageRangeAt',
@@ -567,6 +610,12 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter],
 		}),
+		new web3._extend.Method({
+			name: 'estimateGasSequence',
+			call: 'eth_estimateGasSequence',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputDefaultBlockNumberFormatter],
+		}),
 		new web3._extend.Method({
 			name: 'feeHistory',
 			call: 'eth_feeHistory',
@@ -614,6 +663,10 @@ web3._extend({
 			getter: 'eth_maxPriorityFeePerGas',
 			outputFormatter: web3._extend.utils.toBigNumber
 		}),
+		new web3._extend.Property({
+			name: 'feeEstimates',
+			getter: 'eth_feeEstimates'
+		}),
 	]
 });
 `
@@ -717,6 +770,32 @@ web3._extend({
 			call: 'dev_setFeeRecipient',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'snapshot',
+			call: 'dev_snapshot',
+		}),
+		new web3._extend.Method({
+			name: 'revert',
+			call: 'dev_revert',
+			params: 1
+		}),
 	],
 });
 `
+
+const CheckpointJs = `
+web3._extend({
+	property: 'checkpoint',
+	methods: [],
+	properties: [
+		new web3._extend.Property({
+			name: 'latest',
+			getter: 'checkpoint_latest'
+		}),
+		new web3._extend.Property({
+			name: 'status',
+			getter: 'checkpoint_status'
+		}),
+	]
+});
+`
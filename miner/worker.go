@@ -230,6 +230,10 @@ func (miner *Miner) prepareWork(genParams *generateParams, witness bool) (*envir
 		log.Error("Failed to create sealing context", "err", err)
 		return nil, err
 	}
+	if err := core.ProcessVerkleTransition(env.state, miner.chainConfig, header); err != nil {
+		log.Error("Failed to convert sealing state", "err", err)
+		return nil, err
+	}
 	if header.ParentBeaconRoot != nil {
 		core.ProcessBeaconBlockRoot(*header.ParentBeaconRoot, env.evm)
 	}
@@ -18,6 +18,7 @@ package node
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -120,6 +121,68 @@ func (api *adminAPI) RemoveTrustedPeer(url string) (bool, error) {
 	return true, nil
 }
 
+// AddAllowedNode permits a node to connect to the permissioned server. The node
+// is given as an enode URL, a node record or a hex node ID.
+func (api *adminAPI) AddAllowedNode(node string) (bool, error) {
+	allowlist, err := api.allowlist()
+	if err != nil {
+		return false, err
+	}
+	id, err := p2p.ParseAllowlistEntry(node)
+	if err != nil {
+		return false, fmt.Errorf("invalid node: %v", err)
+	}
+	allowlist.Add(p2p.AllowlistSourceAdmin, id)
+	return true, nil
+}
+
+// RemoveAllowedNode revokes the permission of a node added through the admin
+// API, disconnecting it unless another source of the allowlist lists it.
+func (api *adminAPI) RemoveAllowedNode(node string) (bool, error) {
+	allowlist, err := api.allowlist()
+	if err != nil {
+		return false, err
+	}
+	id, err := p2p.ParseAllowlistEntry(node)
+	if err != nil {
+		return false, fmt.Errorf("invalid node: %v", err)
+	}
+	return allowlist.Remove(p2p.AllowlistSourceAdmin, id), nil
+}
+
+// AllowedNodes returns the nodes permitted to connect to the permissioned
+// server, grouped by the source of the allowlist listing them.
+func (api *adminAPI) AllowedNodes() (map[string][]enode.ID, error) {
+	allowlist, err := api.allowlist()
+	if err != nil {
+		return nil, err
+	}
+	return allowlist.Nodes(), nil
+}
+
+// RejectedNodes returns the audit records of the nodes whose connections were
+// refused by the permissioned server, the most recently rejected first.
+func (api *adminAPI) RejectedNodes() ([]p2p.AllowlistRejection, error) {
+	allowlist, err := api.allowlist()
+	if err != nil {
+		return nil, err
+	}
+	return allowlist.Rejections(), nil
+}
+
+// allowlist retrieves the node allowlist of the running server.
+func (api *adminAPI) allowlist() (*p2p.Allowlist, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	allowlist := server.Allowlist()
+	if allowlist == nil {
+		return nil, errors.New("p2p server is not permissioned")
+	}
+	return allowlist, nil
+}
+
 // PeerEvents creates an RPC subscription which receives peer events from the
 // node's p2p.Server
 func (api *adminAPI) PeerEvents(ctx context.Context) (*rpc.Subscription, error) {
@@ -307,6 +370,20 @@ func (api *adminAPI) Peers() ([]*p2p.PeerInfo, error) {
 	return server.PeersInfo(), nil
 }
 
+// Bandwidth retrieves the traffic with all peers by protocol and priority class,
+// along with the configured bandwidth caps.
+func (api *adminAPI) Bandwidth() (*p2p.BandwidthInfo, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	info := server.BandwidthInfo()
+	if info == nil {
+		return nil, ErrNodeStopped
+	}
+	return info, nil
+}
+
 // NodeInfo retrieves all the information we know about the host node at the
 // protocol granularity.
 func (api *adminAPI) NodeInfo() (*p2p.NodeInfo, error) {
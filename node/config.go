@@ -96,6 +96,14 @@ type Config struct {
 	// SmartCardDaemonPath is the path to the smartcard daemon's socket.
 	SmartCardDaemonPath string `toml:",omitempty"`
 
+	// KeyService is the endpoint of a remote key management service speaking the
+	// HTTP KMS protocol, whose keys are made available as accounts.
+	KeyService string `toml:",omitempty"`
+
+	// KeyServiceTokenFile is the path to the file holding the bearer token to
+	// authenticate with against the key service.
+	KeyServiceTokenFile string `toml:",omitempty"`
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the data directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
@@ -0,0 +1,377 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common/lru"
+	"github.com/rajchain/go-rajchain/p2p/enode"
+)
+
+// Sources the node allowlist is assembled from.
+const (
+	AllowlistSourceFile     = "file"     // Nodes listed in the allowlist file
+	AllowlistSourceAdmin    = "admin"    // Nodes added through the admin API
+	AllowlistSourceContract = "contract" // Nodes listed in the on-chain registry
+)
+
+// allowlistReloadInterval is the time between checks of the allowlist file for
+// modifications.
+const allowlistReloadInterval = 5 * time.Second
+
+// allowlistRejectLogInterval is the minimum time between two logs, at default
+// verbosity, of the connections rejected from the same node.
+const allowlistRejectLogInterval = time.Minute
+
+// allowlistRejectLimit is the number of nodes whose rejected connections are
+// kept track of for auditing, the least recently rejected ones being evicted.
+const allowlistRejectLimit = 1024
+
+// AllowlistRejection is the audit record of the connections of a node refused
+// by the allowlist.
+type AllowlistRejection struct {
+	ID     enode.ID  `json:"id"`
+	Addr   string    `json:"addr"`   // Remote address of the last rejected connection
+	Reason string    `json:"reason"` // Reason of the last rejection
+	Count  uint64    `json:"count"`  // Number of rejected connections
+	First  time.Time `json:"first"`  // Time of the first rejected connection
+	Last   time.Time `json:"last"`   // Time of the last rejected connection
+
+	logged time.Time // Time the node's rejections were last logged at default verbosity
+}
+
+// Allowlist is the set of nodes permitted to connect to a permissioned server,
+// assembled from several independently updated sources. A node is allowed if
+// any of the sources lists it.
+//
+// Sources loaded after the server started are announced through Expect. Until
+// all of them are loaded, the allowlist is incomplete and the server only
+// accepts its static and trusted peers.
+type Allowlist struct {
+	sources map[string]map[enode.ID]struct{}
+	pending map[string]struct{} // Expected sources not loaded yet
+	changed chan struct{}       // Notification channel for the server to drop disallowed peers
+	lock    sync.RWMutex
+
+	rejects    lru.BasicLRU[enode.ID, *AllowlistRejection] // Audit records of the rejected nodes
+	rejectLock sync.Mutex
+}
+
+// newAllowlist creates an empty allowlist.
+func newAllowlist() *Allowlist {
+	return &Allowlist{
+		sources: make(map[string]map[enode.ID]struct{}),
+		pending: make(map[string]struct{}),
+		changed: make(chan struct{}, 1),
+		rejects: lru.NewBasicLRU[enode.ID, *AllowlistRejection](allowlistRejectLimit),
+	}
+}
+
+// Expect marks a source as pending, holding back non-static peers until the
+// source is loaded with Set.
+func (a *Allowlist) Expect(source string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if _, ok := a.sources[source]; !ok {
+		a.pending[source] = struct{}{}
+	}
+}
+
+// Loaded reports whether all expected sources are loaded.
+func (a *Allowlist) Loaded() bool {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	return len(a.pending) == 0
+}
+
+// Allowed reports whether the node is permitted to connect.
+func (a *Allowlist) Allowed(id enode.ID) bool {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	for _, nodes := range a.sources {
+		if _, ok := nodes[id]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Nodes returns the allowed nodes grouped by the source listing them.
+func (a *Allowlist) Nodes() map[string][]enode.ID {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	nodes := make(map[string][]enode.ID, len(a.sources))
+	for source, ids := range a.sources {
+		list := make([]enode.ID, 0, len(ids))
+		for id := range ids {
+			list = append(list, id)
+		}
+		slices.SortFunc(list, func(a, b enode.ID) int {
+			return bytes.Compare(a[:], b[:])
+		})
+		nodes[source] = list
+	}
+	return nodes
+}
+
+// Set replaces the nodes listed by a source.
+func (a *Allowlist) Set(source string, ids []enode.ID) {
+	nodes := make(map[enode.ID]struct{}, len(ids))
+	for _, id := range ids {
+		nodes[id] = struct{}{}
+	}
+	a.lock.Lock()
+	_, pending := a.pending[source]
+	if !pending && maps.Equal(a.sources[source], nodes) {
+		a.lock.Unlock()
+		return
+	}
+	a.sources[source] = nodes
+	delete(a.pending, source)
+	a.lock.Unlock()
+
+	a.notify()
+}
+
+// Add lists a node in a source.
+func (a *Allowlist) Add(source string, id enode.ID) {
+	a.lock.Lock()
+	if a.sources[source] == nil {
+		a.sources[source] = make(map[enode.ID]struct{})
+	}
+	a.sources[source][id] = struct{}{}
+	a.lock.Unlock()
+}
+
+// Remove delists a node from a source, returning whether it was listed.
+func (a *Allowlist) Remove(source string, id enode.ID) bool {
+	a.lock.Lock()
+	_, ok := a.sources[source][id]
+	delete(a.sources[source], id)
+	a.lock.Unlock()
+
+	if ok {
+		a.notify()
+	}
+	return ok
+}
+
+// Rejections returns the audit records of the nodes whose connections were
+// refused, the most recently rejected first.
+func (a *Allowlist) Rejections() []AllowlistRejection {
+	a.rejectLock.Lock()
+	defer a.rejectLock.Unlock()
+
+	rejects := make([]AllowlistRejection, 0, a.rejects.Len())
+	for _, id := range a.rejects.Keys() {
+		r, _ := a.rejects.Peek(id)
+		rejects = append(rejects, *r)
+	}
+	slices.SortFunc(rejects, func(a, b AllowlistRejection) int {
+		return b.Last.Compare(a.Last)
+	})
+	return rejects
+}
+
+// reject records a refused connection of a node, returning its audit record
+// and whether the rejection is due to be logged at default verbosity.
+func (a *Allowlist) reject(id enode.ID, addr string, reason string, now time.Time) (AllowlistRejection, bool) {
+	a.rejectLock.Lock()
+	defer a.rejectLock.Unlock()
+
+	r, ok := a.rejects.Get(id)
+	if !ok {
+		r = &AllowlistRejection{ID: id, First: now}
+		a.rejects.Add(id, r)
+	}
+	r.Addr, r.Reason, r.Last = addr, reason, now
+	r.Count++
+
+	if now.Sub(r.logged) < allowlistRejectLogInterval {
+		return *r, false
+	}
+	r.logged = now
+	return *r, true
+}
+
+// notify signals the server that nodes might have been delisted.
+func (a *Allowlist) notify() {
+	select {
+	case a.changed <- struct{}{}:
+	default:
+	}
+}
+
+// ParseAllowlistEntry parses a node listed in the allowlist, given as an enode
+// URL, a node record or a hex node ID.
+func ParseAllowlistEntry(entry string) (enode.ID, error) {
+	if strings.HasPrefix(entry, "enode://") || strings.HasPrefix(entry, "enr:") {
+		node, err := enode.Parse(enode.ValidSchemes, entry)
+		if err != nil {
+			return enode.ID{}, err
+		}
+		return node.ID(), nil
+	}
+	return enode.ParseID(entry)
+}
+
+// loadAllowlistFile parses a file listing the allowed nodes, one per line in
+// any of the formats accepted by ParseAllowlistEntry. Empty lines and lines
+// starting with '#' are ignored.
+func loadAllowlistFile(path string) ([]enode.ID, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var (
+		ids     []enode.ID
+		scanner = bufio.NewScanner(file)
+	)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		id, err := ParseAllowlistEntry(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, scanner.Err()
+}
+
+// setupAllowlist creates the allowlist of a permissioned server, loading the
+// allowlist file if configured.
+func (srv *Server) setupAllowlist() error {
+	if srv.Allowlist() == nil || srv.AllowlistFile == "" {
+		return nil
+	}
+	ids, err := loadAllowlistFile(srv.AllowlistFile)
+	if err != nil {
+		return err
+	}
+	srv.allowlist.Set(AllowlistSourceFile, ids)
+	srv.log.Info("Loaded node allowlist", "file", srv.AllowlistFile, "nodes", len(ids))
+	return nil
+}
+
+// allowlistLoop reloads the allowlist file whenever it is modified.
+func (srv *Server) allowlistLoop() {
+	defer srv.loopWG.Done()
+
+	var modified time.Time
+	if stat, err := os.Stat(srv.AllowlistFile); err == nil {
+		modified = stat.ModTime()
+	}
+	ticker := time.NewTicker(allowlistReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			stat, err := os.Stat(srv.AllowlistFile)
+			if err != nil || stat.ModTime().Equal(modified) {
+				continue
+			}
+			modified = stat.ModTime()
+
+			ids, err := loadAllowlistFile(srv.AllowlistFile)
+			if err != nil {
+				srv.log.Error("Failed to reload node allowlist, keeping the previous one", "file", srv.AllowlistFile, "err", err)
+				continue
+			}
+			srv.allowlist.Set(AllowlistSourceFile, ids)
+			srv.log.Info("Reloaded node allowlist", "file", srv.AllowlistFile, "nodes", len(ids))
+
+		case <-srv.quit:
+			return
+		}
+	}
+}
+
+// Allowlist returns the allowlist of the nodes permitted to connect, or nil if
+// the server is not permissioned. It is available before the server is started,
+// for the sources loaded later to be expected.
+func (srv *Server) Allowlist() *Allowlist {
+	if !srv.Permissioned {
+		return nil
+	}
+	srv.allowlistOnce.Do(func() {
+		srv.allowlist = newAllowlist()
+	})
+	return srv.allowlist
+}
+
+// allowedConn reports whether the remote node of a connection is permitted to
+// connect, recording the rejection otherwise. Every rejection is logged at low
+// verbosity, and at most once per interval for each node at default verbosity.
+// While the allowlist is not loaded, only static and trusted peers are accepted.
+func (srv *Server) allowedConn(c *conn) bool {
+	if srv.allowlist == nil {
+		return true
+	}
+	if !srv.allowlist.Loaded() {
+		if c.is(staticDialedConn | trustedConn) {
+			return true
+		}
+		permissionRejectMeter.Mark(1)
+		srv.log.Trace("Rejected connection, allowlist not loaded", "id", c.node.ID(), "addr", c.fd.RemoteAddr(), "conn", c.flags)
+		if r, ok := srv.allowlist.reject(c.node.ID(), c.fd.RemoteAddr().String(), "allowlist not loaded", time.Now()); ok {
+			srv.log.Info("Rejected connections, allowlist not loaded", "id", r.ID, "addr", r.Addr, "rejected", r.Count)
+		}
+		return false
+	}
+	if srv.allowlist.Allowed(c.node.ID()) {
+		return true
+	}
+	permissionRejectMeter.Mark(1)
+	srv.log.Debug("Rejected connection from node not in allowlist", "id", c.node.ID(), "addr", c.fd.RemoteAddr(), "conn", c.flags)
+	if r, ok := srv.allowlist.reject(c.node.ID(), c.fd.RemoteAddr().String(), "not in allowlist", time.Now()); ok {
+		srv.log.Warn("Rejected connections from node not in allowlist", "id", r.ID, "addr", r.Addr, "rejected", r.Count)
+	}
+	return false
+}
+
+// dropDisallowed disconnects the peers which were delisted from the allowlist.
+func (srv *Server) dropDisallowed(peers map[enode.ID]*Peer) {
+	if !srv.allowlist.Loaded() {
+		return
+	}
+	for id, p := range peers {
+		if !srv.allowlist.Allowed(id) {
+			permissionDropMeter.Mark(1)
+			srv.log.Warn("Dropping peer removed from allowlist", "id", id, "addr", p.RemoteAddr())
+			p.Disconnect(DiscUselessPeer)
+		}
+	}
+}
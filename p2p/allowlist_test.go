@@ -0,0 +1,254 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rajchain/go-rajchain/internal/testlog"
+	"github.com/rajchain/go-rajchain/log"
+	"github.com/rajchain/go-rajchain/p2p/enode"
+	"github.com/rajchain/go-rajchain/p2p/enr"
+)
+
+func TestLoadAllowlistFile(t *testing.T) {
+	var (
+		key  = newkey()
+		node = enode.NewV4(&key.PublicKey, net.IP{127, 0, 0, 1}, 30303, 30303)
+		id   = randomID()
+		path = filepath.Join(t.TempDir(), "allowlist")
+	)
+	content := fmt.Sprintf("# permissioned nodes\n\n%s\n  %x  \n", node.URLv4(), id[:])
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := loadAllowlistFile(path)
+	if err != nil {
+		t.Fatalf("failed to load allowlist: %v", err)
+	}
+	if want := []enode.ID{node.ID(), id}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("allowlist mismatch: have %v, want %v", ids, want)
+	}
+	// Invalid entries are reported with their line
+	if err := os.WriteFile(path, []byte("# comment\nnot-a-node\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadAllowlistFile(path); err == nil {
+		t.Fatal("invalid allowlist loaded")
+	}
+}
+
+func TestAllowlistSources(t *testing.T) {
+	var (
+		list = newAllowlist()
+		a, b = randomID(), randomID()
+	)
+	list.Set(AllowlistSourceFile, []enode.ID{a})
+	list.Add(AllowlistSourceAdmin, a)
+	list.Add(AllowlistSourceAdmin, b)
+	<-list.changed
+
+	// Nodes stay allowed as long as any source lists them
+	list.Set(AllowlistSourceFile, nil)
+	if !list.Allowed(a) || !list.Allowed(b) {
+		t.Fatal("node listed by admin source not allowed")
+	}
+	if !list.Remove(AllowlistSourceAdmin, a) {
+		t.Fatal("listed node not removed")
+	}
+	if list.Remove(AllowlistSourceAdmin, a) {
+		t.Fatal("delisted node removed twice")
+	}
+	if list.Allowed(a) {
+		t.Fatal("delisted node allowed")
+	}
+	select {
+	case <-list.changed:
+	default:
+		t.Fatal("no change notification after removal")
+	}
+	// Setting an identical list doesn't notify
+	list.Set(AllowlistSourceContract, []enode.ID{b})
+	<-list.changed
+	list.Set(AllowlistSourceContract, []enode.ID{b})
+	select {
+	case <-list.changed:
+		t.Fatal("change notification without change")
+	default:
+	}
+}
+
+// Tests that the rejected connections are recorded for auditing, and logged at
+// default verbosity at most once per interval for each node.
+func TestAllowlistRejections(t *testing.T) {
+	var (
+		list = newAllowlist()
+		a, b = randomID(), randomID()
+		now  = time.Unix(1700000000, 0)
+	)
+	if r, logged := list.reject(a, "10.0.0.1:30303", "not in allowlist", now); !logged || r.Count != 1 {
+		t.Fatalf("first rejection: logged %t, count %d", logged, r.Count)
+	}
+	if r, logged := list.reject(a, "10.0.0.2:30303", "not in allowlist", now.Add(time.Second)); logged || r.Count != 2 {
+		t.Fatalf("repeated rejection: logged %t, count %d", logged, r.Count)
+	}
+	if _, logged := list.reject(b, "10.0.0.3:30303", "allowlist not loaded", now.Add(2*time.Second)); !logged {
+		t.Fatal("rejection of another node not logged")
+	}
+	if _, logged := list.reject(a, "10.0.0.2:30303", "not in allowlist", now.Add(allowlistRejectLogInterval)); !logged {
+		t.Fatal("rejection not logged after the interval")
+	}
+	want := []AllowlistRejection{
+		{ID: a, Addr: "10.0.0.2:30303", Reason: "not in allowlist", Count: 3, First: now, Last: now.Add(allowlistRejectLogInterval), logged: now.Add(allowlistRejectLogInterval)},
+		{ID: b, Addr: "10.0.0.3:30303", Reason: "allowlist not loaded", Count: 1, First: now.Add(2 * time.Second), Last: now.Add(2 * time.Second), logged: now.Add(2 * time.Second)},
+	}
+	if have := list.Rejections(); !reflect.DeepEqual(have, want) {
+		t.Fatalf("rejections mismatch:\nhave %+v\nwant %+v", have, want)
+	}
+}
+
+func TestServerPermissioned(t *testing.T) {
+	allowed, denied := newkey(), newkey()
+
+	path := filepath.Join(t.TempDir(), "allowlist")
+	if err := os.WriteFile(path, []byte(enode.PubkeyToIDV4(&allowed.PublicKey).String()+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	srv := &Server{
+		Config: Config{
+			PrivateKey:    newkey(),
+			MaxPeers:      10,
+			NoDial:        true,
+			NoDiscovery:   true,
+			Permissioned:  true,
+			AllowlistFile: path,
+			Logger:        testlog.Logger(t, log.LvlTrace),
+		},
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start: %v", err)
+	}
+	defer srv.Stop()
+
+	newconn := func(key *ecdsa.PrivateKey) *conn {
+		fd, _ := net.Pipe()
+		tx := newTestTransport(&key.PublicKey, fd, nil)
+		node := enode.SignNull(new(enr.Record), enode.PubkeyToIDV4(&key.PublicKey))
+		return &conn{fd: fd, transport: tx, flags: inboundConn, node: node, cont: make(chan error)}
+	}
+	if err := srv.checkpoint(newconn(denied), srv.checkpointPostHandshake); err != DiscUselessPeer {
+		t.Fatalf("wrong error for conn not in allowlist: %v", err)
+	}
+	if rejects := srv.Allowlist().Rejections(); len(rejects) != 1 || rejects[0].ID != enode.PubkeyToIDV4(&denied.PublicKey) {
+		t.Fatalf("rejected conn not audited: %+v", rejects)
+	}
+	// Nodes are also refused when dialing
+	dialer := &dialScheduler{dialConfig: dialConfig{allowlist: srv.Allowlist()}}
+	if err := dialer.checkDial(enode.NewV4(&denied.PublicKey, net.IP{127, 0, 0, 1}, 30303, 0)); err != errNotAllowed {
+		t.Fatalf("wrong error for dial not in allowlist: %v", err)
+	}
+	// Allowed nodes connect, and are dropped when removed from the allowlist
+	if err := srv.checkpoint(newconn(allowed), srv.checkpointAddPeer); err != nil {
+		t.Fatalf("allowed conn refused: %v", err)
+	}
+	events := make(chan *PeerEvent, 4)
+	sub := srv.SubscribeEvents(events)
+	defer sub.Unsubscribe()
+
+	srv.Allowlist().Set(AllowlistSourceFile, nil)
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type == PeerEventTypeDrop {
+				return
+			}
+		case <-timeout:
+			t.Fatal("delisted peer not dropped")
+		}
+	}
+}
+
+// Tests that only static and trusted peers are accepted while an expected
+// allowlist source is not loaded.
+func TestServerAllowlistPending(t *testing.T) {
+	static, dynamic := newkey(), newkey()
+
+	srv := &Server{
+		Config: Config{
+			PrivateKey:   newkey(),
+			MaxPeers:     10,
+			NoDial:       true,
+			NoDiscovery:  true,
+			Permissioned: true,
+			Logger:       testlog.Logger(t, log.LvlTrace),
+		},
+	}
+	srv.Allowlist().Expect(AllowlistSourceContract)
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start: %v", err)
+	}
+	defer srv.Stop()
+
+	newconn := func(key *ecdsa.PrivateKey, flags connFlag) *conn {
+		fd, _ := net.Pipe()
+		tx := newTestTransport(&key.PublicKey, fd, nil)
+		node := enode.SignNull(new(enr.Record), enode.PubkeyToIDV4(&key.PublicKey))
+		return &conn{fd: fd, transport: tx, flags: flags, node: node, cont: make(chan error)}
+	}
+	var (
+		dynamicID  = enode.PubkeyToIDV4(&dynamic.PublicKey)
+		staticNode = enode.NewV4(&static.PublicKey, net.IP{127, 0, 0, 1}, 30303, 0)
+		dialer     = &dialScheduler{
+			dialConfig: dialConfig{allowlist: srv.Allowlist()},
+			static:     map[enode.ID]*dialTask{staticNode.ID(): newDialTask(staticNode, staticDialedConn)},
+		}
+	)
+	if srv.Allowlist().Loaded() {
+		t.Fatal("allowlist loaded before the expected source")
+	}
+	if err := srv.checkpoint(newconn(dynamic, inboundConn), srv.checkpointPostHandshake); err != DiscUselessPeer {
+		t.Fatalf("wrong error for conn before allowlist loaded: %v", err)
+	}
+	if err := dialer.checkDial(enode.NewV4(&dynamic.PublicKey, net.IP{127, 0, 0, 1}, 30303, 0)); err != errNotAllowed {
+		t.Fatalf("wrong error for dynamic dial before allowlist loaded: %v", err)
+	}
+	if err := srv.checkpoint(newconn(static, staticDialedConn), srv.checkpointPostHandshake); err != nil {
+		t.Fatalf("static conn refused before allowlist loaded: %v", err)
+	}
+	if err := dialer.checkDial(staticNode); err != nil {
+		t.Fatalf("static dial refused before allowlist loaded: %v", err)
+	}
+	// Once loaded, the listed nodes are accepted
+	srv.Allowlist().Set(AllowlistSourceContract, []enode.ID{dynamicID})
+	if !srv.Allowlist().Loaded() {
+		t.Fatal("allowlist not loaded after the expected source")
+	}
+	if err := srv.checkpoint(newconn(dynamic, inboundConn), srv.checkpointPostHandshake); err != nil {
+		t.Fatalf("listed conn refused: %v", err)
+	}
+	if err := dialer.checkDial(staticNode); err != errNotAllowed {
+		t.Fatalf("wrong error for unlisted static dial: %v", err)
+	}
+}
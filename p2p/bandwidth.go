@@ -0,0 +1,374 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common/mclock"
+)
+
+// TrafficClass is the priority of a message when bandwidth is scarce. Messages
+// of a class are only throttled to make room for higher priority classes once
+// a bandwidth cap is reached.
+type TrafficClass uint8
+
+const (
+	TrafficBlocks  TrafficClass = iota // Block propagation, served first
+	TrafficSync                        // Chain data requested by the local node while syncing
+	TrafficServing                     // Serving data requested by peers, e.g. snap sync
+	TrafficGossip                      // Transaction gossip, served last
+
+	numTrafficClasses = iota
+)
+
+// throttleRetry is the time a throttled message waits for higher priority
+// messages to go first, before checking the cap again.
+const throttleRetry = 10 * time.Millisecond
+
+func (c TrafficClass) String() string {
+	switch c {
+	case TrafficBlocks:
+		return "blocks"
+	case TrafficSync:
+		return "sync"
+	case TrafficServing:
+		return "serving"
+	case TrafficGossip:
+		return "gossip"
+	default:
+		return "unknown"
+	}
+}
+
+// BandwidthConfig contains the bandwidth caps of the server, in bytes of message
+// payload per second. Zero means unlimited.
+type BandwidthConfig struct {
+	MaxIngress     uint64 `toml:",omitempty" json:"maxIngress"`     // Cap on the traffic received from all peers
+	MaxEgress      uint64 `toml:",omitempty" json:"maxEgress"`      // Cap on the traffic sent to all peers
+	MaxPeerIngress uint64 `toml:",omitempty" json:"maxPeerIngress"` // Cap on the traffic received from each peer
+	MaxPeerEgress  uint64 `toml:",omitempty" json:"maxPeerEgress"`  // Cap on the traffic sent to each peer
+
+	// MaxProtocolIngress and MaxProtocolEgress cap the traffic of a protocol
+	// with all peers, by protocol name.
+	MaxProtocolIngress map[string]uint64 `toml:",omitempty" json:"maxProtocolIngress,omitempty"`
+	MaxProtocolEgress  map[string]uint64 `toml:",omitempty" json:"maxProtocolEgress,omitempty"`
+}
+
+// TrafficStats is the traffic accounted for a peer, protocol or class.
+type TrafficStats struct {
+	Ingress         uint64 `json:"ingress"`         // Payload bytes received
+	Egress          uint64 `json:"egress"`          // Payload bytes sent
+	IngressMessages uint64 `json:"ingressMessages"` // Number of messages received
+	EgressMessages  uint64 `json:"egressMessages"`  // Number of messages sent
+	Throttled       string `json:"throttled"`       // Total time messages were held back by the caps
+}
+
+// BandwidthInfo is the bandwidth usage of the server, as reported by
+// admin_bandwidth.
+type BandwidthInfo struct {
+	Limits    BandwidthConfig          `json:"limits"`
+	Total     *TrafficStats            `json:"total"`
+	Protocols map[string]*TrafficStats `json:"protocols"`
+	Classes   map[string]*TrafficStats `json:"classes"`
+}
+
+// trafficCounter accounts the traffic of a peer, protocol or class.
+type trafficCounter struct {
+	ingress, egress         atomic.Uint64
+	ingressMsgs, egressMsgs atomic.Uint64
+	throttled               atomic.Int64
+}
+
+func (c *trafficCounter) add(egress bool, size uint32, throttled time.Duration) {
+	if egress {
+		c.egress.Add(uint64(size))
+		c.egressMsgs.Add(1)
+	} else {
+		c.ingress.Add(uint64(size))
+		c.ingressMsgs.Add(1)
+	}
+	if throttled > 0 {
+		c.throttled.Add(int64(throttled))
+	}
+}
+
+func (c *trafficCounter) stats() *TrafficStats {
+	return &TrafficStats{
+		Ingress:         c.ingress.Load(),
+		Egress:          c.egress.Load(),
+		IngressMessages: c.ingressMsgs.Load(),
+		EgressMessages:  c.egressMsgs.Load(),
+		Throttled:       time.Duration(c.throttled.Load()).String(),
+	}
+}
+
+// rateLimiter is a token bucket capping a traffic rate. Messages larger than
+// the available tokens are let through if the bucket isn't empty, putting it
+// in debt, so the cap holds on average regardless of the message size. Lower
+// priority classes wait while higher priority messages are waiting.
+type rateLimiter struct {
+	rate  float64 // Tokens (bytes) added per second
+	burst float64 // Maximum number of tokens, one second worth of traffic
+	clock mclock.Clock
+
+	lock    sync.Mutex
+	tokens  float64
+	last    mclock.AbsTime
+	waiting [numTrafficClasses]int
+}
+
+// newRateLimiter creates a limiter with the given rate, or nil if unlimited.
+func newRateLimiter(rate uint64, clock mclock.Clock) *rateLimiter {
+	if rate == 0 {
+		return nil
+	}
+	return &rateLimiter{
+		rate:   float64(rate),
+		burst:  float64(rate),
+		clock:  clock,
+		tokens: float64(rate),
+		last:   clock.Now(),
+	}
+}
+
+// wait blocks until a message of the given class and size may pass, returning
+// the time it was held back. It returns false if the cancel channel is closed
+// in the meantime.
+func (l *rateLimiter) wait(class TrafficClass, size uint32, cancel <-chan struct{}) (time.Duration, bool) {
+	if l == nil {
+		return 0, true
+	}
+	start := l.clock.Now()
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for queued := false; ; {
+		l.refill()
+		delay := l.delay(class)
+		if delay == 0 {
+			if queued {
+				l.waiting[class]--
+			}
+			l.tokens -= float64(size)
+			return time.Duration(l.clock.Now() - start), true
+		}
+		if !queued {
+			l.waiting[class]++
+			queued = true
+		}
+		l.lock.Unlock()
+		timer := l.clock.NewTimer(delay)
+		select {
+		case <-timer.C():
+		case <-cancel:
+			timer.Stop()
+			l.lock.Lock()
+			l.waiting[class]--
+			return 0, false
+		}
+		l.lock.Lock()
+	}
+}
+
+// charge takes the tokens of a message let through without waiting, putting the
+// bucket in debt if needed.
+func (l *rateLimiter) charge(size uint32) {
+	if l == nil {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.refill()
+	l.tokens -= float64(size)
+}
+
+// refill adds the tokens accumulated since the last refill.
+func (l *rateLimiter) refill() {
+	now := l.clock.Now()
+	l.tokens += float64(now-l.last) / float64(time.Second) * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+}
+
+// delay returns the time a message of the given class has to wait before
+// checking the bucket again, zero if it may pass now.
+func (l *rateLimiter) delay(class TrafficClass) time.Duration {
+	for c := TrafficClass(0); c < class; c++ {
+		if l.waiting[c] > 0 {
+			return throttleRetry
+		}
+	}
+	if l.tokens > 0 {
+		return 0
+	}
+	// Wait until the debt is paid off, rounding up so the bucket isn't empty
+	// when checked again.
+	return time.Duration(-l.tokens/l.rate*float64(time.Second)) + time.Millisecond
+}
+
+// bandwidth accounts and caps the traffic of the server.
+type bandwidth struct {
+	config BandwidthConfig
+	clock  mclock.Clock
+
+	ingress, egress           *rateLimiter
+	protoIngress, protoEgress map[string]*rateLimiter
+
+	total     trafficCounter
+	protocols map[string]*trafficCounter // Fixed at creation, keyed by protocol name
+	classes   [numTrafficClasses]trafficCounter
+}
+
+func newBandwidth(config BandwidthConfig, protocols []Protocol, clock mclock.Clock) *bandwidth {
+	b := &bandwidth{
+		config:       config,
+		clock:        clock,
+		ingress:      newRateLimiter(config.MaxIngress, clock),
+		egress:       newRateLimiter(config.MaxEgress, clock),
+		protoIngress: make(map[string]*rateLimiter),
+		protoEgress:  make(map[string]*rateLimiter),
+		protocols:    make(map[string]*trafficCounter),
+	}
+	for _, proto := range protocols {
+		if _, ok := b.protocols[proto.Name]; ok {
+			continue
+		}
+		b.protocols[proto.Name] = new(trafficCounter)
+		if l := newRateLimiter(config.MaxProtocolIngress[proto.Name], clock); l != nil {
+			b.protoIngress[proto.Name] = l
+		}
+		if l := newRateLimiter(config.MaxProtocolEgress[proto.Name], clock); l != nil {
+			b.protoEgress[proto.Name] = l
+		}
+	}
+	return b
+}
+
+// newPeer creates the bandwidth accounting of a connected peer.
+func (b *bandwidth) newPeer() *peerBandwidth {
+	return &peerBandwidth{
+		server:    b,
+		ingress:   newRateLimiter(b.config.MaxPeerIngress, b.clock),
+		egress:    newRateLimiter(b.config.MaxPeerEgress, b.clock),
+		protocols: make(map[string]*trafficCounter),
+	}
+}
+
+// info returns the bandwidth usage of the server.
+func (b *bandwidth) info() *BandwidthInfo {
+	info := &BandwidthInfo{
+		Limits:    b.config,
+		Total:     b.total.stats(),
+		Protocols: make(map[string]*TrafficStats, len(b.protocols)),
+		Classes:   make(map[string]*TrafficStats, numTrafficClasses),
+	}
+	for name, counter := range b.protocols {
+		info.Protocols[name] = counter.stats()
+	}
+	for class := range b.classes {
+		info.Classes[TrafficClass(class).String()] = b.classes[class].stats()
+	}
+	return info
+}
+
+// BandwidthInfo returns the bandwidth usage of the server, or nil if the server
+// isn't running.
+func (srv *Server) BandwidthInfo() *BandwidthInfo {
+	srv.lock.Lock()
+	b := srv.bandwidth
+	srv.lock.Unlock()
+
+	if b == nil {
+		return nil
+	}
+	return b.info()
+}
+
+// peerBandwidth accounts and caps the traffic of a peer. The protocol, peer and
+// server caps all apply to a message.
+type peerBandwidth struct {
+	server          *bandwidth
+	ingress, egress *rateLimiter
+
+	lock      sync.Mutex
+	protocols map[string]*trafficCounter
+}
+
+// transfer waits for the caps to let a message through and accounts it. It
+// returns false if the cancel channel is closed while waiting.
+//
+// Inbound block propagation messages don't wait for the caps, as waiting holds
+// back the whole connection of the peer. They are charged to the caps all the
+// same, holding back the lower priority traffic instead.
+func (pb *peerBandwidth) transfer(egress bool, proto string, class TrafficClass, size uint32, cancel <-chan struct{}) bool {
+	limiters := [3]*rateLimiter{pb.server.protoIngress[proto], pb.ingress, pb.server.ingress}
+	if egress {
+		limiters = [3]*rateLimiter{pb.server.protoEgress[proto], pb.egress, pb.server.egress}
+	}
+	var throttled time.Duration
+	for _, l := range limiters {
+		if !egress && class == TrafficBlocks {
+			l.charge(size)
+			continue
+		}
+		wait, ok := l.wait(class, size, cancel)
+		if !ok {
+			return false
+		}
+		throttled += wait
+	}
+	if throttled > 0 {
+		throttleMeter.Mark(1)
+	}
+	pb.counter(proto).add(egress, size, throttled)
+	pb.server.total.add(egress, size, throttled)
+	pb.server.classes[class].add(egress, size, throttled)
+	if counter := pb.server.protocols[proto]; counter != nil {
+		counter.add(egress, size, throttled)
+	}
+	return true
+}
+
+func (pb *peerBandwidth) counter(proto string) *trafficCounter {
+	pb.lock.Lock()
+	defer pb.lock.Unlock()
+
+	counter := pb.protocols[proto]
+	if counter == nil {
+		counter = new(trafficCounter)
+		pb.protocols[proto] = counter
+	}
+	return counter
+}
+
+// info returns the traffic of the peer by protocol name.
+func (pb *peerBandwidth) info() map[string]*TrafficStats {
+	pb.lock.Lock()
+	defer pb.lock.Unlock()
+
+	info := make(map[string]*TrafficStats, len(pb.protocols))
+	for name, counter := range pb.protocols {
+		info[name] = counter.stats()
+	}
+	return info
+}
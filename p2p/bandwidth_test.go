@@ -0,0 +1,281 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"errors"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common/mclock"
+	"github.com/rajchain/go-rajchain/log"
+)
+
+// runLimited waits on the limiter in the background, driving the simulated
+// clock until the wait is over. It returns the simulated time it took.
+func runLimited(clock *mclock.Simulated, fn func()) time.Duration {
+	start := clock.Now()
+	done := make(chan struct{})
+	go func() {
+		fn()
+		close(done)
+	}()
+	for {
+		select {
+		case <-done:
+			return time.Duration(clock.Now() - start)
+		case <-time.After(time.Millisecond):
+			clock.Run(10 * time.Millisecond)
+		}
+	}
+}
+
+// Tests that the rate limiter holds the traffic to its rate on average, even
+// with messages larger than the burst.
+func TestRateLimiterRate(t *testing.T) {
+	clock := new(mclock.Simulated)
+	limiter := newRateLimiter(1000, clock)
+
+	elapsed := runLimited(clock, func() {
+		for i := 0; i < 5; i++ {
+			limiter.wait(TrafficServing, 1000, nil)
+		}
+		limiter.wait(TrafficServing, 3000, nil) // over the burst, put in debt
+		limiter.wait(TrafficServing, 1, nil)
+	})
+	// The bucket starts full, so the first message passes right away and each
+	// subsequent one waits for its predecessor to be paid off.
+	if elapsed < 7*time.Second || elapsed > 7*time.Second+200*time.Millisecond {
+		t.Fatalf("wrong time to pass the traffic: have %v, want 7s", elapsed)
+	}
+}
+
+// Tests that waiting higher priority traffic passes before lower priority one.
+func TestRateLimiterPriority(t *testing.T) {
+	clock := new(mclock.Simulated)
+	limiter := newRateLimiter(1000, clock)
+	limiter.wait(TrafficServing, 1000, nil) // drain the bucket
+
+	order := make(chan TrafficClass, 4)
+	wait := func(class TrafficClass) {
+		limiter.wait(class, 500, nil)
+		order <- class
+	}
+	go wait(TrafficGossip)
+	clock.WaitForTimers(1)
+	go wait(TrafficServing)
+	clock.WaitForTimers(2)
+	go wait(TrafficSync)
+	clock.WaitForTimers(3)
+	go wait(TrafficBlocks)
+	clock.WaitForTimers(4)
+
+	runLimited(clock, func() {
+		for _, want := range []TrafficClass{TrafficBlocks, TrafficSync, TrafficServing, TrafficGossip} {
+			if have := <-order; have != want {
+				t.Errorf("wrong traffic order: have %v, want %v", have, want)
+			}
+		}
+	})
+}
+
+// Tests that waiting on the rate limiter can be cancelled.
+func TestRateLimiterCancel(t *testing.T) {
+	clock := new(mclock.Simulated)
+	limiter := newRateLimiter(1000, clock)
+	limiter.wait(TrafficServing, 2000, nil)
+
+	var (
+		cancel = make(chan struct{})
+		result = make(chan bool)
+	)
+	go func() {
+		_, ok := limiter.wait(TrafficGossip, 100, cancel)
+		result <- ok
+	}()
+	clock.WaitForTimers(1)
+	close(cancel)
+	if <-result {
+		t.Fatal("cancelled wait succeeded")
+	}
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+	if limiter.waiting[TrafficGossip] != 0 {
+		t.Fatalf("cancelled wait still queued")
+	}
+}
+
+// Tests that the traffic of a peer is accounted by protocol and class.
+func TestPeerBandwidthAccounting(t *testing.T) {
+	proto := Protocol{
+		Name:   "a",
+		Length: 2,
+		Run: func(peer *Peer, rw MsgReadWriter) error {
+			if err := ExpectMsg(rw, 1, []uint{1}); err != nil {
+				return err
+			}
+			if err := SendItems(rw, 0, uint(2)); err != nil {
+				return err
+			}
+			<-peer.closed
+			return nil
+		},
+		TrafficClass: func(code uint64, egress bool) TrafficClass {
+			if code == 0 {
+				return TrafficBlocks
+			}
+			return TrafficGossip
+		},
+	}
+	var (
+		fd1, fd2   = net.Pipe()
+		key1, key2 = newkey(), newkey()
+		c1         = &conn{fd: fd1, node: newNode(uintID(1), ""), transport: newTestTransport(&key2.PublicKey, fd1, nil)}
+		c2         = &conn{fd: fd2, node: newNode(uintID(2), ""), transport: newTestTransport(&key1.PublicKey, fd2, &key1.PublicKey)}
+		bw         = newBandwidth(BandwidthConfig{}, []Protocol{proto}, mclock.System{})
+	)
+	c1.caps = []Cap{proto.cap()}
+	c2.caps = []Cap{proto.cap()}
+	defer c2.close(errors.New("test done"))
+
+	peer := newPeer(log.Root(), c1, []Protocol{proto})
+	peer.bw = bw.newPeer()
+	go peer.run()
+
+	if err := Send(c2, baseProtocolLength+1, []uint{1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExpectMsg(c2, baseProtocolLength, []uint{2}); err != nil {
+		t.Fatal(err)
+	}
+	// The message is accounted before it's written, check the stats.
+	stats := peer.Info().Bandwidth["a"]
+	if stats == nil {
+		t.Fatal("missing peer traffic of protocol")
+	}
+	if stats.IngressMessages != 1 || stats.Ingress != 2 || stats.EgressMessages != 1 || stats.Egress != 2 {
+		t.Fatalf("wrong peer traffic: %+v", stats)
+	}
+	info := bw.info()
+	if total := info.Total; total.IngressMessages != 1 || total.EgressMessages != 1 {
+		t.Fatalf("wrong total traffic: %+v", total)
+	}
+	if stats := info.Protocols["a"]; stats.Ingress != 2 || stats.Egress != 2 {
+		t.Fatalf("wrong protocol traffic: %+v", stats)
+	}
+	if stats := info.Classes["blocks"]; stats.EgressMessages != 1 || stats.IngressMessages != 0 {
+		t.Fatalf("wrong blocks traffic: %+v", stats)
+	}
+	if stats := info.Classes["gossip"]; stats.IngressMessages != 1 || stats.EgressMessages != 0 {
+		t.Fatalf("wrong gossip traffic: %+v", stats)
+	}
+}
+
+// Tests that the ingress caps hold back the reads from a peer sending mixed
+// traffic classes without blocking its protocol handler, and that block
+// propagation is let through without waiting.
+func TestPeerIngressThrottleMixed(t *testing.T) {
+	const (
+		blockMsg  = 0
+		gossipMsg = 1
+	)
+	received := make(chan uint64, 8)
+	proto := Protocol{
+		Name:   "a",
+		Length: 2,
+		Run: func(peer *Peer, rw MsgReadWriter) error {
+			for {
+				msg, err := rw.ReadMsg()
+				if err != nil {
+					return err
+				}
+				msg.Discard()
+				received <- msg.Code
+			}
+		},
+		TrafficClass: func(code uint64, egress bool) TrafficClass {
+			if code == blockMsg {
+				return TrafficBlocks
+			}
+			return TrafficGossip
+		},
+	}
+	var (
+		fd1, fd2   = net.Pipe()
+		key1, key2 = newkey(), newkey()
+		c1         = &conn{fd: fd1, node: newNode(uintID(1), ""), transport: newTestTransport(&key2.PublicKey, fd1, nil)}
+		c2         = &conn{fd: fd2, node: newNode(uintID(2), ""), transport: newTestTransport(&key1.PublicKey, fd2, &key1.PublicKey)}
+		clock      = new(mclock.Simulated)
+		bw         = newBandwidth(BandwidthConfig{MaxPeerIngress: 1000}, []Protocol{proto}, clock)
+	)
+	c1.caps = []Cap{proto.cap()}
+	c2.caps = []Cap{proto.cap()}
+	defer c2.close(errors.New("test done"))
+
+	peer := newPeer(log.Root(), c1, []Protocol{proto})
+	peer.bw = bw.newPeer()
+	go peer.run()
+
+	// Send gossip filling the cap three times over, followed by a block and a
+	// ping. The writes block while the peer holds back its reads.
+	var (
+		payload = make([]byte, 1000)
+		sent    = make(chan error, 1)
+	)
+	go func() {
+		for _, code := range []uint64{gossipMsg, gossipMsg, gossipMsg, blockMsg} {
+			if err := Send(c2, baseProtocolLength+code, payload); err != nil {
+				sent <- err
+				return
+			}
+		}
+		sent <- SendItems(c2, pingMsg)
+	}()
+	var order []uint64
+	elapsed := runLimited(clock, func() {
+		for len(order) < 4 {
+			order = append(order, <-received)
+		}
+	})
+	if err := <-sent; err != nil {
+		t.Fatal(err)
+	}
+	// The handler gets the messages in order. The bucket starts full, so only
+	// the last gossip waits for the debt of the earlier ones to be paid off,
+	// while the block goes right through.
+	want := []uint64{gossipMsg, gossipMsg, gossipMsg, blockMsg}
+	if !slices.Equal(order, want) {
+		t.Fatalf("wrong message order: have %v, want %v", order, want)
+	}
+	if elapsed < time.Second || elapsed > time.Second+200*time.Millisecond {
+		t.Fatalf("wrong time to pass the traffic: have %v, want 1s", elapsed)
+	}
+	info := bw.info()
+	if stats := info.Classes["blocks"]; stats.IngressMessages != 1 || stats.Throttled != "0s" {
+		t.Fatalf("wrong blocks traffic: %+v", stats)
+	}
+	if stats := info.Classes["gossip"]; stats.IngressMessages != 3 || stats.Throttled == "0s" {
+		t.Fatalf("wrong gossip traffic: %+v", stats)
+	}
+	// The block was charged to the cap, holding back the traffic behind it, but
+	// the base protocol is served again once the read loop resumes.
+	if err := ExpectMsg(c2, pongMsg, nil); err != nil {
+		t.Fatal(err)
+	}
+}
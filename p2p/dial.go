@@ -76,6 +76,7 @@ var (
 	errAlreadyConnected = errors.New("already connected")
 	errRecentlyDialed   = errors.New("recently dialed")
 	errNetRestrict      = errors.New("not contained in netrestrict list")
+	errNotAllowed       = errors.New("not contained in allowlist")
 	errNoPort           = errors.New("node does not provide TCP port")
 )
 
@@ -130,6 +131,7 @@ type dialConfig struct {
 	maxDialPeers   int              // maximum number of dialed peers
 	maxActiveDials int              // maximum number of active dials
 	netRestrict    *netutil.Netlist // IP netrestrict list, disabled if nil
+	allowlist      *Allowlist       // node allowlist, disabled if nil
 	resolver       nodeResolver
 	dialer         NodeDialer
 	log            log.Logger
@@ -388,6 +390,16 @@ func (d *dialScheduler) checkDial(n *enode.Node) error {
 	if d.netRestrict != nil && !d.netRestrict.ContainsAddr(n.IPAddr()) {
 		return errNetRestrict
 	}
+	if d.allowlist != nil {
+		// Until the allowlist is loaded, only static nodes are dialed
+		if !d.allowlist.Loaded() {
+			if _, ok := d.static[n.ID()]; !ok {
+				return errNotAllowed
+			}
+		} else if !d.allowlist.Allowed(n.ID()) {
+			return errNotAllowed
+		}
+	}
 	if d.history.contains(string(n.ID().Bytes())) {
 		return errRecentlyDialed
 	}
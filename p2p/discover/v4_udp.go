@@ -552,6 +552,8 @@ func (t *UDPv4) readLoop(unhandled chan<- ReadPacket) {
 		} else if err != nil && unhandled != nil {
 			select {
 			case unhandled <- ReadPacket{buf[:nbytes], from}:
+				// The packet is now owned by the receiver.
+				buf = make([]byte, maxPacketSize)
 			default:
 			}
 		}
@@ -0,0 +1,568 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"net/netip"
+	"slices"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/common/mclock"
+	"github.com/rajchain/go-rajchain/p2p/discover/v5wire"
+	"github.com/rajchain/go-rajchain/p2p/enode"
+	"github.com/rajchain/go-rajchain/p2p/netutil"
+	"github.com/rajchain/go-rajchain/rlp"
+)
+
+const (
+	topicAdLifetime   = 15 * time.Minute // time an ad stays in the topic table
+	maxTopicAds       = 100              // limit of ads per topic
+	maxTotalAds       = 10000            // limit of ads across all topics
+	maxTotalPending   = 10000            // limit of ticket reservations across all topics
+	maxTopics         = 1000             // limit of topics with ads or reservations
+	ticketValidity    = 10 * time.Second // time a ticket can be used after its wait time
+	topicQueryLimit   = 16               // nodes returned by the TOPICQUERY handler
+	topicRegistrars   = bucketSize       // nodes an ad is placed at
+	topicRetryDelay   = 5 * time.Second  // delay before looking for registrars again
+	topicRequeryDelay = 10 * time.Second // delay between rounds of topic queries
+
+	// An ad is refreshed when this much of its lifetime is left.
+	topicRefreshMargin = topicAdLifetime / 10
+)
+
+var errInvalidTicket = errors.New("invalid ticket")
+
+// Topic identifies a service advertised through discovery v5.
+//
+// Nodes advertise a topic by placing ads at the nodes whose IDs are closest to
+// the topic hash. Advertisers hand out tickets when their table for the topic
+// is full, allowing the registrant to place the ad once the oldest ad expires.
+// Searchers find the same nodes by looking up the topic hash, and ask them for
+// the ads they store.
+type Topic [32]byte
+
+// NewTopic returns the topic with the given name, which is its SHA-256 hash.
+func NewTopic(name string) Topic {
+	return Topic(sha256.Sum256([]byte(name)))
+}
+
+func (t Topic) String() string {
+	return hexutil.Encode(t[:])
+}
+
+// topicTable stores the ads placed at the local node. Registrants waiting with
+// a ticket have a slot reserved, so ads are placed in the order the tickets
+// were issued. Each node holds at most one reservation per topic. The table is
+// only accessed by the dispatch loop.
+type topicTable struct {
+	ads     map[Topic][]topicAd          // ordered by expiry
+	pending map[Topic][]topicReservation // ordered by issuance
+	total   int                          // number of ads across all topics
+	waiting int                          // number of reservations across all topics
+}
+
+type topicAd struct {
+	node    *enode.Node
+	expires mclock.AbsTime
+}
+
+// topicReservation is a slot held for a ticket, until the end of its validity.
+type topicReservation struct {
+	node enode.ID
+	end  mclock.AbsTime
+}
+
+func newTopicTable() *topicTable {
+	return &topicTable{
+		ads:     make(map[Topic][]topicAd),
+		pending: make(map[Topic][]topicReservation),
+	}
+}
+
+// expire removes the expired ads and reservations.
+func (tt *topicTable) expire(now mclock.AbsTime) {
+	for topic, ads := range tt.ads {
+		i := 0
+		for i < len(ads) && ads[i].expires <= now {
+			i++
+		}
+		tt.total -= i
+		if i == len(ads) {
+			delete(tt.ads, topic)
+		} else {
+			tt.ads[topic] = ads[i:]
+		}
+	}
+	for topic, pending := range tt.pending {
+		n := len(pending)
+		pending = slices.DeleteFunc(pending, func(r topicReservation) bool { return r.end <= now })
+		tt.waiting -= n - len(pending)
+		if len(pending) == 0 {
+			delete(tt.pending, topic)
+		} else {
+			tt.pending[topic] = pending
+		}
+	}
+}
+
+// hasRoom reports whether ads can be placed for the topic, which is the case
+// unless it's a new topic and the table already tracks too many of them.
+func (tt *topicTable) hasRoom(topic Topic) bool {
+	if tt.ads[topic] != nil || tt.pending[topic] != nil {
+		return true
+	}
+	topics := len(tt.ads)
+	for topic := range tt.pending {
+		if tt.ads[topic] == nil {
+			topics++
+		}
+	}
+	return topics < maxTopics
+}
+
+// waitTime returns the time until an ad for the topic can be placed. Ticket
+// holders only wait for a free slot, while other registrants also wait for
+// the slots reserved by tickets.
+func (tt *topicTable) waitTime(topic Topic, now mclock.AbsTime, holder bool) time.Duration {
+	var (
+		wait   time.Duration
+		ads    = tt.ads[topic]
+		queued = len(ads)
+	)
+	if !holder {
+		queued += len(tt.pending[topic])
+	}
+	if queued >= maxTopicAds {
+		// Wait for the first slot that isn't reserved to be freed.
+		if k := queued - maxTopicAds; k < len(ads) {
+			wait = time.Duration(ads[k].expires - now)
+		} else {
+			wait = topicAdLifetime
+		}
+	}
+	if tt.total >= maxTotalAds {
+		// The whole table is full, wait for the first ad to expire.
+		var first mclock.AbsTime
+		for _, ads := range tt.ads {
+			if first == 0 || ads[0].expires < first {
+				first = ads[0].expires
+			}
+		}
+		wait = max(wait, time.Duration(first-now))
+	}
+	return max(wait, 0)
+}
+
+// reserve holds a slot for the ticket of a node, valid until the given time. Any
+// previous reservation of the node for the topic is released, the node losing
+// its place in line.
+func (tt *topicTable) reserve(topic Topic, id enode.ID, end mclock.AbsTime) {
+	tt.redeem(topic, id)
+	if len(tt.pending[topic]) < maxTopicAds && tt.waiting < maxTotalPending {
+		tt.pending[topic] = append(tt.pending[topic], topicReservation{node: id, end: end})
+		tt.waiting++
+	}
+}
+
+// redeem releases the slot reserved by the ticket of a node.
+func (tt *topicTable) redeem(topic Topic, id enode.ID) {
+	pending := tt.pending[topic]
+	for i, r := range pending {
+		if r.node == id {
+			pending = slices.Delete(pending, i, i+1)
+			tt.waiting--
+			break
+		}
+	}
+	if len(pending) == 0 {
+		delete(tt.pending, topic)
+	} else {
+		tt.pending[topic] = pending
+	}
+}
+
+// add places an ad, replacing any previous ad of the node for the topic.
+func (tt *topicTable) add(topic Topic, n *enode.Node, now mclock.AbsTime) {
+	ads := tt.ads[topic]
+	for i, ad := range ads {
+		if ad.node.ID() == n.ID() {
+			ads = append(ads[:i], ads[i+1:]...)
+			tt.total--
+			break
+		}
+	}
+	tt.ads[topic] = append(ads, topicAd{node: n, expires: now.Add(topicAdLifetime)})
+	tt.total++
+}
+
+// nodes returns the most recently placed ads for the topic.
+func (tt *topicTable) nodes(topic Topic, limit int) []*enode.Node {
+	ads := tt.ads[topic]
+	nodes := make([]*enode.Node, 0, min(limit, len(ads)))
+	for i := len(ads) - 1; i >= 0 && len(nodes) < limit; i-- {
+		nodes = append(nodes, ads[i].node)
+	}
+	return nodes
+}
+
+// ticket is the content of a registration ticket. Tickets are only read by the
+// node issuing them, which encrypts them so registrants can't forge them.
+type ticket struct {
+	Node   enode.ID
+	Topic  Topic
+	Issued uint64 // mclock time of issuance
+	Wait   uint64 // seconds to wait before using the ticket
+}
+
+// ticketSealer encrypts and authenticates tickets with a key of the local node.
+type ticketSealer struct {
+	aead cipher.AEAD
+}
+
+func newTicketSealer() *ticketSealer {
+	key := make([]byte, 16)
+	crand.Read(key)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return &ticketSealer{aead: aead}
+}
+
+func (s *ticketSealer) seal(t *ticket) []byte {
+	enc, _ := rlp.EncodeToBytes(t)
+	nonce := make([]byte, s.aead.NonceSize())
+	crand.Read(nonce)
+	return s.aead.Seal(nonce, nonce, enc, nil)
+}
+
+func (s *ticketSealer) open(data []byte) (*ticket, error) {
+	if len(data) < s.aead.NonceSize() {
+		return nil, errInvalidTicket
+	}
+	nonce, ciphertext := data[:s.aead.NonceSize()], data[s.aead.NonceSize():]
+	enc, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errInvalidTicket
+	}
+	t := new(ticket)
+	if err := rlp.DecodeBytes(enc, t); err != nil {
+		return nil, errInvalidTicket
+	}
+	return t, nil
+}
+
+// handleRegtopic places an ad for the sender, or hands out a ticket if the topic
+// table is full.
+func (t *UDPv5) handleRegtopic(p *v5wire.Regtopic, fromID enode.ID, fromAddr netip.AddrPort) {
+	n, err := enode.New(t.validSchemes, p.ENR)
+	if err != nil || n.ID() != fromID {
+		t.log.Debug("Invalid record in "+p.Name(), "id", fromID, "addr", fromAddr, "err", err)
+		return
+	}
+	// Nodes can only advertise themselves.
+	if n.IPAddr() != fromAddr.Addr() {
+		t.log.Debug("Record endpoint mismatch in "+p.Name(), "id", fromID, "addr", fromAddr, "ip", n.IPAddr())
+		return
+	}
+	var (
+		now    = t.clock.Now()
+		topic  = Topic(p.Topic)
+		holder bool
+	)
+	if len(p.Ticket) > 0 {
+		// Tickets which are not valid yet or anymore are replaced by new
+		// ones, losing their place in line.
+		err := t.checkTicket(p.Ticket, fromID, topic, now)
+		if err != nil {
+			t.log.Debug("Rejected topic ticket", "id", fromID, "addr", fromAddr, "err", err)
+		}
+		holder = err == nil
+	}
+	t.topics.expire(now)
+	if !t.topics.hasRoom(topic) {
+		t.log.Debug("Dropped "+p.Name()+", too many topics", "id", fromID, "addr", fromAddr, "topic", topic)
+		return
+	}
+	wait := t.topics.waitTime(topic, now, holder)
+	if holder {
+		t.topics.redeem(topic, fromID)
+	}
+	if wait == 0 {
+		t.topics.add(topic, n, now)
+		t.sendResponse(fromID, fromAddr, &v5wire.Regconfirmation{ReqID: p.ReqID, Topic: p.Topic})
+		return
+	}
+	seconds := uint64((wait + time.Second - 1) / time.Second)
+	start := now.Add(time.Duration(seconds) * time.Second)
+	t.topics.reserve(topic, fromID, start.Add(ticketValidity))
+
+	tk := t.tickets.seal(&ticket{Node: fromID, Topic: topic, Issued: uint64(now), Wait: seconds})
+	t.sendResponse(fromID, fromAddr, &v5wire.Ticket{ReqID: p.ReqID, Ticket: tk, WaitTime: uint(seconds)})
+}
+
+// checkTicket verifies that a ticket was issued to the node for the topic, and
+// that its wait time is over.
+func (t *UDPv5) checkTicket(data []byte, id enode.ID, topic Topic, now mclock.AbsTime) error {
+	tk, err := t.tickets.open(data)
+	if err != nil {
+		return err
+	}
+	if tk.Node != id || tk.Topic != topic {
+		return errInvalidTicket
+	}
+	start := mclock.AbsTime(tk.Issued).Add(time.Duration(tk.Wait) * time.Second)
+	if now < start {
+		return errors.New("ticket used too early")
+	}
+	if now > start.Add(ticketValidity) {
+		return errors.New("ticket expired")
+	}
+	return nil
+}
+
+// handleTopicQuery returns the nodes advertising a topic.
+func (t *UDPv5) handleTopicQuery(p *v5wire.TopicQuery, fromID enode.ID, fromAddr netip.AddrPort) {
+	t.topics.expire(t.clock.Now())
+
+	var nodes []*enode.Node
+	for _, n := range t.topics.nodes(Topic(p.Topic), topicQueryLimit) {
+		if netutil.CheckRelayAddr(fromAddr.Addr(), n.IPAddr()) == nil {
+			nodes = append(nodes, n)
+		}
+	}
+	for _, resp := range packNodes(p.ReqID, nodes) {
+		t.sendResponse(fromID, fromAddr, resp)
+	}
+}
+
+// regtopic calls REGTOPIC on a node. It returns a nil ticket if the ad was placed.
+func (t *UDPv5) regtopic(n *enode.Node, topic Topic, ticket []byte) (*v5wire.Ticket, error) {
+	req := &v5wire.Regtopic{Topic: topic, ENR: t.localNode.Node().Record(), Ticket: ticket}
+	resp := t.callToNode(n, v5wire.TicketMsg, req)
+	defer t.callDone(resp)
+
+	select {
+	case p := <-resp.ch:
+		if tk, ok := p.(*v5wire.Ticket); ok {
+			return tk, nil
+		}
+		return nil, nil
+	case err := <-resp.err:
+		return nil, err
+	}
+}
+
+// topicQuery calls TOPICQUERY on a node and waits for the responses.
+func (t *UDPv5) topicQuery(n *enode.Node, topic Topic) ([]*enode.Node, error) {
+	resp := t.callToNode(n, v5wire.NodesMsg, &v5wire.TopicQuery{Topic: topic})
+	return t.waitForNodes(resp, nil)
+}
+
+// RegisterTopic starts advertising the local node under the given topic, until
+// UnregisterTopic is called or the transport is closed.
+func (t *UDPv5) RegisterTopic(topic Topic) {
+	t.regMutex.Lock()
+	defer t.regMutex.Unlock()
+
+	if t.registrations[topic] != nil || t.closeCtx.Err() != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(t.closeCtx)
+	t.registrations[topic] = cancel
+	t.wg.Add(1)
+	go t.registerLoop(ctx, topic)
+}
+
+// UnregisterTopic stops advertising the local node under the given topic. The
+// ads placed before stay until they expire.
+func (t *UDPv5) UnregisterTopic(topic Topic) {
+	t.regMutex.Lock()
+	defer t.regMutex.Unlock()
+
+	if cancel := t.registrations[topic]; cancel != nil {
+		cancel()
+		delete(t.registrations, topic)
+	}
+}
+
+// registrar is a node the local node places its ad for a topic at.
+type registrar struct {
+	node   *enode.Node
+	ticket []byte
+	next   mclock.AbsTime // time of the next registration attempt
+}
+
+// registerLoop keeps the ads for a topic placed at the nodes closest to the
+// topic hash, refreshing them before they expire.
+func (t *UDPv5) registerLoop(ctx context.Context, topic Topic) {
+	defer t.wg.Done()
+
+	var (
+		registrars = make(map[enode.ID]*registrar)
+		lookupAt   mclock.AbsTime
+	)
+	for {
+		now := t.clock.Now()
+		if now >= lookupAt {
+			// Registrars which dropped out are replaced by the closest nodes.
+			closest := t.newLookup(ctx, enode.ID(topic)).run()
+			for _, n := range closest {
+				if len(registrars) >= topicRegistrars {
+					break
+				}
+				if registrars[n.ID()] == nil {
+					registrars[n.ID()] = &registrar{node: n, next: now}
+				}
+			}
+			lookupAt = now.Add(topicAdLifetime - topicRefreshMargin)
+			if len(registrars) == 0 {
+				lookupAt = now.Add(topicRetryDelay)
+			}
+		}
+		next := lookupAt
+		for id, r := range registrars {
+			if r.next <= now {
+				tk, err := t.regtopic(r.node, topic, r.ticket)
+				switch {
+				case errors.Is(err, errClosed):
+					return
+				case err != nil:
+					t.log.Trace("Topic registration failed", "topic", topic, "id", id, "err", err)
+					delete(registrars, id)
+					continue
+				case tk == nil:
+					r.ticket = nil
+					r.next = t.clock.Now().Add(topicAdLifetime - topicRefreshMargin)
+				default:
+					r.ticket = tk.Ticket
+					r.next = t.clock.Now().Add(time.Duration(tk.WaitTime) * time.Second)
+				}
+			}
+			if r.next < next {
+				next = r.next
+			}
+		}
+		timer := t.clock.NewTimer(time.Duration(next - t.clock.Now()))
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// TopicNodes returns an iterator over the nodes advertising the given topic.
+// It keeps querying the nodes closest to the topic hash, so nodes may be
+// returned more than once.
+func (t *UDPv5) TopicNodes(topic Topic) enode.Iterator {
+	ctx, cancel := context.WithCancel(t.closeCtx)
+	return &topicIterator{
+		transport: t,
+		topic:     topic,
+		ctx:       ctx,
+		cancel:    cancel,
+		seen:      make(map[enode.ID]bool),
+	}
+}
+
+// topicIterator queries the nodes closest to a topic hash for its ads.
+type topicIterator struct {
+	transport *UDPv5
+	topic     Topic
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	buffer  []*enode.Node // ads received in the last query
+	queue   []*enode.Node // nodes left to query in the current round
+	seen    map[enode.ID]bool
+	started bool
+}
+
+// Node returns the current node.
+func (it *topicIterator) Node() *enode.Node {
+	if len(it.buffer) == 0 {
+		return nil
+	}
+	return it.buffer[0]
+}
+
+// Next moves to the next node.
+func (it *topicIterator) Next() bool {
+	if len(it.buffer) > 0 {
+		it.buffer = it.buffer[1:]
+	}
+	t := it.transport
+	for len(it.buffer) == 0 {
+		if it.ctx.Err() != nil {
+			it.buffer = nil
+			return false
+		}
+		if len(it.queue) == 0 {
+			// Start a new round of queries, pausing between rounds.
+			if it.started && !it.sleep(topicRequeryDelay) {
+				continue
+			}
+			it.started = true
+			it.queue = t.newLookup(it.ctx, enode.ID(it.topic)).run()
+			clear(it.seen)
+			continue
+		}
+		n := it.queue[0]
+		it.queue = it.queue[1:]
+		nodes, err := t.topicQuery(n, it.topic)
+		if err != nil && !errors.Is(err, errClosed) {
+			t.log.Trace("Topic query failed", "topic", it.topic, "id", n.ID(), "err", err)
+		}
+		for _, ad := range nodes {
+			if ad.ID() != t.Self().ID() && !it.seen[ad.ID()] {
+				it.seen[ad.ID()] = true
+				it.buffer = append(it.buffer, ad)
+			}
+		}
+	}
+	return true
+}
+
+// sleep waits for the given time, returning false if the iterator is closed.
+func (it *topicIterator) sleep(d time.Duration) bool {
+	timer := it.transport.clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return true
+	case <-it.ctx.Done():
+		return false
+	}
+}
+
+// Close ends the iterator.
+func (it *topicIterator) Close() {
+	it.cancel()
+}
@@ -0,0 +1,227 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"fmt"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common/mclock"
+	"github.com/rajchain/go-rajchain/p2p/discover/v5wire"
+	"github.com/rajchain/go-rajchain/p2p/enode"
+)
+
+func TestTopicTableWaitTime(t *testing.T) {
+	var (
+		tt    = newTopicTable()
+		topic = NewTopic("foo")
+		now   = mclock.AbsTime(0)
+	)
+	for i := 0; i < maxTopicAds; i++ {
+		if wait := tt.waitTime(topic, now, false); wait != 0 {
+			t.Fatalf("ad %d: non-zero wait time %v with free slots", i, wait)
+		}
+		tt.add(topic, nodeAtDistance(enode.ID{}, 256, intIP(i)), now)
+		now += mclock.AbsTime(time.Second)
+	}
+	// The topic is full, the next slot frees up when the first ad expires.
+	first := mclock.AbsTime(0).Add(topicAdLifetime)
+	if wait, want := tt.waitTime(topic, now, false), time.Duration(first-now); wait != want {
+		t.Fatalf("wrong wait time for full topic: %v, want %v", wait, want)
+	}
+	// A reservation pushes back other registrants, but not the ticket holder.
+	tt.reserve(topic, enode.ID{1}, first.Add(ticketValidity))
+	if wait, want := tt.waitTime(topic, now, false), time.Duration(first-now)+time.Second; wait != want {
+		t.Fatalf("wrong wait time behind reservation: %v, want %v", wait, want)
+	}
+	if wait, want := tt.waitTime(topic, now, true), time.Duration(first-now); wait != want {
+		t.Fatalf("wrong wait time for ticket holder: %v, want %v", wait, want)
+	}
+	// Expired ads and reservations are removed.
+	tt.expire(first.Add(ticketValidity))
+	if tt.total != maxTopicAds-int(ticketValidity/time.Second)-1 {
+		t.Fatalf("wrong ad count after expiry: %d", tt.total)
+	}
+	if len(tt.pending[topic]) != 0 || tt.waiting != 0 {
+		t.Fatal("expired reservation not removed")
+	}
+	if wait := tt.waitTime(topic, first.Add(ticketValidity), false); wait != 0 {
+		t.Fatalf("non-zero wait time after expiry: %v", wait)
+	}
+}
+
+func TestTopicTableReservations(t *testing.T) {
+	var (
+		tt    = newTopicTable()
+		topic = NewTopic("foo")
+		end   = mclock.AbsTime(0).Add(ticketValidity)
+	)
+	// Nodes hold a single reservation per topic, the latest one.
+	tt.reserve(topic, enode.ID{1}, end)
+	tt.reserve(topic, enode.ID{2}, end)
+	tt.reserve(topic, enode.ID{1}, end+1)
+	if pending := tt.pending[topic]; len(pending) != 2 || pending[0].node != (enode.ID{2}) || pending[1].end != end+1 {
+		t.Fatalf("wrong reservations: %+v", pending)
+	}
+	// Redeeming releases the reservation of the ticket holder.
+	tt.redeem(topic, enode.ID{1})
+	if pending := tt.pending[topic]; len(pending) != 1 || pending[0].node != (enode.ID{2}) || tt.waiting != 1 {
+		t.Fatalf("wrong reservations after redeem: %+v", pending)
+	}
+	// The total number of reservations is capped.
+	for i := 0; tt.waiting < maxTotalPending; i++ {
+		tt.reserve(NewTopic(fmt.Sprint(i/maxTopicAds)), enode.ID{byte(i), byte(i >> 8)}, end)
+	}
+	tt.reserve(topic, enode.ID{3}, end)
+	if len(tt.pending[topic]) != 1 || tt.waiting != maxTotalPending {
+		t.Fatalf("reservation accepted past the limit")
+	}
+	tt.expire(end + 1)
+	if len(tt.pending) != 0 || tt.waiting != 0 {
+		t.Fatal("expired reservations not removed")
+	}
+	// New topics are refused once too many are tracked.
+	n := nodeAtDistance(enode.ID{}, 256, intIP(1))
+	for i := 0; i < maxTopics-1; i++ {
+		tt.add(NewTopic(fmt.Sprint(i)), n, 0)
+	}
+	tt.reserve(topic, enode.ID{1}, end.Add(ticketValidity))
+	if tt.hasRoom(NewTopic("bar")) {
+		t.Fatal("new topic accepted past the limit")
+	}
+	if !tt.hasRoom(topic) || !tt.hasRoom(NewTopic("0")) {
+		t.Fatal("known topic refused")
+	}
+}
+
+func TestTicketSealer(t *testing.T) {
+	var (
+		s  = newTicketSealer()
+		tk = &ticket{Node: enode.ID{1}, Topic: NewTopic("foo"), Issued: 10, Wait: 5}
+	)
+	enc := s.seal(tk)
+	dec, err := s.open(enc)
+	if err != nil {
+		t.Fatalf("can't open ticket: %v", err)
+	}
+	if *dec != *tk {
+		t.Fatalf("ticket mismatch: %+v, want %+v", dec, tk)
+	}
+	enc[len(enc)-1]++
+	if _, err := s.open(enc); err != errInvalidTicket {
+		t.Fatalf("modified ticket opened: %v", err)
+	}
+	if _, err := newTicketSealer().open(s.seal(tk)); err != errInvalidTicket {
+		t.Fatalf("ticket of other node opened: %v", err)
+	}
+}
+
+// This test checks that incoming REGTOPIC and TOPICQUERY calls are handled correctly.
+func TestUDPv5_regtopicHandling(t *testing.T) {
+	t.Parallel()
+	test := newUDPV5Test(t)
+	defer test.close()
+
+	var (
+		topic  = NewTopic("foo")
+		remote = test.getNode(test.remotekey, test.remoteaddr).Node()
+	)
+	// Nodes can't advertise other nodes.
+	other := test.getNode(newkey(), netip.MustParseAddrPort("10.0.2.99:30303")).Node()
+	test.packetIn(&v5wire.Regtopic{ReqID: []byte("foo"), Topic: topic, ENR: other.Record()})
+
+	// The ad is placed while the topic has free slots.
+	test.packetIn(&v5wire.Regtopic{ReqID: []byte("foo"), Topic: topic, ENR: remote.Record()})
+	test.waitPacketOut(func(p *v5wire.Regconfirmation, addr netip.AddrPort, _ v5wire.Nonce) {
+		if string(p.ReqID) != "foo" || p.Topic != topic {
+			t.Errorf("wrong confirmation: %+v", p)
+		}
+	})
+	test.packetIn(&v5wire.TopicQuery{ReqID: []byte("bar"), Topic: topic})
+	test.waitPacketOut(func(p *v5wire.Nodes, addr netip.AddrPort, _ v5wire.Nonce) {
+		if len(p.Nodes) != 1 || p.Nodes[0].Signature() == nil {
+			t.Fatalf("wrong nodes in response: %v", p.Nodes)
+		}
+		n, err := enode.New(enode.ValidSchemesForTesting, p.Nodes[0])
+		if err != nil || n.ID() != remote.ID() {
+			t.Errorf("wrong node in response: %v %v", n, err)
+		}
+	})
+
+	// When the topic is full, registrants get a ticket.
+	now := test.udp.clock.Now()
+	for i := 1; i < maxTopicAds; i++ {
+		test.udp.topics.add(topic, nodeAtDistance(enode.ID{}, 256, intIP(i)), now)
+	}
+	test.packetIn(&v5wire.Regtopic{ReqID: []byte("foo"), Topic: topic, ENR: remote.Record()})
+	var ticket []byte
+	test.waitPacketOut(func(p *v5wire.Ticket, addr netip.AddrPort, _ v5wire.Nonce) {
+		if p.WaitTime == 0 || p.WaitTime > uint(topicAdLifetime/time.Second) {
+			t.Errorf("wrong wait time %d", p.WaitTime)
+		}
+		ticket = p.Ticket
+	})
+	// Using the ticket before the wait time is over yields a new ticket.
+	test.packetIn(&v5wire.Regtopic{ReqID: []byte("foo"), Topic: topic, ENR: remote.Record(), Ticket: ticket})
+	test.waitPacketOut(func(p *v5wire.Ticket, addr netip.AddrPort, _ v5wire.Nonce) {
+		if len(p.Ticket) == 0 {
+			t.Error("empty ticket")
+		}
+	})
+	// The new ticket replaces the reservation of the previous one.
+	if pending := test.udp.topics.pending[topic]; len(pending) != 1 || pending[0].node != remote.ID() {
+		t.Errorf("wrong reservations: %+v", pending)
+	}
+}
+
+// This test checks that nodes advertising a topic are found by other nodes.
+func TestUDPv5_topicE2E(t *testing.T) {
+	t.Parallel()
+
+	const N = 6
+	var nodes []*UDPv5
+	for i := 0; i < N; i++ {
+		var cfg Config
+		if len(nodes) > 0 {
+			cfg.Bootnodes = []*enode.Node{nodes[0].Self()}
+		}
+		node := startLocalhostV5(t, cfg)
+		nodes = append(nodes, node)
+		defer node.Close()
+	}
+	topic := NewTopic("foo")
+	nodes[1].RegisterTopic(topic)
+	nodes[2].RegisterTopic(topic)
+
+	want := map[enode.ID]bool{nodes[1].Self().ID(): true, nodes[2].Self().ID(): true}
+	it := nodes[N-1].TopicNodes(topic)
+	defer it.Close()
+
+	timeout := time.AfterFunc(30*time.Second, it.Close)
+	defer timeout.Stop()
+	for len(want) > 0 && it.Next() {
+		if !want[it.Node().ID()] {
+			continue
+		}
+		delete(want, it.Node().ID())
+	}
+	if len(want) > 0 {
+		t.Fatalf("advertisers not found: %v", want)
+	}
+}
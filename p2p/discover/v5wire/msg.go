@@ -47,8 +47,10 @@ const (
 	NodesMsg
 	TalkRequestMsg
 	TalkResponseMsg
-	RequestTicketMsg
+	RegtopicMsg
 	TicketMsg
+	RegconfirmationMsg
+	TopicQueryMsg
 
 	UnknownPacket   = byte(255) // any non-decryptable packet
 	WhoareyouPacket = byte(254) // the WHOAREYOU packet
@@ -118,6 +120,36 @@ type (
 		ReqID   []byte
 		Message []byte
 	}
+
+	// REGTOPIC requests placing an ad for the sender under a topic. The ticket
+	// is empty on the first attempt, and the last one received afterwards.
+	Regtopic struct {
+		ReqID  []byte
+		Topic  [32]byte
+		ENR    *enr.Record
+		Ticket []byte
+	}
+
+	// TICKET is the reply to REGTOPIC when the ad can't be placed yet. The
+	// registration may be retried with the ticket after the wait time, which
+	// is in seconds.
+	Ticket struct {
+		ReqID    []byte
+		Ticket   []byte
+		WaitTime uint
+	}
+
+	// REGCONFIRMATION is the reply to REGTOPIC when the ad is placed.
+	Regconfirmation struct {
+		ReqID []byte
+		Topic [32]byte
+	}
+
+	// TOPICQUERY requests the nodes advertising a topic. The reply is NODES.
+	TopicQuery struct {
+		ReqID []byte
+		Topic [32]byte
+	}
 )
 
 // DecodeMessage decodes the message body of a packet.
@@ -136,6 +168,14 @@ func DecodeMessage(ptype byte, body []byte) (Packet, error) {
 		dec = new(TalkRequest)
 	case TalkResponseMsg:
 		dec = new(TalkResponse)
+	case RegtopicMsg:
+		dec = new(Regtopic)
+	case TicketMsg:
+		dec = new(Ticket)
+	case RegconfirmationMsg:
+		dec = new(Regconfirmation)
+	case TopicQueryMsg:
+		dec = new(TopicQuery)
 	default:
 		return nil, fmt.Errorf("unknown packet type %d", ptype)
 	}
@@ -227,3 +267,39 @@ func (p *TalkResponse) SetRequestID(id []byte) { p.ReqID = id }
 func (p *TalkResponse) AppendLogInfo(ctx []interface{}) []interface{} {
 	return append(ctx, "req", hexutil.Bytes(p.ReqID), "len", len(p.Message))
 }
+
+func (*Regtopic) Name() string             { return "REGTOPIC/v5" }
+func (*Regtopic) Kind() byte               { return RegtopicMsg }
+func (p *Regtopic) RequestID() []byte      { return p.ReqID }
+func (p *Regtopic) SetRequestID(id []byte) { p.ReqID = id }
+
+func (p *Regtopic) AppendLogInfo(ctx []interface{}) []interface{} {
+	return append(ctx, "req", hexutil.Bytes(p.ReqID), "topic", hexutil.Bytes(p.Topic[:]), "ticket", len(p.Ticket) > 0)
+}
+
+func (*Ticket) Name() string             { return "TICKET/v5" }
+func (*Ticket) Kind() byte               { return TicketMsg }
+func (p *Ticket) RequestID() []byte      { return p.ReqID }
+func (p *Ticket) SetRequestID(id []byte) { p.ReqID = id }
+
+func (p *Ticket) AppendLogInfo(ctx []interface{}) []interface{} {
+	return append(ctx, "req", hexutil.Bytes(p.ReqID), "wait", p.WaitTime)
+}
+
+func (*Regconfirmation) Name() string             { return "REGCONFIRMATION/v5" }
+func (*Regconfirmation) Kind() byte               { return RegconfirmationMsg }
+func (p *Regconfirmation) RequestID() []byte      { return p.ReqID }
+func (p *Regconfirmation) SetRequestID(id []byte) { p.ReqID = id }
+
+func (p *Regconfirmation) AppendLogInfo(ctx []interface{}) []interface{} {
+	return append(ctx, "req", hexutil.Bytes(p.ReqID), "topic", hexutil.Bytes(p.Topic[:]))
+}
+
+func (*TopicQuery) Name() string             { return "TOPICQUERY/v5" }
+func (*TopicQuery) Kind() byte               { return TopicQueryMsg }
+func (p *TopicQuery) RequestID() []byte      { return p.ReqID }
+func (p *TopicQuery) SetRequestID(id []byte) { p.ReqID = id }
+
+func (p *TopicQuery) AppendLogInfo(ctx []interface{}) []interface{} {
+	return append(ctx, "req", hexutil.Bytes(p.ReqID), "topic", hexutil.Bytes(p.Topic[:]))
+}
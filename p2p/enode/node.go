@@ -214,6 +214,16 @@ func (n *Node) QUICEndpoint() (netip.AddrPort, bool) {
 	return netip.AddrPortFrom(n.ip, quic), true
 }
 
+// RLPxQUICEndpoint returns the announced endpoint for RLPx over QUIC.
+func (n *Node) RLPxQUICEndpoint() (netip.AddrPort, bool) {
+	var port uint16
+	n.Load((*enr.RLPxQUIC)(&port))
+	if !n.ip.IsValid() || n.ip.IsUnspecified() || port == 0 {
+		return netip.AddrPort{}, false
+	}
+	return netip.AddrPortFrom(n.ip, port), true
+}
+
 // Pubkey returns the secp256k1 public key of the node, if present.
 func (n *Node) Pubkey() *ecdsa.PublicKey {
 	var key ecdsa.PublicKey
@@ -21,6 +21,7 @@ import (
 	"crypto/rand"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"net/netip"
 	"os"
 	"sync"
@@ -41,6 +42,7 @@ const (
 	dbVersionKey   = "version" // Version of the database to flush if changes
 	dbNodePrefix   = "n:"      // Identifier to prefix node entries with
 	dbLocalPrefix  = "local:"
+	dbScorePrefix  = "score:"
 	dbDiscoverRoot = "v4"
 	dbDiscv5Root   = "v5"
 
@@ -54,12 +56,18 @@ const (
 	// Local information is keyed by ID only, the full key is "local:<ID>:seq".
 	// Use localItemKey to create those keys.
 	dbLocalSeq = "seq"
+
+	// Peer reputation is keyed by ID only, the full key is "score:<ID>:value".
+	// Use scoreItemKey to create those keys.
+	dbScoreValue = "value"
+	dbScoreTime  = "time"
 )
 
 const (
-	dbNodeExpiration = 24 * time.Hour // Time after which an unseen node should be dropped.
-	dbCleanupCycle   = time.Hour      // Time period for running the expiration task.
-	dbVersion        = 9
+	dbNodeExpiration  = 24 * time.Hour     // Time after which an unseen node should be dropped.
+	dbScoreExpiration = 7 * 24 * time.Hour // Time after which an unchanged peer score should be dropped.
+	dbCleanupCycle    = time.Hour          // Time period for running the expiration task.
+	dbVersion         = 9
 )
 
 var (
@@ -194,6 +202,14 @@ func localItemKey(id ID, field string) []byte {
 	return key
 }
 
+// scoreItemKey returns the key of a peer reputation item.
+func scoreItemKey(id ID, field string) []byte {
+	key := append([]byte(dbScorePrefix), id[:]...)
+	key = append(key, ':')
+	key = append(key, field...)
+	return key
+}
+
 // fetchInt64 retrieves an integer associated with a particular key.
 func (db *DB) fetchInt64(key []byte) int64 {
 	blob, err := db.lvl.Get(key, nil)
@@ -315,6 +331,7 @@ func (db *DB) expirer() {
 		select {
 		case <-tick.C:
 			db.expireNodes()
+			db.expireScores()
 		case <-db.quit:
 			return
 		}
@@ -360,6 +377,24 @@ func (db *DB) expireNodes() {
 	}
 }
 
+// expireScores deletes the reputation scores of all peers which have not been
+// updated for some time.
+func (db *DB) expireScores() {
+	it := db.lvl.NewIterator(util.BytesPrefix([]byte(dbScorePrefix)), nil)
+	defer it.Release()
+
+	threshold := time.Now().Add(-dbScoreExpiration).Unix()
+	for it.Next() {
+		key := it.Key()
+		if !bytes.HasSuffix(key, []byte(":"+dbScoreTime)) {
+			continue
+		}
+		if updated, _ := binary.Varint(it.Value()); updated < threshold {
+			deleteRange(db.lvl, key[:len(key)-len(dbScoreTime)])
+		}
+	}
+}
+
 // LastPingReceived retrieves the time of the last ping packet received from
 // a remote node.
 func (db *DB) LastPingReceived(id ID, ip netip.Addr) time.Time {
@@ -427,6 +462,27 @@ func (db *DB) UpdateFindFailsV5(id ID, ip netip.Addr, fails int) error {
 	return db.storeInt64(v5Key(id, ip, dbNodeFindFails), int64(fails))
 }
 
+// Score retrieves the persisted reputation score of a peer and the time it was
+// last updated at.
+func (db *DB) Score(id ID) (float64, time.Time) {
+	updated := db.fetchInt64(scoreItemKey(id, dbScoreTime))
+	if updated == 0 {
+		return 0, time.Time{}
+	}
+	score := math.Float64frombits(db.fetchUint64(scoreItemKey(id, dbScoreValue)))
+	return score, time.Unix(updated, 0)
+}
+
+// UpdateScore stores the reputation score of a peer.
+func (db *DB) UpdateScore(id ID, score float64, instance time.Time) error {
+	// Launch expirer
+	db.ensureExpirer()
+	if err := db.storeUint64(scoreItemKey(id, dbScoreValue), math.Float64bits(score)); err != nil {
+		return err
+	}
+	return db.storeInt64(scoreItemKey(id, dbScoreTime), instance.Unix())
+}
+
 // localSeq retrieves the local record sequence counter, defaulting to the current
 // timestamp if no previous exists. This ensures that wiping all data associated
 // with a node (apart from its key) will not generate already used sequence nums.
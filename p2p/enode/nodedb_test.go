@@ -470,3 +470,28 @@ func TestDBExpireV5(t *testing.T) {
 	db.UpdateFindFailsV5(ID{}, ip, 4)
 	db.expireNodes()
 }
+
+// This test checks that peer scores are stored and expired.
+func TestDBScores(t *testing.T) {
+	db, _ := OpenDB("")
+	defer db.Close()
+
+	if score, updated := db.Score(ID{0x01}); score != 0 || !updated.IsZero() {
+		t.Fatalf("score of unknown node: %v at %v", score, updated)
+	}
+	now := time.Now()
+	db.UpdateScore(ID{0x01}, -12.5, now)
+	db.UpdateScore(ID{0x02}, 30, now.Add(-dbScoreExpiration-time.Minute))
+
+	if score, updated := db.Score(ID{0x01}); score != -12.5 || updated.Unix() != now.Unix() {
+		t.Fatalf("score mismatch: have %v at %v, want %v at %v", score, updated, -12.5, now)
+	}
+	db.expireScores()
+
+	if score, _ := db.Score(ID{0x01}); score != -12.5 {
+		t.Errorf("recent score expired")
+	}
+	if score, updated := db.Score(ID{0x02}); score != 0 || !updated.IsZero() {
+		t.Errorf("stale score not expired: %v at %v", score, updated)
+	}
+}
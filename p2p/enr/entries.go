@@ -87,6 +87,13 @@ type QUIC6 uint16
 
 func (v QUIC6) ENRKey() string { return "quic6" }
 
+// RLPxQUIC is the "rlpx-quic" key, which holds the UDP port on which the node
+// accepts RLPx connections over QUIC. It is distinct from the "quic" key, which
+// other protocols use for their own QUIC transports.
+type RLPxQUIC uint16
+
+func (v RLPxQUIC) ENRKey() string { return "rlpx-quic" }
+
 // ID is the "id" key, which holds the name of the identity scheme.
 type ID string
 
@@ -59,6 +59,25 @@ var (
 	dialUnexpectedIdentity  = metrics.NewRegisteredMeter("p2p/dials/error/id/unexpected", nil)
 	dialEncHandshakeError   = metrics.NewRegisteredMeter("p2p/dials/error/rlpx/enc", nil)
 	dialProtoHandshakeError = metrics.NewRegisteredMeter("p2p/dials/error/rlpx/proto", nil)
+
+	// peer reputation meters
+	scoreTimeoutMeter = metrics.NewRegisteredMeter("p2p/reputation/timeouts", nil)
+	scoreInvalidMeter = metrics.NewRegisteredMeter("p2p/reputation/invalid", nil)
+	scoreUsefulMeter  = metrics.NewRegisteredMeter("p2p/reputation/useful", nil)
+	evictMeter        = metrics.NewRegisteredMeter("p2p/reputation/evicted", nil)
+	rejectMeter       = metrics.NewRegisteredMeter("p2p/reputation/rejected", nil)
+	scoreHistogram    = metrics.NewRegisteredHistogram("p2p/reputation/scores", nil, metrics.ResettingSample(metrics.NewExpDecaySample(1028, 0.015)))
+
+	// QUIC transport meters
+	quicDialMeter     = metrics.NewRegisteredMeter("p2p/dials/quic", nil)
+	quicFallbackMeter = metrics.NewRegisteredMeter("p2p/dials/quic/fallback", nil)
+
+	// bandwidth cap meters
+	throttleMeter = metrics.NewRegisteredMeter("p2p/bandwidth/throttled", nil)
+
+	// node allowlist meters
+	permissionRejectMeter = metrics.NewRegisteredMeter("p2p/permissions/rejected", nil)
+	permissionDropMeter   = metrics.NewRegisteredMeter("p2p/permissions/dropped", nil)
 )
 
 func init() {
@@ -0,0 +1,511 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package netsim
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common/mclock"
+)
+
+// minRetransmitDelay is the lower bound of the time it takes to recover a lost
+// segment on a stream connection, like the minimum retransmission timeout of
+// TCP.
+const minRetransmitDelay = 200 * time.Millisecond
+
+var (
+	errConnReset   = errors.New("connection reset by partition")
+	errUnreachable = errors.New("network unreachable")
+	errRefused     = errors.New("connection refused")
+)
+
+// LinkConfig describes the simulated connection between two nodes.
+type LinkConfig struct {
+	Latency   time.Duration // One-way delay of data sent over the link
+	Bandwidth int           // Throughput in bytes per second, zero is unlimited
+	Loss      float64       // Probability of losing a packet, in [0, 1]
+}
+
+// LinkStats counts the traffic sent over a link in one direction.
+type LinkStats struct {
+	Bytes   uint64 // Bytes sent, including lost ones
+	Packets uint64 // Stream writes and datagrams sent
+	Lost    uint64 // Datagrams dropped and stream writes retransmitted
+}
+
+func (s *LinkStats) add(o LinkStats) {
+	s.Bytes += o.Bytes
+	s.Packets += o.Packets
+	s.Lost += o.Lost
+}
+
+// link is the simulated connection between two nodes. Each direction has its
+// own transmit queue and random source, the latter seeded from the network
+// seed, so losses are reproducible for the same sequence of writes.
+type link struct {
+	clock  mclock.Clock
+	mu     sync.Mutex
+	config LinkConfig
+	down   bool // Set while the nodes are partitioned
+	conns  map[*streamConn]struct{}
+	dirs   [2]linkDirection // Indexed by whether the sender is the higher node
+}
+
+type linkDirection struct {
+	rand  *rand.Rand
+	busy  mclock.AbsTime // Time the link finishes transmitting the queued data
+	stats LinkStats
+}
+
+func newLink(config LinkConfig, seed int64, clock mclock.Clock) *link {
+	l := &link{clock: clock, config: config, conns: make(map[*streamConn]struct{})}
+	l.dirs[0].rand = rand.New(rand.NewSource(seed))
+	l.dirs[1].rand = rand.New(rand.NewSource(seed + 1))
+	return l
+}
+
+// transmit schedules sending size bytes from the given end of the link. It
+// returns the time the data leaves the sender, the time it arrives at the
+// receiver and whether it got lost.
+func (l *link) transmit(dir int, size int) (sent, arrival mclock.AbsTime, lost bool) {
+	d := &l.dirs[dir]
+	sent = l.clock.Now()
+	if d.busy > sent {
+		sent = d.busy
+	}
+	if l.config.Bandwidth > 0 {
+		sent = sent.Add(time.Duration(size) * time.Second / time.Duration(l.config.Bandwidth))
+	}
+	d.busy = sent
+	lost = l.config.Loss > 0 && d.rand.Float64() < l.config.Loss
+
+	d.stats.Bytes += uint64(size)
+	d.stats.Packets++
+	if lost {
+		d.stats.Lost++
+	}
+	return sent, sent.Add(l.config.Latency), lost
+}
+
+// sendStream schedules a stream write. Lost writes are delivered late, after
+// the time it takes to retransmit them. All data of a stream arrives in order,
+// so a retransmission also delays the data following it.
+func (l *link) sendStream(dir int, size int) (sent, arrival mclock.AbsTime, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.down {
+		return 0, 0, errConnReset
+	}
+	sent, arrival, lost := l.transmit(dir, size)
+	if lost {
+		arrival = arrival.Add(max(minRetransmitDelay, 2*l.config.Latency))
+	}
+	return sent, arrival, nil
+}
+
+// sendPacket schedules a datagram, reporting false if it doesn't arrive.
+func (l *link) sendPacket(dir int, size int) (arrival mclock.AbsTime, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.down {
+		return 0, false
+	}
+	_, arrival, lost := l.transmit(dir, size)
+	return arrival, !lost
+}
+
+// setDown partitions or reconnects the nodes of the link. Partitioning resets
+// the stream connections over the link.
+func (l *link) setDown(down bool) {
+	l.mu.Lock()
+	l.down = down
+	var conns []*streamConn
+	if down {
+		for c := range l.conns {
+			conns = append(conns, c)
+		}
+	}
+	l.mu.Unlock()
+
+	for _, c := range conns {
+		c.reset()
+	}
+}
+
+func (l *link) track(c *streamConn) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.down {
+		return false
+	}
+	l.conns[c] = struct{}{}
+	return true
+}
+
+func (l *link) untrack(c *streamConn) {
+	l.mu.Lock()
+	delete(l.conns, c)
+	l.mu.Unlock()
+}
+
+// deadline is a resettable read or write deadline of a connection. The time
+// left until the deadline passes on the clock of the network.
+type deadline struct {
+	clock   mclock.Clock
+	mu      sync.Mutex
+	timer   mclock.Timer
+	expired chan struct{} // Closed when the deadline passes
+}
+
+func newDeadline(clock mclock.Clock) *deadline {
+	return &deadline{clock: clock, expired: make(chan struct{})}
+}
+
+// set changes the deadline, the zero time removing it.
+func (d *deadline) set(t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.timer != nil && !d.timer.Stop() {
+		<-d.expired // Wait for the timer callback to finish closing the channel
+	}
+	d.timer = nil
+
+	select {
+	case <-d.expired:
+		d.expired = make(chan struct{})
+	default:
+	}
+	if t.IsZero() {
+		return
+	}
+	if wait := time.Until(t); wait > 0 {
+		expired := d.expired
+		d.timer = d.clock.AfterFunc(wait, func() { close(expired) })
+	} else {
+		close(d.expired)
+	}
+}
+
+// wait returns a channel closed when the deadline passes.
+func (d *deadline) wait() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.expired
+}
+
+// stream is one direction of a stream connection, holding the data in flight.
+type stream struct {
+	mu   sync.Mutex
+	segs []segment
+	last mclock.AbsTime // Arrival time of the last segment
+	err  error          // Set when the stream ends, io.EOF for a regular close
+	wake chan struct{}  // Signals new segments and the end of the stream
+}
+
+type segment struct {
+	data    []byte
+	arrival mclock.AbsTime
+}
+
+func newStream() *stream {
+	return &stream{wake: make(chan struct{}, 1)}
+}
+
+// push queues data arriving at the given time, keeping the stream in order.
+func (s *stream) push(data []byte, arrival mclock.AbsTime) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	if arrival < s.last {
+		arrival = s.last
+	}
+	s.last = arrival
+	s.segs = append(s.segs, segment{data: data, arrival: arrival})
+	s.notify()
+	return nil
+}
+
+// end terminates the stream. Data in flight is still delivered when the stream
+// ends with io.EOF, and discarded on any other error.
+func (s *stream) end(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err == nil || s.err == io.EOF {
+		s.err = err
+	}
+	s.notify()
+}
+
+func (s *stream) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// pop reads the data arrived by now into b. If no data is available yet, it
+// returns the time to wait for the next segment, or zero if there is none.
+func (s *stream) pop(b []byte, now mclock.AbsTime) (n int, wait time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil && s.err != io.EOF {
+		return 0, 0, s.err
+	}
+	if len(s.segs) == 0 {
+		return 0, 0, s.err
+	}
+	seg := &s.segs[0]
+	if wait := seg.arrival.Sub(now); wait > 0 {
+		return 0, wait, nil
+	}
+	n = copy(b, seg.data)
+	if seg.data = seg.data[n:]; len(seg.data) == 0 {
+		s.segs = s.segs[1:]
+	}
+	return n, 0, nil
+}
+
+// streamConn is one end of a simulated stream connection.
+type streamConn struct {
+	link         *link
+	dir          int // Direction of writes on the link
+	laddr, raddr net.Addr
+	in, out      *stream
+	peer         *streamConn
+	rdead, wdead *deadline
+	closed       chan struct{}
+	closeOnce    sync.Once
+}
+
+// newStreamPipe creates the two ends of a connection over the given link.
+func newStreamPipe(l *link, dir int, laddr, raddr net.Addr) (*streamConn, *streamConn) {
+	ab, ba := newStream(), newStream()
+	a := &streamConn{
+		link: l, dir: dir, laddr: laddr, raddr: raddr, in: ba, out: ab,
+		rdead: newDeadline(l.clock), wdead: newDeadline(l.clock), closed: make(chan struct{}),
+	}
+	b := &streamConn{
+		link: l, dir: 1 - dir, laddr: raddr, raddr: laddr, in: ab, out: ba,
+		rdead: newDeadline(l.clock), wdead: newDeadline(l.clock), closed: make(chan struct{}),
+	}
+	a.peer, b.peer = b, a
+	return a, b
+}
+
+func (c *streamConn) Read(b []byte) (int, error) {
+	for {
+		select {
+		case <-c.closed:
+			return 0, net.ErrClosed
+		case <-c.rdead.wait():
+			return 0, os.ErrDeadlineExceeded
+		default:
+		}
+		n, wait, err := c.in.pop(b, c.link.clock.Now())
+		if n > 0 || err != nil {
+			return n, err
+		}
+		var (
+			timer   mclock.ChanTimer
+			timeout <-chan mclock.AbsTime
+		)
+		if wait > 0 {
+			timer = c.link.clock.NewTimer(wait)
+			timeout = timer.C()
+		}
+		select {
+		case <-c.in.wake:
+		case <-timeout:
+		case <-c.closed:
+		case <-c.rdead.wait():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// Write sends the data once the link has transmitted everything written
+// before, blocking the writer while the bandwidth is exhausted.
+func (c *streamConn) Write(b []byte) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, net.ErrClosed
+	case <-c.wdead.wait():
+		return 0, os.ErrDeadlineExceeded
+	default:
+	}
+	sent, arrival, err := c.link.sendStream(c.dir, len(b))
+	if err != nil {
+		c.reset()
+		return 0, err
+	}
+	if err := c.out.push(append([]byte(nil), b...), arrival); err != nil {
+		return 0, err
+	}
+	if wait := sent.Sub(c.link.clock.Now()); wait > 0 {
+		timer := c.link.clock.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C():
+		case <-c.closed:
+			return 0, net.ErrClosed
+		case <-c.wdead.wait():
+			return 0, os.ErrDeadlineExceeded
+		}
+	}
+	return len(b), nil
+}
+
+// Close ends the connection. The remote end receives the data in flight before
+// reading EOF, while its further writes fail.
+func (c *streamConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.out.end(io.EOF)
+		c.in.end(errConnReset)
+		c.link.untrack(c)
+	})
+	return nil
+}
+
+// reset aborts both ends of the connection.
+func (c *streamConn) reset() {
+	c.in.end(errConnReset)
+	c.out.end(errConnReset)
+	c.link.untrack(c)
+	c.link.untrack(c.peer)
+}
+
+func (c *streamConn) LocalAddr() net.Addr  { return c.laddr }
+func (c *streamConn) RemoteAddr() net.Addr { return c.raddr }
+
+func (c *streamConn) SetDeadline(t time.Time) error {
+	c.rdead.set(t)
+	c.wdead.set(t)
+	return nil
+}
+
+func (c *streamConn) SetReadDeadline(t time.Time) error {
+	c.rdead.set(t)
+	return nil
+}
+
+func (c *streamConn) SetWriteDeadline(t time.Time) error {
+	c.wdead.set(t)
+	return nil
+}
+
+// listener accepts the stream connections dialed to a port of a node.
+type listener struct {
+	node      *Node
+	addr      *net.TCPAddr
+	conns     chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *listener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.closed)
+		l.node.removeListener(l)
+	})
+	return nil
+}
+
+func (l *listener) Addr() net.Addr { return l.addr }
+
+// packet is a datagram received by a node.
+type packet struct {
+	data []byte
+	from netip.AddrPort
+}
+
+// packetConn is a simulated UDP socket, implementing discover.UDPConn.
+type packetConn struct {
+	node      *Node
+	addr      *net.UDPAddr
+	inbox     chan packet
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (c *packetConn) ReadFromUDPAddrPort(b []byte) (int, netip.AddrPort, error) {
+	select {
+	case p := <-c.inbox:
+		return copy(b, p.data), p.from, nil
+	case <-c.closed:
+		return 0, netip.AddrPort{}, net.ErrClosed
+	}
+}
+
+// WriteToUDPAddrPort sends a datagram. Like with a real socket, datagrams that
+// get lost or can't be delivered are reported as sent.
+func (c *packetConn) WriteToUDPAddrPort(b []byte, addr netip.AddrPort) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, net.ErrClosed
+	default:
+	}
+	c.node.net.sendPacket(c.node, c.addr.AddrPort(), addr, append([]byte(nil), b...))
+	return len(b), nil
+}
+
+// deliver queues a received datagram, dropping it if the receive buffer is
+// full.
+func (c *packetConn) deliver(p packet) {
+	select {
+	case c.inbox <- p:
+	case <-c.closed:
+	default:
+	}
+}
+
+func (c *packetConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.node.removePacketConn(c)
+	})
+	return nil
+}
+
+func (c *packetConn) LocalAddr() net.Addr { return c.addr }
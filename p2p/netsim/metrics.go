@@ -0,0 +1,157 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package netsim
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common/mclock"
+	"github.com/rajchain/go-rajchain/p2p"
+)
+
+// Metrics collects statistics about the messages sent by the nodes of a
+// network, and about the spread of events through it.
+type Metrics struct {
+	clock mclock.Clock
+	mu    sync.Mutex
+	msgs  map[msgKey]*MsgStats
+	marks map[string]map[int]mclock.AbsTime
+}
+
+type msgKey struct {
+	protocol string
+	code     uint64
+}
+
+// MsgStats counts the messages of a protocol sent by all nodes.
+type MsgStats struct {
+	Count uint64
+	Bytes uint64
+}
+
+func newMetrics(clock mclock.Clock) *Metrics {
+	return &Metrics{
+		clock: clock,
+		msgs:  make(map[msgKey]*MsgStats),
+		marks: make(map[string]map[int]mclock.AbsTime),
+	}
+}
+
+// collect counts the messages sent by a node until it stops.
+func (m *Metrics) collect(n *Node, events chan *p2p.PeerEvent, done chan struct{}) {
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type != p2p.PeerEventTypeMsgSend || ev.MsgCode == nil {
+				continue
+			}
+			m.mu.Lock()
+			key := msgKey{ev.Protocol, *ev.MsgCode}
+			stats := m.msgs[key]
+			if stats == nil {
+				stats = new(MsgStats)
+				m.msgs[key] = stats
+			}
+			stats.Count++
+			if ev.MsgSize != nil {
+				stats.Bytes += uint64(*ev.MsgSize)
+			}
+			m.mu.Unlock()
+		case <-done:
+			return
+		}
+	}
+}
+
+// Messages returns the statistics of the messages with the given code of the
+// named protocol.
+func (m *Metrics) Messages(protocol string, code uint64) MsgStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stats := m.msgs[msgKey{protocol, code}]; stats != nil {
+		return *stats
+	}
+	return MsgStats{}
+}
+
+// Protocol returns the statistics of all messages of a protocol.
+func (m *Metrics) Protocol(protocol string) MsgStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var total MsgStats
+	for key, stats := range m.msgs {
+		if key.protocol == protocol {
+			total.Count += stats.Count
+			total.Bytes += stats.Bytes
+		}
+	}
+	return total
+}
+
+// Mark records that a node observed an event, like the arrival of a block or
+// transaction. Only the first observation of each node counts, Mark reports
+// whether this is it.
+func (m *Metrics) Mark(event string, n *Node) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	marks := m.marks[event]
+	if marks == nil {
+		marks = make(map[int]mclock.AbsTime)
+		m.marks[event] = marks
+	}
+	if _, ok := marks[n.index]; ok {
+		return false
+	}
+	marks[n.index] = m.clock.Now()
+	return true
+}
+
+// Seen returns the number of nodes which observed an event.
+func (m *Metrics) Seen(event string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.marks[event])
+}
+
+// Spread returns, in ascending order, the time it took every node observing
+// an event to do so, measured from the first observation on the clock of the
+// network.
+func (m *Metrics) Spread(event string) []time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var (
+		first mclock.AbsTime
+		found bool
+	)
+	for _, t := range m.marks[event] {
+		if !found || t < first {
+			first, found = t, true
+		}
+	}
+	delays := make([]time.Duration, 0, len(m.marks[event]))
+	for _, t := range m.marks[event] {
+		delays = append(delays, t.Sub(first))
+	}
+	slices.Sort(delays)
+	return delays
+}
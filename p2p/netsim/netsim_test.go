@@ -0,0 +1,377 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package netsim
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common/mclock"
+	"github.com/rajchain/go-rajchain/p2p"
+	"github.com/rajchain/go-rajchain/p2p/enode"
+)
+
+// newTestNetwork creates a network of count bare nodes.
+func newTestNetwork(t *testing.T, config Config, count int) *Network {
+	t.Helper()
+
+	net := New(config)
+	t.Cleanup(func() { net.Close() })
+	for i := 0; i < count; i++ {
+		if _, err := net.AddNode(NodeConfig{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return net
+}
+
+// drive runs fn while letting the simulation run, until fn returns.
+func drive(t *testing.T, net *Network, fn func()) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := net.Wait(ctx, func(*Network) bool {
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// pipe opens a stream connection between two nodes on a spare port.
+func pipe(t *testing.T, a, b *Node) (ca, cb io.ReadWriteCloser) {
+	t.Helper()
+
+	ln, err := b.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	accepted := make(chan io.ReadWriteCloser, 1)
+	go func() {
+		c, _ := ln.Accept()
+		accepted <- c
+	}()
+	addr := netip.AddrPortFrom(b.IP(), uint16(ln.Addr().(interface{ AddrPort() netip.AddrPort }).AddrPort().Port()))
+	drive(t, a.net, func() {
+		ca, err = a.dial(context.Background(), addr)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca, <-accepted
+}
+
+// Tests that data is delayed by the latency and bandwidth of the link, timed
+// on the simulated clock of the network.
+func TestLinkLatencyBandwidth(t *testing.T) {
+	clock := new(mclock.Simulated)
+	net := newTestNetwork(t, Config{Clock: clock, Link: LinkConfig{Latency: 50 * time.Millisecond, Bandwidth: 100_000}}, 2)
+	a, b := pipe(t, net.Node(0), net.Node(1))
+	defer a.Close()
+	defer b.Close()
+
+	// Queue the write without advancing the clock
+	go a.Write(make([]byte, 10_000))
+	for net.LinkStats(net.Node(0), net.Node(1)).Packets == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	read := make(chan error, 1)
+	go func() {
+		_, err := io.ReadFull(b, make([]byte, 10_000))
+		read <- err
+	}()
+	// 10kB take 100ms to transmit and another 50ms to arrive
+	clock.Run(149 * time.Millisecond)
+	select {
+	case <-read:
+		t.Fatal("data arrived too early")
+	case <-time.After(50 * time.Millisecond):
+	}
+	clock.Run(time.Millisecond)
+	select {
+	case err := <-read:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("data didn't arrive in time")
+	}
+	stats := net.LinkStats(net.Node(0), net.Node(1))
+	if stats.Bytes != 10_000 || stats.Packets != 1 {
+		t.Fatalf("wrong link stats: %+v", stats)
+	}
+	// Closing delivers the data in flight before EOF
+	var (
+		buf []byte
+		err error
+	)
+	drive(t, net, func() {
+		a.Write([]byte("bye"))
+		a.Close()
+		buf, err = io.ReadAll(b)
+	})
+	if err != nil || string(buf) != "bye" {
+		t.Fatalf("wrong data before EOF: %q, %v", buf, err)
+	}
+}
+
+func TestPartition(t *testing.T) {
+	net := newTestNetwork(t, Config{}, 2)
+	n0, n1 := net.Node(0), net.Node(1)
+	a, b := pipe(t, n0, n1)
+	defer a.Close()
+	defer b.Close()
+
+	readErr := make(chan error, 1)
+	go func() {
+		_, err := b.Read(make([]byte, 1))
+		readErr <- err
+	}()
+	net.Partition([]*Node{n0}, []*Node{n1})
+	if err := <-readErr; err != errConnReset {
+		t.Fatalf("wrong read error after partition: %v", err)
+	}
+	if _, err := a.Write([]byte{1}); err == nil {
+		t.Fatal("write succeeded after partition")
+	}
+	if _, err := n0.dial(context.Background(), netip.AddrPortFrom(n1.IP(), defaultPort)); err != errUnreachable {
+		t.Fatalf("wrong dial error during partition: %v", err)
+	}
+	net.Heal()
+	c, err := n0.dial(context.Background(), netip.AddrPortFrom(n1.IP(), defaultPort))
+	if err != nil {
+		t.Fatalf("dial failed after heal: %v", err)
+	}
+	c.Close()
+}
+
+// Tests that packet losses are the same for the same seed.
+func TestPacketLossDeterministic(t *testing.T) {
+	received := func(seed int64) int {
+		net := newTestNetwork(t, Config{Seed: seed, Link: LinkConfig{Loss: 0.3}}, 2)
+		n0, n1 := net.Node(0), net.Node(1)
+		src, err := n0.ListenUDP(":0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst, err := n1.ListenUDP(":0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		to := dst.LocalAddr().(interface{ AddrPort() netip.AddrPort }).AddrPort()
+		for i := 0; i < 200; i++ {
+			src.WriteToUDPAddrPort([]byte{byte(i)}, to)
+		}
+		stats := net.LinkStats(n0, n1)
+		if stats.Packets != 200 {
+			t.Fatalf("wrong packet count: %d", stats.Packets)
+		}
+		// Drain the datagrams that made it
+		for i := 0; i < 200-int(stats.Lost); i++ {
+			if _, _, err := dst.ReadFromUDPAddrPort(make([]byte, 1)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return int(stats.Lost)
+	}
+	lost := received(1)
+	if lost == 0 || lost == 200 {
+		t.Fatalf("implausible loss: %d of 200", lost)
+	}
+	if again := received(1); again != lost {
+		t.Fatalf("loss differs for the same seed: %d != %d", again, lost)
+	}
+}
+
+// flooder runs a protocol which relays every message to all peers once.
+type flooder struct {
+	net  *Network
+	mu   sync.Mutex
+	node *Node
+	rws  map[enode.ID]p2p.MsgReadWriter
+	seen map[uint64]bool
+}
+
+func newFlooder(net *Network) *flooder {
+	return &flooder{net: net, rws: make(map[enode.ID]p2p.MsgReadWriter), seen: make(map[uint64]bool)}
+}
+
+func (f *flooder) protocol() p2p.Protocol {
+	return p2p.Protocol{
+		Name:    "flood",
+		Version: 1,
+		Length:  1,
+		Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+			f.mu.Lock()
+			f.rws[p.ID()] = rw
+			f.mu.Unlock()
+			defer func() {
+				f.mu.Lock()
+				delete(f.rws, p.ID())
+				f.mu.Unlock()
+			}()
+			for {
+				msg, err := rw.ReadMsg()
+				if err != nil {
+					return err
+				}
+				var id uint64
+				if err := msg.Decode(&id); err != nil {
+					return err
+				}
+				f.publish(id)
+			}
+		},
+	}
+}
+
+// publish marks a message as seen and relays it.
+func (f *flooder) publish(id uint64) {
+	f.mu.Lock()
+	if f.seen[id] {
+		f.mu.Unlock()
+		return
+	}
+	f.seen[id] = true
+	f.net.Metrics().Mark(fmt.Sprint(id), f.node)
+	rws := make([]p2p.MsgReadWriter, 0, len(f.rws))
+	for _, rw := range f.rws {
+		rws = append(rws, rw)
+	}
+	f.mu.Unlock()
+
+	for _, rw := range rws {
+		go p2p.Send(rw, 0, id)
+	}
+}
+
+// Tests a scripted simulation, flooding messages through a ring of nodes
+// before and after partitioning it.
+func TestScript(t *testing.T) {
+	const count = 8
+	var (
+		net      = New(Config{Seed: 1, Clock: new(mclock.Simulated), Link: LinkConfig{Latency: 5 * time.Millisecond}})
+		flooders []*flooder
+	)
+	defer net.Close()
+
+	addFlooders := Do("add flooders", func(net *Network) error {
+		for i := 0; i < count; i++ {
+			f := newFlooder(net)
+			n, err := net.AddNode(NodeConfig{Protocols: []p2p.Protocol{f.protocol()}})
+			if err != nil {
+				return err
+			}
+			f.mu.Lock()
+			f.node = n
+			f.mu.Unlock()
+			flooders = append(flooders, f)
+		}
+		return nil
+	})
+	publish := func(from int, id uint64) Step {
+		return Do(fmt.Sprintf("publish %d", id), func(net *Network) error {
+			flooders[from].publish(id)
+			return nil
+		})
+	}
+	// Cutting the ring in two places leaves two halves, with the messages only
+	// reaching the half of the publisher.
+	var (
+		half1 = []int{0, 1, 2, 3}
+		half2 = []int{4, 5, 6, 7}
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	err := net.Run(ctx,
+		addFlooders,
+		ConnectRing(),
+		WaitPeers(2),
+		publish(0, 1),
+		WaitSeen("1", count),
+		Partition(half1, half2),
+		WaitDisconnected(3, 4),
+		WaitDisconnected(7, 0),
+		publish(0, 2),
+		WaitSeen("2", len(half1)),
+		Sleep(100*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seen := net.Metrics().Seen("2"); seen != len(half1) {
+		t.Fatalf("message crossed partition: seen by %d nodes", seen)
+	}
+	if spread := net.Metrics().Spread("1"); len(spread) != count || spread[count-1] < 20*time.Millisecond {
+		t.Fatalf("implausible propagation times: %v", spread)
+	}
+	if stats := net.Metrics().Protocol("flood"); stats.Count < count {
+		t.Fatalf("too few messages counted: %+v", stats)
+	}
+	// Healing lets the nodes reconnect. Node 3 only redials node 4 once its
+	// dial history expires, so connect from the other end.
+	err = net.Run(ctx,
+		Heal(),
+		Connect(4, 3),
+		WaitConnected(3, 4),
+		publish(0, 3),
+		WaitSeen("3", count),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Tests that nodes find each other through discovery on the simulated network.
+func TestDiscovery(t *testing.T) {
+	const count = 6
+	net := New(Config{Seed: 2, Clock: new(mclock.Simulated), Link: LinkConfig{Latency: time.Millisecond}})
+	defer net.Close()
+
+	boot, err := net.AddNode(NodeConfig{Discovery: true, MaxPeers: count})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	err = net.Run(ctx,
+		AddNodes(count-1, NodeConfig{Discovery: true, MaxPeers: count, Bootnodes: []*enode.Node{boot.Node()}}),
+		WaitPeers(2),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
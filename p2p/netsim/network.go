@@ -0,0 +1,659 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package netsim runs many p2p servers in process, connected through a
+// simulated network.
+//
+// Every node of the simulation gets a virtual IP address. Its server listens
+// and dials through the simulated network instead of the operating system,
+// and discovery runs over simulated UDP sockets. The links between the nodes
+// delay, throttle and drop traffic according to their configuration, and can
+// be partitioned. Node keys, topologies and losses are derived from the seed of
+// the network, so a simulation makes the same choices on every run.
+//
+// The links run on the clock of the network. With a simulated clock, time on
+// the links only passes while a script sleeps or waits and while the network
+// closes, and the delivery of data follows the configured delays exactly. The
+// nodes themselves still run on the system clock, so the order of their actions
+// remains subject to goroutine scheduling.
+package netsim
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"net/netip"
+	"strconv"
+	"sync"
+
+	"github.com/rajchain/go-rajchain/common/mclock"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/event"
+	"github.com/rajchain/go-rajchain/log"
+	"github.com/rajchain/go-rajchain/p2p"
+	"github.com/rajchain/go-rajchain/p2p/discover"
+	"github.com/rajchain/go-rajchain/p2p/enode"
+	"github.com/rajchain/go-rajchain/p2p/nat"
+)
+
+const (
+	// defaultPort is the port the simulated nodes listen on.
+	defaultPort = 30303
+
+	// firstEphemeralPort is the first port assigned to dialed connections
+	// and sockets listening on port zero.
+	firstEphemeralPort = 40000
+
+	// inboxSize is the number of datagrams a socket buffers.
+	inboxSize = 256
+)
+
+// Config is the configuration of a simulated network.
+type Config struct {
+	// Seed derives the node keys, random topologies and link losses.
+	Seed int64
+
+	// Link is the configuration of the links between the nodes, unless
+	// changed with SetLink.
+	Link LinkConfig
+
+	// Logger is the parent logger of the nodes.
+	Logger log.Logger
+
+	// Clock is the time source of the links, the system clock if nil. A
+	// *mclock.Simulated is advanced by the scripts run on the network.
+	Clock mclock.Clock
+}
+
+// Service is the software running on a simulated node.
+type Service interface {
+	Server() *p2p.Server
+	Close() error
+}
+
+// StartFunc creates and starts the service of a node, running a p2p server
+// with the given configuration. A node.Node created with the configuration is
+// a valid service.
+type StartFunc func(config p2p.Config) (Service, error)
+
+// NodeConfig is the configuration of a simulated node.
+type NodeConfig struct {
+	// MaxPeers is the peer limit of the node, 10 if zero.
+	MaxPeers int
+
+	// Discovery enables both versions of the discovery protocol.
+	Discovery bool
+
+	// Bootnodes are the discovery bootstrap nodes.
+	Bootnodes []*enode.Node
+
+	// Protocols run on a bare p2p server, if Start isn't set.
+	Protocols []p2p.Protocol
+
+	// Start creates the service of the node. If nil, the node runs a bare
+	// p2p server.
+	Start StartFunc
+}
+
+// Network is a simulated network of nodes.
+type Network struct {
+	config  Config
+	log     log.Logger
+	clock   mclock.Clock
+	metrics *Metrics
+
+	addMu sync.Mutex // Serializes adding nodes
+
+	mu    sync.Mutex
+	rand  *rand.Rand
+	nodes []*Node
+	byIP  map[netip.Addr]*Node
+	links map[[2]int]*link
+}
+
+// New creates an empty network.
+func New(config Config) *Network {
+	logger := config.Logger
+	if logger == nil {
+		logger = log.Root()
+	}
+	clock := config.Clock
+	if clock == nil {
+		clock = mclock.System{}
+	}
+	return &Network{
+		config:  config,
+		log:     logger,
+		clock:   clock,
+		metrics: newMetrics(clock),
+		rand:    rand.New(rand.NewSource(config.Seed)),
+		byIP:    make(map[netip.Addr]*Node),
+		links:   make(map[[2]int]*link),
+	}
+}
+
+// Node is a simulated node.
+type Node struct {
+	net   *Network
+	index int
+	ip    netip.Addr
+	key   *ecdsa.PrivateKey
+
+	service Service
+	sub     event.Subscription
+	done    chan struct{}
+
+	mu        sync.Mutex
+	nextPort  uint16
+	listeners map[uint16]*listener
+	sockets   map[uint16]*packetConn
+}
+
+// Index returns the position of the node in the network.
+func (n *Node) Index() int { return n.index }
+
+// IP returns the address of the node on the simulated network.
+func (n *Node) IP() netip.Addr { return n.ip }
+
+// ID returns the node ID.
+func (n *Node) ID() enode.ID { return enode.PubkeyToIDV4(&n.key.PublicKey) }
+
+// Service returns the service running on the node.
+func (n *Node) Service() Service { return n.service }
+
+// Server returns the p2p server of the node.
+func (n *Node) Server() *p2p.Server { return n.service.Server() }
+
+// Node returns the current record of the node.
+func (n *Node) Node() *enode.Node { return n.Server().Self() }
+
+func (n *Node) String() string { return fmt.Sprintf("node%d", n.index) }
+
+// nodeIP assigns the address of a node. Every node gets its own /24 subnet, so
+// the IP limits of the discovery table don't apply between simulated nodes.
+func nodeIP(index int) netip.Addr {
+	return netip.AddrFrom4([4]byte{10, byte(index >> 8), byte(index), 1})
+}
+
+// nodeKey derives the key of a node from the network seed.
+func (net *Network) nodeKey(index int) *ecdsa.PrivateKey {
+	var buf [24]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(net.config.Seed))
+	binary.BigEndian.PutUint64(buf[8:], uint64(index))
+	for i := uint64(0); ; i++ {
+		binary.BigEndian.PutUint64(buf[16:], i)
+		if key, err := crypto.ToECDSA(crypto.Keccak256(buf[:])); err == nil {
+			return key
+		}
+	}
+}
+
+// AddNode creates and starts a node.
+func (net *Network) AddNode(config NodeConfig) (*Node, error) {
+	net.addMu.Lock()
+	defer net.addMu.Unlock()
+
+	net.mu.Lock()
+	index := len(net.nodes)
+	net.mu.Unlock()
+	if index > 0xffff {
+		return nil, errors.New("network is full")
+	}
+	n := &Node{
+		net:       net,
+		index:     index,
+		ip:        nodeIP(index),
+		key:       net.nodeKey(index),
+		done:      make(chan struct{}),
+		nextPort:  firstEphemeralPort,
+		listeners: make(map[uint16]*listener),
+		sockets:   make(map[uint16]*packetConn),
+	}
+	maxPeers := config.MaxPeers
+	if maxPeers == 0 {
+		maxPeers = 10
+	}
+	cfg := p2p.Config{
+		PrivateKey:       n.key,
+		Name:             n.String(),
+		MaxPeers:         maxPeers,
+		NoDiscovery:      !config.Discovery,
+		DiscoveryV4:      config.Discovery,
+		DiscoveryV5:      config.Discovery,
+		BootstrapNodes:   config.Bootnodes,
+		BootstrapNodesV5: config.Bootnodes,
+		Protocols:        config.Protocols,
+		ListenAddr:       netip.AddrPortFrom(n.ip, defaultPort).String(),
+		NAT:              nat.ExtIP(n.ip.AsSlice()),
+		Dialer:           nodeDialer{n},
+		Sockets:          n,
+		EnableMsgEvents:  true,
+		Logger:           net.log.New("node", index),
+	}
+	// The node must be reachable by the time the service starts, as it may
+	// begin dialing right away.
+	net.mu.Lock()
+	net.nodes = append(net.nodes, n)
+	net.byIP[n.ip] = n
+	net.mu.Unlock()
+
+	start := config.Start
+	if start == nil {
+		start = startServer
+	}
+	service, err := start(cfg)
+	if err != nil {
+		net.mu.Lock()
+		net.nodes = net.nodes[:index]
+		delete(net.byIP, n.ip)
+		net.mu.Unlock()
+		n.closeSockets()
+		return nil, err
+	}
+	n.service = service
+
+	events := make(chan *p2p.PeerEvent, 1024)
+	n.sub = service.Server().SubscribeEvents(events)
+	go net.metrics.collect(n, events, n.done)
+	return n, nil
+}
+
+// startServer runs a bare p2p server.
+func startServer(config p2p.Config) (Service, error) {
+	srv := &p2p.Server{Config: config}
+	if err := srv.Start(); err != nil {
+		return nil, err
+	}
+	return serverService{srv}, nil
+}
+
+type serverService struct{ srv *p2p.Server }
+
+func (s serverService) Server() *p2p.Server { return s.srv }
+
+func (s serverService) Close() error {
+	s.srv.Stop()
+	return nil
+}
+
+// Nodes returns all nodes of the network.
+func (net *Network) Nodes() []*Node {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	return append([]*Node(nil), net.nodes...)
+}
+
+// Node returns the node at the given index, or nil if it doesn't exist.
+func (net *Network) Node(index int) *Node {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	if index < 0 || index >= len(net.nodes) {
+		return nil
+	}
+	return net.nodes[index]
+}
+
+// Metrics returns the collected statistics of the network.
+func (net *Network) Metrics() *Metrics {
+	return net.metrics
+}
+
+// Close stops all nodes.
+func (net *Network) Close() error {
+	if _, ok := net.clock.(*mclock.Simulated); ok {
+		// Keep the simulated clock running, so the links deliver the
+		// disconnect messages and time out the writes of stopping nodes.
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go net.Sleep(ctx, math.MaxInt64)
+	}
+	var errs []error
+	for _, n := range net.Nodes() {
+		if err := n.stop(); err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", n, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stop closes the service of the node.
+func (n *Node) stop() error {
+	select {
+	case <-n.done:
+		return nil
+	default:
+	}
+	err := n.service.Close()
+	n.sub.Unsubscribe()
+	close(n.done)
+	n.closeSockets()
+	return err
+}
+
+func (n *Node) closeSockets() {
+	n.mu.Lock()
+	var (
+		listeners []*listener
+		sockets   []*packetConn
+	)
+	for _, l := range n.listeners {
+		listeners = append(listeners, l)
+	}
+	for _, c := range n.sockets {
+		sockets = append(sockets, c)
+	}
+	n.mu.Unlock()
+
+	for _, l := range listeners {
+		l.Close()
+	}
+	for _, c := range sockets {
+		c.Close()
+	}
+}
+
+// link returns the link between two nodes, creating it on first use.
+func (net *Network) link(a, b *Node) (*link, int) {
+	key, dir := [2]int{a.index, b.index}, 0
+	if a.index > b.index {
+		key, dir = [2]int{b.index, a.index}, 1
+	}
+	net.mu.Lock()
+	defer net.mu.Unlock()
+
+	l := net.links[key]
+	if l == nil {
+		seed := net.config.Seed ^ int64(key[0])<<32 ^ int64(key[1])<<1
+		l = newLink(net.config.Link, seed, net.clock)
+		net.links[key] = l
+	}
+	return l, dir
+}
+
+// SetLink changes the configuration of the link between two nodes. Data in
+// flight is not affected.
+func (net *Network) SetLink(a, b *Node, config LinkConfig) {
+	l, _ := net.link(a, b)
+	l.mu.Lock()
+	l.config = config
+	l.mu.Unlock()
+}
+
+// LinkStats returns the traffic sent from a to b.
+func (net *Network) LinkStats(a, b *Node) LinkStats {
+	l, dir := net.link(a, b)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.dirs[dir].stats
+}
+
+// Traffic returns the total traffic sent over the network.
+func (net *Network) Traffic() LinkStats {
+	net.mu.Lock()
+	links := make([]*link, 0, len(net.links))
+	for _, l := range net.links {
+		links = append(links, l)
+	}
+	net.mu.Unlock()
+
+	var total LinkStats
+	for _, l := range links {
+		l.mu.Lock()
+		total.add(l.dirs[0].stats)
+		total.add(l.dirs[1].stats)
+		l.mu.Unlock()
+	}
+	return total
+}
+
+// Partition cuts the links between the nodes of group a and those of group b.
+// Stream connections between them are reset and datagrams are dropped until
+// the partition is healed.
+func (net *Network) Partition(a, b []*Node) {
+	for _, x := range a {
+		for _, y := range b {
+			if x != y {
+				l, _ := net.link(x, y)
+				l.setDown(true)
+			}
+		}
+	}
+}
+
+// Heal removes all partitions. Note that servers don't redial a node until
+// their dial history of the node expires.
+func (net *Network) Heal() {
+	net.mu.Lock()
+	links := make([]*link, 0, len(net.links))
+	for _, l := range net.links {
+		links = append(links, l)
+	}
+	net.mu.Unlock()
+
+	for _, l := range links {
+		l.setDown(false)
+	}
+}
+
+// Connect makes node a dial node b, without waiting for the connection.
+func (net *Network) Connect(a, b *Node) {
+	a.Server().AddPeer(b.Node())
+}
+
+// Disconnect drops the connection between two nodes.
+func (net *Network) Disconnect(a, b *Node) {
+	a.Server().RemovePeer(b.Node())
+}
+
+// Connected reports whether two nodes are peers.
+func (net *Network) Connected(a, b *Node) bool {
+	id := b.ID()
+	for _, p := range a.Server().Peers() {
+		if p.ID() == id {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeByIP returns the node with the given address.
+func (net *Network) nodeByIP(ip netip.Addr) *Node {
+	net.mu.Lock()
+	defer net.mu.Unlock()
+	return net.byIP[ip.Unmap()]
+}
+
+// sendPacket transmits a datagram from node n.
+func (net *Network) sendPacket(n *Node, from, to netip.AddrPort, data []byte) {
+	dest := net.nodeByIP(to.Addr())
+	if dest == nil {
+		return
+	}
+	l, dir := net.link(n, dest)
+	arrival, ok := l.sendPacket(dir, len(data))
+	if !ok {
+		return
+	}
+	net.clock.AfterFunc(arrival.Sub(net.clock.Now()), func() {
+		if c := dest.packetConn(to.Port()); c != nil {
+			c.deliver(packet{data: data, from: from})
+		}
+	})
+}
+
+// parsePort returns the port of a listening address, allocating one if it is
+// zero.
+func (n *Node) parsePort(addr string) (uint16, error) {
+	_, portstr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return 0, err
+	}
+	port, err := strconv.ParseUint(portstr, 10, 16)
+	if err != nil {
+		return 0, err
+	}
+	if port == 0 {
+		return n.ephemeralPort(), nil
+	}
+	return uint16(port), nil
+}
+
+func (n *Node) ephemeralPort() uint16 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	port := n.nextPort
+	n.nextPort++
+	return port
+}
+
+// Listen implements p2p.SocketProvider, listening on the node address
+// regardless of the host in addr.
+func (n *Node) Listen(network, addr string) (net.Listener, error) {
+	if network != "tcp" {
+		return nil, fmt.Errorf("unsupported network %q", network)
+	}
+	port, err := n.parsePort(addr)
+	if err != nil {
+		return nil, err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.listeners[port] != nil {
+		return nil, fmt.Errorf("port %d in use", port)
+	}
+	l := &listener{
+		node:   n,
+		addr:   net.TCPAddrFromAddrPort(netip.AddrPortFrom(n.ip, port)),
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+	n.listeners[port] = l
+	return l, nil
+}
+
+// ListenUDP implements p2p.SocketProvider, opening a socket on the node address
+// regardless of the host in addr.
+func (n *Node) ListenUDP(addr string) (discover.UDPConn, error) {
+	port, err := n.parsePort(addr)
+	if err != nil {
+		return nil, err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.sockets[port] != nil {
+		return nil, fmt.Errorf("port %d in use", port)
+	}
+	c := &packetConn{
+		node:   n,
+		addr:   net.UDPAddrFromAddrPort(netip.AddrPortFrom(n.ip, port)),
+		inbox:  make(chan packet, inboxSize),
+		closed: make(chan struct{}),
+	}
+	n.sockets[port] = c
+	return c, nil
+}
+
+func (n *Node) listener(port uint16) *listener {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.listeners[port]
+}
+
+func (n *Node) packetConn(port uint16) *packetConn {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.sockets[port]
+}
+
+func (n *Node) removeListener(l *listener) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if port := uint16(l.addr.Port); n.listeners[port] == l {
+		delete(n.listeners, port)
+	}
+}
+
+func (n *Node) removePacketConn(c *packetConn) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if port := uint16(c.addr.Port); n.sockets[port] == c {
+		delete(n.sockets, port)
+	}
+}
+
+// dial opens a stream connection to the given address. Establishing the
+// connection takes a round trip over the link.
+func (n *Node) dial(ctx context.Context, addr netip.AddrPort) (net.Conn, error) {
+	dest := n.net.nodeByIP(addr.Addr())
+	if dest == nil {
+		return nil, errUnreachable
+	}
+	l, dir := n.net.link(n, dest)
+	l.mu.Lock()
+	down, latency := l.down, l.config.Latency
+	l.mu.Unlock()
+	if down {
+		return nil, errUnreachable
+	}
+	if latency > 0 {
+		timer := n.net.clock.NewTimer(2 * latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-n.done:
+			return nil, net.ErrClosed
+		}
+	}
+	ln := dest.listener(addr.Port())
+	if ln == nil {
+		return nil, errRefused
+	}
+	laddr := net.TCPAddrFromAddrPort(netip.AddrPortFrom(n.ip, n.ephemeralPort()))
+	local, remote := newStreamPipe(l, dir, laddr, ln.addr)
+	if !l.track(local) || !l.track(remote) {
+		local.reset()
+		return nil, errUnreachable
+	}
+	var err error
+	select {
+	case ln.conns <- remote:
+		return local, nil
+	case <-ln.closed:
+		err = errRefused
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	local.reset()
+	return nil, err
+}
+
+// nodeDialer dials the peers of a node over the simulated network.
+type nodeDialer struct{ node *Node }
+
+func (d nodeDialer) Dial(ctx context.Context, dest *enode.Node) (net.Conn, error) {
+	addr, ok := dest.TCPEndpoint()
+	if !ok {
+		return nil, errUnreachable
+	}
+	return d.node.dial(ctx, addr)
+}
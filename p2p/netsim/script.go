@@ -0,0 +1,303 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package netsim
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common/mclock"
+)
+
+const (
+	// pollInterval is the interval at which waiting steps check their condition.
+	pollInterval = 20 * time.Millisecond
+
+	// simulatedStep is the time a simulated clock is advanced by at once.
+	// Between steps, the nodes get simulatedStep of real time to react.
+	simulatedStep = time.Millisecond
+)
+
+// Step is an action of a simulation script. Steps refer to nodes by their
+// index, so scripts can be written before the nodes exist.
+type Step struct {
+	Name string
+	Run  func(ctx context.Context, net *Network) error
+}
+
+// Run executes the steps of a script in order, stopping at the first failure.
+func (net *Network) Run(ctx context.Context, steps ...Step) error {
+	for i, step := range steps {
+		start := net.clock.Now()
+		if err := step.Run(ctx, net); err != nil {
+			return fmt.Errorf("step %d (%s): %w", i, step.Name, err)
+		}
+		net.log.Debug("Simulation step done", "index", i, "step", step.Name, "elapsed", net.clock.Now().Sub(start))
+	}
+	return nil
+}
+
+// nodesAt resolves node indices.
+func (net *Network) nodesAt(indices []int) ([]*Node, error) {
+	nodes := make([]*Node, len(indices))
+	for i, index := range indices {
+		if nodes[i] = net.Node(index); nodes[i] == nil {
+			return nil, fmt.Errorf("unknown node %d", index)
+		}
+	}
+	return nodes, nil
+}
+
+// AddNodes adds count nodes with the given configuration.
+func AddNodes(count int, config NodeConfig) Step {
+	return Step{
+		Name: fmt.Sprintf("add %d nodes", count),
+		Run: func(ctx context.Context, net *Network) error {
+			for i := 0; i < count; i++ {
+				if _, err := net.AddNode(config); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// Connect dials node b from node a.
+func Connect(a, b int) Step {
+	return Step{
+		Name: fmt.Sprintf("connect %d-%d", a, b),
+		Run: func(ctx context.Context, net *Network) error {
+			nodes, err := net.nodesAt([]int{a, b})
+			if err != nil {
+				return err
+			}
+			net.Connect(nodes[0], nodes[1])
+			return nil
+		},
+	}
+}
+
+// ConnectChain connects every node to the next one.
+func ConnectChain() Step {
+	return Step{
+		Name: "connect chain",
+		Run: func(ctx context.Context, net *Network) error {
+			nodes := net.Nodes()
+			for i := 1; i < len(nodes); i++ {
+				net.Connect(nodes[i-1], nodes[i])
+			}
+			return nil
+		},
+	}
+}
+
+// ConnectRing connects every node to the next one, and the last to the first.
+func ConnectRing() Step {
+	return Step{
+		Name: "connect ring",
+		Run: func(ctx context.Context, net *Network) error {
+			nodes := net.Nodes()
+			for i := range nodes {
+				if next := nodes[(i+1)%len(nodes)]; next != nodes[i] {
+					net.Connect(nodes[i], next)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// ConnectRandom connects every node to degree other nodes picked by the random
+// source of the network.
+func ConnectRandom(degree int) Step {
+	return Step{
+		Name: fmt.Sprintf("connect random, degree %d", degree),
+		Run: func(ctx context.Context, net *Network) error {
+			nodes := net.Nodes()
+			if degree >= len(nodes) {
+				return fmt.Errorf("degree %d too high for %d nodes", degree, len(nodes))
+			}
+			for i, n := range nodes {
+				net.mu.Lock()
+				perm := net.rand.Perm(len(nodes))
+				net.mu.Unlock()
+
+				picked := 0
+				for _, j := range perm {
+					if picked == degree {
+						break
+					}
+					if j != i {
+						net.Connect(n, nodes[j])
+						picked++
+					}
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// Partition cuts the links between two groups of nodes.
+func Partition(a, b []int) Step {
+	return Step{
+		Name: fmt.Sprintf("partition %v/%v", a, b),
+		Run: func(ctx context.Context, net *Network) error {
+			na, err := net.nodesAt(a)
+			if err != nil {
+				return err
+			}
+			nb, err := net.nodesAt(b)
+			if err != nil {
+				return err
+			}
+			net.Partition(na, nb)
+			return nil
+		},
+	}
+}
+
+// Heal removes all partitions.
+func Heal() Step {
+	return Step{
+		Name: "heal",
+		Run: func(ctx context.Context, net *Network) error {
+			net.Heal()
+			return nil
+		},
+	}
+}
+
+// SetLink changes the configuration of the link between two nodes.
+func SetLink(a, b int, config LinkConfig) Step {
+	return Step{
+		Name: fmt.Sprintf("set link %d-%d", a, b),
+		Run: func(ctx context.Context, net *Network) error {
+			nodes, err := net.nodesAt([]int{a, b})
+			if err != nil {
+				return err
+			}
+			net.SetLink(nodes[0], nodes[1], config)
+			return nil
+		},
+	}
+}
+
+// Sleep lets the simulation run for the given time.
+func Sleep(d time.Duration) Step {
+	return Step{
+		Name: fmt.Sprintf("sleep %v", d),
+		Run: func(ctx context.Context, net *Network) error {
+			return net.Sleep(ctx, d)
+		},
+	}
+}
+
+// Do runs an arbitrary action.
+func Do(name string, fn func(net *Network) error) Step {
+	return Step{
+		Name: name,
+		Run: func(ctx context.Context, net *Network) error {
+			return fn(net)
+		},
+	}
+}
+
+// Until waits for a condition to hold.
+func Until(name string, cond func(net *Network) bool) Step {
+	return Step{
+		Name: name,
+		Run: func(ctx context.Context, net *Network) error {
+			return net.Wait(ctx, cond)
+		},
+	}
+}
+
+// WaitPeers waits until every node has at least min peers.
+func WaitPeers(min int) Step {
+	return Until(fmt.Sprintf("wait for %d peers", min), func(net *Network) bool {
+		for _, n := range net.Nodes() {
+			if n.Server().PeerCount() < min {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// WaitConnected waits until two nodes are peers.
+func WaitConnected(a, b int) Step {
+	return Until(fmt.Sprintf("wait for connection %d-%d", a, b), func(net *Network) bool {
+		na, nb := net.Node(a), net.Node(b)
+		return na != nil && nb != nil && net.Connected(na, nb)
+	})
+}
+
+// WaitDisconnected waits until two nodes are no longer peers.
+func WaitDisconnected(a, b int) Step {
+	return Until(fmt.Sprintf("wait for disconnection %d-%d", a, b), func(net *Network) bool {
+		na, nb := net.Node(a), net.Node(b)
+		return na != nil && nb != nil && !net.Connected(na, nb)
+	})
+}
+
+// WaitSeen waits until the given number of nodes observed an event recorded
+// with Metrics.Mark.
+func WaitSeen(event string, count int) Step {
+	return Until(fmt.Sprintf("wait for %q seen by %d nodes", event, count), func(net *Network) bool {
+		return net.metrics.Seen(event) >= count
+	})
+}
+
+// Wait polls a condition until it holds or the context is canceled, letting
+// the simulation run in between.
+func (net *Network) Wait(ctx context.Context, cond func(net *Network) bool) error {
+	for !cond(net) {
+		if err := net.Sleep(ctx, pollInterval); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sleep lets the given time pass on the clock of the network. A simulated
+// clock is advanced in small steps, giving the nodes time to react to the
+// data delivered in each.
+func (net *Network) Sleep(ctx context.Context, d time.Duration) error {
+	sim, ok := net.clock.(*mclock.Simulated)
+	if !ok {
+		timer := net.clock.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C():
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	for left := d; left > 0; left -= simulatedStep {
+		sim.Run(min(left, simulatedStep))
+		select {
+		case <-time.After(simulatedStep):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
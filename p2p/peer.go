@@ -23,6 +23,7 @@ import (
 	"net"
 	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rajchain/go-rajchain/common/mclock"
@@ -114,6 +115,10 @@ type Peer struct {
 	closed   chan struct{}
 	pingRecv chan struct{}
 	disc     chan DiscReason
+	rep      *reputation
+	bw       *peerBandwidth // Traffic accounting and caps, nil if not run by a server
+
+	discLocal atomic.Bool // Whether the disconnect was requested locally
 
 	// events receives message send / receive events if set
 	events   *event.Feed
@@ -214,6 +219,14 @@ func (p *Peer) Disconnect(reason DiscReason) {
 	}
 }
 
+// DisconnectedLocally reports whether the connection was torn down on request of
+// the local node, such as on shutdown or when evicting the peer, rather than by
+// the remote peer or a failure. It is only meaningful once the protocols of the
+// peer have been stopped.
+func (p *Peer) DisconnectedLocally() bool {
+	return p.discLocal.Load()
+}
+
 // String implements fmt.Stringer.
 func (p *Peer) String() string {
 	id := p.ID()
@@ -235,6 +248,7 @@ func newPeer(log log.Logger, conn *conn, protocols []Protocol) *Peer {
 		protoErr: make(chan error, len(protomap)+1), // protocols + pingLoop
 		closed:   make(chan struct{}),
 		pingRecv: make(chan struct{}, 16),
+		rep:      newReputation(0, time.Time{}),
 		log:      log.New("id", conn.node.ID(), "conn", conn.flags),
 	}
 	return p
@@ -283,6 +297,7 @@ loop:
 			reason = discReasonForError(err)
 			break loop
 		case err = <-p.disc:
+			p.discLocal.Store(true)
 			reason = discReasonForError(err)
 			break loop
 		}
@@ -362,6 +377,14 @@ func (p *Peer) handle(msg Msg) error {
 			metrics.GetOrRegisterMeter(m, nil).Mark(int64(msg.meterSize))
 			metrics.GetOrRegisterMeter(m+"/packets", nil).Mark(1)
 		}
+		// Stop reading from the connection while the ingress caps are reached,
+		// slowing the peer down. Waiting here rather than in the protocol keeps
+		// its handler free to process the messages already let through, but it
+		// does hold back everything behind on the connection, the base protocol
+		// included. Block propagation is never held back by the caps.
+		if p.bw != nil && !p.bw.transfer(false, proto.Name, proto.trafficClass(msg.Code-proto.offset, false), msg.Size, p.closed) {
+			return io.EOF
+		}
 		select {
 		case proto.in <- msg:
 			return nil
@@ -415,6 +438,7 @@ func (p *Peer) startProtocols(writeStart <-chan struct{}, writeErr chan<- error)
 		proto.closed = p.closed
 		proto.wstart = writeStart
 		proto.werr = writeErr
+		proto.bw = p.bw
 		var rw MsgReadWriter = proto
 		if p.events != nil {
 			rw = newMsgEventer(rw, p.events, p.ID(), proto.Name, p.Info().Network.RemoteAddress, p.Info().Network.LocalAddress)
@@ -453,6 +477,7 @@ type protoRW struct {
 	werr   chan<- error    // for write results
 	offset uint64
 	w      MsgWriter
+	bw     *peerBandwidth // traffic accounting and caps, nil if disabled
 }
 
 func (rw *protoRW) WriteMsg(msg Msg) (err error) {
@@ -462,6 +487,11 @@ func (rw *protoRW) WriteMsg(msg Msg) (err error) {
 	msg.meterCap = rw.cap()
 	msg.meterCode = msg.Code
 
+	// Wait for the egress caps before taking the write slot, so higher priority
+	// messages can overtake throttled ones.
+	if rw.bw != nil && !rw.bw.transfer(true, rw.Name, rw.trafficClass(msg.Code, true), msg.Size, rw.closed) {
+		return ErrShuttingDown
+	}
 	msg.Code += rw.offset
 
 	select {
@@ -504,7 +534,9 @@ type PeerInfo struct {
 		Trusted       bool   `json:"trusted"`
 		Static        bool   `json:"static"`
 	} `json:"network"`
-	Protocols map[string]interface{} `json:"protocols"` // Sub-protocol specific metadata fields
+	Protocols  map[string]interface{}   `json:"protocols"`           // Sub-protocol specific metadata fields
+	Reputation *ReputationInfo          `json:"reputation"`          // How well the peer serves the local node
+	Bandwidth  map[string]*TrafficStats `json:"bandwidth,omitempty"` // Traffic with the peer by protocol
 }
 
 // Info gathers and returns a collection of metadata known about a peer.
@@ -516,11 +548,12 @@ func (p *Peer) Info() *PeerInfo {
 	}
 	// Assemble the generic peer metadata
 	info := &PeerInfo{
-		Enode:     p.Node().URLv4(),
-		ID:        p.ID().String(),
-		Name:      p.Fullname(),
-		Caps:      caps,
-		Protocols: make(map[string]interface{}, len(p.running)),
+		Enode:      p.Node().URLv4(),
+		ID:         p.ID().String(),
+		Name:       p.Fullname(),
+		Caps:       caps,
+		Protocols:  make(map[string]interface{}, len(p.running)),
+		Reputation: p.rep.info(),
 	}
 	if p.Node().Seq() > 0 {
 		info.ENR = p.Node().String()
@@ -530,6 +563,9 @@ func (p *Peer) Info() *PeerInfo {
 	info.Network.Inbound = p.rw.is(inboundConn)
 	info.Network.Trusted = p.rw.is(trustedConn)
 	info.Network.Static = p.rw.is(staticDialedConn)
+	if p.bw != nil {
+		info.Bandwidth = p.bw.info()
+	}
 
 	// Gather all the running protocol infos
 	for _, proto := range p.running {
@@ -19,6 +19,8 @@ package p2p
 import (
 	"errors"
 	"fmt"
+	"io"
+	"net"
 )
 
 const (
@@ -117,3 +119,20 @@ func discReasonForError(err error) DiscReason {
 	}
 	return DiscSubprotocolError
 }
+
+// IsDisconnectError reports whether an error is caused by the connection to the
+// peer going down, on either side, rather than by the messages exchanged on it.
+func IsDisconnectError(err error) bool {
+	var (
+		reason DiscReason
+		neterr net.Error
+	)
+	switch {
+	case errors.Is(err, ErrShuttingDown), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, net.ErrClosed):
+		return true
+	case errors.As(err, &reason), errors.As(err, &neterr):
+		return true
+	default:
+		return false
+	}
+}
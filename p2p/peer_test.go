@@ -182,7 +182,7 @@ func TestPeerPing(t *testing.T) {
 // This test checks that a disconnect message sent by a peer is returned
 // as the error from Peer.run.
 func TestPeerDisconnect(t *testing.T) {
-	closer, rw, _, disc := testPeer(nil)
+	closer, rw, p, disc := testPeer(nil)
 	defer closer()
 
 	if err := SendItems(rw, discMsg, DiscQuitting); err != nil {
@@ -193,6 +193,27 @@ func TestPeerDisconnect(t *testing.T) {
 		if reason != DiscQuitting {
 			t.Errorf("run returned wrong reason: got %v, want %v", reason, DiscQuitting)
 		}
+		if p.DisconnectedLocally() {
+			t.Error("remote disconnect reported as local")
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Error("peer did not return")
+	}
+}
+
+func TestPeerDisconnectLocal(t *testing.T) {
+	closer, _, p, disc := testPeer(nil)
+	defer closer()
+
+	p.Disconnect(DiscTooManyPeers)
+	select {
+	case reason := <-disc:
+		if reason != DiscTooManyPeers {
+			t.Errorf("run returned wrong reason: got %v, want %v", reason, DiscTooManyPeers)
+		}
+		if !p.DisconnectedLocally() {
+			t.Error("local disconnect not reported")
+		}
 	case <-time.After(500 * time.Millisecond):
 		t.Error("peer did not return")
 	}
@@ -60,6 +60,17 @@ type Protocol struct {
 	// attempts to create connections to them.
 	DialCandidates enode.Iterator
 
+	// DiscoveryTopic, if non-empty, is the name of the discovery v5 topic under
+	// which the protocol is advertised. When discovery v5 is enabled, the server
+	// advertises the local node under the topic and dials the nodes found
+	// advertising it.
+	DiscoveryTopic string
+
+	// TrafficClass is an optional helper method to retrieve the priority of a
+	// message sent (egress) or received when bandwidth is capped. Messages of
+	// protocols without it are classed as TrafficServing.
+	TrafficClass func(code uint64, egress bool) TrafficClass
+
 	// Attributes contains protocol specific information for the node record.
 	Attributes []enr.Entry
 }
@@ -68,6 +79,14 @@ func (p Protocol) cap() Cap {
 	return Cap{p.Name, p.Version}
 }
 
+// trafficClass returns the priority of a message of the protocol.
+func (p Protocol) trafficClass(code uint64, egress bool) TrafficClass {
+	if p.TrafficClass == nil {
+		return TrafficServing
+	}
+	return p.TrafficClass(code, egress)
+}
+
 // Cap is the structure of a peer capability.
 type Cap struct {
 	Name    string
@@ -0,0 +1,331 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rajchain/go-rajchain/log"
+	"github.com/rajchain/go-rajchain/p2p/enode"
+	"golang.org/x/net/quic"
+)
+
+const (
+	// quicALPN is the application protocol negotiated in the QUIC handshake.
+	quicALPN = "rlpx"
+
+	// quicCloseTimeout is the time allowed for peers to acknowledge the
+	// closing of the QUIC endpoint.
+	quicCloseTimeout = time.Second
+)
+
+// quicEndpoint carries RLPx connections over QUIC. Every QUIC connection holds
+// a single bidirectional stream, on top of which the regular RLPx transport
+// runs. The TLS layer of QUIC is not used for authentication, peers prove
+// their node identity through the RLPx encryption handshake as they do over
+// TCP.
+//
+// quicEndpoint implements net.Listener for the inbound connections and dials
+// the outbound ones from the same UDP socket.
+type quicEndpoint struct {
+	endpoint *quic.Endpoint
+	config   *quic.Config
+	conns    chan net.Conn
+
+	ctx    context.Context // Canceled when the endpoint stops accepting
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// listenQUIC creates a QUIC endpoint listening on the given UDP address.
+func listenQUIC(addr string) (*quicEndpoint, error) {
+	config, err := newQUICConfig()
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := quic.Listen("udp", addr, config)
+	if err != nil {
+		return nil, err
+	}
+	e := &quicEndpoint{
+		endpoint: endpoint,
+		config:   config,
+		conns:    make(chan net.Conn),
+	}
+	e.ctx, e.cancel = context.WithCancel(context.Background())
+
+	e.wg.Add(1)
+	go e.loop()
+	return e, nil
+}
+
+// newQUICConfig creates the endpoint configuration with a fresh self-signed TLS
+// certificate. The certificate only serves to establish the QUIC session, it
+// is neither verified nor tied to the node key.
+func newQUICConfig() (*quic.Config, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(10 * 365 * 24 * time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	return &quic.Config{
+		TLSConfig: &tls.Config{
+			MinVersion:         tls.VersionTLS13,
+			Certificates:       []tls.Certificate{{Certificate: [][]byte{cert}, PrivateKey: key}},
+			NextProtos:         []string{quicALPN},
+			InsecureSkipVerify: true, // Peers are authenticated by the RLPx handshake
+		},
+		MaxBidiRemoteStreams: 1,
+		MaxUniRemoteStreams:  -1,
+		HandshakeTimeout:     handshakeTimeout,
+		MaxIdleTimeout:       frameReadTimeout,
+	}, nil
+}
+
+// loop accepts inbound QUIC connections.
+func (e *quicEndpoint) loop() {
+	defer e.wg.Done()
+
+	for {
+		conn, err := e.endpoint.Accept(e.ctx)
+		if err != nil {
+			return
+		}
+		e.wg.Add(1)
+		go e.acceptStream(conn)
+	}
+}
+
+// acceptStream waits for the remote end to open the stream of the connection,
+// handing it over to Accept.
+func (e *quicEndpoint) acceptStream(conn *quic.Conn) {
+	defer e.wg.Done()
+
+	ctx, cancel := context.WithTimeout(e.ctx, handshakeTimeout)
+	defer cancel()
+
+	stream, err := conn.AcceptStream(ctx)
+	if err != nil {
+		conn.Abort(nil)
+		return
+	}
+	fd := newQUICConn(conn, stream, e.Addr(), quicRemoteAddr(conn))
+	select {
+	case e.conns <- fd:
+	case <-e.ctx.Done():
+		fd.Close()
+	}
+}
+
+// Accept implements net.Listener, returning the next inbound connection.
+func (e *quicEndpoint) Accept() (net.Conn, error) {
+	select {
+	case fd := <-e.conns:
+		return fd, nil
+	case <-e.ctx.Done():
+		return nil, net.ErrClosed
+	}
+}
+
+// Close implements net.Listener. It stops accepting inbound connections, while
+// the established ones are kept until shutdown is called.
+func (e *quicEndpoint) Close() error {
+	e.cancel()
+	e.wg.Wait()
+	return nil
+}
+
+// Addr implements net.Listener, returning the UDP address of the endpoint.
+func (e *quicEndpoint) Addr() net.Addr {
+	return net.UDPAddrFromAddrPort(e.endpoint.LocalAddr())
+}
+
+// shutdown stops accepting and terminates all connections of the endpoint.
+func (e *quicEndpoint) shutdown() {
+	e.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), quicCloseTimeout)
+	defer cancel()
+	e.endpoint.Close(ctx)
+}
+
+// dial establishes a QUIC connection to the given address and opens its stream.
+func (e *quicEndpoint) dial(ctx context.Context, addr netip.AddrPort) (net.Conn, error) {
+	conn, err := e.endpoint.Dial(ctx, "udp", addr.String(), e.config)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := conn.NewStream(ctx)
+	if err != nil {
+		conn.Abort(nil)
+		return nil, err
+	}
+	// Open the stream on the remote end right away, instead of waiting for
+	// the first write.
+	stream.Flush()
+	return newQUICConn(conn, stream, e.Addr(), net.UDPAddrFromAddrPort(addr)), nil
+}
+
+// quicRemoteAddr returns the address of the remote end of a QUIC connection.
+// The quic package doesn't export the peer address yet, it is only available
+// through the string representation of the connection.
+func quicRemoteAddr(conn *quic.Conn) net.Addr {
+	s := strings.TrimSuffix(conn.String(), ")")
+	if i := strings.LastIndex(s, "->"); i >= 0 {
+		if addr, err := netip.ParseAddrPort(s[i+2:]); err == nil {
+			return net.UDPAddrFromAddrPort(addr)
+		}
+	}
+	return &net.UDPAddr{}
+}
+
+// quicConn is the stream of a QUIC connection, wrapped as a net.Conn so the
+// RLPx transport can run on top of it.
+type quicConn struct {
+	conn   *quic.Conn
+	stream *quic.Stream
+	laddr  net.Addr
+	raddr  net.Addr
+
+	rmu, wmu sync.Mutex
+	rcancel  context.CancelFunc // Releases the context of the read deadline
+	wcancel  context.CancelFunc // Releases the context of the write deadline
+}
+
+func newQUICConn(conn *quic.Conn, stream *quic.Stream, laddr, raddr net.Addr) *quicConn {
+	return &quicConn{
+		conn:    conn,
+		stream:  stream,
+		laddr:   laddr,
+		raddr:   raddr,
+		rcancel: func() {},
+		wcancel: func() {},
+	}
+}
+
+// deadlineContext returns a context expiring at the given deadline, or one that
+// never expires if the deadline is zero.
+func deadlineContext(t time.Time) (context.Context, context.CancelFunc) {
+	if t.IsZero() {
+		return context.Background(), func() {}
+	}
+	return context.WithDeadline(context.Background(), t)
+}
+
+func (c *quicConn) Read(b []byte) (int, error) {
+	return c.stream.Read(b)
+}
+
+// Write writes to the stream and flushes it, as RLPx writes whole frames.
+func (c *quicConn) Write(b []byte) (int, error) {
+	n, err := c.stream.Write(b)
+	if err == nil {
+		c.stream.Flush()
+	}
+	return n, err
+}
+
+// Close closes the stream, giving the peer a short time to receive pending
+// data, and then the QUIC connection.
+func (c *quicConn) Close() error {
+	c.wmu.Lock()
+	ctx, cancel := context.WithTimeout(context.Background(), discWriteTimeout)
+	c.wcancel()
+	c.wcancel = cancel
+	c.stream.SetWriteContext(ctx)
+	c.wmu.Unlock()
+
+	c.stream.Close()
+	c.conn.Abort(nil)
+
+	c.rmu.Lock()
+	c.rcancel()
+	c.rmu.Unlock()
+	return nil
+}
+
+func (c *quicConn) LocalAddr() net.Addr  { return c.laddr }
+func (c *quicConn) RemoteAddr() net.Addr { return c.raddr }
+
+func (c *quicConn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	return c.SetWriteDeadline(t)
+}
+
+func (c *quicConn) SetReadDeadline(t time.Time) error {
+	c.rmu.Lock()
+	defer c.rmu.Unlock()
+
+	ctx, cancel := deadlineContext(t)
+	c.rcancel()
+	c.rcancel = cancel
+	c.stream.SetReadContext(ctx)
+	return nil
+}
+
+func (c *quicConn) SetWriteDeadline(t time.Time) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	ctx, cancel := deadlineContext(t)
+	c.wcancel()
+	c.wcancel = cancel
+	c.stream.SetWriteContext(ctx)
+	return nil
+}
+
+// quicDialer dials nodes announcing RLPx over QUIC through the QUIC endpoint,
+// falling back to another dialer if they don't or the QUIC dial fails.
+type quicDialer struct {
+	endpoint *quicEndpoint
+	fallback NodeDialer
+	log      log.Logger
+}
+
+func (d quicDialer) Dial(ctx context.Context, dest *enode.Node) (net.Conn, error) {
+	if addr, ok := dest.RLPxQUICEndpoint(); ok {
+		dialCtx, cancel := context.WithTimeout(ctx, defaultDialTimeout)
+		fd, err := d.endpoint.dial(dialCtx, addr)
+		cancel()
+		if err == nil {
+			quicDialMeter.Mark(1)
+			return fd, nil
+		}
+		quicFallbackMeter.Mark(1)
+		d.log.Trace("QUIC dial failed, falling back to TCP", "id", dest.ID(), "addr", addr, "err", err)
+	}
+	return d.fallback.Dial(ctx, dest)
+}
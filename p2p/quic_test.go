@@ -0,0 +1,160 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rajchain/go-rajchain/internal/testlog"
+	"github.com/rajchain/go-rajchain/log"
+	"github.com/rajchain/go-rajchain/p2p/enode"
+	"github.com/rajchain/go-rajchain/p2p/enr"
+	"github.com/rajchain/go-rajchain/p2p/pipes"
+)
+
+// testTransports are the transports the connection level tests run over.
+var testTransports = []struct {
+	name string
+	pipe func() (net.Conn, net.Conn, error)
+}{
+	{"tcp", pipes.TCPPipe},
+	{"quic", quicPipe},
+}
+
+// quicPipe creates a full duplex pipe based on a localhost QUIC connection.
+func quicPipe() (net.Conn, net.Conn, error) {
+	listener, err := listenQUIC("127.0.0.1:0")
+	if err != nil {
+		return nil, nil, err
+	}
+	dialer, err := listenQUIC("127.0.0.1:0")
+	if err != nil {
+		listener.shutdown()
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	dconn, err := dialer.dial(ctx, listener.Addr().(*net.UDPAddr).AddrPort())
+	if err != nil {
+		listener.shutdown()
+		dialer.shutdown()
+		return nil, nil, err
+	}
+	aconn, err := listener.Accept()
+	if err != nil {
+		dconn.Close()
+		listener.shutdown()
+		dialer.shutdown()
+		return nil, nil, err
+	}
+	return &quicPipeConn{aconn, listener}, &quicPipeConn{dconn, dialer}, nil
+}
+
+// quicPipeConn is one end of a QUIC pipe, owning its endpoint.
+type quicPipeConn struct {
+	net.Conn
+	endpoint *quicEndpoint
+}
+
+func (c *quicPipeConn) Close() error {
+	err := c.Conn.Close()
+	c.endpoint.shutdown()
+	return err
+}
+
+func TestQUICConn(t *testing.T) {
+	fd0, fd1, err := quicPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd0.Close()
+	defer fd1.Close()
+
+	// Data flows in both directions
+	go fd1.Write([]byte("ping"))
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(fd0, buf); err != nil || string(buf) != "ping" {
+		t.Fatalf("read mismatch: %q, %v", buf, err)
+	}
+	go fd0.Write([]byte("pong"))
+	if _, err := io.ReadFull(fd1, buf); err != nil || string(buf) != "pong" {
+		t.Fatalf("read mismatch: %q, %v", buf, err)
+	}
+	// Reads time out at the deadline
+	fd0.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if _, err := fd0.Read(buf); err == nil {
+		t.Fatal("read returned without data before deadline")
+	}
+	// Addresses match on both ends
+	if fd0.LocalAddr().String() != fd1.RemoteAddr().String() {
+		t.Fatalf("address mismatch: %v != %v", fd0.LocalAddr(), fd1.RemoteAddr())
+	}
+	if fd0.RemoteAddr().String() != fd1.LocalAddr().String() {
+		t.Fatalf("address mismatch: %v != %v", fd0.RemoteAddr(), fd1.LocalAddr())
+	}
+}
+
+// This test checks that the server falls back to TCP when the QUIC endpoint
+// announced by a node doesn't respond.
+func TestServerQUICFallback(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// Announce a QUIC port with nothing behind it
+	udp, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IP{127, 0, 0, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udp.Close()
+
+	key := newkey()
+	var r enr.Record
+	r.Set(enr.IPv4{127, 0, 0, 1})
+	r.Set(enr.TCP(listener.Addr().(*net.TCPAddr).Port))
+	r.Set(enr.RLPxQUIC(udp.LocalAddr().(*net.UDPAddr).Port))
+	enode.SignV4(&r, key)
+	node, _ := enode.New(enode.ValidSchemes, &r)
+
+	srv := &Server{Config: Config{
+		PrivateKey:  newkey(),
+		MaxPeers:    1,
+		NoDiscovery: true,
+		QUICAddr:    "127.0.0.1:0",
+		Logger:      testlog.Logger(t, log.LvlTrace),
+	}}
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+
+	fd, err := srv.dialsched.dialer.Dial(context.Background(), node)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer fd.Close()
+	if _, ok := fd.RemoteAddr().(*net.TCPAddr); !ok {
+		t.Fatalf("dial did not fall back to TCP: %v", fd.RemoteAddr())
+	}
+}
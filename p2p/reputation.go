@@ -0,0 +1,231 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/rajchain/go-rajchain/p2p/enode"
+)
+
+const (
+	// Bounds of the reputation score of a peer.
+	maxScore = 100
+	minScore = -100
+
+	responseReward = 1                // Score gained for an instant response
+	responseTarget = time.Second      // Latency at which a response stops gaining score
+	timeoutPenalty = 5                // Score lost for a request which timed out
+	invalidPenalty = 25               // Score lost for delivering invalid data
+	usefulReward   = 0.5              // Score gained for announcing new transactions
+	scoreHalfLife  = time.Hour        // Time after which a score decays to half
+	latencyDecay   = 0.1              // Weight of a new sample in the latency average
+	evictInterval  = 30 * time.Second // Time between checks for peers to evict
+
+	// evictThreshold is the score below which a peer is evicted when the
+	// server is full, making room for a fresh dial.
+	evictThreshold = -10
+
+	// rejectThreshold is the persisted score below which a peer is refused
+	// from connecting again, until its score decays.
+	rejectThreshold = -50
+)
+
+// ReputationInfo is the reputation of a peer, as reported by admin_peers.
+type ReputationInfo struct {
+	Score     float64 `json:"score"`     // Score of the peer, decayed to the current time
+	Responses uint64  `json:"responses"` // Number of requests answered
+	Timeouts  uint64  `json:"timeouts"`  // Number of requests which timed out
+	Invalid   uint64  `json:"invalid"`   // Number of invalid messages delivered
+	Useful    uint64  `json:"useful"`    // Number of useful transaction announcements
+	Latency   string  `json:"latency"`   // Moving average of the response latency
+}
+
+// reputation tracks how well a peer serves the local node. The score rises
+// with fast responses and useful announcements and drops with timeouts and
+// invalid data, decaying towards zero over time.
+type reputation struct {
+	score   float64   // Score as of the last update
+	updated time.Time // Time of the last update, against which the decay is applied
+	latency time.Duration
+
+	responses uint64
+	timeouts  uint64
+	invalid   uint64
+	useful    uint64
+
+	lock sync.Mutex
+}
+
+// newReputation creates the reputation of a peer, starting from a previously
+// persisted score.
+func newReputation(score float64, updated time.Time) *reputation {
+	return &reputation{score: score, updated: updated}
+}
+
+// decayScore applies the exponential decay to a score last updated at the given
+// time.
+func decayScore(score float64, updated time.Time, now time.Time) float64 {
+	if elapsed := now.Sub(updated); elapsed > 0 {
+		score *= math.Pow(0.5, float64(elapsed)/float64(scoreHalfLife))
+	}
+	return score
+}
+
+// add decays the score to the current time and adjusts it by the given delta.
+// The caller must hold the lock.
+func (r *reputation) add(delta float64) {
+	now := time.Now()
+	r.score = decayScore(r.score, r.updated, now) + delta
+	r.score = max(minScore, min(maxScore, r.score))
+	r.updated = now
+}
+
+// current returns the score decayed to the current time and the time it was
+// decayed to.
+func (r *reputation) current() (float64, time.Time) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	return decayScore(r.score, r.updated, now), now
+}
+
+// info returns the reputation summary of the peer.
+func (r *reputation) info() *ReputationInfo {
+	score, _ := r.current()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return &ReputationInfo{
+		Score:     score,
+		Responses: r.responses,
+		Timeouts:  r.timeouts,
+		Invalid:   r.invalid,
+		Useful:    r.useful,
+		Latency:   r.latency.String(),
+	}
+}
+
+// ScoreResponse rates the peer for answering a request with the given latency.
+// Responses faster than the target latency raise the score, slower ones lower
+// it.
+func (p *Peer) ScoreResponse(latency time.Duration) {
+	p.rep.lock.Lock()
+	defer p.rep.lock.Unlock()
+
+	p.rep.responses++
+	if p.rep.latency == 0 {
+		p.rep.latency = latency
+	} else {
+		p.rep.latency += time.Duration(latencyDecay * float64(latency-p.rep.latency))
+	}
+	reward := responseReward * (1 - float64(latency)/float64(responseTarget))
+	p.rep.add(max(-responseReward, reward))
+}
+
+// ScoreTimeout penalizes the peer for not answering a request in time.
+func (p *Peer) ScoreTimeout() {
+	p.rep.lock.Lock()
+	defer p.rep.lock.Unlock()
+
+	p.rep.timeouts++
+	p.rep.add(-timeoutPenalty)
+	scoreTimeoutMeter.Mark(1)
+}
+
+// ScoreInvalid penalizes the peer for delivering invalid data.
+func (p *Peer) ScoreInvalid() {
+	p.rep.lock.Lock()
+	defer p.rep.lock.Unlock()
+
+	p.rep.invalid++
+	p.rep.add(-invalidPenalty)
+	scoreInvalidMeter.Mark(1)
+}
+
+// ScoreUseful rates the peer for announcing transactions which were new to the
+// local node.
+func (p *Peer) ScoreUseful() {
+	p.rep.lock.Lock()
+	defer p.rep.lock.Unlock()
+
+	p.rep.useful++
+	p.rep.add(usefulReward)
+	scoreUsefulMeter.Mark(1)
+}
+
+// Score returns the current reputation score of the peer.
+func (p *Peer) Score() float64 {
+	score, _ := p.rep.current()
+	return score
+}
+
+// loadReputation retrieves the persisted reputation of a node, if any.
+func (srv *Server) loadReputation(c *conn) *reputation {
+	score, updated := srv.nodedb.Score(c.node.ID())
+	return newReputation(score, updated)
+}
+
+// storeReputation persists the reputation of a disconnected peer.
+func (srv *Server) storeReputation(p *Peer) {
+	score, updated := p.rep.current()
+	if err := srv.nodedb.UpdateScore(p.ID(), score, updated); err != nil {
+		srv.log.Warn("Failed to store peer reputation", "id", p.ID(), "err", err)
+	}
+}
+
+// rejectedByReputation reports whether the connection is refused because of
+// the persisted reputation of the remote node. Trusted and static nodes are
+// always allowed.
+func (srv *Server) rejectedByReputation(c *conn) bool {
+	if c.is(trustedConn) || c.is(staticDialedConn) {
+		return false
+	}
+	score, updated := srv.nodedb.Score(c.node.ID())
+	return decayScore(score, updated, time.Now()) < rejectThreshold
+}
+
+// evictPeer disconnects the lowest scoring peer if the server is full and the
+// score is below the eviction threshold, making room for a fresh connection.
+// Trusted and static peers are never evicted.
+func (srv *Server) evictPeer(peers map[enode.ID]*Peer) {
+	var (
+		worst      *Peer
+		worstScore float64
+	)
+	for _, p := range peers {
+		score := p.Score()
+		scoreHistogram.Update(int64(score))
+
+		if p.rw.is(trustedConn) || p.rw.is(staticDialedConn) {
+			continue
+		}
+		if worst == nil || score < worstScore {
+			worst, worstScore = p, score
+		}
+	}
+	if worst == nil || len(peers) < srv.MaxPeers || worstScore >= evictThreshold {
+		return
+	}
+	srv.log.Debug("Evicting low scoring peer", "id", worst.ID(), "score", worstScore)
+	evictMeter.Mark(1)
+	worst.Disconnect(DiscUselessPeer)
+}
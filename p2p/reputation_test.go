@@ -0,0 +1,138 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"maps"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rajchain/go-rajchain/internal/testlog"
+	"github.com/rajchain/go-rajchain/log"
+	"github.com/rajchain/go-rajchain/p2p/enode"
+	"github.com/rajchain/go-rajchain/p2p/enr"
+)
+
+func TestPeerScoring(t *testing.T) {
+	p := NewPeer(randomID(), "test", nil)
+
+	p.ScoreResponse(0)
+	p.ScoreResponse(responseTarget / 2)
+	if score := p.Score(); math.Abs(score-1.5) > 0.01 {
+		t.Fatalf("score mismatch after responses: have %v, want 1.5", score)
+	}
+	// Very slow responses lose at most the reward of a fast one
+	p.ScoreResponse(10 * responseTarget)
+	if score := p.Score(); math.Abs(score-0.5) > 0.01 {
+		t.Fatalf("score mismatch after slow response: have %v, want 0.5", score)
+	}
+	p.ScoreTimeout()
+	p.ScoreUseful()
+	if score := p.Score(); math.Abs(score+4) > 0.01 {
+		t.Fatalf("score mismatch after timeout: have %v, want -4", score)
+	}
+	for i := 0; i < 10; i++ {
+		p.ScoreInvalid()
+	}
+	if score := p.Score(); math.Abs(score-minScore) > 0.01 {
+		t.Fatalf("score not capped: have %v, want %v", score, minScore)
+	}
+	info := p.Info().Reputation
+	if info.Responses != 3 || info.Timeouts != 1 || info.Useful != 1 || info.Invalid != 10 {
+		t.Fatalf("counter mismatch: %+v", info)
+	}
+	// Scores decay to half over the half life
+	now := time.Now()
+	if score := decayScore(-80, now.Add(-2*scoreHalfLife), now); math.Abs(score+20) > 0.01 {
+		t.Fatalf("score decay mismatch: have %v, want -20", score)
+	}
+}
+
+func TestServerReputation(t *testing.T) {
+	srv := &Server{
+		Config: Config{
+			PrivateKey:  newkey(),
+			MaxPeers:    2,
+			NoDial:      true,
+			NoDiscovery: true,
+			Logger:      testlog.Logger(t, log.LvlTrace),
+		},
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start: %v", err)
+	}
+	defer srv.Stop()
+
+	remote := newkey()
+	newconn := func(id enode.ID) *conn {
+		fd, _ := net.Pipe()
+		tx := newTestTransport(&remote.PublicKey, fd, nil)
+		node := enode.SignNull(new(enr.Record), id)
+		return &conn{fd: fd, transport: tx, flags: inboundConn, node: node, cont: make(chan error)}
+	}
+	// Fill up the peer slots and rate one of the peers down
+	bad, good := randomID(), randomID()
+	for _, id := range []enode.ID{bad, good} {
+		if err := srv.checkpoint(newconn(id), srv.checkpointAddPeer); err != nil {
+			t.Fatalf("could not add conn: %v", err)
+		}
+	}
+	events := make(chan *PeerEvent, 4)
+	sub := srv.SubscribeEvents(events)
+	defer sub.Unsubscribe()
+
+	var peers map[enode.ID]*Peer
+	srv.doPeerOp(func(ps map[enode.ID]*Peer) { peers = maps.Clone(ps) })
+	peers[bad].ScoreInvalid()
+	peers[good].ScoreResponse(0)
+
+	// The low scoring peer should be evicted and its score persisted
+	srv.doPeerOp(srv.evictPeer)
+	timeout := time.After(5 * time.Second)
+	for dropped := false; !dropped; {
+		select {
+		case ev := <-events:
+			if ev.Type != PeerEventTypeDrop {
+				continue
+			}
+			if ev.Peer != bad {
+				t.Fatalf("wrong peer evicted: %v", ev.Peer)
+			}
+			dropped = true
+		case <-timeout:
+			t.Fatalf("low scoring peer not evicted")
+		}
+	}
+	if srv.PeerCount() != 1 {
+		t.Fatalf("peer count mismatch: have %d, want 1", srv.PeerCount())
+	}
+	if score, _ := srv.nodedb.Score(bad); math.Abs(score+invalidPenalty) > 0.01 {
+		t.Fatalf("persisted score mismatch: have %v, want %v", score, -invalidPenalty)
+	}
+	// Nodes with a very low persisted score should be refused
+	srv.nodedb.UpdateScore(bad, 2*rejectThreshold, time.Now())
+	if err := srv.checkpoint(newconn(bad), srv.checkpointPostHandshake); err != DiscUselessPeer {
+		t.Fatalf("wrong error for low scoring conn: %v", err)
+	}
+	c := newconn(bad)
+	c.flags |= staticDialedConn
+	if err := srv.checkpoint(c, srv.checkpointPostHandshake); err != nil {
+		t.Fatalf("static conn refused: %v", err)
+	}
+}
@@ -131,6 +131,19 @@ type Config struct {
 	// live nodes in the network.
 	NodeDatabase string `toml:",omitempty"`
 
+	// Permissioned restricts connectivity to the nodes in the allowlist, in
+	// both directions. Trusted and static nodes must be allowlisted as well.
+	Permissioned bool `toml:",omitempty"`
+
+	// AllowlistFile is the path of a file listing the nodes allowed to connect
+	// to a permissioned server. The file is reloaded when modified.
+	AllowlistFile string `toml:",omitempty"`
+
+	// Bandwidth caps the traffic with peers. Messages are held back when a cap
+	// is reached, letting block propagation go before the local sync, the sync
+	// before serving data and serving data before transaction gossip.
+	Bandwidth BandwidthConfig `toml:",omitempty"`
+
 	// Protocols should contain the protocols supported
 	// by the server. Matching protocols are launched for
 	// each peer.
@@ -148,6 +161,14 @@ type Config struct {
 	// for TCP and DiscAddr for the UDP discovery protocol.
 	DiscAddr string
 
+	// If QUICAddr is set to a non-nil value, the server also accepts RLPx
+	// connections over QUIC on this UDP address, announcing it in the node
+	// record, and dials nodes announcing QUIC support over QUIC, falling back
+	// to TCP. The address must not collide with the discovery address.
+	//
+	// The QUIC transport is experimental and disabled by default.
+	QUICAddr string `toml:",omitempty"`
+
 	// If set to a non-nil value, the given NAT port mapper
 	// is used to make the listening port available to the
 	// Internet.
@@ -157,6 +178,12 @@ type Config struct {
 	// is used to dial outbound peer connections.
 	Dialer NodeDialer `toml:"-"`
 
+	// If Sockets is set to a non-nil value, the TCP listener and the
+	// discovery UDP socket are opened through it instead of the operating
+	// system. Together with Dialer, this allows running the server on a
+	// simulated network.
+	Sockets SocketProvider `toml:"-"`
+
 	// If NoDial is true, the server will not dial any peers.
 	NoDial bool `toml:",omitempty"`
 
@@ -170,6 +197,14 @@ type Config struct {
 	clock mclock.Clock
 }
 
+// SocketProvider opens the listening sockets of a server.
+type SocketProvider interface {
+	// Listen opens a stream listener, like net.Listen.
+	Listen(network, addr string) (net.Listener, error)
+	// ListenUDP opens the packet socket used by discovery.
+	ListenUDP(addr string) (discover.UDPConn, error)
+}
+
 // Server manages all peer connections.
 type Server struct {
 	// Config fields may not be modified while the server is running.
@@ -185,12 +220,17 @@ type Server struct {
 	running bool
 
 	listener     net.Listener
+	quic         *quicEndpoint
 	ourHandshake *protoHandshake
 	loopWG       sync.WaitGroup // loop, listenLoop
 	peerFeed     event.Feed
 	log          log.Logger
 
+	allowlistOnce sync.Once // creates the allowlist on first access
+
 	nodedb    *enode.DB
+	allowlist *Allowlist
+	bandwidth *bandwidth
 	localnode *enode.LocalNode
 	discv4    *discover.UDPv4
 	discv5    *discover.UDPv5
@@ -425,15 +465,24 @@ func (srv *Server) Stop() {
 		// this unblocks listener Accept
 		srv.listener.Close()
 	}
+	if srv.quic != nil {
+		srv.quic.Close()
+	}
 	close(srv.quit)
 	srv.lock.Unlock()
 	srv.loopWG.Wait()
+
+	// The QUIC endpoint carries the peer connections, so it can only be
+	// shut down once they are closed.
+	if srv.quic != nil {
+		srv.quic.shutdown()
+	}
 }
 
 // sharedUDPConn implements a shared connection. Write sends messages to the underlying connection while read returns
 // messages that were found unprocessable and sent to the unhandled channel by the primary listener.
 type sharedUDPConn struct {
-	*net.UDPConn
+	discover.UDPConn
 	unhandled chan discover.ReadPacket
 }
 
@@ -472,7 +521,7 @@ func (srv *Server) Start() (err error) {
 	if srv.clock == nil {
 		srv.clock = mclock.System{}
 	}
-	if srv.NoDial && srv.ListenAddr == "" {
+	if srv.NoDial && srv.ListenAddr == "" && srv.QUICAddr == "" {
 		srv.log.Warn("P2P server will be useless, neither dialing nor listening")
 	}
 
@@ -485,6 +534,9 @@ func (srv *Server) Start() (err error) {
 	}
 	if srv.listenFunc == nil {
 		srv.listenFunc = net.Listen
+		if srv.Sockets != nil {
+			srv.listenFunc = srv.Sockets.Listen
+		}
 	}
 	srv.quit = make(chan struct{})
 	srv.delpeer = make(chan peerDrop)
@@ -495,6 +547,10 @@ func (srv *Server) Start() (err error) {
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
 
+	srv.bandwidth = newBandwidth(srv.Bandwidth, srv.Protocols, srv.clock)
+	if err := srv.setupAllowlist(); err != nil {
+		return err
+	}
 	if err := srv.setupLocalNode(); err != nil {
 		return err
 	}
@@ -505,6 +561,11 @@ func (srv *Server) Start() (err error) {
 			return err
 		}
 	}
+	if srv.QUICAddr != "" {
+		if err := srv.setupQUICListening(); err != nil {
+			return err
+		}
+	}
 	if err := srv.setupDiscovery(); err != nil {
 		return err
 	}
@@ -512,6 +573,10 @@ func (srv *Server) Start() (err error) {
 
 	srv.loopWG.Add(1)
 	go srv.run()
+	if srv.allowlist != nil && srv.AllowlistFile != "" {
+		srv.loopWG.Add(1)
+		go srv.allowlistLoop()
+	}
 	return nil
 }
 
@@ -601,6 +666,18 @@ func (srv *Server) setupDiscovery() error {
 			added[proto.Name] = true
 		}
 	}
+	// Advertise and search the discovery topics of the protocols.
+	if srv.discv5 != nil {
+		topics := make(map[string]bool)
+		for _, proto := range srv.Protocols {
+			if proto.DiscoveryTopic != "" && !topics[proto.DiscoveryTopic] {
+				topic := discover.NewTopic(proto.DiscoveryTopic)
+				srv.discv5.RegisterTopic(topic)
+				srv.discmix.AddSource(srv.discv5.TopicNodes(topic))
+				topics[proto.DiscoveryTopic] = true
+			}
+		}
+	}
 	return nil
 }
 
@@ -611,6 +688,7 @@ func (srv *Server) setupDialScheduler() {
 		maxActiveDials: srv.MaxPendingPeers,
 		log:            srv.Logger,
 		netRestrict:    srv.NetRestrict,
+		allowlist:      srv.allowlist,
 		dialer:         srv.Dialer,
 		clock:          srv.clock,
 	}
@@ -620,6 +698,9 @@ func (srv *Server) setupDialScheduler() {
 	if config.dialer == nil {
 		config.dialer = tcpDialer{&net.Dialer{Timeout: defaultDialTimeout}}
 	}
+	if srv.quic != nil {
+		config.dialer = quicDialer{endpoint: srv.quic, fallback: config.dialer, log: srv.log}
+	}
 	srv.dialsched = newDialScheduler(config, srv.discmix, srv.SetupConn)
 	for _, n := range srv.StaticNodes {
 		srv.dialsched.addStatic(n)
@@ -668,11 +749,37 @@ func (srv *Server) setupListening() error {
 	}
 
 	srv.loopWG.Add(1)
-	go srv.listenLoop()
+	go srv.listenLoop(listener)
 	return nil
 }
 
-func (srv *Server) setupUDPListening() (*net.UDPConn, error) {
+// setupQUICListening launches the QUIC endpoint. The UDP port is mapped through
+// NAT separately from the discovery one.
+func (srv *Server) setupQUICListening() error {
+	srv.log.Warn("Enabling experimental QUIC transport", "addr", srv.QUICAddr)
+
+	endpoint, err := listenQUIC(srv.QUICAddr)
+	if err != nil {
+		return err
+	}
+	srv.quic = endpoint
+	srv.QUICAddr = endpoint.Addr().String()
+
+	laddr := endpoint.Addr().(*net.UDPAddr)
+	srv.localnode.Set(enr.RLPxQUIC(laddr.Port))
+	if srv.NAT != nil && !laddr.IP.IsLoopback() && !laddr.IP.IsPrivate() {
+		srv.loopWG.Add(1)
+		go func() {
+			defer srv.loopWG.Done()
+			nat.Map(srv.NAT, srv.quit, "UDP", laddr.Port, laddr.Port, "rajchain quic")
+		}()
+	}
+	srv.loopWG.Add(1)
+	go srv.listenLoop(endpoint)
+	return nil
+}
+
+func (srv *Server) setupUDPListening() (discover.UDPConn, error) {
 	listenAddr := srv.ListenAddr
 
 	// Use an alternate listening address for UDP if
@@ -680,11 +787,11 @@ func (srv *Server) setupUDPListening() (*net.UDPConn, error) {
 	if srv.DiscAddr != "" {
 		listenAddr = srv.DiscAddr
 	}
-	addr, err := net.ResolveUDPAddr("udp", listenAddr)
-	if err != nil {
-		return nil, err
+	listen := listenUDP
+	if srv.Sockets != nil {
+		listen = srv.Sockets.ListenUDP
 	}
-	conn, err := net.ListenUDP("udp", addr)
+	conn, err := listen(listenAddr)
 	if err != nil {
 		return nil, err
 	}
@@ -702,6 +809,19 @@ func (srv *Server) setupUDPListening() (*net.UDPConn, error) {
 	return conn, nil
 }
 
+// listenUDP opens a UDP socket on the given address.
+func listenUDP(listenAddr string) (discover.UDPConn, error) {
+	addr, err := net.ResolveUDPAddr("udp", listenAddr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
 // doPeerOp runs fn on the main loop.
 func (srv *Server) doPeerOp(fn peerOpFunc) {
 	select {
@@ -723,7 +843,15 @@ func (srv *Server) run() {
 		peers        = make(map[enode.ID]*Peer)
 		inboundCount = 0
 		trusted      = make(map[enode.ID]bool, len(srv.TrustedNodes))
+		evict        = time.NewTicker(evictInterval)
+		delisted     <-chan struct{}
 	)
+	defer evict.Stop()
+
+	if srv.allowlist != nil {
+		delisted = srv.allowlist.changed
+	}
+
 	// Put trusted nodes into a map to speed up checks.
 	// Trusted peers are loaded on startup or added via AddTrustedPeer RPC.
 	for _, n := range srv.TrustedNodes {
@@ -755,6 +883,15 @@ running:
 				p.rw.set(trustedConn, false)
 			}
 
+		case <-delisted:
+			// Nodes were removed from the allowlist, drop them if connected.
+			srv.dropDisallowed(peers)
+
+		case <-evict.C:
+			// Make room for fresh connections if the peer slots are taken
+			// up by peers serving us poorly.
+			srv.evictPeer(peers)
+
 		case op := <-srv.peerOp:
 			// This channel is used by Peers and PeerCount.
 			op(peers)
@@ -796,6 +933,7 @@ running:
 			// A peer disconnected.
 			d := common.PrettyDuration(mclock.Now() - pd.created)
 			delete(peers, pd.ID())
+			srv.storeReputation(pd.Peer)
 			srv.log.Debug("Removing p2p peer", "peercount", len(peers), "id", pd.ID(), "duration", d, "req", pd.requested, "err", pd.err)
 			srv.dialsched.peerRemoved(pd.rw)
 			if pd.Inbound() {
@@ -828,11 +966,14 @@ running:
 		p := <-srv.delpeer
 		p.log.Trace("<-delpeer (spindown)")
 		delete(peers, p.ID())
+		srv.storeReputation(p.Peer)
 	}
 }
 
 func (srv *Server) postHandshakeChecks(peers map[enode.ID]*Peer, inboundCount int, c *conn) error {
 	switch {
+	case !srv.allowedConn(c):
+		return DiscUselessPeer
 	case !c.is(trustedConn) && len(peers) >= srv.MaxPeers:
 		return DiscTooManyPeers
 	case !c.is(trustedConn) && c.is(inboundConn) && inboundCount >= srv.maxInboundConns():
@@ -841,6 +982,9 @@ func (srv *Server) postHandshakeChecks(peers map[enode.ID]*Peer, inboundCount in
 		return DiscAlreadyConnected
 	case c.node.ID() == srv.localnode.ID():
 		return DiscSelf
+	case srv.rejectedByReputation(c):
+		rejectMeter.Mark(1)
+		return DiscUselessPeer
 	default:
 		return nil
 	}
@@ -858,8 +1002,8 @@ func (srv *Server) addPeerChecks(peers map[enode.ID]*Peer, inboundCount int, c *
 
 // listenLoop runs in its own goroutine and accepts
 // inbound connections.
-func (srv *Server) listenLoop() {
-	srv.log.Debug("TCP listener up", "addr", srv.listener.Addr())
+func (srv *Server) listenLoop(listener net.Listener) {
+	srv.log.Debug("Listener up", "addr", listener.Addr(), "transport", listener.Addr().Network())
 
 	// The slots channel limits accepts of new connections.
 	tokens := defaultMaxPendingPeers
@@ -890,7 +1034,7 @@ func (srv *Server) listenLoop() {
 			lastLog time.Time
 		)
 		for {
-			fd, err = srv.listener.Accept()
+			fd, err = listener.Accept()
 			if netutil.IsTemporaryError(err) {
 				if time.Since(lastLog) > 1*time.Second {
 					srv.log.Debug("Temporary read error", "err", err)
@@ -1045,6 +1189,8 @@ func (srv *Server) checkpoint(c *conn, stage chan<- *conn) error {
 
 func (srv *Server) launchPeer(c *conn) *Peer {
 	p := newPeer(srv.log, c, srv.Protocols)
+	p.rep = srv.loadReputation(c)
+	p.bw = srv.bandwidth.newPeer()
 	if srv.EnableMsgEvents {
 		// If message events are enabled, pass the peerFeed
 		// to the peer.
@@ -1095,8 +1241,9 @@ type NodeInfo struct {
 	ENR   string `json:"enr"`   // rajchain Node Record
 	IP    string `json:"ip"`    // IP address of the node
 	Ports struct {
-		Discovery int `json:"discovery"` // UDP listening port for discovery protocol
-		Listener  int `json:"listener"`  // TCP listening port for RLPx
+		Discovery int `json:"discovery"`      // UDP listening port for discovery protocol
+		Listener  int `json:"listener"`       // TCP listening port for RLPx
+		QUIC      int `json:"quic,omitempty"` // UDP listening port for RLPx over QUIC
 	} `json:"ports"`
 	ListenAddr string                 `json:"listenAddr"`
 	Protocols  map[string]interface{} `json:"protocols"`
@@ -1116,6 +1263,10 @@ func (srv *Server) NodeInfo() *NodeInfo {
 	}
 	info.Ports.Discovery = node.UDP()
 	info.Ports.Listener = node.TCP()
+	var quic enr.RLPxQUIC
+	if node.Load(&quic) == nil {
+		info.Ports.QUIC = int(quic)
+	}
 	info.ENR = node.String()
 
 	// Gather all the running protocol infos (only once per protocol type)
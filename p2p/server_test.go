@@ -17,12 +17,14 @@
 package p2p
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/sha256"
 	"errors"
 	"io"
 	"math/rand"
 	"net"
+	"net/netip"
 	"reflect"
 	"strconv"
 	"strings"
@@ -73,6 +75,7 @@ func startTestServer(t *testing.T, remoteKey *ecdsa.PublicKey, pf func(*Peer)) *
 		Name:        "test",
 		MaxPeers:    10,
 		ListenAddr:  "127.0.0.1:0",
+		QUICAddr:    "127.0.0.1:0",
 		NoDiscovery: true,
 		PrivateKey:  newkey(),
 		Logger:      testlog.Logger(t, log.LvlTrace),
@@ -91,6 +94,27 @@ func startTestServer(t *testing.T, remoteKey *ecdsa.PublicKey, pf func(*Peer)) *
 }
 
 func TestServerListen(t *testing.T) {
+	t.Run("tcp", func(t *testing.T) {
+		testServerListen(t, func(srv *Server) (net.Conn, error) {
+			return net.DialTimeout("tcp", srv.ListenAddr, 5*time.Second)
+		})
+	})
+	t.Run("quic", func(t *testing.T) {
+		endpoint, err := listenQUIC("127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer endpoint.shutdown()
+
+		testServerListen(t, func(srv *Server) (net.Conn, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			return endpoint.dial(ctx, netip.MustParseAddrPort(srv.QUICAddr))
+		})
+	})
+}
+
+func testServerListen(t *testing.T, dial func(*Server) (net.Conn, error)) {
 	// start the test server
 	connected := make(chan *Peer)
 	remid := &newkey().PublicKey
@@ -104,7 +128,7 @@ func TestServerListen(t *testing.T) {
 	defer srv.Stop()
 
 	// dial the test server
-	conn, err := net.DialTimeout("tcp", srv.ListenAddr, 5*time.Second)
+	conn, err := dial(srv)
 	if err != nil {
 		t.Fatalf("could not dial: %v", err)
 	}
@@ -126,12 +150,33 @@ func TestServerListen(t *testing.T) {
 }
 
 func TestServerDial(t *testing.T) {
-	// run a one-shot TCP server to handle the connection.
-	listener, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		t.Fatalf("could not setup listener: %v", err)
-	}
-	defer listener.Close()
+	t.Run("tcp", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("could not setup listener: %v", err)
+		}
+		defer listener.Close()
+
+		testServerDial(t, listener, func(r *enr.Record) {
+			r.Set(enr.TCP(listener.Addr().(*net.TCPAddr).Port))
+		})
+	})
+	t.Run("quic", func(t *testing.T) {
+		listener, err := listenQUIC("127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("could not setup listener: %v", err)
+		}
+		defer listener.shutdown()
+
+		testServerDial(t, listener, func(r *enr.Record) {
+			r.Set(enr.TCP(1)) // unused, dials go over QUIC
+			r.Set(enr.RLPxQUIC(listener.Addr().(*net.UDPAddr).Port))
+		})
+	})
+}
+
+func testServerDial(t *testing.T, listener net.Listener, setPorts func(*enr.Record)) {
+	// run a one-shot server to handle the connection.
 	accepted := make(chan net.Conn, 1)
 	go func() {
 		conn, err := listener.Accept()
@@ -143,14 +188,18 @@ func TestServerDial(t *testing.T) {
 
 	// start the server
 	connected := make(chan *Peer)
-	remid := &newkey().PublicKey
+	remkey := newkey()
+	remid := &remkey.PublicKey
 	srv := startTestServer(t, remid, func(p *Peer) { connected <- p })
 	defer close(connected)
 	defer srv.Stop()
 
 	// tell the server to connect
-	tcpAddr := listener.Addr().(*net.TCPAddr)
-	node := enode.NewV4(remid, tcpAddr.IP, tcpAddr.Port, 0)
+	var r enr.Record
+	r.Set(enr.IPv4{127, 0, 0, 1})
+	setPorts(&r)
+	enode.SignV4(&r, remkey)
+	node, _ := enode.New(enode.ValidSchemes, &r)
 	srv.AddPeer(node)
 
 	select {
@@ -207,10 +256,16 @@ func TestServerDial(t *testing.T) {
 
 // This test checks that RemovePeer disconnects the peer if it is connected.
 func TestServerRemovePeerDisconnect(t *testing.T) {
+	t.Run("tcp", func(t *testing.T) { testServerRemovePeerDisconnect(t, "") })
+	t.Run("quic", func(t *testing.T) { testServerRemovePeerDisconnect(t, "127.0.0.1:0") })
+}
+
+func testServerRemovePeerDisconnect(t *testing.T, quicAddr string) {
 	srv1 := &Server{Config: Config{
 		PrivateKey:  newkey(),
 		MaxPeers:    1,
 		NoDiscovery: true,
+		QUICAddr:    quicAddr,
 		Logger:      testlog.Logger(t, log.LvlTrace).New("server", "1"),
 	}}
 	srv2 := &Server{Config: Config{
@@ -219,6 +274,7 @@ func TestServerRemovePeerDisconnect(t *testing.T) {
 		NoDiscovery: true,
 		NoDial:      true,
 		ListenAddr:  "127.0.0.1:0",
+		QUICAddr:    quicAddr,
 		Logger:      testlog.Logger(t, log.LvlTrace).New("server", "2"),
 	}}
 	srv1.Start()
@@ -237,6 +293,10 @@ func TestServerRemovePeerDisconnect(t *testing.T) {
 	if !syncAddPeer(srv1, srv2.Self()) {
 		t.Fatal("peer not connected")
 	}
+	_, isQUIC := srv1.Peers()[0].RemoteAddr().(*net.UDPAddr)
+	if want := quicAddr != ""; isQUIC != want {
+		t.Fatalf("peer connected over wrong transport: %v", srv1.Peers()[0].RemoteAddr())
+	}
 	srv1.RemovePeer(srv2.Self())
 	if srv1.PeerCount() > 0 {
 		t.Fatal("removed peer still connected")
@@ -45,10 +45,18 @@ const (
 	maxTrackedPackets = 100000
 )
 
+// Scorer is the reputation of a remote peer, rated on how it services the
+// requests sent to it.
+type Scorer interface {
+	ScoreResponse(latency time.Duration)
+	ScoreTimeout()
+}
+
 // request tracks sent network requests which have not yet received a response.
 type request struct {
 	peer    string
-	version uint // Protocol version
+	scorer  Scorer // Reputation of the peer to rate on the response, if any
+	version uint   // Protocol version
 
 	reqCode uint64 // Protocol message code of the request
 	resCode uint64 // Protocol message code of the expected response
@@ -82,9 +90,10 @@ func New(protocol string, timeout time.Duration) *Tracker {
 }
 
 // Track adds a network request to the tracker to wait for a response to arrive
-// or until the request it cancelled or times out.
-func (t *Tracker) Track(peer string, version uint, reqCode uint64, resCode uint64, id uint64) {
-	if !metrics.Enabled {
+// or until the request it cancelled or times out. The scorer of the peer, if
+// non-nil, is rated on the response latency or the timeout.
+func (t *Tracker) Track(peer string, scorer Scorer, version uint, reqCode uint64, resCode uint64, id uint64) {
+	if !metrics.Enabled && scorer == nil {
 		return
 	}
 	t.lock.Lock()
@@ -105,15 +114,17 @@ func (t *Tracker) Track(peer string, version uint, reqCode uint64, resCode uint6
 	// Id doesn't exist yet, start tracking it
 	t.pending[id] = &request{
 		peer:    peer,
+		scorer:  scorer,
 		version: version,
 		reqCode: reqCode,
 		resCode: resCode,
 		time:    time.Now(),
 		expire:  t.expire.PushBack(id),
 	}
-	g := fmt.Sprintf("%s/%s/%d/%#02x", trackedGaugeName, t.protocol, version, reqCode)
-	metrics.GetOrRegisterGauge(g, nil).Inc(1)
-
+	if metrics.Enabled {
+		g := fmt.Sprintf("%s/%s/%d/%#02x", trackedGaugeName, t.protocol, version, reqCode)
+		metrics.GetOrRegisterGauge(g, nil).Inc(1)
+	}
 	// If we've just inserted the first item, start the expiration timer
 	if t.wake == nil {
 		t.wake = time.AfterFunc(t.timeout, t.clean)
@@ -139,16 +150,56 @@ func (t *Tracker) clean() {
 			break
 		}
 		// Nope, dead, drop it
-		t.expire.Remove(head)
-		delete(t.pending, id)
+		t.drop(id, req, true)
+	}
+	t.schedule()
+}
 
+// drop untracks a request which will not be answered, scoring it as timed out
+// if it was lost. The caller must hold the lock.
+func (t *Tracker) drop(id uint64, req *request, lost bool) {
+	t.expire.Remove(req.expire)
+	delete(t.pending, id)
+
+	if lost && req.scorer != nil {
+		req.scorer.ScoreTimeout()
+	}
+	if metrics.Enabled {
 		g := fmt.Sprintf("%s/%s/%d/%#02x", trackedGaugeName, t.protocol, req.version, req.reqCode)
 		metrics.GetOrRegisterGauge(g, nil).Dec(1)
 
-		m := fmt.Sprintf("%s/%s/%d/%#02x", lostMeterName, t.protocol, req.version, req.reqCode)
-		metrics.GetOrRegisterMeter(m, nil).Mark(1)
+		if lost {
+			m := fmt.Sprintf("%s/%s/%d/%#02x", lostMeterName, t.protocol, req.version, req.reqCode)
+			metrics.GetOrRegisterMeter(m, nil).Mark(1)
+		}
+	}
+}
+
+// Flush drops all pending requests of a disconnecting peer. It is meant to be
+// called before the peer's reputation is persisted, which would otherwise miss
+// the penalties of the requests expiring later.
+//
+// If the peer dropped the connection, its requests are scored as timed out right
+// away. If the local node did, e.g. on shutdown or when evicting the peer, the
+// requests are abandoned without penalizing the peer.
+func (t *Tracker) Flush(peer string, lost bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var first bool
+	for id, req := range t.pending {
+		if req.peer != peer {
+			continue
+		}
+		if req.expire.Prev() == nil {
+			first = true
+		}
+		t.drop(id, req, lost)
+	}
+	// Reschedule the expiration timer if the next request to expire was dropped
+	if first && t.wake.Stop() {
+		t.schedule()
 	}
-	t.schedule()
 }
 
 // schedule starts a timer to trigger on the expiration of the first network
@@ -163,17 +214,16 @@ func (t *Tracker) schedule() {
 
 // Fulfil fills a pending request, if any is available, reporting on various metrics.
 func (t *Tracker) Fulfil(peer string, version uint, code uint64, id uint64) {
-	if !metrics.Enabled {
-		return
-	}
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
 	// If it's a non existing request, track as stale response
 	req, ok := t.pending[id]
 	if !ok {
-		m := fmt.Sprintf("%s/%s/%d/%#02x", staleMeterName, t.protocol, version, code)
-		metrics.GetOrRegisterMeter(m, nil).Mark(1)
+		if metrics.Enabled {
+			m := fmt.Sprintf("%s/%s/%d/%#02x", staleMeterName, t.protocol, version, code)
+			metrics.GetOrRegisterMeter(m, nil).Mark(1)
+		}
 		return
 	}
 	// If the response is funky, it might be some active attack
@@ -192,6 +242,13 @@ func (t *Tracker) Fulfil(peer string, version uint, code uint64, id uint64) {
 			t.schedule()
 		}
 	}
+	latency := time.Since(req.time)
+	if req.scorer != nil {
+		req.scorer.ScoreResponse(latency)
+	}
+	if !metrics.Enabled {
+		return
+	}
 	g := fmt.Sprintf("%s/%s/%d/%#02x", trackedGaugeName, t.protocol, req.version, req.reqCode)
 	metrics.GetOrRegisterGauge(g, nil).Dec(1)
 
@@ -201,5 +258,5 @@ func (t *Tracker) Fulfil(peer string, version uint, code uint64, id uint64) {
 			metrics.NewExpDecaySample(1028, 0.015),
 		)
 	}
-	metrics.GetOrRegisterHistogramLazy(h, nil, sampler).Update(time.Since(req.time).Microseconds())
+	metrics.GetOrRegisterHistogramLazy(h, nil, sampler).Update(latency.Microseconds())
 }
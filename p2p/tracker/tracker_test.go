@@ -0,0 +1,84 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracker
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// testScorer counts the responses and timeouts scored.
+type testScorer struct {
+	lock      sync.Mutex
+	responses int
+	timeouts  int
+}
+
+func (s *testScorer) ScoreResponse(latency time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.responses++
+}
+
+func (s *testScorer) ScoreTimeout() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.timeouts++
+}
+
+func (s *testScorer) counts() (int, int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.responses, s.timeouts
+}
+
+// Tests that flushing a disconnecting peer scores its pending requests as timed
+// out right away, leaving the requests of other peers alone. Peers disconnected
+// by the local node are not scored at all.
+func TestFlush(t *testing.T) {
+	var (
+		tracker = New("test", 50*time.Millisecond)
+		a, b, c = new(testScorer), new(testScorer), new(testScorer)
+	)
+	tracker.Track("a", a, 1, 0x01, 0x02, 1)
+	tracker.Track("b", b, 1, 0x01, 0x02, 2)
+	tracker.Track("a", a, 1, 0x01, 0x02, 3)
+	tracker.Track("c", c, 1, 0x01, 0x02, 4)
+
+	tracker.Flush("a", true)
+	tracker.Flush("c", false)
+	if _, timeouts := a.counts(); timeouts != 2 {
+		t.Fatalf("flushed peer scored %d timeouts, want 2", timeouts)
+	}
+	// Late responses of the flushed peer are stale
+	tracker.Fulfil("a", 1, 0x02, 1)
+	if responses, _ := a.counts(); responses != 0 {
+		t.Fatalf("flushed request scored as answered")
+	}
+	// The requests of other peers still expire
+	if _, timeouts := b.counts(); timeouts != 0 {
+		t.Fatalf("other peer scored %d timeouts before expiry", timeouts)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, timeouts := b.counts(); timeouts != 1 {
+		t.Fatalf("other peer scored %d timeouts after expiry, want 1", timeouts)
+	}
+	if responses, timeouts := c.counts(); responses != 0 || timeouts != 0 {
+		t.Fatalf("locally disconnected peer scored %d responses, %d timeouts", responses, timeouts)
+	}
+}
@@ -18,16 +18,28 @@ package p2p
 
 import (
 	"errors"
+	"net"
 	"reflect"
 	"sync"
 	"testing"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/rajchain/go-rajchain/crypto"
-	"github.com/rajchain/go-rajchain/p2p/pipes"
 )
 
 func TestProtocolHandshake(t *testing.T) {
+	for _, tr := range testTransports {
+		t.Run(tr.name, func(t *testing.T) {
+			fd0, fd1, err := tr.pipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			testProtocolHandshake(t, fd0, fd1)
+		})
+	}
+}
+
+func testProtocolHandshake(t *testing.T, fd0, fd1 net.Conn) {
 	var (
 		prv0, _ = crypto.GenerateKey()
 		pub0    = crypto.FromECDSAPub(&prv0.PublicKey)[1:]
@@ -40,11 +52,6 @@ func TestProtocolHandshake(t *testing.T) {
 		wg sync.WaitGroup
 	)
 
-	fd0, fd1, err := pipes.TCPPipe()
-	if err != nil {
-		t.Fatal(err)
-	}
-
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
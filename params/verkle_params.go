@@ -34,3 +34,8 @@ func ClearVerkleWitnessCosts() {
 	WitnessChunkWriteCost = 0
 	WitnessChunkFillCost = 0
 }
+
+// VerkleConversionStride is the number of merkle leaves (accounts and storage
+// slots) moved into the verkle overlay tree at the start of every block, while
+// the state is being converted (EIP-7748).
+const VerkleConversionStride = 10000
@@ -0,0 +1,129 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package approval
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common/hexutil"
+)
+
+const (
+	// maxDecisionSize is the maximum size of a decision posted over HTTP.
+	maxDecisionSize = 4096
+
+	// listingWindow is the time a signed listing request is accepted for,
+	// bounding the replay of intercepted listing signatures.
+	listingWindow = time.Minute
+)
+
+// Headers authenticating a listing of the pending requests over HTTP.
+const (
+	ListTimeHeader      = "X-Approval-Time"      // Unix time the listing was signed at
+	ListSignatureHeader = "X-Approval-Signature" // Approver signature over ListMessage
+)
+
+var errListingExpired = errors.New("listing signature outside the accepted time window")
+
+// ListMessage returns the text an approver signs, as per EIP-191 personal
+// messages, to list the pending requests over HTTP at the given unix time.
+func ListMessage(time int64) []byte {
+	return []byte(fmt.Sprintf("clef approval\nlist: %d", time))
+}
+
+// API is the approval service exposed to approvers. Since every decision is
+// authenticated by the signature of an approver, the API may be exposed over
+// channels other than the UI, e.g. HTTP.
+type API struct {
+	queue *Queue
+}
+
+// NewAPI creates the approval API of a queue.
+func NewAPI(queue *Queue) *API {
+	return &API{queue: queue}
+}
+
+// Pending returns the requests waiting for approval.
+// Example call
+// {"jsonrpc":"2.0","method":"approval_pending","params":[], "id":1}
+func (api *API) Pending() []*PendingRequest {
+	return api.queue.Pending()
+}
+
+// Decide submits the signed decision of an approver. The signature is made
+// over DecisionMessage of the request as an EIP-191 personal message.
+// Example call
+// {"jsonrpc":"2.0","method":"approval_decide","params":[{"id":"0x..","approve":true,"signature":"0x.."}], "id":2}
+func (api *API) Decide(d Decision) error {
+	return api.queue.Decide(d)
+}
+
+// Handler returns an HTTP handler for approvers to call back into, which may be
+// exposed to remote approvers. A GET lists the pending requests, if signed by an
+// approver through the ListTimeHeader and ListSignatureHeader headers. A POST of
+// a JSON encoded Decision submits it, authenticated by its own signature.
+func (api *API) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			if err := api.authorizeListing(r.Header); err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(api.Pending())
+
+		case http.MethodPost:
+			var d Decision
+			if err := json.NewDecoder(io.LimitReader(r.Body, maxDecisionSize)).Decode(&d); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := api.Decide(d); err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// authorizeListing checks that a listing request is signed by a registered
+// approver within the accepted time window.
+func (api *API) authorizeListing(header http.Header) error {
+	signed, err := strconv.ParseInt(header.Get(ListTimeHeader), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s header: %v", ListTimeHeader, err)
+	}
+	if age := time.Since(time.Unix(signed, 0)); age > listingWindow || age < -listingWindow {
+		return errListingExpired
+	}
+	sig, err := hexutil.Decode(header.Get(ListSignatureHeader))
+	if err != nil {
+		return fmt.Errorf("invalid %s header: %v", ListSignatureHeader, err)
+	}
+	return api.queue.authorize(ListMessage(signed), sig)
+}
@@ -0,0 +1,286 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package approval implements multi-party approval of clef requests. A request
+// is only approved once M of N registered approvers confirmed it, each signing
+// their decision with their own key.
+package approval
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rajchain/go-rajchain/accounts"
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/log"
+)
+
+// maxPending is the maximum number of requests waiting for approval. Requests
+// beyond it are rejected outright.
+const maxPending = 256
+
+var (
+	errUnknownRequest  = errors.New("unknown or expired request")
+	errUnknownApprover = errors.New("signer is not a registered approver")
+	errAlreadyDecided  = errors.New("approver already decided on request")
+	errQueueFull       = errors.New("too many pending requests")
+)
+
+// Config is the set of approvers and the number of them needed to approve.
+type Config struct {
+	Threshold int              // Number of approvals needed, M
+	Approvers []common.Address // Registered approvers, N
+	Timeout   time.Duration    // Time after which pending requests are rejected
+	MinValue  *big.Int         // Transactions below this value skip approval, nil gates all
+}
+
+// Validate checks the sanity of the approval configuration.
+func (c *Config) Validate() error {
+	if c.Threshold <= 0 {
+		return errors.New("approval threshold must be positive")
+	}
+	if c.Threshold > len(c.Approvers) {
+		return fmt.Errorf("approval threshold %d exceeds %d approvers", c.Threshold, len(c.Approvers))
+	}
+	for i, addr := range c.Approvers {
+		if slices.Contains(c.Approvers[:i], addr) {
+			return fmt.Errorf("duplicate approver %v", addr)
+		}
+	}
+	if c.Timeout <= 0 {
+		return errors.New("approval timeout must be positive")
+	}
+	return nil
+}
+
+// Decision is an approver's signed verdict on a pending request.
+type Decision struct {
+	ID        string        `json:"id"`
+	Approve   bool          `json:"approve"`
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+// DecisionMessage returns the text an approver signs, as per EIP-191 personal
+// messages, to approve or reject a request. It commits to the request content
+// through its digest, so a decision can't be replayed onto another request.
+func DecisionMessage(id string, digest common.Hash, approve bool) []byte {
+	verdict := "reject"
+	if approve {
+		verdict = "approve"
+	}
+	return []byte(fmt.Sprintf("clef approval\nrequest: %s\ndigest: %s\ndecision: %s", id, digest.Hex(), verdict))
+}
+
+// PendingRequest is a request waiting for the decisions of the approvers.
+type PendingRequest struct {
+	ID         string           `json:"id"`
+	Kind       string           `json:"kind"`
+	Request    json.RawMessage  `json:"request"`
+	Digest     common.Hash      `json:"digest"`
+	Created    time.Time        `json:"created"`
+	Expires    time.Time        `json:"expires"`
+	Approvals  []common.Address `json:"approvals"`
+	Rejections []common.Address `json:"rejections"`
+
+	done     chan struct{} // Closed when the request is decided or expires
+	approved bool          // Outcome of the request, set before closing done
+}
+
+// Queue tracks the requests waiting for approval and collects the decisions.
+type Queue struct {
+	config  Config
+	pending map[string]*PendingRequest
+	audit   log.Logger // Logger recording every submission and decision
+	lock    sync.Mutex
+}
+
+// NewQueue creates an approval queue. The configuration must be valid.
+func NewQueue(config Config) *Queue {
+	return &Queue{
+		config:  config,
+		pending: make(map[string]*PendingRequest),
+		audit:   log.Root(),
+	}
+}
+
+// SetAuditLog sets the logger every submission and decision is recorded in.
+func (q *Queue) SetAuditLog(logger log.Logger) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.audit = logger.With("api", "approval")
+}
+
+// Pending returns the requests waiting for approval.
+func (q *Queue) Pending() []*PendingRequest {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	reqs := make([]*PendingRequest, 0, len(q.pending))
+	for _, req := range q.pending {
+		cpy := *req
+		cpy.Approvals = slices.Clone(req.Approvals)
+		cpy.Rejections = slices.Clone(req.Rejections)
+		reqs = append(reqs, &cpy)
+	}
+	slices.SortFunc(reqs, func(a, b *PendingRequest) int {
+		return a.Created.Compare(b.Created)
+	})
+	return reqs
+}
+
+// Submit queues a request for approval and blocks until enough approvers
+// confirmed it, too many rejected it or it expired. The request is encoded
+// to JSON for the approvers to inspect.
+func (q *Queue) Submit(kind string, request interface{}) (bool, error) {
+	blob, err := json.Marshal(request)
+	if err != nil {
+		return false, err
+	}
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return false, err
+	}
+	now := time.Now()
+	req := &PendingRequest{
+		ID:      hexutil.Encode(id[:]),
+		Kind:    kind,
+		Request: blob,
+		Digest:  crypto.Keccak256Hash(blob),
+		Created: now,
+		Expires: now.Add(q.config.Timeout),
+		done:    make(chan struct{}),
+	}
+	q.lock.Lock()
+	if len(q.pending) >= maxPending {
+		q.audit.Info("Approval", "type", "rejected", "kind", kind, "reason", errQueueFull)
+		q.lock.Unlock()
+		return false, errQueueFull
+	}
+	q.pending[req.ID] = req
+	q.audit.Info("Approval", "type", "submitted", "id", req.ID, "kind", kind, "digest", req.Digest,
+		"threshold", q.config.Threshold, "approvers", len(q.config.Approvers), "expires", req.Expires)
+	q.lock.Unlock()
+
+	timer := time.NewTimer(q.config.Timeout)
+	defer timer.Stop()
+
+	select {
+	case <-req.done:
+		return req.approved, nil
+	case <-timer.C:
+		q.lock.Lock()
+		defer q.lock.Unlock()
+
+		// The request may have been decided while the lock was contended
+		select {
+		case <-req.done:
+			return req.approved, nil
+		default:
+		}
+		delete(q.pending, req.ID)
+		close(req.done)
+		q.audit.Info("Approval", "type", "expired", "id", req.ID, "kind", kind,
+			"approvals", len(req.Approvals), "rejections", len(req.Rejections))
+		return false, nil
+	}
+}
+
+// Decide records the signed decision of an approver on a pending request,
+// settling the request if it reaches the threshold or can no longer reach it.
+func (q *Queue) Decide(d Decision) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	req, ok := q.pending[d.ID]
+	if !ok {
+		return errUnknownRequest
+	}
+	approver, err := recoverApprover(DecisionMessage(req.ID, req.Digest, d.Approve), d.Signature)
+	if err != nil {
+		q.audit.Warn("Approval", "type", "invalid", "id", req.ID, "err", err)
+		return err
+	}
+	if !slices.Contains(q.config.Approvers, approver) {
+		q.audit.Warn("Approval", "type", "invalid", "id", req.ID, "signer", approver, "err", errUnknownApprover)
+		return errUnknownApprover
+	}
+	if slices.Contains(req.Approvals, approver) || slices.Contains(req.Rejections, approver) {
+		return errAlreadyDecided
+	}
+	if d.Approve {
+		req.Approvals = append(req.Approvals, approver)
+	} else {
+		req.Rejections = append(req.Rejections, approver)
+	}
+	q.audit.Info("Approval", "type", "decision", "id", req.ID, "approver", approver, "approve", d.Approve,
+		"approvals", len(req.Approvals), "rejections", len(req.Rejections))
+
+	switch {
+	case len(req.Approvals) >= q.config.Threshold:
+		req.approved = true
+	case len(req.Rejections) > len(q.config.Approvers)-q.config.Threshold:
+		req.approved = false
+	default:
+		return nil
+	}
+	delete(q.pending, req.ID)
+	close(req.done)
+	q.audit.Info("Approval", "type", "settled", "id", req.ID, "kind", req.Kind, "approved", req.approved)
+	return nil
+}
+
+// authorize checks that a message is signed by a registered approver, recording
+// failed attempts in the audit log.
+func (q *Queue) authorize(message []byte, sig hexutil.Bytes) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	approver, err := recoverApprover(message, sig)
+	if err != nil {
+		q.audit.Warn("Approval", "type", "unauthorized", "err", err)
+		return err
+	}
+	if !slices.Contains(q.config.Approvers, approver) {
+		q.audit.Warn("Approval", "type", "unauthorized", "signer", approver, "err", errUnknownApprover)
+		return errUnknownApprover
+	}
+	return nil
+}
+
+// recoverApprover returns the address that signed the given decision message.
+func recoverApprover(message []byte, sig hexutil.Bytes) (common.Address, error) {
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("signature must be %d bytes long", crypto.SignatureLength)
+	}
+	sig = common.CopyBytes(sig)
+	if sig[crypto.RecoveryIDOffset] == 27 || sig[crypto.RecoveryIDOffset] == 28 {
+		sig[crypto.RecoveryIDOffset] -= 27 // Transform yellow paper V from 27/28 to 0/1
+	}
+	pub, err := crypto.SigToPub(accounts.TextHash(message), sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
@@ -0,0 +1,220 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package approval
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/rajchain/go-rajchain/accounts"
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/crypto"
+)
+
+// testApprovers creates n approver keys along with a queue requiring m of them.
+func testApprovers(t *testing.T, m, n int, timeout time.Duration) (*Queue, []*ecdsa.PrivateKey) {
+	t.Helper()
+
+	config := Config{Threshold: m, Timeout: timeout}
+	keys := make([]*ecdsa.PrivateKey, n)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		config.Approvers = append(config.Approvers, crypto.PubkeyToAddress(keys[i].PublicKey))
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Invalid config: %v", err)
+	}
+	return NewQueue(config), keys
+}
+
+// sign creates the decision of an approver on a pending request.
+func sign(key *ecdsa.PrivateKey, req *PendingRequest, approve bool) Decision {
+	sig, _ := crypto.Sign(accounts.TextHash(DecisionMessage(req.ID, req.Digest, approve)), key)
+	sig[crypto.RecoveryIDOffset] += 27
+	return Decision{ID: req.ID, Approve: approve, Signature: sig}
+}
+
+// submit queues a request in the background, returning it once pending and a
+// channel delivering the outcome.
+func submit(t *testing.T, q *Queue, request interface{}) (*PendingRequest, chan bool) {
+	t.Helper()
+
+	result := make(chan bool, 1)
+	go func() {
+		approved, _ := q.Submit("test", request)
+		result <- approved
+	}()
+	for i := 0; i < 100; i++ {
+		if pending := q.Pending(); len(pending) > 0 {
+			return pending[len(pending)-1], result
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Request not queued")
+	return nil, nil
+}
+
+func TestThresholdApproval(t *testing.T) {
+	q, keys := testApprovers(t, 2, 3, time.Minute)
+	req, result := submit(t, q, map[string]string{"value": "1000"})
+
+	if err := q.Decide(sign(keys[0], req, true)); err != nil {
+		t.Fatalf("Decision failed: %v", err)
+	}
+	if err := q.Decide(sign(keys[0], req, true)); !errors.Is(err, errAlreadyDecided) {
+		t.Fatalf("Duplicate decision: have %v, want %v", err, errAlreadyDecided)
+	}
+	stranger, _ := crypto.GenerateKey()
+	if err := q.Decide(sign(stranger, req, true)); !errors.Is(err, errUnknownApprover) {
+		t.Fatalf("Stranger decision: have %v, want %v", err, errUnknownApprover)
+	}
+	// A signature over another verdict must not count
+	forged := sign(keys[1], req, false)
+	forged.Approve = true
+	if err := q.Decide(forged); !errors.Is(err, errUnknownApprover) {
+		t.Fatalf("Forged decision: have %v, want %v", err, errUnknownApprover)
+	}
+	select {
+	case <-result:
+		t.Fatal("Request settled below threshold")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := q.Decide(sign(keys[2], req, true)); err != nil {
+		t.Fatalf("Decision failed: %v", err)
+	}
+	if approved := <-result; !approved {
+		t.Fatal("Request not approved at threshold")
+	}
+	if err := q.Decide(sign(keys[1], req, true)); !errors.Is(err, errUnknownRequest) {
+		t.Fatalf("Late decision: have %v, want %v", err, errUnknownRequest)
+	}
+}
+
+func TestThresholdRejection(t *testing.T) {
+	q, keys := testApprovers(t, 2, 3, time.Minute)
+	req, result := submit(t, q, "request")
+
+	// Two rejections out of three leave the threshold unreachable
+	for _, key := range keys[:2] {
+		if err := q.Decide(sign(key, req, false)); err != nil {
+			t.Fatalf("Decision failed: %v", err)
+		}
+	}
+	if approved := <-result; approved {
+		t.Fatal("Request approved despite rejections")
+	}
+}
+
+func TestThresholdExpiry(t *testing.T) {
+	q, keys := testApprovers(t, 2, 2, 100*time.Millisecond)
+	req, result := submit(t, q, "request")
+
+	if err := q.Decide(sign(keys[0], req, true)); err != nil {
+		t.Fatalf("Decision failed: %v", err)
+	}
+	if approved := <-result; approved {
+		t.Fatal("Expired request approved")
+	}
+	if pending := q.Pending(); len(pending) != 0 {
+		t.Fatalf("Expired request still pending")
+	}
+}
+
+func TestHTTPCallback(t *testing.T) {
+	q, keys := testApprovers(t, 1, 2, time.Minute)
+	srv := httptest.NewServer(NewAPI(q).Handler())
+	defer srv.Close()
+
+	_, result := submit(t, q, "request")
+
+	list := func(key *ecdsa.PrivateKey, signed time.Time) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		if key != nil {
+			sig, _ := crypto.Sign(accounts.TextHash(ListMessage(signed.Unix())), key)
+			req.Header.Set(ListTimeHeader, strconv.FormatInt(signed.Unix(), 10))
+			req.Header.Set(ListSignatureHeader, hexutil.Encode(sig))
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to list pending requests: %v", err)
+		}
+		return res
+	}
+	// Listing is only served to approvers with a fresh signature
+	outsider, _ := crypto.GenerateKey()
+	for i, key := range []*ecdsa.PrivateKey{nil, outsider, keys[0]} {
+		signed := time.Now()
+		if key == keys[0] {
+			signed = signed.Add(-2 * listingWindow)
+		}
+		res := list(key, signed)
+		res.Body.Close()
+		if res.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("Listing %d: have status %d, want %d", i, res.StatusCode, http.StatusUnauthorized)
+		}
+	}
+	res := list(keys[0], time.Now())
+	var pending []*PendingRequest
+	if err := json.NewDecoder(res.Body).Decode(&pending); err != nil || len(pending) != 1 {
+		t.Fatalf("Invalid pending requests: %v, %d requests", err, len(pending))
+	}
+	res.Body.Close()
+
+	if pending[0].Digest != crypto.Keccak256Hash(pending[0].Request) {
+		t.Fatalf("Digest mismatch: have %v, want %v", pending[0].Digest, crypto.Keccak256Hash(pending[0].Request))
+	}
+	post := func(d Decision) int {
+		blob, _ := json.Marshal(d)
+		res, err := http.Post(srv.URL, "application/json", bytes.NewReader(blob))
+		if err != nil {
+			t.Fatalf("Failed to post decision: %v", err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+	if code := post(Decision{ID: pending[0].ID, Approve: true, Signature: make([]byte, 65)}); code != http.StatusForbidden {
+		t.Fatalf("Unsigned decision: have status %d, want %d", code, http.StatusForbidden)
+	}
+	if code := post(sign(keys[1], pending[0], true)); code != http.StatusNoContent {
+		t.Fatalf("Signed decision: have status %d, want %d", code, http.StatusNoContent)
+	}
+	if approved := <-result; !approved {
+		t.Fatal("Request not approved")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	a, b := common.Address{1}, common.Address{2}
+	for i, config := range []Config{
+		{Threshold: 0, Approvers: []common.Address{a}, Timeout: time.Minute},
+		{Threshold: 2, Approvers: []common.Address{a}, Timeout: time.Minute},
+		{Threshold: 1, Approvers: []common.Address{a, a}, Timeout: time.Minute},
+		{Threshold: 1, Approvers: []common.Address{a, b}},
+	} {
+		if err := config.Validate(); err == nil {
+			t.Errorf("config %d: no error", i)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package approval
+
+import (
+	"fmt"
+
+	"github.com/rajchain/go-rajchain/internal/ethapi"
+	"github.com/rajchain/go-rajchain/log"
+	"github.com/rajchain/go-rajchain/signer/core"
+	"github.com/rajchain/go-rajchain/signer/core/apitypes"
+)
+
+// serviceRegistrar is implemented by UIs able to expose additional services to
+// the user interface, such as the stdio UI.
+type serviceRegistrar interface {
+	RegisterService(namespace string, service interface{}) error
+}
+
+// denialHandler is implemented by UIs needing to know about transactions they
+// approved but the quorum denied, such as the policy UI refunding spend limits.
+type denialHandler interface {
+	OnDeniedTx(request *core.SignTxRequest)
+}
+
+// thresholdUI is an implementation of UIClientAPI that requires transaction and
+// data signing requests to be confirmed by a quorum of approvers on top of the
+// next UI in line. It must be the outermost UI, so that requests approved by
+// rules or policies still need the quorum. Other requests are forwarded to the
+// next UI.
+type thresholdUI struct {
+	next  core.UIClientAPI // The next handler, deciding before the quorum
+	queue *Queue
+}
+
+// NewUI creates a UI submitting signing requests approved by the next UI to the
+// approval queue. It must wrap any rule or policy UI.
+func NewUI(next core.UIClientAPI, queue *Queue) core.UIClientAPI {
+	return &thresholdUI{next: next, queue: queue}
+}
+
+// RegisterUIServer forwards the UI server to the next UI, exposing the approval
+// API to it as well if supported.
+func (ui *thresholdUI) RegisterUIServer(api *core.UIServerAPI) {
+	ui.next.RegisterUIServer(api)
+	if r, ok := ui.next.(serviceRegistrar); ok {
+		if err := r.RegisterService("approval", NewAPI(ui.queue)); err != nil {
+			log.Warn("Failed to expose approval API to UI", "err", err)
+		}
+	}
+}
+
+// needsQuorum reports whether a transaction has to be confirmed by the quorum.
+// Only plain value transfers below the minimum value skip it: contract calls
+// and creations can move any amount of tokens regardless of their value.
+func (ui *thresholdUI) needsQuorum(tx *apitypes.SendTxArgs) bool {
+	min := ui.queue.config.MinValue
+	if min == nil || tx.To == nil {
+		return true
+	}
+	if (tx.Input != nil && len(*tx.Input) > 0) || (tx.Data != nil && len(*tx.Data) > 0) {
+		return true
+	}
+	return tx.Value.ToInt().Cmp(min) >= 0
+}
+
+func (ui *thresholdUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
+	res, err := ui.next.ApproveTx(request)
+	if err != nil || !res.Approved || !ui.needsQuorum(&res.Transaction) {
+		return res, err
+	}
+	ui.next.ShowInfo(fmt.Sprintf("Transaction from %v submitted for approval by %d of %d approvers",
+		res.Transaction.From, ui.queue.config.Threshold, len(ui.queue.config.Approvers)))
+
+	// The approvers decide on the transaction as approved by the next UI, which
+	// may have modified it
+	approved, err := ui.queue.Submit("transaction", &core.SignTxRequest{
+		Transaction: res.Transaction,
+		Callinfo:    request.Callinfo,
+		Meta:        request.Meta,
+	})
+	if err != nil || !approved {
+		if h, ok := ui.next.(denialHandler); ok {
+			h.OnDeniedTx(&core.SignTxRequest{Transaction: res.Transaction})
+		}
+		return core.SignTxResponse{Approved: false}, err
+	}
+	return res, nil
+}
+
+func (ui *thresholdUI) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
+	res, err := ui.next.ApproveSignData(request)
+	if err != nil || !res.Approved {
+		return res, err
+	}
+	ui.next.ShowInfo(fmt.Sprintf("Data signing by %v submitted for approval by %d of %d approvers",
+		request.Address, ui.queue.config.Threshold, len(ui.queue.config.Approvers)))
+
+	approved, err := ui.queue.Submit("signData", request)
+	if err != nil {
+		return core.SignDataResponse{Approved: false}, err
+	}
+	return core.SignDataResponse{Approved: approved}, nil
+}
+
+func (ui *thresholdUI) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
+	return ui.next.ApproveListing(request)
+}
+
+func (ui *thresholdUI) ApproveNewAccount(request *core.NewAccountRequest) (core.NewAccountResponse, error) {
+	return ui.next.ApproveNewAccount(request)
+}
+
+func (ui *thresholdUI) OnInputRequired(info core.UserInputRequest) (core.UserInputResponse, error) {
+	return ui.next.OnInputRequired(info)
+}
+
+func (ui *thresholdUI) ShowError(message string) {
+	ui.next.ShowError(message)
+}
+
+func (ui *thresholdUI) ShowInfo(message string) {
+	ui.next.ShowInfo(message)
+}
+
+func (ui *thresholdUI) OnSignerStartup(info core.StartupInfo) {
+	ui.next.OnSignerStartup(info)
+}
+
+func (ui *thresholdUI) OnApprovedTx(tx ethapi.SignTransactionResult) {
+	ui.next.OnApprovedTx(tx)
+}
@@ -0,0 +1,187 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package approval
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/internal/ethapi"
+	"github.com/rajchain/go-rajchain/signer/core"
+	"github.com/rajchain/go-rajchain/signer/core/apitypes"
+	"github.com/rajchain/go-rajchain/signer/fourbyte"
+	"github.com/rajchain/go-rajchain/signer/policy"
+	"github.com/rajchain/go-rajchain/signer/storage"
+)
+
+// rejectingUI is a UI rejecting all requests, standing in for an operator who
+// is never asked.
+type rejectingUI struct{}
+
+func (rejectingUI) ApproveTx(*core.SignTxRequest) (core.SignTxResponse, error) {
+	return core.SignTxResponse{Approved: false}, nil
+}
+func (rejectingUI) ApproveSignData(*core.SignDataRequest) (core.SignDataResponse, error) {
+	return core.SignDataResponse{Approved: false}, nil
+}
+func (rejectingUI) ApproveListing(*core.ListRequest) (core.ListResponse, error) {
+	return core.ListResponse{}, nil
+}
+func (rejectingUI) ApproveNewAccount(*core.NewAccountRequest) (core.NewAccountResponse, error) {
+	return core.NewAccountResponse{Approved: false}, nil
+}
+func (rejectingUI) ShowError(string)                          {}
+func (rejectingUI) ShowInfo(string)                           {}
+func (rejectingUI) OnApprovedTx(ethapi.SignTransactionResult) {}
+func (rejectingUI) OnSignerStartup(core.StartupInfo)          {}
+func (rejectingUI) OnInputRequired(core.UserInputRequest) (core.UserInputResponse, error) {
+	return core.UserInputResponse{}, nil
+}
+func (rejectingUI) RegisterUIServer(*core.UIServerAPI) {}
+
+// Tests that transactions auto-approved by a policy still need the quorum.
+func TestPolicyApprovedNeedsQuorum(t *testing.T) {
+	p, err := policy.Parse([]byte("transactions:\n  default: approve\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	db, err := fourbyte.New()
+	if err != nil {
+		t.Fatalf("Failed to load 4byte database: %v", err)
+	}
+	var (
+		q, keys = testApprovers(t, 2, 3, time.Minute)
+		ui      = NewUI(policy.NewUI(rejectingUI{}, policy.NewEngine(p, db, storage.NewEphemeralStorage())), q)
+		to      = common.NewMixedcaseAddress(common.HexToAddress("0x2222222222222222222222222222222222222222"))
+		request = &core.SignTxRequest{Transaction: apitypes.SendTxArgs{
+			To:       &to,
+			Value:    hexutil.Big(*big.NewInt(1)),
+			Gas:      21000,
+			GasPrice: (*hexutil.Big)(big.NewInt(1)),
+		}}
+		result = make(chan bool, 1)
+	)
+	go func() {
+		res, _ := ui.ApproveTx(request)
+		result <- res.Approved
+	}()
+	var req *PendingRequest
+	for i := 0; i < 100 && req == nil; i++ {
+		if pending := q.Pending(); len(pending) > 0 {
+			req = pending[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if req == nil {
+		t.Fatal("Policy approved transaction not submitted to the quorum")
+	}
+	if err := q.Decide(sign(keys[0], req, true)); err != nil {
+		t.Fatalf("Decision failed: %v", err)
+	}
+	select {
+	case <-result:
+		t.Fatal("Transaction settled below threshold")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := q.Decide(sign(keys[1], req, true)); err != nil {
+		t.Fatalf("Decision failed: %v", err)
+	}
+	if approved := <-result; !approved {
+		t.Fatal("Transaction not approved at threshold")
+	}
+}
+
+// Tests that the spend limit of a policy is refunded for transactions denied by
+// the quorum.
+func TestQuorumDenialRefundsSpend(t *testing.T) {
+	p, err := policy.Parse([]byte(`
+transactions:
+  rules:
+    - name: limited
+      to: ["0x2222222222222222222222222222222222222222"]
+      spendLimit: {amount: 1, window: 1h}
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	db, err := fourbyte.New()
+	if err != nil {
+		t.Fatalf("Failed to load 4byte database: %v", err)
+	}
+	var (
+		q, keys = testApprovers(t, 1, 1, time.Minute)
+		ui      = NewUI(policy.NewUI(rejectingUI{}, policy.NewEngine(p, db, storage.NewEphemeralStorage())), q)
+		to      = common.NewMixedcaseAddress(common.HexToAddress("0x2222222222222222222222222222222222222222"))
+		request = &core.SignTxRequest{Transaction: apitypes.SendTxArgs{
+			To:       &to,
+			Value:    hexutil.Big(*big.NewInt(1)),
+			Gas:      21000,
+			GasPrice: (*hexutil.Big)(big.NewInt(1)),
+		}}
+	)
+	// Spending the whole limit twice only works if the first denial refunded it
+	for i := 0; i < 2; i++ {
+		result := make(chan bool, 1)
+		go func() {
+			res, _ := ui.ApproveTx(request)
+			result <- res.Approved
+		}()
+		var req *PendingRequest
+		for j := 0; j < 100 && req == nil; j++ {
+			if pending := q.Pending(); len(pending) > 0 {
+				req = pending[0]
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if req == nil {
+			t.Fatalf("attempt %d: transaction not approved by the policy", i)
+		}
+		if err := q.Decide(sign(keys[0], req, false)); err != nil {
+			t.Fatalf("attempt %d: decision failed: %v", i, err)
+		}
+		if approved := <-result; approved {
+			t.Fatalf("attempt %d: transaction approved despite denial", i)
+		}
+	}
+}
+
+// Tests that only plain transfers below the minimum value skip the quorum.
+func TestNeedsQuorum(t *testing.T) {
+	var (
+		to   = common.NewMixedcaseAddress(common.HexToAddress("0x2222222222222222222222222222222222222222"))
+		call = hexutil.Bytes{0xa9, 0x05, 0x9c, 0xbb}
+		ui   = &thresholdUI{queue: NewQueue(Config{MinValue: big.NewInt(100)})}
+	)
+	tests := []struct {
+		tx   apitypes.SendTxArgs
+		want bool
+	}{
+		{apitypes.SendTxArgs{To: &to, Value: hexutil.Big(*big.NewInt(99))}, false},
+		{apitypes.SendTxArgs{To: &to, Value: hexutil.Big(*big.NewInt(100))}, true},
+		{apitypes.SendTxArgs{To: &to, Input: &call}, true},
+		{apitypes.SendTxArgs{To: &to, Data: &call}, true},
+		{apitypes.SendTxArgs{Input: &call}, true},
+	}
+	for i, tt := range tests {
+		if have := ui.needsQuorum(&tt.tx); have != tt.want {
+			t.Errorf("test %d: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
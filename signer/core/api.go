@@ -46,7 +46,7 @@ const (
 	// ExternalAPIVersion -- see extapi_changelog.md
 	ExternalAPIVersion = "6.1.0"
 	// InternalAPIVersion -- see intapi_changelog.md
-	InternalAPIVersion = "7.0.1"
+	InternalAPIVersion = "7.2.0"
 )
 
 // ExternalAPI defines the external API through which signing requests are made.
@@ -241,6 +241,7 @@ type (
 		Callinfo    []apitypes.ValidationInfo `json:"call_info"`
 		Hash        hexutil.Bytes             `json:"hash"`
 		Meta        Metadata                  `json:"meta"`
+		TypedData   *apitypes.TypedData       `json:"typed_data,omitempty"`
 	}
 	SignDataResponse struct {
 		Approved bool `json:"approved"`
@@ -41,6 +41,12 @@ func (ui *StdIOUI) RegisterUIServer(api *UIServerAPI) {
 	ui.client.RegisterName("clef", api)
 }
 
+// RegisterService exposes an additional service to the UI under the given
+// namespace, next to the UI server API.
+func (ui *StdIOUI) RegisterService(namespace string, service interface{}) error {
+	return ui.client.RegisterName(namespace, service)
+}
+
 // dispatch sends a request over the stdio
 func (ui *StdIOUI) dispatch(serviceMethod string, args interface{}, reply interface{}) error {
 	err := ui.client.Call(&reply, serviceMethod, args)
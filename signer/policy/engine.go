@@ -0,0 +1,338 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/log"
+	"github.com/rajchain/go-rajchain/signer/core"
+	"github.com/rajchain/go-rajchain/signer/core/apitypes"
+	"github.com/rajchain/go-rajchain/signer/fourbyte"
+	"github.com/rajchain/go-rajchain/signer/storage"
+)
+
+// Result is the decision taken on a request, along with the rule taking it.
+type Result struct {
+	Action Action `json:"action"`
+	Rule   string `json:"rule,omitempty"` // Empty if no rule matched the request
+	Reason string `json:"reason"`
+}
+
+// spend is a value approved by a rule with a spend limit.
+type spend struct {
+	Time   int64        `json:"time"`
+	Amount *hexutil.Big `json:"amount"`
+}
+
+// reservation is a value approved by a rule with a spend limit, for a
+// transaction not signed yet.
+type reservation struct {
+	rule  *TxRule
+	from  common.Address
+	nonce uint64
+	spend *spend
+}
+
+// Engine evaluates requests against a policy. The values approved by rules
+// with spend limits are reserved until the transaction is signed, and then
+// tracked in the given storage.
+type Engine struct {
+	policy  *Policy
+	db      *fourbyte.Database
+	storage storage.Storage
+	pending []*reservation // Approved values of transactions not signed yet
+	lock    sync.Mutex     // Serializes spend limit checks and updates
+}
+
+// NewEngine creates a policy engine, decoding method calls with the 4byte
+// database and tracking spending in the given storage.
+func NewEngine(policy *Policy, db *fourbyte.Database, store storage.Storage) *Engine {
+	return &Engine{
+		policy:  policy,
+		db:      db,
+		storage: store,
+	}
+}
+
+// CheckTx evaluates a transaction signing request at the given time. If the
+// transaction is approved, its value is reserved against the spend limit of the
+// approving rule until it is either charged by Commit or refunded by Release.
+func (e *Engine) CheckTx(req *core.SignTxRequest, now time.Time) Result {
+	var (
+		tx   = &req.Transaction
+		from = tx.From.Address()
+		data []byte
+	)
+	if tx.Input != nil {
+		data = *tx.Input
+	} else if tx.Data != nil {
+		data = *tx.Data
+	}
+	// Contract creations carry arbitrary code, so they are never decided by the
+	// default action, only by rules allowing them
+	if tx.To == nil {
+		for _, rule := range e.policy.Transactions.Rules {
+			if rule.Create && (len(rule.From) == 0 || slices.Contains(rule.From, from)) {
+				return e.checkTxRule(rule, nil, tx, data, now)
+			}
+		}
+		return Result{Action: Manual, Reason: "contract creation"}
+	}
+	to := tx.To.Address()
+	for _, rule := range e.policy.Transactions.Rules {
+		if len(rule.From) > 0 && !slices.Contains(rule.From, from) {
+			continue
+		}
+		var contract *ContractRule
+		for _, c := range rule.Contracts {
+			if c.Address == to {
+				contract = c
+				break
+			}
+		}
+		if contract == nil && !slices.Contains(rule.To, to) {
+			continue
+		}
+		return e.checkTxRule(rule, contract, tx, data, now)
+	}
+	return Result{Action: e.policy.Transactions.Default.orManual(), Reason: "no matching rule"}
+}
+
+// checkTxRule evaluates a transaction against the rule matching its sender and
+// recipient, or allowing its contract creation. The contract rule is set if the
+// recipient is one of its contracts.
+func (e *Engine) checkTxRule(rule *TxRule, contract *ContractRule, tx *apitypes.SendTxArgs, data []byte, now time.Time) Result {
+	reject := func(format string, args ...interface{}) Result {
+		return Result{Action: Reject, Rule: rule.Name, Reason: fmt.Sprintf(format, args...)}
+	}
+	switch {
+	case tx.To == nil:
+		// Contract creation allowed by the rule, the init code is not checked
+
+	case len(data) == 0 && !slices.Contains(rule.To, tx.To.Address()):
+		return reject("plain transfer to contract %v", tx.To.Address())
+
+	case len(data) > 0 && contract == nil:
+		return reject("call data sent to recipient %v", tx.To.Address())
+
+	case len(data) > 0:
+		if len(data) < 4 {
+			return reject("call data without method selector")
+		}
+		var method string
+		for _, m := range contract.Methods {
+			if bytes.Equal(crypto.Keccak256([]byte(m))[:4], data[:4]) {
+				method = m
+				break
+			}
+		}
+		if method == "" {
+			if name, err := e.db.Selector(data[:4]); err == nil {
+				return reject("method %s not allowed", name)
+			}
+			return reject("unknown method %#x not allowed", data[:4])
+		}
+		messages := new(apitypes.ValidationMessages)
+		e.db.ValidateCallData(&method, data, messages)
+		if err := messages.GetWarnings(); err != nil {
+			return reject("invalid call data for %s: %v", method, err)
+		}
+	}
+	value := tx.Value.ToInt()
+	if rule.MaxValue != nil && value.Cmp((*big.Int)(rule.MaxValue)) > 0 {
+		return reject("value %v above ceiling %v", value, (*big.Int)(rule.MaxValue))
+	}
+	if rule.MaxGas != 0 && uint64(tx.Gas) > rule.MaxGas {
+		return reject("gas %d above ceiling %d", tx.Gas, rule.MaxGas)
+	}
+	if rule.MaxGasPrice != nil {
+		for _, price := range []*hexutil.Big{tx.GasPrice, tx.MaxFeePerGas} {
+			if price != nil && price.ToInt().Cmp((*big.Int)(rule.MaxGasPrice)) > 0 {
+				return reject("gas price %v above ceiling %v", price.ToInt(), (*big.Int)(rule.MaxGasPrice))
+			}
+		}
+	}
+	if rule.SpendLimit == nil {
+		return Result{Action: Approve, Rule: rule.Name, Reason: "within limits"}
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	// Values reserved by pending transactions count against the limit, lest
+	// concurrent requests overshoot it while waiting to be signed
+	start := now.Add(-rule.SpendLimit.Window).Unix()
+	e.pending = slices.DeleteFunc(e.pending, func(r *reservation) bool {
+		return r.rule == rule && r.spend.Time <= start
+	})
+	total := new(big.Int).Set(value)
+	for _, s := range e.spends(rule, now) {
+		total.Add(total, s.Amount.ToInt())
+	}
+	for _, r := range e.pending {
+		if r.rule == rule {
+			total.Add(total, r.spend.Amount.ToInt())
+		}
+	}
+	if limit := (*big.Int)(rule.SpendLimit.Amount); total.Cmp(limit) > 0 {
+		return reject("spending %v within %v above limit %v", total, rule.SpendLimit.Window, limit)
+	}
+	e.pending = append(e.pending, &reservation{
+		rule:  rule,
+		from:  tx.From.Address(),
+		nonce: uint64(tx.Nonce),
+		spend: &spend{Time: now.Unix(), Amount: (*hexutil.Big)(value)},
+	})
+	return Result{Action: Approve, Rule: rule.Name, Reason: "within limits"}
+}
+
+// Commit charges the value reserved for the transaction of the sender with the
+// given nonce against the spend limit of the rule having approved it, once the
+// transaction has been signed. Transactions without reservation are ignored.
+func (e *Engine) Commit(from common.Address, nonce uint64, now time.Time) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if r := e.takeReservation(from, nonce); r != nil {
+		e.storeSpends(r.rule, append(e.spends(r.rule, now), r.spend))
+	}
+}
+
+// Release refunds the value reserved for the transaction of the sender with the
+// given nonce, if it failed to be signed.
+func (e *Engine) Release(from common.Address, nonce uint64) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.takeReservation(from, nonce)
+}
+
+// takeReservation removes and returns the oldest reservation of a transaction.
+func (e *Engine) takeReservation(from common.Address, nonce uint64) *reservation {
+	i := slices.IndexFunc(e.pending, func(r *reservation) bool {
+		return r.from == from && r.nonce == nonce
+	})
+	if i < 0 {
+		return nil
+	}
+	r := e.pending[i]
+	e.pending = slices.Delete(e.pending, i, i+1)
+	return r
+}
+
+// spends returns the values approved by the rule within its spend window.
+func (e *Engine) spends(rule *TxRule, now time.Time) []*spend {
+	blob, err := e.storage.Get(spendKey(rule))
+	if err != nil || blob == "" {
+		return nil
+	}
+	var all []*spend
+	if err := json.Unmarshal([]byte(blob), &all); err != nil {
+		log.Warn("Invalid policy spend records", "rule", rule.Name, "err", err)
+		return nil
+	}
+	start := now.Add(-rule.SpendLimit.Window).Unix()
+	return slices.DeleteFunc(all, func(s *spend) bool {
+		return s.Time <= start || s.Amount == nil
+	})
+}
+
+// storeSpends persists the values approved by the rule.
+func (e *Engine) storeSpends(rule *TxRule, spends []*spend) {
+	blob, err := json.Marshal(spends)
+	if err != nil {
+		log.Warn("Failed to encode policy spend records", "rule", rule.Name, "err", err)
+		return
+	}
+	e.storage.Put(spendKey(rule), string(blob))
+}
+
+// spendKey returns the storage key of the spend records of a rule.
+func spendKey(rule *TxRule) string {
+	return "spend/" + rule.Name
+}
+
+// CheckSignData evaluates a data signing request.
+func (e *Engine) CheckSignData(req *core.SignDataRequest) Result {
+	account := req.Address.Address()
+	for _, rule := range e.policy.SignData.Rules {
+		if len(rule.Accounts) > 0 && !slices.Contains(rule.Accounts, account) {
+			continue
+		}
+		if len(rule.ContentTypes) > 0 && !slices.Contains(rule.ContentTypes, req.ContentType) {
+			continue
+		}
+		if len(rule.Domains) > 0 {
+			if req.TypedData == nil || !slices.ContainsFunc(rule.Domains, func(d *Domain) bool {
+				return d.matches(&req.TypedData.Domain)
+			}) {
+				continue
+			}
+		}
+		action := rule.Action
+		if action == "" {
+			action = Approve
+		}
+		return Result{Action: action, Rule: rule.Name, Reason: "matching rule"}
+	}
+	return Result{Action: e.policy.SignData.Default.orManual(), Reason: "no matching rule"}
+}
+
+// matches returns whether the EIP-712 domain satisfies the restriction.
+func (d *Domain) matches(domain *apitypes.TypedDataDomain) bool {
+	if d.Name != "" && d.Name != domain.Name {
+		return false
+	}
+	if d.Version != "" && d.Version != domain.Version {
+		return false
+	}
+	if d.ChainID != nil && (domain.ChainId == nil || (*big.Int)(d.ChainID).Cmp((*big.Int)(domain.ChainId)) != 0) {
+		return false
+	}
+	if d.VerifyingContract != nil {
+		if !common.IsHexAddress(domain.VerifyingContract) || common.HexToAddress(domain.VerifyingContract) != *d.VerifyingContract {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckListing evaluates an account listing request.
+func (e *Engine) CheckListing(req *core.ListRequest) Result {
+	return Result{Action: e.policy.Listing.orManual(), Reason: "listing policy"}
+}
+
+// String implements fmt.Stringer.
+func (r Result) String() string {
+	var b strings.Builder
+	b.WriteString(string(r.Action))
+	if r.Rule != "" {
+		fmt.Fprintf(&b, " (rule %q)", r.Rule)
+	}
+	fmt.Fprintf(&b, ": %s", r.Reason)
+	return b.String()
+}
@@ -0,0 +1,192 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package policy implements a declarative alternative to the JavaScript rules
+// of clef. A policy is a YAML (or JSON) document listing which requests may be
+// approved or must be rejected, leaving the rest to manual processing.
+package policy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/math"
+	"gopkg.in/yaml.v3"
+)
+
+// Action is the outcome of evaluating a request against a policy.
+type Action string
+
+const (
+	Manual  Action = "manual"  // Request is forwarded to the next UI for manual processing
+	Approve Action = "approve" // Request is approved without user interaction
+	Reject  Action = "reject"  // Request is rejected without user interaction
+)
+
+// UnmarshalText parses an action, rejecting unknown ones.
+func (a *Action) UnmarshalText(input []byte) error {
+	switch action := Action(strings.ToLower(string(input))); action {
+	case Manual, Approve, Reject:
+		*a = action
+		return nil
+	default:
+		return fmt.Errorf("unknown action %q", input)
+	}
+}
+
+// orManual returns the action, defaulting to manual processing if unset.
+func (a Action) orManual() Action {
+	if a == "" {
+		return Manual
+	}
+	return a
+}
+
+// Policy is the set of declarative rules clef evaluates incoming requests with.
+type Policy struct {
+	Transactions TxPolicy   `yaml:"transactions"`
+	SignData     DataPolicy `yaml:"signData"`
+	Listing      Action     `yaml:"listing"` // Action for account listing requests
+}
+
+// TxPolicy lists the rules for transaction signing requests. The first rule
+// matching a transaction decides on it.
+type TxPolicy struct {
+	Default Action    `yaml:"default"` // Action for transactions no rule matches
+	Rules   []*TxRule `yaml:"rules"`
+}
+
+// TxRule approves the transactions sent from one of its accounts to one of its
+// recipients, as long as they stay below its ceilings. Contract creations are
+// only approved by rules explicitly allowing them, never by the default action.
+type TxRule struct {
+	Name        string                `yaml:"name"`
+	From        []common.Address      `yaml:"from"`        // Accounts the rule applies to, any if empty
+	To          []common.Address      `yaml:"to"`          // Recipients of plain value transfers
+	Contracts   []*ContractRule       `yaml:"contracts"`   // Contracts that may be called
+	Create      bool                  `yaml:"create"`      // Whether contracts may be created
+	MaxValue    *math.HexOrDecimal256 `yaml:"maxValue"`    // Maximum value of a single transaction
+	MaxGas      uint64                `yaml:"maxGas"`      // Maximum gas limit, unlimited if zero
+	MaxGasPrice *math.HexOrDecimal256 `yaml:"maxGasPrice"` // Maximum gas price or fee cap
+	SpendLimit  *SpendLimit           `yaml:"spendLimit"`  // Maximum value sent within a rolling window
+}
+
+// ContractRule allows calling the listed methods of a contract.
+type ContractRule struct {
+	Address common.Address `yaml:"address"`
+	Methods []string       `yaml:"methods"` // Method signatures, e.g. transfer(address,uint256)
+}
+
+// SpendLimit caps the total value approved by a rule within a rolling window.
+type SpendLimit struct {
+	Amount *math.HexOrDecimal256 `yaml:"amount"`
+	Window time.Duration         `yaml:"window"`
+}
+
+// DataPolicy lists the rules for data signing requests. The first rule
+// matching a request decides on it.
+type DataPolicy struct {
+	Default Action      `yaml:"default"` // Action for requests no rule matches
+	Rules   []*DataRule `yaml:"rules"`
+}
+
+// DataRule matches data signing requests by account, content type and, for
+// EIP-712 typed data, by signing domain.
+type DataRule struct {
+	Name         string           `yaml:"name"`
+	Action       Action           `yaml:"action"`       // Action for matching requests, approve if unset
+	Accounts     []common.Address `yaml:"accounts"`     // Signing accounts, any if empty
+	ContentTypes []string         `yaml:"contentTypes"` // Content types, any if empty
+	Domains      []*Domain        `yaml:"domains"`      // EIP-712 domains, any if empty
+}
+
+// Domain restricts EIP-712 typed data to a signing domain. Unset fields match
+// any value.
+type Domain struct {
+	Name              string                `yaml:"name"`
+	Version           string                `yaml:"version"`
+	ChainID           *math.HexOrDecimal256 `yaml:"chainId"`
+	VerifyingContract *common.Address       `yaml:"verifyingContract"`
+}
+
+// Load reads and validates a policy from a YAML or JSON file.
+func Load(path string) (*Policy, error) {
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(blob)
+}
+
+// Parse decodes and validates a YAML or JSON encoded policy.
+func Parse(blob []byte) (*Policy, error) {
+	var policy Policy
+	if err := yaml.Unmarshal(blob, &policy); err != nil {
+		return nil, err
+	}
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// validate checks that the rules of the policy are well formed.
+func (p *Policy) validate() error {
+	names := make(map[string]bool)
+	for i, rule := range p.Transactions.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("transaction rule %d: missing name", i)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("transaction rule %q: duplicate name", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if len(rule.To) == 0 && len(rule.Contracts) == 0 && !rule.Create {
+			return fmt.Errorf("transaction rule %q: no recipients, contracts or creation", rule.Name)
+		}
+		for _, contract := range rule.Contracts {
+			if len(contract.Methods) == 0 {
+				return fmt.Errorf("transaction rule %q: no methods for contract %v", rule.Name, contract.Address)
+			}
+			for _, method := range contract.Methods {
+				if !strings.HasSuffix(method, ")") || !strings.Contains(method, "(") {
+					return fmt.Errorf("transaction rule %q: invalid method signature %q", rule.Name, method)
+				}
+			}
+		}
+		if limit := rule.SpendLimit; limit != nil {
+			if limit.Amount == nil {
+				return fmt.Errorf("transaction rule %q: spend limit without amount", rule.Name)
+			}
+			if limit.Window <= 0 {
+				return fmt.Errorf("transaction rule %q: spend limit without window", rule.Name)
+			}
+		}
+	}
+	for i, rule := range p.SignData.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("data rule %d: missing name", i)
+		}
+		if rule.Action == Manual {
+			return fmt.Errorf("data rule %q: rules must either approve or reject", rule.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package policy
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/common/hexutil"
+	"github.com/rajchain/go-rajchain/common/math"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/signer/core"
+	"github.com/rajchain/go-rajchain/signer/core/apitypes"
+	"github.com/rajchain/go-rajchain/signer/fourbyte"
+	"github.com/rajchain/go-rajchain/signer/storage"
+)
+
+var (
+	sender    = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	recipient = common.HexToAddress("0x2222222222222222222222222222222222222222")
+	token     = common.HexToAddress("0x3333333333333333333333333333333333333333")
+)
+
+const testPolicy = `
+transactions:
+  default: reject
+  rules:
+    - name: payroll
+      from: ["0x1111111111111111111111111111111111111111"]
+      to: ["0x2222222222222222222222222222222222222222"]
+      maxValue: 1000
+      maxGas: 21000
+      maxGasPrice: "0x64"
+      spendLimit:
+        amount: 1500
+        window: 24h
+    - name: token
+      contracts:
+        - address: "0x3333333333333333333333333333333333333333"
+          methods: ["transfer(address,uint256)"]
+signData:
+  rules:
+    - name: permit
+      contentTypes: ["data/typed"]
+      domains:
+        - name: Token
+          chainId: 1
+          verifyingContract: "0x3333333333333333333333333333333333333333"
+    - name: plain
+      action: reject
+      contentTypes: ["text/plain"]
+listing: approve
+`
+
+func newTestEngine(t *testing.T) *Engine {
+	t.Helper()
+
+	p, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	db, err := fourbyte.New()
+	if err != nil {
+		t.Fatalf("Failed to load 4byte database: %v", err)
+	}
+	return NewEngine(p, db, storage.NewEphemeralStorage())
+}
+
+func txRequest(to *common.Address, value, gas, gasPrice uint64, data []byte) *core.SignTxRequest {
+	tx := apitypes.SendTxArgs{
+		From:     common.NewMixedcaseAddress(sender),
+		Value:    hexutil.Big(*new(big.Int).SetUint64(value)),
+		Gas:      hexutil.Uint64(gas),
+		GasPrice: (*hexutil.Big)(new(big.Int).SetUint64(gasPrice)),
+	}
+	if to != nil {
+		addr := common.NewMixedcaseAddress(*to)
+		tx.To = &addr
+	}
+	if data != nil {
+		input := hexutil.Bytes(data)
+		tx.Input = &input
+	}
+	return &core.SignTxRequest{Transaction: tx}
+}
+
+func TestParse(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	rule := p.Transactions.Rules[0]
+	if (*big.Int)(rule.MaxValue).Uint64() != 1000 || (*big.Int)(rule.MaxGasPrice).Uint64() != 100 {
+		t.Errorf("Wrong ceilings: value %v, gas price %v", rule.MaxValue, rule.MaxGasPrice)
+	}
+	if rule.SpendLimit.Window != 24*time.Hour {
+		t.Errorf("Wrong spend window: %v", rule.SpendLimit.Window)
+	}
+	if p.Listing != Approve || p.Transactions.Default != Reject {
+		t.Errorf("Wrong actions: listing %q, default %q", p.Listing, p.Transactions.Default)
+	}
+	// Check that malformed policies are rejected
+	for i, blob := range []string{
+		"listing: maybe",
+		"transactions: {rules: [{to: ['0x2222222222222222222222222222222222222222']}]}",
+		"transactions: {rules: [{name: a, maxValue: 1}]}",
+		"transactions: {rules: [{name: a, contracts: [{address: '0x33', methods: [transfer]}]}]}",
+		"transactions: {rules: [{name: a, to: ['0x22'], spendLimit: {amount: 1}}]}",
+		"signData: {rules: [{name: a, action: manual}]}",
+	} {
+		if _, err := Parse([]byte(blob)); err == nil {
+			t.Errorf("policy %d: no error for %q", i, blob)
+		}
+	}
+}
+
+func TestCheckTx(t *testing.T) {
+	var (
+		engine   = newTestEngine(t)
+		now      = time.Now()
+		transfer = append(crypto.Keccak256([]byte("transfer(address,uint256)"))[:4], make([]byte, 64)...)
+		approve  = append(crypto.Keccak256([]byte("approve(address,uint256)"))[:4], make([]byte, 64)...)
+	)
+	tests := []struct {
+		req    *core.SignTxRequest
+		action Action
+		rule   string
+	}{
+		{txRequest(&recipient, 500, 21000, 100, nil), Approve, "payroll"},
+		{txRequest(&recipient, 1001, 21000, 100, nil), Reject, "payroll"},
+		{txRequest(&recipient, 500, 21001, 100, nil), Reject, "payroll"},
+		{txRequest(&recipient, 500, 21000, 101, nil), Reject, "payroll"},
+		{txRequest(&recipient, 500, 21000, 100, transfer), Reject, "payroll"},
+		{txRequest(&token, 0, 50000, 100, transfer), Approve, "token"},
+		{txRequest(&token, 0, 50000, 100, approve), Reject, "token"},
+		{txRequest(&token, 0, 50000, 100, transfer[:36]), Reject, "token"},
+		{txRequest(&token, 0, 50000, 100, nil), Reject, "token"},
+		{txRequest(&sender, 1, 21000, 100, nil), Reject, ""},
+		{txRequest(nil, 0, 100000, 100, []byte{0x60}), Manual, ""},
+	}
+	for i, tt := range tests {
+		res := engine.CheckTx(tt.req, now)
+		if res.Action != tt.action || res.Rule != tt.rule {
+			t.Errorf("test %d: have %v, want %s by %q", i, res, tt.action, tt.rule)
+		}
+	}
+}
+
+// Tests that contract creations are not approved by the default action, only
+// by rules explicitly allowing them.
+func TestCheckCreation(t *testing.T) {
+	p, err := Parse([]byte(`
+transactions:
+  default: approve
+  rules:
+    - name: deployer
+      from: ["0x2222222222222222222222222222222222222222"]
+      create: true
+      maxGas: 1000000
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	db, err := fourbyte.New()
+	if err != nil {
+		t.Fatalf("Failed to load 4byte database: %v", err)
+	}
+	engine := NewEngine(p, db, storage.NewEphemeralStorage())
+
+	// Plain transfers still fall back to the default
+	if res := engine.CheckTx(txRequest(&recipient, 1, 21000, 1, nil), time.Now()); res.Action != Approve {
+		t.Errorf("transfer: have %v, want %s", res, Approve)
+	}
+	// Creations by accounts without a rule go to manual processing
+	if res := engine.CheckTx(txRequest(nil, 0, 100000, 1, []byte{0x60}), time.Now()); res.Action != Manual {
+		t.Errorf("creation without rule: have %v, want %s", res, Manual)
+	}
+	// Creations allowed by a rule are subject to its ceilings
+	deploy := txRequest(nil, 0, 100000, 1, []byte{0x60})
+	deploy.Transaction.From = common.NewMixedcaseAddress(recipient)
+	if res := engine.CheckTx(deploy, time.Now()); res.Action != Approve || res.Rule != "deployer" {
+		t.Errorf("allowed creation: have %v, want %s by deployer", res, Approve)
+	}
+	deploy.Transaction.Gas = 1000001
+	if res := engine.CheckTx(deploy, time.Now()); res.Action != Reject || res.Rule != "deployer" {
+		t.Errorf("creation above gas ceiling: have %v, want %s by deployer", res, Reject)
+	}
+}
+
+func TestSpendLimit(t *testing.T) {
+	var (
+		engine = newTestEngine(t)
+		start  = time.Now()
+	)
+	steps := []struct {
+		offset time.Duration
+		value  uint64
+		action Action
+	}{
+		{0, 1000, Approve},
+		{time.Hour, 500, Approve},
+		{2 * time.Hour, 1, Reject}, // 1500 spent within the window
+		{24 * time.Hour, 1000, Approve},
+		{25 * time.Hour, 1000, Reject}, // 1000 spent at 24h
+		{49 * time.Hour, 1000, Approve},
+	}
+	for i, step := range steps {
+		res := engine.CheckTx(txRequest(&recipient, step.value, 21000, 1, nil), start.Add(step.offset))
+		if res.Action != step.action {
+			t.Errorf("step %d: have %v, want %s", i, res, step.action)
+		}
+	}
+}
+
+// Tests that approved values are only charged once the transaction is signed,
+// and refunded if it is not.
+func TestSpendLimitCommit(t *testing.T) {
+	var (
+		engine = newTestEngine(t)
+		store  = engine.storage
+		now    = time.Now()
+	)
+	request := func(nonce uint64, value uint64) *core.SignTxRequest {
+		req := txRequest(&recipient, value, 21000, 1, nil)
+		req.Transaction.Nonce = hexutil.Uint64(nonce)
+		return req
+	}
+	if res := engine.CheckTx(request(0, 1000), now); res.Action != Approve {
+		t.Fatalf("first transfer: have %v, want %s", res, Approve)
+	}
+	if res := engine.CheckTx(request(1, 500), now); res.Action != Approve {
+		t.Fatalf("second transfer: have %v, want %s", res, Approve)
+	}
+	// Pending transactions count against the limit until settled
+	if res := engine.CheckTx(request(2, 500), now); res.Action != Reject {
+		t.Fatalf("transfer above reserved limit: have %v, want %s", res, Reject)
+	}
+	if blob, _ := store.Get("spend/payroll"); blob != "" {
+		t.Fatalf("spending charged before signing: %s", blob)
+	}
+	// Refunding the second transfer frees its value, signing the first one
+	// charges it
+	engine.Release(sender, 1)
+	engine.Commit(sender, 0, now)
+	if blob, _ := store.Get("spend/payroll"); blob == "" {
+		t.Fatal("spending not charged after signing")
+	}
+	if res := engine.CheckTx(request(2, 500), now); res.Action != Approve {
+		t.Fatalf("transfer after refund: have %v, want %s", res, Approve)
+	}
+	if res := engine.CheckTx(request(3, 1), now); res.Action != Reject {
+		t.Fatalf("transfer above charged limit: have %v, want %s", res, Reject)
+	}
+}
+
+func TestCheckSignData(t *testing.T) {
+	engine := newTestEngine(t)
+
+	typed := func(name string, chainID int64, contract common.Address) *core.SignDataRequest {
+		return &core.SignDataRequest{
+			ContentType: "data/typed",
+			Address:     common.NewMixedcaseAddress(sender),
+			TypedData: &apitypes.TypedData{
+				Domain: apitypes.TypedDataDomain{
+					Name:              name,
+					ChainId:           math.NewHexOrDecimal256(chainID),
+					VerifyingContract: contract.Hex(),
+				},
+			},
+		}
+	}
+	tests := []struct {
+		req    *core.SignDataRequest
+		action Action
+	}{
+		{typed("Token", 1, token), Approve},
+		{typed("Token", 5, token), Manual},
+		{typed("Other", 1, token), Manual},
+		{typed("Token", 1, recipient), Manual},
+		{&core.SignDataRequest{ContentType: "data/typed"}, Manual},
+		{&core.SignDataRequest{ContentType: "text/plain"}, Reject},
+	}
+	for i, tt := range tests {
+		if res := engine.CheckSignData(tt.req); res.Action != tt.action {
+			t.Errorf("test %d: have %v, want %s", i, res, tt.action)
+		}
+	}
+	if res := engine.CheckListing(&core.ListRequest{}); res.Action != Approve {
+		t.Errorf("listing: have %v, want %s", res, Approve)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package policy
+
+import (
+	"time"
+
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/internal/ethapi"
+	"github.com/rajchain/go-rajchain/log"
+	"github.com/rajchain/go-rajchain/signer/core"
+)
+
+// policyUI is an implementation of UIClientAPI that decides on requests using
+// a policy engine, forwarding the ones requiring manual processing to the next
+// UI in line.
+type policyUI struct {
+	next   core.UIClientAPI // The next handler, for manual processing
+	engine *Engine
+}
+
+// NewUI creates a UI evaluating requests against the policy of the engine.
+func NewUI(next core.UIClientAPI, engine *Engine) core.UIClientAPI {
+	return &policyUI{next: next, engine: engine}
+}
+
+func (ui *policyUI) RegisterUIServer(api *core.UIServerAPI) {
+	ui.next.RegisterUIServer(api)
+}
+
+func (ui *policyUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
+	res := ui.engine.CheckTx(request, time.Now())
+	log.Info("Policy decision on transaction", "action", res.Action, "rule", res.Rule, "reason", res.Reason)
+
+	switch res.Action {
+	case Approve:
+		return core.SignTxResponse{Transaction: request.Transaction, Approved: true}, nil
+	case Reject:
+		return core.SignTxResponse{Approved: false}, nil
+	default:
+		return ui.next.ApproveTx(request)
+	}
+}
+
+func (ui *policyUI) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
+	res := ui.engine.CheckSignData(request)
+	log.Info("Policy decision on data signing", "action", res.Action, "rule", res.Rule, "reason", res.Reason)
+
+	switch res.Action {
+	case Approve:
+		return core.SignDataResponse{Approved: true}, nil
+	case Reject:
+		return core.SignDataResponse{Approved: false}, nil
+	default:
+		return ui.next.ApproveSignData(request)
+	}
+}
+
+func (ui *policyUI) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
+	res := ui.engine.CheckListing(request)
+	log.Info("Policy decision on listing", "action", res.Action, "reason", res.Reason)
+
+	switch res.Action {
+	case Approve:
+		return core.ListResponse{Accounts: request.Accounts}, nil
+	case Reject:
+		return core.ListResponse{}, nil
+	default:
+		return ui.next.ApproveListing(request)
+	}
+}
+
+// ApproveNewAccount is not handled by policies, it requires setting a password.
+func (ui *policyUI) ApproveNewAccount(request *core.NewAccountRequest) (core.NewAccountResponse, error) {
+	return ui.next.ApproveNewAccount(request)
+}
+
+// OnInputRequired is not handled by policies.
+func (ui *policyUI) OnInputRequired(info core.UserInputRequest) (core.UserInputResponse, error) {
+	return ui.next.OnInputRequired(info)
+}
+
+func (ui *policyUI) ShowError(message string) {
+	log.Error(message)
+	ui.next.ShowError(message)
+}
+
+func (ui *policyUI) ShowInfo(message string) {
+	log.Info(message)
+	ui.next.ShowInfo(message)
+}
+
+func (ui *policyUI) OnSignerStartup(info core.StartupInfo) {
+	ui.next.OnSignerStartup(info)
+}
+
+// OnApprovedTx charges the value of the signed transaction against the spend
+// limit of the rule having approved it.
+func (ui *policyUI) OnApprovedTx(tx ethapi.SignTransactionResult) {
+	if from, err := types.Sender(types.LatestSignerForChainID(tx.Tx.ChainId()), tx.Tx); err == nil {
+		ui.engine.Commit(from, tx.Tx.Nonce(), time.Now())
+	} else {
+		log.Warn("Failed to recover sender of signed transaction", "hash", tx.Tx.Hash(), "err", err)
+	}
+	ui.next.OnApprovedTx(tx)
+}
+
+// OnDeniedTx refunds the value reserved for a transaction approved by the policy
+// but denied further down the line, such as by a quorum of approvers.
+func (ui *policyUI) OnDeniedTx(request *core.SignTxRequest) {
+	ui.engine.Release(request.Transaction.From.Address(), uint64(request.Transaction.Nonce))
+}
@@ -0,0 +1,192 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/ethdb"
+	"github.com/rajchain/go-rajchain/trie/trienode"
+	"github.com/rajchain/go-rajchain/triedb/database"
+)
+
+// TransitionTrie is the tree used while the merkle state is being converted
+// into a verkle tree (EIP-7612). All the mutations are applied to the verkle
+// overlay tree, whose root is the state root of the block. Reads are served
+// from the overlay tree first, falling back to the read-only merkle tree
+// frozen at the fork for anything that hasn't been written or converted yet.
+//
+// Note, deletions never remove anything from a verkle tree, they overwrite the
+// leaves with zeroes instead. A leaf present in the overlay thus always shadows
+// the base tree, even when it has been cleared.
+type TransitionTrie struct {
+	overlay  *VerkleTrie
+	base     *StateTrie
+	baseRoot common.Hash
+	db       database.NodeDatabase         // Database holding the merkle base tree
+	storages map[common.Address]*StateTrie // Storage tries of the base tree, nil for empty ones
+}
+
+// NewTransitionTrie creates a transition tree on top of the merkle tree with
+// the given root and the verkle overlay tree.
+func NewTransitionTrie(overlay *VerkleTrie, baseRoot common.Hash, db database.NodeDatabase) (*TransitionTrie, error) {
+	base, err := NewStateTrie(StateTrieID(baseRoot), db)
+	if err != nil {
+		return nil, err
+	}
+	return &TransitionTrie{
+		overlay:  overlay,
+		base:     base,
+		baseRoot: baseRoot,
+		db:       db,
+		storages: make(map[common.Address]*StateTrie),
+	}, nil
+}
+
+// Overlay returns the verkle tree all the mutations are applied to.
+func (t *TransitionTrie) Overlay() *VerkleTrie {
+	return t.overlay
+}
+
+// Base returns the read-only merkle tree being converted.
+func (t *TransitionTrie) Base() *StateTrie {
+	return t.base
+}
+
+// BaseStorage returns the storage trie of an account in the merkle base tree,
+// or nil if the account doesn't have any storage there.
+func (t *TransitionTrie) BaseStorage(addr common.Address) (*StateTrie, error) {
+	if tr, ok := t.storages[addr]; ok {
+		return tr, nil
+	}
+	acc, err := t.base.GetAccount(addr)
+	if err != nil {
+		return nil, err
+	}
+	var tr *StateTrie
+	if acc != nil && acc.Root != types.EmptyRootHash {
+		tr, err = NewStateTrie(StorageTrieID(t.baseRoot, crypto.Keccak256Hash(addr.Bytes()), acc.Root), t.db)
+		if err != nil {
+			return nil, err
+		}
+	}
+	t.storages[addr] = tr
+	return tr, nil
+}
+
+// GetKey returns the sha3 preimage of a hashed key that was previously used
+// to store a value in the base tree.
+func (t *TransitionTrie) GetKey(key []byte) []byte {
+	return t.base.GetKey(key)
+}
+
+// GetAccount implements state.Trie, retrieving the account from the overlay
+// tree, or from the base tree if it's not yet present in the former.
+func (t *TransitionTrie) GetAccount(addr common.Address) (*types.StateAccount, error) {
+	acc, err := t.overlay.GetAccount(addr)
+	if err != nil || acc != nil {
+		return acc, err
+	}
+	return t.base.GetAccount(addr)
+}
+
+// GetStorage implements state.Trie, retrieving the storage slot from the
+// overlay tree, or from the base tree if it's not yet present in the former.
+func (t *TransitionTrie) GetStorage(addr common.Address, key []byte) ([]byte, error) {
+	val, err := t.overlay.GetStorage(addr, key)
+	if err != nil || val != nil {
+		return val, err
+	}
+	tr, err := t.BaseStorage(addr)
+	if err != nil || tr == nil {
+		return nil, err
+	}
+	return tr.GetStorage(addr, key)
+}
+
+// UpdateAccount implements state.Trie, writing the account into the overlay.
+func (t *TransitionTrie) UpdateAccount(addr common.Address, acc *types.StateAccount, codeLen int) error {
+	return t.overlay.UpdateAccount(addr, acc, codeLen)
+}
+
+// UpdateStorage implements state.Trie, writing the slot into the overlay.
+func (t *TransitionTrie) UpdateStorage(addr common.Address, key, value []byte) error {
+	return t.overlay.UpdateStorage(addr, key, value)
+}
+
+// DeleteAccount implements state.Trie, deleting the account from the overlay.
+func (t *TransitionTrie) DeleteAccount(addr common.Address) error {
+	return t.overlay.DeleteAccount(addr)
+}
+
+// DeleteStorage implements state.Trie, clearing the slot in the overlay.
+func (t *TransitionTrie) DeleteStorage(addr common.Address, key []byte) error {
+	return t.overlay.DeleteStorage(addr, key)
+}
+
+// UpdateContractCode implements state.Trie, writing the code chunks into the
+// overlay.
+func (t *TransitionTrie) UpdateContractCode(addr common.Address, codeHash common.Hash, code []byte) error {
+	return t.overlay.UpdateContractCode(addr, codeHash, code)
+}
+
+// Hash returns the root hash of the overlay tree, which is the state root
+// during the transition.
+func (t *TransitionTrie) Hash() common.Hash {
+	return t.overlay.Hash()
+}
+
+// Commit collects the dirty nodes of the overlay tree. The base tree is never
+// modified.
+func (t *TransitionTrie) Commit(collectLeaf bool) (common.Hash, *trienode.NodeSet) {
+	return t.overlay.Commit(collectLeaf)
+}
+
+// Witness returns a set containing all trie nodes that have been accessed.
+func (t *TransitionTrie) Witness() map[string]struct{} {
+	panic("not implemented")
+}
+
+// NodeIterator implements state.Trie, returning an iterator over the nodes of
+// the tree. Iterating a tree in transition is not supported.
+func (t *TransitionTrie) NodeIterator(startKey []byte) (NodeIterator, error) {
+	panic("not implemented")
+}
+
+// Prove implements state.Trie, constructing a proof for key. Proving a tree
+// in transition is not supported.
+func (t *TransitionTrie) Prove(key []byte, proofDb ethdb.KeyValueWriter) error {
+	panic("not implemented")
+}
+
+// IsVerkle indicates if the trie is a Verkle trie, which is true as the state
+// root of a tree in transition is the root of the verkle overlay.
+func (t *TransitionTrie) IsVerkle() bool {
+	return true
+}
+
+// Copy returns a deep-copied transition tree.
+func (t *TransitionTrie) Copy() *TransitionTrie {
+	return &TransitionTrie{
+		overlay:  t.overlay.Copy(),
+		base:     t.base.Copy(),
+		baseRoot: t.baseRoot,
+		db:       t.db,
+		storages: make(map[common.Address]*StateTrie),
+	}
+}
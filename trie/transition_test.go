@@ -0,0 +1,115 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/core/rawdb"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/crypto"
+	"github.com/rajchain/go-rajchain/rlp"
+	"github.com/rajchain/go-rajchain/trie/trienode"
+	"github.com/rajchain/go-rajchain/trie/utils"
+	"github.com/holiman/uint256"
+)
+
+func TestTransitionTrieReadWrite(t *testing.T) {
+	// Create the merkle base tree holding the accounts and their storage
+	var (
+		db     = newTestDatabase(rawdb.NewMemoryDatabase(), rawdb.HashScheme)
+		merged = trienode.NewMergedNodeSet()
+		base   = NewEmpty(db)
+	)
+	for addr, acct := range accounts {
+		st, _ := NewStateTrie(StorageTrieID(types.EmptyRootHash, crypto.Keccak256Hash(addr.Bytes()), types.EmptyRootHash), db)
+		for key, val := range storages[addr] {
+			st.UpdateStorage(addr, key.Bytes(), val)
+		}
+		root, nodes := st.Commit(false)
+		merged.Merge(nodes)
+
+		cpy := *acct
+		cpy.Root = root
+		blob, _ := rlp.EncodeToBytes(&cpy)
+		base.MustUpdate(crypto.Keccak256(addr.Bytes()), blob)
+	}
+	baseRoot, nodes := base.Commit(false)
+	merged.Merge(nodes)
+	db.Update(baseRoot, types.EmptyRootHash, merged)
+
+	overlay, _ := NewVerkleTrie(types.EmptyVerkleHash, newTestDatabase(rawdb.NewMemoryDatabase(), rawdb.PathScheme), utils.NewPointCache(100))
+	tr, err := NewTransitionTrie(overlay, baseRoot, db)
+	if err != nil {
+		t.Fatalf("Failed to create transition trie, %v", err)
+	}
+	// Everything is served from the base tree before being written
+	for addr, acct := range accounts {
+		stored, err := tr.GetAccount(addr)
+		if err != nil {
+			t.Fatalf("Failed to get account, %v", err)
+		}
+		if stored.Nonce != acct.Nonce || stored.Balance.Cmp(acct.Balance) != 0 {
+			t.Fatal("account is not matched")
+		}
+		for key, val := range storages[addr] {
+			stored, err := tr.GetStorage(addr, key.Bytes())
+			if err != nil {
+				t.Fatalf("Failed to get storage, %v", err)
+			}
+			if common.BytesToHash(stored) != common.BytesToHash(val) {
+				t.Fatal("storage is not matched")
+			}
+		}
+	}
+	// Writes land in the overlay and shadow the base tree, including deletions
+	var (
+		addr = common.Address{1}
+		acct = &types.StateAccount{Nonce: 101, Balance: uint256.NewInt(101), CodeHash: types.EmptyCodeHash.Bytes()}
+	)
+	if err := tr.UpdateAccount(addr, acct, 0); err != nil {
+		t.Fatalf("Failed to update account, %v", err)
+	}
+	if err := tr.UpdateStorage(addr, common.Hash{10}.Bytes(), []byte{0xa}); err != nil {
+		t.Fatalf("Failed to update storage, %v", err)
+	}
+	if err := tr.DeleteStorage(addr, common.Hash{11}.Bytes()); err != nil {
+		t.Fatalf("Failed to delete storage, %v", err)
+	}
+	stored, err := tr.GetAccount(addr)
+	if err != nil {
+		t.Fatalf("Failed to get account, %v", err)
+	}
+	if !reflect.DeepEqual(stored, acct) {
+		t.Fatal("account is not matched")
+	}
+	for key, want := range map[common.Hash][]byte{{10}: {0xa}, {11}: nil, common.MaxHash: {0xff}} {
+		stored, err := tr.GetStorage(addr, key.Bytes())
+		if err != nil {
+			t.Fatalf("Failed to get storage, %v", err)
+		}
+		if common.BytesToHash(stored) != common.BytesToHash(want) {
+			t.Fatalf("storage %x is not matched, have %x, want %x", key, stored, want)
+		}
+	}
+	// The state root is the root of the overlay
+	if tr.Hash() != overlay.Hash() {
+		t.Fatal("transition root is not the overlay root")
+	}
+}
@@ -97,7 +97,9 @@ func (t *VerkleTrie) GetAccount(addr common.Address) (*types.StateAccount, error
 	default:
 		return nil, errInvalidRootType
 	}
-	if values == nil {
+	// The stem of an account also holds its first storage slots, which may be
+	// present without the account itself while a merkle state is converted.
+	if values == nil || values[utils.BasicDataLeafKey] == nil {
 		return nil, nil
 	}
 	basicData := values[utils.BasicDataLeafKey]
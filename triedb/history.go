@@ -70,3 +70,15 @@ func (db *Database) HistoryRange() (uint64, uint64, error) {
 	}
 	return pdb.HistoryRange()
 }
+
+// HistoricState reconstructs the canonical state with the given root below the
+// persistent state, applying at most depth state histories in reverse.
+//
+// This function is only supported by path mode database.
+func (db *Database) HistoricState(root common.Hash, depth uint64) (*pathdb.HistoricState, error) {
+	pdb, ok := db.backend.(*pathdb.Database)
+	if !ok {
+		return nil, errors.New("not supported")
+	}
+	return pdb.HistoricState(root, depth)
+}
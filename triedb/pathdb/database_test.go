@@ -475,6 +475,65 @@ func TestDatabaseRecoverable(t *testing.T) {
 	}
 }
 
+func TestHistoricState(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	var (
+		tester = newTester(t, 0)
+		index  = tester.bottomIndex()
+	)
+	defer tester.release()
+
+	// States at or above the disk layer are live, not historic
+	if _, err := tester.db.HistoricState(tester.roots[index], 128); !errors.Is(err, errStateUnrecoverable) {
+		t.Fatalf("Unexpected error for disk layer, want %v, got %v", errStateUnrecoverable, err)
+	}
+	if _, err := tester.db.HistoricState(common.Hash{0x1}, 128); !errors.Is(err, errStateUnrecoverable) {
+		t.Fatalf("Unexpected error for unknown state, want %v, got %v", errStateUnrecoverable, err)
+	}
+	if _, err := tester.db.HistoricState(tester.roots[0], uint64(index-1)); !errors.Is(err, errHistoryTooDeep) {
+		t.Fatalf("Unexpected error for deep state, want %v, got %v", errHistoryTooDeep, err)
+	}
+	for i := 0; i < index; i++ {
+		root := tester.roots[i]
+		state, err := tester.db.HistoricState(root, uint64(index))
+		if err != nil {
+			t.Fatalf("Failed to reconstruct state %d, err: %v", i, err)
+		}
+		if state.Root() != root || state.AccountTrie().Hash() != root {
+			t.Fatalf("Unexpected state root, want %x, got %x", root, state.AccountTrie().Hash())
+		}
+		for addrHash, account := range tester.snapAccounts[root] {
+			blob, err := state.AccountTrie().Get(addrHash.Bytes())
+			if err != nil || !bytes.Equal(blob, account) {
+				t.Fatalf("Account %x is mismatched in state %d, err: %v", addrHash, i, err)
+			}
+		}
+		for addrHash, slots := range tester.snapStorages[root] {
+			tr, err := state.StorageTrie(addrHash)
+			if err != nil {
+				t.Fatalf("Failed to reconstruct storage of %x in state %d, err: %v", addrHash, i, err)
+			}
+			for hash, slot := range slots {
+				var blob []byte
+				if tr != nil {
+					blob, err = tr.Get(hash.Bytes())
+				}
+				if err != nil || !bytes.Equal(blob, slot) {
+					t.Fatalf("Slot %x of %x is mismatched in state %d, err: %v", hash, addrHash, i, err)
+				}
+			}
+		}
+		if state.Stale() {
+			t.Fatal("Historic state is unexpectedly stale")
+		}
+	}
+}
+
 func TestDisable(t *testing.T) {
 	// Redefine the diff layer depth allowance for faster testing.
 	maxDiffLayers = 4
@@ -39,4 +39,8 @@ var (
 	// errStateUnrecoverable is returned if state is required to be reverted to
 	// a destination without associated state history available.
 	errStateUnrecoverable = errors.New("state is unrecoverable")
+
+	// errHistoryTooDeep is returned if a historic state is requested, which is
+	// further below the disk layer than allowed.
+	errHistoryTooDeep = errors.New("state history too deep")
 )
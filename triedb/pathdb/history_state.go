@@ -0,0 +1,225 @@
+// Copyright 2024 The go-rajchain Authors
+// This file is part of the go-rajchain library.
+//
+// The go-rajchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-rajchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-rajchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rajchain/go-rajchain/common"
+	"github.com/rajchain/go-rajchain/core/rawdb"
+	"github.com/rajchain/go-rajchain/core/types"
+	"github.com/rajchain/go-rajchain/log"
+	"github.com/rajchain/go-rajchain/rlp"
+	"github.com/rajchain/go-rajchain/trie"
+)
+
+// HistoricState is a read-only view of a canonical state below the disk layer.
+// It is reconstructed in memory by applying the state histories in reverse on
+// top of the tries of the disk layer, without touching the persistent state.
+//
+// The view is bound to the disk layer it was built upon, and turns stale as
+// soon as the persistent state progresses. HistoricState is not thread-safe.
+type HistoricState struct {
+	db   *Database
+	base *diskLayer  // Disk layer the state histories are applied onto
+	root common.Hash // Root of the reconstructed state
+
+	accountTrie  *trie.Trie                             // Reconstructed account trie, never committed
+	storages     map[common.Hash]map[common.Hash][]byte // Original storage slots keyed by account hash and slot hash
+	storageRoots map[common.Hash]common.Hash            // Storage roots in the disk layer of the accounts with modified storage
+	storageTries map[common.Hash]*trie.Trie             // Reconstructed storage tries, nil for empty ones
+}
+
+// HistoricState reconstructs the state with the given root, which must be a
+// canonical state below the disk layer. At most depth state histories are
+// applied; older states are rejected.
+func (db *Database) HistoricState(root common.Hash, depth uint64) (*HistoricState, error) {
+	if db.freezer == nil {
+		return nil, errors.New("state history is not available")
+	}
+	root = types.TrieRootHash(root)
+	id := rawdb.ReadStateID(db.diskdb, root)
+	if id == nil {
+		return nil, fmt.Errorf("%w: unknown state %#x", errStateUnrecoverable, root)
+	}
+	dl := db.tree.bottom()
+	if *id >= dl.stateID() {
+		return nil, fmt.Errorf("%w: state %#x is not below the disk layer", errStateUnrecoverable, root)
+	}
+	if dl.stateID()-*id > depth {
+		return nil, fmt.Errorf("%w: %d histories, limit %d", errHistoryTooDeep, dl.stateID()-*id, depth)
+	}
+	// Collect the original values of all the states mutated above the target.
+	// The histories are read in ascending order, so the first value met for a
+	// key is the one it had in the target state.
+	var (
+		start    = time.Now()
+		accounts = make(map[common.Hash][]byte)
+		storages = make(map[common.Hash]map[common.Hash][]byte)
+		parent   = root
+		h        = newHasher()
+	)
+	defer h.release()
+
+	for i := *id + 1; i <= dl.stateID(); i++ {
+		hist, err := readHistory(db.freezer, i)
+		if err != nil {
+			return nil, err
+		}
+		if hist.meta.parent != parent {
+			return nil, fmt.Errorf("%w: id %d, parent %#x, want %#x", errUnexpectedHistory, i, hist.meta.parent, parent)
+		}
+		parent = hist.meta.root
+
+		for addr, blob := range hist.accounts {
+			addrHash := h.hash(addr.Bytes())
+			if _, ok := accounts[addrHash]; !ok {
+				accounts[addrHash] = blob
+			}
+			slots := hist.storages[addr]
+			if len(slots) == 0 {
+				continue
+			}
+			if storages[addrHash] == nil {
+				storages[addrHash] = make(map[common.Hash][]byte)
+			}
+			for slot, val := range slots {
+				if _, ok := storages[addrHash][slot]; !ok {
+					storages[addrHash][slot] = val
+				}
+			}
+		}
+	}
+	if parent != dl.rootHash() {
+		return nil, fmt.Errorf("%w: history root %#x, disk root %#x", errUnexpectedHistory, parent, dl.rootHash())
+	}
+	// Apply the original accounts onto the account trie of the disk layer
+	tr, err := trie.New(trie.TrieID(dl.rootHash()), db)
+	if err != nil {
+		return nil, err
+	}
+	storageRoots := make(map[common.Hash]common.Hash)
+	for addrHash, blob := range accounts {
+		if _, ok := storages[addrHash]; ok {
+			current, err := tr.Get(addrHash.Bytes())
+			if err != nil {
+				return nil, err
+			}
+			storageRoot := types.EmptyRootHash
+			if len(current) != 0 {
+				var acc types.StateAccount
+				if err := rlp.DecodeBytes(current, &acc); err != nil {
+					return nil, err
+				}
+				storageRoot = acc.Root
+			}
+			storageRoots[addrHash] = storageRoot
+		}
+		if len(blob) == 0 {
+			err = tr.Delete(addrHash.Bytes())
+		} else {
+			var full []byte
+			if full, err = types.FullAccountRLP(blob); err == nil {
+				err = tr.Update(addrHash.Bytes(), full)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if hash := tr.Hash(); hash != root {
+		return nil, fmt.Errorf("%w: reconstructed root %#x, want %#x", errUnexpectedHistory, hash, root)
+	}
+	log.Debug("Reconstructed historic state", "root", root, "histories", dl.stateID()-*id, "accounts", len(accounts), "elapsed", common.PrettyDuration(time.Since(start)))
+
+	return &HistoricState{
+		db:           db,
+		base:         dl,
+		root:         root,
+		accountTrie:  tr,
+		storages:     storages,
+		storageRoots: storageRoots,
+		storageTries: make(map[common.Hash]*trie.Trie),
+	}, nil
+}
+
+// Root returns the root of the reconstructed state.
+func (s *HistoricState) Root() common.Hash {
+	return s.root
+}
+
+// Stale returns whether the disk layer the state was reconstructed upon has
+// progressed, rendering the state unusable.
+func (s *HistoricState) Stale() bool {
+	return s.base.isStale()
+}
+
+// AccountTrie returns the reconstructed account trie, keyed by account hash.
+// The trie must not be mutated.
+func (s *HistoricState) AccountTrie() *trie.Trie {
+	return s.accountTrie
+}
+
+// StorageTrie returns the reconstructed storage trie of the account with the
+// given hash, or nil if the account doesn't exist or has no storage. The trie
+// must not be mutated.
+func (s *HistoricState) StorageTrie(addrHash common.Hash) (*trie.Trie, error) {
+	if tr, ok := s.storageTries[addrHash]; ok {
+		return tr, nil
+	}
+	blob, err := s.accountTrie.Get(addrHash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	var acc types.StateAccount
+	if len(blob) != 0 {
+		if err := rlp.DecodeBytes(blob, &acc); err != nil {
+			return nil, err
+		}
+	}
+	var tr *trie.Trie
+	if len(blob) != 0 && acc.Root != types.EmptyRootHash {
+		// Storage tries untouched since the target state are shared with
+		// the disk layer, others are rebuilt from their original slots.
+		slots, modified := s.storages[addrHash]
+		storageRoot := acc.Root
+		if modified {
+			storageRoot = s.storageRoots[addrHash]
+		}
+		tr, err = trie.New(trie.StorageTrieID(s.base.rootHash(), addrHash, storageRoot), s.db)
+		if err != nil {
+			return nil, err
+		}
+		for slot, val := range slots {
+			if len(val) == 0 {
+				err = tr.Delete(slot.Bytes())
+			} else {
+				err = tr.Update(slot.Bytes(), val)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		if hash := tr.Hash(); hash != acc.Root {
+			return nil, fmt.Errorf("%w: reconstructed storage root %#x of %#x, want %#x", errUnexpectedHistory, hash, addrHash, acc.Root)
+		}
+	}
+	s.storageTries[addrHash] = tr
+	return tr, nil
+}